@@ -0,0 +1,269 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// Abuse detection for the frontend's "too many attempts" and appeal flows.
+// Failed attempts are counted per signer address: txs rejected for a bad
+// signature, a wrong sequence or an unauthorized message count as auth
+// failures, proofs failing VERIFY_PROOFS as verification failures. Other
+// rejections, like a low fee or a missing record, are not counted.
+// ABUSE_MAX_FAILURES (default 5) within ABUSE_WINDOW (default 15m) blocks
+// the address for ABUSE_BAN_DURATION (default 15m), doubling with every
+// further block up to a day. Broadcasts from a blocked address are rejected
+// with code 4 before anything runs.
+//
+// State is public at /api/abuse/{address}, where a blocked address can file
+// an appeal; DELETE /admin/abuse/{address} lifts a block.
+
+const (
+	abuseAuth         = "auth"
+	abuseVerification = "verification"
+	maxBanDuration    = 24 * time.Hour
+)
+
+type abuseAppeal struct {
+	Status      string `json:"status"` // pending, approved
+	Reason      string `json:"reason"`
+	SubmittedAt int64  `json:"submitted_at"`
+	ResolvedAt  int64  `json:"resolved_at,omitempty"`
+}
+
+type abuseRecord struct {
+	Address      string
+	Failures     map[string][]time.Time
+	BlockedUntil time.Time
+	BanCount     int
+	Appeal       *abuseAppeal
+}
+
+type abuseTracker struct {
+	mu          sync.Mutex
+	maxFailures int
+	window      time.Duration
+	banDuration time.Duration
+	records     map[string]*abuseRecord
+}
+
+var abuse = newAbuseTrackerFromEnv()
+
+func newAbuseTrackerFromEnv() *abuseTracker {
+	return &abuseTracker{
+		maxFailures: intFromEnv("ABUSE_MAX_FAILURES", 5),
+		window:      durationFromEnv("ABUSE_WINDOW", 15*time.Minute),
+		banDuration: durationFromEnv("ABUSE_BAN_DURATION", 15*time.Minute),
+		records:     make(map[string]*abuseRecord),
+	}
+}
+
+func init() {
+	registerAdminState("abuse", func() interface{} {
+		abuse.mu.Lock()
+		defer abuse.mu.Unlock()
+		now := appClock.Now()
+		states := make([]map[string]interface{}, 0, len(abuse.records))
+		for _, address := range sortedMapKeys(abuse.records) {
+			states = append(states, abuse.stateLocked(address, now))
+		}
+		return states
+	}, func() {
+		abuse.mu.Lock()
+		defer abuse.mu.Unlock()
+		abuse.records = make(map[string]*abuseRecord)
+	})
+}
+
+// record returns the address's record, creating it if needed. Must be
+// called with t.mu held.
+func (t *abuseTracker) record(address string) *abuseRecord {
+	rec, ok := t.records[address]
+	if !ok {
+		rec = &abuseRecord{Address: address, Failures: make(map[string][]time.Time)}
+		t.records[address] = rec
+	}
+	return rec
+}
+
+// prune drops failures that fell out of the window. Must be called with
+// t.mu held.
+func (t *abuseTracker) prune(rec *abuseRecord, now time.Time) int {
+	total := 0
+	for kind, times := range rec.Failures {
+		kept := times[:0]
+		for _, at := range times {
+			if now.Sub(at) < t.window {
+				kept = append(kept, at)
+			}
+		}
+		rec.Failures[kind] = kept
+		total += len(kept)
+	}
+	return total
+}
+
+// recordFailure counts a failed attempt and blocks the address once it
+// reaches the limit.
+func (t *abuseTracker) recordFailure(address, kind string) {
+	if address == "" {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := appClock.Now()
+	rec := t.record(address)
+	rec.Failures[kind] = append(rec.Failures[kind], now)
+	if t.prune(rec, now) < t.maxFailures || now.Before(rec.BlockedUntil) {
+		return
+	}
+
+	ban := t.banDuration << rec.BanCount
+	if ban > maxBanDuration || ban <= 0 {
+		ban = maxBanDuration
+	}
+	rec.BanCount++
+	rec.BlockedUntil = now.Add(ban)
+	rec.Failures = make(map[string][]time.Time)
+	rec.Appeal = nil
+	log.Printf("Blocked %s for %s after %d failed attempts", address, ban, t.maxFailures)
+}
+
+// checkBlocked rejects txs from a blocked signer.
+func (t *abuseTracker) checkBlocked(address string) *txError {
+	if address == "" {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	rec, ok := t.records[address]
+	if !ok || !appClock.Now().Before(rec.BlockedUntil) {
+		return nil
+	}
+	return txErrorf(codeUnauthorized, "account %s is temporarily blocked after too many failed attempts; retry after %s",
+		address, rec.BlockedUntil.UTC().Format(time.RFC3339))
+}
+
+// stateLocked renders an address's abuse state. Must be called with t.mu
+// held.
+func (t *abuseTracker) stateLocked(address string, now time.Time) map[string]interface{} {
+	failures := map[string]int{abuseAuth: 0, abuseVerification: 0}
+	state := map[string]interface{}{
+		"address":        address,
+		"blocked":        false,
+		"failures":       failures,
+		"max_failures":   t.maxFailures,
+		"window_seconds": int64(t.window.Seconds()),
+		"ban_count":      0,
+	}
+	rec, ok := t.records[address]
+	if !ok {
+		return state
+	}
+	t.prune(rec, now)
+	for kind, times := range rec.Failures {
+		failures[kind] = len(times)
+	}
+	state["ban_count"] = rec.BanCount
+	if now.Before(rec.BlockedUntil) {
+		state["blocked"] = true
+		state["blocked_until"] = rec.BlockedUntil.Unix()
+		state["retry_after_seconds"] = int64(rec.BlockedUntil.Sub(now).Seconds() + 0.5)
+	}
+	if rec.Appeal != nil {
+		state["appeal"] = *rec.Appeal
+	}
+	return state
+}
+
+// Handler for GET /api/abuse/{address}
+func handleGetAbuseState(w http.ResponseWriter, r *http.Request) {
+	address := mux.Vars(r)["address"]
+
+	abuse.mu.Lock()
+	state := abuse.stateLocked(address, appClock.Now())
+	abuse.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	if seconds, ok := state["retry_after_seconds"].(int64); ok {
+		w.Header().Set("Retry-After", strconv.FormatInt(seconds, 10))
+	}
+	json.NewEncoder(w).Encode(state)
+}
+
+// Handler for POST /api/abuse/{address}/appeal
+func handleAppealBlock(w http.ResponseWriter, r *http.Request) {
+	address := mux.Vars(r)["address"]
+	var req struct {
+		Reason string `json:"reason"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	abuse.mu.Lock()
+	defer abuse.mu.Unlock()
+	now := appClock.Now()
+	rec, ok := abuse.records[address]
+	if !ok || !now.Before(rec.BlockedUntil) {
+		http.Error(w, "Address is not blocked", http.StatusConflict)
+		return
+	}
+	if rec.Appeal != nil && rec.Appeal.Status == "pending" {
+		http.Error(w, "An appeal is already pending", http.StatusConflict)
+		return
+	}
+	rec.Appeal = &abuseAppeal{Status: "pending", Reason: req.Reason, SubmittedAt: now.Unix()}
+	log.Printf("Appeal filed for %s", address)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(abuse.stateLocked(address, now))
+}
+
+// Handler for GET /admin/abuse
+func handleListAbuse(w http.ResponseWriter, r *http.Request) {
+	abuse.mu.Lock()
+	now := appClock.Now()
+	states := make([]map[string]interface{}, 0, len(abuse.records))
+	for _, address := range sortedMapKeys(abuse.records) {
+		states = append(states, abuse.stateLocked(address, now))
+	}
+	abuse.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"addresses": states})
+}
+
+// Handler for DELETE /admin/abuse/{address}, which lifts a block, clears
+// the failure counters and approves any pending appeal.
+func handleUnblockAddress(w http.ResponseWriter, r *http.Request) {
+	address := mux.Vars(r)["address"]
+
+	abuse.mu.Lock()
+	defer abuse.mu.Unlock()
+	rec, ok := abuse.records[address]
+	if !ok {
+		http.Error(w, "Address not tracked", http.StatusNotFound)
+		return
+	}
+	now := appClock.Now()
+	rec.BlockedUntil = time.Time{}
+	rec.Failures = make(map[string][]time.Time)
+	if rec.Appeal != nil && rec.Appeal.Status == "pending" {
+		rec.Appeal.Status = "approved"
+		rec.Appeal.ResolvedAt = now.Unix()
+	}
+	log.Printf("Unblocked %s", address)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(abuse.stateLocked(address, now))
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+)
+
+// IP and country access rules, so the frontend's compliance geo-blocking
+// UX can be reached deterministically. A request from a denylisted IP, or
+// from outside a non-empty allowlist, gets 403; a request from a blocked
+// country gets 451 Unavailable For Legal Reasons. Both carry a structured
+// error body.
+//
+// Rules come from IP_ALLOWLIST, IP_DENYLIST (comma-separated IPs or CIDRs)
+// and BLOCKED_COUNTRIES (comma-separated ISO codes), and are managed through
+// /admin/access. Tests can pose as any client with X-Test-Client-IP and
+// X-Test-Country. Admin routes, /health and the policy are never blocked.
+
+type accessRules struct {
+	Allowlist        []string `json:"ip_allowlist"`
+	Denylist         []string `json:"ip_denylist"`
+	BlockedCountries []string `json:"blocked_countries"`
+
+	allow []*net.IPNet
+	deny  []*net.IPNet
+}
+
+var (
+	accessMu sync.Mutex
+	access   = accessRulesFromEnv()
+)
+
+func accessRulesFromEnv() *accessRules {
+	rules := &accessRules{
+		Allowlist:        splitList(os.Getenv("IP_ALLOWLIST")),
+		Denylist:         splitList(os.Getenv("IP_DENYLIST")),
+		BlockedCountries: splitList(os.Getenv("BLOCKED_COUNTRIES")),
+	}
+	if err := rules.compile(); err != nil {
+		log.Printf("Invalid access rules, ignoring them: %v", err)
+		rules = &accessRules{}
+		rules.compile()
+	}
+	return rules
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// compile parses the IP lists and normalizes country codes.
+func (a *accessRules) compile() error {
+	for _, list := range []*[]string{&a.Allowlist, &a.Denylist, &a.BlockedCountries} {
+		if *list == nil {
+			*list = []string{}
+		}
+	}
+	var err error
+	if a.allow, err = parseNets(a.Allowlist); err != nil {
+		return err
+	}
+	if a.deny, err = parseNets(a.Denylist); err != nil {
+		return err
+	}
+	for i, country := range a.BlockedCountries {
+		if len(country) != 2 {
+			return fmt.Errorf("invalid country code: %q", country)
+		}
+		a.BlockedCountries[i] = strings.ToUpper(country)
+	}
+	return nil
+}
+
+// parseNets parses IPs and CIDRs; a bare IP matches only itself.
+func parseNets(values []string) ([]*net.IPNet, error) {
+	var nets []*net.IPNet
+	for _, value := range values {
+		if !strings.Contains(value, "/") {
+			ip := net.ParseIP(value)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid IP: %q", value)
+			}
+			bits := 128
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 32
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, ipNet, err := net.ParseCIDR(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR: %q", value)
+		}
+		nets = append(nets, ipNet)
+	}
+	return nets, nil
+}
+
+func containsIP(nets []*net.IPNet, ip net.IP) bool {
+	for _, n := range nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// clientIP returns the caller's IP: the test override, then the first
+// X-Forwarded-For entry, then the connection's address.
+func clientIP(r *http.Request) net.IP {
+	if override := r.Header.Get("X-Test-Client-IP"); override != "" {
+		return net.ParseIP(strings.TrimSpace(override))
+	}
+	if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+		return net.ParseIP(strings.TrimSpace(strings.Split(forwarded, ",")[0]))
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return net.ParseIP(host)
+}
+
+// clientCountry returns the caller's ISO country code, or "".
+func clientCountry(r *http.Request) string {
+	for _, header := range []string{"X-Test-Country", "CF-IPCountry", "X-Country-Code"} {
+		if country := r.Header.Get(header); country != "" {
+			return strings.ToUpper(strings.TrimSpace(country))
+		}
+	}
+	return ""
+}
+
+func writeAccessDenied(w http.ResponseWriter, status int, code, message string, details map[string]interface{}) {
+	body := map[string]interface{}{
+		"code":    code,
+		"message": message,
+	}
+	for key, value := range details {
+		body[key] = value
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{"error": body})
+}
+
+func accessMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The policy stays reachable, blocked pages link to it
+		if strings.HasPrefix(r.URL.Path, "/admin/") || r.URL.Path == "/health" || r.URL.Path == "/api/access/policy" || r.Method == "OPTIONS" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		accessMu.Lock()
+		rules := access
+		accessMu.Unlock()
+
+		ip := clientIP(r)
+		ipString := ""
+		if ip != nil {
+			ipString = ip.String()
+		}
+		if ip != nil && containsIP(rules.deny, ip) {
+			writeAccessDenied(w, http.StatusForbidden, "ip_denied", "Access from this IP address is not allowed", map[string]interface{}{"ip": ipString})
+			return
+		}
+		if len(rules.allow) > 0 && (ip == nil || !containsIP(rules.allow, ip)) {
+			writeAccessDenied(w, http.StatusForbidden, "ip_not_allowlisted", "This IP address is not on the allowlist", map[string]interface{}{"ip": ipString})
+			return
+		}
+		if country := clientCountry(r); country != "" && containsString(rules.BlockedCountries, country) {
+			// RFC 7725 asks for a link to the blocking entity
+			w.Header().Set("Link", `</api/access/policy>; rel="blocked-by"`)
+			writeAccessDenied(w, http.StatusUnavailableForLegalReasons, "geo_blocked", "This service is not available in your region", map[string]interface{}{
+				"country": country,
+				"policy":  "/api/access/policy",
+			})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Handler for GET /api/access/policy, describing the active restrictions
+// so a blocked page can explain itself.
+func handleAccessPolicy(w http.ResponseWriter, r *http.Request) {
+	accessMu.Lock()
+	countries := append([]string{}, access.BlockedCountries...)
+	accessMu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"blocked_countries": countries,
+		"your_country":      clientCountry(r),
+	})
+}
+
+// Handler for GET /admin/access
+func handleGetAccessRules(w http.ResponseWriter, r *http.Request) {
+	accessMu.Lock()
+	rules := access
+	accessMu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"rules": rules})
+}
+
+// Handler for PUT /admin/access, which replaces all rules
+func handlePutAccessRules(w http.ResponseWriter, r *http.Request) {
+	var rules accessRules
+	if err := json.NewDecoder(r.Body).Decode(&rules); err != nil {
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+	if err := rules.compile(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	accessMu.Lock()
+	access = &rules
+	accessMu.Unlock()
+
+	log.Printf("Access rules updated: %d allowed, %d denied, blocked countries %v", len(rules.Allowlist), len(rules.Denylist), rules.BlockedCountries)
+	handleGetAccessRules(w, r)
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Identity activity feed. Message handlers emit an activity event when a
+// DID is created, updated or deactivated, a credential is issued or
+// revoked, or a proof is submitted or verified. Once the tx commits the
+// events go out on the chain event bus as tm.event='Activity', and
+// GET /events streams them to the frontend as Server-Sent Events:
+//
+//	id: 12
+//	event: credential.issued
+//	data: {"id":12,"type":"credential.issued","address":"cosmos1...",...}
+//
+// ?types= takes a comma-separated list of types or type prefixes
+// (credential, or credential.*), ?address= and ?subject= narrow to one
+// account or one DID, credential or proof. Events are not replayed on
+// reconnect.
+
+const (
+	sseHeartbeat = 15 * time.Second
+	// maxRecentActivity bounds the events kept for /admin/debug/state
+	maxRecentActivity = 1000
+)
+
+type activityEvent struct {
+	ID   int64  `json:"id"`
+	Type string `json:"type"`
+	// Address is the account that caused the event
+	Address string `json:"address,omitempty"`
+	// Subject is the DID, credential or proof ID
+	Subject string          `json:"subject"`
+	TxHash  string          `json:"tx_hash,omitempty"`
+	Height  int64           `json:"height,omitempty"`
+	Time    int64           `json:"time"`
+	Data    json.RawMessage `json:"data,omitempty"`
+	// TraceID is the trace of the request that caused the event (trace.go)
+	TraceID string `json:"trace_id,omitempty"`
+}
+
+// emit records an activity event to publish when the tx commits. The
+// record is copied, as the stored one may change before then.
+func (ctx *msgContext) emit(kind, address, subject string, record map[string]interface{}) {
+	data, _ := json.Marshal(record)
+	ctx.Activity = append(ctx.Activity, activityEvent{
+		Type:    kind,
+		Address: address,
+		Subject: subject,
+		TxHash:  ctx.TxHash,
+		Height:  ctx.Height,
+		Data:    data,
+		TraceID: ctx.TraceID,
+	})
+}
+
+// publishActivity numbers activity events and publishes them on the
+// event bus.
+func (c *Chain) publishActivity(events []activityEvent) {
+	now := c.now().Unix()
+	for _, ev := range events {
+		ev.ID = atomic.AddInt64(&c.activitySeq, 1)
+		if ev.Time == 0 {
+			ev.Time = now
+		}
+		c.eventsMu.Lock()
+		c.recentActivity = append(c.recentActivity, ev)
+		if len(c.recentActivity) > maxRecentActivity {
+			c.recentActivity = c.recentActivity[len(c.recentActivity)-maxRecentActivity:]
+		}
+		if ev.Subject != "" {
+			c.activityBySubject[ev.Subject] = append(c.activityBySubject[ev.Subject], ev)
+		}
+		c.eventsMu.Unlock()
+		recordTrace(ev.TraceID, func(t *traceRecord) { t.Events = append(t.Events, ev) })
+		c.publishEvent(chainEvent{
+			Type: "Activity",
+			Attributes: map[string][]string{
+				"activity.type":     {ev.Type},
+				"activity.address":  {ev.Address},
+				"activity.subject":  {ev.Subject},
+				"activity.trace_id": {ev.TraceID},
+			},
+			Data: map[string]interface{}{
+				"type":  "persona/event/Activity",
+				"value": ev,
+			},
+		})
+	}
+}
+
+type activityFilter struct {
+	types   []string
+	address string
+	subject string
+}
+
+func (f activityFilter) matches(ev activityEvent) bool {
+	if f.address != "" && ev.Address != f.address {
+		return false
+	}
+	if f.subject != "" && ev.Subject != f.subject {
+		return false
+	}
+	if len(f.types) == 0 {
+		return true
+	}
+	for _, t := range f.types {
+		prefix := strings.TrimSuffix(t, "*")
+		if ev.Type == t || strings.HasPrefix(ev.Type, strings.TrimSuffix(prefix, ".")+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// Handler for GET /events
+func (c *Chain) handleActivityStream(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming not supported", http.StatusInternalServerError)
+		return
+	}
+	q := r.URL.Query()
+	filter := activityFilter{
+		types:   splitList(q.Get("types")),
+		address: q.Get("address"),
+		subject: q.Get("subject"),
+	}
+
+	events, unsubscribe := c.subscribeEvents()
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	// Stop proxies such as nginx from buffering the stream
+	w.Header().Set("X-Accel-Buffering", "no")
+	fmt.Fprint(w, "retry: 3000\n: connected\n\n")
+	flusher.Flush()
+	log.Printf("Activity stream client connected: %s", r.RemoteAddr)
+
+	heartbeat := time.NewTicker(sseHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			log.Printf("Activity stream client disconnected: %s", r.RemoteAddr)
+			return
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": ping\n\n")
+			flusher.Flush()
+		case ev, ok := <-events:
+			if !ok {
+				return
+			}
+			activity, isActivity := ev.Data["value"].(activityEvent)
+			if ev.Type != "Activity" || !isActivity || !filter.matches(activity) {
+				continue
+			}
+			data, _ := json.Marshal(activity)
+			fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", activity.ID, activity.Type, data)
+			flusher.Flush()
+		}
+	}
+}
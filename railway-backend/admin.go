@@ -0,0 +1,344 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gorilla/mux"
+)
+
+// Admin API for E2E suites: reset wipes all stored state, seed loads DIDs,
+// credentials and proofs (or a full dump) and dump exports everything. All
+// /admin routes require the ADMIN_TOKEN env var as a bearer token (or an
+// X-Admin-Token header) when it is set, and are open otherwise.
+//
+// Fault and chaos rules are configuration rather than state, so reset
+// leaves them in place. Block height keeps advancing across resets. The ops
+// console uses the versioned /admin/v1 API instead (adminv1.go).
+
+var adminToken = os.Getenv("ADMIN_TOKEN")
+
+// adminState is server-level state outside the chain (billing, dual-write
+// records) that takes part in reset and dump.
+type adminState struct {
+	name  string
+	dump  func() interface{}
+	reset func()
+}
+
+var (
+	adminStatesMu sync.Mutex
+	adminStates   []adminState
+)
+
+// registerAdminState adds server-level state to /admin/reset and
+// /admin/dump. Called from init functions.
+func registerAdminState(name string, dump func() interface{}, reset func()) {
+	adminStatesMu.Lock()
+	defer adminStatesMu.Unlock()
+	adminStates = append(adminStates, adminState{name: name, dump: dump, reset: reset})
+}
+
+// NewAdminRouter returns the /admin subrouter, guarded by ADMIN_TOKEN and
+// audited.
+func NewAdminRouter(r *mux.Router) *mux.Router {
+	admin := r.PathPrefix("/admin").Subrouter()
+	admin.Use(adminAuditMiddleware)
+	admin.Use(adminAuthMiddleware)
+	return admin
+}
+
+func adminAuthMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "OPTIONS" && !adminAuthorized(r) {
+			writeAdminTokenRequired(w)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// adminAuthorized reports whether a request carries the admin token, or
+// no token is configured.
+func adminAuthorized(r *http.Request) bool {
+	if adminToken == "" {
+		return true
+	}
+	token := r.Header.Get("X-Admin-Token")
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		token = strings.TrimPrefix(auth, "Bearer ")
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(adminToken)) == 1
+}
+
+func writeAdminTokenRequired(w http.ResponseWriter) {
+	w.Header().Set("WWW-Authenticate", `Bearer realm="admin"`)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusUnauthorized)
+	json.NewEncoder(w).Encode(map[string]interface{}{"error": "admin token required"})
+}
+
+// RegisterAdminRoutes mounts the chain's admin routes on the admin subrouter.
+func (c *Chain) RegisterAdminRoutes(admin *mux.Router) {
+	// Export stored state as a genesis fragment for the real chain
+	admin.HandleFunc("/genesis", c.handleGenesisExport).Methods("GET", "OPTIONS")
+
+	// Known-state management for E2E suites
+	admin.HandleFunc("/reset", c.handleAdminReset).Methods("POST", "OPTIONS")
+	admin.HandleFunc("/seed", c.handleAdminSeed).Methods("POST", "OPTIONS")
+	admin.HandleFunc("/dump", c.handleAdminDump).Methods("GET", "OPTIONS")
+	admin.HandleFunc("/snapshot", c.handleExportSnapshot).Methods("GET", "OPTIONS")
+	admin.HandleFunc("/snapshot", c.handleCreateCheckpoint).Methods("POST")
+	admin.HandleFunc("/snapshot/import", c.handleImportSnapshot).Methods("POST", "OPTIONS")
+	admin.HandleFunc("/snapshots", c.handleListCheckpoints).Methods("GET", "OPTIONS")
+	admin.HandleFunc("/snapshots/{id}", c.handleDeleteCheckpoint).Methods("DELETE", "OPTIONS")
+	admin.HandleFunc("/restore/{id}", c.handleRestoreCheckpoint).Methods("POST", "OPTIONS")
+	admin.HandleFunc("/scenarios/run", c.handleRunScenario).Methods("POST", "OPTIONS")
+	admin.HandleFunc("/demo", handleListDemos).Methods("GET", "OPTIONS")
+	admin.HandleFunc("/demo/{storyline}", handleGetDemo).Methods("GET", "OPTIONS")
+	admin.HandleFunc("/demo/{storyline}", c.handleStopDemo).Methods("DELETE")
+	admin.HandleFunc("/demo/{storyline}/start", c.handleStartDemo).Methods("POST", "OPTIONS")
+	admin.HandleFunc("/demo/{storyline}/advance", c.handleAdvanceDemo).Methods("POST", "OPTIONS")
+	admin.HandleFunc("/debug/state", c.handleDebugState).Methods("GET", "OPTIONS")
+
+	// Versioned admin API for the ops console
+	c.RegisterAdminV1Routes(admin)
+}
+
+// resetState wipes every module store and all stored txs.
+func (c *Chain) resetState() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, m := range c.modules {
+		m.Store().Reset()
+	}
+	c.txsByHash = make(map[string]*storedTx)
+	c.txSeq = 0
+	c.txsBySender = make(map[string][]string)
+	c.txsByAction = make(map[string][]string)
+	c.txsByHeight = make(map[int64][]string)
+	c.pendingTxs = nil
+
+	c.eventsMu.Lock()
+	c.recentActivity = nil
+	c.activityBySubject = make(map[string][]activityEvent)
+	c.eventsMu.Unlock()
+}
+
+// Handler for POST /admin/reset
+func (c *Chain) handleAdminReset(w http.ResponseWriter, r *http.Request) {
+	c.resetState()
+
+	adminStatesMu.Lock()
+	reset := []string{"chain"}
+	for _, state := range adminStates {
+		state.reset()
+		reset = append(reset, state.name)
+	}
+	adminStatesMu.Unlock()
+	log.Printf("Admin reset: %s", strings.Join(reset, ", "))
+
+	response := map[string]interface{}{"reset": reset}
+	if r.URL.Query().Get("fixtures") == "true" && fixturesDir != "" {
+		seeded, err := c.loadFixtures(fixturesDir)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		response["fixtures"] = seeded
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// Handler for POST /admin/seed
+//
+// The payload holds records per seed key, e.g.
+//
+//	{"accounts": [...], "dids": [...], "credentials": [...], "circuits": [...], "proofs": [...]}
+//
+// and/or "modules", a module store map as returned by /admin/dump. Seeding
+// adds to the current state; ?reset=true wipes it first. Either everything
+// is seeded or, on error, nothing is.
+func (c *Chain) handleAdminSeed(w http.ResponseWriter, r *http.Request) {
+	var payload map[string]json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	result, err := c.seed(payload, r.URL.Query().Get("reset") == "true")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	log.Printf("Admin seed: %v, module stores %v", result.Seeded, result.Modules)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+type seedResult struct {
+	// Seeded counts the records loaded per seed key
+	Seeded map[string]int `json:"seeded"`
+	// Modules lists the module stores loaded from "modules"
+	Modules []string `json:"modules"`
+}
+
+// seed loads a seed payload, optionally wiping the state first. Either
+// everything is seeded or, on error, nothing is.
+func (c *Chain) seed(payload map[string]json.RawMessage, reset bool) (seedResult, error) {
+	seeders := map[string]Seeder{}
+	for _, m := range c.modules {
+		if seeder, ok := m.(Seeder); ok {
+			for _, key := range seeder.SeedKeys() {
+				seeders[key] = seeder
+			}
+		}
+	}
+	records := map[string][]map[string]interface{}{}
+	var stores map[string]json.RawMessage
+	for key, raw := range payload {
+		if key == "modules" {
+			if err := json.Unmarshal(raw, &stores); err != nil {
+				return seedResult{}, fmt.Errorf("modules must map module names to stores")
+			}
+			continue
+		}
+		if seeders[key] == nil {
+			return seedResult{}, fmt.Errorf("unknown seed field: %s", key)
+		}
+		var list []map[string]interface{}
+		if err := json.Unmarshal(raw, &list); err != nil {
+			return seedResult{}, fmt.Errorf("%s must be an array of objects", key)
+		}
+		records[key] = list
+	}
+	for name := range stores {
+		if c.Module(name) == nil {
+			return seedResult{}, fmt.Errorf("unknown module: %s", name)
+		}
+	}
+
+	if reset {
+		c.resetState()
+	}
+
+	c.mu.Lock()
+	// Keep a copy, so a bad record leaves the state untouched
+	backup := c.backupStoresLocked()
+	err := c.seedLocked(stores, records)
+	if err != nil {
+		c.restoreStoresLocked(backup)
+	}
+	c.mu.Unlock()
+	if err != nil {
+		return seedResult{}, err
+	}
+
+	result := seedResult{Seeded: map[string]int{}, Modules: sortedMapKeys(stores)}
+	for key, list := range records {
+		result.Seeded[key] = len(list)
+	}
+	return result, nil
+}
+
+// seedLocked loads module stores and then seed records. Must be called
+// with c.mu held.
+func (c *Chain) seedLocked(stores map[string]json.RawMessage, records map[string][]map[string]interface{}) error {
+	for _, name := range sortedMapKeys(stores) {
+		if err := json.Unmarshal(stores[name], c.Module(name).Store()); err != nil {
+			return fmt.Errorf("module %s: %v", name, err)
+		}
+	}
+	for _, m := range c.modules {
+		seeder, ok := m.(Seeder)
+		if !ok {
+			continue
+		}
+		for _, key := range seeder.SeedKeys() {
+			if records[key] == nil {
+				continue
+			}
+			if err := seeder.Seed(key, records[key]); err != nil {
+				return fmt.Errorf("%s: %v", key, err)
+			}
+		}
+	}
+	return nil
+}
+
+// moduleStores returns every module store keyed by module name. Must be
+// called with c.mu held.
+func (c *Chain) moduleStores() map[string]interface{} {
+	stores := map[string]interface{}{}
+	for _, m := range c.modules {
+		stores[m.Name()] = m.Store()
+	}
+	return stores
+}
+
+// backupStoresLocked copies every module store. Must be called with c.mu
+// held.
+func (c *Chain) backupStoresLocked() []byte {
+	backup, _ := json.Marshal(c.moduleStores())
+	return backup
+}
+
+// restoreStoresLocked puts back stores copied by backupStoresLocked. Must be
+// called with c.mu held.
+func (c *Chain) restoreStoresLocked(backup []byte) {
+	var saved map[string]json.RawMessage
+	json.Unmarshal(backup, &saved)
+	for _, m := range c.modules {
+		m.Store().Reset()
+		json.Unmarshal(saved[m.Name()], m.Store())
+	}
+}
+
+// Handler for GET /admin/dump
+func (c *Chain) handleAdminDump(w http.ResponseWriter, r *http.Request) {
+	c.mu.RLock()
+	// Marshal under the lock, the stores are live
+	modules, err := json.Marshal(c.moduleStores())
+	txList := make([]*storedTx, 0, len(c.txsByHash))
+	for _, tx := range c.txsByHash {
+		txList = append(txList, tx)
+	}
+	sort.Slice(txList, func(i, j int) bool { return txList[i].Seq < txList[j].Seq })
+	txs := make([]map[string]interface{}, 0, len(txList))
+	for _, tx := range txList {
+		txs = append(txs, tx.txResponseJSON())
+	}
+	info := c.info
+	c.mu.RUnlock()
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"chain_id":      info.ChainID,
+		"latest_height": info.LatestHeight,
+		"modules":       json.RawMessage(modules),
+		"txs":           txs,
+	}
+	adminStatesMu.Lock()
+	for _, state := range adminStates {
+		response[state.name] = state.dump()
+	}
+	adminStatesMu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(response)
+}
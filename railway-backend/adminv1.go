@@ -0,0 +1,351 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"persona-backend/idgen"
+
+	"github.com/gorilla/mux"
+)
+
+// Versioned admin API for the ops console. /admin/v1 gathers the admin
+// surface an environment operator needs (reset, seed, state, snapshots,
+// faults, latency, quotas, rate limits, API keys, time, tenants and
+// feature flags) under one authenticated prefix with a stable shape: every
+// response is JSON, errors included ({"error", "status"}), and GET
+// /admin/v1 lists the resources and operations, generated from the same
+// table the routes are mounted from so the two cannot disagree. The
+// unversioned /admin routes stay for E2E suites.
+//
+// Every change made through /admin (anything but GET, HEAD and OPTIONS,
+// whether versioned or not, and including requests refused for a missing
+// token) goes to an audit log at GET /admin/v1/audit. The actor is the
+// X-Admin-Actor header, so a console can name its signed-in operator. The
+// last maxAdminAuditEntries entries are kept, and /admin/reset leaves them
+// in place since the log is how a reset is traced back to someone.
+
+const (
+	adminActorHeader     = "X-Admin-Actor"
+	maxAdminAuditEntries = 1000
+)
+
+type adminV1Route struct {
+	Resource string
+	Method   string
+	Path     string
+	// Legacy is the unversioned /admin route it stands for, if any
+	Legacy      string
+	Description string
+	handler     http.HandlerFunc
+}
+
+type adminAuditEntry struct {
+	ID     string `json:"id"`
+	Time   int64  `json:"time"`
+	Method string `json:"method"`
+	Path   string `json:"path"`
+	// Route is the matched route template, e.g. /admin/v1/faults/{id}
+	Route   string `json:"route"`
+	Status  int    `json:"status"`
+	Actor   string `json:"actor"`
+	IP      string `json:"ip,omitempty"`
+	TraceID string `json:"trace_id,omitempty"`
+}
+
+var (
+	adminAuditMu sync.Mutex
+	// Entries, oldest first
+	adminAuditLog []adminAuditEntry
+)
+
+var adminV1Resources = map[string]string{
+	"environment": "Reset, seed and inspect the whole environment",
+	"snapshots":   "Snapshot archives and in-memory snapshots to roll back to",
+	"faults":      "Broadcast fault injection rules",
+	"latency":     "Chaos rules and per-region latency profiles",
+	"quotas":      "Usage quota overrides and current usage",
+	"ratelimit":   "Per-IP and per-key rate limits",
+	"apikeys":     "API keys for protected routes",
+	"time":        "The app clock, for expiry testing",
+	"proxy":       "The record-and-replay proxy for unknown routes",
+	"recordings":  "Recorded traffic, exported as HAR",
+	"tenants":     "Isolated chains for parallel test shards",
+	"flags":       "Runtime feature flags",
+	"audit":       "Log of admin changes",
+}
+
+func (c *Chain) adminV1Routes() []adminV1Route {
+	return []adminV1Route{
+		{"environment", "POST", "/reset", "/reset", "Wipe all stored state", c.handleAdminReset},
+		{"environment", "POST", "/seed", "/seed", "Load DIDs, credentials and proofs, or a full state dump", c.handleAdminSeed},
+		{"environment", "GET", "/state", "/dump", "Dump all stored state", c.handleAdminDump},
+		{"snapshots", "GET", "/snapshot", "/snapshot", "Export a snapshot", c.handleExportSnapshot},
+		{"snapshots", "POST", "/snapshot/import", "/snapshot/import", "Import a snapshot archive", c.handleImportSnapshot},
+		{"snapshots", "POST", "/snapshot", "/snapshot", "Take an in-memory snapshot", c.handleCreateCheckpoint},
+		{"snapshots", "GET", "/snapshots", "/snapshots", "List in-memory snapshots", c.handleListCheckpoints},
+		{"snapshots", "DELETE", "/snapshots/{id}", "/snapshots/{id}", "Delete an in-memory snapshot", c.handleDeleteCheckpoint},
+		{"snapshots", "POST", "/restore/{id}", "/restore/{id}", "Roll the chain back to an in-memory snapshot", c.handleRestoreCheckpoint},
+		{"faults", "GET", "/faults", "/faults", "List fault rules", handleListFaults},
+		{"faults", "POST", "/faults", "/faults", "Add a fault rule", handleAddFault},
+		{"faults", "DELETE", "/faults", "/faults", "Delete every fault rule", handleDeleteFaults},
+		{"faults", "DELETE", "/faults/{id}", "/faults/{id}", "Delete a fault rule", handleDeleteFaults},
+		{"latency", "GET", "/latency/chaos", "/chaos", "List chaos rules", handleListChaos},
+		{"latency", "POST", "/latency/chaos", "/chaos", "Add a chaos rule", handleAddChaos},
+		{"latency", "DELETE", "/latency/chaos", "/chaos", "Delete every chaos rule", handleDeleteChaos},
+		{"latency", "DELETE", "/latency/chaos/{id}", "/chaos/{id}", "Delete a chaos rule", handleDeleteChaos},
+		{"latency", "GET", "/latency/regions", "/geo", "Show geo latency settings and region profiles", handleGetGeo},
+		{"latency", "POST", "/latency/regions", "/geo", "Configure geo latency", handleConfigureGeo},
+		{"latency", "PUT", "/latency/regions/{region}", "/geo/{region}", "Set a region profile", handlePutRegionProfile},
+		{"latency", "DELETE", "/latency/regions/{region}", "/geo/{region}", "Delete a region profile", handleDeleteRegionProfile},
+		{"quotas", "GET", "/quotas", "/quotas", "Show quota limits, overrides and usage", handleListQuotas},
+		{"quotas", "PUT", "/quotas", "/quotas", "Set or clear quota overrides", handleSetQuotas},
+		{"ratelimit", "GET", "/ratelimit", "/ratelimit", "Show rate limits", handleGetRateLimits},
+		{"ratelimit", "PUT", "/ratelimit", "/ratelimit", "Set rate limits", handleSetRateLimits},
+		{"apikeys", "GET", "/apikeys", "/apikeys", "List API keys", handleListAPIKeys},
+		{"apikeys", "POST", "/apikeys", "/apikeys", "Create an API key", handleCreateAPIKey},
+		{"apikeys", "DELETE", "/apikeys/{id}", "/apikeys/{id}", "Revoke an API key", handleRevokeAPIKey},
+		{"time", "GET", "/time", "/time", "Show the app clock", handleGetTime},
+		{"time", "POST", "/time", "/time", "Advance or set the app clock", handleSetTime},
+		{"time", "DELETE", "/time", "/time", "Put the app clock back on the wall clock", handleResetTime},
+		{"proxy", "GET", "/proxy", "/proxy", "Show the record-and-replay proxy", handleGetProxy},
+		{"recordings", "GET", "/recordings", "/recordings", "Export recorded traffic as HAR", handleGetRecordings},
+		{"recordings", "DELETE", "/recordings", "/recordings", "Drop recorded traffic", handleClearRecordings},
+		{"tenants", "GET", "/tenants", "/tenants", "List tenant chains", handleListTenants},
+		{"tenants", "POST", "/tenants", "/tenants", "Create a tenant chain", handleCreateTenant},
+		{"tenants", "GET", "/tenants/{id}", "/tenants/{id}", "Show a tenant chain", handleGetTenant},
+		{"tenants", "DELETE", "/tenants/{id}", "/tenants/{id}", "Delete a tenant chain", handleDeleteTenant},
+		{"tenants", "POST", "/tenants/{id}/reset", "/tenants/{id}/reset", "Wipe a tenant chain's state", handleResetTenant},
+		{"tenants", "POST", "/tenants/{id}/seed", "/tenants/{id}/seed", "Seed a tenant chain", handleSeedTenant},
+		{"tenants", "POST", "/tenants/{id}/snapshot", "/tenants/{id}/snapshot", "Take an in-memory snapshot of a tenant chain", handleSnapshotTenant},
+		{"tenants", "POST", "/tenants/{id}/restore/{snapshot}", "/tenants/{id}/restore/{snapshot}", "Roll a tenant chain back to a snapshot", handleRestoreTenant},
+		{"flags", "GET", "/flags", "", "List feature flags", handleListFeatureFlags},
+		{"flags", "PUT", "/flags/{name}", "", "Switch a feature flag on or off", handleSetFeatureFlag},
+		{"audit", "GET", "/audit", "", "List admin changes, newest first", handleListAdminAudit},
+	}
+}
+
+// RegisterAdminV1Routes mounts /admin/v1 on the admin subrouter.
+func (c *Chain) RegisterAdminV1Routes(admin *mux.Router) {
+	routes := c.adminV1Routes()
+	admin.HandleFunc("/v1", func(w http.ResponseWriter, r *http.Request) {
+		handleAdminV1Discovery(w, r, routes)
+	}).Methods("GET", "OPTIONS")
+
+	// The first route of each path also answers CORS preflight
+	preflight := make(map[string]bool)
+	for _, route := range routes {
+		methods := []string{route.Method}
+		if !preflight[route.Path] {
+			preflight[route.Path] = true
+			methods = append(methods, "OPTIONS")
+		}
+		admin.Handle("/v1"+route.Path, adminV1JSONErrors(route.handler)).Methods(methods...)
+
+		// Document the route like the one it stands for
+		key := route.Method + " /admin/v1" + route.Path
+		if _, ok := openAPIOperations[key]; !ok && route.Legacy != "" {
+			doc := openAPIOperations[route.Method+" /admin"+route.Legacy]
+			doc.Summary = route.Description
+			openAPIOperations[key] = doc
+		}
+	}
+}
+
+// Handler for GET /admin/v1
+func handleAdminV1Discovery(w http.ResponseWriter, r *http.Request, routes []adminV1Route) {
+	type operation struct {
+		Method      string `json:"method"`
+		Path        string `json:"path"`
+		Description string `json:"description"`
+		Replaces    string `json:"replaces,omitempty"`
+	}
+	var order []string
+	operations := make(map[string][]operation)
+	for _, route := range routes {
+		if operations[route.Resource] == nil {
+			order = append(order, route.Resource)
+		}
+		op := operation{Method: route.Method, Path: "/admin/v1" + route.Path, Description: route.Description}
+		if route.Legacy != "" {
+			op.Replaces = route.Method + " /admin" + route.Legacy
+		}
+		operations[route.Resource] = append(operations[route.Resource], op)
+	}
+	resources := []map[string]interface{}{}
+	for _, name := range order {
+		resources = append(resources, map[string]interface{}{
+			"name":        name,
+			"description": adminV1Resources[name],
+			"operations":  operations[name],
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"version": "v1",
+		"auth": map[string]interface{}{
+			"required":     adminToken != "",
+			"schemes":      []string{"Authorization: Bearer <ADMIN_TOKEN>", "X-Admin-Token: <ADMIN_TOKEN>"},
+			"actor_header": adminActorHeader,
+		},
+		"resources": resources,
+	})
+}
+
+// adminV1ErrorWriter turns the plain-text errors of the wrapped admin
+// handlers into {"error", "status"} bodies.
+type adminV1ErrorWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (ew *adminV1ErrorWriter) WriteHeader(status int) {
+	if ew.status != 0 {
+		return
+	}
+	ew.status = status
+	// A rewritten error is buffered by Write and sent by finish
+	if !ew.rewriting() {
+		ew.ResponseWriter.WriteHeader(status)
+	}
+}
+
+func (ew *adminV1ErrorWriter) Write(p []byte) (int, error) {
+	if ew.status == 0 {
+		ew.WriteHeader(http.StatusOK)
+	}
+	if ew.rewriting() {
+		return ew.body.Write(p)
+	}
+	return ew.ResponseWriter.Write(p)
+}
+
+func (ew *adminV1ErrorWriter) rewriting() bool {
+	return ew.status >= 400 && !strings.HasPrefix(ew.Header().Get("Content-Type"), "application/json")
+}
+
+func (ew *adminV1ErrorWriter) finish() {
+	if !ew.rewriting() {
+		return
+	}
+	ew.Header().Del("X-Content-Type-Options")
+	ew.Header().Set("Content-Type", "application/json")
+	ew.ResponseWriter.WriteHeader(ew.status)
+	json.NewEncoder(ew.ResponseWriter).Encode(map[string]interface{}{
+		"error":  strings.TrimSpace(ew.body.String()),
+		"status": ew.status,
+	})
+}
+
+func adminV1JSONErrors(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ew := &adminV1ErrorWriter{ResponseWriter: w}
+		next.ServeHTTP(ew, r)
+		ew.finish()
+	})
+}
+
+// adminAuditMiddleware records changes made through /admin. It runs before
+// adminAuthMiddleware so refused attempts are recorded too.
+func adminAuditMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" || r.Method == "HEAD" || r.Method == "OPTIONS" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		tw := &traceStatusWriter{ResponseWriter: w}
+		next.ServeHTTP(tw, r)
+		if tw.status == 0 {
+			tw.status = http.StatusOK
+		}
+
+		entry := adminAuditEntry{
+			ID:      idgen.NewWithPrefix("audit"),
+			Time:    appClock.Now().Unix(),
+			Method:  r.Method,
+			Path:    r.URL.Path,
+			Route:   r.URL.Path,
+			Status:  tw.status,
+			Actor:   adminActor(r, tw.status),
+			TraceID: requestTraceID(r),
+		}
+		if route := mux.CurrentRoute(r); route != nil {
+			if template, err := route.GetPathTemplate(); err == nil {
+				entry.Route = template
+			}
+		}
+		if ip := clientIP(r); ip != nil {
+			entry.IP = ip.String()
+		}
+		adminAuditMu.Lock()
+		adminAuditLog = append(adminAuditLog, entry)
+		if len(adminAuditLog) > maxAdminAuditEntries {
+			adminAuditLog = adminAuditLog[len(adminAuditLog)-maxAdminAuditEntries:]
+		}
+		adminAuditMu.Unlock()
+	})
+}
+
+// adminActor names who made an admin request: the X-Admin-Actor header,
+// else "token" for a request that got past the admin token and
+// "anonymous" otherwise.
+func adminActor(r *http.Request, status int) string {
+	if actor := strings.TrimSpace(r.Header.Get(adminActorHeader)); actor != "" {
+		if len(actor) > 128 {
+			actor = actor[:128]
+		}
+		return actor
+	}
+	if adminToken != "" && status != http.StatusUnauthorized {
+		return "token"
+	}
+	return "anonymous"
+}
+
+// Handler for GET /admin/v1/audit. Filters: actor, method, route (prefix
+// of the path), since (unix seconds) and limit (default 100).
+func handleListAdminAudit(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	limit := 100
+	if raw := query.Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 {
+			http.Error(w, "limit must be a positive integer", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+	var since int64
+	if raw := query.Get("since"); raw != "" {
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			http.Error(w, "since must be a unix timestamp", http.StatusBadRequest)
+			return
+		}
+		since = n
+	}
+
+	adminAuditMu.Lock()
+	entries := []adminAuditEntry{}
+	for i := len(adminAuditLog) - 1; i >= 0 && len(entries) < limit; i-- {
+		entry := adminAuditLog[i]
+		if entry.Time < since ||
+			(query.Get("actor") != "" && entry.Actor != query.Get("actor")) ||
+			(query.Get("method") != "" && !strings.EqualFold(entry.Method, query.Get("method"))) ||
+			(query.Get("route") != "" && !strings.HasPrefix(entry.Path, query.Get("route"))) {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	total := len(adminAuditLog)
+	adminAuditMu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"entries": entries, "total": total})
+}
@@ -0,0 +1,429 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"persona-backend/idgen"
+)
+
+// Analytics sink for the frontend's dashboard. Every activity event (see
+// activity.go) becomes an analytics event
+//
+//	{"id", "type", "actor", "subject", "timestamp", "tx_hash", "properties": {...record}}
+//
+// which runs through the anonymization pipeline before it is stored, so
+// the sink at GET /api/analytics/events never holds raw personal data. The
+// pipeline is an ordered list of steps:
+//
+//	hash_dids          replaces every DID with did:hash:<HMAC-SHA256> under
+//	                   ANALYTICS_SALT, and hashes fields (default the
+//	                   account addresses: actor and the controller,
+//	                   creator and prover properties)
+//	bucket_timestamps  truncates fields to the granularity (minute, hour
+//	                   or day; default hour); by default timestamp and every
+//	                   property ending in _at or Date
+//	drop_claims        removes every credentialSubject claim but its id
+//	drop_fields        removes fields
+//
+// Fields are dotted paths such as properties.credentialSubject. The
+// default pipeline hashes DIDs, buckets by the hour and drops claims.
+// ANALYTICS_PIPELINE (a JSON array of steps) replaces it at startup, PUT
+// /admin/analytics/pipeline at runtime, and GET /api/analytics/pipeline
+// shows the one in effect for privacy reviews; POST
+// /api/analytics/pipeline/preview runs it on a sample event. /admin/reset
+// restores the startup pipeline and empties the sink.
+
+const maxAnalyticsEvents = 1000
+
+type analyticsStep struct {
+	Type        string   `json:"type"`
+	Fields      []string `json:"fields,omitempty"`
+	Granularity string   `json:"granularity,omitempty"`
+}
+
+var analyticsGranularities = map[string]time.Duration{
+	"minute": time.Minute,
+	"hour":   time.Hour,
+	"day":    24 * time.Hour,
+}
+
+var defaultAnalyticsPipeline = []analyticsStep{
+	{Type: "hash_dids"},
+	{Type: "bucket_timestamps", Granularity: "hour"},
+	{Type: "drop_claims"},
+}
+
+// analyticsAccountFields hold account addresses, which hash_dids hashes by
+// default.
+var analyticsAccountFields = []string{"actor", "properties.controller", "properties.creator", "properties.prover"}
+
+// analyticsSalt keys DID hashes. The default is public, so set
+// ANALYTICS_SALT wherever hashes must not be reversible by dictionary.
+var analyticsSalt = os.Getenv("ANALYTICS_SALT")
+
+var (
+	analyticsMu sync.Mutex
+	// analyticsPipeline is the pipeline in effect
+	analyticsPipeline []analyticsStep
+	analyticsUpdated  int64
+	// Anonymized events, oldest first
+	analyticsEvents          []map[string]interface{}
+	startupAnalyticsPipeline = defaultAnalyticsPipeline
+)
+
+func init() {
+	if raw := os.Getenv("ANALYTICS_PIPELINE"); raw != "" {
+		var steps []analyticsStep
+		err := json.Unmarshal([]byte(raw), &steps)
+		if err == nil {
+			err = validateAnalyticsPipeline(steps)
+		}
+		if err != nil {
+			log.Printf("Invalid ANALYTICS_PIPELINE: %v", err)
+		} else {
+			startupAnalyticsPipeline = steps
+		}
+	}
+	resetAnalytics()
+	registerAdminState("analytics", func() interface{} {
+		analyticsMu.Lock()
+		defer analyticsMu.Unlock()
+		return map[string]interface{}{"pipeline": analyticsPipeline, "events": len(analyticsEvents)}
+	}, resetAnalytics)
+}
+
+func resetAnalytics() {
+	analyticsMu.Lock()
+	defer analyticsMu.Unlock()
+	analyticsPipeline = startupAnalyticsPipeline
+	analyticsUpdated = appClock.Now().Unix()
+	analyticsEvents = nil
+}
+
+func validateAnalyticsPipeline(steps []analyticsStep) error {
+	for i, step := range steps {
+		switch step.Type {
+		case "hash_dids", "drop_claims":
+		case "bucket_timestamps":
+			if _, ok := analyticsGranularities[step.Granularity]; step.Granularity != "" && !ok {
+				return fmt.Errorf("steps[%d]: granularity must be minute, hour or day", i)
+			}
+		case "drop_fields":
+			if len(step.Fields) == 0 {
+				return fmt.Errorf("steps[%d]: drop_fields needs fields", i)
+			}
+		default:
+			return fmt.Errorf("steps[%d]: unknown step type %q", i, step.Type)
+		}
+	}
+	return nil
+}
+
+// anonymizeAnalyticsEvent runs an event through the pipeline. The event is
+// modified in place.
+func anonymizeAnalyticsEvent(steps []analyticsStep, event map[string]interface{}) map[string]interface{} {
+	for _, step := range steps {
+		switch step.Type {
+		case "hash_dids":
+			fields := step.Fields
+			if fields == nil {
+				fields = analyticsAccountFields
+			}
+			for _, field := range fields {
+				updateAnalyticsField(event, field, func(value interface{}) interface{} {
+					if s, ok := value.(string); ok && s != "" && !strings.HasPrefix(s, "did:") {
+						return analyticsHash(s)
+					}
+					return value
+				})
+			}
+			event = hashDIDs(event).(map[string]interface{})
+		case "bucket_timestamps":
+			granularity := analyticsGranularities[step.Granularity]
+			if granularity == 0 {
+				granularity = time.Hour
+			}
+			fields := step.Fields
+			if fields == nil {
+				fields = []string{"timestamp"}
+				if properties, ok := event["properties"].(map[string]interface{}); ok {
+					for key := range properties {
+						if strings.HasSuffix(key, "_at") || strings.HasSuffix(key, "Date") {
+							fields = append(fields, "properties."+key)
+						}
+					}
+				}
+			}
+			for _, field := range fields {
+				updateAnalyticsField(event, field, func(value interface{}) interface{} {
+					return bucketTimestamp(value, granularity)
+				})
+			}
+		case "drop_claims":
+			dropClaims(event)
+		case "drop_fields":
+			for _, field := range step.Fields {
+				path := strings.Split(field, ".")
+				if parent, ok := analyticsParent(event, path); ok {
+					delete(parent, path[len(path)-1])
+				}
+			}
+		}
+	}
+	return event
+}
+
+// analyticsParent returns the object holding the last element of path.
+func analyticsParent(event map[string]interface{}, path []string) (map[string]interface{}, bool) {
+	current := event
+	for _, key := range path[:len(path)-1] {
+		next, ok := current[key].(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		current = next
+	}
+	return current, true
+}
+
+func updateAnalyticsField(event map[string]interface{}, field string, update func(interface{}) interface{}) {
+	path := strings.Split(field, ".")
+	parent, ok := analyticsParent(event, path)
+	if !ok {
+		return
+	}
+	key := path[len(path)-1]
+	if value, exists := parent[key]; exists {
+		parent[key] = update(value)
+	}
+}
+
+func analyticsHash(value string) string {
+	salt := analyticsSalt
+	if salt == "" {
+		salt = "persona-analytics"
+	}
+	mac := hmac.New(sha256.New, []byte(salt))
+	mac.Write([]byte(value))
+	return hex.EncodeToString(mac.Sum(nil))[:32]
+}
+
+// hashDIDs replaces every DID in value, including those inside strings
+// such as DID URLs.
+func hashDIDs(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			v[key] = hashDIDs(field)
+		}
+		return v
+	case []interface{}:
+		for i, item := range v {
+			v[i] = hashDIDs(item)
+		}
+		return v
+	case string:
+		if !strings.HasPrefix(v, "did:") || strings.HasPrefix(v, "did:hash:") {
+			return v
+		}
+		did, rest := v, ""
+		if i := strings.IndexAny(v, "#?/"); i >= 0 {
+			did, rest = v[:i], v[i:]
+		}
+		return "did:hash:" + analyticsHash(did) + rest
+	}
+	return value
+}
+
+// bucketTimestamp truncates Unix seconds or an RFC 3339 time.
+func bucketTimestamp(value interface{}, granularity time.Duration) interface{} {
+	switch v := value.(type) {
+	case float64:
+		return float64(time.Unix(int64(v), 0).Truncate(granularity).Unix())
+	case int64:
+		return time.Unix(v, 0).Truncate(granularity).Unix()
+	case string:
+		if t, err := time.Parse(time.RFC3339, v); err == nil {
+			return t.UTC().Truncate(granularity).Format(time.RFC3339)
+		}
+		if seconds, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return strconv.FormatInt(time.Unix(seconds, 0).Truncate(granularity).Unix(), 10)
+		}
+	}
+	return value
+}
+
+// dropClaims removes the claims of every credentialSubject in value.
+func dropClaims(value interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			if key != "credentialSubject" {
+				dropClaims(field)
+				continue
+			}
+			subjects, ok := field.([]interface{})
+			if !ok {
+				subjects = []interface{}{field}
+			}
+			for _, subject := range subjects {
+				if claims, ok := subject.(map[string]interface{}); ok {
+					for claim := range claims {
+						if claim != "id" {
+							delete(claims, claim)
+						}
+					}
+				}
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			dropClaims(item)
+		}
+	}
+}
+
+// analyticsEventOf converts an activity event to an analytics event.
+func analyticsEventOf(activity activityEvent) map[string]interface{} {
+	event := map[string]interface{}{
+		"id":        idgen.NewWithPrefix("ana"),
+		"type":      activity.Type,
+		"actor":     activity.Address,
+		"subject":   activity.Subject,
+		"timestamp": float64(activity.Time),
+	}
+	if activity.TxHash != "" {
+		event["tx_hash"] = activity.TxHash
+	}
+	var properties map[string]interface{}
+	if json.Unmarshal(activity.Data, &properties) == nil && properties != nil {
+		event["properties"] = properties
+	}
+	return event
+}
+
+// startAnalyticsSink anonymizes c's activity events into the sink in the
+// background.
+func (c *Chain) startAnalyticsSink() {
+	events, _ := c.subscribeEvents()
+	go func() {
+		for ev := range events {
+			activity, ok := ev.Data["value"].(activityEvent)
+			if !ok || ev.Type != "Activity" {
+				continue
+			}
+			analyticsMu.Lock()
+			event := anonymizeAnalyticsEvent(analyticsPipeline, analyticsEventOf(activity))
+			analyticsEvents = append(analyticsEvents, event)
+			if len(analyticsEvents) > maxAnalyticsEvents {
+				analyticsEvents = analyticsEvents[len(analyticsEvents)-maxAnalyticsEvents:]
+			}
+			analyticsMu.Unlock()
+		}
+	}()
+}
+
+func writeAnalyticsPipeline(w http.ResponseWriter) {
+	analyticsMu.Lock()
+	response := map[string]interface{}{
+		"steps":      analyticsPipeline,
+		"salted":     analyticsSalt != "",
+		"updated_at": analyticsUpdated,
+	}
+	analyticsMu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// Handler for GET /api/analytics/pipeline
+func handleGetAnalyticsPipeline(w http.ResponseWriter, r *http.Request) {
+	writeAnalyticsPipeline(w)
+}
+
+// Handler for PUT /admin/analytics/pipeline. Replaces the steps; events
+// already in the sink are not reprocessed.
+func handleSetAnalyticsPipeline(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Steps []analyticsStep `json:"steps"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+	if req.Steps == nil {
+		req.Steps = []analyticsStep{}
+	}
+	if err := validateAnalyticsPipeline(req.Steps); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	analyticsMu.Lock()
+	analyticsPipeline = req.Steps
+	analyticsUpdated = appClock.Now().Unix()
+	analyticsMu.Unlock()
+
+	log.Printf("Analytics pipeline set to %d steps", len(req.Steps))
+	writeAnalyticsPipeline(w)
+}
+
+// Handler for POST /api/analytics/pipeline/preview. Runs the pipeline on
+// the event in the body without storing it.
+func handlePreviewAnalyticsPipeline(w http.ResponseWriter, r *http.Request) {
+	var event map[string]interface{}
+	if err := json.NewDecoder(r.Body).Decode(&event); err != nil || event == nil {
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+	input, _ := json.Marshal(event)
+
+	analyticsMu.Lock()
+	output := anonymizeAnalyticsEvent(analyticsPipeline, event)
+	analyticsMu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"input":  json.RawMessage(input),
+		"output": output,
+	})
+}
+
+// Handler for GET /api/analytics/events. Lists the sink newest first,
+// optionally of one ?type, up to ?limit (default 100).
+func handleListAnalyticsEvents(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	eventType := query.Get("type")
+	limit := 100
+	if raw := query.Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > maxAnalyticsEvents {
+			http.Error(w, fmt.Sprintf("limit must be between 1 and %d", maxAnalyticsEvents), http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	analyticsMu.Lock()
+	events := []map[string]interface{}{}
+	for i := len(analyticsEvents) - 1; i >= 0 && len(events) < limit; i-- {
+		if eventType == "" || analyticsEvents[i]["type"] == eventType {
+			events = append(events, analyticsEvents[i])
+		}
+	}
+	total := len(analyticsEvents)
+	analyticsMu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"events": events, "total": total})
+}
@@ -0,0 +1,279 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	"persona-backend/idgen"
+
+	"github.com/gorilla/mux"
+)
+
+// API keys for the partner-facing /api endpoints (/api/getRequirements and
+// /api/getVc so far), passed in the X-API-Key header. Each key has scopes (apiKeyScopes, or "*" for all) and counts
+// its requests per endpoint. With API_KEY_AUTH=true a partner endpoint
+// needs a key with its scope; otherwise keys stay optional, but a key that
+// is sent must be valid and in scope, so integrations can be tested before
+// enforcement is switched on.
+//
+// Keys are created through POST /admin/apikeys, which returns the secret
+// once; only its SHA-256 is kept. API_KEYS (a JSON array of {name, key,
+// scopes}) adds keys with known secrets at startup, for CI. /admin/reset
+// restores the startup keys with zeroed counters.
+
+const apiKeyPrefix = "pk_test_"
+
+var apiKeyScopes = []string{"requirements:read", "credentials:read"}
+
+type apiKey struct {
+	ID     string   `json:"id"`
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+	// Hint is the start of the secret, to tell keys apart
+	Hint       string         `json:"hint"`
+	Revoked    bool           `json:"revoked"`
+	CreatedAt  int64          `json:"created_at"`
+	LastUsedAt int64          `json:"last_used_at,omitempty"`
+	Requests   int            `json:"requests"`
+	Rejected   int            `json:"rejected"`
+	ByEndpoint map[string]int `json:"by_endpoint"`
+
+	hash string
+}
+
+type startupAPIKey struct {
+	Name   string   `json:"name"`
+	Key    string   `json:"key"`
+	Scopes []string `json:"scopes"`
+}
+
+var (
+	apiKeyAuth = newFeatureFlag("api_key_auth", "API_KEY_AUTH", "Require an API key or bearer token on protected routes")
+
+	apiKeysMu sync.Mutex
+	// Keys by ID
+	apiKeys        = make(map[string]*apiKey)
+	startupAPIKeys []startupAPIKey
+)
+
+func init() {
+	if raw := os.Getenv("API_KEYS"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &startupAPIKeys); err != nil {
+			log.Printf("Invalid API_KEYS: %v", err)
+			startupAPIKeys = nil
+		}
+	}
+	resetAPIKeys()
+	registerAdminState("apikeys", func() interface{} {
+		apiKeysMu.Lock()
+		defer apiKeysMu.Unlock()
+		return map[string]interface{}{"enforced": apiKeyAuth.Enabled(), "keys": apiKeyList()}
+	}, resetAPIKeys)
+}
+
+func resetAPIKeys() {
+	apiKeysMu.Lock()
+	defer apiKeysMu.Unlock()
+	apiKeys = make(map[string]*apiKey)
+	for _, k := range startupAPIKeys {
+		if k.Key == "" {
+			log.Printf("Skipping API key %q without a key", k.Name)
+			continue
+		}
+		if _, err := addAPIKey(k.Name, k.Key, k.Scopes); err != nil {
+			log.Printf("Skipping API key %q: %v", k.Name, err)
+		}
+	}
+}
+
+func hashAPIKey(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// addAPIKey validates the scopes and stores a key. Must be called with
+// apiKeysMu held.
+func addAPIKey(name, secret string, scopes []string) (*apiKey, error) {
+	if len(scopes) == 0 {
+		return nil, fmt.Errorf("scopes are required, any of %s or *", strings.Join(apiKeyScopes, ", "))
+	}
+	for _, scope := range scopes {
+		if scope != "*" && !containsString(apiKeyScopes, scope) {
+			return nil, fmt.Errorf("unknown scope %s, expected one of %s or *", scope, strings.Join(apiKeyScopes, ", "))
+		}
+	}
+	hash := hashAPIKey(secret)
+	for _, existing := range apiKeys {
+		if existing.hash == hash {
+			return nil, fmt.Errorf("key is already registered as %s", existing.ID)
+		}
+	}
+	// Short keys from API_KEYS show at most half
+	hint := secret[:len(secret)/2]
+	if len(hint) > len(apiKeyPrefix)+4 {
+		hint = hint[:len(apiKeyPrefix)+4]
+	}
+	key := &apiKey{
+		ID:         idgen.NewWithPrefix("apikey"),
+		Name:       name,
+		Scopes:     scopes,
+		Hint:       hint + "...",
+		CreatedAt:  appClock.Now().Unix(),
+		ByEndpoint: make(map[string]int),
+		hash:       hash,
+	}
+	apiKeys[key.ID] = key
+	return key, nil
+}
+
+// apiKeyList returns copies of every key, oldest first. Must be called with
+// apiKeysMu held.
+func apiKeyList() []apiKey {
+	list := []apiKey{}
+	for _, id := range sortedMapKeys(apiKeys) {
+		key := *apiKeys[id]
+		key.ByEndpoint = make(map[string]int, len(apiKeys[id].ByEndpoint))
+		for endpoint, count := range apiKeys[id].ByEndpoint {
+			key.ByEndpoint[endpoint] = count
+		}
+		list = append(list, key)
+	}
+	return list
+}
+
+func (k *apiKey) hasScope(scope string) bool {
+	return containsString(k.Scopes, "*") || containsString(k.Scopes, scope)
+}
+
+// apiKeyProtected guards a partner endpoint with an API key or bearer token
+// scope (see oidc.go), and counts the request against the key under
+// endpoint.
+func apiKeyProtected(scope, endpoint string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "OPTIONS" {
+			next(w, r)
+			return
+		}
+		secret := r.Header.Get(apiKeyHeader)
+		if secret == "" {
+			if token := bearerToken(r); token != "" {
+				if !bearerDenied(w, token, scope) {
+					next(w, r)
+				}
+				return
+			}
+			if apiKeyAuth.Enabled() || oauthRequired.Enabled() {
+				w.Header().Add("WWW-Authenticate", `ApiKey header="`+apiKeyHeader+`"`)
+				w.Header().Add("WWW-Authenticate", `Bearer realm="persona", scope="`+scope+`"`)
+				writeGRPCError(w, http.StatusUnauthorized, 16, "an API key in the "+apiKeyHeader+" header or a bearer token with scope "+scope+" is required")
+				return
+			}
+			next(w, r)
+			return
+		}
+
+		apiKeysMu.Lock()
+		var key *apiKey
+		hash := hashAPIKey(secret)
+		for _, k := range apiKeys {
+			if k.hash == hash {
+				key = k
+				break
+			}
+		}
+		if key == nil || key.Revoked {
+			apiKeysMu.Unlock()
+			writeGRPCError(w, http.StatusUnauthorized, 16, "invalid or revoked API key")
+			return
+		}
+		key.LastUsedAt = appClock.Now().Unix()
+		if !key.hasScope(scope) {
+			key.Rejected++
+			apiKeysMu.Unlock()
+			writeGRPCError(w, http.StatusForbidden, 7, "API key "+key.ID+" lacks scope "+scope)
+			return
+		}
+		key.Requests++
+		key.ByEndpoint[endpoint]++
+		apiKeysMu.Unlock()
+		next(w, r)
+	}
+}
+
+// Handler for GET /admin/apikeys
+func handleListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	apiKeysMu.Lock()
+	keys := apiKeyList()
+	apiKeysMu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"enforced": apiKeyAuth.Enabled(),
+		"scopes":   apiKeyScopes,
+		"keys":     keys,
+	})
+}
+
+// Handler for POST /admin/apikeys. Takes {"name", "scopes"} and returns the
+// key with its secret, which is not shown again.
+func handleCreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Name   string   `json:"name"`
+		Scopes []string `json:"scopes"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+	if req.Name == "" {
+		http.Error(w, "name is required", http.StatusBadRequest)
+		return
+	}
+	random := make([]byte, 20)
+	rand.Read(random)
+	secret := apiKeyPrefix + hex.EncodeToString(random)
+
+	apiKeysMu.Lock()
+	key, err := addAPIKey(req.Name, secret, req.Scopes)
+	var created apiKey
+	if err == nil {
+		created = *key
+	}
+	apiKeysMu.Unlock()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	log.Printf("Created API key %s (%s) with scopes %s", created.ID, created.Name, strings.Join(created.Scopes, ", "))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{"api_key": created, "key": secret})
+}
+
+// Handler for DELETE /admin/apikeys/{id}. Revoked keys keep their counters.
+func handleRevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	apiKeysMu.Lock()
+	key := apiKeys[id]
+	if key != nil {
+		key.Revoked = true
+	}
+	apiKeysMu.Unlock()
+	if key == nil {
+		http.Error(w, "API key "+id+" not found", http.StatusNotFound)
+		return
+	}
+
+	log.Printf("Revoked API key %s", id)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"revoked": id})
+}
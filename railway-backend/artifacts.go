@@ -0,0 +1,151 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"persona-backend/objstore"
+)
+
+// Artifact storage backed by an S3-compatible bucket. Large binaries
+// (circuit artifacts, document uploads, exports, snapshots) are moved with
+// presigned URLs so they never pass through this process.
+
+const presignExpiry = 15 * time.Minute
+
+// artifactCategories are the key prefixes clients may presign under.
+var artifactCategories = map[string]bool{
+	"circuits":  true,
+	"documents": true,
+	"exports":   true,
+	"snapshots": true,
+}
+
+// adminUploadCategories need the admin token for PUT presigns: snapshot
+// archives are imported as chain state, and their manifest travels inside
+// the archive, so an upload there could plant arbitrary state.
+var adminUploadCategories = map[string]bool{
+	"exports":   true,
+	"snapshots": true,
+}
+
+// objectStore is nil when S3_BUCKET is not configured.
+var objectStore *objstore.Client
+
+func initObjectStore() {
+	cfg, ok := objstore.ConfigFromEnv()
+	if !ok {
+		return
+	}
+	objectStore = objstore.New(cfg)
+	log.Printf("Object storage enabled: bucket %s at %s", cfg.Bucket, cfg.Endpoint)
+}
+
+func artifactKey(category, name string) (string, error) {
+	if !artifactCategories[category] {
+		return "", fmt.Errorf("unknown artifact category: %s", category)
+	}
+	name = strings.TrimPrefix(name, "/")
+	if name == "" || strings.Contains(name, "..") {
+		return "", fmt.Errorf("invalid artifact name: %q", name)
+	}
+	return category + "/" + name, nil
+}
+
+func writeStorageUnavailable(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusServiceUnavailable)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"error": "Object storage is not configured (set S3_BUCKET)",
+	})
+}
+
+// Handler for POST /api/artifacts/presign - PUT presigns under exports/
+// and snapshots/ need the admin token.
+func handlePresignArtifact(w http.ResponseWriter, r *http.Request) {
+	if objectStore == nil {
+		writeStorageUnavailable(w)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Failed to read request body", http.StatusBadRequest)
+		return
+	}
+	var req struct {
+		Category string `json:"category"`
+		Name     string `json:"name"`
+		Method   string `json:"method"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	method := strings.ToUpper(req.Method)
+	if method == "" {
+		method = http.MethodPut
+	}
+	if method != http.MethodPut && method != http.MethodGet {
+		http.Error(w, "method must be GET or PUT", http.StatusBadRequest)
+		return
+	}
+	key, err := artifactKey(req.Category, req.Name)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if method == http.MethodPut && adminUploadCategories[req.Category] && !adminAuthorized(r) {
+		writeAdminTokenRequired(w)
+		return
+	}
+
+	url, err := objectStore.Presign(method, key, presignExpiry)
+	if err != nil {
+		log.Printf("Failed to presign %s %s: %v", method, key, err)
+		http.Error(w, "Failed to presign artifact URL", http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"url":        url,
+		"method":     method,
+		"key":        key,
+		"bucket":     objectStore.Bucket(),
+		"expires_at": time.Now().Add(presignExpiry).Format(time.RFC3339),
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// Handler for GET /api/artifacts/{category}/{name} - redirects to a
+// presigned download URL.
+func handleGetArtifact(w http.ResponseWriter, r *http.Request) {
+	if objectStore == nil {
+		writeStorageUnavailable(w)
+		return
+	}
+
+	vars := mux.Vars(r)
+	key, err := artifactKey(vars["category"], vars["name"])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	url, err := objectStore.Presign(http.MethodGet, key, presignExpiry)
+	if err != nil {
+		log.Printf("Failed to presign GET %s: %v", key, err)
+		http.Error(w, "Failed to presign artifact URL", http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, url, http.StatusTemporaryRedirect)
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// Assertion helpers for test frameworks. GET /api/assert/{name} checks one
+// fact about the stored state and answers with a compact result, so a
+// Playwright test can assert backend state without parsing listings:
+//
+//	{"assertion":"credential-exists","ok":false,"reason":"no credential of type AgeCredential for did:persona:123"}
+//
+// The status is 200 whether or not the assertion holds; 400 means the
+// query was incomplete and 404 that the assertion is unknown.
+
+type assertResult struct {
+	Assertion string `json:"assertion"`
+	OK        bool   `json:"ok"`
+	Reason    string `json:"reason"`
+	// Match is the ID of the record that satisfied the assertion
+	Match string `json:"match,omitempty"`
+}
+
+type assertion struct {
+	// Params are the required query parameters
+	Params []string
+	// Check runs with the chain lock held for reading
+	Check func(c *Chain, q url.Values) assertResult
+}
+
+var assertions = map[string]assertion{
+	"credential-exists": {Params: []string{"did", "type"}, Check: assertCredentialExists},
+	"proof-verified":    {Params: []string{"did"}, Check: assertProofVerified},
+	"did-active":        {Params: []string{"did"}, Check: assertDIDActive},
+}
+
+// Handler for GET /api/assert/{name}
+func (c *Chain) handleAssert(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+	a, ok := assertions[name]
+	if !ok {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"error":      "Unknown assertion",
+			"assertion":  name,
+			"assertions": sortedMapKeys(assertions),
+		})
+		return
+	}
+	q := r.URL.Query()
+	for _, param := range a.Params {
+		if q.Get(param) == "" {
+			http.Error(w, fmt.Sprintf("Missing required query parameters: %s", strings.Join(a.Params, ", ")), http.StatusBadRequest)
+			return
+		}
+	}
+
+	c.mu.RLock()
+	result := a.Check(c, q)
+	c.mu.RUnlock()
+	result.Assertion = name
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// heldCredentials returns the credentials stored under the DID's controller
+// and those whose subject is the DID. Must be called with c.mu held.
+func (c *Chain) heldCredentials(didId string) []map[string]interface{} {
+	controller := c.did().controllerOf(didId)
+	held := append([]map[string]interface{}{}, c.vc().store.ByController[controller]...)
+	for _, owner := range sortedMapKeys(c.vc().store.ByController) {
+		if owner == controller {
+			continue
+		}
+		for _, credential := range c.vc().store.ByController[owner] {
+			if subject, ok := credential["credentialSubject"].(map[string]interface{}); ok && subject["id"] == didId {
+				held = append(held, credential)
+			}
+		}
+	}
+	return held
+}
+
+// credentialHasType matches the W3C type list, or the credentialType and
+// templateId subject fields the frontend templates use.
+func credentialHasType(credential map[string]interface{}, credentialType string) bool {
+	switch types := credential["type"].(type) {
+	case string:
+		if types == credentialType {
+			return true
+		}
+	case []interface{}:
+		for _, t := range types {
+			if t == credentialType {
+				return true
+			}
+		}
+	}
+	if subject, ok := credential["credentialSubject"].(map[string]interface{}); ok {
+		return subject["credentialType"] == credentialType || subject["templateId"] == credentialType
+	}
+	return false
+}
+
+// Query: did and type. Revoked credentials do not count.
+func assertCredentialExists(c *Chain, q url.Values) assertResult {
+	didId, credentialType := q.Get("did"), q.Get("type")
+	if c.did().store.Documents[didId] == nil {
+		return assertResult{Reason: fmt.Sprintf("DID %s not found", didId)}
+	}
+	revoked := ""
+	for _, credential := range c.heldCredentials(didId) {
+		if !credentialHasType(credential, credentialType) {
+			continue
+		}
+		id, _ := credential["id"].(string)
+		if credential["is_revoked"] == true {
+			revoked = id
+			continue
+		}
+		return assertResult{OK: true, Reason: fmt.Sprintf("credential %s has type %s", id, credentialType), Match: id}
+	}
+	if revoked != "" {
+		return assertResult{Reason: fmt.Sprintf("credential %s of type %s is revoked", revoked, credentialType)}
+	}
+	return assertResult{Reason: fmt.Sprintf("no credential of type %s for %s", credentialType, didId)}
+}
+
+// Query: did and optionally circuit.
+func assertProofVerified(c *Chain, q url.Values) assertResult {
+	didId, circuit := q.Get("did"), q.Get("circuit")
+	controller := c.did().controllerOf(didId)
+	if controller == "" {
+		return assertResult{Reason: fmt.Sprintf("DID %s not found", didId)}
+	}
+	submitted := 0
+	for _, proof := range c.zk().store.ByController[controller] {
+		if circuit != "" && proof["circuit_id"] != circuit {
+			continue
+		}
+		submitted++
+		if proof["is_verified"] == true {
+			id, _ := proof["id"].(string)
+			return assertResult{OK: true, Reason: fmt.Sprintf("proof %s is verified", id), Match: id}
+		}
+	}
+	scope := "any circuit"
+	if circuit != "" {
+		scope = "circuit " + circuit
+	}
+	if submitted > 0 {
+		return assertResult{Reason: fmt.Sprintf("%d proofs for %s, none verified", submitted, scope)}
+	}
+	return assertResult{Reason: fmt.Sprintf("no proof for %s by %s", scope, didId)}
+}
+
+// Query: did.
+func assertDIDActive(c *Chain, q url.Values) assertResult {
+	didId := q.Get("did")
+	document := c.did().store.Documents[didId]
+	if document == nil {
+		return assertResult{Reason: fmt.Sprintf("DID %s not found", didId)}
+	}
+	if document["is_active"] == false {
+		return assertResult{Reason: fmt.Sprintf("DID %s is deactivated", didId)}
+	}
+	return assertResult{OK: true, Reason: fmt.Sprintf("DID %s is active", didId), Match: didId}
+}
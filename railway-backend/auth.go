@@ -0,0 +1,225 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// Account numbers and sequences, like the Cosmos auth module. Every address
+// is treated as existing; it gets the next account number the first time it
+// is queried or signs a tx. Each accepted broadcast increments the sequence
+// of every signer, and a tx carrying a stale signer sequence is rejected
+// with code 32 before any message runs. With STRICT_SIGNING the signatures
+// are verified first (see signing.go).
+
+func init() {
+	RegisterModule(newAuthModule)
+}
+
+type authAccount struct {
+	Address       string `json:"address"`
+	AccountNumber uint64 `json:"account_number"`
+	Sequence      uint64 `json:"sequence"`
+	// PubKey is the base64 secp256k1 key of the first verified signature
+	PubKey string `json:"pub_key,omitempty"`
+}
+
+// authStore holds accounts keyed by address.
+type authStore struct {
+	Accounts          map[string]*authAccount `json:"accounts"`
+	NextAccountNumber uint64                  `json:"next_account_number"`
+}
+
+func (s *authStore) Reset() {
+	s.Accounts = make(map[string]*authAccount)
+	s.NextAccountNumber = 0
+	// The mock accounts exist from genesis
+	for _, account := range mockAccounts {
+		s.account(account.Address)
+	}
+}
+
+// account returns the account for address, creating it if needed.
+func (s *authStore) account(address string) *authAccount {
+	if acc, ok := s.Accounts[address]; ok {
+		return acc
+	}
+	acc := &authAccount{Address: address, AccountNumber: s.NextAccountNumber}
+	s.NextAccountNumber++
+	s.Accounts[address] = acc
+	return acc
+}
+
+// authModule implements the cosmos auth module.
+type authModule struct {
+	chain *Chain
+	store *authStore
+}
+
+func newAuthModule(c *Chain) Module {
+	store := &authStore{}
+	store.Reset()
+	return &authModule{chain: c, store: store}
+}
+
+func (m *authModule) Name() string { return "auth" }
+
+func (m *authModule) Store() ModuleStore { return m.store }
+
+func (m *authModule) EventTypes() []string { return nil }
+
+func (m *authModule) RegisterMsgs(reg *MsgRegistry) {}
+
+func (m *authModule) RegisterRoutes(r *mux.Router) {
+	r.HandleFunc("/cosmos/auth/v1beta1/accounts/{address}", m.handleGetAccount).Methods("GET", "OPTIONS")
+}
+
+func (m *authModule) ExportGenesis() interface{} {
+	accounts := []map[string]interface{}{}
+	for _, address := range sortedMapKeys(m.store.Accounts) {
+		accounts = append(accounts, baseAccountJSON(m.store.Accounts[address]))
+	}
+	return map[string]interface{}{
+		"params":   map[string]interface{}{},
+		"accounts": accounts,
+	}
+}
+
+func (m *authModule) SeedKeys() []string { return []string{"accounts"} }
+
+// Seed creates accounts, optionally with a starting sequence. An explicit
+// account_number is kept and later accounts are numbered after it.
+func (m *authModule) Seed(key string, records []map[string]interface{}) error {
+	for i, record := range records {
+		var seed struct {
+			Address       string      `json:"address"`
+			AccountNumber json.Number `json:"account_number"`
+			Sequence      json.Number `json:"sequence"`
+		}
+		data, _ := json.Marshal(record)
+		if err := json.Unmarshal(data, &seed); err != nil || seed.Address == "" {
+			return fmt.Errorf("record %d: address is required", i)
+		}
+		acc := m.store.account(seed.Address)
+		if seed.AccountNumber != "" {
+			number, err := strconv.ParseUint(seed.AccountNumber.String(), 10, 64)
+			if err != nil {
+				return fmt.Errorf("record %d: invalid account_number", i)
+			}
+			acc.AccountNumber = number
+			if number >= m.store.NextAccountNumber {
+				m.store.NextAccountNumber = number + 1
+			}
+		}
+		if seed.Sequence != "" {
+			sequence, err := strconv.ParseUint(seed.Sequence.String(), 10, 64)
+			if err != nil {
+				return fmt.Errorf("record %d: invalid sequence", i)
+			}
+			acc.Sequence = sequence
+		}
+	}
+	return nil
+}
+
+// checkSequence rejects the tx when it carries a sequence for the signer
+// other than the expected one. Must be called with the chain lock held.
+func (m *authModule) checkSequence(signer string, sequence uint64, hasSequence bool) *txError {
+	acc := m.store.account(signer)
+	if hasSequence && sequence != acc.Sequence {
+		return txErrorf(codeWrongSequence, "account sequence mismatch, expected %d, got %d", acc.Sequence, sequence)
+	}
+	return nil
+}
+
+func baseAccountJSON(acc *authAccount) map[string]interface{} {
+	var pubKey interface{}
+	if acc.PubKey != "" {
+		pubKey = map[string]interface{}{"@type": secp256k1PubKeyType, "key": acc.PubKey}
+	}
+	return map[string]interface{}{
+		"@type":          "/cosmos.auth.v1beta1.BaseAccount",
+		"address":        acc.Address,
+		"pub_key":        pubKey,
+		"account_number": fmt.Sprintf("%d", acc.AccountNumber),
+		"sequence":       fmt.Sprintf("%d", acc.Sequence),
+	}
+}
+
+// Handler for GET /cosmos/auth/v1beta1/accounts/{address}
+func (m *authModule) handleGetAccount(w http.ResponseWriter, r *http.Request) {
+	address := mux.Vars(r)["address"]
+
+	m.chain.mu.Lock()
+	response := map[string]interface{}{
+		"account": baseAccountJSON(m.store.account(address)),
+	}
+	m.chain.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// txSignerSequence returns the sequence of the i-th signer carried by a
+// broadcast, from auth_info.signer_infos (Cosmos tx JSON) or signatures
+// (legacy StdTx).
+func txSignerSequence(txData map[string]interface{}, i int) (uint64, bool) {
+	tx, ok := txData["tx"].(map[string]interface{})
+	if !ok {
+		return 0, false
+	}
+	var info map[string]interface{}
+	if authInfo, ok := tx["auth_info"].(map[string]interface{}); ok {
+		if infos, ok := authInfo["signer_infos"].([]interface{}); ok && len(infos) > i {
+			info, _ = infos[i].(map[string]interface{})
+		}
+	}
+	if info == nil {
+		if sigs, ok := tx["signatures"].([]interface{}); ok && len(sigs) > i {
+			info, _ = sigs[i].(map[string]interface{})
+		}
+	}
+	if info == nil {
+		return 0, false
+	}
+	switch seq := info["sequence"].(type) {
+	case string:
+		if n, err := strconv.ParseUint(seq, 10, 64); err == nil {
+			return n, true
+		}
+	case float64:
+		return uint64(seq), true
+	}
+	return 0, false
+}
+
+// txSigner returns the first signer of a tx, which pays fees and owns the
+// sequence being checked.
+func txSigner(msgs []interface{}) string {
+	for _, m := range msgs {
+		if msg, ok := m.(map[string]interface{}); ok {
+			if signer := msgSigner(msg); signer != "" {
+				return signer
+			}
+		}
+	}
+	return ""
+}
+
+// txSigners returns the distinct signers of a tx's messages in order of
+// first appearance, as signer_infos lists them; the first pays the fee.
+func txSigners(msgs []interface{}) []string {
+	var signers []string
+	for _, m := range msgs {
+		if msg, ok := m.(map[string]interface{}); ok {
+			if signer := msgSigner(msg); signer != "" {
+				signers = appendUnique(signers, signer)
+			}
+		}
+	}
+	return signers
+}
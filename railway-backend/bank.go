@@ -0,0 +1,460 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/cosmos/btcutil/bech32"
+	"github.com/gorilla/mux"
+)
+
+// Account balances, like the Cosmos bank module. Every address starts with
+// the initial coins until it first pays a fee: BANK_INITIAL_BALANCE
+// (default 1000000000) of the chain's denom, the same of stake and
+// 1000000 of a test IBC denom, or the comma-separated coins of
+// BANK_INITIAL_COINS. The ante handler deducts the fee in auth_info.fee (or
+// a legacy StdTx's fee) from the signer before its sequence is
+// incremented, and rejects the tx with code 13 when a balance is too low to
+// pay it. Balances can be set through POST /admin/seed, e.g.
+//
+//	{"balances": [{"address": "cosmos1...", "coins": [{"denom": "uprsn", "amount": "10"}]}]}
+//
+// The supply of a denom is what the chain's known accounts (every address
+// with an auth account or a balance) hold, plus the fees collected, which
+// the genesis export puts in the fee_collector module account. Denom
+// metadata (display units, decimals and symbol) comes from
+// BANK_DENOM_METADATA (a JSON array in the SDK's Metadata shape) and
+// defaults to an entry for each initial denom.
+
+func init() {
+	RegisterModule(newBankModule)
+}
+
+// testIBCDenom is ATOM over transfer/channel-0
+const testIBCDenom = "ibc/27394FB092D2ECCD56123C74F36E4C1F926001CEADA9CA97EA622B25F41E5EB2"
+
+var bankInitialBalance = int64(intFromEnv("BANK_INITIAL_BALANCE", 1000000000))
+
+// feeCollectorAddress is the fee_collector module account, which holds the
+// collected fees on the real chain
+var feeCollectorAddress = moduleAddress("fee_collector")
+
+// moduleAddress derives a module account address the way the SDK does.
+func moduleAddress(name string) string {
+	sum := sha256.Sum256([]byte(name))
+	address, err := bech32.EncodeFromBase256("cosmos", sum[:20])
+	if err != nil {
+		panic("bech32 encoding failed: " + err.Error())
+	}
+	return address
+}
+
+type denomUnit struct {
+	Denom    string   `json:"denom"`
+	Exponent uint32   `json:"exponent"`
+	Aliases  []string `json:"aliases"`
+}
+
+// denomMetadata is the SDK's bank Metadata.
+type denomMetadata struct {
+	Description string      `json:"description"`
+	DenomUnits  []denomUnit `json:"denom_units"`
+	Base        string      `json:"base"`
+	Display     string      `json:"display"`
+	Name        string      `json:"name"`
+	Symbol      string      `json:"symbol"`
+	URI         string      `json:"uri"`
+	URIHash     string      `json:"uri_hash"`
+}
+
+// defaultDenomMetadata describes a micro-denom (uprsn is PRSN with 6
+// decimals) or, for anything else, a 6-decimal display denom of the same
+// name in upper case.
+func defaultDenomMetadata(base string) denomMetadata {
+	display := strings.TrimPrefix(base, "u")
+	description := "The " + strings.ToUpper(display) + " token"
+	if base == testIBCDenom {
+		display = "atom"
+		description = "Test ATOM over IBC (transfer/channel-0)"
+	} else if display == base {
+		return denomMetadata{
+			Description: description,
+			DenomUnits:  []denomUnit{{Denom: base, Exponent: 0, Aliases: []string{}}},
+			Base:        base,
+			Display:     base,
+			Name:        strings.ToUpper(base),
+			Symbol:      strings.ToUpper(base),
+		}
+	}
+	return denomMetadata{
+		Description: description,
+		DenomUnits: []denomUnit{
+			{Denom: base, Exponent: 0, Aliases: []string{"micro" + display}},
+			{Denom: display, Exponent: 6, Aliases: []string{}},
+		},
+		Base:    base,
+		Display: display,
+		Name:    strings.ToUpper(display),
+		Symbol:  strings.ToUpper(display),
+	}
+}
+
+// bankStore holds balances keyed by address, then denom.
+type bankStore struct {
+	Balances map[string]map[string]int64 `json:"balances"`
+	// FeesCollected totals the fees paid, by denom
+	FeesCollected map[string]int64 `json:"fees_collected"`
+}
+
+func (s *bankStore) Reset() {
+	s.Balances = make(map[string]map[string]int64)
+	s.FeesCollected = make(map[string]int64)
+}
+
+// bankModule implements the cosmos bank module.
+type bankModule struct {
+	chain *Chain
+	store *bankStore
+	// initial holds the coins of an address that never paid a fee
+	initial  map[string]int64
+	metadata []denomMetadata
+}
+
+func newBankModule(c *Chain) Module {
+	store := &bankStore{}
+	store.Reset()
+	m := &bankModule{chain: c, store: store, initial: map[string]int64{
+		c.denom:      bankInitialBalance,
+		"stake":      bankInitialBalance,
+		testIBCDenom: 1000000,
+	}}
+	if raw := os.Getenv("BANK_INITIAL_COINS"); raw != "" {
+		initial := make(map[string]int64)
+		for _, s := range strings.Split(raw, ",") {
+			parsed, err := parseCoin(strings.TrimSpace(s))
+			amount, amountErr := parsed.amountOf()
+			if err != nil || amountErr != nil {
+				log.Printf("Invalid BANK_INITIAL_COINS entry %q", s)
+				continue
+			}
+			initial[parsed.Denom] += amount
+		}
+		m.initial = initial
+	}
+	for _, denom := range sortedMapKeys(m.initial) {
+		m.metadata = append(m.metadata, defaultDenomMetadata(denom))
+	}
+	if raw := os.Getenv("BANK_DENOM_METADATA"); raw != "" {
+		var metadata []denomMetadata
+		if err := json.Unmarshal([]byte(raw), &metadata); err != nil {
+			log.Printf("Invalid BANK_DENOM_METADATA: %v", err)
+		} else {
+			m.metadata = metadata
+		}
+	}
+	return m
+}
+
+func (m *bankModule) Name() string { return "bank" }
+
+func (m *bankModule) Store() ModuleStore { return m.store }
+
+func (m *bankModule) EventTypes() []string { return nil }
+
+func (m *bankModule) RegisterMsgs(reg *MsgRegistry) {}
+
+func (m *bankModule) RegisterRoutes(r *mux.Router) {
+	r.HandleFunc("/cosmos/bank/v1beta1/balances/{address}", m.handleGetBalances).Methods("GET", "OPTIONS")
+	r.HandleFunc("/cosmos/bank/v1beta1/balances/{address}/by_denom", m.handleGetBalanceByDenom).Methods("GET", "OPTIONS")
+	r.HandleFunc("/cosmos/bank/v1beta1/supply", m.handleGetSupply).Methods("GET", "OPTIONS")
+	r.HandleFunc("/cosmos/bank/v1beta1/supply/by_denom", m.handleGetSupplyOf).Methods("GET", "OPTIONS")
+	r.HandleFunc("/cosmos/bank/v1beta1/denoms_metadata", m.handleListDenomsMetadata).Methods("GET", "OPTIONS")
+	r.HandleFunc("/cosmos/bank/v1beta1/denoms_metadata/{denom:.+}", m.handleGetDenomMetadata).Methods("GET", "OPTIONS")
+}
+
+// ExportGenesis exports the bank app_state. Collected fees are exported as
+// the fee_collector module account's balance, where the real chain keeps
+// them; the bank genesis has no field of its own for them.
+func (m *bankModule) ExportGenesis() interface{} {
+	balances := []map[string]interface{}{}
+	for _, address := range sortedMapKeys(m.store.Balances) {
+		balances = append(balances, map[string]interface{}{
+			"address": address,
+			"coins":   m.coins(address),
+		})
+	}
+	if fees := coinsOf(m.store.FeesCollected); len(fees) > 0 {
+		balances = append(balances, map[string]interface{}{
+			"address": feeCollectorAddress,
+			"coins":   fees,
+		})
+		sort.SliceStable(balances, func(i, j int) bool {
+			return balances[i]["address"].(string) < balances[j]["address"].(string)
+		})
+	}
+	return map[string]interface{}{
+		"params":         map[string]interface{}{"default_send_enabled": true},
+		"balances":       balances,
+		"supply":         coinsOf(m.supply()),
+		"denom_metadata": m.metadata,
+	}
+}
+
+func (m *bankModule) SeedKeys() []string { return []string{"balances"} }
+
+// Seed replaces the balances of addresses.
+func (m *bankModule) Seed(key string, records []map[string]interface{}) error {
+	for i, record := range records {
+		var seed struct {
+			Address string `json:"address"`
+			Coins   []coin `json:"coins"`
+		}
+		data, _ := json.Marshal(record)
+		if err := json.Unmarshal(data, &seed); err != nil || seed.Address == "" {
+			return fmt.Errorf("record %d: address is required", i)
+		}
+		balances := make(map[string]int64)
+		for _, c := range seed.Coins {
+			amount, err := c.amountOf()
+			if err != nil || c.Denom == "" {
+				return fmt.Errorf("record %d: invalid coin %s%s", i, c.Amount, c.Denom)
+			}
+			balances[c.Denom] = amount
+		}
+		m.store.Balances[seed.Address] = balances
+	}
+	return nil
+}
+
+// balances returns the balances of address, the initial balance for an
+// address that never paid a fee. Must be called with the chain lock held;
+// the map is only stored when writable is true.
+func (m *bankModule) balances(address string, writable bool) map[string]int64 {
+	if balances, ok := m.store.Balances[address]; ok {
+		return balances
+	}
+	balances := make(map[string]int64, len(m.initial))
+	for denom, amount := range m.initial {
+		balances[denom] = amount
+	}
+	if writable {
+		m.store.Balances[address] = balances
+	}
+	return balances
+}
+
+// coins returns the non-zero balances of address, sorted by denom. Must be
+// called with the chain lock held.
+func (m *bankModule) coins(address string) []coin {
+	return coinsOf(m.balances(address, false))
+}
+
+// supply totals the balances of every known account and the collected
+// fees, by denom. Must be called with the chain lock held.
+func (m *bankModule) supply() map[string]int64 {
+	supply := make(map[string]int64)
+	for denom, amount := range m.store.FeesCollected {
+		supply[denom] += amount
+	}
+	for _, balances := range m.store.Balances {
+		for denom, amount := range balances {
+			supply[denom] += amount
+		}
+	}
+	for address := range m.chain.auth().store.Accounts {
+		if _, ok := m.store.Balances[address]; !ok {
+			for denom, amount := range m.initial {
+				supply[denom] += amount
+			}
+		}
+	}
+	return supply
+}
+
+func coinsOf(amounts map[string]int64) []coin {
+	coins := []coin{}
+	for _, denom := range sortedMapKeys(amounts) {
+		if amounts[denom] > 0 {
+			coins = append(coins, newCoin(denom, amounts[denom]))
+		}
+	}
+	return coins
+}
+
+// txFee returns the fee coins of a broadcast, from auth_info.fee (Cosmos
+// tx JSON or decoded tx_bytes) or a legacy StdTx's fee.
+func txFee(txData map[string]interface{}) ([]coin, error) {
+	fee, _ := txSignatureOf(txData, 0).fee.(map[string]interface{})
+	amounts, _ := fee["amount"].([]interface{})
+	coins := []coin{}
+	for _, amount := range amounts {
+		entry, _ := amount.(map[string]interface{})
+		denom, _ := entry["denom"].(string)
+		c := coin{Denom: denom}
+		switch value := entry["amount"].(type) {
+		case string:
+			c.Amount = value
+		case float64:
+			c.Amount = fmt.Sprintf("%.0f", value)
+		}
+		if _, err := c.amountOf(); err != nil || denom == "" {
+			return nil, fmt.Errorf("invalid fee amount %v", amount)
+		}
+		coins = append(coins, c)
+	}
+	return coins, nil
+}
+
+// checkFee rejects a tx whose signer cannot pay its fee. Must be called with
+// the chain lock held.
+func (m *bankModule) checkFee(signer string, fee []coin) *txError {
+	balances := m.balances(signer, false)
+	due := make(map[string]int64)
+	for _, c := range fee {
+		amount, _ := c.amountOf()
+		due[c.Denom] += amount
+	}
+	for _, denom := range sortedMapKeys(due) {
+		if balances[denom] < due[denom] {
+			return txErrorf(codeInsufficientFee, "spendable balance %d%s is smaller than fee %d%s", balances[denom], denom, due[denom], denom)
+		}
+	}
+	return nil
+}
+
+// deductFee moves a checked fee from the signer to the collected fees. Must
+// be called with the chain lock held.
+func (m *bankModule) deductFee(signer string, fee []coin) {
+	if len(fee) == 0 {
+		return
+	}
+	balances := m.balances(signer, true)
+	for _, c := range fee {
+		amount, _ := c.amountOf()
+		balances[c.Denom] -= amount
+		m.store.FeesCollected[c.Denom] += amount
+	}
+}
+
+// Handler for GET /cosmos/bank/v1beta1/balances/{address}
+func (m *bankModule) handleGetBalances(w http.ResponseWriter, r *http.Request) {
+	address := mux.Vars(r)["address"]
+	pageReq, err := parsePageRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	m.chain.mu.RLock()
+	coins := m.coins(address)
+	m.chain.mu.RUnlock()
+
+	items := make([]map[string]interface{}, len(coins))
+	for i, c := range coins {
+		items[i] = map[string]interface{}{"denom": c.Denom, "amount": c.Amount}
+	}
+	page, pagination := paginate(items, func(_ int, item map[string]interface{}) string {
+		return item["denom"].(string)
+	}, pageReq)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"balances":   page,
+		"pagination": pagination,
+	})
+}
+
+// Handler for GET /cosmos/bank/v1beta1/balances/{address}/by_denom
+func (m *bankModule) handleGetBalanceByDenom(w http.ResponseWriter, r *http.Request) {
+	address := mux.Vars(r)["address"]
+	denom := strings.TrimSpace(r.URL.Query().Get("denom"))
+	if denom == "" {
+		writeGRPCError(w, http.StatusBadRequest, 3, "invalid denom")
+		return
+	}
+
+	m.chain.mu.RLock()
+	amount := m.balances(address, false)[denom]
+	m.chain.mu.RUnlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"balance": newCoin(denom, amount),
+	})
+}
+
+// Handler for GET /cosmos/bank/v1beta1/supply
+func (m *bankModule) handleGetSupply(w http.ResponseWriter, r *http.Request) {
+	pageReq, err := parsePageRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	m.chain.mu.RLock()
+	coins := coinsOf(m.supply())
+	m.chain.mu.RUnlock()
+
+	items := make([]map[string]interface{}, len(coins))
+	for i, c := range coins {
+		items[i] = map[string]interface{}{"denom": c.Denom, "amount": c.Amount}
+	}
+	page, pagination := paginate(items, func(_ int, item map[string]interface{}) string {
+		return item["denom"].(string)
+	}, pageReq)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"supply":     page,
+		"pagination": pagination,
+	})
+}
+
+// Handler for GET /cosmos/bank/v1beta1/supply/by_denom
+func (m *bankModule) handleGetSupplyOf(w http.ResponseWriter, r *http.Request) {
+	denom := strings.TrimSpace(r.URL.Query().Get("denom"))
+	if denom == "" {
+		writeGRPCError(w, http.StatusBadRequest, 3, "invalid denom")
+		return
+	}
+
+	m.chain.mu.RLock()
+	amount := m.supply()[denom]
+	m.chain.mu.RUnlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"amount": newCoin(denom, amount),
+	})
+}
+
+// Handler for GET /cosmos/bank/v1beta1/denoms_metadata
+func (m *bankModule) handleListDenomsMetadata(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"metadatas": m.metadata,
+		"pagination": map[string]interface{}{
+			"next_key": nil,
+			"total":    fmt.Sprintf("%d", len(m.metadata)),
+		},
+	})
+}
+
+// Handler for GET /cosmos/bank/v1beta1/denoms_metadata/{denom}
+func (m *bankModule) handleGetDenomMetadata(w http.ResponseWriter, r *http.Request) {
+	denom := mux.Vars(r)["denom"]
+	for _, metadata := range m.metadata {
+		if metadata.Base == denom {
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(map[string]interface{}{"metadata": metadata})
+			return
+		}
+	}
+	writeGRPCError(w, http.StatusNotFound, 5, "client metadata for denom "+denom)
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+)
+
+// Batch verification for relying parties. POST
+// /persona/zk/v1beta1/verify/batch takes {"items": [...]} of single verify
+// requests and POST /persona/vc/v1beta1/verify/batch takes credential
+// checks; up to VERIFY_BATCH_MAX items (default 100) are verified on the
+// verification worker pool (see workerpools.go). Results keep the order of
+// the items, and a bad item fails on its own rather than the whole batch,
+// as does an item the pool's queue has no room for.
+
+var verifyBatchMax = intFromEnv("VERIFY_BATCH_MAX", 100)
+
+// runBatch calls verify for every index on the verification pool, or
+// rejected when the pool's queue is full, and returns when all are done.
+func runBatch(n int, verify, rejected func(i int)) {
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		i := i
+		wg.Add(1)
+		if err := verificationPool.Submit(func() {
+			defer wg.Done()
+			verify(i)
+		}); err != nil {
+			rejected(i)
+			wg.Done()
+		}
+	}
+	wg.Wait()
+}
+
+// decodeBatch reads {"items": [...]} and checks the batch size.
+func decodeBatch(r *http.Request) ([]json.RawMessage, error) {
+	var req struct {
+		Items []json.RawMessage `json:"items"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, fmt.Errorf("Invalid JSON format")
+	}
+	if len(req.Items) == 0 {
+		return nil, fmt.Errorf("items must be a non-empty list")
+	}
+	if len(req.Items) > verifyBatchMax {
+		return nil, fmt.Errorf("too many items: %d, at most %d", len(req.Items), verifyBatchMax)
+	}
+	return req.Items, nil
+}
+
+func writeBatchResults(w http.ResponseWriter, results []map[string]interface{}) {
+	verified := 0
+	for _, result := range results {
+		if result["verified"] == true {
+			verified++
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"results": results,
+		"summary": map[string]interface{}{
+			"total":    len(results),
+			"verified": verified,
+			"failed":   len(results) - verified,
+		},
+	})
+}
+
+// Handler for POST /persona/zk/v1beta1/verify/batch
+func (m *zkModule) handleVerifyProofBatch(w http.ResponseWriter, r *http.Request) {
+	items, err := decodeBatch(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !chargeVerifications(w, r, len(items)) {
+		return
+	}
+
+	results := make([]map[string]interface{}, len(items))
+	runBatch(len(items), func(i int) {
+		result := map[string]interface{}{"index": i, "verified": false}
+		results[i] = result
+		var req verifyProofRequest
+		if err := json.Unmarshal(items[i], &req); err != nil {
+			result["error"] = "item is not a verify request"
+			return
+		}
+		result["circuit_id"] = req.CircuitID
+		if req.CircuitID == "" || req.proofData() == "" {
+			result["error"] = "circuit_id and proof are required"
+			return
+		}
+		verification, cached := m.verifyProof(req)
+		result["verified"] = verification.Verified
+		result["mode"] = verification.Mode
+		result["checked_at"] = verification.CheckedAt
+		result["cached"] = cached
+		if verification.Error != "" {
+			result["error"] = verification.Error
+		}
+	}, func(i int) {
+		results[i] = map[string]interface{}{"index": i, "verified": false, "error": "verification queue is full"}
+	})
+	log.Printf("Verified batch of %d proofs", len(items))
+	writeBatchResults(w, results)
+}
+
+// verifyCredentialRequest names a stored credential, or carries the
+// credential itself as presented.
+type verifyCredentialRequest struct {
+	CredentialID string                 `json:"credential_id"`
+	Credential   map[string]interface{} `json:"credential"`
+}
+
+// verifyCredential checks that a credential is stored and not revoked, and
+// that a presented credential still matches its schema. Must be called
+// with the chain lock held.
+func (m *vcModule) verifyCredential(req verifyCredentialRequest) map[string]interface{} {
+	id := req.CredentialID
+	if id == "" && req.Credential != nil {
+		id, _ = req.Credential["id"].(string)
+	}
+	result := map[string]interface{}{"credential_id": id, "verified": false}
+	if id == "" {
+		result["error"] = "credential_id or credential.id is required"
+		return result
+	}
+	_, stored := m.findCredential(id)
+	if stored == nil {
+		result["status"] = "not_found"
+		result["error"] = "credential " + id + " not found"
+		return result
+	}
+	if stored["is_revoked"] == true {
+		result["status"] = "revoked"
+		result["error"] = "credential " + id + " is revoked"
+		for key, value := range revocationDetails(stored) {
+			result[key] = value
+		}
+		return result
+	}
+	result["status"] = "active"
+	checked := stored
+	if req.Credential != nil {
+		checked = req.Credential
+		if req.Credential["issuer"] != nil && req.Credential["issuer"] != stored["issuer"] {
+			result["error"] = "issuer does not match the issued credential"
+			return result
+		}
+	}
+	if violations := m.checkSchemas(checked); len(violations) > 0 {
+		result["error"] = fmt.Sprintf("schema violations: %v", violations)
+		return result
+	}
+	result["verified"] = true
+	return result
+}
+
+// Handler for POST /persona/vc/v1beta1/verify/batch
+func (m *vcModule) handleVerifyCredentialBatch(w http.ResponseWriter, r *http.Request) {
+	items, err := decodeBatch(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !chargeVerifications(w, r, len(items)) {
+		return
+	}
+
+	results := make([]map[string]interface{}, len(items))
+	runBatch(len(items), func(i int) {
+		var req verifyCredentialRequest
+		if err := json.Unmarshal(items[i], &req); err != nil {
+			results[i] = map[string]interface{}{"verified": false, "error": "item is not a credential check"}
+		} else {
+			m.chain.mu.RLock()
+			results[i] = m.verifyCredential(req)
+			m.chain.mu.RUnlock()
+		}
+		results[i]["index"] = i
+	}, func(i int) {
+		results[i] = map[string]interface{}{"index": i, "verified": false, "error": "verification queue is full"}
+	})
+	log.Printf("Verified batch of %d credentials", len(items))
+	writeBatchResults(w, results)
+}
@@ -0,0 +1,428 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cosmos/btcutil/base58"
+	"github.com/gorilla/mux"
+
+	"persona-backend/bbs"
+)
+
+// BBS+ credentials for selective disclosure of W3C credentials. The proofs
+// use this project's own BBS+ encoding (package bbs), so they are typed
+// PersonaBbsSignature2026 and PersonaBbsSignatureProof2026 rather than
+// claiming the BbsBlsSignature2020 suite. Issuing a credential whose proof
+// is just {"type": "PersonaBbsSignature2026"} signs it with the issuer's
+// BBS+ key instead of storing the proof as given. The signed messages are
+// the credential's statements, one per leaf field in the "path=<JSON
+// value>" form (arrays are a single leaf), sorted, plus the proof's
+// created, verificationMethod and proofPurpose.
+//
+// POST /persona/vc/v1beta1/derive turns a signed credential into a derived
+// credential with a PersonaBbsSignatureProof2026 that reveals only the
+// requested credentialSubject fields, and POST
+// /persona/vc/v1beta1/derive/verify checks one. Everything outside
+// credentialSubject and credentialSubject.id is always revealed. Issuer
+// keys are derived from BBS_KEY_SEED and the issuer DID, so they are
+// stable across restarts when the seed is set, and published at GET
+// /persona/vc/v1beta1/bbs/keys/{did}.
+
+const (
+	bbsSignatureType  = "PersonaBbsSignature2026"
+	bbsProofType      = "PersonaBbsSignatureProof2026"
+	bbsKeyType        = "Bls12381G2Key2020"
+	bbsKeyFragment    = "#bbs-key-1"
+	bbsSubjectField   = "credentialSubject"
+	bbsProofStatement = "proof."
+)
+
+// Fields the chain adds to stored credentials, which are not signed
+var bbsUnsignedFields = map[string]bool{
+	"proof":                  true,
+	"created_at":             true,
+	"is_revoked":             true,
+	"revoked_at":             true,
+	"revocation_reason":      true,
+	"revocation_reason_code": true,
+	"revocation_explanation": true,
+	"residency":              true,
+}
+
+var bbsProofOptions = []string{"created", "verificationMethod", "proofPurpose"}
+
+var (
+	bbsKeySeed = bbsSeedFromEnv()
+	bbsKeysMu  sync.Mutex
+	bbsKeys    = map[string]*bbs.PrivateKey{}
+)
+
+func bbsSeedFromEnv() []byte {
+	if seed := os.Getenv("BBS_KEY_SEED"); seed != "" {
+		return []byte(seed)
+	}
+	seed := make([]byte, 32)
+	if _, err := rand.Read(seed); err != nil {
+		log.Fatalf("Failed to generate BBS+ key seed: %v", err)
+	}
+	return seed
+}
+
+// bbsKey returns the BBS+ key of an issuer DID.
+func bbsKey(did string) *bbs.PrivateKey {
+	bbsKeysMu.Lock()
+	defer bbsKeysMu.Unlock()
+	key, ok := bbsKeys[did]
+	if !ok {
+		seed := sha256.Sum256(append(append([]byte{}, bbsKeySeed...), did...))
+		key = bbs.GenerateKey(seed[:])
+		bbsKeys[did] = key
+	}
+	return key
+}
+
+// bbsIssuer returns the issuer URI of a credential.
+func bbsIssuer(credential map[string]interface{}) string {
+	issuer, _ := credentialIssuer(credential).(string)
+	return issuer
+}
+
+type bbsStatement struct {
+	path, message string
+}
+
+// bbsStatements flattens a credential and the signed proof options into
+// sorted statements.
+func bbsStatements(credential, proof map[string]interface{}) []bbsStatement {
+	var statements []bbsStatement
+	var flatten func(path string, value interface{})
+	flatten = func(path string, value interface{}) {
+		if object, ok := value.(map[string]interface{}); ok && len(object) > 0 {
+			for key, field := range object {
+				flatten(path+"."+key, field)
+			}
+			return
+		}
+		data, _ := json.Marshal(value)
+		statements = append(statements, bbsStatement{path: path, message: path + "=" + string(data)})
+	}
+	for key, value := range credential {
+		if !bbsUnsignedFields[key] {
+			flatten(key, value)
+		}
+	}
+	for _, option := range bbsProofOptions {
+		if value, ok := proof[option]; ok {
+			flatten("proof."+option, value)
+		}
+	}
+	sort.Slice(statements, func(i, j int) bool { return statements[i].message < statements[j].message })
+	return statements
+}
+
+func bbsMessages(statements []bbsStatement) [][]byte {
+	messages := make([][]byte, len(statements))
+	for i, statement := range statements {
+		messages[i] = []byte(statement.message)
+	}
+	return messages
+}
+
+// signBBSCredential replaces a bare PersonaBbsSignature2026 proof request
+// with a signature by the issuer's key.
+func (m *vcModule) signBBSCredential(credential map[string]interface{}) error {
+	issuer := bbsIssuer(credential)
+	if issuer == "" {
+		return errors.New("issuer is required for a " + bbsSignatureType + " proof")
+	}
+	proof := map[string]interface{}{
+		"type":               bbsSignatureType,
+		"created":            m.chain.now().UTC().Format(time.RFC3339),
+		"verificationMethod": issuer + bbsKeyFragment,
+		"proofPurpose":       "assertionMethod",
+	}
+	sig, err := bbs.Sign(bbsKey(issuer), bbsMessages(bbsStatements(credential, proof)))
+	if err != nil {
+		return err
+	}
+	proof["proofValue"] = base64.StdEncoding.EncodeToString(sig)
+	credential["proof"] = proof
+	return nil
+}
+
+// bbsProofRequested reports whether a credential asks to be signed with
+// BBS+ on issuance.
+func bbsProofRequested(credential map[string]interface{}) bool {
+	proof, ok := credential["proof"].(map[string]interface{})
+	return ok && proof["type"] == bbsSignatureType && proof["proofValue"] == nil
+}
+
+// bbsProof returns a credential's proof of the given type and its decoded
+// proofValue.
+func bbsProof(credential map[string]interface{}, proofType string) (map[string]interface{}, []byte, error) {
+	proof, _ := credential["proof"].(map[string]interface{})
+	if proof == nil || proof["type"] != proofType {
+		return nil, nil, fmt.Errorf("credential has no %s proof", proofType)
+	}
+	value, _ := proof["proofValue"].(string)
+	decoded, err := base64.StdEncoding.DecodeString(value)
+	if err != nil || value == "" {
+		return nil, nil, errors.New("proof.proofValue must be base64")
+	}
+	return proof, decoded, nil
+}
+
+// bbsRevealed reports whether a statement is revealed by one of the
+// requested paths, or always revealed.
+func bbsRevealed(path string, reveal []string) bool {
+	if path != bbsSubjectField && !strings.HasPrefix(path, bbsSubjectField+".") {
+		return true
+	}
+	if path == bbsSubjectField+".id" {
+		return true
+	}
+	for _, requested := range reveal {
+		if path == requested || strings.HasPrefix(path, requested+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// setPath sets a dotted path in a nested object.
+func setPath(object map[string]interface{}, path string, value interface{}) {
+	keys := strings.Split(path, ".")
+	for _, key := range keys[:len(keys)-1] {
+		child, ok := object[key].(map[string]interface{})
+		if !ok {
+			child = map[string]interface{}{}
+			object[key] = child
+		}
+		object = child
+	}
+	object[keys[len(keys)-1]] = value
+}
+
+type bbsDeriveRequest struct {
+	CredentialID string                 `json:"credential_id"`
+	Credential   map[string]interface{} `json:"credential"`
+	Reveal       []string               `json:"reveal"`
+	Nonce        string                 `json:"nonce"`
+}
+
+// deriveBBSCredential derives a credential revealing the requested
+// credentialSubject paths from a PersonaBbsSignature2026-signed credential.
+func deriveBBSCredential(credential map[string]interface{}, reveal []string, nonce string) (map[string]interface{}, error) {
+	proof, sig, err := bbsProof(credential, bbsSignatureType)
+	if err != nil {
+		return nil, err
+	}
+	statements := bbsStatements(credential, proof)
+	for _, path := range reveal {
+		if !strings.HasPrefix(path, bbsSubjectField+".") {
+			return nil, fmt.Errorf("reveal path %q must be under %s", path, bbsSubjectField)
+		}
+		found := false
+		for _, statement := range statements {
+			if statement.path == path || strings.HasPrefix(statement.path, path+".") {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("credential has no %s", path)
+		}
+	}
+
+	derived := map[string]interface{}{}
+	var revealed []int
+	for i, statement := range statements {
+		if !bbsRevealed(statement.path, reveal) {
+			continue
+		}
+		revealed = append(revealed, i)
+		if strings.HasPrefix(statement.path, bbsProofStatement) {
+			// Proof options go back into the derived proof below
+			continue
+		}
+		var value interface{}
+		json.Unmarshal([]byte(strings.TrimPrefix(statement.message, statement.path+"=")), &value)
+		setPath(derived, statement.path, value)
+	}
+
+	issuer := bbsIssuer(credential)
+	pub := bbsKey(issuer).Public
+	proofValue, err := bbs.DeriveProof(pub, bbsMessages(statements), sig, revealed, []byte(nonce))
+	if errors.Is(err, bbs.ErrInvalidSignature) {
+		return nil, errors.New("credential signature does not verify")
+	}
+	if err != nil {
+		return nil, err
+	}
+	derivedProof := map[string]interface{}{
+		"type":       bbsProofType,
+		"nonce":      nonce,
+		"proofValue": base64.StdEncoding.EncodeToString(proofValue),
+	}
+	for _, option := range bbsProofOptions {
+		if value, ok := proof[option]; ok {
+			derivedProof[option] = value
+		}
+	}
+	derived["proof"] = derivedProof
+	return derived, nil
+}
+
+// verifyBBSCredential checks a derived credential, or a signed one, and
+// that its issuer is active and it is not revoked.
+func (m *vcModule) verifyBBSCredential(credential map[string]interface{}) map[string]interface{} {
+	issuer := bbsIssuer(credential)
+	result := map[string]interface{}{"verified": false, "issuer": issuer}
+	proofType := bbsProofType
+	if proof, _ := credential["proof"].(map[string]interface{}); proof != nil && proof["type"] == bbsSignatureType {
+		proofType = bbsSignatureType
+	}
+	proof, proofValue, err := bbsProof(credential, proofType)
+	if err != nil {
+		result["error"] = err.Error()
+		return result
+	}
+	result["proof_type"] = proofType
+	if issuer == "" {
+		result["error"] = "credential has no issuer"
+		return result
+	}
+
+	statements := bbsStatements(credential, proof)
+	pub := bbsKey(issuer).Public
+	if proofType == bbsSignatureType {
+		err = bbs.Verify(pub, bbsMessages(statements), proofValue)
+	} else {
+		nonce, _ := proof["nonce"].(string)
+		err = bbs.VerifyProof(pub, proofValue, bbsMessages(statements), []byte(nonce))
+		if count, _, countErr := bbs.Revealed(proofValue); countErr == nil {
+			result["total_statements"] = count
+		}
+	}
+	if err != nil {
+		result["error"] = err.Error()
+		return result
+	}
+	result["revealed_statements"] = len(statements)
+
+	m.chain.mu.RLock()
+	issuerDoc := m.chain.did().store.Documents[issuer]
+	deactivated := issuerDoc != nil && issuerDoc["is_active"] == false
+	var revoked bool
+	if id, ok := credential["id"].(string); ok {
+		if _, stored := m.findCredential(id); stored != nil {
+			revoked = stored["is_revoked"] == true
+		}
+	}
+	m.chain.mu.RUnlock()
+	if deactivated {
+		result["error"] = "issuer DID is deactivated"
+		return result
+	}
+	if revoked {
+		result["error"] = fmt.Sprintf("credential %v is revoked", credential["id"])
+		return result
+	}
+	result["verified"] = true
+	return result
+}
+
+// Handler for POST /persona/vc/v1beta1/derive
+//
+// Takes a stored credential_id or a full credential, the credentialSubject
+// paths to reveal (e.g. "credentialSubject.age") and the verifier's nonce;
+// a nonce is generated when none is given.
+func (m *vcModule) handleDeriveCredential(w http.ResponseWriter, r *http.Request) {
+	var req bbsDeriveRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+	credential := req.Credential
+	if req.CredentialID != "" {
+		m.chain.mu.RLock()
+		_, stored := m.findCredential(req.CredentialID)
+		if stored != nil {
+			// Copy through JSON so the derivation sees the stored values
+			// as a client would
+			data, _ := json.Marshal(stored)
+			json.Unmarshal(data, &credential)
+		}
+		m.chain.mu.RUnlock()
+		if stored == nil {
+			http.Error(w, "Credential "+req.CredentialID+" not found", http.StatusNotFound)
+			return
+		}
+	}
+	if credential == nil {
+		http.Error(w, "Missing required field: credential_id or credential", http.StatusBadRequest)
+		return
+	}
+	if req.Nonce == "" {
+		nonce := make([]byte, 16)
+		rand.Read(nonce)
+		req.Nonce = base64.RawURLEncoding.EncodeToString(nonce)
+	}
+
+	derived, err := deriveBBSCredential(credential, req.Reveal, req.Nonce)
+	if err != nil {
+		http.Error(w, "Cannot derive credential: "+err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	log.Printf("Derived credential %v revealing %v", credential["id"], req.Reveal)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"credential": derived})
+}
+
+// Handler for POST /persona/vc/v1beta1/derive/verify
+func (m *vcModule) handleVerifyDerivedCredential(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Credential map[string]interface{} `json:"credential"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+	if req.Credential == nil {
+		http.Error(w, "Missing required field: credential", http.StatusBadRequest)
+		return
+	}
+	if !chargeVerifications(w, r, 1) {
+		return
+	}
+
+	result := m.verifyBBSCredential(req.Credential)
+	log.Printf("Verified BBS+ credential from %v: %v", result["issuer"], result["verified"])
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// Handler for GET /persona/vc/v1beta1/bbs/keys/{did}
+func handleBBSKey(w http.ResponseWriter, r *http.Request) {
+	did := mux.Vars(r)["did"]
+	pub := bbsKey(did).Public.Bytes()
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"id":              did + bbsKeyFragment,
+		"type":            bbsKeyType,
+		"controller":      did,
+		"publicKeyBase58": base58.Encode(pub),
+	})
+}
@@ -0,0 +1,444 @@
+// Package bbs implements BBS+ signatures over BLS12-381: a signature over
+// a list of messages from which the holder derives zero-knowledge proofs
+// revealing only some of them. It is the scheme behind the
+// BbsBlsSignature2020 suite but not that suite's encoding, so its
+// signatures and proofs do not interoperate with other implementations. Public keys are G2 points (96 bytes compressed), signatures are
+// (A, e, s) in 112 bytes, and proofs follow Camenisch-Drijvers-Lehmann
+// 2016, made non-interactive with a Fiat-Shamir challenge bound to a
+// verifier nonce.
+//
+// Message generators are hashed to G1 from the public key and message
+// count, so keys carry no per-length parameters.
+package bbs
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+
+	bls12381 "github.com/kilic/bls12-381"
+)
+
+const (
+	g1Size     = 48
+	g2Size     = 96
+	scalarSize = 32
+	// SignatureSize is the encoded size of a signature
+	SignatureSize = g1Size + 2*scalarSize
+)
+
+var (
+	generatorDomain = []byte("PERSONA_BBS_BLS12381G1_XMD:SHA-256_SSWU_RO_")
+	order           = bls12381.NewG1().Q()
+
+	// ErrInvalidSignature is returned when a signature does not verify.
+	ErrInvalidSignature = errors.New("bbs: invalid signature")
+	// ErrInvalidProof is returned when a proof does not verify.
+	ErrInvalidProof = errors.New("bbs: invalid proof")
+)
+
+// PrivateKey is a BBS+ secret key.
+type PrivateKey struct {
+	x      *big.Int
+	Public *PublicKey
+}
+
+// PublicKey is a BBS+ public key.
+type PublicKey struct {
+	w *bls12381.PointG2
+}
+
+// GenerateKey derives a key pair from seed.
+func GenerateKey(seed []byte) *PrivateKey {
+	x := hashToScalar([]byte("BBS_KEYGEN"), seed)
+	if x.Sign() == 0 {
+		x.SetInt64(1)
+	}
+	g2 := bls12381.NewG2()
+	w := g2.New()
+	g2.MulScalarBig(w, g2.One(), x)
+	return &PrivateKey{x: x, Public: &PublicKey{w: w}}
+}
+
+// Bytes returns the compressed public key.
+func (pk *PublicKey) Bytes() []byte {
+	return bls12381.NewG2().ToCompressed(pk.w)
+}
+
+// ParsePublicKey reads a compressed public key.
+func ParsePublicKey(data []byte) (*PublicKey, error) {
+	if len(data) != g2Size {
+		return nil, fmt.Errorf("bbs: public key must be %d bytes", g2Size)
+	}
+	w, err := bls12381.NewG2().FromCompressed(data)
+	if err != nil {
+		return nil, fmt.Errorf("bbs: public key: %v", err)
+	}
+	return &PublicKey{w: w}, nil
+}
+
+// generators returns h0 and h1..hL for L messages under pk.
+func (pk *PublicKey) generators(count int) ([]*bls12381.PointG1, error) {
+	g1 := bls12381.NewG1()
+	base := append(pk.Bytes(), 0, 0, 0, 0)
+	binary.BigEndian.PutUint32(base[len(base)-4:], uint32(count))
+	h := make([]*bls12381.PointG1, count+1)
+	for i := range h {
+		input := append(append([]byte{}, base...), 0, 0, 0, 0)
+		binary.BigEndian.PutUint32(input[len(input)-4:], uint32(i))
+		point, err := g1.HashToCurve(input, generatorDomain)
+		if err != nil {
+			return nil, err
+		}
+		h[i] = point
+	}
+	return h, nil
+}
+
+// hashToScalar maps data to a scalar mod the group order, with a
+// 64-byte digest so the result is close to uniform.
+func hashToScalar(parts ...[]byte) *big.Int {
+	var digest []byte
+	for counter := byte(0); counter < 2; counter++ {
+		h := sha256.New()
+		h.Write([]byte{counter})
+		for _, part := range parts {
+			var length [4]byte
+			binary.BigEndian.PutUint32(length[:], uint32(len(part)))
+			h.Write(length[:])
+			h.Write(part)
+		}
+		digest = h.Sum(digest)
+	}
+	return new(big.Int).Mod(new(big.Int).SetBytes(digest), order)
+}
+
+// messageScalars hashes messages to scalars.
+func messageScalars(messages [][]byte) []*big.Int {
+	scalars := make([]*big.Int, len(messages))
+	for i, message := range messages {
+		scalars[i] = hashToScalar([]byte("BBS_MESSAGE"), message)
+	}
+	return scalars
+}
+
+func randomScalar() (*big.Int, error) {
+	for {
+		k, err := rand.Int(rand.Reader, order)
+		if err != nil {
+			return nil, err
+		}
+		if k.Sign() != 0 {
+			return k, nil
+		}
+	}
+}
+
+func scalarBytes(k *big.Int) []byte {
+	return new(big.Int).Mod(k, order).FillBytes(make([]byte, scalarSize))
+}
+
+func mod(k *big.Int) *big.Int {
+	return k.Mod(k, order)
+}
+
+// commitment computes g1 + h0^s + sum h_i^m_i, the value A is a root of.
+func commitment(h []*bls12381.PointG1, s *big.Int, scalars []*big.Int) *bls12381.PointG1 {
+	g1 := bls12381.NewG1()
+	b := g1.New().Set(g1.One())
+	term := g1.New()
+	g1.Add(b, b, g1.MulScalarBig(term, h[0], s))
+	for i, m := range scalars {
+		g1.Add(b, b, g1.MulScalarBig(term, h[i+1], m))
+	}
+	return b
+}
+
+// Sign signs messages.
+func Sign(priv *PrivateKey, messages [][]byte) ([]byte, error) {
+	if len(messages) == 0 {
+		return nil, errors.New("bbs: nothing to sign")
+	}
+	h, err := priv.Public.generators(len(messages))
+	if err != nil {
+		return nil, err
+	}
+	e, err := randomScalar()
+	if err != nil {
+		return nil, err
+	}
+	s, err := randomScalar()
+	if err != nil {
+		return nil, err
+	}
+	exponent := new(big.Int).Add(priv.x, e)
+	exponent.ModInverse(mod(exponent), order)
+
+	g1 := bls12381.NewG1()
+	a := g1.MulScalarBig(g1.New(), commitment(h, s, messageScalars(messages)), exponent)
+	out := append(g1.ToCompressed(a), scalarBytes(e)...)
+	return append(out, scalarBytes(s)...), nil
+}
+
+func parseSignature(sig []byte) (*bls12381.PointG1, *big.Int, *big.Int, error) {
+	if len(sig) != SignatureSize {
+		return nil, nil, nil, ErrInvalidSignature
+	}
+	a, err := bls12381.NewG1().FromCompressed(sig[:g1Size])
+	if err != nil {
+		return nil, nil, nil, ErrInvalidSignature
+	}
+	e := new(big.Int).SetBytes(sig[g1Size : g1Size+scalarSize])
+	s := new(big.Int).SetBytes(sig[g1Size+scalarSize:])
+	return a, e, s, nil
+}
+
+// Verify checks a signature over messages.
+func Verify(pk *PublicKey, messages [][]byte, sig []byte) error {
+	a, e, s, err := parseSignature(sig)
+	if err != nil {
+		return err
+	}
+	h, err := pk.generators(len(messages))
+	if err != nil {
+		return err
+	}
+	// e(A, w * g2^e) == e(B, g2)
+	g1, g2 := bls12381.NewG1(), bls12381.NewG2()
+	we := g2.MulScalarBig(g2.New(), g2.One(), e)
+	g2.Add(we, we, pk.w)
+	engine := bls12381.NewEngine()
+	engine.AddPair(a, we)
+	engine.AddPairInv(commitment(h, s, messageScalars(messages)), g2.One())
+	if g1.IsZero(a) || !engine.Check() {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// Proof layout: message count (uint16), revealed bitvector, then A', Abar,
+// d, the challenge and the responses for e, r2, r3, s' and each hidden
+// message.
+
+func encodeHeader(count int, revealed map[int]bool) []byte {
+	header := make([]byte, 2+(count+7)/8)
+	binary.BigEndian.PutUint16(header, uint16(count))
+	for i := range revealed {
+		header[2+i/8] |= 1 << (i % 8)
+	}
+	return header
+}
+
+// Revealed returns the message count and the revealed indexes of a proof.
+func Revealed(proof []byte) (int, []int, error) {
+	if len(proof) < 2 {
+		return 0, nil, ErrInvalidProof
+	}
+	count := int(binary.BigEndian.Uint16(proof))
+	if len(proof) < 2+(count+7)/8 {
+		return 0, nil, ErrInvalidProof
+	}
+	var revealed []int
+	for i := 0; i < count; i++ {
+		if proof[2+i/8]&(1<<(i%8)) != 0 {
+			revealed = append(revealed, i)
+		}
+	}
+	return count, revealed, nil
+}
+
+func challenge(points [][]byte, revealed []int, scalars []*big.Int, nonce []byte) *big.Int {
+	parts := append([][]byte{[]byte("BBS_CHALLENGE")}, points...)
+	for _, i := range revealed {
+		var index [4]byte
+		binary.BigEndian.PutUint32(index[:], uint32(i))
+		parts = append(parts, index[:], scalarBytes(scalars[i]))
+	}
+	return hashToScalar(append(parts, nonce)...)
+}
+
+// DeriveProof proves knowledge of a signature over messages while
+// revealing only the messages at the revealed indexes. nonce binds the
+// proof to one verifier request.
+func DeriveProof(pk *PublicKey, messages [][]byte, sig []byte, revealed []int, nonce []byte) ([]byte, error) {
+	if err := Verify(pk, messages, sig); err != nil {
+		return nil, err
+	}
+	a, e, s, _ := parseSignature(sig)
+	h, err := pk.generators(len(messages))
+	if err != nil {
+		return nil, err
+	}
+	scalars := messageScalars(messages)
+	isRevealed := map[int]bool{}
+	for _, i := range revealed {
+		if i < 0 || i >= len(messages) {
+			return nil, fmt.Errorf("bbs: revealed index %d out of range", i)
+		}
+		isRevealed[i] = true
+	}
+
+	random := func(n int) ([]*big.Int, error) {
+		out := make([]*big.Int, n)
+		for i := range out {
+			k, err := randomScalar()
+			if err != nil {
+				return nil, err
+			}
+			out[i] = k
+		}
+		return out, nil
+	}
+	blinds, err := random(6)
+	if err != nil {
+		return nil, err
+	}
+	r1, r2, eTilde, r2Tilde, r3Tilde, sTilde := blinds[0], blinds[1], blinds[2], blinds[3], blinds[4], blinds[5]
+	var hidden []int
+	for i := range messages {
+		if !isRevealed[i] {
+			hidden = append(hidden, i)
+		}
+	}
+	mTilde, err := random(len(hidden))
+	if err != nil {
+		return nil, err
+	}
+
+	g1 := bls12381.NewG1()
+	term := g1.New()
+	b := commitment(h, s, scalars)
+	r3 := new(big.Int).ModInverse(r1, order)
+	// A' = A^r1, Abar = A'^-e * B^r1, d = B^r1 * h0^-r2, s' = s - r2*r3
+	aPrime := g1.MulScalarBig(g1.New(), a, r1)
+	bR1 := g1.MulScalarBig(g1.New(), b, r1)
+	aBar := g1.MulScalarBig(g1.New(), aPrime, mod(new(big.Int).Neg(e)))
+	g1.Add(aBar, aBar, bR1)
+	d := g1.MulScalarBig(g1.New(), h[0], mod(new(big.Int).Neg(r2)))
+	g1.Add(d, d, bR1)
+	sPrime := mod(new(big.Int).Sub(s, new(big.Int).Mul(r2, r3)))
+
+	// C1 = A'^-e~ * h0^r2~
+	c1 := g1.MulScalarBig(g1.New(), aPrime, mod(new(big.Int).Neg(eTilde)))
+	g1.Add(c1, c1, g1.MulScalarBig(term, h[0], r2Tilde))
+	// C2 = d^r3~ * h0^-s~ * prod hidden h_j^-m~_j
+	c2 := g1.MulScalarBig(g1.New(), d, r3Tilde)
+	g1.Add(c2, c2, g1.MulScalarBig(term, h[0], mod(new(big.Int).Neg(sTilde))))
+	for k, j := range hidden {
+		g1.Add(c2, c2, g1.MulScalarBig(term, h[j+1], mod(new(big.Int).Neg(mTilde[k]))))
+	}
+
+	points := [][]byte{g1.ToCompressed(aPrime), g1.ToCompressed(aBar), g1.ToCompressed(d), g1.ToCompressed(c1), g1.ToCompressed(c2)}
+	revealedSorted := make([]int, 0, len(isRevealed))
+	for i := range messages {
+		if isRevealed[i] {
+			revealedSorted = append(revealedSorted, i)
+		}
+	}
+	c := challenge(points, revealedSorted, scalars, nonce)
+	respond := func(blind, secret *big.Int) []byte {
+		return scalarBytes(new(big.Int).Add(blind, new(big.Int).Mul(c, secret)))
+	}
+
+	var proof bytes.Buffer
+	proof.Write(encodeHeader(len(messages), isRevealed))
+	proof.Write(points[0])
+	proof.Write(points[1])
+	proof.Write(points[2])
+	proof.Write(scalarBytes(c))
+	proof.Write(respond(eTilde, e))
+	proof.Write(respond(r2Tilde, r2))
+	proof.Write(respond(r3Tilde, r3))
+	proof.Write(respond(sTilde, sPrime))
+	for k, j := range hidden {
+		proof.Write(respond(mTilde[k], scalars[j]))
+	}
+	return proof.Bytes(), nil
+}
+
+// VerifyProof checks a derived proof against the revealed messages, in
+// index order, and the nonce it was derived for.
+func VerifyProof(pk *PublicKey, proof []byte, revealedMessages [][]byte, nonce []byte) error {
+	count, revealed, err := Revealed(proof)
+	if err != nil {
+		return err
+	}
+	if len(revealed) != len(revealedMessages) {
+		return fmt.Errorf("bbs: proof reveals %d messages, got %d", len(revealed), len(revealedMessages))
+	}
+	hiddenCount := count - len(revealed)
+	body := proof[2+(count+7)/8:]
+	if len(body) != 3*g1Size+(5+hiddenCount)*scalarSize {
+		return ErrInvalidProof
+	}
+
+	g1, g2 := bls12381.NewG1(), bls12381.NewG2()
+	var points [3]*bls12381.PointG1
+	for i := range points {
+		if points[i], err = g1.FromCompressed(body[i*g1Size : (i+1)*g1Size]); err != nil {
+			return ErrInvalidProof
+		}
+	}
+	aPrime, aBar, d := points[0], points[1], points[2]
+	scalar := func(i int) *big.Int {
+		offset := 3*g1Size + i*scalarSize
+		return new(big.Int).SetBytes(body[offset : offset+scalarSize])
+	}
+	c, eHat, r2Hat, r3Hat, sHat := scalar(0), scalar(1), scalar(2), scalar(3), scalar(4)
+	if g1.IsZero(aPrime) {
+		return ErrInvalidProof
+	}
+
+	h, err := pk.generators(count)
+	if err != nil {
+		return err
+	}
+	scalars := make([]*big.Int, count)
+	isRevealed := map[int]bool{}
+	for k, i := range revealed {
+		scalars[i] = messageScalars(revealedMessages[k : k+1])[0]
+		isRevealed[i] = true
+	}
+	term := g1.New()
+	negC := mod(new(big.Int).Neg(c))
+
+	// C1 = A'^-e^ * h0^r2^ * (Abar/d)^-c
+	c1 := g1.MulScalarBig(g1.New(), aPrime, mod(new(big.Int).Neg(eHat)))
+	g1.Add(c1, c1, g1.MulScalarBig(term, h[0], r2Hat))
+	quotient := g1.Sub(g1.New(), aBar, d)
+	g1.Add(c1, c1, g1.MulScalarBig(term, quotient, negC))
+
+	// C2 = d^r3^ * h0^-s^ * prod hidden h_j^-m^_j * (g1 * prod revealed h_i^m_i)^-c
+	c2 := g1.MulScalarBig(g1.New(), d, r3Hat)
+	g1.Add(c2, c2, g1.MulScalarBig(term, h[0], mod(new(big.Int).Neg(sHat))))
+	disclosed := g1.New().Set(g1.One())
+	k := 0
+	for i := 0; i < count; i++ {
+		if isRevealed[i] {
+			g1.Add(disclosed, disclosed, g1.MulScalarBig(term, h[i+1], scalars[i]))
+			continue
+		}
+		mHat := scalar(5 + k)
+		k++
+		g1.Add(c2, c2, g1.MulScalarBig(term, h[i+1], mod(new(big.Int).Neg(mHat))))
+	}
+	g1.Add(c2, c2, g1.MulScalarBig(term, disclosed, negC))
+
+	expected := challenge([][]byte{body[:g1Size], body[g1Size : 2*g1Size], body[2*g1Size : 3*g1Size], g1.ToCompressed(c1), g1.ToCompressed(c2)}, revealed, scalars, nonce)
+	if expected.Cmp(c) != 0 {
+		return ErrInvalidProof
+	}
+
+	// e(A', w) == e(Abar, g2)
+	engine := bls12381.NewEngine()
+	engine.AddPair(aPrime, pk.w)
+	engine.AddPairInv(aBar, g2.One())
+	if !engine.Check() {
+		return ErrInvalidProof
+	}
+	return nil
+}
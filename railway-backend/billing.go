@@ -0,0 +1,673 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"persona-backend/idgen"
+)
+
+// Subscription billing simulation for the billing settings pages. Objects
+// follow Stripe's shapes and status names: subscriptions move through
+// trialing, incomplete, active, past_due, unpaid and canceled; invoices
+// through open, paid, uncollectible and void.
+//
+// A billing cycle lasts BILLING_PERIOD (default 720h). The simulator checks
+// every BILLING_TICK (default 10s) for periods that ended and invoices due
+// for a retry; POST /admin/billing/advance ends every current period at
+// once. Payment succeeds unless the subscription's payment method is one of
+// Stripe's declining test methods (e.g. pm_card_chargeDeclined). A failed
+// invoice is retried every BILLING_RETRY_INTERVAL (default 24h) up to
+// maxPaymentAttempts, after which the invoice is marked uncollectible and
+// the subscription becomes unpaid until an invoice is paid again.
+//
+// Billing events are kept for GET /api/billing/events and delivered as
+// signed Stripe webhooks (see stripewebhooks.go).
+
+const (
+	maxPaymentAttempts = 4
+	maxBillingEvents   = 1000
+)
+
+type billingPlan struct {
+	ID            string `json:"id"`
+	Name          string `json:"name"`
+	Amount        int64  `json:"amount"` // cents per period
+	Currency      string `json:"currency"`
+	Interval      string `json:"interval"`
+	Verifications int    `json:"included_verifications"`
+}
+
+var billingPlans = []billingPlan{
+	{ID: "plan_free", Name: "Free", Amount: 0, Currency: "usd", Interval: "month", Verifications: 100},
+	{ID: "plan_starter", Name: "Starter", Amount: 4900, Currency: "usd", Interval: "month", Verifications: 1000},
+	{ID: "plan_growth", Name: "Growth", Amount: 19900, Currency: "usd", Interval: "month", Verifications: 10000},
+	{ID: "plan_enterprise", Name: "Enterprise", Amount: 99900, Currency: "usd", Interval: "month", Verifications: 100000},
+}
+
+// Stripe test payment methods that decline
+var decliningPaymentMethods = map[string]string{
+	"pm_card_chargeDeclined":                  "card_declined",
+	"pm_card_chargeDeclinedInsufficientFunds": "insufficient_funds",
+	"pm_card_chargeDeclinedExpiredCard":       "expired_card",
+	"pm_card_chargeCustomerFail":              "card_declined",
+}
+
+type subscription struct {
+	ID                 string `json:"id"`
+	Object             string `json:"object"`
+	Customer           string `json:"customer"`
+	Plan               string `json:"plan"`
+	Status             string `json:"status"`
+	PaymentMethod      string `json:"default_payment_method"`
+	CurrentPeriodStart int64  `json:"current_period_start"`
+	CurrentPeriodEnd   int64  `json:"current_period_end"`
+	TrialEnd           int64  `json:"trial_end,omitempty"`
+	CancelAtPeriodEnd  bool   `json:"cancel_at_period_end"`
+	CanceledAt         int64  `json:"canceled_at,omitempty"`
+	LatestInvoice      string `json:"latest_invoice,omitempty"`
+	Created            int64  `json:"created"`
+}
+
+type invoice struct {
+	ID                 string `json:"id"`
+	Object             string `json:"object"`
+	Customer           string `json:"customer"`
+	Subscription       string `json:"subscription"`
+	Status             string `json:"status"`
+	AmountDue          int64  `json:"amount_due"`
+	AmountPaid         int64  `json:"amount_paid"`
+	Currency           string `json:"currency"`
+	PeriodStart        int64  `json:"period_start"`
+	PeriodEnd          int64  `json:"period_end"`
+	AttemptCount       int    `json:"attempt_count"`
+	NextPaymentAttempt int64  `json:"next_payment_attempt,omitempty"`
+	LastPaymentError   string `json:"last_payment_error,omitempty"`
+	Created            int64  `json:"created"`
+	PaidAt             int64  `json:"paid_at,omitempty"`
+}
+
+// stripePlan renders a plan as a Stripe plan object.
+func (p billingPlan) stripePlan() map[string]interface{} {
+	return map[string]interface{}{
+		"id":             p.ID,
+		"object":         "plan",
+		"active":         true,
+		"amount":         p.Amount,
+		"currency":       p.Currency,
+		"interval":       p.Interval,
+		"interval_count": 1,
+		"nickname":       p.Name,
+		"product":        "prod_persona_verifications",
+	}
+}
+
+// MarshalJSON expands the plan and adds the items list, as Stripe does.
+func (s subscription) MarshalJSON() ([]byte, error) {
+	type plain subscription
+	data, err := json.Marshal(plain(s))
+	if err != nil {
+		return nil, err
+	}
+	var out map[string]interface{}
+	json.Unmarshal(data, &out)
+
+	plan, _ := findPlan(s.Plan)
+	out["plan"] = plan.stripePlan()
+	out["items"] = listResponse([]map[string]interface{}{{
+		"object":   "subscription_item",
+		"plan":     plan.stripePlan(),
+		"quantity": 1,
+	}})
+	out["livemode"] = billingLivemode
+	return json.Marshal(out)
+}
+
+// MarshalJSON adds Stripe's derived invoice fields.
+func (inv invoice) MarshalJSON() ([]byte, error) {
+	type plain invoice
+	data, err := json.Marshal(plain(inv))
+	if err != nil {
+		return nil, err
+	}
+	var out map[string]interface{}
+	json.Unmarshal(data, &out)
+
+	out["paid"] = inv.Status == "paid"
+	out["amount_remaining"] = inv.AmountDue - inv.AmountPaid
+	out["livemode"] = billingLivemode
+	return json.Marshal(out)
+}
+
+type billingEvent struct {
+	ID              string      `json:"id"`
+	Object          string      `json:"object"`
+	APIVersion      string      `json:"api_version"`
+	Type            string      `json:"type"`
+	Created         int64       `json:"created"`
+	Data            interface{} `json:"data"`
+	Livemode        bool        `json:"livemode"`
+	PendingWebhooks int         `json:"pending_webhooks"`
+	Request         interface{} `json:"request"`
+}
+
+var (
+	billingPeriod        = durationFromEnv("BILLING_PERIOD", 720*time.Hour)
+	billingRetryInterval = durationFromEnv("BILLING_RETRY_INTERVAL", 24*time.Hour)
+
+	billingMu     sync.Mutex
+	subscriptions = make(map[string]*subscription)
+	invoices      = make(map[string]*invoice)
+	billingEvents []billingEvent
+)
+
+func init() {
+	registerAdminState("billing", dumpBilling, resetBilling)
+}
+
+func dumpBilling() interface{} {
+	billingMu.Lock()
+	defer billingMu.Unlock()
+	subs := make([]*subscription, 0, len(subscriptions))
+	for _, id := range sortedMapKeys(subscriptions) {
+		subs = append(subs, subscriptions[id])
+	}
+	invs := make([]*invoice, 0, len(invoices))
+	for _, id := range sortedMapKeys(invoices) {
+		invs = append(invs, invoices[id])
+	}
+	// Marshal while locked, the billing cycle mutates these in place
+	data, _ := json.Marshal(map[string]interface{}{
+		"subscriptions": subs,
+		"invoices":      invs,
+		"events":        append([]billingEvent{}, billingEvents...),
+	})
+	return json.RawMessage(data)
+}
+
+func resetBilling() {
+	billingMu.Lock()
+	defer billingMu.Unlock()
+	subscriptions = make(map[string]*subscription)
+	invoices = make(map[string]*invoice)
+	billingEvents = nil
+}
+
+func findPlan(id string) (billingPlan, bool) {
+	for _, plan := range billingPlans {
+		if plan.ID == id {
+			return plan, true
+		}
+	}
+	return billingPlan{}, false
+}
+
+// startBilling runs the billing cycle simulator until the process exits.
+func startBilling() {
+	tick := durationFromEnv("BILLING_TICK", 10*time.Second)
+	go func() {
+		ticker := time.NewTicker(tick)
+		defer ticker.Stop()
+		for range ticker.C {
+			billingMu.Lock()
+			runBillingCycle(appClock.Now(), false)
+			billingMu.Unlock()
+		}
+	}()
+}
+
+// runBillingCycle renews subscriptions whose period has ended (all of them
+// when force is set) and retries invoices that are due. Must be called with
+// billingMu held.
+func runBillingCycle(now time.Time, force bool) {
+	// Retries first, so an invoice created by this cycle is not retried
+	// within the same cycle
+	for _, id := range sortedMapKeys(invoices) {
+		inv := invoices[id]
+		if inv.Status != "open" || inv.NextPaymentAttempt == 0 || subscriptions[inv.Subscription].Status == "unpaid" {
+			continue
+		}
+		if force || now.Unix() >= inv.NextPaymentAttempt {
+			attemptPayment(subscriptions[inv.Subscription], inv, now)
+		}
+	}
+
+	for _, id := range sortedMapKeys(subscriptions) {
+		sub := subscriptions[id]
+		// Unpaid and incomplete subscriptions wait for their invoice to be paid
+		if sub.Status == "canceled" || sub.Status == "incomplete" || sub.Status == "unpaid" {
+			continue
+		}
+		if !force && now.Unix() < sub.CurrentPeriodEnd {
+			continue
+		}
+		if sub.CancelAtPeriodEnd {
+			cancelSubscription(sub, now)
+			continue
+		}
+		sub.CurrentPeriodStart = now.Unix()
+		sub.CurrentPeriodEnd = now.Add(billingPeriod).Unix()
+		sub.TrialEnd = 0
+		inv := createInvoice(sub, now)
+		attemptPayment(sub, inv, now)
+	}
+}
+
+func createInvoice(sub *subscription, now time.Time) *invoice {
+	plan, _ := findPlan(sub.Plan)
+	inv := &invoice{
+		ID:           idgen.NewWithPrefix("in"),
+		Object:       "invoice",
+		Customer:     sub.Customer,
+		Subscription: sub.ID,
+		Status:       "open",
+		AmountDue:    plan.Amount,
+		Currency:     plan.Currency,
+		PeriodStart:  sub.CurrentPeriodStart,
+		PeriodEnd:    sub.CurrentPeriodEnd,
+		Created:      now.Unix(),
+	}
+	invoices[inv.ID] = inv
+	sub.LatestInvoice = inv.ID
+	emitBillingEvent("invoice.created", inv)
+	return inv
+}
+
+// attemptPayment charges the subscription's payment method for an open
+// invoice and moves the subscription through the dunning states.
+func attemptPayment(sub *subscription, inv *invoice, now time.Time) {
+	inv.AttemptCount++
+	declineCode, declined := decliningPaymentMethods[sub.PaymentMethod]
+	if sub.PaymentMethod == "" {
+		declineCode, declined = "payment_method_missing", true
+	}
+
+	if !declined || inv.AmountDue == 0 {
+		inv.Status = "paid"
+		inv.AmountPaid = inv.AmountDue
+		inv.PaidAt = now.Unix()
+		inv.NextPaymentAttempt = 0
+		inv.LastPaymentError = ""
+		emitBillingEvent("invoice.payment_succeeded", inv)
+		emitBillingEvent("invoice.paid", inv)
+		setSubscriptionStatus(sub, "active")
+		return
+	}
+
+	inv.LastPaymentError = declineCode
+	firstInvoice := sub.Status == "incomplete"
+	if inv.AttemptCount >= maxPaymentAttempts && !firstInvoice {
+		inv.NextPaymentAttempt = 0
+		inv.Status = "uncollectible"
+		emitBillingEvent("invoice.payment_failed", inv)
+		emitBillingEvent("invoice.marked_uncollectible", inv)
+		setSubscriptionStatus(sub, "unpaid")
+		return
+	}
+
+	emitBillingEvent("invoice.payment_failed", inv)
+	if firstInvoice {
+		// The first invoice is not retried automatically, like Stripe's
+		// incomplete subscriptions; paying it activates the subscription.
+		inv.NextPaymentAttempt = 0
+		setSubscriptionStatus(sub, "incomplete")
+		return
+	}
+	inv.NextPaymentAttempt = now.Add(billingRetryInterval).Unix()
+	if sub.Status != "unpaid" {
+		setSubscriptionStatus(sub, "past_due")
+	}
+}
+
+func setSubscriptionStatus(sub *subscription, status string) {
+	if sub.Status == status {
+		return
+	}
+	previous := sub.Status
+	sub.Status = status
+	if previous == "" {
+		emitBillingEvent("customer.subscription.created", sub)
+		return
+	}
+	emitBillingEvent("customer.subscription.updated", sub)
+}
+
+func cancelSubscription(sub *subscription, now time.Time) {
+	sub.Status = "canceled"
+	sub.CanceledAt = now.Unix()
+	for _, inv := range invoices {
+		if inv.Subscription == sub.ID && inv.Status == "open" {
+			inv.Status = "void"
+			inv.NextPaymentAttempt = 0
+			emitBillingEvent("invoice.voided", inv)
+		}
+	}
+	emitBillingEvent("customer.subscription.deleted", sub)
+}
+
+// emitBillingEvent records an event and delivers it to the webhook URL.
+// Must be called with billingMu held.
+func emitBillingEvent(eventType string, object interface{}) {
+	// Snapshot the object so later changes do not leak into the event
+	data, _ := json.Marshal(object)
+	var snapshot map[string]interface{}
+	json.Unmarshal(data, &snapshot)
+
+	ev := billingEvent{
+		ID:              idgen.NewWithPrefix("evt"),
+		Object:          "event",
+		APIVersion:      stripeAPIVersion,
+		Type:            eventType,
+		Created:         appClock.Now().Unix(),
+		Data:            map[string]interface{}{"object": snapshot},
+		Livemode:        billingLivemode,
+		PendingWebhooks: countWebhookEndpoints(eventType),
+		Request:         map[string]interface{}{"id": nil, "idempotency_key": nil},
+	}
+	billingEvents = append(billingEvents, ev)
+	if len(billingEvents) > maxBillingEvents {
+		billingEvents = billingEvents[len(billingEvents)-maxBillingEvents:]
+	}
+	log.Printf("Billing event %s: %s", ev.ID, eventType)
+
+	sendBillingWebhooks(ev)
+}
+
+func writeBillingError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"error": map[string]interface{}{
+			"type":    "invalid_request_error",
+			"message": message,
+		},
+	})
+}
+
+func writeBillingJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// listResponse wraps items in Stripe's list object shape.
+func listResponse(items interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"object":   "list",
+		"data":     items,
+		"has_more": false,
+	}
+}
+
+// Handler for GET /api/billing/plans
+func handleListPlans(w http.ResponseWriter, r *http.Request) {
+	writeBillingJSON(w, http.StatusOK, listResponse(billingPlans))
+}
+
+// Handler for POST /api/billing/subscriptions
+func handleCreateSubscription(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Customer      string `json:"customer"`
+		Plan          string `json:"plan"`
+		PaymentMethod string `json:"payment_method"`
+		TrialDays     int    `json:"trial_period_days"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeBillingError(w, http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+	if req.Customer == "" {
+		writeBillingError(w, http.StatusBadRequest, "Missing required param: customer")
+		return
+	}
+	if _, ok := findPlan(req.Plan); !ok {
+		writeBillingError(w, http.StatusBadRequest, fmt.Sprintf("No such plan: '%s'", req.Plan))
+		return
+	}
+
+	now := appClock.Now()
+	sub := &subscription{
+		ID:                 idgen.NewWithPrefix("sub"),
+		Object:             "subscription",
+		Customer:           req.Customer,
+		Plan:               req.Plan,
+		PaymentMethod:      req.PaymentMethod,
+		CurrentPeriodStart: now.Unix(),
+		CurrentPeriodEnd:   now.Add(billingPeriod).Unix(),
+		Created:            now.Unix(),
+	}
+
+	billingMu.Lock()
+	subscriptions[sub.ID] = sub
+	if req.TrialDays > 0 {
+		trialEnd := now.Add(time.Duration(req.TrialDays) * 24 * time.Hour).Unix()
+		sub.CurrentPeriodEnd = trialEnd
+		sub.TrialEnd = trialEnd
+		setSubscriptionStatus(sub, "trialing")
+	} else {
+		// Like Stripe, the subscription starts incomplete until its first
+		// invoice is paid
+		setSubscriptionStatus(sub, "incomplete")
+		inv := createInvoice(sub, now)
+		attemptPayment(sub, inv, now)
+	}
+	response := *sub
+	billingMu.Unlock()
+
+	writeBillingJSON(w, http.StatusOK, response)
+}
+
+// Handler for GET /api/billing/subscriptions (optional customer filter)
+func handleListSubscriptions(w http.ResponseWriter, r *http.Request) {
+	customer := r.URL.Query().Get("customer")
+
+	billingMu.Lock()
+	items := []subscription{}
+	for _, id := range sortedMapKeys(subscriptions) {
+		if sub := subscriptions[id]; customer == "" || sub.Customer == customer {
+			items = append(items, *sub)
+		}
+	}
+	billingMu.Unlock()
+
+	writeBillingJSON(w, http.StatusOK, listResponse(items))
+}
+
+// Handler for GET /api/billing/subscriptions/{id}
+func handleGetSubscription(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	billingMu.Lock()
+	sub, ok := subscriptions[id]
+	var response subscription
+	if ok {
+		response = *sub
+	}
+	billingMu.Unlock()
+
+	if !ok {
+		writeBillingError(w, http.StatusNotFound, fmt.Sprintf("No such subscription: '%s'", id))
+		return
+	}
+	writeBillingJSON(w, http.StatusOK, response)
+}
+
+// Handler for POST /api/billing/subscriptions/{id}
+// Updates plan, payment_method and cancel_at_period_end. A new payment
+// method immediately retries the open invoice, like Stripe does.
+func handleUpdateSubscription(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	var req struct {
+		Plan              *string `json:"plan"`
+		PaymentMethod     *string `json:"payment_method"`
+		CancelAtPeriodEnd *bool   `json:"cancel_at_period_end"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeBillingError(w, http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+
+	billingMu.Lock()
+	defer billingMu.Unlock()
+
+	sub, ok := subscriptions[id]
+	if !ok {
+		writeBillingError(w, http.StatusNotFound, fmt.Sprintf("No such subscription: '%s'", id))
+		return
+	}
+	if sub.Status == "canceled" {
+		writeBillingError(w, http.StatusBadRequest, "A canceled subscription can only update its cancellation_details and metadata.")
+		return
+	}
+	if req.Plan != nil {
+		if _, ok := findPlan(*req.Plan); !ok {
+			writeBillingError(w, http.StatusBadRequest, fmt.Sprintf("No such plan: '%s'", *req.Plan))
+			return
+		}
+		sub.Plan = *req.Plan
+	}
+	if req.CancelAtPeriodEnd != nil {
+		sub.CancelAtPeriodEnd = *req.CancelAtPeriodEnd
+	}
+	emitBillingEvent("customer.subscription.updated", sub)
+
+	if req.PaymentMethod != nil {
+		sub.PaymentMethod = *req.PaymentMethod
+		if inv := invoices[sub.LatestInvoice]; inv != nil && (inv.Status == "open" || inv.Status == "uncollectible") {
+			attemptPayment(sub, inv, appClock.Now())
+		}
+	}
+
+	writeBillingJSON(w, http.StatusOK, *sub)
+}
+
+// Handler for DELETE /api/billing/subscriptions/{id} (cancel immediately)
+func handleCancelSubscription(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	billingMu.Lock()
+	defer billingMu.Unlock()
+
+	sub, ok := subscriptions[id]
+	if !ok {
+		writeBillingError(w, http.StatusNotFound, fmt.Sprintf("No such subscription: '%s'", id))
+		return
+	}
+	if sub.Status != "canceled" {
+		cancelSubscription(sub, appClock.Now())
+	}
+	writeBillingJSON(w, http.StatusOK, *sub)
+}
+
+// Handler for GET /api/billing/invoices (optional customer and
+// subscription filters)
+func handleListInvoices(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	billingMu.Lock()
+	items := []invoice{}
+	for _, id := range sortedMapKeys(invoices) {
+		inv := invoices[id]
+		if (q.Get("customer") != "" && inv.Customer != q.Get("customer")) ||
+			(q.Get("subscription") != "" && inv.Subscription != q.Get("subscription")) ||
+			(q.Get("status") != "" && inv.Status != q.Get("status")) {
+			continue
+		}
+		items = append(items, *inv)
+	}
+	billingMu.Unlock()
+
+	writeBillingJSON(w, http.StatusOK, listResponse(items))
+}
+
+// Handler for GET /api/billing/invoices/{id}
+func handleGetInvoice(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	billingMu.Lock()
+	inv, ok := invoices[id]
+	var response invoice
+	if ok {
+		response = *inv
+	}
+	billingMu.Unlock()
+
+	if !ok {
+		writeBillingError(w, http.StatusNotFound, fmt.Sprintf("No such invoice: '%s'", id))
+		return
+	}
+	writeBillingJSON(w, http.StatusOK, response)
+}
+
+// Handler for POST /api/billing/invoices/{id}/pay
+func handlePayInvoice(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	billingMu.Lock()
+	defer billingMu.Unlock()
+
+	inv, ok := invoices[id]
+	if !ok {
+		writeBillingError(w, http.StatusNotFound, fmt.Sprintf("No such invoice: '%s'", id))
+		return
+	}
+	// Uncollectible invoices can still be paid, which reactivates an unpaid
+	// subscription
+	if inv.Status != "open" && inv.Status != "uncollectible" {
+		writeBillingError(w, http.StatusBadRequest, fmt.Sprintf("Invoice is already %s", inv.Status))
+		return
+	}
+	attemptPayment(subscriptions[inv.Subscription], inv, appClock.Now())
+	if inv.Status != "paid" {
+		writeBillingJSON(w, http.StatusPaymentRequired, map[string]interface{}{
+			"error": map[string]interface{}{
+				"type":         "card_error",
+				"code":         "card_declined",
+				"decline_code": inv.LastPaymentError,
+				"message":      "Your card was declined.",
+				"invoice":      *inv,
+			},
+		})
+		return
+	}
+	writeBillingJSON(w, http.StatusOK, *inv)
+}
+
+// Handler for GET /api/billing/events (optional type prefix filter)
+func handleListBillingEvents(w http.ResponseWriter, r *http.Request) {
+	eventType := r.URL.Query().Get("type")
+
+	billingMu.Lock()
+	items := []billingEvent{}
+	// Newest first, like Stripe
+	for i := len(billingEvents) - 1; i >= 0; i-- {
+		if eventType == "" || strings.HasPrefix(billingEvents[i].Type, eventType) {
+			items = append(items, billingEvents[i])
+		}
+	}
+	billingMu.Unlock()
+
+	writeBillingJSON(w, http.StatusOK, listResponse(items))
+}
+
+// Handler for POST /admin/billing/advance
+// Ends every current billing period now and runs all due payment retries.
+func handleAdvanceBilling(w http.ResponseWriter, r *http.Request) {
+	billingMu.Lock()
+	before := len(billingEvents)
+	runBillingCycle(appClock.Now(), true)
+	emitted := len(billingEvents) - before
+	billingMu.Unlock()
+
+	writeBillingJSON(w, http.StatusOK, map[string]interface{}{
+		"advanced_at":    appClock.Now().Unix(),
+		"events_emitted": emitted,
+	})
+}
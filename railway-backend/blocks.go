@@ -0,0 +1,186 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"persona-backend/clock"
+)
+
+// Simulated block production. A background producer advances the height
+// every BLOCK_TIME (default 5s); broadcast txs are assigned to the next
+// block and included when it is produced.
+
+const maxRetainedBlocks = 10000
+
+type block struct {
+	Height       int64
+	Hash         string
+	LastHash     string
+	Time         time.Time
+	TxHashes     []string
+	ProposerAddr string
+}
+
+// latestHeight returns the height of the last produced block.
+func (c *Chain) latestHeight() int64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.info.LatestHeight
+}
+
+// assignToNextBlock queues a tx for the next block and returns that height.
+// Must be called with c.mu held.
+func (c *Chain) assignToNextBlock(txHash string) int64 {
+	c.pendingTxs = append(c.pendingTxs, txHash)
+	return c.info.LatestHeight + 1
+}
+
+// startBlockProducer records the initial block and produces a new one
+// every block time until stopBlockProducer or the process exits.
+func (c *Chain) startBlockProducer() {
+	c.mu.Lock()
+	initial := c.newBlock(c.info.LatestHeight, "", c.now().UTC(), nil)
+	c.blocks[initial.Height] = initial
+	c.info.LatestTime = initial.Time.Format(time.RFC3339)
+	c.lastBlockAt = time.Now()
+	c.producing = true
+	stop := make(chan struct{})
+	c.stopProducer = stop
+	c.mu.Unlock()
+
+	log.Printf("Block producer started for %s: block time %s", c.info.ChainID, c.blockTime)
+	go func() {
+		ticker := time.NewTicker(c.blockTime)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				c.produceBlock()
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// stopBlockProducer stops the block producer of a chain that is going away.
+func (c *Chain) stopBlockProducer() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.stopProducer != nil {
+		close(c.stopProducer)
+		c.stopProducer = nil
+	}
+	c.producing = false
+}
+
+func (c *Chain) produceBlock() *block {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// A manual clock (deterministic mode) moves one block time per block
+	if manual, ok := c.clock.(*clock.Manual); ok {
+		manual.Advance(c.blockTime)
+	}
+
+	var lastHash string
+	if last, ok := c.blocks[c.info.LatestHeight]; ok {
+		lastHash = last.Hash
+	}
+	b := c.newBlock(c.info.LatestHeight+1, lastHash, c.now().UTC(), c.pendingTxs)
+	c.pendingTxs = nil
+
+	c.blocks[b.Height] = b
+	delete(c.blocks, b.Height-maxRetainedBlocks)
+	c.info.LatestHeight = b.Height
+	c.info.LatestTime = b.Time.Format(time.RFC3339)
+	c.lastBlockAt = time.Now()
+	c.publishEvent(c.newBlockEvent(b))
+	return b
+}
+
+func (c *Chain) newBlock(height int64, lastHash string, t time.Time, txHashes []string) *block {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s/%d/%s/%d", c.info.ChainID, height, lastHash, t.UnixNano())
+	for _, txHash := range txHashes {
+		h.Write([]byte(txHash))
+	}
+	if txHashes == nil {
+		txHashes = []string{}
+	}
+	return &block{
+		Height:       height,
+		Hash:         strings.ToUpper(hex.EncodeToString(h.Sum(nil))),
+		LastHash:     lastHash,
+		Time:         t,
+		TxHashes:     txHashes,
+		ProposerAddr: c.info.NodeInfo.ID,
+	}
+}
+
+// blockJSON renders a block in the Tendermint /block response shape.
+func (c *Chain) blockJSON(b *block) map[string]interface{} {
+	return map[string]interface{}{
+		"block_id": map[string]interface{}{
+			"hash": b.Hash,
+		},
+		"block": map[string]interface{}{
+			"header": map[string]interface{}{
+				"chain_id": c.info.ChainID,
+				"height":   fmt.Sprintf("%d", b.Height),
+				"time":     b.Time.Format(time.RFC3339Nano),
+				"last_block_id": map[string]interface{}{
+					"hash": b.LastHash,
+				},
+				"proposer_address": b.ProposerAddr,
+			},
+			"data": map[string]interface{}{
+				"txs":       []string{},
+				"tx_hashes": b.TxHashes,
+			},
+		},
+	}
+}
+
+// Handler for GET /blocks/{height} (also accepts "latest")
+func (c *Chain) handleGetBlock(w http.ResponseWriter, r *http.Request) {
+	heightParam := mux.Vars(r)["height"]
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	height := c.info.LatestHeight
+	if heightParam != "latest" {
+		parsed, err := strconv.ParseInt(heightParam, 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid block height", http.StatusBadRequest)
+			return
+		}
+		height = parsed
+	}
+
+	b, exists := c.blocks[height]
+	if !exists {
+		response := map[string]interface{}{
+			"error":         fmt.Sprintf("height %d is not available", height),
+			"latest_height": c.info.LatestHeight,
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(response)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(c.blockJSON(b))
+}
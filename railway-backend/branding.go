@@ -0,0 +1,188 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"regexp"
+	"sync"
+
+	"github.com/gorilla/mux"
+)
+
+// White-label theming per relying party: colors, logos and custom copy that
+// the frontend's verification pages fetch from GET /api/branding/{rp}.
+// Records come from BRANDING (a JSON array) at startup and are managed
+// through /admin/branding. /admin/reset restores the startup records.
+
+type brandColors struct {
+	Primary    string `json:"primary,omitempty"`
+	Secondary  string `json:"secondary,omitempty"`
+	Accent     string `json:"accent,omitempty"`
+	Background string `json:"background,omitempty"`
+	Text       string `json:"text,omitempty"`
+}
+
+type branding struct {
+	RPID        string      `json:"rp_id"`
+	DisplayName string      `json:"display_name"`
+	Colors      brandColors `json:"colors"`
+	LogoURL     string      `json:"logo_url,omitempty"`
+	LogoDarkURL string      `json:"logo_dark_url,omitempty"`
+	FaviconURL  string      `json:"favicon_url,omitempty"`
+	FontFamily  string      `json:"font_family,omitempty"`
+	// Copy overrides page text by key, e.g. "headline", "cta", "consent"
+	Copy      map[string]string `json:"copy,omitempty"`
+	UpdatedAt int64             `json:"updated_at"`
+}
+
+var hexColor = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6}|[0-9a-fA-F]{8})$`)
+
+func (b *branding) validate() error {
+	if b.RPID == "" {
+		return fmt.Errorf("rp_id is required")
+	}
+	if b.DisplayName == "" {
+		return fmt.Errorf("display_name is required")
+	}
+	for name, color := range map[string]string{
+		"primary":    b.Colors.Primary,
+		"secondary":  b.Colors.Secondary,
+		"accent":     b.Colors.Accent,
+		"background": b.Colors.Background,
+		"text":       b.Colors.Text,
+	} {
+		if color != "" && !hexColor.MatchString(color) {
+			return fmt.Errorf("colors.%s must be a hex color like #1a2b3c", name)
+		}
+	}
+	return nil
+}
+
+var (
+	brandingMu      sync.Mutex
+	brandings       = make(map[string]*branding)
+	startupBranding []*branding
+)
+
+func init() {
+	if raw := os.Getenv("BRANDING"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &startupBranding); err != nil {
+			log.Printf("Invalid BRANDING: %v", err)
+			startupBranding = nil
+		}
+	}
+	resetBranding()
+	registerAdminState("branding", func() interface{} {
+		brandingMu.Lock()
+		defer brandingMu.Unlock()
+		records := make([]branding, 0, len(brandings))
+		for _, rp := range sortedMapKeys(brandings) {
+			records = append(records, *brandings[rp])
+		}
+		return records
+	}, resetBranding)
+}
+
+func resetBranding() {
+	brandingMu.Lock()
+	defer brandingMu.Unlock()
+	brandings = make(map[string]*branding)
+	for _, b := range startupBranding {
+		if err := b.validate(); err != nil {
+			log.Printf("Skipping branding for %q: %v", b.RPID, err)
+			continue
+		}
+		record := *b
+		record.UpdatedAt = appClock.Now().Unix()
+		brandings[b.RPID] = &record
+	}
+}
+
+// lookupBranding returns a copy of the relying party's branding.
+func lookupBranding(rp string) (branding, bool) {
+	brandingMu.Lock()
+	defer brandingMu.Unlock()
+	b, ok := brandings[rp]
+	if !ok {
+		return branding{}, false
+	}
+	return *b, true
+}
+
+// Handler for GET /api/branding/{rp}
+func handleGetBranding(w http.ResponseWriter, r *http.Request) {
+	rp := mux.Vars(r)["rp"]
+
+	b, ok := lookupBranding(rp)
+	w.Header().Set("Content-Type", "application/json")
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"error": "No branding for relying party",
+			"rp_id": rp,
+		})
+		return
+	}
+	w.Header().Set("Cache-Control", "public, max-age=60")
+	json.NewEncoder(w).Encode(map[string]interface{}{"branding": b})
+}
+
+// Handler for GET /admin/branding
+func handleListBranding(w http.ResponseWriter, r *http.Request) {
+	brandingMu.Lock()
+	records := make([]branding, 0, len(brandings))
+	for _, rp := range sortedMapKeys(brandings) {
+		records = append(records, *brandings[rp])
+	}
+	brandingMu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"branding": records})
+}
+
+// Handler for PUT /admin/branding/{rp}
+func handlePutBranding(w http.ResponseWriter, r *http.Request) {
+	var b branding
+	if err := json.NewDecoder(r.Body).Decode(&b); err != nil {
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+	b.RPID = mux.Vars(r)["rp"]
+	if err := b.validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	b.UpdatedAt = appClock.Now().Unix()
+
+	brandingMu.Lock()
+	_, existed := brandings[b.RPID]
+	brandings[b.RPID] = &b
+	brandingMu.Unlock()
+
+	log.Printf("Branding for %s updated", b.RPID)
+	w.Header().Set("Content-Type", "application/json")
+	if !existed {
+		w.WriteHeader(http.StatusCreated)
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{"branding": b})
+}
+
+// Handler for DELETE /admin/branding/{rp}
+func handleDeleteBranding(w http.ResponseWriter, r *http.Request) {
+	rp := mux.Vars(r)["rp"]
+
+	brandingMu.Lock()
+	_, ok := brandings[rp]
+	delete(brandings, rp)
+	brandingMu.Unlock()
+
+	if !ok {
+		http.Error(w, "Branding not found", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"removed": 1})
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Broadcast modes. The mode field of a broadcast picks what the response
+// reports, as on a real node:
+//
+//	BROADCAST_MODE_SYNC   the CheckTx result with the latest committed
+//	                      height (0 when CheckTx rejects the tx). Unlike a
+//	                      real node, a message that fails is reported too,
+//	                      since delivery has already run.
+//	BROADCAST_MODE_ASYNC  only the hash; only a duplicate tx is rejected.
+//	BROADCAST_MODE_BLOCK  waits for the block that includes the tx and
+//	                      returns its height, the delivery result, events
+//	                      and logs (txevents.go).
+//
+// A missing mode is sync; the legacy sync, async and block spellings are
+// accepted too. Txs are delivered on broadcast whatever the mode, so state
+// changes are visible before the block. Block mode gives up after
+// BROADCAST_TIMEOUT (default 10s, CometBFT's timeout_broadcast_tx_commit);
+// on a chain without a block producer (isolated chains) it produces the
+// block itself.
+
+const (
+	broadcastModeSync  = "BROADCAST_MODE_SYNC"
+	broadcastModeAsync = "BROADCAST_MODE_ASYNC"
+	broadcastModeBlock = "BROADCAST_MODE_BLOCK"
+)
+
+var broadcastTimeout = durationFromEnv("BROADCAST_TIMEOUT", 10*time.Second)
+
+// broadcastModeOf returns the broadcast mode of a tx, or false for an
+// unknown one.
+func broadcastModeOf(txData map[string]interface{}) (string, bool) {
+	mode, _ := txData["mode"].(string)
+	switch strings.ToUpper(mode) {
+	case "", broadcastModeSync, "SYNC":
+		return broadcastModeSync, true
+	case broadcastModeAsync, "ASYNC":
+		return broadcastModeAsync, true
+	case broadcastModeBlock, "BLOCK":
+		return broadcastModeBlock, true
+	}
+	return mode, false
+}
+
+// waitForBlock waits until the block at height is produced, producing it
+// when the chain has no block producer. newBlocks is an event subscription
+// taken before the tx was assigned to the block, or nil without a
+// producer.
+func (c *Chain) waitForBlock(height int64, newBlocks <-chan chainEvent, done <-chan struct{}) error {
+	if newBlocks == nil {
+		c.produceBlock()
+		return nil
+	}
+	timeout := time.NewTimer(broadcastTimeout)
+	defer timeout.Stop()
+	want := fmt.Sprintf("%d", height)
+	for {
+		select {
+		case ev, ok := <-newBlocks:
+			if !ok {
+				return fmt.Errorf("event subscription closed")
+			}
+			if ev.Type == "NewBlock" && containsString(ev.Attributes["block.height"], want) {
+				return nil
+			}
+			if c.latestHeight() >= height {
+				return nil
+			}
+		case <-timeout.C:
+			return fmt.Errorf("timed out waiting for tx to be included in a block")
+		case <-done:
+			return fmt.Errorf("request canceled")
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// JSON key casing negotiation. Responses mix snake_case (created_at, Cosmos
+// REST) and camelCase (credentialSubject, W3C and Keplr). A client that
+// sends X-JSON-Casing: camel (or ?casing=camel) gets every JSON response
+// with camelCase keys, and snake the same in snake_case. Without a
+// preference, or with legacy, responses are left exactly as they were.
+//
+// Only keys that are identifiers in the other casing are renamed, so map
+// keys holding data (addresses, denoms, "ip:1.2.3.4") pass through.
+// JSON-LD documents (objects with an @context, such as credentials and DID
+// documents) keep their camelCase terms in snake mode, as their context
+// defines them. Request bodies are not converted, and non-JSON responses,
+// event streams and websockets are never touched.
+
+const casingHeader = "X-JSON-Casing"
+
+var (
+	snakeKey = regexp.MustCompile(`^[a-z][a-z0-9]*(_[a-z0-9]+)+$`)
+	camelKey = regexp.MustCompile(`^[a-z][a-z0-9]*([A-Z][a-zA-Z0-9]*)+$`)
+)
+
+// requestCasing returns the negotiated casing: camel, snake or "" for the
+// legacy mixed casing.
+func requestCasing(r *http.Request) string {
+	casing := r.URL.Query().Get("casing")
+	if casing == "" {
+		casing = r.Header.Get(casingHeader)
+	}
+	switch strings.ToLower(strings.TrimSpace(casing)) {
+	case "camel", "camelcase":
+		return "camel"
+	case "snake", "snake_case":
+		return "snake"
+	}
+	return ""
+}
+
+func snakeToCamel(key string) string {
+	parts := strings.Split(key, "_")
+	for i := 1; i < len(parts); i++ {
+		parts[i] = strings.ToUpper(parts[i][:1]) + parts[i][1:]
+	}
+	return strings.Join(parts, "")
+}
+
+// camelToSnake keeps acronyms together: issuerDID is issuer_did and
+// chainID chain_id.
+func camelToSnake(key string) string {
+	runes := []rune(key)
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			prevLower := i > 0 && !unicode.IsUpper(runes[i-1])
+			nextLower := i > 0 && i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if prevLower || nextLower {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// recase renames the keys of a decoded JSON value.
+func recase(value interface{}, casing string, inJSONLD bool) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if _, ok := v["@context"]; ok {
+			inJSONLD = true
+		}
+		out := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			renamed := key
+			if casing == "camel" && snakeKey.MatchString(key) {
+				renamed = snakeToCamel(key)
+			} else if casing == "snake" && !inJSONLD && camelKey.MatchString(key) {
+				renamed = camelToSnake(key)
+			}
+			if _, taken := v[renamed]; taken && renamed != key {
+				// Both spellings are present; keep each as is
+				renamed = key
+			}
+			out[renamed] = recase(item, casing, inJSONLD)
+		}
+		return out
+	case []interface{}:
+		for i, item := range v {
+			v[i] = recase(item, casing, inJSONLD)
+		}
+		return v
+	}
+	return value
+}
+
+// casingWriter buffers JSON responses to rename their keys, and passes
+// anything else straight through.
+type casingWriter struct {
+	http.ResponseWriter
+	casing  string
+	status  int
+	decided bool
+	buffer  *bytes.Buffer
+}
+
+func (cw *casingWriter) decide() {
+	if cw.decided {
+		return
+	}
+	cw.decided = true
+	contentType := cw.Header().Get("Content-Type")
+	if strings.HasPrefix(contentType, "application/json") || strings.Contains(contentType, "+json") {
+		cw.buffer = &bytes.Buffer{}
+	}
+}
+
+func (cw *casingWriter) WriteHeader(status int) {
+	cw.decide()
+	if cw.buffer == nil {
+		cw.ResponseWriter.WriteHeader(status)
+		return
+	}
+	cw.status = status
+}
+
+func (cw *casingWriter) Write(p []byte) (int, error) {
+	cw.decide()
+	if cw.buffer == nil {
+		return cw.ResponseWriter.Write(p)
+	}
+	return cw.buffer.Write(p)
+}
+
+func (cw *casingWriter) Flush() {
+	cw.decide()
+	if cw.buffer != nil {
+		return
+	}
+	if flusher, ok := cw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (cw *casingWriter) finish() {
+	if cw.buffer == nil {
+		return
+	}
+	body := cw.buffer.Bytes()
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err == nil {
+		if recased, err := json.Marshal(recase(value, cw.casing, false)); err == nil {
+			body = append(recased, '\n')
+		}
+	}
+	cw.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	if cw.status != 0 {
+		cw.ResponseWriter.WriteHeader(cw.status)
+	}
+	cw.ResponseWriter.Write(body)
+}
+
+func casingMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		casing := requestCasing(r)
+		if casing == "" || r.Header.Get("Upgrade") != "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Set(casingHeader, casing)
+		w.Header().Add("Vary", casingHeader)
+		cw := &casingWriter{ResponseWriter: w, casing: casing}
+		next.ServeHTTP(cw, r)
+		cw.finish()
+	})
+}
@@ -0,0 +1,463 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"persona-backend/clock"
+)
+
+// Chain is one simulated persona network: chain info, blocks, stored txs,
+// the message registry and an instance of every registered module.
+
+type ChainConfig struct {
+	ChainID       string
+	InitialHeight int64
+	BlockTime     time.Duration
+	NodeInfo      NodeInfo
+	// Denom is the staking and fee denom, uprsn by default
+	Denom string
+	// Clock defaults to the wall clock
+	Clock clock.Clock
+	// Isolated chains (throwaway chains such as the compat selftest's) skip
+	// the server-wide fault injection, abuse tracking and dual-write
+	Isolated bool
+}
+
+type Chain struct {
+	// mu guards all chain state, including module stores. Message handlers
+	// run with it held for writing; query handlers hold it for reading.
+	mu sync.RWMutex
+
+	info      MockChainInfo
+	blockTime time.Duration
+	denom     string
+	clock     clock.Clock
+	isolated  bool
+
+	msgs         *MsgRegistry
+	modules      []Module
+	moduleByName map[string]Module
+
+	// Stored txs and their search indexes (values are normalized hashes)
+	txsByHash   map[string]*storedTx
+	txSeq       int
+	txsBySender map[string][]string
+	txsByAction map[string][]string
+	txsByHeight map[int64][]string
+
+	// Produced blocks and txs waiting for the next one
+	blocks     map[int64]*block
+	pendingTxs []string
+	// producing is set once the block producer runs; closing stopProducer
+	// stops it
+	producing    bool
+	stopProducer chan struct{}
+	// Numbers activity events, see activity.go
+	activitySeq int64
+	// lastBlockAt is the wall time the last block was produced, so a
+	// stalled producer shows even when the chain clock is manual
+	lastBlockAt time.Time
+
+	// Event bus subscribers, guarded by eventsMu rather than mu
+	eventsMu         sync.Mutex
+	subscribers      map[int]chan chainEvent
+	nextSubscriberID int
+	// The most recent activity events and every event per subject, also
+	// guarded by eventsMu
+	recentActivity    []activityEvent
+	activityBySubject map[string][]activityEvent
+
+	// In-memory snapshots, see checkpoint.go
+	checkpoints checkpointStore
+}
+
+// NewChain creates a chain with a fresh instance of every registered module.
+func NewChain(cfg ChainConfig) *Chain {
+	if cfg.Clock == nil {
+		cfg.Clock = clock.System
+	}
+	if cfg.Denom == "" {
+		cfg.Denom = "uprsn"
+	}
+	c := &Chain{
+		info: MockChainInfo{
+			ChainID:      cfg.ChainID,
+			LatestHeight: cfg.InitialHeight,
+			LatestTime:   cfg.Clock.Now().Format(time.RFC3339),
+			NodeInfo:     cfg.NodeInfo,
+		},
+		blockTime:    cfg.BlockTime,
+		denom:        cfg.Denom,
+		clock:        cfg.Clock,
+		isolated:     cfg.Isolated,
+		msgs:         NewMsgRegistry(),
+		moduleByName: make(map[string]Module),
+		txsByHash:    make(map[string]*storedTx),
+		txsBySender:  make(map[string][]string),
+		txsByAction:  make(map[string][]string),
+		txsByHeight:  make(map[int64][]string),
+		blocks:       make(map[int64]*block),
+		subscribers:  make(map[int]chan chainEvent),
+
+		activityBySubject: make(map[string][]activityEvent),
+	}
+	for _, factory := range moduleFactories {
+		m := factory(c)
+		m.RegisterMsgs(c.msgs)
+		c.modules = append(c.modules, m)
+		c.moduleByName[m.Name()] = m
+	}
+	return c
+}
+
+// ChainID returns the chain's ID.
+func (c *Chain) ChainID() string {
+	return c.info.ChainID
+}
+
+// now returns the chain's current time.
+func (c *Chain) now() time.Time {
+	return c.clock.Now()
+}
+
+// Module returns the named module, or nil.
+func (c *Chain) Module(name string) Module {
+	return c.moduleByName[name]
+}
+
+func (c *Chain) auth() *authModule {
+	return c.moduleByName["auth"].(*authModule)
+}
+
+func (c *Chain) bank() *bankModule {
+	return c.moduleByName["bank"].(*bankModule)
+}
+
+func (c *Chain) did() *didModule {
+	return c.moduleByName["did"].(*didModule)
+}
+
+func (c *Chain) vc() *vcModule {
+	return c.moduleByName["vc"].(*vcModule)
+}
+
+func (c *Chain) zk() *zkModule {
+	return c.moduleByName["zk"].(*zkModule)
+}
+
+// RegisterRoutes mounts the core chain routes and every module's routes.
+func (c *Chain) RegisterRoutes(r *mux.Router) {
+	// Status endpoint - mimics Cosmos SDK status
+	r.HandleFunc("/status", c.handleStatus).Methods("GET")
+
+	// Simulated blocks
+	r.HandleFunc("/blocks/{height}", c.handleGetBlock).Methods("GET", "OPTIONS")
+
+	// Node info endpoint
+	r.HandleFunc("/node_info", c.handleNodeInfo).Methods("GET")
+
+	// Keplr suggestChain info
+	r.HandleFunc("/chain-info", c.handleChainInfo).Methods("GET", "OPTIONS")
+
+	// Tendermint RPC event subscriptions
+	r.HandleFunc("/websocket", c.handleWebsocket).Methods("GET")
+
+	// Identity activity feed (Server-Sent Events)
+	r.HandleFunc("/events", c.handleActivityStream).Methods("GET")
+
+	// Mock transaction broadcast
+	r.HandleFunc("/cosmos/tx/v1beta1/txs", c.handleBroadcastTx).Methods("POST", "OPTIONS")
+	r.HandleFunc("/cosmos/tx/v1beta1/txs", c.handleSearchTxs).Methods("GET")
+	r.HandleFunc("/cosmos/tx/v1beta1/txs/{hash}", c.handleGetTx).Methods("GET", "OPTIONS")
+
+	for _, m := range c.modules {
+		m.RegisterRoutes(r)
+	}
+}
+
+func (c *Chain) handleBroadcastTx(w http.ResponseWriter, r *http.Request) {
+	var msgs []interface{}
+	var txData map[string]interface{}
+	var decodeErr *txError
+
+	// Read the request body to extract the messages and signer sequence
+	body, err := io.ReadAll(r.Body)
+	if err == nil {
+		if json.Unmarshal(body, &txData) == nil {
+			decodeErr = decodeProtoTx(txData)
+			msgs = extractMessages(txData)
+		}
+	}
+	mode, ok := broadcastModeOf(txData)
+	if !ok {
+		writeGRPCError(w, http.StatusBadRequest, 3, fmt.Sprintf("invalid broadcast mode %q", mode))
+		return
+	}
+	txHash := computeTxHash(body)
+	// Pairing checks run on the proving pool, not under the chain lock
+	var proofs map[int]error
+	var proofErr *txError
+	if decodeErr == nil {
+		proofs, proofErr = preverifyProofs(msgs)
+	}
+
+	c.mu.Lock()
+	// Like a real node, the same tx bytes cannot be broadcast twice
+	if c.txsByHash[txHash] != nil {
+		c.mu.Unlock()
+		response := MockTxResponse{
+			TxHash:    txHash,
+			Height:    0,
+			Code:      codeTxInMempoolCache,
+			Codespace: "sdk",
+			RawLog:    "tx already exists in cache",
+		}
+		log.Printf("Rejected duplicate tx: %s", txHash)
+		writeTxResponse(w, r, response)
+		return
+	}
+
+	// Issuance and proof quotas (quota.go), before the ante handler so a
+	// rejected tx pays no fee. They are counted here, in the same critical
+	// section as the check, and refunded when the tx fails.
+	var charges []quotaCharge
+	var quotaUsed *quotaUsage
+	chargedAt := c.now()
+	if !c.isolated {
+		charges = c.txQuotaCharges(msgs)
+		quotaMu.Lock()
+		exceeded := checkQuotas(charges, chargedAt)
+		if exceeded == nil {
+			quotaUsed = consumeQuotas(charges, chargedAt)
+		}
+		quotaMu.Unlock()
+		if exceeded != nil {
+			c.mu.Unlock()
+			log.Printf("Rejected tx %s: %s quota exceeded for %s", txHash, exceeded.Kind, exceeded.Subject)
+			writeQuotaExceeded(w, *exceeded, c.now())
+			return
+		}
+	}
+
+	// Ante handler: undecodable tx_bytes, a full proving pool, injected
+	// faults, the signatures, the fee and the signers' sequences are
+	// checked, and the fee deducted, before anything runs. Rejected txs are
+	// not stored.
+	txErr := decodeErr
+	if txErr == nil {
+		txErr = proofErr
+	}
+	if txErr == nil {
+		txErr = c.checkTx(txData, msgs)
+	}
+	if txErr != nil {
+		c.mu.Unlock()
+		quotaMu.Lock()
+		refundQuotas(charges, chargedAt)
+		quotaMu.Unlock()
+		response := MockTxResponse{
+			TxHash:    txHash,
+			Height:    0,
+			Code:      txErr.Code,
+			Codespace: txErr.Codespace,
+			RawLog:    txErr.Log,
+		}
+		log.Printf("Rejected tx: %s", response.RawLog)
+		if mode == broadcastModeAsync {
+			// Async returns before CheckTx runs
+			response = MockTxResponse{TxHash: txHash}
+		}
+		writeTxResponse(w, r, response)
+		return
+	}
+
+	// Block mode listens for the block before the tx can land in it
+	var newBlocks <-chan chainEvent
+	if mode == broadcastModeBlock && c.producing {
+		var unsubscribe func()
+		newBlocks, unsubscribe = c.subscribeEvents()
+		defer unsubscribe()
+	}
+	height := c.assignToNextBlock(txHash)
+	txIndex := len(c.pendingTxs) - 1
+	ctx := &msgContext{TxHash: txHash, Height: height, Residency: requestResidency(r), TraceID: requestTraceID(r), Proofs: proofs}
+	msgIndex, txErr := c.deliverMsgs(ctx, msgs)
+
+	// Build the tx response (code 0 unless a message failed)
+	response := MockTxResponse{
+		TxHash: txHash,
+		Height: height,
+		Code:   codeOK,
+		Data:   "",
+	}
+	if txErr == nil {
+		response.Events = ctx.Events
+		response.Logs = txLogs(ctx.Events, len(msgs))
+	} else {
+		response.Code = txErr.Code
+		response.Codespace = txErr.Codespace
+		response.RawLog = fmt.Sprintf("failed to execute message; message index: %d: %s", msgIndex, txErr.Log)
+		log.Printf("Rejected tx: %s", response.RawLog)
+		quotaMu.Lock()
+		refundQuotas(charges, chargedAt)
+		quotaMu.Unlock()
+		if !c.isolated && txErr.Code == codeUnauthorized {
+			abuse.recordFailure(txSigner(msgs), abuseAuth)
+		}
+	}
+	tx := c.recordTx(response, msgs)
+	tx.Residency = ctx.Residency
+	tx.TraceID = ctx.TraceID
+	c.mu.Unlock()
+	recordTrace(ctx.TraceID, func(t *traceRecord) { t.TxHashes = append(t.TxHashes, txHash) })
+
+	if response.Code == codeOK {
+		if quotaUsed != nil {
+			setQuotaHeaders(w, *quotaUsed, c.now())
+		}
+		c.publishEvent(txEvent(tx, txIndex, txBytesOf(body)))
+		c.publishActivity(ctx.Activity)
+	}
+	if !c.isolated {
+		forwardDualWrite(body, response)
+	}
+
+	switch mode {
+	case broadcastModeAsync:
+		response = MockTxResponse{TxHash: txHash}
+	case broadcastModeSync:
+		// Delivery already ran, so a failed message is reported here
+		// instead of passing CheckTx and failing silently. The height is
+		// the latest committed one, as the frontend has always seen it.
+		response = MockTxResponse{
+			TxHash:    txHash,
+			Height:    c.latestHeight(),
+			Code:      response.Code,
+			Codespace: response.Codespace,
+			RawLog:    response.RawLog,
+		}
+	case broadcastModeBlock:
+		if err := c.waitForBlock(height, newBlocks, r.Context().Done()); err != nil {
+			writeGRPCError(w, http.StatusServiceUnavailable, 14, err.Error())
+			return
+		}
+	}
+	writeTxResponse(w, r, response)
+}
+
+// checkTx runs the ante checks. Must be called with c.mu held.
+func (c *Chain) checkTx(txData map[string]interface{}, msgs []interface{}) *txError {
+	signer := txSigner(msgs)
+	if !c.isolated {
+		if txErr := abuse.checkBlocked(signer); txErr != nil {
+			return txErr
+		}
+		if txErr := faults.inject(c, msgs, signer); txErr != nil {
+			return txErr
+		}
+	}
+	strict := strictSigning.Enabled() && !c.isolated
+	if strict {
+		// A message without a signer would run unauthorized
+		for i, m := range msgs {
+			if msg, _ := m.(map[string]interface{}); msg == nil || msgSigner(msg) == "" {
+				return txErrorf(codeNoSignatures, "message %d has no signer", i)
+			}
+		}
+	}
+	if signer == "" {
+		return nil
+	}
+	fee, err := txFee(txData)
+	if err != nil {
+		return txErrorf(codeTxDecode, "%v", err)
+	}
+	signers := txSigners(msgs)
+	txErr := func() *txError {
+		for i, s := range signers {
+			sequence, hasSequence := txSignerSequence(txData, i)
+			if strict {
+				if txErr := c.auth().verifySignature(txData, msgs, s, i, sequence, hasSequence); txErr != nil {
+					return txErr
+				}
+			}
+			if i == 0 {
+				if txErr := c.bank().checkFee(signer, fee); txErr != nil {
+					return txErr
+				}
+			}
+			if txErr := c.auth().checkSequence(s, sequence, hasSequence); txErr != nil {
+				return txErr
+			}
+		}
+		return nil
+	}()
+	if txErr != nil {
+		// Fee errors are not auth failures
+		if !c.isolated && (txErr.Code == codeUnauthorized || txErr.Code == codeWrongSequence) {
+			abuse.recordFailure(signer, abuseAuth)
+		}
+		return txErr
+	}
+	// Every check passed, so nothing is charged for a rejected tx
+	for _, s := range signers {
+		c.auth().store.account(s).Sequence++
+	}
+	c.bank().deductFee(signer, fee)
+	return nil
+}
+
+// extractMessages handles both the direct msgs format and the nested
+// tx.body.messages format.
+func extractMessages(txData map[string]interface{}) []interface{} {
+	if directMsgs, ok := txData["msgs"].([]interface{}); ok {
+		return directMsgs
+	}
+	if tx, ok := txData["tx"].(map[string]interface{}); ok {
+		if body, ok := tx["body"].(map[string]interface{}); ok {
+			if nestedMsgs, ok := body["messages"].([]interface{}); ok {
+				return nestedMsgs
+			}
+		}
+	}
+	return nil
+}
+
+func (c *Chain) handleStatus(w http.ResponseWriter, r *http.Request) {
+	// Height advances with the block producer
+	c.mu.RLock()
+	var latestHash string
+	if b, ok := c.blocks[c.info.LatestHeight]; ok {
+		latestHash = b.Hash
+	}
+	response := map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"result": map[string]interface{}{
+			"node_info": c.info.NodeInfo,
+			"sync_info": map[string]interface{}{
+				"latest_block_hash":   latestHash,
+				"latest_block_height": fmt.Sprintf("%d", c.info.LatestHeight),
+				"latest_block_time":   c.info.LatestTime,
+				"catching_up":         false,
+			},
+		},
+	}
+	c.mu.RUnlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (c *Chain) handleNodeInfo(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(c.info.NodeInfo)
+}
@@ -0,0 +1,322 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/rand"
+	"net"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// Chaos middleware for validating frontend timeouts, retries and loading
+// states. Each rule targets a route pattern ("*" matches any run of
+// characters, e.g. /persona/did/*) and can add latency, fail a fraction of
+// requests with an HTTP error, reset the connection, or drip the response
+// out slowly. Rules come from CHAOS_RULES (a JSON array) at startup and are
+// managed through /admin/chaos. Admin routes are never affected, so chaos
+// can always be switched off again.
+
+type chaosRule struct {
+	ID string `json:"id"`
+	// Route is the path pattern the rule applies to
+	Route string `json:"route"`
+	// Methods restricts the rule to these HTTP methods; empty means all
+	Methods []string `json:"methods,omitempty"`
+	// DelayMs plus a random 0..JitterMs is added before handling
+	DelayMs  int `json:"delay_ms,omitempty"`
+	JitterMs int `json:"jitter_ms,omitempty"`
+	// ErrorRate of requests get ErrorStatus (default 500) instead
+	ErrorRate   float64 `json:"error_rate,omitempty"`
+	ErrorStatus int     `json:"error_status,omitempty"`
+	// ResetRate of requests have their connection reset without a response
+	ResetRate float64 `json:"reset_rate,omitempty"`
+	// DripBytes > 0 writes the response DripBytes at a time, pausing
+	// DripIntervalMs (default 100) between chunks
+	DripBytes      int `json:"drip_bytes,omitempty"`
+	DripIntervalMs int `json:"drip_interval_ms,omitempty"`
+	Hits           int `json:"hits"`
+
+	pattern *regexp.Regexp
+}
+
+type chaosEngine struct {
+	mu     sync.Mutex
+	rules  []*chaosRule
+	nextID int
+}
+
+var chaos = newChaosEngineFromEnv()
+
+func newChaosEngineFromEnv() *chaosEngine {
+	e := &chaosEngine{}
+	if raw := os.Getenv("CHAOS_RULES"); raw != "" {
+		var rules []*chaosRule
+		if err := json.Unmarshal([]byte(raw), &rules); err != nil {
+			log.Printf("Invalid CHAOS_RULES: %v", err)
+		}
+		for _, rule := range rules {
+			if err := e.add(rule); err != nil {
+				log.Printf("Skipping chaos rule: %v", err)
+			}
+		}
+	}
+	return e
+}
+
+// routePattern compiles a route pattern where "*" matches anything.
+func routePattern(route string) (*regexp.Regexp, error) {
+	if !strings.HasPrefix(route, "/") {
+		return nil, fmt.Errorf("route must start with /")
+	}
+	quoted := regexp.QuoteMeta(route)
+	return regexp.Compile("^" + strings.ReplaceAll(quoted, `\*`, ".*") + "$")
+}
+
+func (e *chaosEngine) add(rule *chaosRule) error {
+	pattern, err := routePattern(rule.Route)
+	if err != nil {
+		return err
+	}
+	for _, rate := range []float64{rule.ErrorRate, rule.ResetRate} {
+		if rate < 0 || rate > 1 {
+			return fmt.Errorf("rates must be between 0 and 1")
+		}
+	}
+	if rule.DelayMs < 0 || rule.JitterMs < 0 || rule.DripBytes < 0 || rule.DripIntervalMs < 0 {
+		return fmt.Errorf("delays and sizes must not be negative")
+	}
+	if rule.ErrorStatus == 0 {
+		rule.ErrorStatus = http.StatusInternalServerError
+	}
+	if rule.DripBytes > 0 && rule.DripIntervalMs == 0 {
+		rule.DripIntervalMs = 100
+	}
+	for i, method := range rule.Methods {
+		rule.Methods[i] = strings.ToUpper(method)
+	}
+	rule.pattern = pattern
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.nextID++
+	rule.ID = strconv.Itoa(e.nextID)
+	rule.Hits = 0
+	e.rules = append(e.rules, rule)
+	log.Printf("Chaos rule %s added for %s", rule.ID, rule.Route)
+	return nil
+}
+
+// match returns a copy of the first rule applying to the request.
+func (e *chaosEngine) match(r *http.Request) *chaosRule {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, rule := range e.rules {
+		if !rule.pattern.MatchString(r.URL.Path) {
+			continue
+		}
+		if len(rule.Methods) > 0 && !containsString(rule.Methods, r.Method) {
+			continue
+		}
+		rule.Hits++
+		matched := *rule
+		return &matched
+	}
+	return nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func chaosMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/admin/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+		rule := chaos.match(r)
+		if rule == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if !sleepWithJitter(r, rule.DelayMs, rule.JitterMs) {
+			return
+		}
+
+		if rule.ResetRate > 0 && rand.Float64() < rule.ResetRate {
+			resetConnection(w)
+			return
+		}
+
+		if rule.ErrorRate > 0 && rand.Float64() < rule.ErrorRate {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(rule.ErrorStatus)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"error": fmt.Sprintf("chaos: injected %d (rule %s)", rule.ErrorStatus, rule.ID),
+			})
+			return
+		}
+
+		// Websocket upgrades need the real ResponseWriter
+		if rule.DripBytes > 0 && r.Header.Get("Upgrade") == "" {
+			drip := &dripWriter{header: w.Header(), status: http.StatusOK}
+			next.ServeHTTP(drip, r)
+			drip.flushTo(w, r, rule.DripBytes, time.Duration(rule.DripIntervalMs)*time.Millisecond)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// sleepWithJitter waits delayMs plus a random 0..jitterMs. It returns false
+// if the client went away meanwhile.
+func sleepWithJitter(r *http.Request, delayMs, jitterMs int) bool {
+	delay := delayMs
+	if jitterMs > 0 {
+		delay += rand.Intn(jitterMs + 1)
+	}
+	if delay <= 0 {
+		return true
+	}
+	select {
+	case <-time.After(time.Duration(delay) * time.Millisecond):
+		return true
+	case <-r.Context().Done():
+		return false
+	}
+}
+
+// resetConnection drops the client connection without a response. With
+// SO_LINGER 0 the kernel sends a TCP RST, which clients report as
+// "connection reset by peer".
+func resetConnection(w http.ResponseWriter) {
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		panic(http.ErrAbortHandler)
+	}
+	conn, _, err := hijacker.Hijack()
+	if err != nil {
+		panic(http.ErrAbortHandler)
+	}
+	if tcp, ok := conn.(*net.TCPConn); ok {
+		tcp.SetLinger(0)
+	}
+	conn.Close()
+}
+
+// dripWriter buffers a response so it can be written out slowly.
+type dripWriter struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (d *dripWriter) Header() http.Header { return d.header }
+
+func (d *dripWriter) Write(p []byte) (int, error) { return d.body.Write(p) }
+
+func (d *dripWriter) WriteHeader(status int) { d.status = status }
+
+func (d *dripWriter) flushTo(w http.ResponseWriter, r *http.Request, chunk int, interval time.Duration) {
+	// The length is known up front, so clients can show real progress
+	w.Header().Set("Content-Length", strconv.Itoa(d.body.Len()))
+	w.WriteHeader(d.status)
+	flusher, _ := w.(http.Flusher)
+
+	data := d.body.Bytes()
+	for len(data) > 0 {
+		n := chunk
+		if n > len(data) {
+			n = len(data)
+		}
+		if _, err := w.Write(data[:n]); err != nil {
+			return
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		data = data[n:]
+		if len(data) == 0 {
+			break
+		}
+		select {
+		case <-time.After(interval):
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+func (e *chaosEngine) snapshot() []chaosRule {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	rules := make([]chaosRule, 0, len(e.rules))
+	for _, rule := range e.rules {
+		rules = append(rules, *rule)
+	}
+	return rules
+}
+
+// Handler for GET /admin/chaos
+func handleListChaos(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"rules": chaos.snapshot()})
+}
+
+// Handler for POST /admin/chaos
+func handleAddChaos(w http.ResponseWriter, r *http.Request) {
+	var rule chaosRule
+	if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+	if err := chaos.add(&rule); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{"rule": rule})
+}
+
+// Handler for DELETE /admin/chaos and DELETE /admin/chaos/{id}
+func handleDeleteChaos(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	chaos.mu.Lock()
+	removed := 0
+	kept := chaos.rules[:0]
+	for _, rule := range chaos.rules {
+		if id == "" || rule.ID == id {
+			removed++
+			continue
+		}
+		kept = append(kept, rule)
+	}
+	chaos.rules = kept
+	chaos.mu.Unlock()
+
+	if id != "" && removed == 0 {
+		http.Error(w, "Chaos rule not found", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"removed": removed})
+}
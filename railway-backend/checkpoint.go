@@ -0,0 +1,198 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"persona-backend/idgen"
+
+	"github.com/gorilla/mux"
+)
+
+// In-memory snapshots for test isolation: POST /admin/snapshot saves the
+// chain state and returns a snapshot ID, and POST /admin/restore/{id} rolls
+// everything back to it (module stores, so DIDs, credentials, proofs and
+// balances, plus stored txs, blocks, height and the activity history). A
+// Playwright test snapshots in setup and restores in teardown instead of
+// resetting and reseeding. A snapshot can be restored any number of times.
+//
+// Unlike the archives of snapshot.go nothing is compressed or verified, so
+// both directions are cheap. The last maxCheckpoints snapshots are kept;
+// they survive /admin/reset, so a suite can restore its seeded state after
+// a reset. Server-level state (quotas, webhooks, faults) is not included.
+
+var maxCheckpoints = intFromEnv("SNAPSHOT_CHECKPOINTS_MAX", 100)
+
+type chainCheckpoint struct {
+	ID           string `json:"id"`
+	CreatedAt    int64  `json:"created_at"`
+	LatestHeight int64  `json:"latest_height"`
+	Txs          int    `json:"txs"`
+
+	info     MockChainInfo
+	stores   []byte
+	txs      []byte
+	txSeq    int
+	blocks   map[int64]*block
+	pending  []string
+	activity []activityEvent
+	subjects map[string][]activityEvent
+}
+
+// checkpointStore holds a chain's snapshots.
+type checkpointStore struct {
+	mu sync.Mutex
+	// Snapshots by ID, and their IDs oldest first
+	byID  map[string]*chainCheckpoint
+	order []string
+}
+
+func (s *checkpointStore) get(id string) *chainCheckpoint {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.byID[id]
+}
+
+// checkpoint saves the chain state.
+func (c *Chain) checkpoint() *chainCheckpoint {
+	c.mu.RLock()
+	cp := &chainCheckpoint{
+		ID:           idgen.NewWithPrefix("snap"),
+		CreatedAt:    c.now().Unix(),
+		LatestHeight: c.info.LatestHeight,
+		Txs:          len(c.txsByHash),
+		info:         c.info,
+		stores:       c.backupStoresLocked(),
+		txSeq:        c.txSeq,
+		blocks:       make(map[int64]*block, len(c.blocks)),
+		pending:      append([]string(nil), c.pendingTxs...),
+	}
+	// Stored txs are copied through JSON like snapshot archives carry them
+	txs := make([]*storedTx, 0, len(c.txsByHash))
+	for _, tx := range c.txsByHash {
+		txs = append(txs, tx)
+	}
+	sort.Slice(txs, func(i, j int) bool { return txs[i].Seq < txs[j].Seq })
+	cp.txs, _ = json.Marshal(txs)
+	// Blocks are never changed once produced
+	for height, b := range c.blocks {
+		cp.blocks[height] = b
+	}
+	c.mu.RUnlock()
+
+	c.eventsMu.Lock()
+	cp.activity = append([]activityEvent(nil), c.recentActivity...)
+	cp.subjects = make(map[string][]activityEvent, len(c.activityBySubject))
+	for subject, events := range c.activityBySubject {
+		cp.subjects[subject] = append([]activityEvent(nil), events...)
+	}
+	c.eventsMu.Unlock()
+
+	store := &c.checkpoints
+	store.mu.Lock()
+	if store.byID == nil {
+		store.byID = make(map[string]*chainCheckpoint)
+	}
+	store.byID[cp.ID] = cp
+	store.order = append(store.order, cp.ID)
+	if len(store.order) > maxCheckpoints {
+		delete(store.byID, store.order[0])
+		store.order = store.order[1:]
+	}
+	store.mu.Unlock()
+	return cp
+}
+
+// restoreCheckpoint rolls the chain back to a checkpoint.
+func (c *Chain) restoreCheckpoint(cp *chainCheckpoint) error {
+	var txs []*storedTx
+	if err := json.Unmarshal(cp.txs, &txs); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	c.restoreStoresLocked(cp.stores)
+	c.info = cp.info
+	c.txSeq = cp.txSeq
+	c.indexTxsLocked(txs)
+	c.pendingTxs = append([]string(nil), cp.pending...)
+	c.blocks = make(map[int64]*block, len(cp.blocks))
+	for height, b := range cp.blocks {
+		c.blocks[height] = b
+	}
+	c.lastBlockAt = time.Now()
+	c.mu.Unlock()
+
+	c.eventsMu.Lock()
+	c.recentActivity = append([]activityEvent(nil), cp.activity...)
+	c.activityBySubject = make(map[string][]activityEvent, len(cp.subjects))
+	for subject, events := range cp.subjects {
+		c.activityBySubject[subject] = append([]activityEvent(nil), events...)
+	}
+	c.eventsMu.Unlock()
+	verifyCache.Flush()
+	return nil
+}
+
+// Handler for POST /admin/snapshot
+func (c *Chain) handleCreateCheckpoint(w http.ResponseWriter, r *http.Request) {
+	cp := c.checkpoint()
+	log.Printf("Snapshot %s at height %d: %d txs", cp.ID, cp.LatestHeight, cp.Txs)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{"id": cp.ID, "snapshot": cp})
+}
+
+// Handler for POST /admin/restore/{id}
+func (c *Chain) handleRestoreCheckpoint(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	cp := c.checkpoints.get(id)
+	if cp == nil {
+		http.Error(w, "Snapshot "+id+" not found", http.StatusNotFound)
+		return
+	}
+	if err := c.restoreCheckpoint(cp); err != nil {
+		http.Error(w, "Failed to restore snapshot: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	log.Printf("Restored snapshot %s: height %d, %d txs", cp.ID, cp.LatestHeight, cp.Txs)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"restored": cp})
+}
+
+// Handler for GET /admin/snapshots
+func (c *Chain) handleListCheckpoints(w http.ResponseWriter, r *http.Request) {
+	store := &c.checkpoints
+	store.mu.Lock()
+	list := make([]*chainCheckpoint, 0, len(store.order))
+	for _, id := range store.order {
+		list = append(list, store.byID[id])
+	}
+	store.mu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"snapshots": list, "max": maxCheckpoints})
+}
+
+// Handler for DELETE /admin/snapshots/{id}
+func (c *Chain) handleDeleteCheckpoint(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	store := &c.checkpoints
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if store.byID[id] == nil {
+		http.Error(w, "Snapshot "+id+" not found", http.StatusNotFound)
+		return
+	}
+	delete(store.byID, id)
+	for i, existing := range store.order {
+		if existing == id {
+			store.order = append(store.order[:i], store.order[i+1:]...)
+			break
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,293 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// Command line: persona-mock <command> [flags] [args], built with cobra.
+// With no command the daemon serves, as deployments start it. The
+// operational commands (seed, dump, verify-proof, genesis, ...) talk to a
+// running daemon through its admin API, except verify-proof, which checks
+// a Groth16 proof locally unless given --url. "persona-mock help" lists
+// them and "persona-mock <command> --help" shows a command's flags.
+
+// runCLI runs the command in args and reports whether main is done, which
+// it is unless the command is serve.
+func runCLI(args []string) bool {
+	if len(args) == 0 {
+		return false
+	}
+	serve := false
+	root := &cobra.Command{
+		Use:           "persona-mock",
+		Short:         "Persona chain mock daemon and operational commands",
+		Long:          "Persona chain mock daemon and operational commands. With no command the daemon serves. Daemon commands default to --url http://localhost:8080 and --token $ADMIN_TOKEN.",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		CompletionOptions: cobra.CompletionOptions{
+			DisableDefaultCmd: true,
+		},
+	}
+	root.AddCommand(
+		newServeCommand(&serve),
+		newSeedCommand(),
+		newDumpCommand(),
+		newVerifyProofCommand(),
+		newGenesisCommand(),
+		newGenerateClientsCommand(),
+		newPinContractCommand(),
+	)
+	root.SetArgs(args)
+	if cmd, err := root.ExecuteC(); err != nil {
+		if cmd == root {
+			fmt.Fprintf(os.Stderr, "%v\n\n", err)
+			root.Usage()
+			os.Exit(2)
+		}
+		log.Fatalf("%s: %v", cmd.Name(), err)
+	}
+	return !serve
+}
+
+// newServeCommand applies the serve flags over the env vars they stand
+// for and leaves the serving to main.
+func newServeCommand(serve *bool) *cobra.Command {
+	var port, fixtures string
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Run the mock daemon (the default)",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if port != "" {
+				os.Setenv("PORT", port)
+			}
+			fixturesDir = fixtures
+			*serve = true
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&port, "port", os.Getenv("PORT"), "port to listen on (default $PORT, else 8080)")
+	cmd.Flags().StringVar(&fixtures, "fixtures", fixturesDir, "fixture directory seeded at startup (default $FIXTURES_DIR)")
+	return cmd
+}
+
+// daemonFlags adds the --url and --token flags of commands that talk to a
+// running daemon.
+func daemonFlags(cmd *cobra.Command) (url, token *string) {
+	url = cmd.Flags().String("url", "http://localhost:8080", "base URL of the running mock daemon")
+	token = cmd.Flags().String("token", os.Getenv("ADMIN_TOKEN"), "admin token of the daemon (default $ADMIN_TOKEN)")
+	return url, token
+}
+
+// daemonRequest sends a request to a running daemon and returns the body
+// of a 2xx response.
+func daemonRequest(method, url, token, path string, body []byte) ([]byte, error) {
+	req, err := http.NewRequest(method, strings.TrimSuffix(url, "/")+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	req.Header.Set(adminActorHeader, "cli")
+	client := &http.Client{Timeout: time.Minute}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("daemon returned %s: %s", resp.Status, strings.TrimSpace(string(data)))
+	}
+	return data, nil
+}
+
+// newSeedCommand implements "seed": every fixture file of a directory, or
+// a single file, is sent to /admin/v1/seed in load order.
+func newSeedCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "seed <fixtures dir | file>",
+		Short: "Seed a running daemon from fixture files",
+		Args:  cobra.ExactArgs(1),
+	}
+	url, token := daemonFlags(cmd)
+	reset := cmd.Flags().Bool("reset", false, "wipe the daemon's state first")
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		return runSeed(*url, *token, *reset, args[0])
+	}
+	return cmd
+}
+
+func runSeed(url, token string, reset bool, path string) error {
+	paths := []string{path}
+	if info, err := os.Stat(path); err != nil {
+		return err
+	} else if info.IsDir() {
+		files, err := fixtureFiles(path)
+		if err != nil {
+			return err
+		}
+		if len(files) == 0 {
+			return fmt.Errorf("no .json, .yaml or .yml files in %s", path)
+		}
+		paths = paths[:0]
+		for _, name := range files {
+			paths = append(paths, filepath.Join(path, name))
+		}
+	}
+
+	for i, file := range paths {
+		payload, err := readFixture(file)
+		if err != nil {
+			return fmt.Errorf("%s: %v", file, err)
+		}
+		body, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+		endpoint := "/admin/v1/seed"
+		if reset && i == 0 {
+			endpoint += "?reset=true"
+		}
+		data, err := daemonRequest("POST", url, token, endpoint, body)
+		if err != nil {
+			return fmt.Errorf("%s: %v", file, err)
+		}
+		var result seedResult
+		if err := json.Unmarshal(data, &result); err != nil {
+			return fmt.Errorf("%s: daemon returned an invalid seed result: %v", file, err)
+		}
+		fmt.Printf("Seeded %s: %v\n", file, result.Seeded)
+	}
+	return nil
+}
+
+// newDumpCommand implements "dump".
+func newDumpCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "dump",
+		Short: "Dump the state of a running daemon",
+		Args:  cobra.NoArgs,
+	}
+	url, token := daemonFlags(cmd)
+	out := cmd.Flags().String("out", "-", "output file (- for stdout)")
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		return runDump(*url, *token, *out)
+	}
+	return cmd
+}
+
+func runDump(url, token, out string) error {
+	data, err := daemonRequest("GET", url, token, "/admin/v1/state", nil)
+	if err != nil {
+		return err
+	}
+	// Indent, so dumps diff well
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, data, "", "  "); err != nil {
+		return fmt.Errorf("daemon returned invalid JSON: %v", err)
+	}
+	if out == "-" {
+		_, err = os.Stdout.Write(indented.Bytes())
+		return err
+	}
+	if err := os.WriteFile(out, indented.Bytes(), 0o644); err != nil {
+		return err
+	}
+	fmt.Printf("Wrote state dump to %s\n", out)
+	return nil
+}
+
+// newVerifyProofCommand implements "verify-proof". The proof file is a
+// verify request as POST /persona/zk/v1beta1/verify takes it ({circuit_id,
+// proof, public_inputs}). Locally it is checked against --key or the keys
+// of VERIFYING_KEYS_DIR; with --url the daemon verifies it, including any
+// credential_ids. Fails unless the proof verifies.
+func newVerifyProofCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "verify-proof <proof.json>",
+		Short: "Verify a Groth16 proof",
+		Args:  cobra.ExactArgs(1),
+	}
+	key := cmd.Flags().String("key", "", "verifying key file (snarkjs verification_key.json) for the proof's circuit")
+	url := cmd.Flags().String("url", "", "verify on this running daemon instead")
+	cmd.MarkFlagsMutuallyExclusive("key", "url")
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		return runVerifyProof(*key, *url, args[0])
+	}
+	return cmd
+}
+
+func runVerifyProof(key, url, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var req verifyProofRequest
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&req); err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+	if req.CircuitID == "" || req.proofData() == "" {
+		return fmt.Errorf("%s: circuit_id and proof are required", path)
+	}
+
+	result := map[string]interface{}{"circuit_id": req.CircuitID}
+	if url != "" {
+		response, err := daemonRequest("POST", url, "", "/persona/zk/v1beta1/verify", data)
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal(response, &result); err != nil {
+			return fmt.Errorf("daemon returned an invalid result: %v", err)
+		}
+	} else {
+		if key != "" {
+			keyData, err := os.ReadFile(key)
+			if err != nil {
+				return err
+			}
+			vk, err := parseVerifyingKey(keyData)
+			if err != nil {
+				return fmt.Errorf("%s: %v", key, err)
+			}
+			verifyingKeysMu.Lock()
+			verifyingKeys[req.CircuitID] = vk
+			verifyingKeysMu.Unlock()
+		}
+		result["mode"] = "groth16"
+		result["verified"] = true
+		if err := verifyGroth16(req.CircuitID, req.proofData(), req.PublicInputs); err != nil {
+			result["verified"] = false
+			result["error"] = err.Error()
+		}
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(result)
+	if verified, _ := result["verified"].(bool); !verified {
+		return errors.New("proof did not verify")
+	}
+	return nil
+}
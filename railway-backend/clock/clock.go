@@ -0,0 +1,87 @@
+// Package clock abstracts the current time so the daemon can run on a
+// fixed, manually advanced clock in deterministic mode.
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock tells the current time.
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+// System is the wall clock.
+var System Clock = systemClock{}
+
+// Manual is a clock that stands still until it is advanced.
+type Manual struct {
+	mu sync.Mutex
+	t  time.Time
+}
+
+// NewManual returns a manual clock set to t.
+func NewManual(t time.Time) *Manual {
+	return &Manual{t: t}
+}
+
+// Now returns the clock's current time.
+func (m *Manual) Now() time.Time {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.t
+}
+
+// Advance moves the clock forward by d.
+func (m *Manual) Advance(d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.t = m.t.Add(d)
+}
+
+// Advancer is a clock that can be moved forward (or, with a negative
+// duration, back).
+type Advancer interface {
+	Clock
+	Advance(d time.Duration)
+}
+
+// Offset is a clock running at the pace of its base clock, shifted by an
+// offset that Advance adds to.
+type Offset struct {
+	base Clock
+
+	mu     sync.Mutex
+	offset time.Duration
+}
+
+// NewOffset returns a clock that tells base's time until it is advanced.
+func NewOffset(base Clock) *Offset {
+	return &Offset{base: base}
+}
+
+// Now returns the base clock's time plus the offset.
+func (o *Offset) Now() time.Time {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.base.Now().Add(o.offset)
+}
+
+// Advance moves the clock forward by d.
+func (o *Offset) Advance(d time.Duration) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.offset += d
+}
+
+// Offset returns how far the clock is ahead of its base clock.
+func (o *Offset) Offset() time.Duration {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.offset
+}
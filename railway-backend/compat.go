@@ -0,0 +1,398 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+
+	"persona-backend/clock"
+	txv1beta1 "persona-backend/proto/cosmos/tx/v1beta1"
+	zkv1 "persona-backend/proto/persona/zk/v1"
+
+	"github.com/gorilla/mux"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// Compatibility selftest: GET /api/compat/selftest runs canonical
+// operations against a throwaway isolated chain built from the same
+// modules, and reports which features, formats and endpoints work, so the
+// frontend SDK can feature-detect the mock at startup. The live chain's
+// state is never touched.
+
+const compatAPIVersion = "v1beta1"
+
+type compatResult struct {
+	Feature   string `json:"feature"`
+	Category  string `json:"category"`
+	Endpoint  string `json:"endpoint"`
+	Supported bool   `json:"supported"`
+	Detail    string `json:"detail,omitempty"`
+}
+
+// compatRunner drives the scratch chain through its router.
+type compatRunner struct {
+	chain  *Chain
+	router *mux.Router
+	// hashes of txs broadcast so far, by feature
+	txHashes map[string]string
+}
+
+func (cr *compatRunner) do(method, path string, body interface{}) (int, map[string]interface{}) {
+	var reader *bytes.Reader
+	if body != nil {
+		data, _ := json.Marshal(body)
+		reader = bytes.NewReader(data)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+	req := httptest.NewRequest(method, path, reader)
+	rec := httptest.NewRecorder()
+	cr.router.ServeHTTP(rec, req)
+
+	var decoded map[string]interface{}
+	json.Unmarshal(rec.Body.Bytes(), &decoded)
+	return rec.Code, decoded
+}
+
+// broadcast sends msgs as a Cosmos tx JSON body in block mode, so failed
+// messages are reported, and returns the response.
+func (cr *compatRunner) broadcast(msgs ...map[string]interface{}) (map[string]interface{}, error) {
+	status, resp := cr.do("POST", "/cosmos/tx/v1beta1/txs", map[string]interface{}{
+		"tx":   map[string]interface{}{"body": map[string]interface{}{"messages": msgs}},
+		"mode": "BROADCAST_MODE_BLOCK",
+	})
+	return checkTxResponse(status, resp)
+}
+
+func checkTxResponse(status int, resp map[string]interface{}) (map[string]interface{}, error) {
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %d", status)
+	}
+	if code, _ := resp["code"].(float64); code != 0 {
+		return resp, fmt.Errorf("code %v: %v", resp["code"], resp["raw_log"])
+	}
+	return resp, nil
+}
+
+func (cr *compatRunner) get(path string) (map[string]interface{}, error) {
+	status, resp := cr.do("GET", path, nil)
+	if status != http.StatusOK {
+		return resp, fmt.Errorf("HTTP %d", status)
+	}
+	return resp, nil
+}
+
+type compatCheck struct {
+	feature  string
+	category string
+	endpoint string
+	run      func(cr *compatRunner) (string, error)
+}
+
+const (
+	compatAddress = "cosmos1compatselftest"
+	compatDID     = "did:persona:compat-selftest"
+	compatVC      = "urn:uuid:compat-selftest-vc"
+)
+
+var compatChecks = []compatCheck{
+	{"broadcast_tx_json", "tx", "POST /cosmos/tx/v1beta1/txs", func(cr *compatRunner) (string, error) {
+		resp, err := cr.broadcast(map[string]interface{}{
+			"@type":        "/persona.did.v1.MsgCreateDid",
+			"creator":      compatAddress,
+			"did_document": map[string]interface{}{"id": compatDID, "controller": compatAddress},
+		})
+		if err != nil {
+			return "", err
+		}
+		cr.txHashes["did"] = resp["txhash"].(string)
+		return "tx.body.messages", nil
+	}},
+	{"broadcast_legacy_msgs", "tx", "POST /cosmos/tx/v1beta1/txs", func(cr *compatRunner) (string, error) {
+		status, resp := cr.do("POST", "/cosmos/tx/v1beta1/txs", map[string]interface{}{
+			"msgs": []interface{}{map[string]interface{}{
+				"@type":   "/persona.zk.v1.MsgSubmitProof",
+				"creator": compatAddress, "circuit_id": "circuit_001", "proof": "selftest",
+			}},
+		})
+		_, err := checkTxResponse(status, resp)
+		return "top-level msgs", err
+	}},
+	{"broadcast_tx_bytes", "tx", "POST /cosmos/tx/v1beta1/txs", func(cr *compatRunner) (string, error) {
+		msg, _ := anypb.New(&zkv1.MsgSubmitProof{Creator: compatAddress, CircuitId: "circuit_001", Proof: "bytes"})
+		bodyBytes, _ := proto.Marshal(&txv1beta1.TxBody{Messages: []*anypb.Any{msg}})
+		txBytes, _ := proto.Marshal(&txv1beta1.TxRaw{BodyBytes: bodyBytes})
+		status, resp := cr.do("POST", "/cosmos/tx/v1beta1/txs", map[string]interface{}{
+			"tx_bytes": base64.StdEncoding.EncodeToString(txBytes),
+			"mode":     "BROADCAST_MODE_SYNC",
+		})
+		if _, err := checkTxResponse(status, resp); err != nil {
+			return "", err
+		}
+		// Accepted, but only stored if the messages were decoded
+		_, proofs := cr.do("GET", "/persona/zk/v1beta1/proofs_by_controller/"+compatAddress, nil)
+		if total, _ := proofs["pagination"].(map[string]interface{})["total"].(string); total != "2" {
+			return "tx_bytes accepted but messages are not decoded", fmt.Errorf("messages not decoded")
+		}
+		return "", nil
+	}},
+	{"duplicate_tx_rejection", "tx", "POST /cosmos/tx/v1beta1/txs", func(cr *compatRunner) (string, error) {
+		status, resp := cr.do("POST", "/cosmos/tx/v1beta1/txs", map[string]interface{}{
+			"tx": map[string]interface{}{"body": map[string]interface{}{"messages": []interface{}{map[string]interface{}{
+				"@type":        "/persona.did.v1.MsgCreateDid",
+				"creator":      compatAddress,
+				"did_document": map[string]interface{}{"id": compatDID, "controller": compatAddress},
+			}}}},
+			// The same body as the first broadcast, mode included
+			"mode": "BROADCAST_MODE_BLOCK",
+		})
+		if code, _ := resp["code"].(float64); status != http.StatusOK || int(code) != codeTxInMempoolCache {
+			return "", fmt.Errorf("expected code %d, got %v", codeTxInMempoolCache, resp["code"])
+		}
+		return fmt.Sprintf("code %d", codeTxInMempoolCache), nil
+	}},
+	{"account_sequence", "auth", "GET /cosmos/auth/v1beta1/accounts/{address}", func(cr *compatRunner) (string, error) {
+		sequence := func() (interface{}, error) {
+			resp, err := cr.get("/cosmos/auth/v1beta1/accounts/" + compatAddress)
+			if err != nil {
+				return nil, err
+			}
+			account, _ := resp["account"].(map[string]interface{})
+			return account["sequence"], nil
+		}
+		before, err := sequence()
+		if err != nil {
+			return "", err
+		}
+		if _, err := cr.broadcast(map[string]interface{}{
+			"@type": "/persona.zk.v1.MsgSubmitProof", "creator": compatAddress, "circuit_id": "circuit_001", "proof": "sequence",
+		}); err != nil {
+			return "", err
+		}
+		after, err := sequence()
+		if err != nil {
+			return "", err
+		}
+		if before == after {
+			return "", fmt.Errorf("sequence stayed at %v after a tx", before)
+		}
+		return "sequence increments per accepted tx", nil
+	}},
+	{"sequence_mismatch_rejection", "auth", "POST /cosmos/tx/v1beta1/txs", func(cr *compatRunner) (string, error) {
+		status, resp := cr.do("POST", "/cosmos/tx/v1beta1/txs", map[string]interface{}{
+			"tx": map[string]interface{}{
+				"body": map[string]interface{}{"messages": []interface{}{map[string]interface{}{
+					"@type": "/persona.zk.v1.MsgSubmitProof", "creator": compatAddress, "circuit_id": "circuit_001", "proof": "stale",
+				}}},
+				"auth_info": map[string]interface{}{"signer_infos": []interface{}{map[string]interface{}{"sequence": "0"}}},
+			},
+		})
+		if code, _ := resp["code"].(float64); status != http.StatusOK || int(code) != codeWrongSequence {
+			return "", fmt.Errorf("expected code %d, got %v", codeWrongSequence, resp["code"])
+		}
+		return fmt.Sprintf("code %d", codeWrongSequence), nil
+	}},
+	{"tx_query_by_hash", "tx", "GET /cosmos/tx/v1beta1/txs/{hash}", func(cr *compatRunner) (string, error) {
+		resp, err := cr.get("/cosmos/tx/v1beta1/txs/" + cr.txHashes["did"])
+		if err != nil {
+			return "", err
+		}
+		if _, ok := resp["tx_response"]; !ok {
+			return "", fmt.Errorf("missing tx_response")
+		}
+		return "", nil
+	}},
+	{"tx_search_events", "tx", "GET /cosmos/tx/v1beta1/txs?events=", func(cr *compatRunner) (string, error) {
+		query := url.Values{"events": {"message.sender='" + compatAddress + "'"}}
+		resp, err := cr.get("/cosmos/tx/v1beta1/txs?" + query.Encode())
+		if err != nil {
+			return "", err
+		}
+		if txs, _ := resp["tx_responses"].([]interface{}); len(txs) == 0 {
+			return "", fmt.Errorf("no txs found")
+		}
+		return "message.sender, message.action, tx.height", nil
+	}},
+	{"blocks", "chain", "GET /blocks/{height}", func(cr *compatRunner) (string, error) {
+		cr.chain.produceBlock()
+		resp, err := cr.get("/blocks/latest")
+		if err != nil {
+			return "", err
+		}
+		if _, ok := resp["block_id"]; !ok {
+			return "", fmt.Errorf("missing block_id")
+		}
+		return "Tendermint /block shape", nil
+	}},
+	{"did_query", "did", "GET /persona/did/v1beta1/did_documents/{id}", func(cr *compatRunner) (string, error) {
+		resp, err := cr.get("/persona/did/v1beta1/did_documents/" + compatDID)
+		if err != nil {
+			return "", err
+		}
+		if doc, _ := resp["did_document"].(map[string]interface{}); doc == nil || doc["id"] != compatDID {
+			return "", fmt.Errorf("created DID not returned")
+		}
+		return "", nil
+	}},
+	{"did_by_controller", "did", "GET /persona/did/v1beta1/did_by_controller/{controller}", func(cr *compatRunner) (string, error) {
+		_, err := cr.get("/persona/did/v1beta1/did_by_controller/" + compatAddress)
+		return "", err
+	}},
+	{"pagination", "query", "GET /persona/did/v1beta1/did_documents?pagination.limit=", func(cr *compatRunner) (string, error) {
+		resp, err := cr.get("/persona/did/v1beta1/did_documents?pagination.limit=1")
+		if err != nil {
+			return "", err
+		}
+		page, _ := resp["pagination"].(map[string]interface{})
+		if page["next_key"] == nil {
+			return "", fmt.Errorf("no next_key")
+		}
+		return "key, offset, limit, count_total, reverse", nil
+	}},
+	{"vc_issue_and_query", "vc", "GET /persona/vc/v1beta1/credentials_by_controller/{controller}", func(cr *compatRunner) (string, error) {
+		if _, err := cr.broadcast(map[string]interface{}{
+			"@type":   "/persona.vc.v1.MsgIssueCredential",
+			"creator": compatAddress,
+			"vc_data": w3cCredential(compatVC, compatDID, nil, map[string]interface{}{"id": compatDID}, cr.chain.now()),
+		}); err != nil {
+			return "", err
+		}
+		resp, err := cr.get("/persona/vc/v1beta1/credentials_by_controller/" + compatAddress)
+		if err != nil {
+			return "", err
+		}
+		if records, _ := resp["vc_records"].([]interface{}); len(records) != 1 {
+			return "", fmt.Errorf("issued credential not returned")
+		}
+		return "", nil
+	}},
+	{"vc_revocation_status", "vc", "GET /persona/vc/v1beta1/credentials/{id}/status", func(cr *compatRunner) (string, error) {
+		if _, err := cr.broadcast(map[string]interface{}{
+			"@type":         "/persona.vc.v1.MsgRevokeCredential",
+			"creator":       compatAddress,
+			"credential_id": compatVC,
+			"reason":        "selftest",
+		}); err != nil {
+			return "", err
+		}
+		resp, err := cr.get("/persona/vc/v1beta1/credentials/" + compatVC + "/status")
+		if err != nil {
+			return "", err
+		}
+		if resp["status"] != "revoked" {
+			return "", fmt.Errorf("status %v after revocation", resp["status"])
+		}
+		return "", nil
+	}},
+	{"zk_proofs", "zk", "GET /persona/zk/v1beta1/proofs_by_controller/{controller}", func(cr *compatRunner) (string, error) {
+		_, err := cr.get("/persona/zk/v1beta1/proofs_by_controller/" + compatAddress)
+		return "", err
+	}},
+	{"zk_circuits", "zk", "GET /persona/zk/v1beta1/circuits", func(cr *compatRunner) (string, error) {
+		_, err := cr.get("/persona/zk/v1beta1/circuits")
+		return "", err
+	}},
+}
+
+// newScratchRunner builds a fresh isolated chain with the same modules and
+// settings as c, for running canonical operations without touching c. It
+// gets its own manual clock so producing blocks on it cannot move c's.
+func (c *Chain) newScratchRunner() *compatRunner {
+	scratch := NewChain(ChainConfig{
+		ChainID:       c.info.ChainID,
+		InitialHeight: 1,
+		BlockTime:     c.blockTime,
+		Denom:         c.denom,
+		NodeInfo:      c.info.NodeInfo,
+		Clock:         clock.NewManual(c.now()),
+		Isolated:      true,
+	})
+	runner := &compatRunner{chain: scratch, router: mux.NewRouter(), txHashes: map[string]string{}}
+	scratch.RegisterRoutes(runner.router)
+	return runner
+}
+
+// runCompatSelftest runs every check on a fresh isolated chain.
+func (c *Chain) runCompatSelftest() []compatResult {
+	runner := c.newScratchRunner()
+	results := make([]compatResult, 0, len(compatChecks))
+	for _, check := range compatChecks {
+		detail, err := check.run(runner)
+		result := compatResult{
+			Feature:   check.feature,
+			Category:  check.category,
+			Endpoint:  check.endpoint,
+			Supported: err == nil,
+			Detail:    detail,
+		}
+		if err != nil && detail == "" {
+			result.Detail = err.Error()
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// Handler for GET /api/compat/selftest
+func (c *Chain) handleCompatSelftest(w http.ResponseWriter, r *http.Request) {
+	results := c.runCompatSelftest()
+	passed := 0
+	for _, result := range results {
+		if result.Supported {
+			passed++
+		}
+	}
+
+	modules := make([]string, 0, len(c.modules))
+	for _, m := range c.modules {
+		modules = append(modules, m.Name())
+	}
+	response := map[string]interface{}{
+		"chain_id": c.info.ChainID,
+		"versions": map[string]interface{}{
+			"api":            compatAPIVersion,
+			"node":           c.info.NodeInfo.Version,
+			"stripe_api":     stripeAPIVersion,
+			"tendermint_rpc": "jsonrpc-2.0 websocket",
+		},
+		"modules":  modules,
+		"messages": c.msgs.TypeURLs(),
+		"formats": map[string]bool{
+			"tx_json":         resultSupported(results, "broadcast_tx_json"),
+			"legacy_msgs":     resultSupported(results, "broadcast_legacy_msgs"),
+			"tx_bytes":        resultSupported(results, "broadcast_tx_bytes"),
+			"grpc_error_body": true,
+		},
+		"server": map[string]bool{
+			"websocket_events":     true,
+			"grpc":                 os.Getenv("GRPC_PORT") != "",
+			"deterministic":        deterministic,
+			"admin_token_required": adminToken != "",
+			"read_through":         readThroughURL != "",
+			"dual_write":           dualWriteURL != "",
+			"fixtures":             fixturesDir != "",
+		},
+		"features": results,
+		"summary": map[string]int{
+			"passed": passed,
+			"failed": len(results) - passed,
+		},
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func resultSupported(results []compatResult, feature string) bool {
+	for _, result := range results {
+		if result.Feature == feature {
+			return result.Supported
+		}
+	}
+	return false
+}
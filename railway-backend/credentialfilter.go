@@ -0,0 +1,179 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Credential search for the list endpoints (credentials and
+// credentials_by_controller), so the frontend's filter chips query instead
+// of filtering the full list client-side. All filters are optional and
+// combine:
+//
+//	type             the W3C type, or the credentialType or templateId subject field
+//	templateId       the templateId subject field
+//	issuer_did       the issuer
+//	issued_after     issued at or after (RFC 3339 or unix seconds)
+//	issued_before    issued before (RFC 3339 or unix seconds)
+//	revoked          true for revoked credentials only, false for the others
+//	include_expired  false leaves out credentials past their expirationDate
+//
+// A credential is issued at its issuanceDate, else when it was stored.
+// sort orders by issued_at, expiration_date or id, descending with a "-"
+// prefix (e.g. sort=-issued_at for newest first); credentials without an
+// expirationDate come last. Pagination applies after filtering and sorting.
+
+type credentialFilter struct {
+	Type           string
+	TemplateID     string
+	IssuerDID      string
+	IssuedAfter    time.Time
+	IssuedBefore   time.Time
+	Revoked        *bool
+	IncludeExpired bool
+	Sort           string
+	Descending     bool
+}
+
+var credentialSorts = []string{"issued_at", "expiration_date", "id"}
+
+// parseCredentialFilter reads the search query parameters.
+func parseCredentialFilter(q url.Values) (credentialFilter, error) {
+	f := credentialFilter{
+		Type:           q.Get("type"),
+		TemplateID:     q.Get("templateId"),
+		IssuerDID:      q.Get("issuer_did"),
+		IncludeExpired: true,
+	}
+	var err error
+	if f.IssuedAfter, err = parseIssuedBound(q, "issued_after"); err != nil {
+		return f, err
+	}
+	if f.IssuedBefore, err = parseIssuedBound(q, "issued_before"); err != nil {
+		return f, err
+	}
+	switch q.Get("revoked") {
+	case "":
+	case "true", "false":
+		revoked := q.Get("revoked") == "true"
+		f.Revoked = &revoked
+	default:
+		return f, fmt.Errorf("revoked must be true or false")
+	}
+	switch q.Get("include_expired") {
+	case "", "true":
+	case "false":
+		f.IncludeExpired = false
+	default:
+		return f, fmt.Errorf("include_expired must be true or false")
+	}
+	if sortBy := q.Get("sort"); sortBy != "" {
+		f.Descending = strings.HasPrefix(sortBy, "-")
+		f.Sort = strings.TrimPrefix(sortBy, "-")
+		known := false
+		for _, name := range credentialSorts {
+			known = known || f.Sort == name
+		}
+		if !known {
+			return f, fmt.Errorf("sort must be one of %s, with an optional - prefix", strings.Join(credentialSorts, ", "))
+		}
+	}
+	return f, nil
+}
+
+// parseIssuedBound reads an RFC 3339 time or unix seconds.
+func parseIssuedBound(q url.Values, name string) (time.Time, error) {
+	raw := q.Get(name)
+	if raw == "" {
+		return time.Time{}, nil
+	}
+	if seconds, err := strconv.ParseInt(raw, 10, 64); err == nil {
+		return time.Unix(seconds, 0), nil
+	}
+	t, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s must be an RFC 3339 time or unix seconds", name)
+	}
+	return t, nil
+}
+
+// credentialIssuedAt returns when a credential was issued: its
+// issuanceDate, else the time it was stored.
+func credentialIssuedAt(credential map[string]interface{}) time.Time {
+	if s, ok := credential["issuanceDate"].(string); ok {
+		if t, err := time.Parse(time.RFC3339, s); err == nil {
+			return t
+		}
+	}
+	// created_at is a float64 once a store went through JSON
+	switch created := credential["created_at"].(type) {
+	case int64:
+		return time.Unix(created, 0)
+	case float64:
+		return time.Unix(int64(created), 0)
+	}
+	return time.Time{}
+}
+
+func (f credentialFilter) matches(credential map[string]interface{}, now time.Time) bool {
+	if f.Type != "" && !credentialHasType(credential, f.Type) {
+		return false
+	}
+	if f.TemplateID != "" {
+		subject, _ := credential["credentialSubject"].(map[string]interface{})
+		if subject == nil || subject["templateId"] != f.TemplateID {
+			return false
+		}
+	}
+	if f.IssuerDID != "" && credentialIssuer(credential) != f.IssuerDID {
+		return false
+	}
+	if !f.IssuedAfter.IsZero() || !f.IssuedBefore.IsZero() {
+		issued := credentialIssuedAt(credential)
+		if (!f.IssuedAfter.IsZero() && issued.Before(f.IssuedAfter)) ||
+			(!f.IssuedBefore.IsZero() && !issued.Before(f.IssuedBefore)) {
+			return false
+		}
+	}
+	if f.Revoked != nil && (credential["is_revoked"] == true) != *f.Revoked {
+		return false
+	}
+	if !f.IncludeExpired && credentialExpired(credential, now) {
+		return false
+	}
+	return true
+}
+
+// less orders two credentials by f.Sort.
+func (f credentialFilter) less(a, b map[string]interface{}) bool {
+	if f.Descending {
+		a, b = b, a
+	}
+	switch f.Sort {
+	case "issued_at":
+		return credentialIssuedAt(a).Before(credentialIssuedAt(b))
+	case "expiration_date":
+		aExpires, aOK := credentialExpiry(a)
+		bExpires, bOK := credentialExpiry(b)
+		if aOK != bOK {
+			// Without an expirationDate last, in both directions
+			return aOK != f.Descending
+		}
+		return aExpires.Before(bExpires)
+	}
+	aID, _ := a["id"].(string)
+	bID, _ := b["id"].(string)
+	return aID < bID
+}
+
+// sortCredentials orders credentials by f.Sort, keeping their order when
+// there is none.
+func (f credentialFilter) sortCredentials(credentials []map[string]interface{}) {
+	if f.Sort != "" {
+		sort.SliceStable(credentials, func(i, j int) bool { return f.less(credentials[i], credentials[j]) })
+	}
+}
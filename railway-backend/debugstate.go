@@ -0,0 +1,127 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+)
+
+// Triage dump for failed E2E assertions: GET /admin/debug/state?did= returns
+// everything related to one DID in a single document. That is the DID
+// document, the credentials held by its controller, issued by the DID or
+// about it, the controller's proofs, the controller's txs (the audit trail,
+// including failed ones) and the recent activity events that touch any of
+// them. Activity events are kept for the last maxRecentActivity only.
+
+// Handler for GET /admin/debug/state
+func (c *Chain) handleDebugState(w http.ResponseWriter, r *http.Request) {
+	didId := r.URL.Query().Get("did")
+	if didId == "" {
+		http.Error(w, "Missing required query parameter: did", http.StatusBadRequest)
+		return
+	}
+
+	c.mu.RLock()
+	document, exists := c.did().store.Documents[didId]
+	if !exists {
+		c.mu.RUnlock()
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"error": "DID not found",
+			"did":   didId,
+		})
+		return
+	}
+	controller := c.did().controllerOf(didId)
+	// Subjects are the IDs whose activity events belong in the dump
+	subjects := map[string]bool{didId: true}
+
+	held := c.vc().store.ByController[controller]
+	issued := []map[string]interface{}{}
+	about := []map[string]interface{}{}
+	for _, owner := range sortedMapKeys(c.vc().store.ByController) {
+		for _, credential := range c.vc().store.ByController[owner] {
+			if owner == controller {
+				continue
+			}
+			if credential["issuer"] == didId {
+				issued = append(issued, credential)
+			} else if subject, ok := credential["credentialSubject"].(map[string]interface{}); ok && subject["id"] == didId {
+				about = append(about, credential)
+			}
+		}
+	}
+	for _, list := range [][]map[string]interface{}{held, issued, about} {
+		for _, credential := range list {
+			if id, ok := credential["id"].(string); ok {
+				subjects[id] = true
+			}
+		}
+	}
+
+	proofs := c.zk().store.ByController[controller]
+	for _, proof := range proofs {
+		if id, ok := proof["id"].(string); ok {
+			subjects[id] = true
+		}
+	}
+
+	txs := []map[string]interface{}{}
+	for _, hash := range c.txsBySender[controller] {
+		txs = append(txs, c.txsByHash[hash].txResponseJSON())
+	}
+
+	// Marshal under the lock, the records are live
+	body, err := json.Marshal(map[string]interface{}{
+		"document": document,
+		"credentials": map[string]interface{}{
+			"held":   nonNil(held),
+			"issued": issued,
+			"about":  about,
+		},
+		"proofs": nonNil(proofs),
+		"txs":    txs,
+	})
+	height := c.info.LatestHeight
+	c.mu.RUnlock()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	c.eventsMu.Lock()
+	events := []activityEvent{}
+	for _, ev := range c.recentActivity {
+		if subjects[ev.Subject] || (controller != "" && ev.Address == controller) {
+			events = append(events, ev)
+		}
+	}
+	c.eventsMu.Unlock()
+	sort.Slice(events, func(i, j int) bool { return events[i].ID < events[j].ID })
+
+	var related map[string]json.RawMessage
+	json.Unmarshal(body, &related)
+	response := map[string]interface{}{
+		"did":           didId,
+		"controller":    controller,
+		"latest_height": height,
+		"events":        events,
+	}
+	for key, value := range related {
+		response[key] = value
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(response)
+}
+
+// nonNil returns an empty list for a nil one, so it encodes as [].
+func nonNil(list []map[string]interface{}) []map[string]interface{} {
+	if list == nil {
+		return []map[string]interface{}{}
+	}
+	return list
+}
@@ -0,0 +1,428 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+
+	"github.com/gorilla/mux"
+)
+
+// Demo mode: named storylines a presenter steps through during a live
+// demo, instead of improvising curl commands. Each storyline is a state
+// machine, not_started -> in_progress (at step n) -> completed, driven by
+//
+//	POST   /admin/demo/{storyline}/start    run the setup, before step 1
+//	POST   /admin/demo/{storyline}/advance  run the next step
+//	DELETE /admin/demo/{storyline}          undo the run
+//
+// and GET /admin/demo and /admin/demo/{storyline} show where each one is,
+// with the narration of the next step and the entities created so far.
+// Steps are scenario scripts (scenario.go), so they are atomic and create
+// their DIDs, credentials and proofs through the normal message handlers;
+// a failed step leaves the storyline where it was. Starting again, or
+// deleting, first rolls the stores back to before the earlier start, so a
+// storyline can be rehearsed any number of times; storylines started after
+// it are rolled back with it. start?reset=true wipes all state first.
+
+type demoStep struct {
+	Name      string                   `json:"name"`
+	Title     string                   `json:"title"`
+	Narration string                   `json:"narration"`
+	Actions   []map[string]interface{} `json:"-"`
+}
+
+type demoStoryline struct {
+	Name        string                   `json:"name"`
+	Title       string                   `json:"title"`
+	Description string                   `json:"description"`
+	Setup       []map[string]interface{} `json:"-"`
+	Steps       []demoStep               `json:"-"`
+}
+
+// demoRun is the progress of a started storyline.
+type demoRun struct {
+	// Step is the number of steps run so far
+	Step      int   `json:"step"`
+	StartedAt int64 `json:"started_at"`
+	// StepTimes are when each step ran, as Unix seconds
+	StepTimes []int64 `json:"step_times"`
+	// backup holds the module stores from before the start
+	backup []byte
+	// seq orders runs by start
+	seq int
+}
+
+var (
+	demoMu   sync.Mutex
+	demoRuns = map[string]*demoRun{}
+	demoSeq  int
+)
+
+func init() {
+	registerAdminState("demo", func() interface{} {
+		demoMu.Lock()
+		defer demoMu.Unlock()
+		runs := map[string]demoRun{}
+		for name, run := range demoRuns {
+			runs[name] = *run
+		}
+		return runs
+	}, func() {
+		demoMu.Lock()
+		demoRuns = map[string]*demoRun{}
+		demoMu.Unlock()
+	})
+}
+
+var demoStorylines = []demoStoryline{
+	{
+		Name:        "onboarding",
+		Title:       "New user onboarding",
+		Description: "Alice creates her Persona identity, verifies her email and adds a recovery key.",
+		Setup: []map[string]interface{}{
+			{"create_did": map[string]interface{}{"id": "did:persona:demo-persona-issuer", "controller": "cosmos1demopersonaissuer"}},
+		},
+		Steps: []demoStep{
+			{
+				Name:      "create-did",
+				Title:     "Create Alice's DID",
+				Narration: "Alice installs the wallet and gets a DID anchored on chain, controlled by her account.",
+				Actions: []map[string]interface{}{
+					{"create_did": map[string]interface{}{"id": "did:persona:demo-alice", "controller": "cosmos1demoalice"}},
+				},
+			},
+			{
+				Name:      "verify-email",
+				Title:     "Verify her email",
+				Narration: "Persona confirms Alice's email address and issues her an EmailCredential.",
+				Actions: []map[string]interface{}{
+					{"issue_vc": map[string]interface{}{
+						"id": "urn:uuid:demo-alice-email", "issuer": "did:persona:demo-persona-issuer", "subject": "did:persona:demo-alice",
+						"type": "EmailCredential", "claims": map[string]interface{}{"email": "alice@example.com"},
+					}},
+				},
+			},
+			{
+				Name:      "add-recovery-key",
+				Title:     "Add a recovery key",
+				Narration: "Alice adds a second device key so she can recover her identity if she loses her phone.",
+				Actions: []map[string]interface{}{
+					{"update_did": map[string]interface{}{"id": "did:persona:demo-alice", "document": map[string]interface{}{
+						"verificationMethod": []interface{}{map[string]interface{}{
+							"id": "#recovery", "type": "Multikey", "controller": "did:persona:demo-alice",
+							"publicKeyMultibase": "z6MkhaXgBZDvotDkL5257faiztiGiC2QtKLGpbnnEGta2doK",
+						}},
+					}}},
+				},
+			},
+			{
+				Name:      "personhood",
+				Title:     "Prove she is a unique person",
+				Narration: "Alice completes the liveness check and receives a PersonhoodCredential. Onboarding is done.",
+				Actions: []map[string]interface{}{
+					{"issue_vc": map[string]interface{}{
+						"id": "urn:uuid:demo-alice-personhood", "issuer": "did:persona:demo-persona-issuer", "subject": "did:persona:demo-alice",
+						"type": "PersonhoodCredential", "claims": map[string]interface{}{"unique": true},
+					}},
+				},
+			},
+		},
+	},
+	{
+		Name:        "bar-age-check",
+		Title:       "Bar age check",
+		Description: "Bob proves he is over 21 at the door without showing his birth date, until his licence is suspended.",
+		Setup: []map[string]interface{}{
+			{"create_did": map[string]interface{}{"id": "did:persona:demo-dmv", "controller": "cosmos1demodmv"}},
+			{"create_did": map[string]interface{}{"id": "did:persona:demo-bob", "controller": "cosmos1demobob"}},
+		},
+		Steps: []demoStep{
+			{
+				Name:      "issue-licence",
+				Title:     "The DMV issues Bob's licence",
+				Narration: "The DMV issues Bob a driver's licence credential carrying his birth date.",
+				Actions: []map[string]interface{}{
+					{"issue_vc": map[string]interface{}{
+						"id": "urn:uuid:demo-bob-licence", "issuer": "did:persona:demo-dmv", "subject": "did:persona:demo-bob",
+						"type": "DriversLicenseCredential", "claims": map[string]interface{}{"birthDate": "1998-04-02", "licenseClass": "C"},
+					}},
+				},
+			},
+			{
+				Name:      "prove-age",
+				Title:     "Bob proves he is over 21",
+				Narration: "At the door Bob scans the bar's QR code and his wallet sends a zero-knowledge proof of age. The bouncer sees \"over 21\" and nothing else.",
+				Actions: []map[string]interface{}{
+					{"submit_proof": map[string]interface{}{
+						"prover": "cosmos1demobob", "circuit_id": "circuit_001", "proof": "demo-age-over-21",
+						"public_inputs": []interface{}{"21"}, "metadata": map[string]interface{}{"verifier": "demo-bar", "credential_id": "urn:uuid:demo-bob-licence"},
+					}},
+				},
+			},
+			{
+				Name:      "suspend-licence",
+				Title:     "The licence is suspended",
+				Narration: "The DMV suspends Bob's licence. The credential is revoked on chain, so his next age proof is refused.",
+				Actions: []map[string]interface{}{
+					{"revoke_vc": map[string]interface{}{"id": "urn:uuid:demo-bob-licence", "reason": "licence suspended"}},
+				},
+			},
+		},
+	},
+	{
+		Name:        "bank-kyc",
+		Title:       "Bank KYC",
+		Description: "Carol opens a bank account by sharing a reusable KYC credential instead of uploading documents again.",
+		Setup: []map[string]interface{}{
+			{"create_did": map[string]interface{}{"id": "did:persona:demo-kyc-provider", "controller": "cosmos1demokyc"}},
+			{"create_did": map[string]interface{}{"id": "did:persona:demo-carol", "controller": "cosmos1democarol"}},
+		},
+		Steps: []demoStep{
+			{
+				Name:      "verify-identity",
+				Title:     "Carol verifies her identity",
+				Narration: "Carol scans her passport once with the KYC provider, who issues an IdentityCredential.",
+				Actions: []map[string]interface{}{
+					{"issue_vc": map[string]interface{}{
+						"id": "urn:uuid:demo-carol-identity", "issuer": "did:persona:demo-kyc-provider", "subject": "did:persona:demo-carol",
+						"type": "IdentityCredential", "claims": map[string]interface{}{"givenName": "Carol", "familyName": "Rivera", "nationality": "US"},
+					}},
+				},
+			},
+			{
+				Name:      "kyc-credential",
+				Title:     "The provider completes KYC",
+				Narration: "After sanctions and PEP screening the provider issues a KYCCredential at the enhanced level.",
+				Actions: []map[string]interface{}{
+					{"issue_vc": map[string]interface{}{
+						"id": "urn:uuid:demo-carol-kyc", "issuer": "did:persona:demo-kyc-provider", "subject": "did:persona:demo-carol",
+						"type": "KYCCredential", "claims": map[string]interface{}{"level": "enhanced", "sanctionsScreened": true},
+					}},
+				},
+			},
+			{
+				Name:      "open-account",
+				Title:     "Carol opens a bank account",
+				Narration: "The bank asks for proof of KYC. Carol's wallet proves it from her credential and the account opens in seconds.",
+				Actions: []map[string]interface{}{
+					{"submit_proof": map[string]interface{}{
+						"prover": "cosmos1democarol", "circuit_id": "circuit_001", "proof": "demo-kyc-enhanced",
+						"metadata": map[string]interface{}{"verifier": "demo-bank", "credential_id": "urn:uuid:demo-carol-kyc"},
+					}},
+				},
+			},
+		},
+	},
+}
+
+func findDemoStoryline(name string) *demoStoryline {
+	for i := range demoStorylines {
+		if demoStorylines[i].Name == name {
+			return &demoStorylines[i]
+		}
+	}
+	return nil
+}
+
+// demoEntities lists the ids of the DIDs and credentials actions create.
+func demoEntities(actions []map[string]interface{}) []string {
+	entities := []string{}
+	for _, action := range actions {
+		for _, kind := range []string{"create_did", "issue_vc"} {
+			if args, ok := action[kind].(map[string]interface{}); ok {
+				entities = append(entities, stringArg(args, "id"))
+			}
+		}
+	}
+	return entities
+}
+
+// demoView renders a storyline and its run, which may be nil. Must be
+// called with demoMu held.
+func demoView(storyline *demoStoryline, run *demoRun) map[string]interface{} {
+	state, step := "not_started", 0
+	if run != nil {
+		state, step = "in_progress", run.Step
+		if run.Step == len(storyline.Steps) {
+			state = "completed"
+		}
+	}
+
+	entities := []string{}
+	if run != nil {
+		entities = append(entities, demoEntities(storyline.Setup)...)
+	}
+	steps := make([]map[string]interface{}, len(storyline.Steps))
+	for i, s := range storyline.Steps {
+		status := "pending"
+		entry := map[string]interface{}{"index": i + 1, "name": s.Name, "title": s.Title, "narration": s.Narration}
+		switch {
+		case i < step:
+			status = "done"
+			entry["ran_at"] = run.StepTimes[i]
+			entities = append(entities, demoEntities(s.Actions)...)
+		case i == step && run != nil:
+			status = "next"
+		}
+		entry["status"] = status
+		steps[i] = entry
+	}
+
+	view := map[string]interface{}{
+		"name":        storyline.Name,
+		"title":       storyline.Title,
+		"description": storyline.Description,
+		"state":       state,
+		"step":        step,
+		"total_steps": len(storyline.Steps),
+		"steps":       steps,
+		"entities":    entities,
+	}
+	if run != nil {
+		view["started_at"] = run.StartedAt
+		if step < len(storyline.Steps) {
+			view["next"] = steps[step]
+		}
+	}
+	return view
+}
+
+// rewindDemo restores the stores from before a run started and forgets it
+// and every run started after it. Must be called with demoMu held.
+func (c *Chain) rewindDemo(run *demoRun) {
+	c.mu.Lock()
+	c.restoreStoresLocked(run.backup)
+	c.mu.Unlock()
+	for name, other := range demoRuns {
+		if other.seq >= run.seq {
+			delete(demoRuns, name)
+		}
+	}
+}
+
+func writeDemoError(w http.ResponseWriter, status int, message string, report *scenarioReport) {
+	body := map[string]interface{}{"error": message}
+	if report != nil {
+		body["report"] = report
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+// Handler for GET /admin/demo
+func handleListDemos(w http.ResponseWriter, r *http.Request) {
+	demoMu.Lock()
+	storylines := make([]map[string]interface{}, len(demoStorylines))
+	for i := range demoStorylines {
+		storylines[i] = demoView(&demoStorylines[i], demoRuns[demoStorylines[i].Name])
+	}
+	demoMu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"storylines": storylines})
+}
+
+// Handler for GET /admin/demo/{storyline}
+func handleGetDemo(w http.ResponseWriter, r *http.Request) {
+	storyline := findDemoStoryline(mux.Vars(r)["storyline"])
+	if storyline == nil {
+		writeDemoError(w, http.StatusNotFound, "unknown storyline "+mux.Vars(r)["storyline"], nil)
+		return
+	}
+	demoMu.Lock()
+	view := demoView(storyline, demoRuns[storyline.Name])
+	demoMu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(view)
+}
+
+// Handler for POST /admin/demo/{storyline}/start
+func (c *Chain) handleStartDemo(w http.ResponseWriter, r *http.Request) {
+	storyline := findDemoStoryline(mux.Vars(r)["storyline"])
+	if storyline == nil {
+		writeDemoError(w, http.StatusNotFound, "unknown storyline "+mux.Vars(r)["storyline"], nil)
+		return
+	}
+
+	demoMu.Lock()
+	defer demoMu.Unlock()
+	if r.URL.Query().Get("reset") == "true" {
+		// Earlier runs' backups predate the wipe, so forget them
+		c.resetState()
+		demoRuns = map[string]*demoRun{}
+	} else if previous := demoRuns[storyline.Name]; previous != nil {
+		c.rewindDemo(previous)
+	}
+
+	c.mu.Lock()
+	demoSeq++
+	run := &demoRun{StartedAt: c.now().Unix(), StepTimes: []int64{}, backup: c.backupStoresLocked(), seq: demoSeq}
+	c.mu.Unlock()
+	report := c.runScenario(scenarioScript{Name: storyline.Name + " setup", Steps: storyline.Setup}, false)
+	if !report.OK {
+		writeDemoError(w, http.StatusUnprocessableEntity, "setup failed: "+report.Error, &report)
+		return
+	}
+	demoRuns[storyline.Name] = run
+	log.Printf("Demo %s started", storyline.Name)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(demoView(storyline, run))
+}
+
+// Handler for POST /admin/demo/{storyline}/advance
+func (c *Chain) handleAdvanceDemo(w http.ResponseWriter, r *http.Request) {
+	storyline := findDemoStoryline(mux.Vars(r)["storyline"])
+	if storyline == nil {
+		writeDemoError(w, http.StatusNotFound, "unknown storyline "+mux.Vars(r)["storyline"], nil)
+		return
+	}
+
+	demoMu.Lock()
+	defer demoMu.Unlock()
+	run := demoRuns[storyline.Name]
+	switch {
+	case run == nil:
+		writeDemoError(w, http.StatusConflict, storyline.Name+" has not been started", nil)
+		return
+	case run.Step == len(storyline.Steps):
+		writeDemoError(w, http.StatusConflict, storyline.Name+" is already completed", nil)
+		return
+	}
+
+	step := storyline.Steps[run.Step]
+	report := c.runScenario(scenarioScript{Name: fmt.Sprintf("%s step %d: %s", storyline.Name, run.Step+1, step.Name), Steps: step.Actions}, false)
+	if !report.OK {
+		writeDemoError(w, http.StatusUnprocessableEntity, fmt.Sprintf("step %s failed: %s", step.Name, report.Error), &report)
+		return
+	}
+	run.Step++
+	run.StepTimes = append(run.StepTimes, report.Time)
+	log.Printf("Demo %s: step %d/%d (%s)", storyline.Name, run.Step, len(storyline.Steps), step.Name)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(demoView(storyline, run))
+}
+
+// Handler for DELETE /admin/demo/{storyline}
+func (c *Chain) handleStopDemo(w http.ResponseWriter, r *http.Request) {
+	storyline := findDemoStoryline(mux.Vars(r)["storyline"])
+	if storyline == nil {
+		writeDemoError(w, http.StatusNotFound, "unknown storyline "+mux.Vars(r)["storyline"], nil)
+		return
+	}
+
+	demoMu.Lock()
+	defer demoMu.Unlock()
+	if run := demoRuns[storyline.Name]; run != nil {
+		c.rewindDemo(run)
+		log.Printf("Demo %s rewound", storyline.Name)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(demoView(storyline, nil))
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"log"
+	"os"
+	"time"
+
+	"persona-backend/clock"
+	"persona-backend/idgen"
+)
+
+// Deterministic mode for golden-file tests (DETERMINISTIC=true). Every
+// timestamp comes from a manual clock starting at DETERMINISTIC_EPOCH
+// (default 2024-01-01T00:00:00Z) that only moves when a block is produced,
+// by exactly the block time, and proof, settlement, billing and other IDs
+// are sequential. Tx hashes are already derived from the tx bytes, and
+// block hashes from the clock, so identical runs give identical responses.
+//
+// Real-world deadlines (websocket pings, cache TTLs, presigned URLs and
+// webhook signatures) keep using the wall clock.
+//
+// Outside deterministic mode appClock is the wall clock behind an offset,
+// which scenarios can advance.
+
+var (
+	deterministic = os.Getenv("DETERMINISTIC") == "true"
+	appClock      = newAppClock()
+)
+
+func newAppClock() clock.Clock {
+	if !deterministic {
+		return clock.NewOffset(clock.System)
+	}
+	epoch := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	if raw := os.Getenv("DETERMINISTIC_EPOCH"); raw != "" {
+		parsed, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			log.Printf("Invalid DETERMINISTIC_EPOCH=%q, using %s", raw, epoch.Format(time.RFC3339))
+		} else {
+			epoch = parsed.UTC()
+		}
+	}
+	c := clock.NewManual(epoch)
+	idgen.SetDeterministic(c.Now)
+	log.Printf("Deterministic mode: clock starts at %s", epoch.Format(time.RFC3339))
+	return c
+}
@@ -0,0 +1,491 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"persona-backend/idgen"
+
+	"github.com/gorilla/mux"
+)
+
+// Devices per DID for the frontend's "manage devices" screen. Registering
+// a device at POST /api/dids/{did}/devices signs it in: the response
+// carries a session token, sent back as X-Persona-Session. Each request
+// with a live token (GET /api/session, say) refreshes the session's and
+// its device's last_seen_at. Devices can be renamed and revoked; revoking
+// one revokes all of its sessions, so their tokens stop working. A device
+// may link the WebAuthn credential ID of its passkey, which can only be
+// linked to one active device; signing such a device in again takes the
+// passkey's credential ID as the challenge response. Registrations and
+// failed challenges go to the DID's security events (securityevents.go).
+//
+// Sessions record the IP and user agent they were last used from. The
+// security settings page lists the active sessions of the signed-in DID at
+// GET /api/sessions and signs them out remotely: DELETE /api/sessions/{id}
+// revokes one (the current one too, as a logout) and DELETE /api/sessions
+// every session but the current one.
+//
+// Devices and sessions are server-level state on the default network and
+// are cleared by /admin/reset.
+
+const sessionHeader = "X-Persona-Session"
+
+var devicePlatforms = []string{"ios", "android", "web", "macos", "windows", "linux"}
+
+type device struct {
+	ID         string `json:"id"`
+	DID        string `json:"did"`
+	Name       string `json:"name"`
+	Platform   string `json:"platform"`
+	PasskeyID  string `json:"passkey_id,omitempty"`
+	Status     string `json:"status"` // active or revoked
+	CreatedAt  int64  `json:"created_at"`
+	LastSeenAt int64  `json:"last_seen_at"`
+	RevokedAt  int64  `json:"revoked_at,omitempty"`
+
+	// Consecutive failed sign-in challenges
+	failedChallenges int
+}
+
+type session struct {
+	ID         string `json:"id"`
+	DID        string `json:"did"`
+	DeviceID   string `json:"device_id"`
+	Status     string `json:"status"` // active or revoked
+	CreatedAt  int64  `json:"created_at"`
+	LastSeenAt int64  `json:"last_seen_at"`
+	IP         string `json:"ip,omitempty"`
+	UserAgent  string `json:"user_agent,omitempty"`
+	RevokedAt  int64  `json:"revoked_at,omitempty"`
+	// RevokedReason is device_revoked when the session went with its
+	// device, remote_logout when another session revoked it and signed_out
+	// when it revoked itself
+	RevokedReason string `json:"revoked_reason,omitempty"`
+	// Current marks the caller's own session in GET /api/sessions
+	Current bool `json:"current,omitempty"`
+
+	token string
+}
+
+var (
+	devicesMu sync.Mutex
+	devices   = make(map[string]*device)
+	sessions  = make(map[string]*session)
+	// Session IDs by token
+	sessionTokens = make(map[string]string)
+)
+
+func init() {
+	registerAdminState("devices", func() interface{} {
+		devicesMu.Lock()
+		defer devicesMu.Unlock()
+		deviceList := make([]device, 0, len(devices))
+		for _, id := range sortedMapKeys(devices) {
+			deviceList = append(deviceList, *devices[id])
+		}
+		sessionList := make([]session, 0, len(sessions))
+		for _, id := range sortedMapKeys(sessions) {
+			sessionList = append(sessionList, *sessions[id])
+		}
+		return map[string]interface{}{"devices": deviceList, "sessions": sessionList}
+	}, func() {
+		devicesMu.Lock()
+		defer devicesMu.Unlock()
+		devices = make(map[string]*device)
+		sessions = make(map[string]*session)
+		sessionTokens = make(map[string]string)
+	})
+}
+
+func newSessionToken() string {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		panic("crypto/rand failed: " + err.Error())
+	}
+	return "pst_" + hex.EncodeToString(buf)
+}
+
+// startSession signs a device in from r. Must be called with devicesMu
+// held.
+func startSession(d *device, r *http.Request) (*session, string) {
+	now := appClock.Now().Unix()
+	s := &session{
+		ID:         idgen.NewWithPrefix("sess"),
+		DID:        d.DID,
+		DeviceID:   d.ID,
+		Status:     "active",
+		CreatedAt:  now,
+		LastSeenAt: now,
+		token:      newSessionToken(),
+	}
+	s.seenFrom(r)
+	sessions[s.ID] = s
+	sessionTokens[s.token] = s.ID
+	d.LastSeenAt = now
+	return s, s.token
+}
+
+// seenFrom records the client r came from.
+func (s *session) seenFrom(r *http.Request) {
+	if ip := clientIP(r); ip != nil {
+		s.IP = ip.String()
+	}
+	if userAgent := r.Header.Get("User-Agent"); userAgent != "" {
+		s.UserAgent = userAgent
+	}
+}
+
+// revokeSession ends a session. Must be called with devicesMu held.
+func revokeSession(s *session, reason string) {
+	s.Status = "revoked"
+	s.RevokedAt = appClock.Now().Unix()
+	s.RevokedReason = reason
+	delete(sessionTokens, s.token)
+}
+
+// requestSession returns the live session of a request's session token and
+// marks it and its device as seen from the request's client.
+func requestSession(r *http.Request) (session, bool) {
+	token := r.Header.Get(sessionHeader)
+	if token == "" {
+		return session{}, false
+	}
+	devicesMu.Lock()
+	defer devicesMu.Unlock()
+	s := sessions[sessionTokens[token]]
+	if s == nil {
+		return session{}, false
+	}
+	now := appClock.Now().Unix()
+	s.LastSeenAt = now
+	s.seenFrom(r)
+	if d := devices[s.DeviceID]; d != nil {
+		d.LastSeenAt = now
+	}
+	return *s, true
+}
+
+// didDevice returns the {id} device of {did}, or writes a 404. Must be called
+// with devicesMu held.
+func didDevice(w http.ResponseWriter, r *http.Request) *device {
+	vars := mux.Vars(r)
+	d := devices[vars["id"]]
+	if d == nil || d.DID != vars["did"] {
+		http.Error(w, "Device not found", http.StatusNotFound)
+		return nil
+	}
+	return d
+}
+
+func writeDevice(w http.ResponseWriter, status int, response map[string]interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
+
+// Handler for POST /api/dids/{did}/devices
+func handleRegisterDevice(w http.ResponseWriter, r *http.Request) {
+	var d device
+	if err := json.NewDecoder(r.Body).Decode(&d); err != nil {
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+	d.DID = mux.Vars(r)["did"]
+	d.Name = strings.TrimSpace(d.Name)
+	d.Platform = strings.ToLower(d.Platform)
+	if d.Name == "" {
+		http.Error(w, "name is required", http.StatusBadRequest)
+		return
+	}
+	if !containsString(devicePlatforms, d.Platform) {
+		http.Error(w, "platform must be one of "+strings.Join(devicePlatforms, ", "), http.StatusBadRequest)
+		return
+	}
+	if ok, detail := didResolvableOn(defaultChain, d.DID); !ok {
+		http.Error(w, detail, http.StatusNotFound)
+		return
+	}
+
+	devicesMu.Lock()
+	if d.PasskeyID != "" {
+		for _, other := range devices {
+			if other.PasskeyID == d.PasskeyID && other.Status == "active" {
+				devicesMu.Unlock()
+				http.Error(w, "Passkey is already linked to device "+other.ID, http.StatusConflict)
+				return
+			}
+		}
+	}
+	d.ID = idgen.NewWithPrefix("dev")
+	d.Status = "active"
+	d.CreatedAt = appClock.Now().Unix()
+	d.RevokedAt = 0
+	devices[d.ID] = &d
+	s, token := startSession(&d, r)
+	response := map[string]interface{}{"device": d, "session": *s, "session_token": token}
+	devicesMu.Unlock()
+
+	log.Printf("Device %s (%s) registered for %s", d.ID, d.Platform, d.DID)
+	recordSecurityEvent(requestTraceID(r), d.DID, "new_device_login", "warning", fmt.Sprintf("New %s device %q signed in", d.Platform, d.Name), map[string]interface{}{
+		"device_id":  d.ID,
+		"session_id": s.ID,
+		"ip":         s.IP,
+		"user_agent": s.UserAgent,
+	})
+	writeDevice(w, http.StatusCreated, response)
+}
+
+// Handler for GET /api/dids/{did}/devices. Revoked devices are listed with
+// ?include_revoked=true.
+func handleListDevices(w http.ResponseWriter, r *http.Request) {
+	did := mux.Vars(r)["did"]
+	includeRevoked := r.URL.Query().Get("include_revoked") == "true"
+
+	devicesMu.Lock()
+	list := []device{}
+	for _, d := range devices {
+		if d.DID == did && (includeRevoked || d.Status == "active") {
+			list = append(list, *d)
+		}
+	}
+	devicesMu.Unlock()
+
+	// Most recently seen first, like the settings screen shows them
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].LastSeenAt != list[j].LastSeenAt {
+			return list[i].LastSeenAt > list[j].LastSeenAt
+		}
+		return list[i].ID < list[j].ID
+	})
+	writeDevice(w, http.StatusOK, map[string]interface{}{"did": did, "devices": list})
+}
+
+// Handler for GET /api/dids/{did}/devices/{id}
+func handleGetDevice(w http.ResponseWriter, r *http.Request) {
+	devicesMu.Lock()
+	defer devicesMu.Unlock()
+	if d := didDevice(w, r); d != nil {
+		writeDevice(w, http.StatusOK, map[string]interface{}{"device": *d})
+	}
+}
+
+// Handler for PATCH /api/dids/{did}/devices/{id}
+func handleRenameDevice(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Name string `json:"name"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		http.Error(w, "name is required", http.StatusBadRequest)
+		return
+	}
+
+	devicesMu.Lock()
+	defer devicesMu.Unlock()
+	d := didDevice(w, r)
+	if d == nil {
+		return
+	}
+	if d.Status != "active" {
+		http.Error(w, "Device is revoked", http.StatusConflict)
+		return
+	}
+	d.Name = req.Name
+	writeDevice(w, http.StatusOK, map[string]interface{}{"device": *d})
+}
+
+// Handler for POST /api/dids/{did}/devices/{id}/sessions. Signs an
+// existing device in again. A device with a passkey must send its
+// credential ID as {"passkey_id"}; a mismatch is a failed challenge.
+func handleStartDeviceSession(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		PasskeyID string `json:"passkey_id"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+			return
+		}
+	}
+
+	devicesMu.Lock()
+	defer devicesMu.Unlock()
+	d := didDevice(w, r)
+	if d == nil {
+		return
+	}
+	if d.Status != "active" {
+		http.Error(w, "Device is revoked", http.StatusConflict)
+		return
+	}
+	if d.PasskeyID != "" && req.PasskeyID != d.PasskeyID {
+		d.failedChallenges++
+		severity := "warning"
+		if d.failedChallenges >= challengeFailureAlert {
+			severity = "critical"
+		}
+		ip := ""
+		if clientIP := clientIP(r); clientIP != nil {
+			ip = clientIP.String()
+		}
+		recordSecurityEvent(requestTraceID(r), d.DID, "challenge_failed", severity, fmt.Sprintf("Failed passkey challenge on device %q (attempt %d)", d.Name, d.failedChallenges), map[string]interface{}{
+			"device_id":  d.ID,
+			"attempts":   d.failedChallenges,
+			"ip":         ip,
+			"user_agent": r.UserAgent(),
+		})
+		http.Error(w, "Passkey challenge failed", http.StatusUnauthorized)
+		return
+	}
+	d.failedChallenges = 0
+	s, token := startSession(d, r)
+	writeDevice(w, http.StatusCreated, map[string]interface{}{"session": *s, "session_token": token})
+}
+
+// Handler for DELETE /api/dids/{did}/devices/{id}. Revokes the device and
+// every session it has.
+func handleRevokeDevice(w http.ResponseWriter, r *http.Request) {
+	devicesMu.Lock()
+	defer devicesMu.Unlock()
+	d := didDevice(w, r)
+	if d == nil {
+		return
+	}
+	if d.Status != "active" {
+		http.Error(w, "Device is already revoked", http.StatusConflict)
+		return
+	}
+	d.Status = "revoked"
+	d.RevokedAt = appClock.Now().Unix()
+	revoked := 0
+	for _, s := range sessions {
+		if s.DeviceID == d.ID && s.Status == "active" {
+			revokeSession(s, "device_revoked")
+			revoked++
+		}
+	}
+
+	log.Printf("Device %s of %s revoked with %d sessions", d.ID, d.DID, revoked)
+	writeDevice(w, http.StatusOK, map[string]interface{}{"device": *d, "revoked_sessions": revoked})
+}
+
+// authenticatedSession returns the session of X-Persona-Session, or writes
+// a 401 when the token is unknown or revoked.
+func authenticatedSession(w http.ResponseWriter, r *http.Request) (session, bool) {
+	s, ok := requestSession(r)
+	if !ok {
+		http.Error(w, "Session is missing or revoked", http.StatusUnauthorized)
+	}
+	return s, ok
+}
+
+// Handler for GET /api/session. Returns the session of X-Persona-Session,
+// or 401 when the token is unknown or revoked.
+func handleGetSession(w http.ResponseWriter, r *http.Request) {
+	s, ok := authenticatedSession(w, r)
+	if !ok {
+		return
+	}
+	s.Current = true
+	writeDevice(w, http.StatusOK, map[string]interface{}{"session": s})
+}
+
+// Handler for GET /api/sessions. Lists the active sessions of the
+// authenticated DID with the device each belongs to.
+func handleListSessions(w http.ResponseWriter, r *http.Request) {
+	current, ok := authenticatedSession(w, r)
+	if !ok {
+		return
+	}
+
+	devicesMu.Lock()
+	type sessionEntry struct {
+		session
+		DeviceName     string `json:"device_name"`
+		DevicePlatform string `json:"device_platform"`
+	}
+	list := []sessionEntry{}
+	for _, s := range sessions {
+		if s.DID != current.DID || s.Status != "active" {
+			continue
+		}
+		entry := sessionEntry{session: *s}
+		entry.Current = s.ID == current.ID
+		if d := devices[s.DeviceID]; d != nil {
+			entry.DeviceName, entry.DevicePlatform = d.Name, d.Platform
+		}
+		list = append(list, entry)
+	}
+	devicesMu.Unlock()
+
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].LastSeenAt != list[j].LastSeenAt {
+			return list[i].LastSeenAt > list[j].LastSeenAt
+		}
+		return list[i].ID < list[j].ID
+	})
+	writeDevice(w, http.StatusOK, map[string]interface{}{"did": current.DID, "sessions": list})
+}
+
+// Handler for DELETE /api/sessions/{id}. Revokes one session of the
+// authenticated DID; revoking the current session signs out.
+func handleRevokeSession(w http.ResponseWriter, r *http.Request) {
+	current, ok := authenticatedSession(w, r)
+	if !ok {
+		return
+	}
+
+	devicesMu.Lock()
+	defer devicesMu.Unlock()
+	s := sessions[mux.Vars(r)["id"]]
+	if s == nil || s.DID != current.DID {
+		http.Error(w, "Session not found", http.StatusNotFound)
+		return
+	}
+	if s.Status != "active" {
+		http.Error(w, "Session is already revoked", http.StatusConflict)
+		return
+	}
+	reason := "remote_logout"
+	if s.ID == current.ID {
+		reason = "signed_out"
+	}
+	revokeSession(s, reason)
+
+	log.Printf("Session %s of %s revoked (%s)", s.ID, s.DID, reason)
+	writeDevice(w, http.StatusOK, map[string]interface{}{"session": *s})
+}
+
+// Handler for DELETE /api/sessions. Revokes every active session of the
+// authenticated DID except the current one.
+func handleRevokeOtherSessions(w http.ResponseWriter, r *http.Request) {
+	current, ok := authenticatedSession(w, r)
+	if !ok {
+		return
+	}
+
+	devicesMu.Lock()
+	revoked := 0
+	for _, s := range sessions {
+		if s.DID == current.DID && s.ID != current.ID && s.Status == "active" {
+			revokeSession(s, "remote_logout")
+			revoked++
+		}
+	}
+	devicesMu.Unlock()
+
+	log.Printf("Signed %s out of %d other sessions", current.DID, revoked)
+	writeDevice(w, http.StatusOK, map[string]interface{}{"current_session": current.ID, "revoked_sessions": revoked})
+}
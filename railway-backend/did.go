@@ -0,0 +1,668 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+
+	"github.com/gorilla/mux"
+)
+
+// deactivatedDIDGone makes REST reads of a deactivated DID return 410 Gone
+// (DEACTIVATED_DID_GONE=true). The body still carries the document.
+var deactivatedDIDGone = os.Getenv("DEACTIVATED_DID_GONE") == "true"
+
+func init() {
+	RegisterModule(newDIDModule)
+}
+
+// didStore holds created DID documents.
+type didStore struct {
+	// DID documents keyed by DID ID
+	Documents map[string]map[string]interface{} `json:"documents"`
+	// Wallet address to DID ID for easy lookup
+	ByController map[string]string `json:"by_controller"`
+	// Recorded versions of each DID document, oldest first
+	Versions map[string][]didVersion `json:"versions"`
+}
+
+func (s *didStore) Reset() {
+	s.Documents = make(map[string]map[string]interface{})
+	s.ByController = make(map[string]string)
+	s.Versions = make(map[string][]didVersion)
+}
+
+// didDocumentFields are the DID Core properties stored verbatim from
+// submitted documents.
+var didDocumentFields = []string{"verificationMethod", "authentication", "assertionMethod", "service"}
+
+// didModule implements the persona did module.
+type didModule struct {
+	chain *Chain
+	store *didStore
+}
+
+func newDIDModule(c *Chain) Module {
+	store := &didStore{}
+	store.Reset()
+	return &didModule{chain: c, store: store}
+}
+
+func (m *didModule) Name() string { return "did" }
+
+func (m *didModule) Store() ModuleStore { return m.store }
+
+func (m *didModule) EventTypes() []string {
+	return []string{"did.created", "did.updated", "did.key_rotated", "did.service_added", "did.service_removed", "did.deactivated"}
+}
+
+func (m *didModule) RegisterMsgs(reg *MsgRegistry) {
+	registerMsg(reg, "/persona.did.v1.MsgCreateDid", m.handleMsgCreateDid)
+	registerMsg(reg, "/persona.did.v1.MsgUpdateDid", m.handleMsgUpdateDid)
+	registerMsg(reg, "/persona.did.v1.MsgRotateKey", m.handleMsgRotateKey)
+	registerMsg(reg, "/persona.did.v1.MsgAddService", m.handleMsgAddService)
+	registerMsg(reg, "/persona.did.v1.MsgRemoveService", m.handleMsgRemoveService)
+	registerMsg(reg, "/persona.did.v1.MsgDeactivateDid", m.handleMsgDeactivateDid)
+}
+
+func (m *didModule) RegisterRoutes(r *mux.Router) {
+	r.HandleFunc("/persona/did/v1beta1/did_documents", m.handleListDIDs).Methods("GET", "OPTIONS")
+	r.HandleFunc("/persona/did/v1beta1/did_documents/{id}", m.handleGetDID).Methods("GET", "OPTIONS")
+	r.HandleFunc("/persona/did/v1beta1/did_documents/{id}/versions", m.handleListDIDVersions).Methods("GET", "OPTIONS")
+	r.HandleFunc("/persona/did/v1beta1/did_by_controller/{controller}", m.handleGetDIDByController).Methods("GET", "OPTIONS")
+	r.HandleFunc("/1.0/identifiers/{did}", m.handleResolveDID).Methods("GET", "OPTIONS")
+}
+
+func (m *didModule) ExportGenesis() interface{} {
+	didDocuments := []map[string]interface{}{}
+	for _, id := range sortedMapKeys(m.store.Documents) {
+		did := m.store.Documents[id]
+		document, _ := json.Marshal(did)
+		didDocuments = append(didDocuments, map[string]interface{}{
+			"id":           did["id"],
+			"controller":   did["controller"],
+			"did_document": string(document),
+			"created_at":   did["created_at"],
+			"updated_at":   did["updated_at"],
+			"is_active":    did["is_active"],
+		})
+	}
+	return map[string]interface{}{
+		"params":        map[string]interface{}{},
+		"did_documents": didDocuments,
+	}
+}
+
+func (m *didModule) SeedKeys() []string { return []string{"dids"} }
+
+// Seed stores DID documents as given; each needs an id and a controller.
+func (m *didModule) Seed(key string, records []map[string]interface{}) error {
+	now := m.chain.now().Unix()
+	for i, record := range records {
+		didId, _ := record["id"].(string)
+		controller, _ := record["controller"].(string)
+		if didId == "" || controller == "" {
+			return fmt.Errorf("record %d: id and controller are required", i)
+		}
+		document := map[string]interface{}{"created_at": now, "updated_at": now, "is_active": true}
+		for key, value := range record {
+			document[key] = value
+		}
+		m.store.Documents[didId] = document
+		m.store.ByController[controller] = didId
+		m.recordVersion("", didId, "created", nil)
+	}
+	return nil
+}
+
+// lookupByController returns the DID document controlled by address.
+// Must be called with the chain lock held.
+func (m *didModule) lookupByController(address string) map[string]interface{} {
+	if didId, exists := m.store.ByController[address]; exists {
+		return m.store.Documents[didId]
+	}
+	return nil
+}
+
+// controllerOf returns the controller address of a stored DID.
+// Must be called with the chain lock held.
+func (m *didModule) controllerOf(didId string) string {
+	for controller, id := range m.store.ByController {
+		if id == didId {
+			return controller
+		}
+	}
+	return ""
+}
+
+func (m *didModule) handleListDIDs(w http.ResponseWriter, r *http.Request) {
+	// Start with the default mock DIDs
+	mockDIDs := []map[string]interface{}{
+		{
+			"id":         "did:persona:123",
+			"controller": "cosmos1test1",
+			"created_at": m.chain.now().Unix(),
+			"updated_at": m.chain.now().Unix(),
+			"is_active":  true,
+		},
+		{
+			"id":         "did:persona:456",
+			"controller": "cosmos1test2",
+			"created_at": m.chain.now().Unix(),
+			"updated_at": m.chain.now().Unix(),
+			"is_active":  true,
+		},
+	}
+
+	pageReq, err := parsePageRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	m.chain.mu.RLock()
+	defer m.chain.mu.RUnlock()
+
+	// Add any created DIDs from the request's region
+	for _, did := range m.store.Documents {
+		if residencyVisible(r, did) {
+			mockDIDs = append(mockDIDs, did)
+		}
+	}
+
+	page, pagination := paginate(mockDIDs, keyByID, pageReq)
+
+	response := map[string]interface{}{
+		"did_documents": page,
+		"pagination":    pagination,
+	}
+
+	log.Printf("Returning %d of %d DIDs (including %d created)", len(page), len(mockDIDs), len(m.store.Documents))
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (m *didModule) handleGetDID(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	// Check if it's a created DID first
+	m.chain.mu.RLock()
+	if did, exists := m.store.Documents[id]; exists {
+		if residencyDenied(w, r, did) {
+			m.chain.mu.RUnlock()
+			return
+		}
+		version, failure := m.selectDIDVersion(id, r.URL.Query())
+		if failure != nil {
+			m.chain.mu.RUnlock()
+			grpcCode := 5
+			if failure.status == http.StatusBadRequest {
+				grpcCode = 3
+			}
+			writeGRPCError(w, failure.status, grpcCode, failure.message)
+			return
+		}
+		if version != nil {
+			did = version.Document
+		}
+		status := http.StatusOK
+		if deactivatedDIDGone && did["is_active"] == false {
+			status = http.StatusGone
+		}
+		m.writeDIDResolution(w, r, did, "chain", status)
+		m.chain.mu.RUnlock()
+		return
+	}
+	m.chain.mu.RUnlock()
+
+	// Try the upstream node before inventing a mock DID
+	if upstream, ok := fetchUpstream(r.URL.Path); ok {
+		writeUpstream(w, upstream)
+		return
+	}
+
+	// Fallback to mock DID
+	mockDID := map[string]interface{}{
+		"id":         id,
+		"controller": "cosmos1test1",
+		"created_at": m.chain.now().Unix(),
+		"updated_at": m.chain.now().Unix(),
+		"is_active":  true,
+	}
+	m.writeDIDResolution(w, r, mockDID, "mock", http.StatusOK)
+}
+
+func (m *didModule) handleGetDIDByController(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	controller := vars["controller"]
+
+	log.Printf("Looking up DID for controller: %s", controller)
+
+	// Check if this controller has a DID
+	m.chain.mu.RLock()
+	if did := m.lookupByController(controller); did != nil {
+		if residencyDenied(w, r, did) {
+			m.chain.mu.RUnlock()
+			return
+		}
+		log.Printf("Found DID for controller %s: %s", controller, did["id"])
+		m.writeDIDResolution(w, r, did, "chain", http.StatusOK)
+		m.chain.mu.RUnlock()
+		return
+	}
+	m.chain.mu.RUnlock()
+
+	if upstream, ok := fetchUpstream(r.URL.Path); ok {
+		writeUpstream(w, upstream)
+		return
+	}
+
+	// No DID found for this controller
+	log.Printf("No DID found for controller: %s", controller)
+	m.writeDIDMiss(w, controller)
+}
+
+type MsgCreateDid struct {
+	Creator     string     `json:"creator"`
+	DidID       string     `json:"did_id"`
+	DidDocument jsonObject `json:"did_document"`
+}
+
+func (m MsgCreateDid) ValidateBasic() error {
+	if m.DidDocument == nil {
+		return errors.New("did_document is required")
+	}
+	if id, _ := m.DidDocument["id"].(string); id == "" {
+		return errors.New("did_document.id is required")
+	}
+	if controller, _ := m.DidDocument["controller"].(string); controller == "" {
+		return errors.New("did_document.controller is required")
+	}
+	return nil
+}
+
+func (m *didModule) handleMsgCreateDid(ctx *msgContext, msg MsgCreateDid) *txError {
+	didId := msg.DidDocument["id"].(string)
+	controller := msg.DidDocument["controller"].(string)
+	if _, exists := m.store.Documents[didId]; exists {
+		return txErrorf(codeInvalidRequest, "DID %s already exists", didId)
+	}
+
+	// Store the DID
+	document := map[string]interface{}{
+		"id":         didId,
+		"controller": controller,
+		"created_at": m.chain.now().Unix(),
+		"updated_at": m.chain.now().Unix(),
+		"is_active":  true,
+	}
+	if ctx.Residency != "" {
+		document["residency"] = ctx.Residency
+	}
+	for _, field := range didDocumentFields {
+		if value, ok := msg.DidDocument[field].([]interface{}); ok {
+			document[field] = value
+		}
+	}
+	m.store.Documents[didId] = document
+	// Map controller to DID for easy lookup
+	m.store.ByController[controller] = didId
+	m.recordVersion(ctx.TxHash, didId, "created", nil)
+	ctx.emit("did.created", controller, didId, m.store.Documents[didId])
+	ctx.event("did.created", "did_id", didId, "controller", controller)
+	log.Printf("Stored DID: %s for controller: %s", didId, controller)
+	return nil
+}
+
+type MsgUpdateDid struct {
+	Creator     string     `json:"creator"`
+	DidDocument jsonObject `json:"did_document"`
+}
+
+func (m MsgUpdateDid) ValidateBasic() error {
+	if m.Creator == "" {
+		return errors.New("creator is required")
+	}
+	if m.DidDocument == nil {
+		return errors.New("did_document is required")
+	}
+	if id, _ := m.DidDocument["id"].(string); id == "" {
+		return errors.New("did_document.id is required")
+	}
+	return nil
+}
+
+// handleMsgUpdateDid merges verification methods and services from the
+// submitted document into the stored one and replaces the verification
+// relationships it carries. Only the original controller, signing as
+// creator, may update a DID, and not once it is deactivated.
+func (m *didModule) handleMsgUpdateDid(ctx *msgContext, msg MsgUpdateDid) *txError {
+	didId := msg.DidDocument["id"].(string)
+
+	stored, exists := m.store.Documents[didId]
+	if !exists {
+		return txErrorf(codeNotFound, "DID %s", didId)
+	}
+
+	if msg.Creator != stored["controller"] {
+		return txErrorf(codeUnauthorized, "%s is not the controller of %s", msg.Creator, didId)
+	}
+	if stored["is_active"] == false {
+		return txErrorf(codeInvalidRequest, "DID %s is deactivated", didId)
+	}
+
+	for _, field := range []string{"verificationMethod", "service"} {
+		if updates, ok := msg.DidDocument[field].([]interface{}); ok {
+			existing, _ := stored[field].([]interface{})
+			stored[field] = mergeByID(existing, updates)
+		}
+	}
+	for _, field := range []string{"authentication", "assertionMethod"} {
+		if references, ok := msg.DidDocument[field].([]interface{}); ok {
+			stored[field] = references
+		}
+	}
+	stored["updated_at"] = m.chain.now().Unix()
+	m.recordVersion(ctx.TxHash, didId, "updated", nil)
+	ctx.emit("did.updated", msg.Creator, didId, stored)
+
+	log.Printf("Updated DID: %s by controller: %s", didId, msg.Creator)
+	return nil
+}
+
+type MsgRotateKey struct {
+	Creator               string     `json:"creator"`
+	DidID                 string     `json:"did_id"`
+	KeyID                 string     `json:"key_id"`
+	NewVerificationMethod jsonObject `json:"new_verification_method"`
+}
+
+func (m MsgRotateKey) ValidateBasic() error {
+	if m.DidID == "" {
+		return errors.New("did_id is required")
+	}
+	if m.KeyID == "" {
+		return errors.New("key_id is required")
+	}
+	if m.NewVerificationMethod == nil {
+		return errors.New("new_verification_method is required")
+	}
+	for _, field := range []string{"id", "type"} {
+		if value, _ := m.NewVerificationMethod[field].(string); value == "" {
+			return fmt.Errorf("new_verification_method.%s is required", field)
+		}
+	}
+	return nil
+}
+
+// handleMsgRotateKey replaces the verification method key_id with a new
+// one, repointing authentication and assertionMethod references, and
+// records the rotation as a new document version.
+func (m *didModule) handleMsgRotateKey(ctx *msgContext, msg MsgRotateKey) *txError {
+	stored, exists := m.store.Documents[msg.DidID]
+	if !exists {
+		return txErrorf(codeNotFound, "DID %s", msg.DidID)
+	}
+	if msg.Creator != stored["controller"] {
+		return txErrorf(codeUnauthorized, "%s is not the controller of %s", msg.Creator, msg.DidID)
+	}
+	if stored["is_active"] == false {
+		return txErrorf(codeInvalidRequest, "DID %s is deactivated", msg.DidID)
+	}
+
+	methods, _ := stored["verificationMethod"].([]interface{})
+	oldID := didURL(msg.DidID, msg.KeyID)
+	newID := didURL(msg.DidID, msg.NewVerificationMethod["id"].(string))
+	index := -1
+	for i, entry := range methods {
+		method, _ := entry.(map[string]interface{})
+		id, _ := method["id"].(string)
+		switch didURL(msg.DidID, id) {
+		case oldID:
+			index = i
+		case newID:
+			return txErrorf(codeInvalidRequest, "verification method %s already exists on %s", newID, msg.DidID)
+		}
+	}
+	if index < 0 {
+		return txErrorf(codeNotFound, "verification method %s of %s", msg.KeyID, msg.DidID)
+	}
+
+	replacement := map[string]interface{}{"controller": msg.DidID}
+	for key, value := range msg.NewVerificationMethod {
+		replacement[key] = value
+	}
+	rotated := append([]interface{}{}, methods...)
+	rotated[index] = replacement
+	stored["verificationMethod"] = rotated
+	for _, field := range []string{"authentication", "assertionMethod"} {
+		references, _ := stored[field].([]interface{})
+		repointed := make([]interface{}, len(references))
+		for i, reference := range references {
+			repointed[i] = reference
+			if id, ok := reference.(string); ok && didURL(msg.DidID, id) == oldID {
+				repointed[i] = replacement["id"]
+			}
+		}
+		if references != nil {
+			stored[field] = repointed
+		}
+	}
+	stored["updated_at"] = m.chain.now().Unix()
+	m.recordVersion(ctx.TxHash, msg.DidID, "key_rotated", &didKeyRotation{OldKeyID: oldID, NewKeyID: newID})
+	ctx.emit("did.key_rotated", msg.Creator, msg.DidID, stored)
+
+	log.Printf("Rotated key %s to %s on DID %s", oldID, newID, msg.DidID)
+	return nil
+}
+
+type MsgAddService struct {
+	Creator string     `json:"creator"`
+	DidID   string     `json:"did_id"`
+	Service jsonObject `json:"service"`
+}
+
+func (m MsgAddService) ValidateBasic() error {
+	if m.DidID == "" {
+		return errors.New("did_id is required")
+	}
+	if m.Service == nil {
+		return errors.New("service is required")
+	}
+	for _, field := range []string{"id", "type"} {
+		if value, _ := m.Service[field].(string); value == "" {
+			return fmt.Errorf("service.%s is required", field)
+		}
+	}
+	switch endpoint := m.Service["serviceEndpoint"].(type) {
+	case string:
+		if endpoint == "" {
+			return errors.New("service.serviceEndpoint is required")
+		}
+	case map[string]interface{}, []interface{}:
+	default:
+		return errors.New("service.serviceEndpoint must be a URI, a map or a set")
+	}
+	return nil
+}
+
+// serviceIndex returns the index of the service with the given id (relative
+// or absolute) in a stored document, or -1.
+func serviceIndex(stored map[string]interface{}, didId, serviceID string) int {
+	services, _ := stored["service"].([]interface{})
+	for i, entry := range services {
+		service, _ := entry.(map[string]interface{})
+		if id, _ := service["id"].(string); didURL(didId, id) == didURL(didId, serviceID) {
+			return i
+		}
+	}
+	return -1
+}
+
+// handleMsgAddService adds a service endpoint (DIDComm messaging, a
+// credential repository, ...) to a DID document. Service ids are unique
+// per document; replacing one is a MsgRemoveService then MsgAddService.
+func (m *didModule) handleMsgAddService(ctx *msgContext, msg MsgAddService) *txError {
+	stored, exists := m.store.Documents[msg.DidID]
+	if !exists {
+		return txErrorf(codeNotFound, "DID %s", msg.DidID)
+	}
+	if msg.Creator != stored["controller"] {
+		return txErrorf(codeUnauthorized, "%s is not the controller of %s", msg.Creator, msg.DidID)
+	}
+	if stored["is_active"] == false {
+		return txErrorf(codeInvalidRequest, "DID %s is deactivated", msg.DidID)
+	}
+	serviceID := msg.Service["id"].(string)
+	if serviceIndex(stored, msg.DidID, serviceID) >= 0 {
+		return txErrorf(codeInvalidRequest, "service %s already exists on %s", didURL(msg.DidID, serviceID), msg.DidID)
+	}
+
+	service := map[string]interface{}{}
+	for key, value := range msg.Service {
+		service[key] = value
+	}
+	service["id"] = didURL(msg.DidID, serviceID)
+	services, _ := stored["service"].([]interface{})
+	stored["service"] = append(append([]interface{}{}, services...), service)
+	stored["updated_at"] = m.chain.now().Unix()
+	m.recordVersion(ctx.TxHash, msg.DidID, "service_added", nil)
+	ctx.emit("did.service_added", msg.Creator, msg.DidID, stored)
+
+	log.Printf("Added service %s to DID %s", service["id"], msg.DidID)
+	return nil
+}
+
+type MsgRemoveService struct {
+	Creator   string `json:"creator"`
+	DidID     string `json:"did_id"`
+	ServiceID string `json:"service_id"`
+}
+
+func (m MsgRemoveService) ValidateBasic() error {
+	if m.DidID == "" {
+		return errors.New("did_id is required")
+	}
+	if m.ServiceID == "" {
+		return errors.New("service_id is required")
+	}
+	return nil
+}
+
+// handleMsgRemoveService removes a service endpoint from a DID document.
+func (m *didModule) handleMsgRemoveService(ctx *msgContext, msg MsgRemoveService) *txError {
+	stored, exists := m.store.Documents[msg.DidID]
+	if !exists {
+		return txErrorf(codeNotFound, "DID %s", msg.DidID)
+	}
+	if msg.Creator != stored["controller"] {
+		return txErrorf(codeUnauthorized, "%s is not the controller of %s", msg.Creator, msg.DidID)
+	}
+	if stored["is_active"] == false {
+		return txErrorf(codeInvalidRequest, "DID %s is deactivated", msg.DidID)
+	}
+	index := serviceIndex(stored, msg.DidID, msg.ServiceID)
+	if index < 0 {
+		return txErrorf(codeNotFound, "service %s of %s", msg.ServiceID, msg.DidID)
+	}
+
+	services := stored["service"].([]interface{})
+	remaining := append(append([]interface{}{}, services[:index]...), services[index+1:]...)
+	if len(remaining) == 0 {
+		delete(stored, "service")
+	} else {
+		stored["service"] = remaining
+	}
+	stored["updated_at"] = m.chain.now().Unix()
+	m.recordVersion(ctx.TxHash, msg.DidID, "service_removed", nil)
+	ctx.emit("did.service_removed", msg.Creator, msg.DidID, stored)
+
+	log.Printf("Removed service %s from DID %s", didURL(msg.DidID, msg.ServiceID), msg.DidID)
+	return nil
+}
+
+type MsgDeactivateDid struct {
+	Creator string `json:"creator"`
+	DidID   string `json:"did_id"`
+	ID      string `json:"id"`
+}
+
+func (m MsgDeactivateDid) ValidateBasic() error {
+	if m.Creator == "" {
+		return errors.New("creator is required")
+	}
+	if m.DidID == "" && m.ID == "" {
+		return errors.New("did_id is required")
+	}
+	return nil
+}
+
+// handleMsgDeactivateDid marks the DID inactive; only its controller,
+// signing as creator, may. The document is kept so later reads show the
+// deactivated state.
+func (m *didModule) handleMsgDeactivateDid(ctx *msgContext, msg MsgDeactivateDid) *txError {
+	didId := msg.DidID
+	if didId == "" {
+		didId = msg.ID
+	}
+
+	stored, exists := m.store.Documents[didId]
+	if !exists {
+		return txErrorf(codeNotFound, "DID %s", didId)
+	}
+
+	if msg.Creator != stored["controller"] {
+		return txErrorf(codeUnauthorized, "%s is not the controller of %s", msg.Creator, didId)
+	}
+	if stored["is_active"] == false {
+		return txErrorf(codeInvalidRequest, "DID %s is already deactivated", didId)
+	}
+
+	now := m.chain.now().Unix()
+	stored["is_active"] = false
+	stored["deactivated_at"] = now
+	stored["updated_at"] = now
+	m.recordVersion(ctx.TxHash, didId, "deactivated", nil)
+	ctx.emit("did.deactivated", msg.Creator, didId, stored)
+
+	log.Printf("Deactivated DID: %s by controller: %s", didId, msg.Creator)
+	return nil
+}
+
+// sortedMapKeys returns the keys of a map in sorted order.
+func sortedMapKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// mergeByID replaces entries whose "id" matches an update and appends the rest.
+func mergeByID(existing, updates []interface{}) []interface{} {
+	merged := append([]interface{}{}, existing...)
+	for _, update := range updates {
+		updateObj, ok := update.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		replaced := false
+		for i, entry := range merged {
+			if entryObj, ok := entry.(map[string]interface{}); ok && entryObj["id"] != nil && entryObj["id"] == updateObj["id"] {
+				merged[i] = updateObj
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			merged = append(merged, updateObj)
+		}
+	}
+	return merged
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Caching headers and resolver metadata on DID reads, matching what the
+// production resolver sends so the frontend's resolver cache behaves the
+// same against both. A stored document is served with
+//
+//	Cache-Control: public, max-age=<DID_CACHE_MAX_AGE>, stale-while-revalidate=<DID_CACHE_STALE>
+//	ETag, Last-Modified (updated_at), X-Persona-Source: chain
+//
+// and answers a matching If-None-Match or If-Modified-Since with 304.
+// Invented mock documents are no-store. The body carries the same hints
+// as did_resolution_metadata, next to did_document_metadata.
+//
+// A did_by_controller miss is cacheable for DID_MISS_CACHE_TTL (default
+// 5s, 0 for no-cache), which absorbs the frontend's polling before
+// onboarding completes while a new DID still shows up within seconds.
+// DID_MISS_404=true answers misses with a gateway-style 404 NotFound and
+// Retry-After instead of 200 with a null did_document, to try that
+// contract out.
+
+var (
+	didCacheMaxAge = durationFromEnv("DID_CACHE_MAX_AGE", time.Minute)
+	didCacheStale  = durationFromEnv("DID_CACHE_STALE", 5*time.Minute)
+	didMissTTL     = durationFromEnv("DID_MISS_CACHE_TTL", 5*time.Second)
+	didMiss404     = os.Getenv("DID_MISS_404") == "true"
+)
+
+// didTimestamp reads a unix timestamp a document stores as int64 or, once
+// it has been through JSON, float64.
+func didTimestamp(value interface{}) (time.Time, bool) {
+	switch v := value.(type) {
+	case int64:
+		return time.Unix(v, 0).UTC(), true
+	case float64:
+		return time.Unix(int64(v), 0).UTC(), true
+	}
+	return time.Time{}, false
+}
+
+// didDocumentMetadata returns the DID Resolution document metadata of a
+// document.
+func didDocumentMetadata(doc map[string]interface{}) map[string]interface{} {
+	metadata := map[string]interface{}{"deactivated": doc["is_active"] == false}
+	if created, ok := didTimestamp(doc["created_at"]); ok {
+		metadata["created"] = created.Format(time.RFC3339)
+	}
+	if updated, ok := didTimestamp(doc["updated_at"]); ok {
+		metadata["updated"] = updated.Format(time.RFC3339)
+	}
+	return metadata
+}
+
+// didETag returns a strong ETag for a document's current content.
+func didETag(doc map[string]interface{}) string {
+	data, _ := json.Marshal(doc)
+	sum := sha256.Sum256(data)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// didNotModified reports whether the request's validators match the
+// document.
+func didNotModified(r *http.Request, etag string, updated time.Time, hasUpdated bool) bool {
+	if match := r.Header.Get("If-None-Match"); match != "" {
+		for _, candidate := range strings.Split(match, ",") {
+			candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+			if candidate == etag || candidate == "*" {
+				return true
+			}
+		}
+		return false
+	}
+	if since, err := http.ParseTime(r.Header.Get("If-Modified-Since")); err == nil && hasUpdated {
+		return !updated.After(since)
+	}
+	return false
+}
+
+// setDIDCacheHeaders sets the caching headers of a stored document and
+// returns the matching cache hints, and whether the request's validators
+// match it.
+func (m *didModule) setDIDCacheHeaders(w http.ResponseWriter, r *http.Request, doc map[string]interface{}) (map[string]interface{}, bool) {
+	header := w.Header()
+	etag := didETag(doc)
+	maxAge, stale := int(didCacheMaxAge.Seconds()), int(didCacheStale.Seconds())
+	header.Set("Cache-Control", fmt.Sprintf("public, max-age=%d, stale-while-revalidate=%d", maxAge, stale))
+	header.Set("ETag", etag)
+	updated, hasUpdated := didTimestamp(doc["updated_at"])
+	if hasUpdated {
+		header.Set("Last-Modified", updated.Format(http.TimeFormat))
+	}
+	cache := map[string]interface{}{
+		"cacheable":              true,
+		"max_age":                maxAge,
+		"stale_while_revalidate": stale,
+		"etag":                   etag,
+		"expires":                m.chain.now().Add(didCacheMaxAge).UTC().Format(time.RFC3339),
+	}
+	return cache, didNotModified(r, etag, updated, hasUpdated)
+}
+
+// writeDIDResolution writes doc with caching headers and resolution
+// metadata. source is "chain" for stored documents and "mock" for invented
+// ones, which are not cacheable. Must be called with the chain lock held
+// when doc is a stored document.
+func (m *didModule) writeDIDResolution(w http.ResponseWriter, r *http.Request, doc map[string]interface{}, source string, status int) {
+	resolution := map[string]interface{}{
+		"content_type": "application/did+json",
+		"retrieved":    m.chain.now().UTC().Format(time.RFC3339),
+		"source":       source,
+	}
+	header := w.Header()
+	header.Set("Content-Type", "application/json")
+	header.Set("X-Persona-Source", source)
+	if source == "mock" {
+		header.Set("Cache-Control", "no-store")
+		resolution["cache"] = map[string]interface{}{"cacheable": false}
+	} else {
+		var notModified bool
+		resolution["cache"], notModified = m.setDIDCacheHeaders(w, r, doc)
+		if status == http.StatusOK && notModified {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+	}
+
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"did_document":            doc,
+		"did_resolution_metadata": resolution,
+		"did_document_metadata":   m.documentMetadata(doc),
+	})
+}
+
+// writeDIDMiss answers a did_by_controller lookup that found nothing.
+func (m *didModule) writeDIDMiss(w http.ResponseWriter, controller string) {
+	ttl := int(didMissTTL.Seconds())
+	cache := map[string]interface{}{"cacheable": ttl > 0}
+	if ttl > 0 {
+		w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", ttl))
+		cache["max_age"] = ttl
+		cache["expires"] = m.chain.now().Add(didMissTTL).UTC().Format(time.RFC3339)
+	} else {
+		w.Header().Set("Cache-Control", "no-cache")
+	}
+	w.Header().Set("X-Persona-Source", "chain")
+
+	if didMiss404 {
+		w.Header().Set("Retry-After", strconv.Itoa(max(ttl, 1)))
+		writeGRPCError(w, http.StatusNotFound, 5, "no DID for controller "+controller)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"did_document": nil,
+		"did_resolution_metadata": map[string]interface{}{
+			"retrieved": m.chain.now().UTC().Format(time.RFC3339),
+			"source":    "chain",
+			"error":     "notFound",
+			"cache":     cache,
+		},
+	})
+}
@@ -0,0 +1,235 @@
+package main
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cosmos/btcutil/base58"
+)
+
+// did:key and did:web resolution next to did:persona, so flows that link
+// an external DID can be tested end to end.
+//
+// did:key documents are derived locally from the multibase key (Ed25519,
+// X25519, secp256k1, P-256 and BLS12-381 G2) as a single Multikey
+// verification method, and never change. did:web documents are fetched
+// from https://<host>/.well-known/did.json (or /<path>/did.json) and cached
+// for DID_WEB_CACHE_TTL, misses included. DID_WEB_FIXTURES switches did:web
+// to offline mode: documents are read from the same layout under that
+// directory, e.g. <dir>/example.com/.well-known/did.json, and the network
+// is never used.
+
+const multikeyContext = "https://w3id.org/security/multikey/v1"
+
+var (
+	didWebFixtures = os.Getenv("DID_WEB_FIXTURES")
+	didWebCacheTTL = durationFromEnv("DID_WEB_CACHE_TTL", 5*time.Minute)
+	didWebClient   = &http.Client{Timeout: 10 * time.Second}
+
+	didWebMu    sync.Mutex
+	didWebCache = make(map[string]upstreamEntry)
+)
+
+// externalDID is a document resolved by a method other than did:persona.
+type externalDID struct {
+	document map[string]interface{}
+	source   string // "derived", "network" or "fixture"
+	maxAge   time.Duration
+}
+
+// didResolveError is a DID Resolution error with its HTTP status.
+type didResolveError struct {
+	status  int
+	code    string
+	message string
+}
+
+func (e *didResolveError) Error() string {
+	return e.code + ": " + e.message
+}
+
+// didMethodResolvers resolves the methods the chain does not store.
+var didMethodResolvers = map[string]func(did string) (*externalDID, *didResolveError){
+	"key": resolveDIDKey,
+	"web": resolveDIDWeb,
+}
+
+// multicodecKeys are the public key codecs did:key supports, with their
+// key length and whether they are signing keys or key agreement keys.
+var multicodecKeys = map[uint64]struct {
+	name      string
+	size      int
+	agreement bool
+}{
+	0xed:   {"ed25519-pub", 32, false},
+	0xec:   {"x25519-pub", 32, true},
+	0xe7:   {"secp256k1-pub", 33, false},
+	0x1200: {"p256-pub", 33, false},
+	0xeb:   {"bls12_381-g2-pub", 96, false},
+}
+
+// resolveDIDKey derives the document of a did:key.
+func resolveDIDKey(did string) (*externalDID, *didResolveError) {
+	fingerprint := strings.TrimPrefix(did, "did:key:")
+	if !strings.HasPrefix(fingerprint, "z") || strings.Contains(fingerprint, ":") {
+		return nil, &didResolveError{http.StatusBadRequest, "invalidDid", "did:key must be a base58btc multibase key starting with z"}
+	}
+	decoded := base58.Decode(fingerprint[1:])
+	codec, n := binary.Uvarint(decoded)
+	if len(decoded) == 0 || n <= 0 {
+		return nil, &didResolveError{http.StatusBadRequest, "invalidDid", "did:key is not valid base58btc multicodec"}
+	}
+	key, supported := multicodecKeys[codec]
+	if !supported {
+		return nil, &didResolveError{http.StatusBadRequest, "invalidPublicKeyType", fmt.Sprintf("multicodec 0x%x is not a supported public key type", codec)}
+	}
+	publicKey := decoded[n:]
+	if len(publicKey) != key.size {
+		return nil, &didResolveError{http.StatusBadRequest, "invalidPublicKeyLength", fmt.Sprintf("%s keys are %d bytes, got %d", key.name, key.size, len(publicKey))}
+	}
+	if key.size == 33 && publicKey[0] != 0x02 && publicKey[0] != 0x03 {
+		return nil, &didResolveError{http.StatusBadRequest, "invalidPublicKey", key.name + " key must be a compressed point"}
+	}
+
+	methodID := did + "#" + fingerprint
+	document := map[string]interface{}{
+		"@context": []interface{}{didCoreContext, multikeyContext},
+		"id":       did,
+		"verificationMethod": []interface{}{map[string]interface{}{
+			"id":                 methodID,
+			"type":               "Multikey",
+			"controller":         did,
+			"publicKeyMultibase": fingerprint,
+		}},
+	}
+	relationships := []string{"authentication", "assertionMethod", "capabilityInvocation", "capabilityDelegation"}
+	if key.agreement {
+		relationships = []string{"keyAgreement"}
+	}
+	for _, relationship := range relationships {
+		document[relationship] = []interface{}{methodID}
+	}
+	// did:key documents are a pure function of the DID
+	return &externalDID{document: document, source: "derived", maxAge: 365 * 24 * time.Hour}, nil
+}
+
+// didWebLocation returns the host and path of a did:web document.
+func didWebLocation(did string) (string, []string, *didResolveError) {
+	parts := strings.Split(strings.TrimPrefix(did, "did:web:"), ":")
+	segments := make([]string, 0, len(parts))
+	for _, part := range parts {
+		segment, err := url.PathUnescape(part)
+		if err != nil || segment == "" || segment == "." || segment == ".." || strings.ContainsAny(segment, `/\`) {
+			return "", nil, &didResolveError{http.StatusBadRequest, "invalidDid", "did:web has an invalid segment " + part}
+		}
+		segments = append(segments, segment)
+	}
+	if host, err := url.Parse("https://" + segments[0]); err != nil || host.Host != segments[0] || host.Hostname() == "" {
+		return "", nil, &didResolveError{http.StatusBadRequest, "invalidDid", "did:web host " + segments[0] + " is not a valid host"}
+	}
+	path := segments[1:]
+	if len(path) == 0 {
+		path = []string{".well-known"}
+	}
+	return segments[0], append(path, "did.json"), nil
+}
+
+// resolveDIDWeb reads a did:web document from its fixture or its HTTPS
+// location.
+func resolveDIDWeb(did string) (*externalDID, *didResolveError) {
+	host, path, failure := didWebLocation(did)
+	if failure != nil {
+		return nil, failure
+	}
+
+	var document map[string]interface{}
+	source := "network"
+	if didWebFixtures != "" {
+		source = "fixture"
+		data, err := os.ReadFile(filepath.Join(append([]string{didWebFixtures, host}, path...)...))
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, &didResolveError{http.StatusNotFound, "notFound", "no did:web fixture for " + did}
+		}
+		if err == nil {
+			err = json.Unmarshal(data, &document)
+		}
+		if err != nil {
+			return nil, &didResolveError{http.StatusInternalServerError, "internalError", "reading did:web fixture: " + err.Error()}
+		}
+	} else {
+		location := "https://" + host + "/" + strings.Join(path, "/")
+		var err error
+		if document, err = fetchDIDWeb(location); err != nil {
+			return nil, &didResolveError{http.StatusInternalServerError, "internalError", "fetching " + location + ": " + err.Error()}
+		}
+		if document == nil {
+			return nil, &didResolveError{http.StatusNotFound, "notFound", location + " returned 404"}
+		}
+	}
+
+	if document["id"] != did {
+		return nil, &didResolveError{http.StatusInternalServerError, "invalidDidDocument", fmt.Sprintf("document id %v does not match %s", document["id"], did)}
+	}
+	return &externalDID{document: document, source: source, maxAge: didWebCacheTTL}, nil
+}
+
+// fetchDIDWeb returns the document at location, using the cache when
+// fresh. A 404 is cached as a nil document; other failures fall back to a
+// stale entry when there is one.
+func fetchDIDWeb(location string) (map[string]interface{}, error) {
+	didWebMu.Lock()
+	entry, cached := didWebCache[location]
+	didWebMu.Unlock()
+	if cached && time.Since(entry.fetchedAt) < didWebCacheTTL {
+		return entry.body, nil
+	}
+
+	document, err := getDIDWeb(location)
+	if err != nil {
+		if cached {
+			log.Printf("did:web fetch of %s failed, serving cached copy: %v", location, err)
+			return entry.body, nil
+		}
+		return nil, err
+	}
+	didWebMu.Lock()
+	didWebCache[location] = upstreamEntry{body: document, fetchedAt: time.Now()}
+	didWebMu.Unlock()
+	return document, nil
+}
+
+// getDIDWeb returns (nil, nil) when location responds 404.
+func getDIDWeb(location string) (map[string]interface{}, error) {
+	req, err := http.NewRequest("GET", location, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", didJSONType+", "+didLDJSONType+", application/json")
+	resp, err := didWebClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, &upstreamStatusError{status: resp.Status}
+	}
+	var document map[string]interface{}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&document); err != nil {
+		return nil, err
+	}
+	return document, nil
+}
@@ -0,0 +1,293 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// DID Resolution (W3C DID Resolution, Universal Resolver HTTP binding) at
+// GET /1.0/identifiers/{did}, for the frontend's universal-resolver
+// client. The result is
+//
+//	{"@context", "didDocument", "didDocumentMetadata", "didResolutionMetadata"}
+//
+// with the stored document rendered as a W3C DID document: the controller
+// account becomes a CAIP-10 blockchainAccountId verification method next
+// to any verification methods and services added by MsgUpdateDid or
+// MsgAddService. Errors are reported in didResolutionMetadata.error with
+// the status the Universal Resolver uses: invalidDid 400, notFound 404,
+// methodNotSupported 501 and representationNotSupported 406. A deactivated DID is a 410 with
+// didDocumentMetadata.deactivated. Accept: application/did+ld+json (or
+// application/did+json) returns the bare document. Stored documents carry
+// the same caching headers as the REST reads. did:key and did:web are
+// resolved by didMethodResolvers (didmethods.go).
+
+const (
+	didResolutionContext = "https://w3id.org/did-resolution/v1"
+	didCoreContext       = "https://www.w3.org/ns/did/v1"
+	didResolutionType    = `application/ld+json;profile="https://w3id.org/did-resolution"`
+	didLDJSONType        = "application/did+ld+json"
+	didJSONType          = "application/did+json"
+)
+
+var didSyntax = regexp.MustCompile(`^did:[a-z0-9]+:[A-Za-z0-9._:%-]*[A-Za-z0-9._-]$`)
+
+// didMethod returns the method of a syntactically valid DID.
+func didMethod(did string) (string, bool) {
+	if !didSyntax.MatchString(did) {
+		return "", false
+	}
+	return strings.SplitN(did, ":", 3)[1], true
+}
+
+// w3cDIDDocument renders a stored DID document in the DID Core data model.
+// Relative method ids and references are expanded to DID URLs, and stored
+// authentication and assertionMethod relationships are kept next to the
+// controller key; without them every method is in both.
+func w3cDIDDocument(doc map[string]interface{}, chainID string) map[string]interface{} {
+	id, _ := doc["id"].(string)
+	controllerKey := id + "#controller"
+	methods := []interface{}{}
+	if controller, _ := doc["controller"].(string); controller != "" {
+		methods = append(methods, map[string]interface{}{
+			"id":                  controllerKey,
+			"type":                "EcdsaSecp256k1RecoveryMethod2020",
+			"controller":          id,
+			"blockchainAccountId": "cosmos:" + chainID + ":" + controller,
+		})
+	}
+	references := []interface{}{controllerKey}
+	if stored, ok := doc["verificationMethod"].([]interface{}); ok {
+		for _, method := range stored {
+			object, ok := method.(map[string]interface{})
+			if !ok || object["id"] == nil {
+				methods = append(methods, method)
+				continue
+			}
+			expanded := map[string]interface{}{}
+			for key, value := range object {
+				expanded[key] = value
+			}
+			if methodID, ok := object["id"].(string); ok {
+				expanded["id"] = didURL(id, methodID)
+			}
+			methods = append(methods, expanded)
+			references = append(references, expanded["id"])
+		}
+	}
+	document := map[string]interface{}{
+		"@context":           []interface{}{didCoreContext},
+		"id":                 id,
+		"verificationMethod": methods,
+	}
+	for _, relationship := range []string{"authentication", "assertionMethod"} {
+		stored, ok := doc[relationship].([]interface{})
+		if !ok {
+			document[relationship] = references
+			continue
+		}
+		relationshipReferences := []interface{}{controllerKey}
+		for _, reference := range stored {
+			if methodID, ok := reference.(string); ok {
+				reference = didURL(id, methodID)
+			}
+			relationshipReferences = append(relationshipReferences, reference)
+		}
+		document[relationship] = relationshipReferences
+	}
+	if services, ok := doc["service"].([]interface{}); ok && len(services) > 0 {
+		expanded := make([]interface{}, len(services))
+		for i, entry := range services {
+			expanded[i] = entry
+			if service, ok := entry.(map[string]interface{}); ok {
+				if serviceID, ok := service["id"].(string); ok {
+					copied := map[string]interface{}{}
+					for key, value := range service {
+						copied[key] = value
+					}
+					copied["id"] = didURL(id, serviceID)
+					expanded[i] = copied
+				}
+			}
+		}
+		document["service"] = expanded
+	}
+	return document
+}
+
+// didResolutionAccept picks the representation for an Accept header:
+// the resolution result, or the bare document as didLDJSONType or
+// didJSONType. ok is false when none of them is acceptable.
+func didResolutionAccept(accept string) (string, bool) {
+	if accept == "" {
+		return didResolutionType, true
+	}
+	for _, part := range strings.Split(accept, ",") {
+		mediaType := strings.TrimSpace(part)
+		switch base := strings.TrimSpace(strings.SplitN(mediaType, ";", 2)[0]); {
+		case base == "application/ld+json" && strings.Contains(mediaType, "did-resolution"):
+			return didResolutionType, true
+		case base == didLDJSONType || base == didJSONType:
+			return base, true
+		case base == "application/json" || base == "application/ld+json" || base == "*/*" || base == "application/*":
+			return didResolutionType, true
+		}
+	}
+	return "", false
+}
+
+// requestDID returns the DID of a resolution request. mux decodes the
+// path, which would turn did:web's %3A port separator into a colon, so the
+// DID is read from the escaped path; a DID sent fully percent-encoded is
+// decoded once.
+func requestDID(r *http.Request) string {
+	// Cut rather than trim, so the route also works under a network prefix
+	_, did, _ := strings.Cut(r.URL.EscapedPath(), "/1.0/identifiers/")
+	if !strings.HasPrefix(did, "did:") {
+		if decoded, err := url.PathUnescape(did); err == nil {
+			return decoded
+		}
+		return mux.Vars(r)["did"]
+	}
+	return did
+}
+
+// Handler for GET /1.0/identifiers/{did}
+func (m *didModule) handleResolveDID(w http.ResponseWriter, r *http.Request) {
+	started := time.Now()
+	did := requestDID(r)
+	representation, acceptable := didResolutionAccept(r.Header.Get("Accept"))
+
+	metadata := map[string]interface{}{
+		"contentType": representation,
+		"retrieved":   m.chain.now().UTC().Format(time.RFC3339),
+		"did":         map[string]interface{}{"didString": did},
+	}
+	fail := func(status int, code, message string) {
+		metadata["error"] = code
+		metadata["errorMessage"] = message
+		metadata["contentType"] = didResolutionType
+		metadata["duration"] = time.Since(started).Milliseconds()
+		w.Header().Set("Content-Type", didResolutionType)
+		w.Header().Set("Cache-Control", "no-cache")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"@context":              didResolutionContext,
+			"didDocument":           nil,
+			"didDocumentMetadata":   map[string]interface{}{},
+			"didResolutionMetadata": metadata,
+		})
+	}
+
+	method, valid := didMethod(did)
+	switch {
+	case !acceptable:
+		fail(http.StatusNotAcceptable, "representationNotSupported", "supported representations are "+didResolutionType+", "+didLDJSONType+" and "+didJSONType)
+		return
+	case !valid:
+		fail(http.StatusBadRequest, "invalidDid", did+" is not a valid DID")
+		return
+	case method != "persona" && didMethodResolvers[method] == nil:
+		fail(http.StatusNotImplemented, "methodNotSupported", "DID method "+method+" is not supported")
+		return
+	}
+	metadata["did"] = map[string]interface{}{"didString": did, "method": method, "methodSpecificId": strings.SplitN(did, ":", 3)[2]}
+
+	if resolve := didMethodResolvers[method]; resolve != nil {
+		resolved, failure := resolve(did)
+		if failure != nil {
+			fail(failure.status, failure.code, failure.message)
+			return
+		}
+		etag := didETag(resolved.document)
+		w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(resolved.maxAge.Seconds())))
+		w.Header().Set("ETag", etag)
+		if didNotModified(r, etag, time.Time{}, false) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		metadata["source"] = resolved.source
+		metadata["cache"] = map[string]interface{}{"cacheable": true, "max_age": int(resolved.maxAge.Seconds()), "etag": etag}
+		m.writeResolvedDID(w, representation, http.StatusOK, resolved.document, map[string]interface{}{}, metadata, started)
+		return
+	}
+
+	// render builds the response from a stored or upstream document
+	var document, documentMetadata, cache map[string]interface{}
+	var status int
+	var notModified bool
+	render := func(stored map[string]interface{}) {
+		document = w3cDIDDocument(stored, m.chain.ChainID())
+		documentMetadata = m.documentMetadata(stored)
+		status = http.StatusOK
+		if stored["is_active"] == false {
+			status = http.StatusGone
+			if deactivated, ok := didTimestamp(stored["deactivated_at"]); ok {
+				documentMetadata["deactivatedAt"] = deactivated.Format(time.RFC3339)
+			}
+		}
+		cache, notModified = m.setDIDCacheHeaders(w, r, stored)
+	}
+
+	m.chain.mu.RLock()
+	if stored, exists := m.store.Documents[did]; exists {
+		if !residencyVisible(r, stored) {
+			m.chain.mu.RUnlock()
+			fail(http.StatusForbidden, "notAllowed", did+" resides in region "+recordResidency(stored)+"; set cross_region=true to resolve it")
+			return
+		}
+		version, failure := m.selectDIDVersion(did, r.URL.Query())
+		if failure != nil {
+			m.chain.mu.RUnlock()
+			fail(failure.status, failure.code, failure.message)
+			return
+		}
+		if version != nil {
+			stored = version.Document
+		}
+		render(stored)
+	}
+	m.chain.mu.RUnlock()
+	if document == nil {
+		if upstream, ok := fetchUpstream("/persona/did/v1beta1/did_documents/" + did); ok {
+			if stored, ok := upstream["did_document"].(map[string]interface{}); ok {
+				render(stored)
+			}
+		}
+	}
+	if document == nil {
+		fail(http.StatusNotFound, "notFound", "DID "+did+" not found")
+		return
+	}
+	if status == http.StatusOK && notModified {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	metadata["source"] = "chain"
+	metadata["cache"] = cache
+	m.writeResolvedDID(w, representation, status, document, documentMetadata, metadata, started)
+}
+
+// writeResolvedDID writes a resolved document as representation.
+func (m *didModule) writeResolvedDID(w http.ResponseWriter, representation string, status int, document, documentMetadata, metadata map[string]interface{}, started time.Time) {
+	metadata["duration"] = time.Since(started).Milliseconds()
+	w.Header().Set("Content-Type", representation)
+	w.WriteHeader(status)
+	if representation != didResolutionType {
+		json.NewEncoder(w).Encode(document)
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"@context":              didResolutionContext,
+		"didDocument":           document,
+		"didDocumentMetadata":   documentMetadata,
+		"didResolutionMetadata": metadata,
+	})
+}
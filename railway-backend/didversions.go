@@ -0,0 +1,169 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// DID document versions: a copy of the document is recorded on every
+// change (creation or seeding, MsgUpdateDid, MsgRotateKey, MsgAddService,
+// MsgRemoveService and deactivation), and GET /persona/did/v1beta1/did_documents/{id}/versions
+// lists them oldest first for the frontend's audit trail. ?rotations=true
+// lists only the key rotations.
+//
+// Reads and DID Resolution take the DID Core versionId and versionTime
+// parameters to return the document as it was: versionId selects a
+// version, versionTime (RFC 3339) the latest version at that time. The
+// document metadata carries versionId and, for past versions,
+// nextVersionId and nextUpdate.
+
+// didVersion is one recorded state of a DID document.
+type didVersion struct {
+	VersionID   int                    `json:"version_id"`
+	VersionTime int64                  `json:"version_time"`
+	TxHash      string                 `json:"tx_hash,omitempty"`
+	Change      string                 `json:"change"`
+	Rotation    *didKeyRotation        `json:"rotation,omitempty"`
+	Document    map[string]interface{} `json:"did_document"`
+}
+
+// didKeyRotation is the verification method a MsgRotateKey replaced.
+type didKeyRotation struct {
+	OldKeyID string `json:"old_key_id"`
+	NewKeyID string `json:"new_key_id"`
+}
+
+// didURL expands a relative verification method reference like "#key-1"
+// to a DID URL of did.
+func didURL(did, reference string) string {
+	if strings.HasPrefix(reference, "#") {
+		return did + reference
+	}
+	return reference
+}
+
+// recordVersion numbers the current document as a new version and appends
+// a copy of it to its history. change is "created", "updated",
+// "key_rotated", "service_added", "service_removed" or "deactivated". Must be called with the chain lock held.
+func (m *didModule) recordVersion(txHash, didId, change string, rotation *didKeyRotation) {
+	versions := m.store.Versions[didId]
+	stored := m.store.Documents[didId]
+	stored["version_id"] = len(versions) + 1
+
+	var document map[string]interface{}
+	data, _ := json.Marshal(stored)
+	json.Unmarshal(data, &document)
+	m.store.Versions[didId] = append(versions, didVersion{
+		VersionID:   len(versions) + 1,
+		VersionTime: m.chain.now().Unix(),
+		TxHash:      txHash,
+		Change:      change,
+		Rotation:    rotation,
+		Document:    document,
+	})
+}
+
+// didVersionID returns the version number a document carries, or 0 for
+// documents stored before versions were recorded.
+func didVersionID(doc map[string]interface{}) int {
+	switch v := doc["version_id"].(type) {
+	case int:
+		return v
+	case float64:
+		return int(v)
+	}
+	return 0
+}
+
+// selectDIDVersion returns the version of didId that the versionId and
+// versionTime parameters select, or nil when neither is set. Must be
+// called with the chain lock held.
+func (m *didModule) selectDIDVersion(didId string, query url.Values) (*didVersion, *didResolveError) {
+	versionID, versionTime := query.Get("versionId"), query.Get("versionTime")
+	if versionID == "" && versionTime == "" {
+		return nil, nil
+	}
+	versions := m.store.Versions[didId]
+	var selected *didVersion
+	if versionID != "" {
+		n, err := strconv.Atoi(versionID)
+		if err != nil {
+			return nil, &didResolveError{http.StatusBadRequest, "invalidDidUrl", "versionId must be a version number, got " + versionID}
+		}
+		if n < 1 || n > len(versions) {
+			return nil, &didResolveError{http.StatusNotFound, "notFound", fmt.Sprintf("%s has no version %d", didId, n)}
+		}
+		selected = &versions[n-1]
+	}
+	if versionTime != "" {
+		t, err := time.Parse(time.RFC3339, versionTime)
+		if err != nil {
+			return nil, &didResolveError{http.StatusBadRequest, "invalidDidUrl", "versionTime must be an RFC 3339 datetime, got " + versionTime}
+		}
+		if selected == nil {
+			for i := range versions {
+				if versions[i].VersionTime <= t.Unix() {
+					selected = &versions[i]
+				}
+			}
+		}
+		if selected == nil || selected.VersionTime > t.Unix() {
+			return nil, &didResolveError{http.StatusNotFound, "notFound", didId + " had no version at " + versionTime}
+		}
+	}
+	return selected, nil
+}
+
+// documentMetadata returns the DID Resolution document metadata of a
+// stored document or one of its versions. Must be called with the chain
+// lock held.
+func (m *didModule) documentMetadata(doc map[string]interface{}) map[string]interface{} {
+	metadata := didDocumentMetadata(doc)
+	versionID := didVersionID(doc)
+	if versionID == 0 {
+		return metadata
+	}
+	metadata["versionId"] = strconv.Itoa(versionID)
+	id, _ := doc["id"].(string)
+	if versions := m.store.Versions[id]; versionID < len(versions) {
+		next := versions[versionID]
+		metadata["nextVersionId"] = strconv.Itoa(next.VersionID)
+		metadata["nextUpdate"] = time.Unix(next.VersionTime, 0).UTC().Format(time.RFC3339)
+	}
+	return metadata
+}
+
+// Handler for GET /persona/did/v1beta1/did_documents/{id}/versions
+func (m *didModule) handleListDIDVersions(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	rotationsOnly := r.URL.Query().Get("rotations") == "true"
+
+	m.chain.mu.RLock()
+	defer m.chain.mu.RUnlock()
+	if m.store.Documents[id] == nil {
+		writeGRPCError(w, http.StatusNotFound, 5, "DID "+id+" not found")
+		return
+	}
+	if residencyDenied(w, r, m.store.Documents[id]) {
+		return
+	}
+	versions := []didVersion{}
+	for _, version := range m.store.Versions[id] {
+		if !rotationsOnly || version.Rotation != nil {
+			versions = append(versions, version)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"did_id":   id,
+		"versions": versions,
+	})
+}
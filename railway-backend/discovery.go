@@ -0,0 +1,137 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// Capability discovery: GET /.well-known/persona-configuration describes
+// this deployment (enabled modules and server features, credential
+// formats, proof systems, endpoints and limits), so the frontend
+// configures itself from the server it talks to instead of per-environment
+// constants. Endpoints are absolute URLs on the host the request reached.
+// GET /api/compat/selftest reports the same modules with a live check of
+// each feature, for when the frontend needs to know what actually works.
+
+const configurationVersion = "1"
+
+// Handler for GET /.well-known/persona-configuration
+func (c *Chain) handleConfiguration(w http.ResponseWriter, r *http.Request) {
+	base := requestBaseURL(r)
+	wsBase := "ws" + strings.TrimPrefix(base, "http")
+
+	modules := make([]string, 0, len(c.modules))
+	for _, m := range c.modules {
+		modules = append(modules, m.Name())
+	}
+	c.mu.RLock()
+	circuits := []string{}
+	for _, circuit := range c.zk().listCircuits() {
+		if active, _ := circuit["is_active"].(bool); active {
+			circuits = append(circuits, circuit["id"].(string))
+		}
+	}
+	c.mu.RUnlock()
+
+	proofMode := "mock"
+	if verifyProofs.Enabled() {
+		proofMode = "groth16"
+	}
+	reasonCodes := make([]string, len(revocationReasons))
+	for i, reason := range revocationReasons {
+		reasonCodes[i] = reason.Code
+	}
+	chainIDs := make([]string, 0, len(networks))
+	for _, n := range networks {
+		chainIDs = append(chainIDs, n.chain.ChainID())
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"version":     configurationVersion,
+		"api_version": compatAPIVersion,
+		"issuer":      base,
+		"chain_id":    c.ChainID(),
+		"networks":    chainIDs,
+		"denom":       c.denom,
+		"modules":     modules,
+		"messages":    c.msgs.TypeURLs(),
+		"did_methods": []string{"persona", "key", "web"},
+		"credential_formats": []map[string]interface{}{
+			{"format": "ldp_vc", "data_model": vcContextV1, "proof_types": []string{"any", bbsSignatureType}},
+			{"format": sdJWTType, "alg_values_supported": []string{"ES256"}, "key_binding": true},
+		},
+		"proof_systems": []map[string]interface{}{
+			{"type": "groth16", "curve": "bn254", "verification": proofMode, "circuits": circuits},
+			{"type": "bbs+", "signature": bbsSignatureType, "derived_proof": bbsProofType},
+			{"type": "sd-jwt", "digest": "sha-256"},
+		},
+		"revocation_reasons": reasonCodes,
+		// Negotiated through X-JSON-Casing, see casing.go
+		"json_casings": []string{"legacy", "snake", "camel"},
+		"endpoints": map[string]string{
+			"rest":                 base,
+			"rpc":                  base,
+			"websocket":            wsBase + "/websocket",
+			"activity_events":      base + "/events",
+			"graphql":              base + "/graphql",
+			"openapi":              base + "/openapi.json",
+			"chain_info":           base + "/chain-info",
+			"networks":             base + "/api/networks",
+			"did_resolution":       base + "/1.0/identifiers/{did}",
+			"credential_status":    base + "/persona/vc/v1beta1/credentials/{id}/status",
+			"credential_verify":    base + "/persona/vc/v1beta1/verify/batch",
+			"proof_verify":         base + "/persona/zk/v1beta1/verify",
+			"sd_jwt_issue":         base + "/persona/vc/v1beta1/sd-jwt/issue",
+			"sd_jwt_verify":        base + "/persona/vc/v1beta1/sd-jwt/verify",
+			"sd_jwt_jwks":          base + "/persona/vc/v1beta1/sd-jwt/jwks",
+			"bbs_derive":           base + "/persona/vc/v1beta1/derive",
+			"bbs_derive_verify":    base + "/persona/vc/v1beta1/derive/verify",
+			"bbs_keys":             base + "/persona/vc/v1beta1/bbs/keys/{did}",
+			"compat_selftest":      base + "/api/compat/selftest",
+			"disputes":             base + "/api/dids/{did}/disputes",
+			"revocation_reasons":   base + "/persona/vc/v1beta1/revocation_reasons",
+			"broadcast_tx":         base + "/cosmos/tx/v1beta1/txs",
+			"account_balances":     base + "/cosmos/bank/v1beta1/balances/{address}",
+			"policies":             base + "/api/policies",
+			"analytics_events":     base + "/api/analytics/events",
+			"security_events":      base + "/api/dids/{did}/security/events",
+			"device_registration":  base + "/api/dids/{did}/devices",
+			"oauth_token":          base + "/oauth/token",
+			"openid_configuration": base + "/.well-known/openid-configuration",
+			"contract_drift":       base + "/api/drift",
+			"trace":                base + "/api/trace/{id}",
+			"quotas":               base + "/api/quotas/{subject}",
+			"admin":                base + "/admin/v1",
+			"tenant_prefix":        base + "/t/{tenant}",
+		},
+		"limits": map[string]interface{}{
+			"page_limit_default":         defaultPageLimit,
+			"verify_batch_max":           verifyBatchMax,
+			"revocation_explanation_max": maxRevocationExplanation,
+			"dispute_text_max":           maxDisputeText,
+			"dispute_evidence_max":       maxDisputeEvidence,
+			"abuse_max_failures":         abuse.maxFailures,
+			"abuse_window_seconds":       int64(abuse.window.Seconds()),
+			"block_time_ms":              c.blockTime.Milliseconds(),
+			"broadcast_timeout_ms":       broadcastTimeout.Milliseconds(),
+		},
+		"features": map[string]bool{
+			"proof_verification":   verifyProofs.Enabled(),
+			"strict_signing":       strictSigning.Enabled(),
+			"data_residency":       residencyEnabled.Enabled(),
+			"grpc":                 os.Getenv("GRPC_PORT") != "",
+			"deterministic":        deterministic,
+			"admin_token_required": adminToken != "",
+			"read_through":         readThroughURL != "",
+			"dual_write":           dualWriteURL != "",
+			"api_key_required":     apiKeyAuth.Enabled(),
+			"oauth_required":       oauthRequired.Enabled(),
+			"contract_check":       contractFile != "",
+			"proxy_mode":           proxyMode != "",
+			"traffic_recording":    trafficRecording.Enabled(),
+		},
+	})
+}
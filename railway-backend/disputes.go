@@ -0,0 +1,494 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"persona-backend/idgen"
+
+	"github.com/gorilla/mux"
+)
+
+// Disputes of failed verifications, backing the frontend's appeal flow. A
+// holder opens a dispute on a proof that failed verification (proof_id) or
+// a rejected tx (tx_hash) of its DID's controller, attaches evidence, and
+// the relying party or an admin resolves it:
+//
+//	open -> under_review -> resolved (upheld or overturned)
+//	open, under_review -> withdrawn
+//
+// The relying party of a proof is its metadata.verifier, or relying_party
+// when opening the dispute. Resolving records the outcome only; an
+// overturned dispute does not change chain state. Every transition is kept
+// in the dispute's history. /admin/reset clears disputes.
+
+const (
+	disputeOpen        = "open"
+	disputeUnderReview = "under_review"
+	disputeResolved    = "resolved"
+	disputeWithdrawn   = "withdrawn"
+
+	maxDisputeText     = 2000
+	maxDisputeEvidence = 20
+)
+
+var (
+	disputeOutcomes      = []string{"upheld", "overturned"}
+	disputeEvidenceTypes = []string{"note", "document", "link", "credential"}
+)
+
+type disputeEvidence struct {
+	ID          string `json:"id"`
+	Type        string `json:"type"`
+	Description string `json:"description,omitempty"`
+	// URI is the document or link, CredentialID the presented credential
+	URI          string `json:"uri,omitempty"`
+	CredentialID string `json:"credential_id,omitempty"`
+	AddedBy      string `json:"added_by"`
+	AddedAt      int64  `json:"added_at"`
+}
+
+type disputeTransition struct {
+	Status string `json:"status"`
+	At     int64  `json:"at"`
+	By     string `json:"by"`
+	Note   string `json:"note,omitempty"`
+}
+
+type dispute struct {
+	ID  string `json:"id"`
+	DID string `json:"did"`
+	// ProofID or TxHash is the disputed verification
+	ProofID      string              `json:"proof_id,omitempty"`
+	TxHash       string              `json:"tx_hash,omitempty"`
+	FailureCause string              `json:"failure_cause,omitempty"`
+	RelyingParty string              `json:"relying_party,omitempty"`
+	Reason       string              `json:"reason"`
+	Status       string              `json:"status"`
+	Outcome      string              `json:"outcome,omitempty"`
+	Resolution   string              `json:"resolution,omitempty"`
+	ResolvedBy   string              `json:"resolved_by,omitempty"`
+	Evidence     []*disputeEvidence  `json:"evidence"`
+	History      []disputeTransition `json:"history"`
+	CreatedAt    int64               `json:"created_at"`
+	UpdatedAt    int64               `json:"updated_at"`
+}
+
+var (
+	disputesMu sync.Mutex
+	disputes   = make(map[string]*dispute)
+)
+
+func init() {
+	registerAdminState("disputes", func() interface{} {
+		disputesMu.Lock()
+		defer disputesMu.Unlock()
+		return disputeList(func(*dispute) bool { return true })
+	}, func() {
+		disputesMu.Lock()
+		defer disputesMu.Unlock()
+		disputes = make(map[string]*dispute)
+	})
+}
+
+// disputeList returns copies of the matching disputes, oldest first. Must
+// be called with disputesMu held.
+func disputeList(match func(*dispute) bool) []dispute {
+	list := []dispute{}
+	for _, id := range sortedMapKeys(disputes) {
+		if d := disputes[id]; match(d) {
+			list = append(list, d.copy())
+		}
+	}
+	sort.SliceStable(list, func(i, j int) bool { return list[i].CreatedAt < list[j].CreatedAt })
+	return list
+}
+
+func (d *dispute) copy() dispute {
+	copied := *d
+	copied.Evidence = append([]*disputeEvidence{}, d.Evidence...)
+	copied.History = append([]disputeTransition{}, d.History...)
+	return copied
+}
+
+// transition moves the dispute to a new status. Must be called with
+// disputesMu held.
+func (d *dispute) transition(status, by, note string) error {
+	allowed := false
+	switch status {
+	case disputeUnderReview:
+		allowed = d.Status == disputeOpen
+	case disputeResolved, disputeWithdrawn:
+		allowed = d.Status == disputeOpen || d.Status == disputeUnderReview
+	}
+	if !allowed {
+		return fmt.Errorf("dispute %s is %s and cannot become %s", d.ID, d.Status, status)
+	}
+	now := appClock.Now().Unix()
+	d.Status = status
+	d.UpdatedAt = now
+	d.History = append(d.History, disputeTransition{Status: status, At: now, By: by, Note: note})
+	return nil
+}
+
+// disputedVerification looks up the failed verification a dispute targets
+// and returns its failure cause and relying party.
+func (c *Chain) disputedVerification(did, proofID, txHash string) (cause, relyingParty string, status int, err error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	document, ok := c.did().store.Documents[did]
+	if !ok {
+		return "", "", http.StatusNotFound, fmt.Errorf("DID %s not found", did)
+	}
+	controller, _ := document["controller"].(string)
+
+	if proofID != "" {
+		for _, proof := range c.zk().store.ByController[controller] {
+			if proof["id"] != proofID {
+				continue
+			}
+			if verified, _ := proof["is_verified"].(bool); verified {
+				return "", "", http.StatusConflict, fmt.Errorf("proof %s passed verification", proofID)
+			}
+			cause, _ = proof["verification_error"].(string)
+			if metadata, ok := proof["metadata"].(map[string]interface{}); ok {
+				relyingParty, _ = metadata["verifier"].(string)
+			}
+			return cause, relyingParty, 0, nil
+		}
+		return "", "", http.StatusNotFound, fmt.Errorf("proof %s of %s not found", proofID, did)
+	}
+
+	tx := c.txsByHash[normalizeTxHash(txHash)]
+	if tx == nil || !containsString(tx.Senders, controller) {
+		return "", "", http.StatusNotFound, fmt.Errorf("tx %s of %s not found", txHash, did)
+	}
+	if tx.Response.Code == codeOK {
+		return "", "", http.StatusConflict, fmt.Errorf("tx %s succeeded", txHash)
+	}
+	return tx.Response.RawLog, "", 0, nil
+}
+
+// lookupDispute returns the dispute in the path, or writes a 404.
+func lookupDispute(w http.ResponseWriter, r *http.Request) *dispute {
+	id := mux.Vars(r)["id"]
+	d := disputes[id]
+	if d == nil {
+		http.Error(w, "Dispute "+id+" not found", http.StatusNotFound)
+	}
+	return d
+}
+
+func writeDispute(w http.ResponseWriter, status int, d *dispute) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{"dispute": d.copy()})
+}
+
+// Handler for POST /api/dids/{did}/disputes. Takes {"proof_id"} or
+// {"tx_hash"}, a reason, optional evidence and, for proofs without a
+// verifier, the relying_party.
+func (c *Chain) handleOpenDispute(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ProofID      string            `json:"proof_id"`
+		TxHash       string            `json:"tx_hash"`
+		RelyingParty string            `json:"relying_party"`
+		Reason       string            `json:"reason"`
+		Evidence     []disputeEvidence `json:"evidence"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+	if (req.ProofID == "") == (req.TxHash == "") {
+		http.Error(w, "Exactly one of proof_id and tx_hash is required", http.StatusBadRequest)
+		return
+	}
+	if req.Reason == "" || len(req.Reason) > maxDisputeText {
+		http.Error(w, fmt.Sprintf("reason is required, at most %d characters", maxDisputeText), http.StatusBadRequest)
+		return
+	}
+	if len(req.Evidence) > maxDisputeEvidence {
+		http.Error(w, fmt.Sprintf("At most %d pieces of evidence", maxDisputeEvidence), http.StatusBadRequest)
+		return
+	}
+	for _, evidence := range req.Evidence {
+		if err := validateDisputeEvidence(evidence); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	did := mux.Vars(r)["did"]
+	cause, relyingParty, status, err := c.disputedVerification(did, req.ProofID, req.TxHash)
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	if relyingParty == "" {
+		relyingParty = req.RelyingParty
+	}
+
+	disputesMu.Lock()
+	defer disputesMu.Unlock()
+	for _, existing := range disputes {
+		active := existing.Status == disputeOpen || existing.Status == disputeUnderReview
+		if active && existing.DID == did && existing.ProofID == req.ProofID && normalizeTxHash(existing.TxHash) == normalizeTxHash(req.TxHash) {
+			http.Error(w, "Dispute "+existing.ID+" is already open for this verification", http.StatusConflict)
+			return
+		}
+	}
+	now := appClock.Now().Unix()
+	d := &dispute{
+		ID:           idgen.NewWithPrefix("dispute"),
+		DID:          did,
+		ProofID:      req.ProofID,
+		TxHash:       req.TxHash,
+		FailureCause: cause,
+		RelyingParty: relyingParty,
+		Reason:       req.Reason,
+		Status:       disputeOpen,
+		Evidence:     []*disputeEvidence{},
+		History:      []disputeTransition{{Status: disputeOpen, At: now, By: did, Note: req.Reason}},
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	for _, evidence := range req.Evidence {
+		d.addEvidence(evidence, did, now)
+	}
+	disputes[d.ID] = d
+	log.Printf("%s opened dispute %s", did, d.ID)
+	writeDispute(w, http.StatusCreated, d)
+}
+
+func validateDisputeEvidence(evidence disputeEvidence) error {
+	if !containsString(disputeEvidenceTypes, evidence.Type) {
+		return fmt.Errorf("evidence type must be one of %s", strings.Join(disputeEvidenceTypes, ", "))
+	}
+	if len(evidence.Description) > maxDisputeText {
+		return fmt.Errorf("evidence description must be at most %d characters", maxDisputeText)
+	}
+	switch {
+	case evidence.Type == "note" && evidence.Description == "":
+		return fmt.Errorf("note evidence needs a description")
+	case (evidence.Type == "document" || evidence.Type == "link") && evidence.URI == "":
+		return fmt.Errorf("%s evidence needs a uri", evidence.Type)
+	case evidence.Type == "credential" && evidence.CredentialID == "":
+		return fmt.Errorf("credential evidence needs a credential_id")
+	}
+	return nil
+}
+
+// addEvidence attaches validated evidence. Must be called with disputesMu
+// held.
+func (d *dispute) addEvidence(evidence disputeEvidence, by string, now int64) *disputeEvidence {
+	evidence.ID = idgen.NewWithPrefix("evidence")
+	evidence.AddedBy = by
+	evidence.AddedAt = now
+	d.Evidence = append(d.Evidence, &evidence)
+	d.UpdatedAt = now
+	return &evidence
+}
+
+// Handler for GET /api/dids/{did}/disputes
+func handleListDIDDisputes(w http.ResponseWriter, r *http.Request) {
+	did := mux.Vars(r)["did"]
+	disputesMu.Lock()
+	list := disputeList(func(d *dispute) bool { return d.DID == did })
+	disputesMu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"did": did, "disputes": list})
+}
+
+// Handler for GET /api/disputes/{id}
+func handleGetDispute(w http.ResponseWriter, r *http.Request) {
+	disputesMu.Lock()
+	defer disputesMu.Unlock()
+	if d := lookupDispute(w, r); d != nil {
+		writeDispute(w, http.StatusOK, d)
+	}
+}
+
+// Handler for POST /api/disputes/{id}/evidence. Takes one piece of evidence
+// and who adds it (by, defaulting to the disputing DID), while the dispute
+// is open or under review.
+func handleAddDisputeEvidence(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		disputeEvidence
+		By string `json:"by"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+	if err := validateDisputeEvidence(req.disputeEvidence); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	disputesMu.Lock()
+	defer disputesMu.Unlock()
+	d := lookupDispute(w, r)
+	if d == nil {
+		return
+	}
+	if d.Status != disputeOpen && d.Status != disputeUnderReview {
+		http.Error(w, "Dispute "+d.ID+" is "+d.Status, http.StatusConflict)
+		return
+	}
+	if len(d.Evidence) >= maxDisputeEvidence {
+		http.Error(w, fmt.Sprintf("At most %d pieces of evidence", maxDisputeEvidence), http.StatusConflict)
+		return
+	}
+	by := req.By
+	if by == "" {
+		by = d.DID
+	}
+	d.addEvidence(req.disputeEvidence, by, appClock.Now().Unix())
+	writeDispute(w, http.StatusCreated, d)
+}
+
+// Handler for POST /api/disputes/{id}/withdraw. Takes an optional note.
+func handleWithdrawDispute(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Note string `json:"note"`
+	}
+	// The body is optional
+	json.NewDecoder(r.Body).Decode(&req)
+
+	disputesMu.Lock()
+	defer disputesMu.Unlock()
+	d := lookupDispute(w, r)
+	if d == nil {
+		return
+	}
+	if err := d.transition(disputeWithdrawn, d.DID, req.Note); err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	log.Printf("%s withdrew dispute %s", d.DID, d.ID)
+	writeDispute(w, http.StatusOK, d)
+}
+
+// Handler for POST /api/disputes/{id}/review. The relying party (resolver)
+// takes the dispute under review.
+func handleReviewDispute(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Resolver string `json:"resolver"`
+		Note     string `json:"note"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	disputesMu.Lock()
+	defer disputesMu.Unlock()
+	d := lookupDispute(w, r)
+	if d == nil || !relyingPartyAllowed(w, d, req.Resolver) {
+		return
+	}
+	if err := d.transition(disputeUnderReview, req.Resolver, req.Note); err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	writeDispute(w, http.StatusOK, d)
+}
+
+// relyingPartyAllowed writes a 403 and returns false unless the resolver is
+// the dispute's relying party. Anyone may act on disputes without one.
+func relyingPartyAllowed(w http.ResponseWriter, d *dispute, resolver string) bool {
+	if resolver == "" {
+		http.Error(w, "resolver is required", http.StatusBadRequest)
+		return false
+	}
+	if d.RelyingParty != "" && resolver != d.RelyingParty {
+		http.Error(w, "Only "+d.RelyingParty+" can act on dispute "+d.ID, http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
+type disputeResolution struct {
+	Resolver   string `json:"resolver"`
+	Outcome    string `json:"outcome"`
+	Resolution string `json:"resolution"`
+}
+
+// resolveDispute records the outcome. Must be called with disputesMu held.
+func resolveDispute(w http.ResponseWriter, d *dispute, req disputeResolution) {
+	if !containsString(disputeOutcomes, req.Outcome) {
+		http.Error(w, "outcome must be one of "+strings.Join(disputeOutcomes, ", "), http.StatusBadRequest)
+		return
+	}
+	if len(req.Resolution) > maxDisputeText {
+		http.Error(w, fmt.Sprintf("resolution must be at most %d characters", maxDisputeText), http.StatusBadRequest)
+		return
+	}
+	if err := d.transition(disputeResolved, req.Resolver, req.Resolution); err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	d.Outcome = req.Outcome
+	d.Resolution = req.Resolution
+	d.ResolvedBy = req.Resolver
+	log.Printf("%s resolved dispute %s: %s", req.Resolver, d.ID, req.Outcome)
+	writeDispute(w, http.StatusOK, d)
+}
+
+// Handler for POST /api/disputes/{id}/resolve. Takes {"resolver",
+// "outcome", "resolution"}; only the relying party can resolve.
+func handleResolveDispute(w http.ResponseWriter, r *http.Request) {
+	var req disputeResolution
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	disputesMu.Lock()
+	defer disputesMu.Unlock()
+	d := lookupDispute(w, r)
+	if d == nil || !relyingPartyAllowed(w, d, req.Resolver) {
+		return
+	}
+	resolveDispute(w, d, req)
+}
+
+// Handler for GET /admin/disputes. Filters on ?status= and
+// ?relying_party=.
+func handleListDisputes(w http.ResponseWriter, r *http.Request) {
+	status := r.URL.Query().Get("status")
+	relyingParty := r.URL.Query().Get("relying_party")
+	disputesMu.Lock()
+	list := disputeList(func(d *dispute) bool {
+		return (status == "" || d.Status == status) && (relyingParty == "" || d.RelyingParty == relyingParty)
+	})
+	disputesMu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"disputes": list})
+}
+
+// Handler for POST /admin/disputes/{id}/resolve. Resolves any dispute, as
+// resolver "admin" unless given.
+func handleAdminResolveDispute(w http.ResponseWriter, r *http.Request) {
+	var req disputeResolution
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+	if req.Resolver == "" {
+		req.Resolver = "admin"
+	}
+
+	disputesMu.Lock()
+	defer disputesMu.Unlock()
+	if d := lookupDispute(w, r); d != nil {
+		resolveDispute(w, d, req)
+	}
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/gorilla/mux"
+)
+
+// Custom domain simulation for white-label verifiers. A routing table maps
+// hosts (exact, or "*.example.com" for any subdomain) to relying parties;
+// requests arriving on a mapped host carry that relying party, so pointing
+// an /etc/hosts entry at the daemon exercises the custom-domain flow
+// locally. Mappings come from CUSTOM_DOMAINS (a JSON array) at startup and
+// are managed through /admin/domains.
+
+type customDomain struct {
+	Domain    string `json:"domain"`
+	RPID      string `json:"rp_id"`
+	CreatedAt int64  `json:"created_at"`
+}
+
+type rpContextKey struct{}
+
+var (
+	domainsMu      sync.Mutex
+	customDomains  = make(map[string]*customDomain)
+	startupDomains []*customDomain
+)
+
+func init() {
+	if raw := os.Getenv("CUSTOM_DOMAINS"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &startupDomains); err != nil {
+			log.Printf("Invalid CUSTOM_DOMAINS: %v", err)
+			startupDomains = nil
+		}
+	}
+	resetDomains()
+	registerAdminState("custom_domains", func() interface{} {
+		return snapshotDomains()
+	}, resetDomains)
+}
+
+func resetDomains() {
+	domainsMu.Lock()
+	defer domainsMu.Unlock()
+	customDomains = make(map[string]*customDomain)
+	for _, d := range startupDomains {
+		domain, err := normalizeDomain(d.Domain)
+		if err != nil || d.RPID == "" {
+			log.Printf("Skipping custom domain %q: domain and rp_id are required", d.Domain)
+			continue
+		}
+		customDomains[domain] = &customDomain{Domain: domain, RPID: d.RPID, CreatedAt: appClock.Now().Unix()}
+	}
+}
+
+// normalizeDomain lowercases a domain and checks it is a hostname, allowing
+// a leading "*." wildcard label.
+func normalizeDomain(domain string) (string, error) {
+	domain = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(domain)), ".")
+	name := strings.TrimPrefix(domain, "*.")
+	if name == "" || strings.ContainsAny(name, "/:* ") || (!strings.Contains(name, ".") && name != "localhost") {
+		return "", fmt.Errorf("invalid domain: %q", domain)
+	}
+	return domain, nil
+}
+
+// requestHost returns the host a request was addressed to, without port.
+// X-Forwarded-Host wins when the daemon runs behind a proxy.
+func requestHost(r *http.Request) string {
+	host := r.Host
+	if forwarded := r.Header.Get("X-Forwarded-Host"); forwarded != "" {
+		host = strings.TrimSpace(strings.Split(forwarded, ",")[0])
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.TrimSuffix(strings.ToLower(host), ".")
+}
+
+// resolveDomain returns the mapping for host: an exact match first, then
+// the nearest wildcard.
+func resolveDomain(host string) (customDomain, bool) {
+	domainsMu.Lock()
+	defer domainsMu.Unlock()
+	if d, ok := customDomains[host]; ok {
+		return *d, true
+	}
+	for name := host; strings.Contains(name, "."); {
+		name = name[strings.Index(name, ".")+1:]
+		if d, ok := customDomains["*."+name]; ok {
+			return *d, true
+		}
+	}
+	return customDomain{}, false
+}
+
+// customDomainMiddleware tags requests on a mapped host with its relying
+// party, both in the request context and the X-Persona-RP response header.
+func customDomainMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if d, ok := resolveDomain(requestHost(r)); ok {
+			w.Header().Set("X-Persona-RP", d.RPID)
+			r = r.WithContext(context.WithValue(r.Context(), rpContextKey{}, d.RPID))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// relyingPartyFromContext returns the relying party of the request's host.
+func relyingPartyFromContext(ctx context.Context) (string, bool) {
+	rp, ok := ctx.Value(rpContextKey{}).(string)
+	return rp, ok
+}
+
+func snapshotDomains() []customDomain {
+	domainsMu.Lock()
+	defer domainsMu.Unlock()
+	domains := make([]customDomain, 0, len(customDomains))
+	for _, domain := range sortedMapKeys(customDomains) {
+		domains = append(domains, *customDomains[domain])
+	}
+	return domains
+}
+
+// Handler for GET /api/domain, which reports the relying party configured
+// for the requesting host.
+func handleResolveDomain(w http.ResponseWriter, r *http.Request) {
+	host := requestHost(r)
+	w.Header().Set("Content-Type", "application/json")
+
+	rp, ok := relyingPartyFromContext(r.Context())
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"error": "Host is not mapped to a relying party",
+			"host":  host,
+		})
+		return
+	}
+	response := map[string]interface{}{
+		"host":  host,
+		"rp_id": rp,
+	}
+	if b, ok := lookupBranding(rp); ok {
+		response["branding"] = b
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// Handler for GET /api/branding, the branding of the requesting host's
+// relying party.
+func handleGetHostBranding(w http.ResponseWriter, r *http.Request) {
+	rp, ok := relyingPartyFromContext(r.Context())
+	if !ok {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"error": "Host is not mapped to a relying party",
+			"host":  requestHost(r),
+		})
+		return
+	}
+	handleGetBranding(w, mux.SetURLVars(r, map[string]string{"rp": rp}))
+}
+
+// Handler for GET /admin/domains
+func handleListDomains(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"domains": snapshotDomains()})
+}
+
+// Handler for PUT /admin/domains/{domain}
+func handlePutDomain(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		RPID string `json:"rp_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+	domain, err := normalizeDomain(mux.Vars(r)["domain"])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.RPID == "" {
+		http.Error(w, "rp_id is required", http.StatusBadRequest)
+		return
+	}
+
+	d := customDomain{Domain: domain, RPID: req.RPID, CreatedAt: appClock.Now().Unix()}
+	domainsMu.Lock()
+	existing, existed := customDomains[domain]
+	if existed {
+		d.CreatedAt = existing.CreatedAt
+	}
+	customDomains[domain] = &d
+	domainsMu.Unlock()
+
+	log.Printf("Custom domain %s mapped to %s", domain, d.RPID)
+	w.Header().Set("Content-Type", "application/json")
+	if !existed {
+		w.WriteHeader(http.StatusCreated)
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{"domain": d})
+}
+
+// Handler for DELETE /admin/domains/{domain}
+func handleDeleteDomain(w http.ResponseWriter, r *http.Request) {
+	domain, _ := normalizeDomain(mux.Vars(r)["domain"])
+
+	domainsMu.Lock()
+	_, ok := customDomains[domain]
+	delete(customDomains, domain)
+	domainsMu.Unlock()
+
+	if !ok {
+		http.Error(w, "Custom domain not found", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"removed": 1})
+}
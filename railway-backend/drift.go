@@ -0,0 +1,359 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/spf13/cobra"
+)
+
+// Contract drift detection. The frontend commits a contract artifact, the
+// response schemas of every operation and the component schemas as this
+// server generates them for /openapi.json, written by `make pin-contract`.
+// With CONTRACT_FILE pointing at it, the server compares its own schemas
+// against the pinned ones at startup and whenever the file changes. Any
+// difference is drift: /health answers 503, the contract preflight check
+// fails, and GET /api/drift lists each change, so a mock/frontend contract
+// mismatch fails CI before the E2E suite runs.
+//
+// Changes are breaking (an operation or property removed, a type, format,
+// enum or $ref changed, a property no longer required) or additive (an
+// operation or property added, a property newly required). With
+// CONTRACT_ALLOW_ADDITIONS=true only breaking changes count as drift.
+
+const contractVersion = 1
+
+type contract struct {
+	Version  int    `json:"version"`
+	PinnedAt string `json:"pinned_at"`
+	// Response schemas by "METHOD /path"
+	Operations map[string]interface{} `json:"operations"`
+	Schemas    map[string]interface{} `json:"schemas"`
+}
+
+type driftChange struct {
+	Location string      `json:"location"`
+	Kind     string      `json:"kind"` // breaking or additive
+	Change   string      `json:"change"`
+	Pinned   interface{} `json:"pinned,omitempty"`
+	Current  interface{} `json:"current,omitempty"`
+}
+
+type driftReport struct {
+	Enabled   bool          `json:"enabled"`
+	File      string        `json:"file,omitempty"`
+	PinnedAt  string        `json:"pinned_at,omitempty"`
+	CheckedAt int64         `json:"checked_at,omitempty"`
+	Drifted   bool          `json:"drifted"`
+	Breaking  int           `json:"breaking"`
+	Additive  int           `json:"additive"`
+	Changes   []driftChange `json:"changes"`
+	Error     string        `json:"error,omitempty"`
+}
+
+// Schema keywords compared as a whole; anything else (descriptions,
+// examples) is documentation and may change freely.
+var driftKeywords = []string{"type", "format", "$ref", "enum", "nullable", "oneOf", "discriminator"}
+
+var (
+	contractFile          = os.Getenv("CONTRACT_FILE")
+	contractAllowAddition = os.Getenv("CONTRACT_ALLOW_ADDITIONS") == "true"
+
+	driftMu     sync.Mutex
+	driftRouter *mux.Router
+	driftCached *driftReport
+	// Modification time and size of the contract file behind driftCached
+	driftFileStamp string
+)
+
+// startContractCheck compares the routes of router against CONTRACT_FILE
+// and logs the result. Called from main once every route is registered.
+func (c *Chain) startContractCheck(router *mux.Router) {
+	driftMu.Lock()
+	driftRouter = router
+	driftMu.Unlock()
+	if contractFile == "" {
+		return
+	}
+	report := c.contractDrift()
+	switch {
+	case report.Error != "":
+		log.Printf("Contract check failed: %s", report.Error)
+	case report.Drifted:
+		log.Printf("Contract drift against %s: %d breaking, %d additive changes, see /api/drift", contractFile, report.Breaking, report.Additive)
+	default:
+		log.Printf("Contract matches %s (pinned %s)", contractFile, report.PinnedAt)
+	}
+}
+
+// currentContract builds the contract of the running server.
+func (c *Chain) currentContract(router *mux.Router) (*contract, error) {
+	spec, err := c.buildOpenAPISpec(router, "")
+	if err != nil {
+		return nil, err
+	}
+	// Round trip, so both sides hold plain decoded JSON
+	raw, err := json.Marshal(spec)
+	if err != nil {
+		return nil, err
+	}
+	var decoded struct {
+		Paths      map[string]map[string]map[string]interface{} `json:"paths"`
+		Components struct {
+			Schemas map[string]interface{} `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		return nil, err
+	}
+	current := &contract{
+		Version:    contractVersion,
+		PinnedAt:   appClock.Now().UTC().Format(time.RFC3339),
+		Operations: make(map[string]interface{}),
+		Schemas:    decoded.Components.Schemas,
+	}
+	for path, methods := range decoded.Paths {
+		for method, op := range methods {
+			responses, _ := op["responses"].(map[string]interface{})
+			for _, status := range []string{"200", "101"} {
+				if response, ok := responses[status].(map[string]interface{}); ok {
+					current.Operations[strings.ToUpper(method)+" "+path] = responseSchema(response)
+					break
+				}
+			}
+		}
+	}
+	return current, nil
+}
+
+// responseSchema returns the schema of the first content type of a response.
+func responseSchema(response map[string]interface{}) interface{} {
+	content, _ := response["content"].(map[string]interface{})
+	for _, contentType := range sortedMapKeys(content) {
+		if media, ok := content[contentType].(map[string]interface{}); ok {
+			return media["schema"]
+		}
+	}
+	return map[string]interface{}{}
+}
+
+// contractDrift returns the drift report, recomputed when the contract
+// file changed since the last check.
+func (c *Chain) contractDrift() *driftReport {
+	if contractFile == "" {
+		return &driftReport{Enabled: false, Changes: []driftChange{}}
+	}
+	stamp := ""
+	if info, err := os.Stat(contractFile); err == nil {
+		stamp = fmt.Sprintf("%d/%d", info.ModTime().UnixNano(), info.Size())
+	}
+
+	driftMu.Lock()
+	defer driftMu.Unlock()
+	if driftCached != nil && stamp != "" && stamp == driftFileStamp {
+		return driftCached
+	}
+	report := &driftReport{Enabled: true, File: contractFile, CheckedAt: appClock.Now().Unix(), Changes: []driftChange{}}
+	fail := func(err error) *driftReport {
+		// An unreadable contract fails the check too
+		report.Drifted = true
+		report.Error = err.Error()
+		driftCached, driftFileStamp = report, stamp
+		return report
+	}
+	if driftRouter == nil {
+		report.Error = "routes are not registered yet"
+		return report
+	}
+	data, err := os.ReadFile(contractFile)
+	if err != nil {
+		return fail(err)
+	}
+	var pinned contract
+	if err := json.Unmarshal(data, &pinned); err != nil {
+		return fail(fmt.Errorf("contract %s: %v", contractFile, err))
+	}
+	if pinned.Version != contractVersion {
+		return fail(fmt.Errorf("contract %s has version %d, expected %d; pin it again", contractFile, pinned.Version, contractVersion))
+	}
+	current, err := c.currentContract(driftRouter)
+	if err != nil {
+		return fail(err)
+	}
+
+	report.PinnedAt = pinned.PinnedAt
+	diffSchemaSet("", pinned.Operations, current.Operations, &report.Changes)
+	diffSchemaSet("schema ", pinned.Schemas, current.Schemas, &report.Changes)
+	for _, change := range report.Changes {
+		if change.Kind == "breaking" {
+			report.Breaking++
+		} else {
+			report.Additive++
+		}
+	}
+	report.Drifted = report.Breaking > 0 || (report.Additive > 0 && !contractAllowAddition)
+	driftCached, driftFileStamp = report, stamp
+	return report
+}
+
+// diffSchemaSet compares named schemas: operations or components.
+func diffSchemaSet(prefix string, pinned, current map[string]interface{}, changes *[]driftChange) {
+	for _, name := range sortedMapKeys(pinned) {
+		if _, ok := current[name]; !ok {
+			*changes = append(*changes, driftChange{Location: prefix + name, Kind: "breaking", Change: "removed"})
+			continue
+		}
+		diffSchema(prefix+name, pinned[name], current[name], changes)
+	}
+	for _, name := range sortedMapKeys(current) {
+		if _, ok := pinned[name]; !ok {
+			*changes = append(*changes, driftChange{Location: prefix + name, Kind: "additive", Change: "added"})
+		}
+	}
+}
+
+// diffSchema compares two JSON schemas of a response, from the point of
+// view of a client reading it.
+func diffSchema(location string, pinned, current interface{}, changes *[]driftChange) {
+	p, _ := pinned.(map[string]interface{})
+	c, _ := current.(map[string]interface{})
+	if p == nil || c == nil {
+		if !reflect.DeepEqual(pinned, current) {
+			*changes = append(*changes, driftChange{Location: location, Kind: "breaking", Change: "changed", Pinned: pinned, Current: current})
+		}
+		return
+	}
+
+	for _, keyword := range driftKeywords {
+		if !reflect.DeepEqual(p[keyword], c[keyword]) {
+			*changes = append(*changes, driftChange{Location: location, Kind: "breaking", Change: keyword + "_changed", Pinned: p[keyword], Current: c[keyword]})
+		}
+	}
+
+	pinnedProps, _ := p["properties"].(map[string]interface{})
+	currentProps, _ := c["properties"].(map[string]interface{})
+	for _, name := range sortedMapKeys(pinnedProps) {
+		if _, ok := currentProps[name]; !ok {
+			*changes = append(*changes, driftChange{Location: location + "." + name, Kind: "breaking", Change: "removed"})
+			continue
+		}
+		diffSchema(location+"."+name, pinnedProps[name], currentProps[name], changes)
+	}
+	for _, name := range sortedMapKeys(currentProps) {
+		if _, ok := pinnedProps[name]; !ok {
+			*changes = append(*changes, driftChange{Location: location + "." + name, Kind: "additive", Change: "added"})
+		}
+	}
+
+	pinnedRequired, currentRequired := stringList(p["required"]), stringList(c["required"])
+	for _, name := range pinnedRequired {
+		if !containsString(currentRequired, name) {
+			*changes = append(*changes, driftChange{Location: location + "." + name, Kind: "breaking", Change: "no_longer_required"})
+		}
+	}
+	for _, name := range currentRequired {
+		if !containsString(pinnedRequired, name) {
+			*changes = append(*changes, driftChange{Location: location + "." + name, Kind: "additive", Change: "now_required"})
+		}
+	}
+
+	if p["items"] != nil || c["items"] != nil {
+		diffSchema(location+"[]", p["items"], c["items"], changes)
+	}
+	// additionalProperties is a boolean or the schema of map values
+	if !reflect.DeepEqual(p["additionalProperties"], c["additionalProperties"]) {
+		diffSchema(location+"{}", p["additionalProperties"], c["additionalProperties"], changes)
+	}
+}
+
+// stringList returns a decoded JSON array of strings, sorted.
+func stringList(value interface{}) []string {
+	items, _ := value.([]interface{})
+	list := make([]string, 0, len(items))
+	for _, item := range items {
+		if s, ok := item.(string); ok {
+			list = append(list, s)
+		}
+	}
+	sort.Strings(list)
+	return list
+}
+
+// Handler for GET /api/drift. Responds 200 with the report either way;
+// /health carries the pass or fail.
+func (c *Chain) handleDrift(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(c.contractDrift())
+}
+
+// Handler for GET /api/contract, the contract to pin for this server.
+func (c *Chain) handleContract(w http.ResponseWriter, r *http.Request) {
+	driftMu.Lock()
+	router := driftRouter
+	driftMu.Unlock()
+	current, err := c.currentContract(router)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(current)
+}
+
+// preflightContract fails when the server drifted from CONTRACT_FILE.
+func preflightContract(c *Chain) (string, error) {
+	report := c.contractDrift()
+	if !report.Enabled {
+		return "no contract pinned (CONTRACT_FILE)", nil
+	}
+	if report.Error != "" {
+		return "", fmt.Errorf("%s", report.Error)
+	}
+	if report.Drifted {
+		return "", fmt.Errorf("%d breaking and %d additive changes against %s, see /api/drift", report.Breaking, report.Additive, report.File)
+	}
+	return "matches " + report.File, nil
+}
+
+// newPinContractCommand implements `persona-mock pin-contract`, writing
+// the contract of a running daemon to a file.
+func newPinContractCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "pin-contract",
+		Short: "Pin the response contract for drift detection",
+		Args:  cobra.NoArgs,
+	}
+	url := cmd.Flags().String("url", "http://localhost:8080", "base URL of the running mock daemon")
+	out := cmd.Flags().String("out", "../src/lib/persona-contract.json", "contract file")
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		return runPinContract(*url, *out)
+	}
+	return cmd
+}
+
+func runPinContract(url, out string) error {
+	data, err := daemonRequest("GET", url, "", "/api/contract", nil)
+	if err != nil {
+		return err
+	}
+	var pinned contract
+	if err := json.Unmarshal(data, &pinned); err != nil {
+		return fmt.Errorf("daemon returned an invalid contract: %v", err)
+	}
+	if err := os.WriteFile(out, data, 0o644); err != nil {
+		return err
+	}
+	fmt.Printf("Pinned %d operations and %d schemas to %s\n", len(pinned.Operations), len(pinned.Schemas), out)
+	return nil
+}
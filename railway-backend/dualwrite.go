@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Dual-write mode: accepted broadcasts are also forwarded to a real LCD
+// (DUAL_WRITE_URL) on a best-effort basis. Outcomes are kept so the mock and
+// the real chain can be reconciled during the migration period.
+
+const maxDualWriteRecords = 1000
+
+type dualWriteRecord struct {
+	MockTxHash     string `json:"mock_txhash"`
+	MockCode       int    `json:"mock_code"`
+	UpstreamTxHash string `json:"upstream_txhash,omitempty"`
+	UpstreamCode   int    `json:"upstream_code"`
+	UpstreamLog    string `json:"upstream_raw_log,omitempty"`
+	Error          string `json:"error,omitempty"`
+	Status         string `json:"status"` // pending, matched, mismatched, failed
+	SubmittedAt    int64  `json:"submitted_at"`
+	CompletedAt    int64  `json:"completed_at,omitempty"`
+}
+
+var (
+	dualWriteURL    = strings.TrimSuffix(os.Getenv("DUAL_WRITE_URL"), "/")
+	dualWriteClient = &http.Client{Timeout: 30 * time.Second}
+
+	dualWriteMu      sync.Mutex
+	dualWriteRecords []*dualWriteRecord
+)
+
+func init() {
+	registerAdminState("dual_write", func() interface{} {
+		dualWriteMu.Lock()
+		defer dualWriteMu.Unlock()
+		records := make([]dualWriteRecord, 0, len(dualWriteRecords))
+		for _, record := range dualWriteRecords {
+			records = append(records, *record)
+		}
+		return records
+	}, func() {
+		dualWriteMu.Lock()
+		defer dualWriteMu.Unlock()
+		dualWriteRecords = nil
+	})
+}
+
+// forwardDualWrite sends an accepted tx to the upstream node in the
+// background. It never affects the mock's own response.
+func forwardDualWrite(body []byte, mockResponse MockTxResponse) {
+	if dualWriteURL == "" || mockResponse.Code != codeOK {
+		return
+	}
+
+	record := &dualWriteRecord{
+		MockTxHash:  mockResponse.TxHash,
+		MockCode:    mockResponse.Code,
+		Status:      "pending",
+		SubmittedAt: time.Now().Unix(),
+	}
+	dualWriteMu.Lock()
+	dualWriteRecords = append(dualWriteRecords, record)
+	if len(dualWriteRecords) > maxDualWriteRecords {
+		dualWriteRecords = dualWriteRecords[len(dualWriteRecords)-maxDualWriteRecords:]
+	}
+	dualWriteMu.Unlock()
+
+	go func() {
+		status, upstream, err := postUpstreamTx(body)
+
+		dualWriteMu.Lock()
+		defer dualWriteMu.Unlock()
+		record.CompletedAt = time.Now().Unix()
+		if err != nil {
+			record.Status = "failed"
+			record.Error = err.Error()
+			log.Printf("Dual-write of %s failed: %v", record.MockTxHash, err)
+			return
+		}
+		record.UpstreamTxHash = upstream.TxHash
+		record.UpstreamCode = upstream.Code
+		record.UpstreamLog = upstream.RawLog
+		if status/100 == 2 && upstream.Code == record.MockCode {
+			record.Status = "matched"
+		} else {
+			record.Status = "mismatched"
+			log.Printf("Dual-write mismatch for %s: upstream HTTP %d code %d", record.MockTxHash, status, upstream.Code)
+		}
+	}()
+}
+
+func postUpstreamTx(body []byte) (int, MockTxResponse, error) {
+	var upstream MockTxResponse
+	resp, err := dualWriteClient.Post(dualWriteURL+"/cosmos/tx/v1beta1/txs", "application/json", bytes.NewReader(body))
+	if err != nil {
+		return 0, upstream, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return resp.StatusCode, upstream, err
+	}
+	// Real LCDs wrap the result in tx_response
+	var wrapped struct {
+		TxResponse *MockTxResponse `json:"tx_response"`
+	}
+	if json.Unmarshal(data, &wrapped) == nil && wrapped.TxResponse != nil {
+		return resp.StatusCode, *wrapped.TxResponse, nil
+	}
+	json.Unmarshal(data, &upstream)
+	return resp.StatusCode, upstream, nil
+}
+
+// Handler for GET /admin/dual-write/report
+func handleDualWriteReport(w http.ResponseWriter, r *http.Request) {
+	dualWriteMu.Lock()
+	summary := map[string]int{"pending": 0, "matched": 0, "mismatched": 0, "failed": 0}
+	records := make([]dualWriteRecord, 0, len(dualWriteRecords))
+	for _, record := range dualWriteRecords {
+		summary[record.Status]++
+		records = append(records, *record)
+	}
+	dualWriteMu.Unlock()
+
+	response := map[string]interface{}{
+		"enabled":      dualWriteURL != "",
+		"upstream_url": dualWriteURL,
+		"summary":      summary,
+		"records":      records,
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// Per-entity history: GET /api/entities/{type}/{id}/events lists every
+// activity event of one DID, credential or proof, oldest first, for
+// debugging and the frontend's per-item history drawer. Only events of
+// committed txs are recorded, so seeded records start without history.
+// ?reverse=true lists newest first.
+
+// entityEventPrefixes maps entity types to the activity event types that
+// belong to them.
+var entityEventPrefixes = map[string]string{
+	"did":        "did.",
+	"credential": "credential.",
+	"proof":      "proof.",
+}
+
+// entityExists reports whether the entity is stored. Must be called with
+// c.mu held.
+func (c *Chain) entityExists(entityType, id string) bool {
+	switch entityType {
+	case "did":
+		return c.did().store.Documents[id] != nil
+	case "credential":
+		_, credential := c.vc().findCredential(id)
+		return credential != nil
+	case "proof":
+		for _, proofs := range c.zk().store.ByController {
+			for _, proof := range proofs {
+				if proof["id"] == id {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// Handler for GET /api/entities/{type}/{id}/events
+func (c *Chain) handleEntityEvents(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	entityType, id := vars["type"], vars["id"]
+	prefix, ok := entityEventPrefixes[entityType]
+	if !ok {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"error": "Unknown entity type",
+			"type":  entityType,
+			"types": sortedMapKeys(entityEventPrefixes),
+		})
+		return
+	}
+
+	c.mu.RLock()
+	exists := c.entityExists(entityType, id)
+	c.mu.RUnlock()
+
+	events := []activityEvent{}
+	c.eventsMu.Lock()
+	for _, ev := range c.activityBySubject[id] {
+		if strings.HasPrefix(ev.Type, prefix) {
+			events = append(events, ev)
+		}
+	}
+	c.eventsMu.Unlock()
+
+	if !exists && len(events) == 0 {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"error": "Entity not found",
+			"type":  entityType,
+			"id":    id,
+		})
+		return
+	}
+	if r.URL.Query().Get("reverse") == "true" {
+		for i, j := 0, len(events)-1; i < j; i, j = i+1, j-1 {
+			events[i], events[j] = events[j], events[i]
+		}
+	}
+
+	response := map[string]interface{}{
+		"entity": map[string]interface{}{"type": entityType, "id": id},
+		"events": events,
+		"total":  len(events),
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Localized error messages: every tx error code has a human message per
+// supported locale, keyed "<codespace>:<code>" like "sdk:5" or "vc:13".
+// Tx responses (broadcast and GET /cosmos/tx/v1beta1/txs/{hash}) carry the
+// message for the locale negotiated from Accept-Language in localized_log,
+// with Content-Language set; code, codespace and raw_log are unchanged, so
+// clients keep matching on codes. GET /api/errors returns the catalog for
+// rendering errors without a round trip.
+
+const defaultErrorLocale = "en"
+
+var errorLocales = []string{"en", "es", "fr", "de", "ja", "pt"}
+
+var errorCatalog = map[string]map[string]string{
+	errorKey("sdk", codeTxDecode): {
+		"en": "The transaction could not be read. Please try again.",
+		"es": "No se pudo leer la transacción. Inténtalo de nuevo.",
+		"fr": "La transaction n'a pas pu être lue. Veuillez réessayer.",
+		"de": "Die Transaktion konnte nicht gelesen werden. Bitte versuche es erneut.",
+		"ja": "トランザクションを読み取れませんでした。もう一度お試しください。",
+		"pt": "Não foi possível ler a transação. Tente novamente.",
+	},
+	errorKey("sdk", codeUnauthorized): {
+		"en": "You are not allowed to perform this action.",
+		"es": "No tienes permiso para realizar esta acción.",
+		"fr": "Vous n'êtes pas autorisé à effectuer cette action.",
+		"de": "Du bist nicht berechtigt, diese Aktion auszuführen.",
+		"ja": "この操作を行う権限がありません。",
+		"pt": "Você não tem permissão para realizar esta ação.",
+	},
+	errorKey("sdk", codeInsufficientFunds): {
+		"en": "Your balance is too low to complete this action.",
+		"es": "Tu saldo es insuficiente para completar esta acción.",
+		"fr": "Votre solde est insuffisant pour effectuer cette action.",
+		"de": "Dein Guthaben reicht für diese Aktion nicht aus.",
+		"ja": "残高が不足しているため、この操作を完了できません。",
+		"pt": "Seu saldo é insuficiente para concluir esta ação.",
+	},
+	errorKey("sdk", codeInvalidAddress): {
+		"en": "The account address is not valid.",
+		"es": "La dirección de la cuenta no es válida.",
+		"fr": "L'adresse du compte n'est pas valide.",
+		"de": "Die Kontoadresse ist ungültig.",
+		"ja": "アカウントアドレスが無効です。",
+		"pt": "O endereço da conta não é válido.",
+	},
+	errorKey("sdk", codeInvalidPubKey): {
+		"en": "The signing key does not belong to this account.",
+		"es": "La clave de firma no pertenece a esta cuenta.",
+		"fr": "La clé de signature n'appartient pas à ce compte.",
+		"de": "Der Signaturschlüssel gehört nicht zu diesem Konto.",
+		"ja": "署名鍵がこのアカウントのものではありません。",
+		"pt": "A chave de assinatura não pertence a esta conta.",
+	},
+	errorKey("sdk", codeOutOfGas): {
+		"en": "The transaction ran out of gas. Try again with a higher gas limit.",
+		"es": "La transacción se quedó sin gas. Inténtalo con un límite de gas mayor.",
+		"fr": "La transaction a manqué de gas. Réessayez avec une limite de gas plus élevée.",
+		"de": "Der Transaktion ist das Gas ausgegangen. Versuche es mit einem höheren Gaslimit.",
+		"ja": "トランザクションのガスが不足しました。ガス上限を上げて再度お試しください。",
+		"pt": "A transação ficou sem gas. Tente novamente com um limite de gas maior.",
+	},
+	errorKey("sdk", codeInsufficientFee): {
+		"en": "The fee is too low for this transaction.",
+		"es": "La comisión es demasiado baja para esta transacción.",
+		"fr": "Les frais sont insuffisants pour cette transaction.",
+		"de": "Die Gebühr ist für diese Transaktion zu niedrig.",
+		"ja": "このトランザクションの手数料が不足しています。",
+		"pt": "A taxa é muito baixa para esta transação.",
+	},
+	errorKey("sdk", codeNoSignatures): {
+		"en": "The transaction is not signed. Sign it in your wallet and try again.",
+		"es": "La transacción no está firmada. Fírmala en tu billetera e inténtalo de nuevo.",
+		"fr": "La transaction n'est pas signée. Signez-la dans votre portefeuille et réessayez.",
+		"de": "Die Transaktion ist nicht signiert. Signiere sie in deiner Wallet und versuche es erneut.",
+		"ja": "トランザクションが署名されていません。ウォレットで署名してから再度お試しください。",
+		"pt": "A transação não está assinada. Assine-a na sua carteira e tente novamente.",
+	},
+	errorKey("sdk", codeInvalidRequest): {
+		"en": "The request is not valid.",
+		"es": "La solicitud no es válida.",
+		"fr": "La requête n'est pas valide.",
+		"de": "Die Anfrage ist ungültig.",
+		"ja": "リクエストが無効です。",
+		"pt": "A solicitação não é válida.",
+	},
+	errorKey("sdk", codeTxInMempoolCache): {
+		"en": "This transaction was already submitted.",
+		"es": "Esta transacción ya fue enviada.",
+		"fr": "Cette transaction a déjà été soumise.",
+		"de": "Diese Transaktion wurde bereits gesendet.",
+		"ja": "このトランザクションは既に送信されています。",
+		"pt": "Esta transação já foi enviada.",
+	},
+	errorKey("sdk", codeMempoolIsFull): {
+		"en": "The network is busy. Please try again in a moment.",
+		"es": "La red está ocupada. Inténtalo de nuevo en un momento.",
+		"fr": "Le réseau est saturé. Veuillez réessayer dans un instant.",
+		"de": "Das Netzwerk ist ausgelastet. Bitte versuche es gleich noch einmal.",
+		"ja": "ネットワークが混雑しています。しばらくしてから再度お試しください。",
+		"pt": "A rede está ocupada. Tente novamente em instantes.",
+	},
+	errorKey("sdk", codeWrongSequence): {
+		"en": "Your account is out of sync. Refresh and try again.",
+		"es": "Tu cuenta no está sincronizada. Actualiza e inténtalo de nuevo.",
+		"fr": "Votre compte n'est pas synchronisé. Actualisez et réessayez.",
+		"de": "Dein Konto ist nicht synchron. Aktualisiere und versuche es erneut.",
+		"ja": "アカウントの同期がずれています。更新してから再度お試しください。",
+		"pt": "Sua conta está fora de sincronia. Atualize e tente novamente.",
+	},
+	errorKey("sdk", codeNotFound): {
+		"en": "The requested item could not be found.",
+		"es": "No se encontró el elemento solicitado.",
+		"fr": "L'élément demandé est introuvable.",
+		"de": "Das angeforderte Element wurde nicht gefunden.",
+		"ja": "要求された項目が見つかりません。",
+		"pt": "O item solicitado não foi encontrado.",
+	},
+	errorKey("vc", codeVCSchemaViolation): {
+		"en": "The credential does not match its schema.",
+		"es": "La credencial no coincide con su esquema.",
+		"fr": "L'attestation ne correspond pas à son schéma.",
+		"de": "Der Nachweis entspricht nicht seinem Schema.",
+		"ja": "クレデンシャルがスキーマに一致しません。",
+		"pt": "A credencial não corresponde ao seu esquema.",
+	},
+	errorKey("vc", codeVCInvalidCredential): {
+		"en": "The credential is not a valid verifiable credential.",
+		"es": "La credencial no es una credencial verificable válida.",
+		"fr": "L'attestation n'est pas une attestation vérifiable valide.",
+		"de": "Der Nachweis ist kein gültiger überprüfbarer Nachweis.",
+		"ja": "有効な検証可能クレデンシャルではありません。",
+		"pt": "A credencial não é uma credencial verificável válida.",
+	},
+}
+
+func errorKey(codespace string, code int) string {
+	return codespace + ":" + strconv.Itoa(code)
+}
+
+// negotiateErrorLocale picks the supported locale an Accept-Language
+// header prefers, matching on the primary language subtag.
+func negotiateErrorLocale(acceptLanguage string) string {
+	best, bestQ := defaultErrorLocale, 0.0
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		language := strings.ToLower(strings.SplitN(strings.TrimSpace(fields[0]), "-", 2)[0])
+		q := 1.0
+		for _, param := range fields[1:] {
+			if value, ok := strings.CutPrefix(strings.TrimSpace(param), "q="); ok {
+				if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+					q = parsed
+				}
+			}
+		}
+		if q <= bestQ {
+			continue
+		}
+		for _, locale := range errorLocales {
+			if language == locale {
+				best, bestQ = locale, q
+			}
+		}
+	}
+	return best
+}
+
+// localizedErrorMessage returns the message for an error code in locale,
+// falling back to English, or "" when the code is not in the catalog.
+func localizedErrorMessage(locale, codespace string, code int) string {
+	messages := errorCatalog[errorKey(codespace, code)]
+	if message, ok := messages[locale]; ok {
+		return message
+	}
+	return messages[defaultErrorLocale]
+}
+
+// setErrorLocale negotiates the request's locale and declares it on the
+// response.
+func setErrorLocale(w http.ResponseWriter, r *http.Request) string {
+	locale := negotiateErrorLocale(r.Header.Get("Accept-Language"))
+	w.Header().Set("Content-Language", locale)
+	w.Header().Add("Vary", "Accept-Language")
+	return locale
+}
+
+// writeTxResponse writes a broadcast response with its localized message.
+func writeTxResponse(w http.ResponseWriter, r *http.Request, response MockTxResponse) {
+	locale := setErrorLocale(w, r)
+	if response.Code != codeOK {
+		response.LocalizedLog = localizedErrorMessage(locale, response.Codespace, response.Code)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// Handler for GET /api/errors
+func handleErrorCatalog(w http.ResponseWriter, r *http.Request) {
+	locale := r.URL.Query().Get("locale")
+	if locale == "" {
+		locale = setErrorLocale(w, r)
+	} else if errorCatalog[errorKey("sdk", codeNotFound)][locale] == "" {
+		http.Error(w, fmt.Sprintf("Unsupported locale %s, expected one of %s", locale, strings.Join(errorLocales, ", ")), http.StatusBadRequest)
+		return
+	}
+
+	keys := sortedMapKeys(errorCatalog)
+	entries := make([]map[string]interface{}, 0, len(keys))
+	for _, key := range keys {
+		codespace, code, _ := strings.Cut(key, ":")
+		number, _ := strconv.Atoi(code)
+		entries = append(entries, map[string]interface{}{
+			"key":       key,
+			"codespace": codespace,
+			"code":      number,
+			"message":   localizedErrorMessage(locale, codespace, number),
+		})
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i]["codespace"] != entries[j]["codespace"] {
+			return entries[i]["codespace"].(string) < entries[j]["codespace"].(string)
+		}
+		return entries[i]["code"].(int) < entries[j]["code"].(int)
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"locale":  locale,
+		"locales": errorLocales,
+		"errors":  entries,
+	})
+}
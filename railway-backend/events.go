@@ -0,0 +1,174 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+)
+
+// Chain event bus. The block producer and tx broadcast publish events in the
+// Tendermint shape (tm.event plus composite-key attributes); subscribers
+// filter them with Tendermint queries such as
+// tm.event='Tx' AND message.sender='cosmos1...'.
+
+const eventBufferSize = 100
+
+type chainEvent struct {
+	// Type is the tm.event value: NewBlock or Tx
+	Type string
+	// Attributes are the composite-key event attributes, e.g. tx.hash
+	Attributes map[string][]string
+	// Data is the amino-JSON event payload ({"type": ..., "value": ...})
+	Data map[string]interface{}
+}
+
+type eventQuery []eventCondition
+
+// parseEventQuery parses an AND-joined Tendermint event query.
+func parseEventQuery(query string) (eventQuery, error) {
+	if strings.TrimSpace(query) == "" {
+		return nil, fmt.Errorf("query is empty")
+	}
+	var conditions eventQuery
+	for _, expr := range queryAndPattern.Split(query, -1) {
+		cond, err := parseEventCondition(expr)
+		if err != nil {
+			return nil, err
+		}
+		conditions = append(conditions, cond)
+	}
+	return conditions, nil
+}
+
+// Matches reports whether every condition holds for some value of its key.
+// Comparison operators compare numerically.
+func (q eventQuery) Matches(ev chainEvent) bool {
+	for _, cond := range q {
+		values := ev.Attributes[cond.Key]
+		if cond.Key == "tm.event" {
+			values = []string{ev.Type}
+		}
+		matched := false
+		for _, value := range values {
+			if cond.Op == "=" {
+				matched = value == cond.Value
+			} else {
+				have, err1 := strconv.ParseFloat(value, 64)
+				want, err2 := strconv.ParseFloat(cond.Value, 64)
+				matched = err1 == nil && err2 == nil && compareFloat(have, cond.Op, want)
+			}
+			if matched {
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+func compareFloat(have float64, op string, want float64) bool {
+	switch op {
+	case "<":
+		return have < want
+	case "<=":
+		return have <= want
+	case ">":
+		return have > want
+	case ">=":
+		return have >= want
+	default:
+		return have == want
+	}
+}
+
+// subscribeEvents registers a subscriber for every chain event. The returned
+// function unsubscribes and closes the channel.
+func (c *Chain) subscribeEvents() (<-chan chainEvent, func()) {
+	ch := make(chan chainEvent, eventBufferSize)
+
+	c.eventsMu.Lock()
+	c.nextSubscriberID++
+	id := c.nextSubscriberID
+	c.subscribers[id] = ch
+	c.eventsMu.Unlock()
+
+	return ch, func() {
+		c.eventsMu.Lock()
+		defer c.eventsMu.Unlock()
+		if _, ok := c.subscribers[id]; ok {
+			delete(c.subscribers, id)
+			close(ch)
+		}
+	}
+}
+
+// publishEvent delivers an event to every subscriber. It never blocks: a
+// subscriber whose buffer is full misses the event.
+func (c *Chain) publishEvent(ev chainEvent) {
+	c.eventsMu.Lock()
+	defer c.eventsMu.Unlock()
+	for id, ch := range c.subscribers {
+		select {
+		case ch <- ev:
+		default:
+			log.Printf("Event subscriber %d is too slow, dropped %s event", id, ev.Type)
+		}
+	}
+}
+
+// newBlockEvent builds the NewBlock event for a produced block.
+// Must be called with c.mu held.
+func (c *Chain) newBlockEvent(b *block) chainEvent {
+	return chainEvent{
+		Type: "NewBlock",
+		Attributes: map[string][]string{
+			"block.height": {fmt.Sprintf("%d", b.Height)},
+		},
+		Data: map[string]interface{}{
+			"type": "tendermint/event/NewBlock",
+			"value": map[string]interface{}{
+				"block":              c.blockJSON(b)["block"],
+				"result_begin_block": map[string]interface{}{},
+				"result_end_block": map[string]interface{}{
+					"validator_updates": []interface{}{},
+				},
+			},
+		},
+	}
+}
+
+// txEvent builds the Tx event for a stored tx at position index in its block.
+func txEvent(tx *storedTx, index int, txBytes []byte) chainEvent {
+	height := fmt.Sprintf("%d", tx.Response.Height)
+	return chainEvent{
+		Type: "Tx",
+		Attributes: map[string][]string{
+			"tx.hash":        {tx.Response.TxHash},
+			"tx.height":      {height},
+			"message.sender": tx.Senders,
+			"message.action": tx.Actions,
+		},
+		Data: map[string]interface{}{
+			"type": "tendermint/event/Tx",
+			"value": map[string]interface{}{
+				"TxResult": map[string]interface{}{
+					"height": height,
+					"index":  index,
+					"tx":     base64.StdEncoding.EncodeToString(txBytes),
+					"result": map[string]interface{}{
+						"code":       tx.Response.Code,
+						"data":       tx.Response.Data,
+						"log":        tx.Response.RawLog,
+						"gas_wanted": "200000",
+						"gas_used":   "0",
+						"events":     tx.events(),
+					},
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,261 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/rand"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/gorilla/mux"
+)
+
+// Fault injection for broadcasts, to exercise the frontend's error handling.
+// A rule matches every broadcast, or only those carrying a given message
+// type, and fails a percentage of them with a realistic non-zero code. An
+// injected failure is a CheckTx rejection: height 0, nothing executed or
+// stored, and the signer's sequence is left unchanged.
+//
+// Rules come from the environment at startup:
+//
+//	FAULT_RATE=0.2 FAULT_ERROR=insufficient_fees FAULT_MSG_TYPE=/persona.did.v1.MsgCreateDid
+//	FAULT_RULES='[{"msg_type": "...", "rate": 0.5, "error": "unauthorized"}]'
+//
+// and can be managed at runtime through /admin/faults.
+
+type faultRule struct {
+	ID string `json:"id"`
+	// MsgType restricts the rule to txs containing this message @type
+	MsgType string `json:"msg_type,omitempty"`
+	// Rate is the fraction of matching broadcasts to fail; 0 means all
+	Rate float64 `json:"rate"`
+	// Error names a preset; otherwise Code, Codespace and RawLog are used
+	Error     string `json:"error,omitempty"`
+	Code      int    `json:"code,omitempty"`
+	Codespace string `json:"codespace,omitempty"`
+	RawLog    string `json:"raw_log,omitempty"`
+	// Remaining limits how many more times the rule fires; 0 is unlimited
+	Remaining int `json:"remaining,omitempty"`
+	Injected  int `json:"injected"`
+}
+
+// faultPreset builds a realistic error for the signer of the tx. Called with
+// the chain lock held.
+type faultPreset func(c *Chain, signer string) *txError
+
+var faultPresets = map[string]faultPreset{
+	"insufficient_fees": func(c *Chain, signer string) *txError {
+		return txErrorf(codeInsufficientFee, "insufficient fees; got: 0uprsn required: 5000uprsn")
+	},
+	"insufficient_funds": func(c *Chain, signer string) *txError {
+		return txErrorf(codeInsufficientFunds, "spendable balance 0uprsn is smaller than 5000uprsn")
+	},
+	"unauthorized": func(c *Chain, signer string) *txError {
+		var accountNumber uint64
+		if signer != "" {
+			accountNumber = c.auth().store.account(signer).AccountNumber
+		}
+		return txErrorf(codeUnauthorized, "signature verification failed; please verify account number (%d) and chain-id (%s)", accountNumber, c.info.ChainID)
+	},
+	"out_of_gas": func(c *Chain, signer string) *txError {
+		return txErrorf(codeOutOfGas, "out of gas in location: WriteFlat; gasWanted: 200000, gasUsed: 200417")
+	},
+	"wrong_sequence": func(c *Chain, signer string) *txError {
+		var expected uint64
+		if signer != "" {
+			expected = c.auth().store.account(signer).Sequence
+		}
+		return txErrorf(codeWrongSequence, "account sequence mismatch, expected %d, got %d", expected+1, expected)
+	},
+	"mempool_full": func(c *Chain, signer string) *txError {
+		return txErrorf(codeMempoolIsFull, "mempool is full: number of txs 5000 (max: 5000), total txs bytes 1073741824 (max: 1073741824)")
+	},
+}
+
+type faultInjector struct {
+	mu     sync.Mutex
+	rules  []*faultRule
+	nextID int
+}
+
+var faults = newFaultInjectorFromEnv()
+
+func newFaultInjectorFromEnv() *faultInjector {
+	f := &faultInjector{}
+	if raw := os.Getenv("FAULT_RULES"); raw != "" {
+		var rules []*faultRule
+		if err := json.Unmarshal([]byte(raw), &rules); err != nil {
+			log.Printf("Invalid FAULT_RULES: %v", err)
+		}
+		for _, rule := range rules {
+			if err := f.add(rule); err != nil {
+				log.Printf("Skipping fault rule: %v", err)
+			}
+		}
+	}
+	if rateEnv := os.Getenv("FAULT_RATE"); rateEnv != "" {
+		rate, err := strconv.ParseFloat(rateEnv, 64)
+		if err != nil {
+			log.Printf("Invalid FAULT_RATE=%q", rateEnv)
+		} else {
+			rule := &faultRule{
+				MsgType: os.Getenv("FAULT_MSG_TYPE"),
+				Rate:    rate,
+				Error:   os.Getenv("FAULT_ERROR"),
+			}
+			if rule.Error == "" {
+				rule.Error = "insufficient_fees"
+			}
+			if err := f.add(rule); err != nil {
+				log.Printf("Skipping fault rule: %v", err)
+			}
+		}
+	}
+	return f
+}
+
+func (f *faultInjector) add(rule *faultRule) error {
+	if rule.Rate < 0 || rule.Rate > 1 {
+		return fmt.Errorf("rate must be between 0 and 1")
+	}
+	if rule.Error != "" {
+		if _, ok := faultPresets[rule.Error]; !ok {
+			return fmt.Errorf("unknown error preset: %s", rule.Error)
+		}
+	} else if rule.Code == 0 {
+		return fmt.Errorf("either error or a non-zero code is required")
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.nextID++
+	rule.ID = strconv.Itoa(f.nextID)
+	rule.Injected = 0
+	f.rules = append(f.rules, rule)
+	log.Printf("Fault rule %s added: msg_type=%q rate=%g error=%q code=%d", rule.ID, rule.MsgType, rule.Rate, rule.Error, rule.Code)
+	return nil
+}
+
+// inject returns the error of the first rule that fires for the tx, if any.
+// Must be called with c.mu held.
+func (f *faultInjector) inject(c *Chain, msgs []interface{}, signer string) *txError {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for i, rule := range f.rules {
+		if rule.MsgType != "" && !containsMsgType(msgs, rule.MsgType) {
+			continue
+		}
+		if rule.Rate > 0 && rand.Float64() >= rule.Rate {
+			continue
+		}
+
+		rule.Injected++
+		if rule.Remaining > 0 {
+			rule.Remaining--
+			if rule.Remaining == 0 {
+				f.rules = append(f.rules[:i], f.rules[i+1:]...)
+			}
+		}
+
+		if rule.Error != "" {
+			txErr := faultPresets[rule.Error](c, signer)
+			log.Printf("Injected fault %s (rule %s)", rule.Error, rule.ID)
+			return txErr
+		}
+		codespace := rule.Codespace
+		if codespace == "" {
+			codespace = "sdk"
+		}
+		rawLog := rule.RawLog
+		if rawLog == "" {
+			rawLog = codeNames[rule.Code]
+		}
+		if rawLog == "" {
+			rawLog = fmt.Sprintf("injected failure with code %d", rule.Code)
+		}
+		log.Printf("Injected fault code %d (rule %s)", rule.Code, rule.ID)
+		return &txError{Code: rule.Code, Codespace: codespace, Log: rawLog}
+	}
+	return nil
+}
+
+func containsMsgType(msgs []interface{}, msgType string) bool {
+	for _, m := range msgs {
+		if msg, ok := m.(map[string]interface{}); ok && msg["@type"] == msgType {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *faultInjector) snapshot() []faultRule {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	rules := make([]faultRule, 0, len(f.rules))
+	for _, rule := range f.rules {
+		rules = append(rules, *rule)
+	}
+	return rules
+}
+
+// Handler for GET /admin/faults
+func handleListFaults(w http.ResponseWriter, r *http.Request) {
+	presets := make([]string, 0, len(faultPresets))
+	for name := range faultPresets {
+		presets = append(presets, name)
+	}
+	sort.Strings(presets)
+
+	response := map[string]interface{}{
+		"rules":   faults.snapshot(),
+		"presets": presets,
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// Handler for POST /admin/faults
+func handleAddFault(w http.ResponseWriter, r *http.Request) {
+	var rule faultRule
+	if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+	if err := faults.add(&rule); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{"rule": rule})
+}
+
+// Handler for DELETE /admin/faults and DELETE /admin/faults/{id}
+func handleDeleteFaults(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	faults.mu.Lock()
+	removed := 0
+	kept := faults.rules[:0]
+	for _, rule := range faults.rules {
+		if id == "" || rule.ID == id {
+			removed++
+			continue
+		}
+		kept = append(kept, rule)
+	}
+	faults.rules = kept
+	faults.mu.Unlock()
+
+	if id != "" && removed == 0 {
+		http.Error(w, "Fault rule not found", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"removed": removed})
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"github.com/gorilla/mux"
+)
+
+// Feature flags: server behaviours that used to be fixed by an env var at
+// startup and can now be switched at runtime from the admin console, e.g.
+// turning on real Groth16 verification for one test. Each flag starts from
+// its env var, is listed at GET /admin/v1/flags, set with PUT
+// /admin/v1/flags/{name} and restored to its startup value by /admin/reset.
+
+type featureFlag struct {
+	Name        string
+	EnvVar      string
+	Description string
+	// Startup is the value from EnvVar, restored by /admin/reset
+	Startup bool
+
+	value atomic.Bool
+}
+
+// featureFlagState is how flags are listed.
+type featureFlagState struct {
+	Name        string `json:"name"`
+	Enabled     bool   `json:"enabled"`
+	Startup     bool   `json:"startup"`
+	EnvVar      string `json:"env_var"`
+	Description string `json:"description"`
+}
+
+var (
+	featureFlagsMu sync.Mutex
+	featureFlags   = make(map[string]*featureFlag)
+)
+
+func init() {
+	registerAdminState("feature_flags", func() interface{} {
+		return featureFlagStates()
+	}, func() {
+		for _, flag := range featureFlagList() {
+			flag.value.Store(flag.Startup)
+		}
+	})
+}
+
+// newFeatureFlag registers a flag, enabled when envVar is "true".
+func newFeatureFlag(name, envVar, description string) *featureFlag {
+	flag := &featureFlag{Name: name, EnvVar: envVar, Description: description, Startup: os.Getenv(envVar) == "true"}
+	flag.value.Store(flag.Startup)
+	featureFlagsMu.Lock()
+	featureFlags[name] = flag
+	featureFlagsMu.Unlock()
+	return flag
+}
+
+func (f *featureFlag) Enabled() bool {
+	return f.value.Load()
+}
+
+// featureFlagList returns every flag by name.
+func featureFlagList() []*featureFlag {
+	featureFlagsMu.Lock()
+	defer featureFlagsMu.Unlock()
+	list := make([]*featureFlag, 0, len(featureFlags))
+	for _, flag := range featureFlags {
+		list = append(list, flag)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+func featureFlagStates() []featureFlagState {
+	states := []featureFlagState{}
+	for _, flag := range featureFlagList() {
+		states = append(states, featureFlagState{
+			Name:        flag.Name,
+			Enabled:     flag.Enabled(),
+			Startup:     flag.Startup,
+			EnvVar:      flag.EnvVar,
+			Description: flag.Description,
+		})
+	}
+	return states
+}
+
+// Handler for GET /admin/v1/flags
+func handleListFeatureFlags(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"flags": featureFlagStates()})
+}
+
+// Handler for PUT /admin/v1/flags/{name}. Takes {"enabled": true}.
+func handleSetFeatureFlag(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+	featureFlagsMu.Lock()
+	flag := featureFlags[name]
+	featureFlagsMu.Unlock()
+	if flag == nil {
+		http.Error(w, "Feature flag "+name+" not found", http.StatusNotFound)
+		return
+	}
+	var req struct {
+		Enabled *bool `json:"enabled"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Enabled == nil {
+		http.Error(w, `Expected {"enabled": true|false}`, http.StatusBadRequest)
+		return
+	}
+	flag.value.Store(*req.Enabled)
+	log.Printf("Feature flag %s set to %v", name, *req.Enabled)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"name": name, "enabled": *req.Enabled, "startup": flag.Startup})
+}
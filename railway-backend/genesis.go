@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// Genesis export: converts the mock's stored DIDs, credentials, proofs and
+// circuits into an app_state fragment for the real persona chain modules,
+// so curated demo states can be promoted to the actual testnet. Each module
+// implementing GenesisExporter contributes its own app_state entry.
+
+func (c *Chain) buildGenesis() map[string]interface{} {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	appState := map[string]interface{}{}
+	for _, m := range c.modules {
+		if exporter, ok := m.(GenesisExporter); ok {
+			appState[m.Name()] = exporter.ExportGenesis()
+		}
+	}
+
+	return map[string]interface{}{
+		"genesis_time": c.now().UTC().Format(time.RFC3339),
+		"chain_id":     c.info.ChainID,
+		"app_state":    appState,
+	}
+}
+
+// Handler for GET /admin/genesis
+func (c *Chain) handleGenesisExport(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(c.buildGenesis())
+}
+
+// newGenesisCommand implements `persona-mock genesis`, which fetches the
+// genesis fragment from a running daemon and writes it to a file.
+func newGenesisCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "genesis",
+		Short: "Export state as a genesis fragment for the real chain",
+		Args:  cobra.NoArgs,
+	}
+	url, token := daemonFlags(cmd)
+	out := cmd.Flags().String("out", "genesis.json", "output file (- for stdout)")
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		return runGenesis(*url, *token, *out)
+	}
+	return cmd
+}
+
+func runGenesis(url, token, out string) error {
+	data, err := daemonRequest("GET", url, token, "/admin/genesis", nil)
+	if err != nil {
+		return err
+	}
+	if out == "-" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	if err := os.WriteFile(out, data, 0o644); err != nil {
+		return err
+	}
+	fmt.Printf("Wrote genesis fragment to %s\n", out)
+	return nil
+}
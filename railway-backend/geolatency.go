@@ -0,0 +1,245 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/rand"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/gorilla/mux"
+)
+
+// Geo latency emulation (GEO_LATENCY=true), for the frontend's
+// region-aware endpoint selection and failover. Each request is attributed
+// to a client region, taken from the X-Client-Region header or inferred
+// from a country header set by the edge (CF-IPCountry or X-Country-Code),
+// falling back to GEO_DEFAULT_REGION. The region's profile then adds
+// latency, fails a fraction of requests, or takes the region down
+// entirely. GEO_PROFILES (a JSON array) replaces the built-in profiles and
+// /admin/geo manages them at runtime. Admin routes are never affected.
+
+type regionProfile struct {
+	Region    string  `json:"region"`
+	DelayMs   int     `json:"delay_ms"`
+	JitterMs  int     `json:"jitter_ms,omitempty"`
+	ErrorRate float64 `json:"error_rate,omitempty"`
+	// ErrorStatus defaults to 503
+	ErrorStatus int `json:"error_status,omitempty"`
+	// Down fails every request from the region
+	Down bool `json:"down,omitempty"`
+	Hits int  `json:"hits"`
+}
+
+var defaultRegionProfiles = []regionProfile{
+	{Region: "us-east", DelayMs: 20, JitterMs: 10},
+	{Region: "us-west", DelayMs: 45, JitterMs: 15},
+	{Region: "eu-west", DelayMs: 90, JitterMs: 20},
+	{Region: "ap-southeast", DelayMs: 180, JitterMs: 40},
+	{Region: "sa-east", DelayMs: 140, JitterMs: 30},
+}
+
+// countryRegions infers a region from an ISO country code.
+var countryRegions = map[string]string{
+	"US": "us-east", "CA": "us-east", "MX": "us-west",
+	"GB": "eu-west", "IE": "eu-west", "FR": "eu-west", "DE": "eu-west",
+	"NL": "eu-west", "ES": "eu-west", "IT": "eu-west", "SE": "eu-west",
+	"SG": "ap-southeast", "JP": "ap-southeast", "AU": "ap-southeast",
+	"IN": "ap-southeast", "KR": "ap-southeast", "ID": "ap-southeast",
+	"BR": "sa-east", "AR": "sa-east", "CL": "sa-east", "CO": "sa-east",
+}
+
+type geoEmulator struct {
+	mu            sync.Mutex
+	enabled       bool
+	defaultRegion string
+	profiles      map[string]*regionProfile
+}
+
+var geo = newGeoEmulatorFromEnv()
+
+func newGeoEmulatorFromEnv() *geoEmulator {
+	g := &geoEmulator{
+		enabled:       os.Getenv("GEO_LATENCY") == "true",
+		defaultRegion: os.Getenv("GEO_DEFAULT_REGION"),
+		profiles:      make(map[string]*regionProfile),
+	}
+	profiles := defaultRegionProfiles
+	if raw := os.Getenv("GEO_PROFILES"); raw != "" {
+		var custom []regionProfile
+		if err := json.Unmarshal([]byte(raw), &custom); err != nil {
+			log.Printf("Invalid GEO_PROFILES: %v", err)
+		} else {
+			profiles = custom
+		}
+	}
+	for _, profile := range profiles {
+		if err := g.set(profile); err != nil {
+			log.Printf("Skipping region profile: %v", err)
+		}
+	}
+	return g
+}
+
+func (g *geoEmulator) set(profile regionProfile) error {
+	profile.Region = strings.ToLower(profile.Region)
+	if profile.Region == "" {
+		return fmt.Errorf("region is required")
+	}
+	if profile.ErrorRate < 0 || profile.ErrorRate > 1 {
+		return fmt.Errorf("error_rate must be between 0 and 1")
+	}
+	if profile.DelayMs < 0 || profile.JitterMs < 0 {
+		return fmt.Errorf("delays must not be negative")
+	}
+	if profile.ErrorStatus == 0 {
+		profile.ErrorStatus = http.StatusServiceUnavailable
+	}
+	profile.Hits = 0
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.profiles[profile.Region] = &profile
+	return nil
+}
+
+// clientRegion attributes a request to a region, or returns "".
+func clientRegion(r *http.Request) string {
+	if region := r.Header.Get("X-Client-Region"); region != "" {
+		return strings.ToLower(region)
+	}
+	for _, header := range []string{"CF-IPCountry", "X-Country-Code"} {
+		if region, ok := countryRegions[strings.ToUpper(r.Header.Get(header))]; ok {
+			return region
+		}
+	}
+	return geo.defaultRegion
+}
+
+// match returns a copy of the profile for the request's region.
+func (g *geoEmulator) match(region string) *regionProfile {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if !g.enabled {
+		return nil
+	}
+	profile, ok := g.profiles[region]
+	if !ok {
+		return nil
+	}
+	profile.Hits++
+	matched := *profile
+	return &matched
+}
+
+func geoLatencyMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/admin/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+		region := clientRegion(r)
+		profile := geo.match(region)
+		if profile == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Set("X-Client-Region", region)
+
+		if !sleepWithJitter(r, profile.DelayMs, profile.JitterMs) {
+			return
+		}
+		if profile.Down || (profile.ErrorRate > 0 && rand.Float64() < profile.ErrorRate) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(profile.ErrorStatus)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"error":  fmt.Sprintf("region %s unavailable", region),
+				"region": region,
+			})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (g *geoEmulator) snapshot() []regionProfile {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	profiles := make([]regionProfile, 0, len(g.profiles))
+	for _, region := range sortedMapKeys(g.profiles) {
+		profiles = append(profiles, *g.profiles[region])
+	}
+	return profiles
+}
+
+// Handler for GET /admin/geo
+func handleGetGeo(w http.ResponseWriter, r *http.Request) {
+	geo.mu.Lock()
+	enabled, defaultRegion := geo.enabled, geo.defaultRegion
+	geo.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"enabled":        enabled,
+		"default_region": defaultRegion,
+		"profiles":       geo.snapshot(),
+	})
+}
+
+// Handler for POST /admin/geo, which switches emulation on or off and sets
+// the default region.
+func handleConfigureGeo(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Enabled       *bool   `json:"enabled"`
+		DefaultRegion *string `json:"default_region"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+	geo.mu.Lock()
+	if req.Enabled != nil {
+		geo.enabled = *req.Enabled
+	}
+	if req.DefaultRegion != nil {
+		geo.defaultRegion = strings.ToLower(*req.DefaultRegion)
+	}
+	geo.mu.Unlock()
+	handleGetGeo(w, r)
+}
+
+// Handler for PUT /admin/geo/{region}
+func handlePutRegionProfile(w http.ResponseWriter, r *http.Request) {
+	var profile regionProfile
+	if err := json.NewDecoder(r.Body).Decode(&profile); err != nil {
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+	profile.Region = mux.Vars(r)["region"]
+	if err := geo.set(profile); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	log.Printf("Region profile %s updated", profile.Region)
+	handleGetGeo(w, r)
+}
+
+// Handler for DELETE /admin/geo/{region}
+func handleDeleteRegionProfile(w http.ResponseWriter, r *http.Request) {
+	region := strings.ToLower(mux.Vars(r)["region"])
+
+	geo.mu.Lock()
+	_, ok := geo.profiles[region]
+	delete(geo.profiles, region)
+	geo.mu.Unlock()
+
+	if !ok {
+		http.Error(w, "Region profile not found", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"removed": 1})
+}
@@ -0,0 +1,466 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// GraphQL query layer at /graphql over the stored DIDs, credentials, proofs
+// and circuits, with their relationships (DID -> credentials -> proofs ->
+// circuits), for the dashboard's GraphQL client. It is read-only: writes
+// still go through tx broadcasts. Field names are camelCase; the raw
+// stored record is available as the data field. Only stored records are
+// returned, not the mock defaults the REST list endpoints add.
+
+// gqlRecord is a stored record together with the address it is stored
+// under, which credentials do not carry themselves.
+type gqlRecord struct {
+	owner string
+	data  map[string]interface{}
+}
+
+var jsonScalar = graphql.NewScalar(graphql.ScalarConfig{
+	Name:         "JSON",
+	Description:  "Arbitrary JSON value",
+	Serialize:    func(value interface{}) interface{} { return value },
+	ParseValue:   func(value interface{}) interface{} { return value },
+	ParseLiteral: parseJSONLiteral,
+})
+
+func parseJSONLiteral(value ast.Value) interface{} {
+	switch v := value.(type) {
+	case *ast.StringValue:
+		return v.Value
+	case *ast.BooleanValue:
+		return v.Value
+	case *ast.IntValue:
+		n, _ := strconv.ParseInt(v.Value, 10, 64)
+		return n
+	case *ast.FloatValue:
+		f, _ := strconv.ParseFloat(v.Value, 64)
+		return f
+	case *ast.ListValue:
+		list := make([]interface{}, len(v.Values))
+		for i, item := range v.Values {
+			list[i] = parseJSONLiteral(item)
+		}
+		return list
+	case *ast.ObjectValue:
+		object := make(map[string]interface{}, len(v.Fields))
+		for _, field := range v.Fields {
+			object[field.Name.Value] = parseJSONLiteral(field.Value)
+		}
+		return object
+	}
+	return nil
+}
+
+// recordField resolves a camelCase field from a snake_case key of the
+// stored record.
+func recordField(key string) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		return p.Source.(gqlRecord).data[key], nil
+	}
+}
+
+func recordFieldDefault(key string, def interface{}) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		if value, ok := p.Source.(gqlRecord).data[key]; ok && value != nil {
+			return value, nil
+		}
+		return def, nil
+	}
+}
+
+func recordData(p graphql.ResolveParams) (interface{}, error) {
+	return p.Source.(gqlRecord).data, nil
+}
+
+func recordOwner(p graphql.ResolveParams) (interface{}, error) {
+	return p.Source.(gqlRecord).owner, nil
+}
+
+var pageArgs = graphql.FieldConfigArgument{
+	"limit":  &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: defaultPageLimit},
+	"offset": &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 0},
+}
+
+func withPageArgs(args graphql.FieldConfigArgument) graphql.FieldConfigArgument {
+	for name, arg := range pageArgs {
+		args[name] = arg
+	}
+	return args
+}
+
+// page applies the limit and offset arguments.
+func page(records []gqlRecord, args map[string]interface{}) []gqlRecord {
+	offset, _ := args["offset"].(int)
+	limit, _ := args["limit"].(int)
+	if offset < 0 {
+		offset = 0
+	}
+	if offset > len(records) {
+		offset = len(records)
+	}
+	records = records[offset:]
+	if limit > 0 && limit < len(records) {
+		records = records[:limit]
+	}
+	return records
+}
+
+// Lookups over the module stores. All must be called with the chain lock
+// held.
+
+func (c *Chain) gqlDID(id string) interface{} {
+	document, ok := c.did().store.Documents[id]
+	if !ok {
+		return nil
+	}
+	return gqlRecord{owner: c.did().controllerOf(id), data: document}
+}
+
+func (c *Chain) gqlDIDByController(controller string) interface{} {
+	didId, ok := c.did().store.ByController[controller]
+	if !ok {
+		return nil
+	}
+	return c.gqlDID(didId)
+}
+
+func (c *Chain) gqlDIDs() []gqlRecord {
+	store := c.did().store
+	records := make([]gqlRecord, 0, len(store.Documents))
+	for _, id := range sortedMapKeys(store.Documents) {
+		records = append(records, c.gqlDID(id).(gqlRecord))
+	}
+	return records
+}
+
+// gqlOwned flattens a by-controller store, optionally for one controller.
+func gqlOwned(byController map[string][]map[string]interface{}, controller string) []gqlRecord {
+	records := []gqlRecord{}
+	for _, owner := range sortedMapKeys(byController) {
+		if controller != "" && owner != controller {
+			continue
+		}
+		for _, record := range byController[owner] {
+			records = append(records, gqlRecord{owner: owner, data: record})
+		}
+	}
+	return records
+}
+
+func (c *Chain) gqlCredentials(controller string) []gqlRecord {
+	return gqlOwned(c.vc().store.ByController, controller)
+}
+
+func (c *Chain) gqlProofs(controller, circuitId string) []gqlRecord {
+	records := []gqlRecord{}
+	for _, proof := range gqlOwned(c.zk().store.ByController, controller) {
+		if circuitId == "" || proof.data["circuit_id"] == circuitId {
+			records = append(records, proof)
+		}
+	}
+	return records
+}
+
+func (c *Chain) gqlCircuits() []gqlRecord {
+	circuits := c.zk().listCircuits()
+	records := make([]gqlRecord, len(circuits))
+	for i, circuit := range circuits {
+		creator, _ := circuit["creator"].(string)
+		records[i] = gqlRecord{owner: creator, data: circuit}
+	}
+	sort.SliceStable(records, func(i, j int) bool {
+		return fmt.Sprint(records[i].data["id"]) < fmt.Sprint(records[j].data["id"])
+	})
+	return records
+}
+
+func findRecord(records []gqlRecord, id string) interface{} {
+	for _, record := range records {
+		if record.data["id"] == id {
+			return record
+		}
+	}
+	return nil
+}
+
+// credentialSubject returns the subject DID of a credential in either the
+// W3C shape or the flattened record shape.
+func credentialSubject(credential map[string]interface{}) interface{} {
+	if subject, ok := credential["credentialSubject"].(map[string]interface{}); ok {
+		return subject["id"]
+	}
+	return credential["subject_did"]
+}
+
+func credentialIssuer(credential map[string]interface{}) interface{} {
+	switch issuer := credential["issuer"].(type) {
+	case string:
+		return issuer
+	case map[string]interface{}:
+		return issuer["id"]
+	}
+	return credential["issuer_did"]
+}
+
+// graphQLSchema builds the schema for c.
+func (c *Chain) graphQLSchema() (graphql.Schema, error) {
+	var didType, credentialType, proofType, circuitType *graphql.Object
+
+	didType = graphql.NewObject(graphql.ObjectConfig{
+		Name:        "DID",
+		Description: "A DID document",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"id":         {Type: graphql.NewNonNull(graphql.String), Resolve: recordField("id")},
+				"controller": {Type: graphql.String, Resolve: recordOwner},
+				"isActive":   {Type: graphql.Boolean, Resolve: recordFieldDefault("is_active", true)},
+				"createdAt":  {Type: graphql.Int, Resolve: recordField("created_at")},
+				"updatedAt":  {Type: graphql.Int, Resolve: recordField("updated_at")},
+				"data":       {Type: jsonScalar, Resolve: recordData},
+				"credentials": {
+					Type:        graphql.NewList(credentialType),
+					Description: "Credentials stored under the DID's controller",
+					Args:        withPageArgs(graphql.FieldConfigArgument{}),
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return page(c.gqlCredentials(p.Source.(gqlRecord).owner), p.Args), nil
+					},
+				},
+				"proofs": {
+					Type:        graphql.NewList(proofType),
+					Description: "Proofs submitted by the DID's controller",
+					Args:        withPageArgs(graphql.FieldConfigArgument{}),
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return page(c.gqlProofs(p.Source.(gqlRecord).owner, ""), p.Args), nil
+					},
+				},
+			}
+		}),
+	})
+
+	credentialType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Credential",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"id":         {Type: graphql.String, Resolve: recordField("id")},
+				"controller": {Type: graphql.String, Resolve: recordOwner},
+				"issuer": {Type: graphql.String, Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return credentialIssuer(p.Source.(gqlRecord).data), nil
+				}},
+				"subject": {Type: graphql.String, Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return credentialSubject(p.Source.(gqlRecord).data), nil
+				}},
+				"type": {Type: jsonScalar, Resolve: recordField("type")},
+				"status": {Type: graphql.String, Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					if p.Source.(gqlRecord).data["is_revoked"] == true {
+						return "revoked", nil
+					}
+					return "active", nil
+				}},
+				"isRevoked":             {Type: graphql.Boolean, Resolve: recordFieldDefault("is_revoked", false)},
+				"revocationReason":      {Type: graphql.String, Resolve: recordField("revocation_reason")},
+				"revocationReasonCode":  {Type: graphql.String, Resolve: recordField("revocation_reason_code")},
+				"revocationExplanation": {Type: graphql.String, Resolve: recordField("revocation_explanation")},
+				"revokedAt":             {Type: graphql.Int, Resolve: recordField("revoked_at")},
+				"issuedAt":              {Type: graphql.Int, Resolve: recordField("created_at")},
+				"data":                  {Type: jsonScalar, Resolve: recordData},
+				"controllerDid": {Type: didType, Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return c.gqlDIDByController(p.Source.(gqlRecord).owner), nil
+				}},
+				"issuerDid": {Type: didType, Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					issuer, _ := credentialIssuer(p.Source.(gqlRecord).data).(string)
+					return c.gqlDID(issuer), nil
+				}},
+				"subjectDid": {Type: didType, Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					subject, _ := credentialSubject(p.Source.(gqlRecord).data).(string)
+					return c.gqlDID(subject), nil
+				}},
+				"proofs": {
+					Type:        graphql.NewList(proofType),
+					Description: "Proofs submitted by the credential's controller",
+					Args:        withPageArgs(graphql.FieldConfigArgument{}),
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return page(c.gqlProofs(p.Source.(gqlRecord).owner, ""), p.Args), nil
+					},
+				},
+			}
+		}),
+	})
+
+	proofType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Proof",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"id":           {Type: graphql.String, Resolve: recordField("id")},
+				"prover":       {Type: graphql.String, Resolve: recordOwner},
+				"circuitId":    {Type: graphql.String, Resolve: recordField("circuit_id")},
+				"isVerified":   {Type: graphql.Boolean, Resolve: recordFieldDefault("is_verified", false)},
+				"createdAt":    {Type: graphql.Int, Resolve: recordField("created_at")},
+				"publicInputs": {Type: jsonScalar, Resolve: recordField("public_inputs")},
+				"metadata":     {Type: jsonScalar, Resolve: recordField("metadata")},
+				"data":         {Type: jsonScalar, Resolve: recordData},
+				"circuit": {Type: circuitType, Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					circuitId, _ := p.Source.(gqlRecord).data["circuit_id"].(string)
+					return findRecord(c.gqlCircuits(), circuitId), nil
+				}},
+				"proverDid": {Type: didType, Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return c.gqlDIDByController(p.Source.(gqlRecord).owner), nil
+				}},
+			}
+		}),
+	})
+
+	circuitType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Circuit",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"id":        {Type: graphql.String, Resolve: recordField("id")},
+				"name":      {Type: graphql.String, Resolve: recordField("name")},
+				"creator":   {Type: graphql.String, Resolve: recordOwner},
+				"isActive":  {Type: graphql.Boolean, Resolve: recordFieldDefault("is_active", true)},
+				"createdAt": {Type: graphql.Int, Resolve: recordField("created_at")},
+				"data":      {Type: jsonScalar, Resolve: recordData},
+				"proofs": {
+					Type: graphql.NewList(proofType),
+					Args: withPageArgs(graphql.FieldConfigArgument{}),
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						circuitId, _ := p.Source.(gqlRecord).data["id"].(string)
+						return page(c.gqlProofs("", circuitId), p.Args), nil
+					},
+				},
+			}
+		}),
+	})
+
+	argOf := func(p graphql.ResolveParams, name string) string {
+		value, _ := p.Args[name].(string)
+		return value
+	}
+	query := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"did": {
+				Type: didType,
+				Args: graphql.FieldConfigArgument{"id": {Type: graphql.NewNonNull(graphql.String)}},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return c.gqlDID(argOf(p, "id")), nil
+				},
+			},
+			"didByController": {
+				Type: didType,
+				Args: graphql.FieldConfigArgument{"controller": {Type: graphql.NewNonNull(graphql.String)}},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return c.gqlDIDByController(argOf(p, "controller")), nil
+				},
+			},
+			"dids": {
+				Type: graphql.NewList(didType),
+				Args: withPageArgs(graphql.FieldConfigArgument{}),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return page(c.gqlDIDs(), p.Args), nil
+				},
+			},
+			"credential": {
+				Type: credentialType,
+				Args: graphql.FieldConfigArgument{"id": {Type: graphql.NewNonNull(graphql.String)}},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return findRecord(c.gqlCredentials(""), argOf(p, "id")), nil
+				},
+			},
+			"credentials": {
+				Type: graphql.NewList(credentialType),
+				Args: withPageArgs(graphql.FieldConfigArgument{"controller": {Type: graphql.String}}),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return page(c.gqlCredentials(argOf(p, "controller")), p.Args), nil
+				},
+			},
+			"proof": {
+				Type: proofType,
+				Args: graphql.FieldConfigArgument{"id": {Type: graphql.NewNonNull(graphql.String)}},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return findRecord(c.gqlProofs("", ""), argOf(p, "id")), nil
+				},
+			},
+			"proofs": {
+				Type: graphql.NewList(proofType),
+				Args: withPageArgs(graphql.FieldConfigArgument{
+					"controller": {Type: graphql.String},
+					"circuitId":  {Type: graphql.String},
+				}),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return page(c.gqlProofs(argOf(p, "controller"), argOf(p, "circuitId")), p.Args), nil
+				},
+			},
+			"circuit": {
+				Type: circuitType,
+				Args: graphql.FieldConfigArgument{"id": {Type: graphql.NewNonNull(graphql.String)}},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return findRecord(c.gqlCircuits(), argOf(p, "id")), nil
+				},
+			},
+			"circuits": {
+				Type: graphql.NewList(circuitType),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return c.gqlCircuits(), nil
+				},
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{Query: query})
+}
+
+// graphQLHandler serves POST (JSON body) and GET (?query=) requests.
+func (c *Chain) graphQLHandler() http.HandlerFunc {
+	schema, err := c.graphQLSchema()
+	if err != nil {
+		panic("invalid GraphQL schema: " + err.Error())
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Query         string                 `json:"query"`
+			Variables     map[string]interface{} `json:"variables"`
+			OperationName string                 `json:"operationName"`
+		}
+		if r.Method == "GET" {
+			q := r.URL.Query()
+			req.Query, req.OperationName = q.Get("query"), q.Get("operationName")
+			if variables := q.Get("variables"); variables != "" {
+				if err := json.Unmarshal([]byte(variables), &req.Variables); err != nil {
+					http.Error(w, "Invalid variables", http.StatusBadRequest)
+					return
+				}
+			}
+		} else if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+			return
+		}
+		if req.Query == "" {
+			http.Error(w, "query is required", http.StatusBadRequest)
+			return
+		}
+
+		// Resolvers read the stores directly
+		c.mu.RLock()
+		result := graphql.Do(graphql.Params{
+			Schema:         schema,
+			RequestString:  req.Query,
+			VariableValues: req.Variables,
+			OperationName:  req.OperationName,
+			Context:        r.Context(),
+		})
+		c.mu.RUnlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(result)
+	}
+}
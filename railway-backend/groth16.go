@@ -0,0 +1,366 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fp"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	groth16bn254 "github.com/consensys/gnark/backend/groth16/bn254"
+	"github.com/consensys/gnark/logger"
+	"github.com/gorilla/mux"
+)
+
+// Groth16 proof verification. By default MsgSubmitProof marks every proof
+// verified. With VERIFY_PROOFS=true a proof is checked against the
+// verifying key registered for its circuit (such as age_verification_v1)
+// with gnark's BN254 Groth16 verifier, and stored with is_verified=false and a
+// verification_error when the check fails or the circuit has no key.
+//
+// Keys and proofs use the snarkjs JSON layout (verification_key.json, and
+// {pi_a, pi_b, pi_c} in proof_data, raw or base64 encoded, as the frontend
+// sends it) and are converted to gnark's types. Public inputs are decimal
+// strings, as snarkjs writes public.json; plain JSON numbers are only
+// exact up to 2^53 and rejected above it. Keys are
+// loaded from VERIFYING_KEYS_DIR (<circuit id>.json) at startup and managed
+// at /admin/zk/verifying-keys. They are configuration, so /admin/reset
+// leaves them in place. testdata/groth16 holds a snarkjs key for
+// x^3 + x + 5 = out with a valid and a tampered proof, which the groth16
+// preflight check runs through the verifier.
+
+var verifyProofs = newFeatureFlag("verify_proofs", "VERIFY_PROOFS", "Verify Groth16 proofs against the circuit verifying keys")
+
+type groth16VerifyingKey struct {
+	Protocol string     `json:"protocol"`
+	Curve    string     `json:"curve"`
+	NPublic  int        `json:"nPublic"`
+	Alpha1   []string   `json:"vk_alpha_1"`
+	Beta2    [][]string `json:"vk_beta_2"`
+	Gamma2   [][]string `json:"vk_gamma_2"`
+	Delta2   [][]string `json:"vk_delta_2"`
+	IC       [][]string `json:"IC"`
+
+	vk *groth16bn254.VerifyingKey
+}
+
+type groth16Proof struct {
+	// pi_a and pi_c are G1 points, pi_b a G2 point, in projective
+	// coordinates with z = 1
+	PiA []string   `json:"pi_a"`
+	PiB [][]string `json:"pi_b"`
+	PiC []string   `json:"pi_c"`
+}
+
+var (
+	verifyingKeysMu sync.RWMutex
+	verifyingKeys   = make(map[string]*groth16VerifyingKey)
+)
+
+func init() {
+	// gnark logs every verification at debug level
+	logger.Disable()
+
+	dir := os.Getenv("VERIFYING_KEYS_DIR")
+	if dir == "" {
+		return
+	}
+	files, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	for _, path := range files {
+		circuit := strings.TrimSuffix(filepath.Base(path), ".json")
+		data, err := os.ReadFile(path)
+		var vk *groth16VerifyingKey
+		if err == nil {
+			vk, err = parseVerifyingKey(data)
+		}
+		if err != nil {
+			log.Printf("Skipping verifying key %s: %v", path, err)
+			continue
+		}
+		verifyingKeys[circuit] = vk
+	}
+	log.Printf("Loaded %d verifying keys from %s", len(verifyingKeys), dir)
+}
+
+// parseFieldElement reads a base field element, in decimal or 0x hex.
+func parseFieldElement(s string) (fp.Element, error) {
+	var e fp.Element
+	n, ok := new(big.Int).SetString(strings.TrimSpace(s), 0)
+	if !ok || n.Sign() < 0 || n.Cmp(fp.Modulus()) >= 0 {
+		return e, fmt.Errorf("invalid field element %q", s)
+	}
+	e.SetBigInt(n)
+	return e, nil
+}
+
+// parseG1 reads a G1 point given as [x, y] or [x, y, z] with z = 1, or
+// z = 0 for the point at infinity.
+func parseG1(coords []string) (bn254.G1Affine, error) {
+	var point bn254.G1Affine
+	if len(coords) != 2 && len(coords) != 3 {
+		return point, fmt.Errorf("G1 point needs 2 or 3 coordinates, got %d", len(coords))
+	}
+	if len(coords) == 3 && coords[2] == "0" {
+		return point, nil
+	}
+	var err error
+	if point.X, err = parseFieldElement(coords[0]); err != nil {
+		return point, err
+	}
+	if point.Y, err = parseFieldElement(coords[1]); err != nil {
+		return point, err
+	}
+	if !point.IsOnCurve() {
+		return point, fmt.Errorf("point is not on the curve")
+	}
+	return point, nil
+}
+
+// parseG2 reads a G2 point given as [[x0, x1], [y0, y1]] plus an optional
+// [z0, z1], where x = x0 + x1*i.
+func parseG2(coords [][]string) (bn254.G2Affine, error) {
+	var point bn254.G2Affine
+	if len(coords) != 2 && len(coords) != 3 {
+		return point, fmt.Errorf("G2 point needs 2 or 3 coordinates, got %d", len(coords))
+	}
+	for _, coord := range coords {
+		if len(coord) != 2 {
+			return point, fmt.Errorf("G2 coordinate needs 2 elements, got %d", len(coord))
+		}
+	}
+	if len(coords) == 3 && coords[2][0] == "0" && coords[2][1] == "0" {
+		return point, nil
+	}
+	for _, target := range []struct {
+		element *fp.Element
+		s       string
+	}{
+		{&point.X.A0, coords[0][0]}, {&point.X.A1, coords[0][1]},
+		{&point.Y.A0, coords[1][0]}, {&point.Y.A1, coords[1][1]},
+	} {
+		var err error
+		if *target.element, err = parseFieldElement(target.s); err != nil {
+			return point, err
+		}
+	}
+	if !point.IsOnCurve() || !point.IsInSubGroup() {
+		return point, fmt.Errorf("point is not in G2")
+	}
+	return point, nil
+}
+
+func parseVerifyingKey(data []byte) (*groth16VerifyingKey, error) {
+	var vk groth16VerifyingKey
+	if err := json.Unmarshal(data, &vk); err != nil {
+		return nil, err
+	}
+	if vk.Protocol != "" && vk.Protocol != "groth16" {
+		return nil, fmt.Errorf("unsupported protocol %s", vk.Protocol)
+	}
+	if vk.Curve != "" && vk.Curve != "bn128" && vk.Curve != "bn254" {
+		return nil, fmt.Errorf("unsupported curve %s", vk.Curve)
+	}
+	if len(vk.IC) == 0 {
+		return nil, fmt.Errorf("IC is required")
+	}
+	vk.vk = new(groth16bn254.VerifyingKey)
+	var err error
+	if vk.vk.G1.Alpha, err = parseG1(vk.Alpha1); err != nil {
+		return nil, fmt.Errorf("vk_alpha_1: %v", err)
+	}
+	for name, target := range map[string]struct {
+		coords [][]string
+		point  *bn254.G2Affine
+	}{
+		"vk_beta_2":  {vk.Beta2, &vk.vk.G2.Beta},
+		"vk_gamma_2": {vk.Gamma2, &vk.vk.G2.Gamma},
+		"vk_delta_2": {vk.Delta2, &vk.vk.G2.Delta},
+	} {
+		if *target.point, err = parseG2(target.coords); err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+	}
+	for i, coords := range vk.IC {
+		point, err := parseG1(coords)
+		if err != nil {
+			return nil, fmt.Errorf("IC[%d]: %v", i, err)
+		}
+		vk.vk.G1.K = append(vk.vk.G1.K, point)
+	}
+	if err := vk.vk.Precompute(); err != nil {
+		return nil, err
+	}
+	vk.NPublic = vk.vk.NbPublicWitness()
+	return &vk, nil
+}
+
+// decodeProofData accepts the proof as JSON or base64-encoded JSON.
+func decodeProofData(proofData string) (*groth16Proof, error) {
+	data := []byte(strings.TrimSpace(proofData))
+	if len(data) > 0 && data[0] != '{' {
+		decoded, err := base64.StdEncoding.DecodeString(string(data))
+		if err != nil {
+			decoded, err = base64.RawURLEncoding.DecodeString(strings.TrimRight(string(data), "="))
+		}
+		if err != nil {
+			return nil, fmt.Errorf("proof is neither JSON nor base64")
+		}
+		data = decoded
+	}
+	var proof groth16Proof
+	if err := json.Unmarshal(data, &proof); err != nil {
+		return nil, fmt.Errorf("proof is not a Groth16 proof: %v", err)
+	}
+	return &proof, nil
+}
+
+// maxExactJSONNumber is the largest integer a float64 holds exactly.
+const maxExactJSONNumber = 1 << 53
+
+// publicSignals converts public inputs to scalar field elements. Inputs
+// are decimal strings or json.Number; a float64, what JSON numbers decode
+// to, is only taken up to 2^53, above which it has lost digits.
+func publicSignals(inputs interface{}) (fr.Vector, error) {
+	list, ok := inputs.([]interface{})
+	if inputs != nil && !ok {
+		return nil, fmt.Errorf("public_inputs must be a list")
+	}
+	signals := make(fr.Vector, len(list))
+	for i, input := range list {
+		var s string
+		switch v := input.(type) {
+		case string:
+			s = v
+		case json.Number:
+			s = v.String()
+		case float64:
+			if v != math.Trunc(v) || math.Abs(v) > maxExactJSONNumber {
+				return nil, fmt.Errorf("public input %d must be a decimal string, a JSON number is only exact up to 2^53", i)
+			}
+			s = strconv.FormatFloat(v, 'f', 0, 64)
+		default:
+			return nil, fmt.Errorf("public input %d is not a number", i)
+		}
+		n, ok := new(big.Int).SetString(strings.TrimSpace(s), 0)
+		if !ok || n.Sign() < 0 || n.Cmp(fr.Modulus()) >= 0 {
+			return nil, fmt.Errorf("public input %d is not a field element", i)
+		}
+		signals[i].SetBigInt(n)
+	}
+	return signals, nil
+}
+
+// verify checks e(A, B) = e(alpha, beta) * e(vk_x, gamma) * e(C, delta),
+// where vk_x = IC[0] + sum of input[i] * IC[i+1], with gnark.
+func (vk *groth16VerifyingKey) verify(proof *groth16Proof, inputs fr.Vector) error {
+	if len(inputs) != vk.NPublic {
+		return fmt.Errorf("expected %d public inputs, got %d", vk.NPublic, len(inputs))
+	}
+	var p groth16bn254.Proof
+	var err error
+	if p.Ar, err = parseG1(proof.PiA); err != nil {
+		return fmt.Errorf("pi_a: %v", err)
+	}
+	if p.Bs, err = parseG2(proof.PiB); err != nil {
+		return fmt.Errorf("pi_b: %v", err)
+	}
+	if p.Krs, err = parseG1(proof.PiC); err != nil {
+		return fmt.Errorf("pi_c: %v", err)
+	}
+	return groth16bn254.Verify(&p, vk.vk, inputs)
+}
+
+// verifyGroth16 verifies a submitted proof for circuit.
+func verifyGroth16(circuit, proofData string, publicInputs interface{}) error {
+	verifyingKeysMu.RLock()
+	vk := verifyingKeys[circuit]
+	verifyingKeysMu.RUnlock()
+	if vk == nil {
+		return fmt.Errorf("no verifying key registered for circuit %s", circuit)
+	}
+	proof, err := decodeProofData(proofData)
+	if err != nil {
+		return err
+	}
+	inputs, err := publicSignals(publicInputs)
+	if err != nil {
+		return err
+	}
+	return vk.verify(proof, inputs)
+}
+
+// Handler for PUT /admin/zk/verifying-keys/{circuit}, taking a snarkjs
+// verification_key.json
+func handlePutVerifyingKey(w http.ResponseWriter, r *http.Request) {
+	circuit := mux.Vars(r)["circuit"]
+	var raw json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&raw); err != nil {
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+	vk, err := parseVerifyingKey(raw)
+	if err != nil {
+		http.Error(w, "Invalid verifying key: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	verifyingKeysMu.Lock()
+	verifyingKeys[circuit] = vk
+	verifyingKeysMu.Unlock()
+	verifyCache.Invalidate(circuitTag(circuit))
+
+	log.Printf("Verifying key registered for circuit %s (%d public inputs)", circuit, vk.NPublic)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"circuit_id":    circuit,
+		"n_public":      vk.NPublic,
+		"verify_proofs": verifyProofs.Enabled(),
+	})
+}
+
+// Handler for GET /admin/zk/verifying-keys
+func handleListVerifyingKeys(w http.ResponseWriter, r *http.Request) {
+	verifyingKeysMu.RLock()
+	keys := make([]map[string]interface{}, 0, len(verifyingKeys))
+	for _, circuit := range sortedMapKeys(verifyingKeys) {
+		keys = append(keys, map[string]interface{}{
+			"circuit_id": circuit,
+			"n_public":   verifyingKeys[circuit].NPublic,
+		})
+	}
+	verifyingKeysMu.RUnlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"verify_proofs":  verifyProofs.Enabled(),
+		"verifying_keys": keys,
+	})
+}
+
+// Handler for DELETE /admin/zk/verifying-keys/{circuit}
+func handleDeleteVerifyingKey(w http.ResponseWriter, r *http.Request) {
+	circuit := mux.Vars(r)["circuit"]
+
+	verifyingKeysMu.Lock()
+	_, ok := verifyingKeys[circuit]
+	delete(verifyingKeys, circuit)
+	verifyingKeysMu.Unlock()
+	verifyCache.Invalidate(circuitTag(circuit))
+
+	if !ok {
+		http.Error(w, "No verifying key for circuit "+circuit, http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"removed": 1})
+}
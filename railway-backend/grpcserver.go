@@ -0,0 +1,287 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+
+	abciv1beta1 "persona-backend/proto/cosmos/base/abci/v1beta1"
+	queryv1beta1 "persona-backend/proto/cosmos/base/query/v1beta1"
+	txv1beta1 "persona-backend/proto/cosmos/tx/v1beta1"
+	didv1 "persona-backend/proto/persona/did/v1"
+	vcv1 "persona-backend/proto/persona/vc/v1"
+	zkv1 "persona-backend/proto/persona/zk/v1"
+
+	"github.com/gorilla/mux"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// gRPC server (GRPC_PORT), so clients that talk gRPC to real nodes can run
+// against the mock. It serves the cosmos.tx.v1beta1 tx service and the
+// persona.did/vc/zk query services defined under proto/. Each call is
+// replayed against the chain's REST routes and the JSON response decoded
+// into the proto response, so both transports share one implementation.
+// Server reflection is enabled for grpcurl.
+
+type grpcBridge struct {
+	router *mux.Router
+}
+
+// call runs a REST request and decodes its response into out.
+func (b *grpcBridge) call(ctx context.Context, method, path string, query url.Values, body interface{}, out proto.Message) error {
+	var reader *bytes.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return status.Error(codes.InvalidArgument, err.Error())
+		}
+		reader = bytes.NewReader(data)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, path, reader)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	b.router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		return grpcStatusFromREST(rec.Code, rec.Body.Bytes())
+	}
+	if err := (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(rec.Body.Bytes(), out); err != nil {
+		return status.Errorf(codes.Internal, "decoding response: %v", err)
+	}
+	return nil
+}
+
+// grpcStatusFromREST converts a REST error. Responses already in the
+// grpc-gateway shape keep their code.
+func grpcStatusFromREST(httpStatus int, body []byte) error {
+	var gatewayErr struct {
+		Code    *int   `json:"code"`
+		Message string `json:"message"`
+		Error   string `json:"error"`
+	}
+	json.Unmarshal(body, &gatewayErr)
+	if gatewayErr.Code != nil && gatewayErr.Message != "" {
+		return status.Error(codes.Code(*gatewayErr.Code), gatewayErr.Message)
+	}
+	message := gatewayErr.Error
+	if message == "" {
+		message = string(bytes.TrimSpace(body))
+	}
+
+	code := codes.Internal
+	switch httpStatus {
+	case http.StatusBadRequest:
+		code = codes.InvalidArgument
+	case http.StatusNotFound, http.StatusGone:
+		code = codes.NotFound
+	case http.StatusUnauthorized:
+		code = codes.Unauthenticated
+	case http.StatusForbidden:
+		code = codes.PermissionDenied
+	case http.StatusTooManyRequests:
+		code = codes.ResourceExhausted
+	case http.StatusServiceUnavailable:
+		code = codes.Unavailable
+	}
+	return status.Error(code, message)
+}
+
+// pageQuery renders a PageRequest as pagination.* query parameters.
+func pageQuery(page *queryv1beta1.PageRequest) url.Values {
+	q := url.Values{}
+	if page == nil {
+		return q
+	}
+	if len(page.Key) > 0 {
+		q.Set("pagination.key", base64.StdEncoding.EncodeToString(page.Key))
+	}
+	if page.Offset > 0 {
+		q.Set("pagination.offset", strconv.FormatUint(page.Offset, 10))
+	}
+	if page.Limit > 0 {
+		q.Set("pagination.limit", strconv.FormatUint(page.Limit, 10))
+	}
+	// REST counts the total unless told otherwise
+	if page.CountTotal {
+		q.Set("pagination.count_total", "true")
+	}
+	if page.Reverse {
+		q.Set("pagination.reverse", "true")
+	}
+	return q
+}
+
+type txServer struct {
+	txv1beta1.UnimplementedServiceServer
+	*grpcBridge
+}
+
+func (s txServer) BroadcastTx(ctx context.Context, req *txv1beta1.BroadcastTxRequest) (*txv1beta1.BroadcastTxResponse, error) {
+	if len(req.TxBytes) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "invalid empty tx")
+	}
+	body := map[string]interface{}{
+		"tx_bytes": base64.StdEncoding.EncodeToString(req.TxBytes),
+		"mode":     req.Mode.String(),
+	}
+	var txResponse abciv1beta1.TxResponse
+	if err := s.call(ctx, "POST", "/cosmos/tx/v1beta1/txs", nil, body, &txResponse); err != nil {
+		return nil, err
+	}
+	return &txv1beta1.BroadcastTxResponse{TxResponse: &txResponse}, nil
+}
+
+func (s txServer) GetTx(ctx context.Context, req *txv1beta1.GetTxRequest) (*txv1beta1.GetTxResponse, error) {
+	var resp txv1beta1.GetTxResponse
+	err := s.call(ctx, "GET", "/cosmos/tx/v1beta1/txs/"+url.PathEscape(req.Hash), nil, nil, &resp)
+	return &resp, err
+}
+
+func (s txServer) GetTxsEvent(ctx context.Context, req *txv1beta1.GetTxsEventRequest) (*txv1beta1.GetTxsEventResponse, error) {
+	q := pageQuery(req.Pagination)
+	for _, event := range req.Events {
+		q.Add("events", event)
+	}
+	if req.Query != "" {
+		q.Set("query", req.Query)
+	}
+	if req.OrderBy != txv1beta1.OrderBy_ORDER_BY_UNSPECIFIED {
+		q.Set("order_by", req.OrderBy.String())
+	}
+	if req.Page > 0 {
+		q.Set("page", strconv.FormatUint(req.Page, 10))
+	}
+	if req.Limit > 0 {
+		q.Set("limit", strconv.FormatUint(req.Limit, 10))
+	}
+	var resp txv1beta1.GetTxsEventResponse
+	err := s.call(ctx, "GET", "/cosmos/tx/v1beta1/txs", q, nil, &resp)
+	return &resp, err
+}
+
+type didQueryServer struct {
+	didv1.UnimplementedQueryServer
+	*grpcBridge
+}
+
+func (s didQueryServer) DidDocument(ctx context.Context, req *didv1.QueryDidDocumentRequest) (*didv1.QueryDidDocumentResponse, error) {
+	var resp didv1.QueryDidDocumentResponse
+	err := s.call(ctx, "GET", "/persona/did/v1beta1/did_documents/"+url.PathEscape(req.Id), nil, nil, &resp)
+	return &resp, err
+}
+
+func (s didQueryServer) DidDocuments(ctx context.Context, req *didv1.QueryDidDocumentsRequest) (*didv1.QueryDidDocumentsResponse, error) {
+	var resp didv1.QueryDidDocumentsResponse
+	err := s.call(ctx, "GET", "/persona/did/v1beta1/did_documents", pageQuery(req.Pagination), nil, &resp)
+	return &resp, err
+}
+
+func (s didQueryServer) DidByController(ctx context.Context, req *didv1.QueryDidByControllerRequest) (*didv1.QueryDidByControllerResponse, error) {
+	var resp didv1.QueryDidByControllerResponse
+	err := s.call(ctx, "GET", "/persona/did/v1beta1/did_by_controller/"+url.PathEscape(req.Controller), nil, nil, &resp)
+	return &resp, err
+}
+
+type vcQueryServer struct {
+	vcv1.UnimplementedQueryServer
+	*grpcBridge
+}
+
+func (s vcQueryServer) Credentials(ctx context.Context, req *vcv1.QueryCredentialsRequest) (*vcv1.QueryCredentialsResponse, error) {
+	var resp vcv1.QueryCredentialsResponse
+	err := s.call(ctx, "GET", "/persona/vc/v1beta1/credentials", pageQuery(req.Pagination), nil, &resp)
+	return &resp, err
+}
+
+func (s vcQueryServer) CredentialsByController(ctx context.Context, req *vcv1.QueryCredentialsByControllerRequest) (*vcv1.QueryCredentialsResponse, error) {
+	var resp vcv1.QueryCredentialsResponse
+	err := s.call(ctx, "GET", "/persona/vc/v1beta1/credentials_by_controller/"+url.PathEscape(req.Controller), pageQuery(req.Pagination), nil, &resp)
+	return &resp, err
+}
+
+func (s vcQueryServer) CredentialStatus(ctx context.Context, req *vcv1.QueryCredentialStatusRequest) (*vcv1.QueryCredentialStatusResponse, error) {
+	var resp vcv1.QueryCredentialStatusResponse
+	err := s.call(ctx, "GET", "/persona/vc/v1beta1/credentials/"+url.PathEscape(req.CredentialId)+"/status", nil, nil, &resp)
+	return &resp, err
+}
+
+type zkQueryServer struct {
+	zkv1.UnimplementedQueryServer
+	*grpcBridge
+}
+
+func (s zkQueryServer) Proofs(ctx context.Context, req *zkv1.QueryProofsRequest) (*zkv1.QueryProofsResponse, error) {
+	var resp zkv1.QueryProofsResponse
+	err := s.call(ctx, "GET", "/persona/zk/v1beta1/proofs", pageQuery(req.Pagination), nil, &resp)
+	return &resp, err
+}
+
+func (s zkQueryServer) ProofsByController(ctx context.Context, req *zkv1.QueryProofsByControllerRequest) (*zkv1.QueryProofsResponse, error) {
+	var resp zkv1.QueryProofsResponse
+	err := s.call(ctx, "GET", "/persona/zk/v1beta1/proofs_by_controller/"+url.PathEscape(req.Controller), pageQuery(req.Pagination), nil, &resp)
+	return &resp, err
+}
+
+func (s zkQueryServer) Circuits(ctx context.Context, req *zkv1.QueryCircuitsRequest) (*zkv1.QueryCircuitsResponse, error) {
+	var resp zkv1.QueryCircuitsResponse
+	err := s.call(ctx, "GET", "/persona/zk/v1beta1/circuits", pageQuery(req.Pagination), nil, &resp)
+	return &resp, err
+}
+
+// NewGRPCServer registers the tx and query services for c.
+func (c *Chain) NewGRPCServer() *grpc.Server {
+	bridge := &grpcBridge{router: mux.NewRouter()}
+	c.RegisterRoutes(bridge.router)
+
+	server := grpc.NewServer(grpc.UnaryInterceptor(logGRPCErrors))
+	txv1beta1.RegisterServiceServer(server, txServer{grpcBridge: bridge})
+	didv1.RegisterQueryServer(server, didQueryServer{grpcBridge: bridge})
+	vcv1.RegisterQueryServer(server, vcQueryServer{grpcBridge: bridge})
+	zkv1.RegisterQueryServer(server, zkQueryServer{grpcBridge: bridge})
+	reflection.Register(server)
+	return server
+}
+
+func logGRPCErrors(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	resp, err := handler(ctx, req)
+	if err != nil {
+		log.Printf("gRPC %s failed: %v", info.FullMethod, err)
+	}
+	return resp, err
+}
+
+// startGRPCServer serves gRPC on port in the background.
+func (c *Chain) startGRPCServer(port string) error {
+	listener, err := net.Listen("tcp", "0.0.0.0:"+port)
+	if err != nil {
+		return fmt.Errorf("listening on gRPC port %s: %v", port, err)
+	}
+	server := c.NewGRPCServer()
+	go func() {
+		if err := server.Serve(listener); err != nil {
+			log.Printf("gRPC server stopped: %v", err)
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,127 @@
+// Package idgen generates collision-free, time-ordered identifiers (ULIDs)
+// for proofs, sessions, jobs and tx hashes. IDs created within the same
+// millisecond are monotonic, so two entities arriving together never share
+// an ID and still sort in creation order.
+package idgen
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Crockford's base32 alphabet used by ULIDs.
+const encoding = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// ULID is a 128-bit identifier: 48-bit millisecond timestamp followed by 80
+// bits of randomness.
+type ULID [16]byte
+
+var (
+	mu      sync.Mutex
+	lastMs  uint64
+	lastRnd [10]byte
+	now     = time.Now
+
+	// sequential replaces the random part with a counter
+	sequential bool
+)
+
+// SetDeterministic makes IDs reproducible: timestamps come from now and the
+// random part is a counter starting at zero, so the same sequence of calls
+// yields the same IDs on every run.
+func SetDeterministic(clock func() time.Time) {
+	mu.Lock()
+	defer mu.Unlock()
+	now = clock
+	sequential = true
+	lastMs = 0
+	lastRnd = [10]byte{}
+}
+
+// NewULID returns a new monotonic ULID.
+func NewULID() ULID {
+	mu.Lock()
+	defer mu.Unlock()
+
+	ms := uint64(now().UnixMilli())
+	if ms <= lastMs || sequential {
+		// Same (or earlier) millisecond, or sequential mode: increment the
+		// random part
+		if ms < lastMs {
+			ms = lastMs
+		}
+		lastMs = ms
+		for i := len(lastRnd) - 1; i >= 0; i-- {
+			lastRnd[i]++
+			if lastRnd[i] != 0 {
+				break
+			}
+		}
+	} else {
+		lastMs = ms
+		if _, err := rand.Read(lastRnd[:]); err != nil {
+			panic("idgen: crypto/rand failed: " + err.Error())
+		}
+	}
+
+	var id ULID
+	var ts [8]byte
+	binary.BigEndian.PutUint64(ts[:], ms)
+	copy(id[:6], ts[2:])
+	copy(id[6:], lastRnd[:])
+	return id
+}
+
+// String encodes the ULID as 26 Crockford base32 characters.
+func (id ULID) String() string {
+	var out [26]byte
+	// 128 bits in 26 characters of 5 bits: the first character carries 3 bits
+	hi := binary.BigEndian.Uint64(id[:8])
+	lo := binary.BigEndian.Uint64(id[8:])
+	for i := 25; i >= 0; i-- {
+		out[i] = encoding[lo&31]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(out[:])
+}
+
+// Time returns the timestamp embedded in the ULID.
+func (id ULID) Time() time.Time {
+	var ts [8]byte
+	copy(ts[2:], id[:6])
+	return time.UnixMilli(int64(binary.BigEndian.Uint64(ts[:])))
+}
+
+// New returns a new ULID string.
+func New() string {
+	return NewULID().String()
+}
+
+// NewWithPrefix returns "<prefix>_<ulid>", e.g. proof_01HZX3....
+func NewWithPrefix(prefix string) string {
+	return prefix + "_" + New()
+}
+
+// NewHex returns a unique 64-character uppercase hex string built from a
+// ULID followed by 16 random bytes, suitable where a hash-shaped ID is
+// expected.
+func NewHex() string {
+	id := NewULID()
+	var buf [32]byte
+	copy(buf[:16], id[:])
+	mu.Lock()
+	deterministic := sequential
+	mu.Unlock()
+	if deterministic {
+		return strings.ToUpper(hex.EncodeToString(buf[:]))
+	}
+	if _, err := rand.Read(buf[16:]); err != nil {
+		panic("idgen: crypto/rand failed: " + err.Error())
+	}
+	return strings.ToUpper(hex.EncodeToString(buf[:]))
+}
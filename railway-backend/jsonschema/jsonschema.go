@@ -0,0 +1,423 @@
+// Package jsonschema validates decoded JSON values against the subset of
+// JSON Schema (draft 2020-12) that credential schemas use: type, enum,
+// const, the string, number and array bounds, pattern, format, properties,
+// required, additionalProperties, items, allOf, anyOf and oneOf. Other
+// annotation keywords are ignored; $ref is rejected, as schemas are
+// registered standalone.
+package jsonschema
+
+import (
+	"fmt"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Schema is a compiled schema.
+type Schema struct {
+	types                []string
+	enum                 []interface{}
+	constValue           interface{}
+	hasConst             bool
+	minimum, maximum     *float64
+	exclusiveMin         *float64
+	exclusiveMax         *float64
+	minLength, maxLength *int
+	pattern              *regexp.Regexp
+	format               string
+	properties           map[string]*Schema
+	required             []string
+	additional           *Schema
+	noAdditional         bool
+	items                *Schema
+	minItems, maxItems   *int
+	allOf, anyOf, oneOf  []*Schema
+	// never is the false schema
+	never bool
+}
+
+// ValidationError is one violation, at a JSON path such as
+// credentialSubject.age.
+type ValidationError struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+func (e ValidationError) Error() string {
+	if e.Path == "" {
+		return e.Message
+	}
+	return e.Path + ": " + e.Message
+}
+
+// Compile checks a decoded schema and prepares it for validation.
+func Compile(schema interface{}) (*Schema, error) {
+	return compile(schema, "")
+}
+
+func compile(raw interface{}, at string) (*Schema, error) {
+	switch v := raw.(type) {
+	case bool:
+		return &Schema{never: !v}, nil
+	case map[string]interface{}:
+		return compileObject(v, at)
+	}
+	return nil, fmt.Errorf("%sschema must be an object or a boolean", prefix(at))
+}
+
+func compileObject(raw map[string]interface{}, at string) (*Schema, error) {
+	s := &Schema{}
+	if _, ok := raw["$ref"]; ok {
+		return nil, fmt.Errorf("%s$ref is not supported", prefix(at))
+	}
+
+	switch t := raw["type"].(type) {
+	case nil:
+	case string:
+		s.types = []string{t}
+	case []interface{}:
+		for _, item := range t {
+			name, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("%stype must list type names", prefix(at))
+			}
+			s.types = append(s.types, name)
+		}
+	default:
+		return nil, fmt.Errorf("%stype must be a string or a list", prefix(at))
+	}
+	for _, t := range s.types {
+		switch t {
+		case "object", "array", "string", "number", "integer", "boolean", "null":
+		default:
+			return nil, fmt.Errorf("%sunknown type %q", prefix(at), t)
+		}
+	}
+
+	if enum, ok := raw["enum"]; ok {
+		list, ok := enum.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%senum must be a list", prefix(at))
+		}
+		s.enum = list
+	}
+	s.constValue, s.hasConst = raw["const"]
+
+	var err error
+	for keyword, dst := range map[string]**float64{
+		"minimum":          &s.minimum,
+		"maximum":          &s.maximum,
+		"exclusiveMinimum": &s.exclusiveMin,
+		"exclusiveMaximum": &s.exclusiveMax,
+	} {
+		if *dst, err = number(raw, keyword, at); err != nil {
+			return nil, err
+		}
+	}
+	for keyword, dst := range map[string]**int{
+		"minLength": &s.minLength,
+		"maxLength": &s.maxLength,
+		"minItems":  &s.minItems,
+		"maxItems":  &s.maxItems,
+	} {
+		if *dst, err = count(raw, keyword, at); err != nil {
+			return nil, err
+		}
+	}
+
+	if pattern, ok := raw["pattern"].(string); ok {
+		if s.pattern, err = regexp.Compile(pattern); err != nil {
+			return nil, fmt.Errorf("%spattern: %v", prefix(at), err)
+		}
+	}
+	s.format, _ = raw["format"].(string)
+
+	if props, ok := raw["properties"]; ok {
+		propMap, ok := props.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%sproperties must be an object", prefix(at))
+		}
+		s.properties = make(map[string]*Schema, len(propMap))
+		for name, sub := range propMap {
+			if s.properties[name], err = compile(sub, join(at, name)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if required, ok := raw["required"]; ok {
+		list, ok := required.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%srequired must be a list", prefix(at))
+		}
+		for _, item := range list {
+			name, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("%srequired must list property names", prefix(at))
+			}
+			s.required = append(s.required, name)
+		}
+	}
+	switch additional := raw["additionalProperties"].(type) {
+	case nil:
+	case bool:
+		s.noAdditional = !additional
+	default:
+		if s.additional, err = compile(additional, join(at, "*")); err != nil {
+			return nil, err
+		}
+	}
+	if items, ok := raw["items"]; ok {
+		if s.items, err = compile(items, at+"[]"); err != nil {
+			return nil, err
+		}
+	}
+	for keyword, dst := range map[string]*[]*Schema{"allOf": &s.allOf, "anyOf": &s.anyOf, "oneOf": &s.oneOf} {
+		list, ok := raw[keyword]
+		if !ok {
+			continue
+		}
+		subs, ok := list.([]interface{})
+		if !ok || len(subs) == 0 {
+			return nil, fmt.Errorf("%s%s must be a non-empty list", prefix(at), keyword)
+		}
+		for _, sub := range subs {
+			compiled, err := compile(sub, at)
+			if err != nil {
+				return nil, err
+			}
+			*dst = append(*dst, compiled)
+		}
+	}
+	return s, nil
+}
+
+func number(raw map[string]interface{}, keyword, at string) (*float64, error) {
+	value, ok := raw[keyword]
+	if !ok {
+		return nil, nil
+	}
+	n, ok := value.(float64)
+	if !ok {
+		return nil, fmt.Errorf("%s%s must be a number", prefix(at), keyword)
+	}
+	return &n, nil
+}
+
+func count(raw map[string]interface{}, keyword, at string) (*int, error) {
+	value, ok := raw[keyword]
+	if !ok {
+		return nil, nil
+	}
+	n, ok := value.(float64)
+	if !ok || n < 0 || n != float64(int(n)) {
+		return nil, fmt.Errorf("%s%s must be a non-negative integer", prefix(at), keyword)
+	}
+	i := int(n)
+	return &i, nil
+}
+
+// Validate returns every violation of value, in a stable order. value is
+// as decoded by encoding/json into interface{}.
+func (s *Schema) Validate(value interface{}) []ValidationError {
+	var errs []ValidationError
+	s.validate(value, "", &errs)
+	return errs
+}
+
+func (s *Schema) validate(value interface{}, at string, errs *[]ValidationError) {
+	fail := func(format string, args ...interface{}) {
+		*errs = append(*errs, ValidationError{Path: at, Message: fmt.Sprintf(format, args...)})
+	}
+	if s.never {
+		fail("not allowed")
+		return
+	}
+	if len(s.types) > 0 && !s.matchesType(value) {
+		fail("must be %s, got %s", strings.Join(s.types, " or "), typeOf(value))
+		return
+	}
+	if s.enum != nil {
+		found := false
+		for _, allowed := range s.enum {
+			if equal(value, allowed) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			fail("must be one of %s", formatList(s.enum))
+		}
+	}
+	if s.hasConst && !equal(value, s.constValue) {
+		fail("must be %v", s.constValue)
+	}
+
+	switch v := value.(type) {
+	case float64:
+		if s.minimum != nil && v < *s.minimum {
+			fail("must be >= %v", *s.minimum)
+		}
+		if s.maximum != nil && v > *s.maximum {
+			fail("must be <= %v", *s.maximum)
+		}
+		if s.exclusiveMin != nil && v <= *s.exclusiveMin {
+			fail("must be > %v", *s.exclusiveMin)
+		}
+		if s.exclusiveMax != nil && v >= *s.exclusiveMax {
+			fail("must be < %v", *s.exclusiveMax)
+		}
+	case string:
+		length := utf8.RuneCountInString(v)
+		if s.minLength != nil && length < *s.minLength {
+			fail("must be at least %d characters", *s.minLength)
+		}
+		if s.maxLength != nil && length > *s.maxLength {
+			fail("must be at most %d characters", *s.maxLength)
+		}
+		if s.pattern != nil && !s.pattern.MatchString(v) {
+			fail("must match %s", s.pattern)
+		}
+		if s.format != "" && !validFormat(s.format, v) {
+			fail("must be a valid %s", s.format)
+		}
+	case []interface{}:
+		if s.minItems != nil && len(v) < *s.minItems {
+			fail("must have at least %d items", *s.minItems)
+		}
+		if s.maxItems != nil && len(v) > *s.maxItems {
+			fail("must have at most %d items", *s.maxItems)
+		}
+		if s.items != nil {
+			for i, item := range v {
+				s.items.validate(item, fmt.Sprintf("%s[%d]", at, i), errs)
+			}
+		}
+	case map[string]interface{}:
+		for _, name := range s.required {
+			if _, ok := v[name]; !ok {
+				*errs = append(*errs, ValidationError{Path: join(at, name), Message: "is required"})
+			}
+		}
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if sub, ok := s.properties[name]; ok {
+				sub.validate(v[name], join(at, name), errs)
+			} else if s.noAdditional {
+				*errs = append(*errs, ValidationError{Path: join(at, name), Message: "is not allowed"})
+			} else if s.additional != nil {
+				s.additional.validate(v[name], join(at, name), errs)
+			}
+		}
+	}
+
+	for _, sub := range s.allOf {
+		sub.validate(value, at, errs)
+	}
+	if len(s.anyOf) > 0 {
+		matched := false
+		for _, sub := range s.anyOf {
+			if len(sub.Validate(value)) == 0 {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			fail("must match at least one schema in anyOf")
+		}
+	}
+	if len(s.oneOf) > 0 {
+		matched := 0
+		for _, sub := range s.oneOf {
+			if len(sub.Validate(value)) == 0 {
+				matched++
+			}
+		}
+		if matched != 1 {
+			fail("must match exactly one schema in oneOf, matched %d", matched)
+		}
+	}
+}
+
+func (s *Schema) matchesType(value interface{}) bool {
+	actual := typeOf(value)
+	for _, t := range s.types {
+		if t == actual || (t == "number" && actual == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+func typeOf(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		if v == float64(int64(v)) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+func validFormat(format, value string) bool {
+	switch format {
+	case "date":
+		_, err := time.Parse("2006-01-02", value)
+		return err == nil
+	case "date-time":
+		_, err := time.Parse(time.RFC3339, value)
+		return err == nil
+	case "email":
+		_, err := mail.ParseAddress(value)
+		return err == nil
+	case "uri":
+		u, err := url.Parse(value)
+		return err == nil && u.Scheme != ""
+	}
+	// Unknown formats are annotations only
+	return true
+}
+
+func equal(a, b interface{}) bool {
+	return fmt.Sprintf("%#v", a) == fmt.Sprintf("%#v", b)
+}
+
+func formatList(values []interface{}) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = fmt.Sprintf("%v", v)
+	}
+	return strings.Join(parts, ", ")
+}
+
+func join(at, name string) string {
+	if at == "" {
+		return name
+	}
+	return at + "." + name
+}
+
+func prefix(at string) string {
+	if at == "" {
+		return ""
+	}
+	return at + ": "
+}
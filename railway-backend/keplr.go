@@ -0,0 +1,131 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Keplr chain info, so the frontend's "Add Persona testnet to Keplr" button
+// can call window.keplr.experimentalSuggestChain with GET /chain-info.
+// Every network serves its own (/networks/{chain_id}/chain-info), with rpc
+// and rest pointing back at this server. Currencies come from the bank
+// denom metadata; the chain denom is the stake and fee currency.
+// KEPLR_CHAIN_NAME names the default network ("Persona <chain id>"
+// otherwise) and KEPLR_GAS_PRICE_STEP sets the low,average,high gas prices
+// (0.01,0.025,0.04).
+
+// Addresses are cosmos1..., see signing.go
+const (
+	bech32AccountPrefix = "cosmos"
+	bip44CoinType       = 118
+)
+
+type keplrCurrency struct {
+	CoinDenom        string `json:"coinDenom"`
+	CoinMinimalDenom string `json:"coinMinimalDenom"`
+	CoinDecimals     uint32 `json:"coinDecimals"`
+}
+
+type keplrGasPriceStep struct {
+	Low     float64 `json:"low"`
+	Average float64 `json:"average"`
+	High    float64 `json:"high"`
+}
+
+type keplrFeeCurrency struct {
+	keplrCurrency
+	GasPriceStep keplrGasPriceStep `json:"gasPriceStep"`
+}
+
+var (
+	keplrChainName = os.Getenv("KEPLR_CHAIN_NAME")
+	keplrGasPrices = gasPriceStepFromEnv()
+)
+
+func gasPriceStepFromEnv() keplrGasPriceStep {
+	step := keplrGasPriceStep{Low: 0.01, Average: 0.025, High: 0.04}
+	raw := os.Getenv("KEPLR_GAS_PRICE_STEP")
+	if raw == "" {
+		return step
+	}
+	fields := strings.Split(raw, ",")
+	prices := make([]float64, len(fields))
+	for i, field := range fields {
+		price, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+		if err != nil || price < 0 {
+			fields = nil
+			break
+		}
+		prices[i] = price
+	}
+	if len(fields) != 3 || prices[0] > prices[1] || prices[1] > prices[2] {
+		log.Printf("Invalid KEPLR_GAS_PRICE_STEP %q, expected low,average,high", raw)
+		return step
+	}
+	return keplrGasPriceStep{Low: prices[0], Average: prices[1], High: prices[2]}
+}
+
+// keplrCurrencyOf describes a denom in its display unit.
+func keplrCurrencyOf(metadata denomMetadata) keplrCurrency {
+	currency := keplrCurrency{CoinDenom: metadata.Symbol, CoinMinimalDenom: metadata.Base}
+	for _, unit := range metadata.DenomUnits {
+		if unit.Denom == metadata.Display {
+			currency.CoinDecimals = unit.Exponent
+		}
+	}
+	if currency.CoinDenom == "" {
+		currency.CoinDenom = strings.ToUpper(metadata.Display)
+	}
+	return currency
+}
+
+// Handler for GET /chain-info
+func (c *Chain) handleChainInfo(w http.ResponseWriter, r *http.Request) {
+	endpoint := requestBaseURL(r)
+	name := "Persona " + c.ChainID()
+	if n := findNetwork(c.ChainID()); n == nil || n.isDefault {
+		if keplrChainName != "" {
+			name = keplrChainName
+		}
+	} else {
+		endpoint += networkPrefix(c.ChainID())
+	}
+
+	currencies := []keplrCurrency{}
+	stake := keplrCurrencyOf(defaultDenomMetadata(c.denom))
+	for _, metadata := range c.bank().metadata {
+		currency := keplrCurrencyOf(metadata)
+		if metadata.Base == c.denom {
+			stake = currency
+		}
+		currencies = append(currencies, currency)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"chainId":   c.ChainID(),
+		"chainName": name,
+		// Tendermint RPC (/status, /websocket) and REST share this server
+		"rpc":   endpoint,
+		"rest":  endpoint,
+		"bip44": map[string]interface{}{"coinType": bip44CoinType},
+		"bech32Config": map[string]string{
+			"bech32PrefixAccAddr":  bech32AccountPrefix,
+			"bech32PrefixAccPub":   bech32AccountPrefix + "pub",
+			"bech32PrefixValAddr":  bech32AccountPrefix + "valoper",
+			"bech32PrefixValPub":   bech32AccountPrefix + "valoperpub",
+			"bech32PrefixConsAddr": bech32AccountPrefix + "valcons",
+			"bech32PrefixConsPub":  bech32AccountPrefix + "valconspub",
+		},
+		"currencies":    currencies,
+		"feeCurrencies": []keplrFeeCurrency{{keplrCurrency: stake, GasPriceStep: keplrGasPrices}},
+		"stakeCurrency": stake,
+		// Read by Keplr versions before 0.10
+		"gasPriceStep": keplrGasPrices,
+		"features":     []string{},
+	})
+}
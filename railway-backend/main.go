@@ -29,10 +29,18 @@ type NodeInfo struct {
 }
 
 type MockTxResponse struct {
-	TxHash string `json:"txhash"`
-	Height int64  `json:"height"`
-	Code   int    `json:"code"`
-	Data   string `json:"data"`
+	TxHash    string `json:"txhash"`
+	Height    int64  `json:"height"`
+	Code      int    `json:"code"`
+	Codespace string `json:"codespace,omitempty"`
+	Data      string `json:"data"`
+	RawLog    string `json:"raw_log"`
+	// Human message for the code in the negotiated locale (errorcatalog.go)
+	LocalizedLog string `json:"localized_log,omitempty"`
+	// Events and logs of the delivered tx (txevents.go), reported in block
+	// mode and by tx queries
+	Events []abciEvent       `json:"events,omitempty"`
+	Logs   []abciMessageLog `json:"logs,omitempty"`
 }
 
 type MockAccount struct {
@@ -41,70 +49,341 @@ type MockAccount struct {
 }
 
 var (
-	chainInfo = MockChainInfo{
-		ChainID:      "persona-testnet-1",
-		LatestHeight: 1000,
-		LatestTime:   time.Now().Format(time.RFC3339),
-		NodeInfo: NodeInfo{
-			ID:      "mock-node-001",
-			Moniker: "testnet-node",
-			Version: "v1.0.0-test",
-		},
-	}
+	// The default chain. Created in main, after every module has registered.
+	defaultChain *Chain
 	
 	mockAccounts = []MockAccount{
 		{Address: "cosmos1test1", Balance: "1000000000stake"},
 		{Address: "cosmos1test2", Balance: "1000000000stake"},
 	}
-	
-	// In-memory storage for created DIDs (keyed by DID ID)
-	createdDIDs = make(map[string]map[string]interface{})
-	// Map wallet address to DID ID for easy lookup
-	walletToDID = make(map[string]string)
-	// Storage for credentials by controller
-	credentialsByController = make(map[string][]map[string]interface{})
-	// Storage for proofs by controller
-	proofsByController = make(map[string][]map[string]interface{})
 )
 
 func main() {
-	r := mux.NewRouter()
-	
-	// Add CORS middleware to allow cross-origin requests
-	r.Use(corsMiddleware)
+	// Subcommands (cli.go); serving is the default
+	if runCLI(os.Args[1:]) {
+		return
+	}
 	
-	// Status endpoint - mimics Cosmos SDK status
-	r.HandleFunc("/status", handleStatus).Methods("GET")
+	initObjectStore()
+	defaultConfig := ChainConfig{
+		ChainID:       "persona-testnet-1",
+		InitialHeight: 1000,
+		BlockTime:     durationFromEnv("BLOCK_TIME", 5*time.Second),
+		Clock:         appClock,
+		NodeInfo: NodeInfo{
+			ID:      "mock-node-001",
+			Moniker: "testnet-node",
+			Version: "v1.0.0-test",
+		},
+	}
+	defaultChain = NewChain(defaultConfig)
+	if fixturesDir != "" {
+		if _, err := defaultChain.loadFixtures(fixturesDir); err != nil {
+			log.Fatalf("Loading fixtures: %v", err)
+		}
+	}
+	defaultChain.startBlockProducer()
+	setupNetworks(defaultConfig)
+	startBilling()
+	defaultChain.startWebhookDispatcher()
+	defaultChain.startSecurityEventRecorder()
+	defaultChain.startAnalyticsSink()
+	resourceWatchdog.start()
 	
-	// Node info endpoint
-	r.HandleFunc("/node_info", handleNodeInfo).Methods("GET")
+	r := mux.NewRouter()
 	
-	// Mock transaction broadcast
-	r.HandleFunc("/cosmos/tx/v1beta1/txs", handleBroadcastTx).Methods("POST", "OPTIONS")
+	// Traffic recording as HAR, see /admin/recordings
+	r.Use(recordingMiddleware)
 	
-	// Mock account queries
-	r.HandleFunc("/cosmos/bank/v1beta1/balances/{address}", handleAccountBalance).Methods("GET", "OPTIONS")
+	// Add CORS middleware to allow cross-origin requests
+	r.Use(corsMiddleware)
 	
-	// Mock DID operations
-	r.HandleFunc("/persona/did/v1beta1/did_documents", handleListDIDs).Methods("GET", "OPTIONS")
-	r.HandleFunc("/persona/did/v1beta1/did_documents/{id}", handleGetDID).Methods("GET", "OPTIONS")
-	r.HandleFunc("/persona/did/v1beta1/did_by_controller/{controller}", handleGetDIDByController).Methods("GET", "OPTIONS")
+	// Trace IDs from X-Request-ID or traceparent, see /api/trace/{id}
+	r.Use(traceMiddleware)
+	
+	// Custom domains resolve to their relying party, see /admin/domains
+	r.Use(customDomainMiddleware)
+	
+	// IP and country blocking, configured through /admin/access
+	r.Use(accessMiddleware)
+	
+	// Per-IP and per-API-key rate limits, configured through /admin/ratelimit
+	r.Use(rateLimitMiddleware)
+	
+	// Latency and failure injection, configured through /admin/chaos
+	r.Use(chaosMiddleware)
+	
+	// Per-region latency and outages, configured through /admin/geo
+	r.Use(geoLatencyMiddleware)
+	
+	// snake_case or camelCase JSON keys, negotiated through X-JSON-Casing
+	r.Use(casingMiddleware)
+	
+	// Chain core routes plus the did, vc and zk module routes
+	defaultChain.RegisterRoutes(r)
+	
+	// Every network's chain routes under /networks/{chain_id}, see NETWORKS
+	registerNetworkRoutes(r)
+	r.HandleFunc("/api/networks", handleListNetworks).Methods("GET", "OPTIONS")
+	r.HandleFunc("/api/networks/portability", handleCheckPortability).Methods("POST", "OPTIONS")
+	
+	// Tenant chains under /t/{tenant}, created at /admin/tenants
+	r.PathPrefix("/t/{tenant}/").HandlerFunc(handleTenantRequest)
+	
+	// New API routes for template system, behind API keys (apikeys.go)
+	r.HandleFunc("/api/getRequirements", apiKeyProtected("requirements:read", "getRequirements", handleGetRequirements)).Methods("POST", "OPTIONS")
+	r.HandleFunc("/api/getVc", apiKeyProtected("credentials:read", "getVc", handleGetVc)).Methods("GET", "OPTIONS")
+	
+	// Artifact storage (presigned S3 URLs)
+	r.HandleFunc("/api/artifacts/presign", handlePresignArtifact).Methods("POST", "OPTIONS")
+	r.HandleFunc("/api/artifacts/{category}/{name:.+}", handleGetArtifact).Methods("GET", "OPTIONS")
+	
+	// Subscription billing simulation
+	r.HandleFunc("/api/billing/plans", handleListPlans).Methods("GET", "OPTIONS")
+	r.HandleFunc("/api/billing/subscriptions", handleCreateSubscription).Methods("POST", "OPTIONS")
+	r.HandleFunc("/api/billing/subscriptions", handleListSubscriptions).Methods("GET")
+	r.HandleFunc("/api/billing/subscriptions/{id}", handleGetSubscription).Methods("GET", "OPTIONS")
+	r.HandleFunc("/api/billing/subscriptions/{id}", handleUpdateSubscription).Methods("POST")
+	r.HandleFunc("/api/billing/subscriptions/{id}", handleCancelSubscription).Methods("DELETE")
+	r.HandleFunc("/api/billing/invoices", handleListInvoices).Methods("GET", "OPTIONS")
+	r.HandleFunc("/api/billing/invoices/{id}", handleGetInvoice).Methods("GET", "OPTIONS")
+	r.HandleFunc("/api/billing/invoices/{id}/pay", handlePayInvoice).Methods("POST", "OPTIONS")
+	r.HandleFunc("/api/billing/events", handleListBillingEvents).Methods("GET", "OPTIONS")
+	
+	// White-label theming for relying parties
+	r.HandleFunc("/api/branding", handleGetHostBranding).Methods("GET", "OPTIONS")
+	r.HandleFunc("/api/branding/{rp}", handleGetBranding).Methods("GET", "OPTIONS")
+	r.HandleFunc("/api/domain", handleResolveDomain).Methods("GET", "OPTIONS")
+	
+	// Admin routes, guarded by ADMIN_TOKEN when it is set
+	admin := NewAdminRouter(r)
+	defaultChain.RegisterAdminRoutes(admin)
+	
+	// Broadcast fault injection
+	admin.HandleFunc("/faults", handleListFaults).Methods("GET", "OPTIONS")
+	admin.HandleFunc("/faults", handleAddFault).Methods("POST")
+	admin.HandleFunc("/faults", handleDeleteFaults).Methods("DELETE")
+	admin.HandleFunc("/faults/{id}", handleDeleteFaults).Methods("DELETE", "OPTIONS")
+	
+	// API keys of the partner endpoints
+	admin.HandleFunc("/apikeys", handleListAPIKeys).Methods("GET", "OPTIONS")
+	admin.HandleFunc("/apikeys", handleCreateAPIKey).Methods("POST")
+	admin.HandleFunc("/apikeys/{id}", handleRevokeAPIKey).Methods("DELETE", "OPTIONS")
+	
+	// Rate limits for the rate limit middleware
+	admin.HandleFunc("/ratelimit", handleGetRateLimits).Methods("GET", "OPTIONS")
+	admin.HandleFunc("/ratelimit", handleSetRateLimits).Methods("PUT")
+	
+	// Verification, issuance and proof quotas
+	admin.HandleFunc("/quotas", handleListQuotas).Methods("GET", "OPTIONS")
+	admin.HandleFunc("/quotas", handleSetQuotas).Methods("PUT")
+	
+	// Chaos rules for the chaos middleware
+	admin.HandleFunc("/chaos", handleListChaos).Methods("GET", "OPTIONS")
+	admin.HandleFunc("/chaos", handleAddChaos).Methods("POST")
+	admin.HandleFunc("/chaos", handleDeleteChaos).Methods("DELETE")
+	admin.HandleFunc("/chaos/{id}", handleDeleteChaos).Methods("DELETE", "OPTIONS")
+	
+	// IP allow/deny lists and blocked countries
+	admin.HandleFunc("/access", handleGetAccessRules).Methods("GET", "OPTIONS")
+	admin.HandleFunc("/access", handlePutAccessRules).Methods("PUT")
+	
+	// Abuse blocks
+	admin.HandleFunc("/abuse", handleListAbuse).Methods("GET", "OPTIONS")
+	admin.HandleFunc("/abuse/{address}", handleUnblockAddress).Methods("DELETE", "OPTIONS")
+	
+	// Client region latency profiles
+	admin.HandleFunc("/geo", handleGetGeo).Methods("GET", "OPTIONS")
+	admin.HandleFunc("/geo", handleConfigureGeo).Methods("POST")
+	admin.HandleFunc("/geo/{region}", handlePutRegionProfile).Methods("PUT", "OPTIONS")
+	admin.HandleFunc("/geo/{region}", handleDeleteRegionProfile).Methods("DELETE")
+	
+	// Billing clock and webhook deliveries
+	admin.HandleFunc("/billing/advance", handleAdvanceBilling).Methods("POST", "OPTIONS")
+	admin.HandleFunc("/billing/webhooks", handleListWebhooks).Methods("GET", "OPTIONS")
+	
+	// Relying-party branding records
+	admin.HandleFunc("/branding", handleListBranding).Methods("GET", "OPTIONS")
+	admin.HandleFunc("/branding/{rp}", handlePutBranding).Methods("PUT", "OPTIONS")
+	admin.HandleFunc("/branding/{rp}", handleDeleteBranding).Methods("DELETE")
+	
+	// Custom domain routing table
+	admin.HandleFunc("/domains", handleListDomains).Methods("GET", "OPTIONS")
+	admin.HandleFunc("/domains/{domain}", handlePutDomain).Methods("PUT", "OPTIONS")
+	admin.HandleFunc("/domains/{domain}", handleDeleteDomain).Methods("DELETE")
+	
+	// Signed callbacks for credential and proof lifecycle events
+	admin.HandleFunc("/webhooks", handleListWebhookRegistrations).Methods("GET", "OPTIONS")
+	admin.HandleFunc("/webhooks", handleRegisterWebhook).Methods("POST")
+	admin.HandleFunc("/webhooks/{id}", handleGetWebhookRegistration).Methods("GET", "OPTIONS")
+	admin.HandleFunc("/webhooks/{id}", handleDeleteWebhookRegistration).Methods("DELETE")
+	admin.HandleFunc("/webhooks/{id}/ping", handlePingWebhook).Methods("POST", "OPTIONS")
+	admin.HandleFunc("/zk/verifying-keys", handleListVerifyingKeys).Methods("GET", "OPTIONS")
+	admin.HandleFunc("/zk/verifying-keys/{circuit}", handlePutVerifyingKey).Methods("PUT", "OPTIONS")
+	admin.HandleFunc("/zk/verifying-keys/{circuit}", handleDeleteVerifyingKey).Methods("DELETE")
+	
+	// Notifications sent through the mock push gateway
+	admin.HandleFunc("/push", handleListPushDevices).Methods("GET", "OPTIONS")
+	admin.HandleFunc("/push/{token}", handleGetPushInbox).Methods("GET", "OPTIONS")
+	admin.HandleFunc("/push/{token}", handleClearPushInbox).Methods("DELETE")
+	
+	// Worker pool sizes and queue depths
+	admin.HandleFunc("/pools", handleListWorkerPools).Methods("GET", "OPTIONS")
+	admin.HandleFunc("/pools/{name}", handleResizeWorkerPool).Methods("PUT", "OPTIONS")
+	
+	// Policy document versions
+	admin.HandleFunc("/policies", handleListPolicyVersions).Methods("GET", "OPTIONS")
+	admin.HandleFunc("/policies", handlePublishPolicy).Methods("POST")
+	
+	// Data residency partitions
+	admin.HandleFunc("/residency", defaultChain.handleGetResidency).Methods("GET", "OPTIONS")
+	
+	// Disputes of failed verifications
+	admin.HandleFunc("/disputes", handleListDisputes).Methods("GET", "OPTIONS")
+	admin.HandleFunc("/disputes/{id}/resolve", handleAdminResolveDispute).Methods("POST", "OPTIONS")
+	
+	// Analytics anonymization pipeline
+	admin.HandleFunc("/analytics/pipeline", handleSetAnalyticsPipeline).Methods("PUT", "OPTIONS")
+	
+	// Cross-network trust policies for credential portability
+	admin.HandleFunc("/networks/trust", handleListTrustPolicies).Methods("GET", "OPTIONS")
+	admin.HandleFunc("/networks/{chain_id}/trust", handlePutTrustPolicy).Methods("PUT", "OPTIONS")
+	admin.HandleFunc("/networks/{chain_id}/trust", handleDeleteTrustPolicy).Methods("DELETE")
+	
+	// Time travel for expiry testing
+	admin.HandleFunc("/time", handleGetTime).Methods("GET", "OPTIONS")
+	admin.HandleFunc("/time", handleSetTime).Methods("POST")
+	admin.HandleFunc("/time", handleResetTime).Methods("DELETE")
+	
+	// Record-and-replay proxy status
+	admin.HandleFunc("/proxy", handleGetProxy).Methods("GET", "OPTIONS")
+	
+	// Recorded traffic as HAR
+	admin.HandleFunc("/recordings", handleGetRecordings).Methods("GET", "OPTIONS")
+	admin.HandleFunc("/recordings", handleClearRecordings).Methods("DELETE")
+	
+	// Tenant chains for parallel E2E shards
+	admin.HandleFunc("/tenants", handleListTenants).Methods("GET", "OPTIONS")
+	admin.HandleFunc("/tenants", handleCreateTenant).Methods("POST")
+	admin.HandleFunc("/tenants/{id}", handleGetTenant).Methods("GET", "OPTIONS")
+	admin.HandleFunc("/tenants/{id}", handleDeleteTenant).Methods("DELETE")
+	admin.HandleFunc("/tenants/{id}/reset", handleResetTenant).Methods("POST", "OPTIONS")
+	admin.HandleFunc("/tenants/{id}/seed", handleSeedTenant).Methods("POST", "OPTIONS")
+	admin.HandleFunc("/tenants/{id}/snapshot", handleSnapshotTenant).Methods("POST", "OPTIONS")
+	admin.HandleFunc("/tenants/{id}/restore/{snapshot}", handleRestoreTenant).Methods("POST", "OPTIONS")
+	
+	// Dual-write reconciliation against the real node
+	admin.HandleFunc("/dual-write/report", handleDualWriteReport).Methods("GET", "OPTIONS")
+	
+	// Mock push gateway (FCM/APNs stand-in)
+	r.HandleFunc("/api/push/devices", handleRegisterPushDevice).Methods("POST", "OPTIONS")
+	r.HandleFunc("/api/push/devices/{token}", handleUnregisterPushDevice).Methods("DELETE", "OPTIONS")
+	r.HandleFunc("/api/push/send", handleSendPush).Methods("POST", "OPTIONS")
+	
+	// Devices per DID and their sessions
+	r.HandleFunc("/api/dids/{did}/devices", handleListDevices).Methods("GET", "OPTIONS")
+	r.HandleFunc("/api/dids/{did}/devices", handleRegisterDevice).Methods("POST")
+	r.HandleFunc("/api/dids/{did}/devices/{id}", handleGetDevice).Methods("GET", "OPTIONS")
+	r.HandleFunc("/api/dids/{did}/devices/{id}", handleRenameDevice).Methods("PATCH")
+	r.HandleFunc("/api/dids/{did}/devices/{id}", handleRevokeDevice).Methods("DELETE")
+	r.HandleFunc("/api/dids/{did}/devices/{id}/sessions", handleStartDeviceSession).Methods("POST", "OPTIONS")
+	r.HandleFunc("/api/session", handleGetSession).Methods("GET", "OPTIONS")
+	r.HandleFunc("/api/sessions", handleListSessions).Methods("GET", "OPTIONS")
+	r.HandleFunc("/api/sessions", handleRevokeOtherSessions).Methods("DELETE")
+	r.HandleFunc("/api/sessions/{id}", handleRevokeSession).Methods("DELETE", "OPTIONS")
+	r.HandleFunc("/api/dids/{did}/security/events", handleListSecurityEvents).Methods("GET", "OPTIONS")
+	r.HandleFunc("/api/dids/{did}/security/events/ack", handleAcknowledgeSecurityEvents).Methods("POST", "OPTIONS")
+	r.HandleFunc("/api/dids/{did}/security/events/{id}/ack", handleAcknowledgeSecurityEvent).Methods("POST", "OPTIONS")
+	
+	// Terms of service and policy acceptance
+	r.HandleFunc("/api/policies", handleListPolicies).Methods("GET", "OPTIONS")
+	r.HandleFunc("/api/dids/{did}/policies", handleGetPolicyStatus).Methods("GET", "OPTIONS")
+	r.HandleFunc("/api/dids/{did}/policies/accept", handleAcceptPolicies).Methods("POST", "OPTIONS")
+	
+	// Disputes and appeals of failed verifications
+	r.HandleFunc("/api/dids/{did}/disputes", handleListDIDDisputes).Methods("GET", "OPTIONS")
+	r.HandleFunc("/api/dids/{did}/disputes", defaultChain.handleOpenDispute).Methods("POST")
+	r.HandleFunc("/api/disputes/{id}", handleGetDispute).Methods("GET", "OPTIONS")
+	r.HandleFunc("/api/disputes/{id}/evidence", handleAddDisputeEvidence).Methods("POST", "OPTIONS")
+	r.HandleFunc("/api/disputes/{id}/withdraw", handleWithdrawDispute).Methods("POST", "OPTIONS")
+	r.HandleFunc("/api/disputes/{id}/review", handleReviewDispute).Methods("POST", "OPTIONS")
+	r.HandleFunc("/api/disputes/{id}/resolve", handleResolveDispute).Methods("POST", "OPTIONS")
+	
+	// Anonymized analytics events and the pipeline producing them
+	r.HandleFunc("/api/analytics/events", handleListAnalyticsEvents).Methods("GET", "OPTIONS")
+	r.HandleFunc("/api/analytics/pipeline", handleGetAnalyticsPipeline).Methods("GET", "OPTIONS")
+	r.HandleFunc("/api/analytics/pipeline/preview", handlePreviewAnalyticsPipeline).Methods("POST", "OPTIONS")
+	
+	// Read-only GraphQL over DIDs, credentials, proofs and circuits
+	r.HandleFunc("/graphql", defaultChain.graphQLHandler()).Methods("GET", "POST", "OPTIONS")
+	
+	// Unique identifiers for parallel E2E shards
+	r.HandleFunc("/api/test-ids/reserve", handleReserveTestIDs).Methods("POST", "OPTIONS")
+	r.HandleFunc("/api/test-ids/runs/{run}", handleGetTestRun).Methods("GET", "OPTIONS")
+	r.HandleFunc("/api/test-ids/runs/{run}", handleReleaseTestRun).Methods("DELETE")
+	
+	// Lifecycle events of one DID, credential or proof
+	r.HandleFunc("/api/entities/{type}/{id:.+}/events", defaultChain.handleEntityEvents).Methods("GET", "OPTIONS")
+	
+	// Compact state assertions for test frameworks
+	r.HandleFunc("/api/assert/{name}", defaultChain.handleAssert).Methods("GET", "OPTIONS")
+	
+	// E2E preflight of the subsystems a test suite needs
+	r.HandleFunc("/api/preflight", defaultChain.handlePreflight).Methods("GET", "OPTIONS")
+	
+	// Capability discovery
+	r.HandleFunc("/.well-known/persona-configuration", defaultChain.handleConfiguration).Methods("GET", "OPTIONS")
+	
+	// Mock OIDC issuer for bearer tokens
+	r.HandleFunc("/.well-known/openid-configuration", handleOpenIDConfiguration).Methods("GET", "OPTIONS")
+	r.HandleFunc("/oauth/token", handleOAuthToken).Methods("POST", "OPTIONS")
+	r.HandleFunc("/oauth/jwks", handleOAuthKeys).Methods("GET", "OPTIONS")
+	
+	// SDK feature detection
+	r.HandleFunc("/api/compat/selftest", defaultChain.handleCompatSelftest).Methods("GET", "OPTIONS")
+	r.HandleFunc("/api/conformance", defaultChain.handleConformance).Methods("GET", "OPTIONS")
+	
+	// Failed attempt counters, blocks and appeals
+	r.HandleFunc("/api/abuse/{address}", handleGetAbuseState).Methods("GET", "OPTIONS")
+	r.HandleFunc("/api/abuse/{address}/appeal", handleAppealBlock).Methods("POST", "OPTIONS")
+	
+	// Localized messages for tx error codes
+	r.HandleFunc("/api/errors", handleErrorCatalog).Methods("GET", "OPTIONS")
+	
+	// Restrictions behind a 451 response
+	r.HandleFunc("/api/access/policy", handleAccessPolicy).Methods("GET", "OPTIONS")
+	
+	// OpenAPI document generated from the routes above, and Swagger UI
+	r.HandleFunc("/openapi.json", defaultChain.openAPIHandler(r)).Methods("GET", "OPTIONS")
+	r.HandleFunc("/docs", handleSwaggerUI).Methods("GET")
+	
+	// TypeScript client generated from the same document
+	r.HandleFunc("/api/sdk/typescript.zip", defaultChain.typeScriptSDKHandler(r)).Methods("GET", "OPTIONS")
+	
+	// Quota usage of a relying party, issuer or DID
+	r.HandleFunc("/api/quotas/{subject}", handleGetQuotas).Methods("GET", "OPTIONS")
+	
+	// Everything a request caused, by its trace ID
+	r.HandleFunc("/api/trace/{id}", defaultChain.handleGetTrace).Methods("GET", "OPTIONS")
+	
+	// Response schemas against the contract pinned by the frontend
+	r.HandleFunc("/api/drift", defaultChain.handleDrift).Methods("GET", "OPTIONS")
+	r.HandleFunc("/api/contract", defaultChain.handleContract).Methods("GET", "OPTIONS")
 	
-	// Mock ZK proof operations
-	r.HandleFunc("/persona/zk/v1beta1/proofs", handleListProofs).Methods("GET", "OPTIONS")
-	r.HandleFunc("/persona/zk/v1beta1/proofs_by_controller/{controller}", handleGetProofsByController).Methods("GET", "OPTIONS")
-	r.HandleFunc("/persona/zk/v1beta1/circuits", handleListCircuits).Methods("GET", "OPTIONS")
+	// Health check
+	r.HandleFunc("/health", handleHealth).Methods("GET")
 	
-	// Mock VC operations
-	r.HandleFunc("/persona/vc/v1beta1/credentials", handleListVCs).Methods("GET", "OPTIONS")
-	r.HandleFunc("/persona/vc/v1beta1/credentials_by_controller/{controller}", handleGetCredentialsByController).Methods("GET", "OPTIONS")
+	// Soak-test leak watchdog and Prometheus metrics
+	r.HandleFunc("/api/watchdog", handleWatchdog).Methods("GET", "OPTIONS")
+	r.HandleFunc("/metrics", handleMetrics).Methods("GET")
 	
-	// New API routes for template system
-	r.HandleFunc("/api/getRequirements", handleGetRequirements).Methods("POST", "OPTIONS")
-	r.HandleFunc("/api/getVc", handleGetVc).Methods("GET", "OPTIONS")
+	// Compare the routes above with CONTRACT_FILE, see drift.go
+	defaultChain.startContractCheck(r)
 	
-	// Health check
-	r.HandleFunc("/health", handleHealth).Methods("GET")
+	// Record or replay unknown routes against UPSTREAM_URL, see proxy.go
+	if err := setupProxy(r); err != nil {
+		log.Fatalf("Proxy: %v", err)
+	}
 	
 	port := os.Getenv("PORT")
 	if port == "" {
@@ -115,19 +394,29 @@ func main() {
 	bindAddr := "0.0.0.0:" + port
 	
 	fmt.Printf("Mock testnet daemon starting on address %s...\n", bindAddr)
-	fmt.Printf("Chain ID: %s\n", chainInfo.ChainID)
+	fmt.Printf("Chain ID: %s\n", defaultChain.ChainID())
 	fmt.Printf("Port from environment: %s\n", os.Getenv("PORT"))
 	fmt.Printf("Endpoints available:\n")
 	fmt.Printf("  - Health: %s/health\n", bindAddr)
 	fmt.Printf("  - Status: %s/status\n", bindAddr)
 	fmt.Printf("  - DIDs: %s/persona/did/v1beta1/did_documents\n", bindAddr)
+	fmt.Printf("  - API docs: %s/docs\n", bindAddr)
+	
+	// gRPC transport alongside REST, when GRPC_PORT is set
+	if grpcPort := os.Getenv("GRPC_PORT"); grpcPort != "" {
+		if err := defaultChain.startGRPCServer(grpcPort); err != nil {
+			log.Fatalf("gRPC server failed to start: %v", err)
+		}
+		fmt.Printf("  - gRPC: 0.0.0.0:%s\n", grpcPort)
+	}
 	
 	fmt.Printf("Starting HTTP server on %s\n", bindAddr)
 	fmt.Printf("Server ready to accept connections\n")
 	
 	server := &http.Server{
-		Addr:    bindAddr,
-		Handler: r,
+		Addr:      bindAddr,
+		Handler:   r,
+		ConnState: trackConnState,
 	}
 	
 	if err := server.ListenAndServe(); err != nil {
@@ -140,8 +429,9 @@ func corsMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// Allow requests from any origin (for development)
 		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Accept, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Accept, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, X-Admin-Token, X-Client-Region, X-Test-Client-IP, X-Test-Country, X-Persona-Session, X-API-Key, X-JSON-Casing, X-Request-ID, traceparent, X-Persona-RP, X-Admin-Actor, X-Test-Run")
+		w.Header().Set("Access-Control-Expose-Headers", "Retry-After, WWW-Authenticate, X-RateLimit-Limit, X-RateLimit-Remaining, X-RateLimit-Reset, X-Quota-Kind, X-Quota-Limit, X-Quota-Remaining, X-Quota-Reset, X-JSON-Casing, X-Request-ID, X-Persona-RP")
 		
 		// Handle preflight requests
 		if r.Method == "OPTIONS" {
@@ -153,413 +443,24 @@ func corsMiddleware(next http.Handler) http.Handler {
 	})
 }
 
-func handleBroadcastTx(w http.ResponseWriter, r *http.Request) {
-	// Read the request body to extract DID information
-	body, err := io.ReadAll(r.Body)
-	if err == nil {
-		var txData map[string]interface{}
-		if json.Unmarshal(body, &txData) == nil {
-			// Check if this is a DID creation transaction
-			var msgs []interface{}
-			// Handle both direct msgs format and nested tx.body.messages format
-			if directMsgs, ok := txData["msgs"].([]interface{}); ok {
-				msgs = directMsgs
-			} else if tx, ok := txData["tx"].(map[string]interface{}); ok {
-				if body, ok := tx["body"].(map[string]interface{}); ok {
-					if nestedMsgs, ok := body["messages"].([]interface{}); ok {
-						msgs = nestedMsgs
-					}
-				}
-			}
-			
-			if len(msgs) > 0 {
-				if msg, ok := msgs[0].(map[string]interface{}); ok {
-					if msgType, ok := msg["@type"].(string); ok {
-						switch msgType {
-						case "/persona.did.v1.MsgCreateDid":
-							// Extract DID information and store it
-							var didDoc map[string]interface{}
-							
-							// Handle both string and object formats for did_document
-							if didDocStr, ok := msg["did_document"].(string); ok {
-								// Parse JSON string
-								if json.Unmarshal([]byte(didDocStr), &didDoc) != nil {
-									log.Printf("Failed to parse DID document JSON: %s", didDocStr)
-									break
-								}
-							} else if didDocObj, ok := msg["did_document"].(map[string]interface{}); ok {
-								// Already an object
-								didDoc = didDocObj
-							} else {
-								log.Printf("DID document not found or invalid format")
-								break
-							}
-							
-							if didId, ok := didDoc["id"].(string); ok {
-								if controller, ok := didDoc["controller"].(string); ok {
-									// Store the DID
-									createdDIDs[didId] = map[string]interface{}{
-										"id":         didId,
-										"controller": controller,
-										"created_at": time.Now().Unix(),
-										"updated_at": time.Now().Unix(),
-										"is_active":  true,
-									}
-									// Map controller to DID for easy lookup
-									walletToDID[controller] = didId
-									log.Printf("Stored DID: %s for controller: %s", didId, controller)
-								}
-							}
-						
-						case "/persona.vc.v1.MsgIssueCredential":
-							// Extract credential information and store it
-							if creator, ok := msg["creator"].(string); ok {
-								if vcData, ok := msg["vc_data"].(string); ok {
-									// Parse the credential data
-									var credential map[string]interface{}
-									if json.Unmarshal([]byte(vcData), &credential) == nil {
-										// Add metadata
-										credential["created_at"] = time.Now().Unix()
-										credential["is_revoked"] = false
-										
-										// Store credential by controller
-										if credentialsByController[creator] == nil {
-											credentialsByController[creator] = []map[string]interface{}{}
-										}
-										credentialsByController[creator] = append(credentialsByController[creator], credential)
-										log.Printf("Stored credential for controller: %s", creator)
-									}
-								}
-							}
-						
-						case "/persona.zk.v1.MsgSubmitProof":
-							// Extract proof information and store it
-							var prover string
-							var proofData string
-							
-							// Handle field name variations
-							if creator, ok := msg["creator"].(string); ok {
-								prover = creator
-							} else if p, ok := msg["prover"].(string); ok {
-								prover = p
-							}
-							
-							if proof, ok := msg["proof"].(string); ok {
-								proofData = proof
-							} else if pd, ok := msg["proof_data"].(string); ok {
-								proofData = pd
-							}
-							
-							if circuitId, ok := msg["circuit_id"].(string); ok && prover != "" && proofData != "" {
-								// Create proof record
-								proof := map[string]interface{}{
-									"id":          fmt.Sprintf("proof_%d", time.Now().Unix()),
-									"circuit_id":  circuitId,
-									"prover":      prover,
-									"proof_data":  proofData,
-									"public_inputs": msg["public_inputs"],
-									"metadata":    msg["metadata"],
-									"is_verified": true, // Mock verification
-									"created_at":  time.Now().Unix(),
-								}
-								
-								// Store proof by controller
-								if proofsByController[prover] == nil {
-									proofsByController[prover] = []map[string]interface{}{}
-								}
-								proofsByController[prover] = append(proofsByController[prover], proof)
-								log.Printf("Stored proof for controller: %s", prover)
-							} else {
-								log.Printf("Missing required proof fields: prover=%s, proof_data=%s, circuit_id=%s", prover, proofData, circuitId)
-							}
-						}
-					}
-				}
-			}
-		}
-	}
-	
-	// Mock successful transaction
-	response := MockTxResponse{
-		TxHash: fmt.Sprintf("0x%064d", time.Now().Unix()),
-		Height: chainInfo.LatestHeight,
-		Code:   0, // Success
-		Data:   "",
-	}
-	
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
-}
-
-func handleAccountBalance(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	_ = vars["address"] // Mock - we return the same balance for any address
-	
-	// Return mock balance
-	response := map[string]interface{}{
-		"balances": []map[string]string{
-			{"denom": "uprsn", "amount": "1000000000"},
-		},
-		"pagination": map[string]interface{}{
-			"next_key": nil,
-			"total":    "1",
-		},
-	}
-	
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
-}
-
-func handleListDIDs(w http.ResponseWriter, r *http.Request) {
-	// Start with the default mock DIDs
-	mockDIDs := []map[string]interface{}{
-		{
-			"id":           "did:persona:123",
-			"controller":   "cosmos1test1",
-			"created_at":   time.Now().Unix(),
-			"updated_at":   time.Now().Unix(),
-			"is_active":    true,
-		},
-		{
-			"id":           "did:persona:456",
-			"controller":   "cosmos1test2",
-			"created_at":   time.Now().Unix(),
-			"updated_at":   time.Now().Unix(),
-			"is_active":    true,
-		},
-	}
-	
-	// Add any created DIDs
-	for _, did := range createdDIDs {
-		mockDIDs = append(mockDIDs, did)
-	}
-	
-	response := map[string]interface{}{
-		"did_documents": mockDIDs,
-		"pagination": map[string]interface{}{
-			"next_key": nil,
-			"total":    fmt.Sprintf("%d", len(mockDIDs)),
-		},
-	}
-	
-	log.Printf("Returning %d DIDs (including %d created)", len(mockDIDs), len(createdDIDs))
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
-}
-
-func handleGetDID(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	id := vars["id"]
-	
-	// Check if it's a created DID first
-	if did, exists := createdDIDs[id]; exists {
-		response := map[string]interface{}{
-			"did_document": did,
-		}
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(response)
-		return
-	}
-	
-	// Fallback to mock DID
-	mockDID := map[string]interface{}{
-		"did_document": map[string]interface{}{
-			"id":         id,
-			"controller": "cosmos1test1",
-			"created_at": time.Now().Unix(),
-			"updated_at": time.Now().Unix(),
-			"is_active":  true,
-		},
-	}
-	
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(mockDID)
-}
-
-func handleGetDIDByController(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	controller := vars["controller"]
-	
-	log.Printf("Looking up DID for controller: %s", controller)
-	
-	// Check if this controller has a DID
-	if didId, exists := walletToDID[controller]; exists {
-		if did, didExists := createdDIDs[didId]; didExists {
-			response := map[string]interface{}{
-				"did_document": did,
-			}
-			log.Printf("Found DID for controller %s: %s", controller, didId)
-			w.Header().Set("Content-Type", "application/json")
-			json.NewEncoder(w).Encode(response)
-			return
-		}
-	}
-	
-	// No DID found for this controller
-	log.Printf("No DID found for controller: %s", controller)
-	response := map[string]interface{}{
-		"did_document": nil,
-	}
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
-}
-
-func handleListProofs(w http.ResponseWriter, r *http.Request) {
-	mockProofs := []map[string]interface{}{
-		{
-			"id":          "proof_001",
-			"circuit_id":  "circuit_001",
-			"prover":      "cosmos1test1",
-			"is_verified": true,
-			"created_at":  time.Now().Unix(),
-		},
-	}
-	
-	response := map[string]interface{}{
-		"zk_proofs": mockProofs,
-		"pagination": map[string]interface{}{
-			"next_key": nil,
-			"total":    fmt.Sprintf("%d", len(mockProofs)),
-		},
-	}
-	
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
-}
-
-func handleListCircuits(w http.ResponseWriter, r *http.Request) {
-	mockCircuits := []map[string]interface{}{
-		{
-			"id":        "circuit_001",
-			"name":      "test_circuit",
-			"creator":   "cosmos1test1",
-			"is_active": true,
-			"created_at": time.Now().Unix(),
-		},
-	}
-	
-	response := map[string]interface{}{
-		"circuits": mockCircuits,
-		"pagination": map[string]interface{}{
-			"next_key": nil,
-			"total":    fmt.Sprintf("%d", len(mockCircuits)),
-		},
-	}
-	
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
-}
-
-func handleListVCs(w http.ResponseWriter, r *http.Request) {
-	mockVCs := []map[string]interface{}{
-		{
-			"id":          "vc_001",
-			"issuer_did":  "did:persona:123",
-			"subject_did": "did:persona:456",
-			"issued_at":   time.Now().Unix(),
-			"is_revoked":  false,
-		},
-	}
-	
-	response := map[string]interface{}{
-		"vc_records": mockVCs,
-		"pagination": map[string]interface{}{
-			"next_key": nil,
-			"total":    fmt.Sprintf("%d", len(mockVCs)),
-		},
-	}
-	
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
-}
-
-func handleGetCredentialsByController(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	controller := vars["controller"]
-	
-	log.Printf("Looking up credentials for controller: %s", controller)
-	
-	// Get credentials for this controller
-	credentials, exists := credentialsByController[controller]
-	if !exists {
-		credentials = []map[string]interface{}{}
-	}
-	
-	response := map[string]interface{}{
-		"vc_records": credentials,
-		"pagination": map[string]interface{}{
-			"next_key": nil,
-			"total":    fmt.Sprintf("%d", len(credentials)),
-		},
-	}
-	
-	log.Printf("Returning %d credentials for controller %s", len(credentials), controller)
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
-}
-
-func handleGetProofsByController(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	controller := vars["controller"]
-	
-	log.Printf("Looking up proofs for controller: %s", controller)
-	
-	// Get proofs for this controller
-	proofs, exists := proofsByController[controller]
-	if !exists {
-		proofs = []map[string]interface{}{}
-	}
-	
-	response := map[string]interface{}{
-		"zk_proofs": proofs,
-		"pagination": map[string]interface{}{
-			"next_key": nil,
-			"total":    fmt.Sprintf("%d", len(proofs)),
-		},
-	}
-	
-	log.Printf("Returning %d proofs for controller %s", len(proofs), controller)
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
-}
-
-func handleStatus(w http.ResponseWriter, r *http.Request) {
-	// Update height to simulate progression
-	chainInfo.LatestHeight++
-	chainInfo.LatestTime = time.Now().Format(time.RFC3339)
-	
-	response := map[string]interface{}{
-		"jsonrpc": "2.0",
-		"id":      1,
-		"result": map[string]interface{}{
-			"node_info": chainInfo.NodeInfo,
-			"sync_info": map[string]interface{}{
-				"latest_block_hash":   "0x" + fmt.Sprintf("%064d", chainInfo.LatestHeight),
-				"latest_block_height": fmt.Sprintf("%d", chainInfo.LatestHeight),
-				"latest_block_time":   chainInfo.LatestTime,
-				"catching_up":         false,
-			},
-		},
-	}
-	
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
-}
-
-func handleNodeInfo(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(chainInfo.NodeInfo)
-}
-
 func handleHealth(w http.ResponseWriter, r *http.Request) {
 	response := map[string]interface{}{
 		"status":    "healthy",
-		"chain_id":  chainInfo.ChainID,
-		"height":    chainInfo.LatestHeight,
-		"timestamp": time.Now().Unix(),
+		"chain_id":  defaultChain.ChainID(),
+		"height":    defaultChain.latestHeight(),
+		"timestamp": appClock.Now().Unix(),
+	}
+	
+	// Contract drift fails health, so CI stops before the E2E run
+	status := http.StatusOK
+	if drift := defaultChain.contractDrift(); drift.Drifted {
+		response["status"] = "contract_drift"
+		response["contract"] = map[string]interface{}{"breaking": drift.Breaking, "additive": drift.Additive, "error": drift.Error, "details": "/api/drift"}
+		status = http.StatusServiceUnavailable
 	}
 	
 	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
 	json.NewEncoder(w).Encode(response)
 }
 
@@ -612,7 +513,7 @@ func handleGetRequirements(w http.ResponseWriter, r *http.Request) {
 		"requirements": requirements,
 		"did":         did,
 		"useCase":     useCase,
-		"timestamp":   time.Now().Unix(),
+		"timestamp":   appClock.Now().Unix(),
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -632,14 +533,11 @@ func handleGetVc(w http.ResponseWriter, r *http.Request) {
 
 	log.Printf("Getting VC for DID: %s, TemplateID: %s", did, templateId)
 
+	defaultChain.mu.RLock()
+	defer defaultChain.mu.RUnlock()
+	
 	// Look up controller from DID
-	var controller string
-	for ctrl, didId := range walletToDID {
-		if didId == did {
-			controller = ctrl
-			break
-		}
-	}
+	controller := defaultChain.did().controllerOf(did)
 
 	if controller == "" {
 		// Return 404 if DID not found
@@ -654,7 +552,7 @@ func handleGetVc(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Look up credentials for this controller
-	credentials, exists := credentialsByController[controller]
+	credentials, exists := defaultChain.vc().store.ByController[controller]
 	if !exists || len(credentials) == 0 {
 		response := map[string]interface{}{
 			"error": "No credentials found for this DID",
@@ -666,23 +564,50 @@ func handleGetVc(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Find credential matching the template
-	var matchingCredential map[string]interface{}
+	// Find credential matching the template, preferring one that has not
+	// expired
+	var matchingCredential, expiredCredential map[string]interface{}
+	now := defaultChain.now()
 	for _, cred := range credentials {
+		matches := false
 		// Check if credential matches the template ID
 		if credSubject, ok := cred["credentialSubject"].(map[string]interface{}); ok {
 			if credTemplateId, ok := credSubject["templateId"].(string); ok && credTemplateId == templateId {
-				matchingCredential = cred
-				break
+				matches = true
 			}
 		}
 		// Fallback: check credential type
 		if credType, ok := cred["credentialSubject"].(map[string]interface{}); ok {
 			if credTypeStr, ok := credType["credentialType"].(string); ok && credTypeStr == templateId {
-				matchingCredential = cred
-				break
+				matches = true
 			}
 		}
+		if !matches {
+			continue
+		}
+		if !credentialExpired(cred, now) {
+			matchingCredential = cred
+			break
+		}
+		if expiredCredential == nil {
+			expiredCredential = cred
+		}
+	}
+	
+	// No proof is built from an expired credential
+	if matchingCredential == nil && expiredCredential != nil {
+		response := map[string]interface{}{
+			"error":          "Credential has expired",
+			"code":           "credential_expired",
+			"did":            did,
+			"templateId":     templateId,
+			"credentialId":   expiredCredential["id"],
+			"expirationDate": expiredCredential["expirationDate"],
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusGone)
+		json.NewEncoder(w).Encode(response)
+		return
 	}
 
 	if matchingCredential == nil {
@@ -700,7 +625,7 @@ func handleGetVc(w http.ResponseWriter, r *http.Request) {
 	// Create mock proof data
 	proofData := map[string]interface{}{
 		"type":       "ZKProof",
-		"created":    time.Now().Format(time.RFC3339),
+		"created":    appClock.Now().Format(time.RFC3339),
 		"verified":   true,
 		"templateId": templateId,
 	}
@@ -708,7 +633,7 @@ func handleGetVc(w http.ResponseWriter, r *http.Request) {
 	publicInputs := map[string]interface{}{
 		"templateId": templateId,
 		"did":       did,
-		"timestamp": time.Now().Unix(),
+		"timestamp": appClock.Now().Unix(),
 	}
 
 	metadata := map[string]interface{}{
@@ -716,6 +641,9 @@ func handleGetVc(w http.ResponseWriter, r *http.Request) {
 		"issuanceDate": matchingCredential["issuanceDate"],
 		"templateId":   templateId,
 	}
+	if expirationDate, ok := matchingCredential["expirationDate"]; ok {
+		metadata["expirationDate"] = expirationDate
+	}
 
 	response := map[string]interface{}{
 		"proof":        proofData,
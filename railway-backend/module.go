@@ -0,0 +1,51 @@
+package main
+
+import (
+	"github.com/gorilla/mux"
+)
+
+// Module is a persona chain module (did, vc, zk, ...) plugged into a Chain.
+// Every chain builds its own instance of each registered module, so module
+// state is always scoped to the chain that owns it.
+type Module interface {
+	// Name is the module's store key and genesis app_state key.
+	Name() string
+	// RegisterRoutes mounts the module's REST query routes.
+	RegisterRoutes(r *mux.Router)
+	// RegisterMsgs registers the message types the module handles.
+	RegisterMsgs(reg *MsgRegistry)
+	// EventTypes lists the event types the module emits.
+	EventTypes() []string
+	// Store returns the module's state. It must be JSON-serializable.
+	Store() ModuleStore
+}
+
+// ModuleStore is the state schema owned by a module.
+type ModuleStore interface {
+	// Reset wipes the store back to empty.
+	Reset()
+}
+
+// GenesisExporter is implemented by modules that contribute to the genesis
+// app_state export.
+type GenesisExporter interface {
+	ExportGenesis() interface{}
+}
+
+// moduleFactories builds the modules for a new chain, in registration order.
+var moduleFactories []func(c *Chain) Module
+
+// RegisterModule adds a module to every chain created afterwards. Modules
+// call it from an init function in their own file, so adding a module does
+// not require touching the core server.
+func RegisterModule(factory func(c *Chain) Module) {
+	moduleFactories = append(moduleFactories, factory)
+}
+
+// Seeder is implemented by modules that accept records from POST /admin/seed.
+type Seeder interface {
+	// SeedKeys are the seed payload fields holding the module's records.
+	SeedKeys() []string
+	// Seed stores the records of one key. Called with the chain lock held.
+	Seed(key string, records []map[string]interface{}) error
+}
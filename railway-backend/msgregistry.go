@@ -0,0 +1,183 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"reflect"
+	"sort"
+)
+
+// Message type registry. Each @type URL maps to a typed decoder, a
+// stateless validator and a handler, so supporting a new message is a
+// single registerMsg call instead of another branch in handleBroadcastTx.
+
+// Msg is implemented by every typed message.
+type Msg interface {
+	// ValidateBasic performs stateless checks before the handler runs.
+	ValidateBasic() error
+}
+
+// msgContext carries tx-level information to message handlers.
+type msgContext struct {
+	TxHash   string
+	Height   int64
+	MsgIndex int
+	// Activity collects the identity activity events to publish once the
+	// tx commits
+	Activity []activityEvent
+	// Events collects the tx's ABCI events (txevents.go)
+	Events []abciEvent
+	// Residency is the region records created by the tx reside in, or ""
+	// (residency.go)
+	Residency string
+	// TraceID is the trace of the broadcast request (trace.go)
+	TraceID string
+	// Proofs holds the Groth16 results verified before the chain lock was
+	// taken, by message index (zk.go)
+	Proofs map[int]error
+}
+
+type registeredMsg struct {
+	typeURL string
+	goType  reflect.Type
+	decode  func(raw map[string]interface{}) (Msg, error)
+	handle  func(ctx *msgContext, msg Msg) *txError
+}
+
+// MsgRegistry maps @type URLs to registered message types.
+type MsgRegistry struct {
+	types map[string]*registeredMsg
+}
+
+func NewMsgRegistry() *MsgRegistry {
+	return &MsgRegistry{types: make(map[string]*registeredMsg)}
+}
+
+// registerMsg registers message type T under typeURL. The raw JSON message
+// is decoded into a T, validated, and passed to handler. A handler must
+// return its error before writing to a store, see deliverMsgs.
+func registerMsg[T Msg](reg *MsgRegistry, typeURL string, handler func(ctx *msgContext, msg T) *txError) {
+	if _, exists := reg.types[typeURL]; exists {
+		panic("duplicate message type registration: " + typeURL)
+	}
+	reg.types[typeURL] = &registeredMsg{
+		typeURL: typeURL,
+		goType:  reflect.TypeOf((*T)(nil)).Elem(),
+		decode: func(raw map[string]interface{}) (Msg, error) {
+			data, err := json.Marshal(raw)
+			if err != nil {
+				return nil, err
+			}
+			var msg T
+			if err := json.Unmarshal(data, &msg); err != nil {
+				return nil, err
+			}
+			return msg, nil
+		},
+		handle: func(ctx *msgContext, msg Msg) *txError {
+			return handler(ctx, msg.(T))
+		},
+	}
+}
+
+// TypeURLs lists the registered message types.
+func (reg *MsgRegistry) TypeURLs() []string {
+	urls := make([]string, 0, len(reg.types))
+	for url := range reg.types {
+		urls = append(urls, url)
+	}
+	sort.Strings(urls)
+	return urls
+}
+
+// GoType returns the Go type registered for typeURL, or nil.
+func (reg *MsgRegistry) GoType(typeURL string) reflect.Type {
+	if entry, ok := reg.types[typeURL]; ok {
+		return entry.goType
+	}
+	return nil
+}
+
+type decodedMsg struct {
+	entry *registeredMsg
+	msg   Msg
+}
+
+// Deliver decodes and validates every message, then executes them in order.
+// Messages with unregistered types are logged and skipped. It returns the
+// first failure with the index of the message that caused it.
+func (reg *MsgRegistry) Deliver(ctx *msgContext, rawMsgs []interface{}) (int, *txError) {
+	decoded := make([]decodedMsg, len(rawMsgs))
+	for i, rawMsg := range rawMsgs {
+		raw, ok := rawMsg.(map[string]interface{})
+		if !ok {
+			return i, txErrorf(codeTxDecode, "message is not an object")
+		}
+		typeURL, _ := raw["@type"].(string)
+		entry, ok := reg.types[typeURL]
+		if !ok {
+			log.Printf("Ignoring unregistered message type %q", typeURL)
+			continue
+		}
+		msg, err := entry.decode(raw)
+		if err != nil {
+			return i, txErrorf(codeTxDecode, "failed to decode %s: %v", typeURL, err)
+		}
+		if err := msg.ValidateBasic(); err != nil {
+			return i, txErrorf(codeInvalidRequest, "%s", err.Error())
+		}
+		decoded[i] = decodedMsg{entry: entry, msg: msg}
+	}
+
+	for i, d := range decoded {
+		if d.entry == nil {
+			continue
+		}
+		ctx.MsgIndex = i
+		raw := rawMsgs[i].(map[string]interface{})
+		ctx.event("message", "action", d.entry.typeURL, "sender", msgSigner(raw), "module", msgModule(d.entry.typeURL))
+		if txErr := d.entry.handle(ctx, d.msg); txErr != nil {
+			return i, txErr
+		}
+	}
+	return 0, nil
+}
+
+// deliverMsgs runs a tx's messages atomically: when one fails, the module
+// stores are rolled back to before the first, so a failed tx leaves no
+// state behind. The fee and sequence charged by the ante handler stay
+// spent, as on a real chain. Handlers check everything before they write,
+// so a single message has nothing to roll back and skips copying the
+// stores. Must be called with c.mu held.
+func (c *Chain) deliverMsgs(ctx *msgContext, rawMsgs []interface{}) (int, *txError) {
+	if len(rawMsgs) <= 1 {
+		return c.msgs.Deliver(ctx, rawMsgs)
+	}
+	backup := c.backupStoresLocked()
+	msgIndex, txErr := c.msgs.Deliver(ctx, rawMsgs)
+	if txErr != nil {
+		c.restoreStoresLocked(backup)
+	}
+	return msgIndex, txErr
+}
+
+// jsonObject accepts either a JSON object or a string containing one, since
+// the frontend sends documents both ways.
+type jsonObject map[string]interface{}
+
+func (o *jsonObject) UnmarshalJSON(data []byte) error {
+	var s string
+	if json.Unmarshal(data, &s) == nil {
+		if s == "" {
+			return nil
+		}
+		data = []byte(s)
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return fmt.Errorf("expected a JSON object: %v", err)
+	}
+	*o = m
+	return nil
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// Multiple simulated networks in one process, so the frontend's network
+// switcher has more than one real target. NETWORKS lists the extra chains
+// as comma-separated chain_id[:denom[:block_time]] entries, e.g.
+//
+//	NETWORKS=persona-devnet-1:udev:1s,persona-mainnet-sim:uprsn:6s
+//
+// Each network is a full chain with its own state, block producer and
+// modules, served under /networks/{chain_id}/ (for example
+// /networks/persona-devnet-1/cosmos/tx/v1beta1/txs). The default network
+// keeps the unprefixed routes and is also mounted under its prefix. GET
+// /api/networks lists them for discovery. Admin routes, fixtures and the
+// server-level APIs under /api stay with the default network.
+
+type network struct {
+	chain     *Chain
+	isDefault bool
+}
+
+// networks is the registry, default network first. Set up in main.
+var networks []*network
+
+// networkPrefix returns the route prefix of a network's chain.
+func networkPrefix(chainID string) string {
+	return "/networks/" + chainID
+}
+
+// parseNetworks parses a NETWORKS value into chain configs.
+func parseNetworks(value string, defaults ChainConfig) ([]ChainConfig, error) {
+	configs := []ChainConfig{}
+	seen := map[string]bool{defaults.ChainID: true}
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		fields := strings.Split(entry, ":")
+		if len(fields) > 3 || fields[0] == "" {
+			return nil, fmt.Errorf("invalid network %q, expected chain_id[:denom[:block_time]]", entry)
+		}
+		if seen[fields[0]] {
+			return nil, fmt.Errorf("duplicate network %s", fields[0])
+		}
+		seen[fields[0]] = true
+		cfg := ChainConfig{
+			ChainID:       fields[0],
+			InitialHeight: 1,
+			BlockTime:     defaults.BlockTime,
+			Clock:         defaults.Clock,
+			NodeInfo: NodeInfo{
+				ID:      "mock-node-" + fields[0],
+				Moniker: fields[0] + "-node",
+				Version: defaults.NodeInfo.Version,
+			},
+		}
+		if len(fields) > 1 {
+			cfg.Denom = fields[1]
+		}
+		if len(fields) > 2 {
+			d, err := time.ParseDuration(fields[2])
+			if err != nil || d <= 0 {
+				return nil, fmt.Errorf("invalid block time %q for network %s", fields[2], fields[0])
+			}
+			cfg.BlockTime = d
+		}
+		configs = append(configs, cfg)
+	}
+	return configs, nil
+}
+
+// setupNetworks creates the NETWORKS chains next to the default one and
+// starts their block producers.
+func setupNetworks(defaults ChainConfig) {
+	networks = []*network{{chain: defaultChain, isDefault: true}}
+	configs, err := parseNetworks(os.Getenv("NETWORKS"), defaults)
+	if err != nil {
+		log.Fatalf("NETWORKS: %v", err)
+	}
+	for _, cfg := range configs {
+		chain := NewChain(cfg)
+		chain.startBlockProducer()
+		networks = append(networks, &network{chain: chain})
+		log.Printf("Network %s (%s, block time %s) at %s/", cfg.ChainID, chain.denom, cfg.BlockTime, networkPrefix(cfg.ChainID))
+	}
+}
+
+// findNetwork returns the network with the given chain ID, or nil.
+func findNetwork(chainID string) *network {
+	for _, n := range networks {
+		if n.chain.ChainID() == chainID {
+			return n
+		}
+	}
+	return nil
+}
+
+// registerNetworkRoutes mounts every network's chain routes under its
+// prefix.
+func registerNetworkRoutes(r *mux.Router) {
+	for _, n := range networks {
+		n.chain.RegisterRoutes(r.PathPrefix(networkPrefix(n.chain.ChainID())).Subrouter())
+	}
+}
+
+// Handler for GET /api/networks
+func handleListNetworks(w http.ResponseWriter, r *http.Request) {
+	baseURL := requestBaseURL(r)
+	wsBase := "ws" + strings.TrimPrefix(baseURL, "http")
+	list := make([]map[string]interface{}, 0, len(networks))
+	for _, n := range networks {
+		c := n.chain
+		c.mu.RLock()
+		height := c.info.LatestHeight
+		c.mu.RUnlock()
+
+		prefix := networkPrefix(c.ChainID())
+		rest := baseURL + prefix
+		if n.isDefault {
+			rest = baseURL
+		}
+		list = append(list, map[string]interface{}{
+			"chain_id":      c.ChainID(),
+			"denom":         c.denom,
+			"block_time":    c.blockTime.String(),
+			"latest_height": height,
+			"default":       n.isDefault,
+			"prefix":        prefix,
+			"rest":          rest,
+			"rpc":           rest,
+			"websocket":     wsBase + strings.TrimPrefix(rest, baseURL) + "/websocket",
+			"node_info":     c.info.NodeInfo,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"networks": list})
+}
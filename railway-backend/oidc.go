@@ -0,0 +1,246 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"persona-backend/idgen"
+)
+
+// Mock OIDC issuer for bearer-token auth, so the frontend's Authorization
+// header plumbing and 401/403 handling can be tested without a real IdP.
+// POST /oauth/token implements the client_credentials grant (RFC 6749
+// 4.4): clients authenticate with HTTP Basic or client_id/client_secret
+// form fields and get an ES256 JWT access token carrying the granted
+// scopes, valid for OAUTH_TOKEN_TTL (1h) on the app clock. Discovery is at
+// GET /.well-known/openid-configuration and the signing key at GET
+// /oauth/jwks.
+//
+// Protected routes (apiKeyProtected) accept such a token as
+// "Authorization: Bearer <jwt>" next to API keys, and answer 401 with
+// error="invalid_token" for bad or expired tokens and 403 with
+// error="insufficient_scope" when the scope is missing. With
+// OAUTH_REQUIRED=true a protected route needs a token or key.
+//
+// Clients come from OAUTH_CLIENTS (a JSON array of {client_id,
+// client_secret, scopes}), defaulting to persona-frontend / persona-secret
+// with every scope.
+
+const oidcKeyID = "persona-oidc-1"
+
+type oauthClient struct {
+	ClientID     string   `json:"client_id"`
+	ClientSecret string   `json:"client_secret,omitempty"`
+	Scopes       []string `json:"scopes"`
+	// Tokens issued and protected requests made since the last reset
+	TokensIssued int `json:"tokens_issued"`
+	Requests     int `json:"requests"`
+}
+
+var defaultOAuthClients = []oauthClient{
+	{ClientID: "persona-frontend", ClientSecret: "persona-secret", Scopes: []string{"*"}},
+}
+
+var (
+	oidcKey        = mustGenerateSDJWTKey()
+	oauthRequired  = newFeatureFlag("oauth_required", "OAUTH_REQUIRED", "Require a bearer token or API key on protected routes")
+	oauthTokenTTL  = durationFromEnv("OAUTH_TOKEN_TTL", time.Hour)
+	oauthAudience  = "persona-api"
+	startupClients = defaultOAuthClients
+
+	oauthMu      sync.Mutex
+	oauthClients = make(map[string]*oauthClient)
+)
+
+func init() {
+	if audience := os.Getenv("OAUTH_AUDIENCE"); audience != "" {
+		oauthAudience = audience
+	}
+	if raw := os.Getenv("OAUTH_CLIENTS"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &startupClients); err != nil {
+			log.Printf("Invalid OAUTH_CLIENTS: %v", err)
+			startupClients = defaultOAuthClients
+		}
+	}
+	resetOAuthClients()
+	registerAdminState("oauth", func() interface{} {
+		oauthMu.Lock()
+		defer oauthMu.Unlock()
+		clients := []oauthClient{}
+		for _, id := range sortedMapKeys(oauthClients) {
+			client := *oauthClients[id]
+			client.ClientSecret = ""
+			clients = append(clients, client)
+		}
+		return map[string]interface{}{"required": oauthRequired.Enabled(), "clients": clients}
+	}, resetOAuthClients)
+}
+
+func resetOAuthClients() {
+	oauthMu.Lock()
+	defer oauthMu.Unlock()
+	oauthClients = make(map[string]*oauthClient)
+	for _, c := range startupClients {
+		if c.ClientID == "" || c.ClientSecret == "" {
+			log.Printf("Skipping OAuth client %q without an id or secret", c.ClientID)
+			continue
+		}
+		client := c
+		client.TokensIssued, client.Requests = 0, 0
+		oauthClients[client.ClientID] = &client
+	}
+}
+
+// oauthError writes an RFC 6749 5.2 error response.
+func oauthError(w http.ResponseWriter, status int, code, description string) {
+	if status == http.StatusUnauthorized {
+		w.Header().Set("WWW-Authenticate", `Basic realm="persona"`)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": code, "error_description": description})
+}
+
+// Handler for POST /oauth/token
+func handleOAuthToken(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		oauthError(w, http.StatusBadRequest, "invalid_request", "body must be application/x-www-form-urlencoded")
+		return
+	}
+	if grant := r.PostForm.Get("grant_type"); grant != "client_credentials" {
+		oauthError(w, http.StatusBadRequest, "unsupported_grant_type", fmt.Sprintf("grant_type %q is not supported, use client_credentials", grant))
+		return
+	}
+	clientID, secret, basic := r.BasicAuth()
+	if !basic {
+		clientID, secret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+
+	oauthMu.Lock()
+	defer oauthMu.Unlock()
+	client := oauthClients[clientID]
+	if client == nil || subtle.ConstantTimeCompare([]byte(secret), []byte(client.ClientSecret)) != 1 {
+		oauthError(w, http.StatusUnauthorized, "invalid_client", "unknown client or wrong secret")
+		return
+	}
+	// No scope parameter grants everything the client may have
+	granted := client.Scopes
+	if containsString(granted, "*") {
+		granted = apiKeyScopes
+	}
+	if requested := strings.Fields(r.PostForm.Get("scope")); len(requested) > 0 {
+		for _, scope := range requested {
+			if !containsString(apiKeyScopes, scope) || !(containsString(client.Scopes, "*") || containsString(client.Scopes, scope)) {
+				oauthError(w, http.StatusBadRequest, "invalid_scope", "scope "+scope+" is not allowed for "+clientID)
+				return
+			}
+		}
+		granted = requested
+	}
+
+	now := appClock.Now()
+	token, err := signES256(oidcKey, map[string]interface{}{"alg": "ES256", "typ": "at+jwt", "kid": oidcKeyID}, map[string]interface{}{
+		"iss":       requestBaseURL(r),
+		"sub":       clientID,
+		"aud":       oauthAudience,
+		"client_id": clientID,
+		"scope":     strings.Join(granted, " "),
+		"iat":       now.Unix(),
+		"exp":       now.Add(oauthTokenTTL).Unix(),
+		"jti":       idgen.NewWithPrefix("token"),
+	})
+	if err != nil {
+		oauthError(w, http.StatusInternalServerError, "server_error", err.Error())
+		return
+	}
+	client.TokensIssued++
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"access_token": token,
+		"token_type":   "Bearer",
+		"expires_in":   int64(oauthTokenTTL.Seconds()),
+		"scope":        strings.Join(granted, " "),
+	})
+}
+
+// bearerToken returns the token of an Authorization: Bearer header, or "".
+func bearerToken(r *http.Request) string {
+	auth := r.Header.Get("Authorization")
+	if len(auth) > 7 && strings.EqualFold(auth[:7], "Bearer ") {
+		return strings.TrimSpace(auth[7:])
+	}
+	return ""
+}
+
+// bearerDenied writes an RFC 6750 error and returns true unless the token
+// is valid and carries the scope.
+func bearerDenied(w http.ResponseWriter, token, scope string) bool {
+	deny := func(status int, code, description string) bool {
+		w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="persona", error=%q, error_description=%q, scope=%q`, code, description, scope))
+		grpcCode := 16
+		if status == http.StatusForbidden {
+			grpcCode = 7
+		}
+		writeGRPCError(w, status, grpcCode, description)
+		return true
+	}
+	_, claims, err := parseES256(token, &oidcKey.PublicKey)
+	if err != nil {
+		return deny(http.StatusUnauthorized, "invalid_token", "token "+err.Error())
+	}
+	if exp, _ := claims["exp"].(float64); int64(exp) <= appClock.Now().Unix() {
+		return deny(http.StatusUnauthorized, "invalid_token", "token expired")
+	}
+	if claims["aud"] != oauthAudience {
+		return deny(http.StatusUnauthorized, "invalid_token", fmt.Sprintf("token audience %v is not %s", claims["aud"], oauthAudience))
+	}
+	scopes, _ := claims["scope"].(string)
+	if !containsString(strings.Fields(scopes), scope) {
+		return deny(http.StatusForbidden, "insufficient_scope", "token lacks scope "+scope)
+	}
+
+	clientID, _ := claims["client_id"].(string)
+	oauthMu.Lock()
+	if client := oauthClients[clientID]; client != nil {
+		client.Requests++
+	}
+	oauthMu.Unlock()
+	return false
+}
+
+// Handler for GET /.well-known/openid-configuration
+func handleOpenIDConfiguration(w http.ResponseWriter, r *http.Request) {
+	base := requestBaseURL(r)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"issuer":                                base,
+		"token_endpoint":                        base + "/oauth/token",
+		"jwks_uri":                              base + "/oauth/jwks",
+		"grant_types_supported":                 []string{"client_credentials"},
+		"response_types_supported":              []string{"token"},
+		"scopes_supported":                      apiKeyScopes,
+		"token_endpoint_auth_methods_supported": []string{"client_secret_basic", "client_secret_post"},
+		"id_token_signing_alg_values_supported": []string{"ES256"},
+		"subject_types_supported":               []string{"public"},
+	})
+}
+
+// Handler for GET /oauth/jwks
+func handleOAuthKeys(w http.ResponseWriter, r *http.Request) {
+	jwk := publicJWK(&oidcKey.PublicKey)
+	jwk["kid"] = oidcKeyID
+	jwk["alg"] = "ES256"
+	jwk["use"] = "sig"
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"keys": []interface{}{jwk}})
+}
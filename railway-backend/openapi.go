@@ -0,0 +1,1663 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/gorilla/mux"
+)
+
+// OpenAPI 3.0 documentation, generated at request time from the router so
+// every registered endpoint is listed: paths, methods and path parameters
+// come from the routes, operation IDs and summaries from the handler names,
+// and component schemas are reflected from the Go types the handlers
+// encode, including every registered message type. openAPIOperations adds
+// query parameters and response shapes where a handler builds its response
+// by hand. Served at /openapi.json, with Swagger UI at /docs.
+
+// openAPIOperation documents what the router cannot tell.
+type openAPIOperation struct {
+	Summary     string
+	Description string
+	Paginated   bool
+	Query       []openAPIParam
+	Request     map[string]interface{}
+	Response    map[string]interface{}
+}
+
+type openAPIParam struct {
+	Name        string
+	Description string
+	Type        string
+}
+
+func ref(name string) map[string]interface{} {
+	return map[string]interface{}{"$ref": "#/components/schemas/" + name}
+}
+
+func arrayOf(items map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{"type": "array", "items": items}
+}
+
+func objectOf(properties map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{"type": "object", "properties": properties}
+}
+
+// stripeList is the Stripe list object wrapping the billing collections.
+func stripeList(name string) map[string]interface{} {
+	return objectOf(map[string]interface{}{
+		"object":   map[string]interface{}{"type": "string", "example": "list"},
+		"data":     arrayOf(ref(name)),
+		"has_more": map[string]interface{}{"type": "boolean"},
+	})
+}
+
+var anyObject = map[string]interface{}{"type": "object", "additionalProperties": true}
+
+var didResolutionResponse = objectOf(map[string]interface{}{
+	"did_document":            anyObject,
+	"did_resolution_metadata": anyObject,
+	"did_document_metadata":   anyObject,
+})
+
+// openAPISchemaTypes are the Go types published as component schemas.
+// didVersionParams are the DID Core version parameters of DID reads.
+var didVersionParams = []openAPIParam{
+	{Name: "versionId", Description: "Return this version of the document", Type: "string"},
+	{Name: "versionTime", Description: "Return the version current at this RFC 3339 time", Type: "string"},
+	crossRegionParam,
+}
+
+var analyticsPipelineResponse = objectOf(map[string]interface{}{
+	"steps":      arrayOf(ref("AnalyticsStep")),
+	"salted":     map[string]interface{}{"type": "boolean"},
+	"updated_at": map[string]interface{}{"type": "integer"},
+})
+
+var disputeResponse = objectOf(map[string]interface{}{"dispute": ref("Dispute")})
+
+var disputeResolutionRequest = objectOf(map[string]interface{}{
+	"resolver":   map[string]interface{}{"type": "string"},
+	"outcome":    map[string]interface{}{"type": "string", "enum": disputeOutcomes},
+	"resolution": map[string]interface{}{"type": "string"},
+})
+
+var rateLimitStateResponse = objectOf(map[string]interface{}{
+	"limits": objectOf(map[string]interface{}{
+		"ip":  ref("RateLimit"),
+		"key": ref("RateLimit"),
+	}),
+	"buckets":  map[string]interface{}{"type": "integer"},
+	"rejected": map[string]interface{}{"type": "object", "additionalProperties": map[string]interface{}{"type": "integer"}},
+})
+
+var quotaStateResponse = objectOf(map[string]interface{}{
+	"limits":    map[string]interface{}{"type": "object", "additionalProperties": map[string]interface{}{"type": "integer"}},
+	"overrides": map[string]interface{}{"type": "object", "additionalProperties": map[string]interface{}{"type": "object", "additionalProperties": map[string]interface{}{"type": "integer"}}},
+	"usage":     arrayOf(ref("QuotaUsage")),
+})
+
+var clockStateResponse = objectOf(map[string]interface{}{
+	"now":            map[string]interface{}{"type": "string", "format": "date-time"},
+	"unix":           map[string]interface{}{"type": "integer", "format": "int64"},
+	"mode":           map[string]interface{}{"type": "string", "enum": []string{"offset", "manual"}},
+	"offset":         map[string]interface{}{"type": "string"},
+	"offset_seconds": map[string]interface{}{"type": "integer", "format": "int64"},
+})
+
+var tenantView = objectOf(map[string]interface{}{
+	"id":            map[string]interface{}{"type": "string"},
+	"chain_id":      map[string]interface{}{"type": "string"},
+	"denom":         map[string]interface{}{"type": "string"},
+	"block_time":    map[string]interface{}{"type": "string"},
+	"latest_height": map[string]interface{}{"type": "integer", "format": "int64"},
+	"created_at":    map[string]interface{}{"type": "integer", "format": "int64"},
+	"prefix":        map[string]interface{}{"type": "string"},
+	"rest":          map[string]interface{}{"type": "string"},
+	"rpc":           map[string]interface{}{"type": "string"},
+	"websocket":     map[string]interface{}{"type": "string"},
+	"ttl":           map[string]interface{}{"type": "string"},
+})
+
+var credentialSearchParams = []openAPIParam{
+	{Name: "type", Description: "W3C type, or the credentialType or templateId subject field", Type: "string"},
+	{Name: "templateId", Description: "templateId subject field", Type: "string"},
+	{Name: "issuer_did", Description: "Issuer DID", Type: "string"},
+	{Name: "issued_after", Description: "Issued at or after this RFC 3339 time or unix seconds", Type: "string"},
+	{Name: "issued_before", Description: "Issued before this RFC 3339 time or unix seconds", Type: "string"},
+	{Name: "revoked", Description: "true for revoked credentials only, false for the others", Type: "boolean"},
+	{Name: "include_expired", Description: "false leaves out expired credentials (default true)", Type: "boolean"},
+	{Name: "sort", Description: "issued_at, expiration_date or id; a - prefix sorts descending", Type: "string"},
+}
+
+var crossRegionParam = openAPIParam{Name: "cross_region", Description: "Read records of other data residency regions (DATA_RESIDENCY)", Type: "boolean"}
+
+var openAPISchemaTypes = map[string]reflect.Type{
+	"TxResponse":        reflect.TypeOf(MockTxResponse{}),
+	"DIDVersion":        reflect.TypeOf(didVersion{}),
+	"NodeInfo":          reflect.TypeOf(NodeInfo{}),
+	"Account":           reflect.TypeOf(authAccount{}),
+	"Attestation":       reflect.TypeOf(attestation{}),
+	"EscrowAccount":     reflect.TypeOf(escrowAccount{}),
+	"Settlement":        reflect.TypeOf(settlement{}),
+	"Coin":              reflect.TypeOf(coin{}),
+	"BillingPlan":       reflect.TypeOf(billingPlan{}),
+	"Subscription":      reflect.TypeOf(subscription{}),
+	"Invoice":           reflect.TypeOf(invoice{}),
+	"BillingEvent":      reflect.TypeOf(billingEvent{}),
+	"WebhookDelivery":   reflect.TypeOf(webhookDelivery{}),
+	"Branding":          reflect.TypeOf(branding{}),
+	"CustomDomain":      reflect.TypeOf(customDomain{}),
+	"AccessRules":       reflect.TypeOf(accessRules{}),
+	"RegionProfile":     reflect.TypeOf(regionProfile{}),
+	"FaultRule":         reflect.TypeOf(faultRule{}),
+	"ChaosRule":         reflect.TypeOf(chaosRule{}),
+	"CompatResult":      reflect.TypeOf(compatResult{}),
+	"ConformanceResult": reflect.TypeOf(conformanceResult{}),
+	"PortabilityCheck":  reflect.TypeOf(portabilityCheck{}),
+	"TrustPolicy":       reflect.TypeOf(trustPolicy{}),
+	"Device":            reflect.TypeOf(device{}),
+	"Session":           reflect.TypeOf(session{}),
+	"SecurityEvent":     reflect.TypeOf(securityEvent{}),
+	"PolicyDocument":    reflect.TypeOf(policyDocument{}),
+	"PolicyStatus":      reflect.TypeOf(policyStatus{}),
+	"AnalyticsStep":     reflect.TypeOf(analyticsStep{}),
+	"RevocationReason":  reflect.TypeOf(revocationReason{}),
+	"DenomMetadata":     reflect.TypeOf(denomMetadata{}),
+	"Dispute":           reflect.TypeOf(dispute{}),
+	"APIKey":            reflect.TypeOf(apiKey{}),
+	"RateLimit":         reflect.TypeOf(rateLimit{}),
+	"KeplrCurrency":     reflect.TypeOf(keplrCurrency{}),
+	"KeplrFeeCurrency":  reflect.TypeOf(keplrFeeCurrency{}),
+	"KeplrGasPriceStep": reflect.TypeOf(keplrGasPriceStep{}),
+	"DisputeEvidence":   reflect.TypeOf(disputeEvidence{}),
+	"PushDevice":        reflect.TypeOf(pushDevice{}),
+	"PushNotification":  reflect.TypeOf(pushNotification{}),
+	"ScenarioReport":    reflect.TypeOf(scenarioReport{}),
+	"ActivityEvent":     reflect.TypeOf(activityEvent{}),
+	"TestIdentity":      reflect.TypeOf(testIdentity{}),
+	"TestRun":           reflect.TypeOf(testRun{}),
+	"Webhook":           reflect.TypeOf(identityWebhook{}),
+	"WebhookCallback":   reflect.TypeOf(identityWebhookDelivery{}),
+	"AssertResult":      reflect.TypeOf(assertResult{}),
+	"WatchdogSample":    reflect.TypeOf(watchdogSample{}),
+	"CredentialSchema":  reflect.TypeOf(credentialSchema{}),
+	"WatchdogAnomaly":   reflect.TypeOf(watchdogAnomaly{}),
+	"QuotaUsage":        reflect.TypeOf(quotaUsage{}),
+	"FeatureFlag":       reflect.TypeOf(featureFlagState{}),
+	"AdminAuditEntry":   reflect.TypeOf(adminAuditEntry{}),
+	"ChainSnapshot":     reflect.TypeOf(chainCheckpoint{}),
+	"WorkerPool":        reflect.TypeOf(workerPoolStats{}),
+}
+
+var openAPIOperations = map[string]openAPIOperation{
+	"GET /health":    {Summary: "Health check", Response: objectOf(map[string]interface{}{"status": map[string]interface{}{"type": "string"}, "chain_id": map[string]interface{}{"type": "string"}, "height": map[string]interface{}{"type": "integer"}, "timestamp": map[string]interface{}{"type": "integer"}})},
+	"GET /node_info": {Response: ref("NodeInfo")},
+	"GET /chain-info": {
+		Description: "Chain info for Keplr's experimentalSuggestChain: chain ID, bech32 prefixes, currencies from the bank denom metadata, the chain denom as stake and fee currency with its gas price step, and this server as rpc and rest.",
+		Response: objectOf(map[string]interface{}{
+			"chainId":       map[string]interface{}{"type": "string"},
+			"chainName":     map[string]interface{}{"type": "string"},
+			"rpc":           map[string]interface{}{"type": "string"},
+			"rest":          map[string]interface{}{"type": "string"},
+			"bip44":         anyObject,
+			"bech32Config":  anyObject,
+			"currencies":    arrayOf(ref("KeplrCurrency")),
+			"feeCurrencies": arrayOf(ref("KeplrFeeCurrency")),
+			"stakeCurrency": ref("KeplrCurrency"),
+			"gasPriceStep":  ref("KeplrGasPriceStep"),
+			"features":      arrayOf(map[string]interface{}{"type": "string"}),
+		}),
+	},
+	"GET /blocks/{height}": {
+		Description: "Tendermint /block shape. height may be a number or \"latest\".",
+	},
+	"GET /websocket": {
+		Summary:     "Tendermint JSON-RPC websocket",
+		Description: "Upgrades to a websocket speaking Tendermint JSON-RPC subscribe/unsubscribe for NewBlock and Tx events.",
+	},
+	"GET /events": {
+		Summary:     "Identity activity stream",
+		Description: "Server-Sent Events for DID, credential and proof activity. Each event's data is an ActivityEvent.",
+		Query: []openAPIParam{
+			{Name: "types", Description: "Comma-separated event types or prefixes, e.g. credential,proof.verified", Type: "string"},
+			{Name: "address", Description: "Only events caused by this account", Type: "string"},
+			{Name: "subject", Description: "Only events about this DID, credential or proof", Type: "string"},
+		},
+		Response: ref("ActivityEvent"),
+	},
+	"POST /cosmos/tx/v1beta1/txs": {
+		Description: "Accepts tx.body.messages, top-level msgs or tx_bytes (a base64 protobuf TxRaw; undecodable bytes, unknown message types or tx_bytes sent with tx or msgs are code 2). Rejections are reported in code and raw_log with HTTP 200, like a real node. localized_log adds a human message in the Accept-Language locale. mode BROADCAST_MODE_SYNC (default) reports CheckTx and failed messages with the latest committed height (0 when CheckTx rejects the tx), BROADCAST_MODE_ASYNC only the hash, and BROADCAST_MODE_BLOCK waits for the block and adds the height, delivery result and events; an unknown mode is a 400.",
+		Request:     ref("BroadcastTxRequest"),
+		Response:    ref("TxResponse"),
+	},
+	"GET /cosmos/tx/v1beta1/txs": {
+		Paginated: true,
+		Query: []openAPIParam{
+			{Name: "events", Description: "Event condition such as message.sender='cosmos1...'; repeatable", Type: "string"},
+			{Name: "query", Description: "AND-joined event conditions (Cosmos SDK 0.50)", Type: "string"},
+			{Name: "order_by", Description: "ORDER_BY_ASC or ORDER_BY_DESC", Type: "string"},
+			{Name: "limit", Description: "Legacy page size", Type: "integer"},
+			{Name: "page", Description: "Legacy page number", Type: "integer"},
+		},
+		Response: objectOf(map[string]interface{}{
+			"txs":          arrayOf(anyObject),
+			"tx_responses": arrayOf(anyObject),
+			"pagination":   ref("PageResponse"),
+		}),
+	},
+	"GET /cosmos/tx/v1beta1/txs/{hash}": {
+		Response: objectOf(map[string]interface{}{"tx": anyObject, "tx_response": anyObject}),
+	},
+	"GET /cosmos/auth/v1beta1/accounts/{address}": {
+		Response: objectOf(map[string]interface{}{"account": anyObject}),
+	},
+	"GET /cosmos/bank/v1beta1/balances/{address}": {
+		Paginated:   true,
+		Description: "Balances after the fees the address paid, in every denom. An address that never paid a fee has the initial coins: BANK_INITIAL_BALANCE of the chain's denom and stake, and a test IBC denom.",
+		Response:    objectOf(map[string]interface{}{"balances": arrayOf(ref("Coin"))}),
+	},
+	"GET /cosmos/bank/v1beta1/balances/{address}/by_denom": {
+		Query:    []openAPIParam{{Name: "denom", Description: "Denom to query", Type: "string"}},
+		Response: objectOf(map[string]interface{}{"balance": ref("Coin")}),
+	},
+	"GET /cosmos/bank/v1beta1/supply": {
+		Paginated:   true,
+		Description: "Total supply by denom: the balances of every known account plus the collected fees.",
+		Response:    objectOf(map[string]interface{}{"supply": arrayOf(ref("Coin"))}),
+	},
+	"GET /cosmos/bank/v1beta1/supply/by_denom": {
+		Query:    []openAPIParam{{Name: "denom", Description: "Denom to query", Type: "string"}},
+		Response: objectOf(map[string]interface{}{"amount": ref("Coin")}),
+	},
+	"GET /cosmos/bank/v1beta1/denoms_metadata": {
+		Description: "Display units, decimals and symbol of each denom, from BANK_DENOM_METADATA or the defaults.",
+		Response:    objectOf(map[string]interface{}{"metadatas": arrayOf(ref("DenomMetadata")), "pagination": ref("PageResponse")}),
+	},
+	"GET /cosmos/bank/v1beta1/denoms_metadata/{denom}": {
+		Description: "Metadata of one denom; IBC denoms keep their ibc/ prefix. 404 NotFound for a denom without metadata.",
+		Response:    objectOf(map[string]interface{}{"metadata": ref("DenomMetadata")}),
+	},
+	"GET /persona/did/v1beta1/did_documents": {Paginated: true},
+	"GET /persona/did/v1beta1/did_documents/{id}": {
+		Description: "Stored documents carry Cache-Control (DID_CACHE_MAX_AGE, DID_CACHE_STALE), ETag and Last-Modified, and a matching If-None-Match or If-Modified-Since gets 304. Mock documents are no-store. versionId or versionTime return a past version; an unknown version is 404 NotFound. With DATA_RESIDENCY, a DID of another region is 403 PermissionDenied unless cross_region is set.",
+		Query:       didVersionParams,
+		Response:    didResolutionResponse,
+	},
+	"GET /persona/did/v1beta1/did_documents/{id}/versions": {
+		Description: "Versions of a DID document, oldest first: one per change (created, updated, key_rotated, service_added, service_removed, deactivated), with the rotated key ids for rotations. 404 NotFound for an unknown DID.",
+		Query:       []openAPIParam{{Name: "rotations", Description: "Only list key rotations", Type: "boolean"}},
+		Response: objectOf(map[string]interface{}{
+			"did_id":   map[string]interface{}{"type": "string"},
+			"versions": arrayOf(ref("DIDVersion")),
+		}),
+	},
+	"GET /persona/did/v1beta1/did_by_controller/{controller}": {
+		Description: "Cached like GET /persona/did/v1beta1/did_documents/{id}. A miss is did_document null, cacheable for DID_MISS_CACHE_TTL; with DID_MISS_404=true it is a 404 NotFound with Retry-After instead.",
+		Response:    didResolutionResponse,
+	},
+	"GET /1.0/identifiers/{did}": {
+		Summary:     "Resolve a DID (DID Resolution)",
+		Description: "Universal Resolver binding: a resolution result with didDocument, didDocumentMetadata and didResolutionMetadata, or the bare document for Accept: application/did+ld+json or application/did+json. Resolves did:persona from chain state, derives did:key documents locally and fetches did:web documents (or reads them from DID_WEB_FIXTURES). Errors are in didResolutionMetadata.error: invalidDid 400, notFound 404, representationNotSupported 406, methodNotSupported 501; a deactivated DID is 410. did:key reports invalidPublicKeyType, invalidPublicKeyLength and invalidPublicKey as 400. versionId and versionTime resolve a past version of a did:persona document; a malformed one is invalidDidUrl 400. With DATA_RESIDENCY, a DID of another region is notAllowed 403 unless cross_region is set.",
+		Query:       didVersionParams,
+		Response: objectOf(map[string]interface{}{
+			"@context":              map[string]interface{}{"type": "string"},
+			"didDocument":           anyObject,
+			"didDocumentMetadata":   anyObject,
+			"didResolutionMetadata": anyObject,
+		}),
+	},
+	"GET /persona/vc/v1beta1/credentials": {
+		Paginated:   true,
+		Description: "The default mock credential vc_001 followed by every stored credential, filtered and sorted by the search parameters. Stored credentials carry is_expired, true once their expirationDate has passed on the app clock.",
+		Query:       append([]openAPIParam{crossRegionParam}, credentialSearchParams...),
+	},
+	"GET /persona/vc/v1beta1/credentials_by_controller/{controller}": {
+		Paginated:   true,
+		Description: "Filtered and sorted by the search parameters. Each record carries is_expired, true once its expirationDate has passed on the app clock. With DATA_RESIDENCY, credentials of other regions are left out unless cross_region is set.",
+		Query:       append([]openAPIParam{crossRegionParam}, credentialSearchParams...),
+	},
+	"GET /persona/zk/v1beta1/proofs":                            {Paginated: true},
+	"GET /persona/zk/v1beta1/proofs_by_controller/{controller}": {Paginated: true},
+	"GET /persona/zk/v1beta1/circuits":                          {Paginated: true},
+	"GET /persona/reputation/v1beta1/attestations":              {Paginated: true},
+	"GET /persona/reputation/v1beta1/attestations/{id}": {
+		Response: objectOf(map[string]interface{}{"attestation": ref("Attestation")}),
+	},
+	"GET /persona/payments/v1beta1/settlements/{address}": {Paginated: true},
+	"GET /api/sdk/typescript.zip": {
+		Summary:     "Generated TypeScript client",
+		Description: "A zip of a TypeScript client (types.ts, client.ts, index.ts, package.json) generated from this document, so its types match the running server. make generate-clients writes the same files into the frontend.",
+	},
+	"POST /api/getRequirements": {
+		Description: "The credential templates a use case requires. Needs an API key (X-API-Key) or bearer token with scope requirements:read when API_KEY_AUTH or OAUTH_REQUIRED is true.",
+	},
+	"GET /api/getVc": {
+		Description: "The holder's credential for a proof template. Expired credentials are skipped; when only expired ones match, the response is a 410 with code credential_expired. Needs an API key (X-API-Key) or bearer token with scope credentials:read when API_KEY_AUTH or OAUTH_REQUIRED is true.",
+		Query: []openAPIParam{
+			{Name: "did", Description: "Holder DID", Type: "string"},
+			{Name: "templateId", Description: "Proof template ID", Type: "string"},
+		},
+	},
+	"GET /api/billing/plans":          {Response: stripeList("BillingPlan")},
+	"POST /api/billing/subscriptions": {Response: ref("Subscription")},
+	"GET /api/billing/subscriptions": {
+		Query:    []openAPIParam{{Name: "customer", Description: "Only this customer's subscriptions", Type: "string"}},
+		Response: stripeList("Subscription"),
+	},
+	"GET /api/billing/subscriptions/{id}":    {Response: ref("Subscription")},
+	"POST /api/billing/subscriptions/{id}":   {Response: ref("Subscription")},
+	"DELETE /api/billing/subscriptions/{id}": {Response: ref("Subscription")},
+	"GET /api/billing/invoices":              {Response: stripeList("Invoice")},
+	"GET /api/billing/invoices/{id}":         {Response: ref("Invoice")},
+	"POST /api/billing/invoices/{id}/pay":    {Response: ref("Invoice")},
+	"GET /api/billing/events": {
+		Query:    []openAPIParam{{Name: "type", Description: "Only events of this type", Type: "string"}},
+		Response: stripeList("BillingEvent"),
+	},
+	"GET /api/branding":      {Response: objectOf(map[string]interface{}{"branding": ref("Branding")})},
+	"GET /api/branding/{rp}": {Response: objectOf(map[string]interface{}{"branding": ref("Branding")})},
+	"GET /api/compat/selftest": {
+		Description: "Runs canonical operations against a throwaway chain and reports what the mock supports.",
+		Response: objectOf(map[string]interface{}{
+			"features": arrayOf(ref("CompatResult")),
+			"formats":  anyObject,
+			"server":   anyObject,
+			"versions": anyObject,
+			"messages": arrayOf(map[string]interface{}{"type": "string"}),
+		}),
+	},
+	"GET /api/networks": {
+		Description: "The simulated networks: the default one plus those configured in NETWORKS, each with its chain ID, denom, block time and the base URLs its routes are served under.",
+		Response:    objectOf(map[string]interface{}{"networks": arrayOf(anyObject)}),
+	},
+	"POST /api/networks/portability": {
+		Description: "Checks whether a credential issued on one network would be accepted on another: status, format, schemas, the target's trust policy and issuer resolvability. portable is false when a must-level check fails. 404 for an unknown network or credential.",
+		Request: objectOf(map[string]interface{}{
+			"from":          map[string]interface{}{"type": "string", "description": "Source chain ID, default the default network"},
+			"to":            map[string]interface{}{"type": "string"},
+			"credential_id": map[string]interface{}{"type": "string"},
+			"credential":    anyObject,
+		}),
+		Response: objectOf(map[string]interface{}{
+			"from":          map[string]interface{}{"type": "string"},
+			"to":            map[string]interface{}{"type": "string"},
+			"credential_id": map[string]interface{}{"type": "string"},
+			"portable":      map[string]interface{}{"type": "boolean"},
+			"checks":        arrayOf(ref("PortabilityCheck")),
+			"checked_at":    map[string]interface{}{"type": "integer"},
+		}),
+	},
+	"GET /admin/networks/trust": {
+		Description: "Trust policies of the networks that have one.",
+		Response:    objectOf(map[string]interface{}{"policies": arrayOf(ref("TrustPolicy"))}),
+	},
+	"PUT /admin/networks/{chain_id}/trust": {
+		Description: "Sets a network's trust policy: the networks and issuer DIDs it trusts and the credential types it accepts. 201 when new.",
+		Request:     ref("TrustPolicy"),
+		Response:    objectOf(map[string]interface{}{"policy": ref("TrustPolicy")}),
+	},
+	"DELETE /admin/networks/{chain_id}/trust": {
+		Description: "Removes a network's trust policy, so it trusts every network again.",
+	},
+	"GET /api/dids/{did}/devices": {
+		Description: "Devices registered to a DID, most recently seen first. Revoked devices are left out unless include_revoked=true.",
+		Query:       []openAPIParam{{Name: "include_revoked", Description: "Also list revoked devices", Type: "boolean"}},
+		Response:    objectOf(map[string]interface{}{"did": map[string]interface{}{"type": "string"}, "devices": arrayOf(ref("Device"))}),
+	},
+	"POST /api/dids/{did}/devices": {
+		Description: "Registers a device and signs it in. Takes name, platform (ios, android, web, macos, windows, linux) and an optional passkey_id. The session_token goes in X-Persona-Session. 404 for a DID that does not resolve, 409 when the passkey is linked to another active device.",
+		Request:     ref("Device"),
+		Response: objectOf(map[string]interface{}{
+			"device":        ref("Device"),
+			"session":       ref("Session"),
+			"session_token": map[string]interface{}{"type": "string"},
+		}),
+	},
+	"GET /api/dids/{did}/devices/{id}": {
+		Response: objectOf(map[string]interface{}{"device": ref("Device")}),
+	},
+	"PATCH /api/dids/{did}/devices/{id}": {
+		Description: "Renames a device. 409 for a revoked device.",
+		Request:     objectOf(map[string]interface{}{"name": map[string]interface{}{"type": "string"}}),
+		Response:    objectOf(map[string]interface{}{"device": ref("Device")}),
+	},
+	"DELETE /api/dids/{did}/devices/{id}": {
+		Description: "Revokes a device and all of its sessions.",
+		Response:    objectOf(map[string]interface{}{"device": ref("Device"), "revoked_sessions": map[string]interface{}{"type": "integer"}}),
+	},
+	"POST /api/dids/{did}/devices/{id}/sessions": {
+		Description: "Signs an existing device in again with a new session. A device with a passkey needs {passkey_id}; a mismatch is a 401 and a challenge_failed security event.",
+		Response:    objectOf(map[string]interface{}{"session": ref("Session"), "session_token": map[string]interface{}{"type": "string"}}),
+	},
+	"GET /api/session": {
+		Description: "The session of the X-Persona-Session token, marking it and its device as seen. 401 when the token is unknown or revoked.",
+		Response:    objectOf(map[string]interface{}{"session": ref("Session")}),
+	},
+	"GET /api/sessions": {
+		Description: "Active sessions of the DID signed in with X-Persona-Session, most recently seen first, with the IP and user agent each was last used from. current marks the caller's session. 401 without a live session.",
+		Response: objectOf(map[string]interface{}{
+			"did":      map[string]interface{}{"type": "string"},
+			"sessions": arrayOf(ref("Session")),
+		}),
+	},
+	"DELETE /api/sessions": {
+		Description: "Signs the authenticated DID out of every other session (revoked_reason remote_logout), keeping the current one.",
+		Response: objectOf(map[string]interface{}{
+			"current_session":  map[string]interface{}{"type": "string"},
+			"revoked_sessions": map[string]interface{}{"type": "integer"},
+		}),
+	},
+	"DELETE /api/sessions/{id}": {
+		Description: "Revokes a session of the authenticated DID: remote_logout for another session, signed_out for the current one. 404 for sessions of other DIDs.",
+		Response:    objectOf(map[string]interface{}{"session": ref("Session")}),
+	},
+	"GET /api/dids/{did}/security/events": {
+		Description: "Security events of a DID, newest first: new_device_login, challenge_failed (critical from the third failure in a row) and key_rotated. unacknowledged counts every unacknowledged event.",
+		Query: []openAPIParam{
+			{Name: "severity", Description: "info, warning or critical", Type: "string"},
+			{Name: "unacknowledged", Description: "true lists unacknowledged events only", Type: "boolean"},
+		},
+		Response: objectOf(map[string]interface{}{
+			"did":            map[string]interface{}{"type": "string"},
+			"events":         arrayOf(ref("SecurityEvent")),
+			"unacknowledged": map[string]interface{}{"type": "integer"},
+		}),
+	},
+	"POST /api/dids/{did}/security/events/{id}/ack": {
+		Description: "Acknowledges a security event. The event stays in the feed.",
+		Response:    objectOf(map[string]interface{}{"event": ref("SecurityEvent")}),
+	},
+	"POST /api/dids/{did}/security/events/ack": {
+		Description: "Acknowledges every security event of the DID.",
+		Response: objectOf(map[string]interface{}{
+			"did":          map[string]interface{}{"type": "string"},
+			"acknowledged": map[string]interface{}{"type": "integer"},
+		}),
+	},
+	"GET /api/policies": {
+		Description: "The current version of every policy kind (terms, privacy, ...).",
+		Response:    objectOf(map[string]interface{}{"policies": arrayOf(ref("PolicyDocument"))}),
+	},
+	"GET /api/dids/{did}/policies": {
+		Description: "Where a DID stands on each policy. needs_acceptance is true when it never accepted a kind or a version requiring re-acceptance was published after the one it accepted; the frontend shows the acceptance modal then.",
+		Response: objectOf(map[string]interface{}{
+			"did":              map[string]interface{}{"type": "string"},
+			"policies":         arrayOf(ref("PolicyStatus")),
+			"needs_acceptance": map[string]interface{}{"type": "boolean"},
+		}),
+	},
+	"POST /api/dids/{did}/policies/accept": {
+		Description: "Records acceptance of {kind, version}, or of each entry of {acceptances}. 409 when a version is not the current one, 404 for an unknown kind.",
+		Request: objectOf(map[string]interface{}{
+			"kind":        map[string]interface{}{"type": "string"},
+			"version":     map[string]interface{}{"type": "string"},
+			"acceptances": arrayOf(anyObject),
+		}),
+		Response: objectOf(map[string]interface{}{
+			"did":              map[string]interface{}{"type": "string"},
+			"policies":         arrayOf(ref("PolicyStatus")),
+			"needs_acceptance": map[string]interface{}{"type": "boolean"},
+		}),
+	},
+	"GET /admin/residency": {
+		Description: "Data residency settings and the number of DIDs, credentials and txs in each region's partition. Records are tagged with the region from X-Data-Residency or the client region when DATA_RESIDENCY is true.",
+		Response: objectOf(map[string]interface{}{
+			"enabled":        map[string]interface{}{"type": "boolean"},
+			"default_region": map[string]interface{}{"type": "string"},
+			"regions":        arrayOf(map[string]interface{}{"type": "string"}),
+			"your_region":    map[string]interface{}{"type": "string"},
+			"partitions":     anyObject,
+		}),
+	},
+	"GET /api/analytics/events": {
+		Description: "The analytics sink, newest first: activity events after the anonymization pipeline, with DIDs hashed, timestamps bucketed and claims dropped by default.",
+		Query: []openAPIParam{
+			{Name: "type", Description: "Only events of this activity type", Type: "string"},
+			{Name: "limit", Description: "Maximum events, default 100", Type: "integer"},
+		},
+		Response: objectOf(map[string]interface{}{
+			"events": arrayOf(anyObject),
+			"total":  map[string]interface{}{"type": "integer"},
+		}),
+	},
+	"GET /api/analytics/pipeline": {
+		Description: "The anonymization steps applied before events reach the analytics sink, in order: hash_dids, bucket_timestamps, drop_claims and drop_fields. salted is false while DID hashes use the public default salt.",
+		Response:    analyticsPipelineResponse,
+	},
+	"POST /api/analytics/pipeline/preview": {
+		Description: "Runs the current pipeline on the event in the body without storing it.",
+		Request:     anyObject,
+		Response: objectOf(map[string]interface{}{
+			"input":  anyObject,
+			"output": anyObject,
+		}),
+	},
+	"PUT /admin/analytics/pipeline": {
+		Description: "Replaces the anonymization steps. Events already in the sink are not reprocessed.",
+		Request:     objectOf(map[string]interface{}{"steps": arrayOf(ref("AnalyticsStep"))}),
+		Response:    analyticsPipelineResponse,
+	},
+	"POST /api/dids/{did}/disputes": {
+		Description: "Opens a dispute on a failed verification of the DID's controller: a proof that failed verification (proof_id) or a rejected tx (tx_hash). relying_party defaults to the proof's metadata.verifier. 404 when the verification is not the DID's, 409 when it succeeded or is already disputed.",
+		Request: objectOf(map[string]interface{}{
+			"proof_id":      map[string]interface{}{"type": "string"},
+			"tx_hash":       map[string]interface{}{"type": "string"},
+			"relying_party": map[string]interface{}{"type": "string"},
+			"reason":        map[string]interface{}{"type": "string"},
+			"evidence":      arrayOf(ref("DisputeEvidence")),
+		}),
+		Response: disputeResponse,
+	},
+	"GET /api/dids/{did}/disputes": {
+		Description: "The DID's disputes, oldest first.",
+		Response: objectOf(map[string]interface{}{
+			"did":      map[string]interface{}{"type": "string"},
+			"disputes": arrayOf(ref("Dispute")),
+		}),
+	},
+	"GET /api/disputes/{id}": {
+		Description: "A dispute with its evidence and status history.",
+		Response:    disputeResponse,
+	},
+	"POST /api/disputes/{id}/evidence": {
+		Description: "Attaches evidence (note, document, link or credential) added by {by}, the disputing DID by default. 409 once the dispute is resolved or withdrawn.",
+		Request:     ref("DisputeEvidence"),
+		Response:    disputeResponse,
+	},
+	"POST /api/disputes/{id}/withdraw": {
+		Description: "Withdraws an open or under_review dispute.",
+		Request:     objectOf(map[string]interface{}{"note": map[string]interface{}{"type": "string"}}),
+		Response:    disputeResponse,
+	},
+	"POST /api/disputes/{id}/review": {
+		Description: "The relying party (resolver) takes an open dispute under review. 403 for anyone else.",
+		Request: objectOf(map[string]interface{}{
+			"resolver": map[string]interface{}{"type": "string"},
+			"note":     map[string]interface{}{"type": "string"},
+		}),
+		Response: disputeResponse,
+	},
+	"POST /api/disputes/{id}/resolve": {
+		Description: "The relying party (resolver) resolves the dispute as upheld or overturned. Chain state is not changed. 403 for anyone else.",
+		Request:     disputeResolutionRequest,
+		Response:    disputeResponse,
+	},
+	"GET /admin/disputes": {
+		Description: "Every dispute, oldest first.",
+		Query: []openAPIParam{
+			{Name: "status", Description: "open, under_review, resolved or withdrawn", Type: "string"},
+			{Name: "relying_party", Description: "Only disputes of this relying party", Type: "string"},
+		},
+		Response: objectOf(map[string]interface{}{"disputes": arrayOf(ref("Dispute"))}),
+	},
+	"POST /admin/disputes/{id}/resolve": {
+		Description: "Resolves any open or under_review dispute, as resolver admin unless given.",
+		Request:     disputeResolutionRequest,
+		Response:    disputeResponse,
+	},
+	"GET /.well-known/persona-configuration": {
+		Description: "Capability discovery for this deployment: enabled modules and features, DID methods, credential formats, proof systems, absolute endpoint URLs and limits.",
+		Response: objectOf(map[string]interface{}{
+			"version":            map[string]interface{}{"type": "string"},
+			"api_version":        map[string]interface{}{"type": "string"},
+			"issuer":             map[string]interface{}{"type": "string"},
+			"chain_id":           map[string]interface{}{"type": "string"},
+			"networks":           arrayOf(map[string]interface{}{"type": "string"}),
+			"denom":              map[string]interface{}{"type": "string"},
+			"modules":            arrayOf(map[string]interface{}{"type": "string"}),
+			"messages":           arrayOf(map[string]interface{}{"type": "string"}),
+			"did_methods":        arrayOf(map[string]interface{}{"type": "string"}),
+			"credential_formats": arrayOf(anyObject),
+			"proof_systems":      arrayOf(anyObject),
+			"revocation_reasons": arrayOf(map[string]interface{}{"type": "string"}),
+			"json_casings":       arrayOf(map[string]interface{}{"type": "string"}),
+			"endpoints":          objectOf(map[string]interface{}{}),
+			"limits":             anyObject,
+			"features":           anyObject,
+		}),
+	},
+	"GET /admin/ratelimit": {
+		Description: "The per-IP and per-API-key (X-API-Key) token-bucket limits, the number of live buckets and the 429s per bucket since the last reset. A rate of 0 disables a limit.",
+		Response:    rateLimitStateResponse,
+	},
+	"PUT /admin/ratelimit": {
+		Description: "Sets the ip and/or key limit; burst defaults to the rate rounded up. Every bucket starts over full.",
+		Request: objectOf(map[string]interface{}{
+			"ip":  ref("RateLimit"),
+			"key": ref("RateLimit"),
+		}),
+		Response: rateLimitStateResponse,
+	},
+	"GET /admin/apikeys": {
+		Description: "Every API key with its scopes and usage counters, and whether keys are enforced (API_KEY_AUTH).",
+		Response: objectOf(map[string]interface{}{
+			"enforced": map[string]interface{}{"type": "boolean"},
+			"scopes":   arrayOf(map[string]interface{}{"type": "string"}),
+			"keys":     arrayOf(ref("APIKey")),
+		}),
+	},
+	"POST /admin/apikeys": {
+		Description: "Creates a key with the given scopes (requirements:read, credentials:read or *). The secret is returned in key, once.",
+		Request: objectOf(map[string]interface{}{
+			"name":   map[string]interface{}{"type": "string"},
+			"scopes": arrayOf(map[string]interface{}{"type": "string"}),
+		}),
+		Response: objectOf(map[string]interface{}{
+			"api_key": ref("APIKey"),
+			"key":     map[string]interface{}{"type": "string"},
+		}),
+	},
+	"DELETE /admin/apikeys/{id}": {
+		Description: "Revokes a key. It keeps its counters.",
+		Response:    objectOf(map[string]interface{}{"revoked": map[string]interface{}{"type": "string"}}),
+	},
+	"POST /oauth/token": {
+		Description: "Mock OIDC token endpoint. Takes a form-encoded client_credentials grant (client_id and client_secret as form fields or HTTP Basic, optional space-separated scope) and returns an ES256 JWT access token for Authorization: Bearer. Errors follow RFC 6749 (invalid_client, invalid_scope, unsupported_grant_type).",
+		Response: objectOf(map[string]interface{}{
+			"access_token": map[string]interface{}{"type": "string"},
+			"token_type":   map[string]interface{}{"type": "string"},
+			"expires_in":   map[string]interface{}{"type": "integer"},
+			"scope":        map[string]interface{}{"type": "string"},
+		}),
+	},
+	"GET /oauth/jwks": {
+		Description: "The JWK set that verifies access tokens from /oauth/token.",
+		Response:    objectOf(map[string]interface{}{"keys": arrayOf(anyObject)}),
+	},
+	"GET /.well-known/openid-configuration": {
+		Description: "OpenID provider metadata of the mock issuer: token endpoint, JWKS URI, grant types and scopes.",
+		Response:    anyObject,
+	},
+	"GET /api/quotas/{subject}": {
+		Description: "Usage of every quota for a subject: a relying party (verifications per day), an issuer DID (issuances per day) or a DID (proofs per hour). A limit of 0 is unlimited.",
+		Response: objectOf(map[string]interface{}{
+			"subject": map[string]interface{}{"type": "string"},
+			"quotas":  arrayOf(ref("QuotaUsage")),
+		}),
+	},
+	"GET /admin/quotas": {
+		Description: "Quota limits, per-subject overrides and the usage of every subject in the current windows.",
+		Response:    quotaStateResponse,
+	},
+	"PUT /admin/quotas": {
+		Description: "Changes quota limits and per-subject overrides. Omitted kinds and subjects are unchanged; a null override is removed. Usage is kept.",
+		Request: objectOf(map[string]interface{}{
+			"limits":    map[string]interface{}{"type": "object", "additionalProperties": map[string]interface{}{"type": "integer"}},
+			"overrides": map[string]interface{}{"type": "object", "additionalProperties": map[string]interface{}{"type": "object", "additionalProperties": map[string]interface{}{"type": "integer", "nullable": true}}},
+		}),
+		Response: quotaStateResponse,
+	},
+	"GET /api/trace/{id}": {
+		Description: "Everything a request trace caused: the traced requests, the txs they broadcast, the activity events of those txs, the webhook deliveries of the events and the security events recorded. The trace ID is the X-Request-ID (or traceparent trace-id) a client sent, or the one returned in X-Request-ID.",
+		Response: objectOf(map[string]interface{}{
+			"trace_id":   map[string]interface{}{"type": "string"},
+			"first_seen": map[string]interface{}{"type": "integer", "format": "int64"},
+			"requests": arrayOf(objectOf(map[string]interface{}{
+				"method":      map[string]interface{}{"type": "string"},
+				"path":        map[string]interface{}{"type": "string"},
+				"status":      map[string]interface{}{"type": "integer"},
+				"time":        map[string]interface{}{"type": "integer", "format": "int64"},
+				"duration_ms": map[string]interface{}{"type": "integer", "format": "int64"},
+			})),
+			"txs":                arrayOf(anyObject),
+			"events":             arrayOf(anyObject),
+			"webhook_deliveries": arrayOf(anyObject),
+			"security_events":    arrayOf(ref("SecurityEvent")),
+		}),
+	},
+	"GET /api/drift": {
+		Description: "Differences between this server's response schemas and the contract pinned in CONTRACT_FILE. Each change is breaking or additive; drifted is what fails /health and the contract preflight check.",
+		Response: objectOf(map[string]interface{}{
+			"enabled":    map[string]interface{}{"type": "boolean"},
+			"file":       map[string]interface{}{"type": "string"},
+			"pinned_at":  map[string]interface{}{"type": "string"},
+			"checked_at": map[string]interface{}{"type": "integer", "format": "int64"},
+			"drifted":    map[string]interface{}{"type": "boolean"},
+			"breaking":   map[string]interface{}{"type": "integer"},
+			"additive":   map[string]interface{}{"type": "integer"},
+			"changes": arrayOf(objectOf(map[string]interface{}{
+				"location": map[string]interface{}{"type": "string"},
+				"kind":     map[string]interface{}{"type": "string", "enum": []string{"breaking", "additive"}},
+				"change":   map[string]interface{}{"type": "string"},
+				"pinned":   map[string]interface{}{},
+				"current":  map[string]interface{}{},
+			})),
+			"error": map[string]interface{}{"type": "string"},
+		}),
+	},
+	"GET /api/contract": {
+		Description: "The contract of this server to pin in the frontend: the response schema of every operation and the component schemas. make pin-contract writes it to CONTRACT_OUT.",
+		Response: objectOf(map[string]interface{}{
+			"version":    map[string]interface{}{"type": "integer"},
+			"pinned_at":  map[string]interface{}{"type": "string"},
+			"operations": anyObject,
+			"schemas":    anyObject,
+		}),
+	},
+	"GET /admin/v1": {
+		Description: "Discovery for the versioned admin API: how to authenticate and every resource with its operations, and for each operation the unversioned /admin route it replaces.",
+		Response: objectOf(map[string]interface{}{
+			"version": map[string]interface{}{"type": "string"},
+			"auth":    anyObject,
+			"resources": arrayOf(objectOf(map[string]interface{}{
+				"name":        map[string]interface{}{"type": "string"},
+				"description": map[string]interface{}{"type": "string"},
+				"operations": arrayOf(objectOf(map[string]interface{}{
+					"method":      map[string]interface{}{"type": "string"},
+					"path":        map[string]interface{}{"type": "string"},
+					"description": map[string]interface{}{"type": "string"},
+					"replaces":    map[string]interface{}{"type": "string"},
+				})),
+			})),
+		}),
+	},
+	"GET /admin/v1/flags": {
+		Summary:     "List feature flags",
+		Description: "Every runtime feature flag with its current value, its value at startup and the env var that set it.",
+		Response:    objectOf(map[string]interface{}{"flags": arrayOf(ref("FeatureFlag"))}),
+	},
+	"PUT /admin/v1/flags/{name}": {
+		Summary:     "Switch a feature flag on or off",
+		Description: "Sets a feature flag until the next /admin/reset, which restores its startup value.",
+		Request:     objectOf(map[string]interface{}{"enabled": map[string]interface{}{"type": "boolean"}}),
+		Response: objectOf(map[string]interface{}{
+			"name":    map[string]interface{}{"type": "string"},
+			"enabled": map[string]interface{}{"type": "boolean"},
+			"startup": map[string]interface{}{"type": "boolean"},
+		}),
+	},
+	"GET /admin/v1/audit": {
+		Summary:     "List admin changes",
+		Description: "Changes made through /admin, newest first, including ones refused for a missing admin token. The actor is the X-Admin-Actor header of the request.",
+		Query: []openAPIParam{
+			{Name: "actor", Description: "Only entries by this actor", Type: "string"},
+			{Name: "method", Description: "Only entries with this HTTP method", Type: "string"},
+			{Name: "route", Description: "Only entries whose path starts with this", Type: "string"},
+			{Name: "since", Description: "Only entries at or after this unix time", Type: "integer"},
+			{Name: "limit", Description: "At most this many entries (default 100)", Type: "integer"},
+		},
+		Response: objectOf(map[string]interface{}{
+			"entries": arrayOf(ref("AdminAuditEntry")),
+			"total":   map[string]interface{}{"type": "integer"},
+		}),
+	},
+	"GET /admin/time": {
+		Description: "The app clock: its current time and, unless deterministic (mode manual), how far it is ahead of the wall clock.",
+		Response:    clockStateResponse,
+	},
+	"POST /admin/time": {
+		Summary:     "Move the app clock",
+		Description: "Moves the clock every chain reads for issuance and expiration dates, proof freshness and block timestamps. advance takes a duration such as 720h or 30d (negative goes back), set an RFC 3339 time. Time keeps running from there.",
+		Request: objectOf(map[string]interface{}{
+			"advance": map[string]interface{}{"type": "string", "example": "720h"},
+			"set":     map[string]interface{}{"type": "string", "format": "date-time"},
+		}),
+		Response: clockStateResponse,
+	},
+	"DELETE /admin/time": {
+		Summary:     "Reset the app clock",
+		Description: "Puts the app clock back on the wall clock, as /admin/reset does. 409 in deterministic mode.",
+		Response:    clockStateResponse,
+	},
+	"GET /admin/proxy": {
+		Description: "The record-and-replay proxy for unknown routes: its PROXY_MODE (record, replay or empty when off), UPSTREAM_URL, cassette file, the exchanges loaded for replay and the recorded, replayed, miss and error counts since the last reset.",
+		Response: objectOf(map[string]interface{}{
+			"mode":      map[string]interface{}{"type": "string", "enum": []string{"", "record", "replay"}},
+			"upstream":  map[string]interface{}{"type": "string"},
+			"cassette":  map[string]interface{}{"type": "string"},
+			"exchanges": map[string]interface{}{"type": "integer"},
+			"stats":     map[string]interface{}{"type": "object", "additionalProperties": map[string]interface{}{"type": "integer"}},
+		}),
+	},
+	"GET /admin/recordings": {
+		Summary:     "Export recorded traffic as HAR",
+		Description: "Requests and responses recorded while the traffic_recording flag (RECORD_TRAFFIC) is on, as a HAR 1.2 file. Credentials are redacted and bodies cut at RECORDINGS_BODY_MAX bytes. Each entry carries _testRun (the X-Test-Run header) and _traceId.",
+		Query: []openAPIParam{
+			{Name: "run", Description: "Only requests with this X-Test-Run header", Type: "string"},
+			{Name: "limit", Description: "Only the newest this many requests", Type: "integer"},
+		},
+		Response: objectOf(map[string]interface{}{
+			"log": objectOf(map[string]interface{}{
+				"version": map[string]interface{}{"type": "string"},
+				"creator": map[string]interface{}{"type": "object"},
+				"entries": arrayOf(map[string]interface{}{"type": "object"}),
+			}),
+		}),
+	},
+	"DELETE /admin/recordings": {
+		Description: "Drops the recorded traffic, as /admin/reset does.",
+	},
+	"GET /admin/tenants": {
+		Description: "Every tenant chain, with the most that may exist at a time.",
+		Response: objectOf(map[string]interface{}{
+			"tenants": arrayOf(tenantView),
+			"max":     map[string]interface{}{"type": "integer"},
+		}),
+	},
+	"POST /admin/tenants": {
+		Description: "Creates an isolated chain served under /t/{id}/ with its own state and block producer. chain_id defaults to persona-{id} and block_time to the default chain's; with a ttl the tenant is deleted once idle that long.",
+		Request: objectOf(map[string]interface{}{
+			"id":         map[string]interface{}{"type": "string", "pattern": "^[a-z0-9][a-z0-9-]{0,62}$"},
+			"chain_id":   map[string]interface{}{"type": "string"},
+			"denom":      map[string]interface{}{"type": "string"},
+			"block_time": map[string]interface{}{"type": "string", "example": "1s"},
+			"ttl":        map[string]interface{}{"type": "string", "example": "30m"},
+		}),
+		Response: objectOf(map[string]interface{}{"tenant": tenantView}),
+	},
+	"GET /admin/tenants/{id}": {
+		Response: objectOf(map[string]interface{}{"tenant": tenantView}),
+	},
+	"POST /admin/tenants/{id}/reset": {
+		Description: "Wipes the tenant chain's state. Server-level state and other tenants are untouched.",
+		Response: objectOf(map[string]interface{}{
+			"reset":  arrayOf(map[string]interface{}{"type": "string"}),
+			"tenant": map[string]interface{}{"type": "string"},
+		}),
+	},
+	"POST /admin/tenants/{id}/seed": {
+		Description: "Seeds the tenant chain with the payload POST /admin/seed takes; ?reset=true wipes it first.",
+		Request:     anyObject,
+		Response:    anyObject,
+	},
+	"GET /admin/policies": {
+		Description: "Every published policy version with the number of DIDs whose latest acceptance is that version.",
+		Response: objectOf(map[string]interface{}{"versions": arrayOf(objectOf(map[string]interface{}{
+			"document":    ref("PolicyDocument"),
+			"acceptances": map[string]interface{}{"type": "integer"},
+		}))}),
+	},
+	"POST /admin/policies": {
+		Description: "Publishes a new version of a policy kind, which becomes current. requires_reacceptance prompts DIDs that accepted an earlier version; the first version of a kind always does.",
+		Request:     ref("PolicyDocument"),
+		Response:    objectOf(map[string]interface{}{"policy": ref("PolicyDocument")}),
+	},
+	"GET /api/errors": {
+		Description: "Human messages for every tx error code, keyed codespace:code, in the locale from ?locale or Accept-Language (en, es, fr, de, ja, pt). Tx responses carry the same message in localized_log.",
+		Query:       []openAPIParam{{Name: "locale", Description: "Locale instead of Accept-Language", Type: "string"}},
+		Response: objectOf(map[string]interface{}{
+			"locale":  map[string]interface{}{"type": "string"},
+			"locales": arrayOf(map[string]interface{}{"type": "string"}),
+			"errors": arrayOf(objectOf(map[string]interface{}{
+				"key":       map[string]interface{}{"type": "string"},
+				"codespace": map[string]interface{}{"type": "string"},
+				"code":      map[string]interface{}{"type": "integer"},
+				"message":   map[string]interface{}{"type": "string"},
+			})),
+		}),
+	},
+	"GET /api/conformance": {
+		Description: "Runs W3C DID Resolution and VC Data Model checks against a throwaway chain and reports which pass. conformant is false when a must-level check fails.",
+		Query:       []openAPIParam{{Name: "suite", Description: "Only run did-resolution or vc-data-model", Type: "string"}},
+		Response: objectOf(map[string]interface{}{
+			"chain_id":      map[string]interface{}{"type": "string"},
+			"generated_at":  map[string]interface{}{"type": "string", "format": "date-time"},
+			"vc_validation": map[string]interface{}{"type": "string"},
+			"conformant":    map[string]interface{}{"type": "boolean"},
+			"suites": arrayOf(objectOf(map[string]interface{}{
+				"name":   map[string]interface{}{"type": "string"},
+				"spec":   map[string]interface{}{"type": "string"},
+				"passed": map[string]interface{}{"type": "integer"},
+				"failed": map[string]interface{}{"type": "integer"},
+				"checks": arrayOf(ref("ConformanceResult")),
+			})),
+			"summary": anyObject,
+		}),
+	},
+	"POST /api/test-ids/reserve": {
+		Description: "Reserves identifiers no other caller gets. 201 for a new run, 200 when adding to one.",
+		Query: []openAPIParam{
+			{Name: "count", Description: "Identifiers to reserve, 1 to 500", Type: "integer"},
+			{Name: "run", Description: "Add to this run instead of starting one", Type: "string"},
+			{Name: "ttl", Description: "Run lifetime as a Go duration, default TEST_IDS_TTL or 1h", Type: "string"},
+		},
+		Response: objectOf(map[string]interface{}{
+			"run_id":     map[string]interface{}{"type": "string"},
+			"expires_at": map[string]interface{}{"type": "string", "format": "date-time"},
+			"ids":        arrayOf(ref("TestIdentity")),
+			"total":      map[string]interface{}{"type": "integer"},
+		}),
+	},
+	"GET /api/test-ids/runs/{run}": {Response: ref("TestRun")},
+	"GET /api/assert/{name}": {
+		Description: "Checks one fact about the stored state: credential-exists, proof-verified or did-active. 200 whether or not it holds.",
+		Query: []openAPIParam{
+			{Name: "did", Description: "The DID the assertion is about", Type: "string"},
+			{Name: "type", Description: "Credential type, for credential-exists", Type: "string"},
+			{Name: "circuit", Description: "Circuit ID, for proof-verified", Type: "string"},
+		},
+		Response: ref("AssertResult"),
+	},
+	"GET /api/entities/{type}/{id}/events": {
+		Description: "Every activity event of one entity, oldest first. type is did, credential or proof.",
+		Query:       []openAPIParam{{Name: "reverse", Description: "Newest first", Type: "boolean"}},
+		Response: objectOf(map[string]interface{}{
+			"entity": anyObject,
+			"events": arrayOf(ref("ActivityEvent")),
+			"total":  map[string]interface{}{"type": "integer"},
+		}),
+	},
+	"POST /persona/vc/v1beta1/schemas": {
+		Description: "Registers a JSON Schema for a credential type. MsgIssueCredential then rejects credentials of that type whose credentialSubject violates it, with code 12 in the vc codespace.",
+		Request:     ref("CredentialSchema"),
+		Response:    objectOf(map[string]interface{}{"schema": ref("CredentialSchema")}),
+	},
+	"GET /persona/vc/v1beta1/schemas": {
+		Paginated: true,
+		Response:  objectOf(map[string]interface{}{"schemas": arrayOf(ref("CredentialSchema")), "pagination": ref("PageResponse")}),
+	},
+	"GET /persona/vc/v1beta1/schemas/{type}": {Response: objectOf(map[string]interface{}{"schema": ref("CredentialSchema")})},
+	"GET /persona/vc/v1beta1/credentials/{id}/status": {
+		Description: "Whether a credential is active, revoked or expired (its expirationDate has passed on the app clock). A revoked credential carries revocation_reason_code, its revocation_reason_label and the holder-facing revocation_explanation, the issuer's or the code's default.",
+		Response: objectOf(map[string]interface{}{
+			"credential_id":           map[string]interface{}{"type": "string"},
+			"status":                  map[string]interface{}{"type": "string"},
+			"is_revoked":              map[string]interface{}{"type": "boolean"},
+			"is_expired":              map[string]interface{}{"type": "boolean"},
+			"expiration_date":         map[string]interface{}{"type": "string", "format": "date-time"},
+			"revocation_reason":       map[string]interface{}{"type": "string"},
+			"revocation_reason_code":  map[string]interface{}{"type": "string"},
+			"revocation_reason_label": map[string]interface{}{"type": "string"},
+			"revocation_explanation":  map[string]interface{}{"type": "string"},
+			"revoked_at":              map[string]interface{}{"type": "integer"},
+			"checked_at":              map[string]interface{}{"type": "integer"},
+		}),
+	},
+	"GET /persona/vc/v1beta1/revocation_reasons": {
+		Description: "The reason codes MsgRevokeCredential accepts in reason_code, with the label and default holder-facing explanation of each.",
+		Response:    objectOf(map[string]interface{}{"reasons": arrayOf(ref("RevocationReason"))}),
+	},
+	"POST /persona/zk/v1beta1/verify/batch": {
+		Description: "Verifies up to VERIFY_BATCH_MAX proofs concurrently. Each item is a single verify request; results keep the item order.",
+		Request:     objectOf(map[string]interface{}{"items": arrayOf(anyObject)}),
+		Response:    objectOf(map[string]interface{}{"results": arrayOf(anyObject), "summary": anyObject}),
+	},
+	"POST /persona/vc/v1beta1/verify/batch": {
+		Description: "Checks up to VERIFY_BATCH_MAX credentials concurrently. Each item is {credential_id} or {credential}; a credential verifies when it is stored, not revoked and matches its schema.",
+		Request:     objectOf(map[string]interface{}{"items": arrayOf(anyObject)}),
+		Response:    objectOf(map[string]interface{}{"results": arrayOf(anyObject), "summary": anyObject}),
+	},
+	"POST /persona/vc/v1beta1/sd-jwt/issue": {
+		Summary:     "Issue an SD-JWT VC",
+		Description: "Signs claims for a stored issuer DID as an SD-JWT VC with one salted disclosure per claim, apart from always_disclosed ones. holder_jwk binds it to a P-256 holder key.",
+		Request:     anyObject,
+		Response: objectOf(map[string]interface{}{
+			"sd_jwt":      map[string]interface{}{"type": "string"},
+			"issuer_jwt":  map[string]interface{}{"type": "string"},
+			"disclosures": arrayOf(anyObject),
+		}),
+	},
+	"POST /persona/vc/v1beta1/sd-jwt/verify": {
+		Summary:     "Verify an SD-JWT presentation",
+		Description: "Takes presentation (<JWT>~<disclosure>~...~[<KB-JWT>]) or sd_jwt with disclosures, and returns the revealed claims. A failed check is a 200 with verified=false.",
+		Request:     anyObject,
+		Response: objectOf(map[string]interface{}{
+			"verified":    map[string]interface{}{"type": "boolean"},
+			"issuer":      map[string]interface{}{"type": "string"},
+			"vct":         map[string]interface{}{"type": "string"},
+			"claims":      anyObject,
+			"disclosed":   arrayOf(map[string]interface{}{"type": "string"}),
+			"key_binding": map[string]interface{}{"type": "boolean"},
+			"error":       map[string]interface{}{"type": "string"},
+		}),
+	},
+	"GET /persona/vc/v1beta1/sd-jwt/jwks": {
+		Summary:  "SD-JWT issuer signing keys",
+		Response: objectOf(map[string]interface{}{"keys": arrayOf(anyObject)}),
+	},
+	"POST /persona/vc/v1beta1/derive": {
+		Summary:     "Derive a selective disclosure credential",
+		Description: "Takes a stored credential_id or a credential signed with PersonaBbsSignature2026, the credentialSubject paths to reveal and the verifier's nonce, and returns a derived credential with a PersonaBbsSignatureProof2026 revealing only those fields. Fields outside credentialSubject and credentialSubject.id are always revealed. 422 when the credential is not BBS+ signed or a path is not in it.",
+		Request: objectOf(map[string]interface{}{
+			"credential_id": map[string]interface{}{"type": "string"},
+			"credential":    anyObject,
+			"reveal":        arrayOf(map[string]interface{}{"type": "string"}),
+			"nonce":         map[string]interface{}{"type": "string"},
+		}),
+		Response: objectOf(map[string]interface{}{"credential": anyObject}),
+	},
+	"POST /persona/vc/v1beta1/derive/verify": {
+		Summary:     "Verify a derived or BBS+ signed credential",
+		Description: "Checks the PersonaBbsSignatureProof2026 (or PersonaBbsSignature2026) of {credential} against the issuer's key, and that the issuer DID is active and the credential is not revoked. A failed check is a 200 with verified=false.",
+		Request:     objectOf(map[string]interface{}{"credential": anyObject}),
+		Response: objectOf(map[string]interface{}{
+			"verified":            map[string]interface{}{"type": "boolean"},
+			"issuer":              map[string]interface{}{"type": "string"},
+			"proof_type":          map[string]interface{}{"type": "string"},
+			"revealed_statements": map[string]interface{}{"type": "integer"},
+			"total_statements":    map[string]interface{}{"type": "integer"},
+			"error":               map[string]interface{}{"type": "string"},
+		}),
+	},
+	"GET /persona/vc/v1beta1/bbs/keys/{did}": {
+		Summary: "BBS+ public key of an issuer DID",
+		Response: objectOf(map[string]interface{}{
+			"id":              map[string]interface{}{"type": "string"},
+			"type":            map[string]interface{}{"type": "string"},
+			"controller":      map[string]interface{}{"type": "string"},
+			"publicKeyBase58": map[string]interface{}{"type": "string"},
+		}),
+	},
+	"POST /persona/zk/v1beta1/verify": {
+		Summary:     "Verify a proof without submitting it",
+		Description: "Checks {circuit_id, proof, public_inputs} as MsgSubmitProof would and stores nothing. proof may be the proof_data string or the proof object; credential_ids must not be revoked. 200 with verified=false when the check fails. Results are cached until revocation or a key change.",
+		Request: objectOf(map[string]interface{}{
+			"circuit_id":     map[string]interface{}{"type": "string"},
+			"proof":          map[string]interface{}{},
+			"public_inputs":  arrayOf(map[string]interface{}{"type": "string"}),
+			"credential_ids": arrayOf(map[string]interface{}{"type": "string"}),
+		}),
+		Response: objectOf(map[string]interface{}{
+			"circuit_id": map[string]interface{}{"type": "string"},
+			"verified":   map[string]interface{}{"type": "boolean"},
+			"mode":       map[string]interface{}{"type": "string", "enum": []string{"mock", "groth16"}},
+			"error":      map[string]interface{}{"type": "string"},
+			"checked_at": map[string]interface{}{"type": "integer"},
+			"cached":     map[string]interface{}{"type": "boolean"},
+		}),
+	},
+	"GET /api/watchdog": {
+		Description: "Resource samples and leak anomalies. status is leak_suspected while a metric keeps growing.",
+		Response: objectOf(map[string]interface{}{
+			"status":    map[string]interface{}{"type": "string", "enum": []string{"ok", "leak_suspected"}},
+			"current":   ref("WatchdogSample"),
+			"baseline":  ref("WatchdogSample"),
+			"growing":   arrayOf(map[string]interface{}{"type": "string"}),
+			"anomalies": arrayOf(ref("WatchdogAnomaly")),
+			"samples":   arrayOf(ref("WatchdogSample")),
+		}),
+	},
+	"GET /metrics": {Summary: "Prometheus metrics", Description: "Resource gauges and watchdog anomalies in the Prometheus text format."},
+	"GET /api/preflight": {
+		Description: "Checks the subsystems a test suite needs. 503 when any check fails.",
+		Query: []openAPIParam{
+			{Name: "suite", Description: "all, issuance, verification or explorer", Type: "string"},
+			{Name: "checks", Description: "Comma-separated checks (storage, block_producer, issuance, verification, groth16, events, contract), instead of a suite", Type: "string"},
+		},
+	},
+	"POST /graphql": {
+		Summary:     "GraphQL query",
+		Description: "Read-only GraphQL over DIDs, credentials, proofs and circuits. Also accepts GET with ?query=.",
+		Request: objectOf(map[string]interface{}{
+			"query":         map[string]interface{}{"type": "string"},
+			"variables":     anyObject,
+			"operationName": map[string]interface{}{"type": "string"},
+		}),
+		Response: objectOf(map[string]interface{}{
+			"data":   anyObject,
+			"errors": arrayOf(anyObject),
+		}),
+	},
+	"GET /graphql": {
+		Summary: "GraphQL query",
+		Query: []openAPIParam{
+			{Name: "query", Description: "GraphQL query document", Type: "string"},
+			{Name: "variables", Description: "JSON-encoded variables", Type: "string"},
+			{Name: "operationName", Type: "string"},
+		},
+		Response: objectOf(map[string]interface{}{
+			"data":   anyObject,
+			"errors": arrayOf(anyObject),
+		}),
+	},
+	"GET /api/access/policy": {
+		Response: objectOf(map[string]interface{}{
+			"blocked_countries": arrayOf(map[string]interface{}{"type": "string"}),
+			"your_country":      map[string]interface{}{"type": "string"},
+		}),
+	},
+	"POST /admin/reset": {
+		Query: []openAPIParam{{Name: "fixtures", Description: "Reload FIXTURES_DIR after the reset", Type: "boolean"}},
+	},
+	"GET /admin/snapshot": {
+		Summary:     "Export a snapshot",
+		Description: "The module stores and txs as a zstd-compressed tar with a SHA-256 manifest. X-Snapshot-SHA256 carries the archive digest. With name, the archive is stored at snapshots/<name>.tar.zst in object storage instead. With region, only that data residency partition is exported, stored at snapshots/<region>/<name>.tar.zst.",
+		Query: []openAPIParam{
+			{Name: "name", Description: "Store the archive in object storage under this name", Type: "string"},
+			{Name: "region", Description: "Export only this residency region (us, eu, ap, sa)", Type: "string"},
+		},
+	},
+	"POST /admin/snapshot/import": {
+		Summary:     "Import a snapshot",
+		Description: "Replaces the module stores and txs with a snapshot's after checking every file against its manifest. 400 with neither a body nor name, 422 when the archive or a checksum does not match.",
+		Query: []openAPIParam{
+			{Name: "name", Description: "Load the archive from object storage instead of the body", Type: "string"},
+			{Name: "region", Description: "Residency region of a partition stored with name", Type: "string"},
+			{Name: "sha256", Description: "Expected SHA-256 of the archive", Type: "string"},
+		},
+	},
+	"GET /admin/snapshots": {
+		Description: "In-memory snapshots of the chain, oldest first, with the most kept at a time.",
+		Response: objectOf(map[string]interface{}{
+			"snapshots": arrayOf(ref("ChainSnapshot")),
+			"max":       map[string]interface{}{"type": "integer"},
+		}),
+	},
+	"POST /admin/snapshot": {
+		Summary:     "Take an in-memory snapshot",
+		Description: "Saves the chain state and returns its id (201) for POST /admin/restore/{id}. The oldest snapshot is dropped once the most are kept.",
+		Response:    objectOf(map[string]interface{}{"id": map[string]interface{}{"type": "string"}, "snapshot": ref("ChainSnapshot")}),
+	},
+	"POST /admin/restore/{id}": {
+		Summary:     "Restore an in-memory snapshot",
+		Description: "Rolls the chain back to a snapshot from POST /admin/snapshot: module stores (DIDs, credentials, proofs, balances), stored txs, blocks, height and activity history. The snapshot is kept, so it can be restored again.",
+		Response:    objectOf(map[string]interface{}{"restored": ref("ChainSnapshot")}),
+	},
+	"POST /admin/tenants/{id}/snapshot": {
+		Description: "Takes an in-memory snapshot of a tenant chain.",
+		Response:    objectOf(map[string]interface{}{"id": map[string]interface{}{"type": "string"}, "snapshot": ref("ChainSnapshot")}),
+	},
+	"POST /admin/tenants/{id}/restore/{snapshot}": {
+		Description: "Rolls a tenant chain back to one of its snapshots.",
+		Response: objectOf(map[string]interface{}{
+			"restored": ref("ChainSnapshot"),
+			"tenant":   map[string]interface{}{"type": "string"},
+		}),
+	},
+	"POST /admin/seed": {
+		Query: []openAPIParam{{Name: "reset", Description: "Wipe all state before seeding", Type: "boolean"}},
+	},
+	"POST /admin/scenarios/run": {
+		Description: "Runs a YAML or JSON scenario script atomically. 422 with the report when a step fails.",
+		Query:       []openAPIParam{{Name: "dry_run", Description: "Roll back after running", Type: "boolean"}},
+		Response:    ref("ScenarioReport"),
+	},
+	"GET /admin/demo": {
+		Description: "Demo storylines (onboarding, bar-age-check, bank-kyc) with their state: not_started, in_progress or completed, the next step's narration and the entities created so far.",
+		Response:    objectOf(map[string]interface{}{"storylines": arrayOf(anyObject)}),
+	},
+	"POST /admin/demo/{storyline}/start": {
+		Description: "Runs the storyline's setup and puts it before step 1. Starting again first rolls back the earlier run. 422 with the scenario report when the setup fails.",
+		Query:       []openAPIParam{{Name: "reset", Description: "Wipe all state first", Type: "boolean"}},
+	},
+	"POST /admin/demo/{storyline}/advance": {
+		Description: "Runs the next step atomically. 409 when the storyline is not started or already completed; 422 with the scenario report when the step fails, leaving the storyline where it was.",
+	},
+	"DELETE /admin/demo/{storyline}": {
+		Description: "Rolls the module stores back to before the storyline was started and marks it not started.",
+	},
+	"GET /admin/debug/state": {
+		Description: "Everything related to one DID for triage: document, credentials, proofs, txs and recent activity. 404 for an unknown DID.",
+		Query:       []openAPIParam{{Name: "did", Description: "The DID to dump", Type: "string"}},
+		Response: objectOf(map[string]interface{}{
+			"did":         map[string]interface{}{"type": "string"},
+			"controller":  map[string]interface{}{"type": "string"},
+			"document":    anyObject,
+			"credentials": anyObject,
+			"proofs":      arrayOf(anyObject),
+			"txs":         arrayOf(anyObject),
+			"events":      arrayOf(ref("ActivityEvent")),
+		}),
+	},
+	"PUT /admin/zk/verifying-keys/{circuit}": {
+		Description: "Registers a Groth16 BN254 verifying key in the snarkjs verification_key.json layout. Proofs are checked against it when VERIFY_PROOFS=true.",
+		Request:     anyObject,
+	},
+	"POST /admin/webhooks": {
+		Description: "Registers a signed callback for activity events. events takes types or prefixes such as credential.*; the secret is generated when omitted.",
+		Request:     ref("Webhook"),
+		Response:    objectOf(map[string]interface{}{"webhook": ref("Webhook")}),
+	},
+	"GET /admin/pools": {Response: objectOf(map[string]interface{}{"pools": arrayOf(ref("WorkerPool"))})},
+	"PUT /admin/pools/{name}": {
+		Description: "Resizes the proving, verification or webhooks worker pool. Takes workers and queue_capacity; an omitted field keeps its value.",
+		Request:     anyObject,
+		Response:    objectOf(map[string]interface{}{"pool": ref("WorkerPool")}),
+	},
+	"GET /admin/webhooks": {Response: objectOf(map[string]interface{}{"webhooks": arrayOf(ref("Webhook"))})},
+	"GET /admin/webhooks/{id}": {
+		Response: objectOf(map[string]interface{}{"webhook": ref("Webhook"), "deliveries": arrayOf(ref("WebhookCallback"))}),
+	},
+	"POST /admin/webhooks/{id}/ping": {Response: objectOf(map[string]interface{}{"delivery": ref("WebhookCallback")})},
+	"GET /admin/access":              {Response: objectOf(map[string]interface{}{"rules": ref("AccessRules")})},
+	"PUT /admin/access":              {Request: ref("AccessRules"), Response: objectOf(map[string]interface{}{"rules": ref("AccessRules")})},
+	"GET /admin/faults":              {Response: objectOf(map[string]interface{}{"rules": arrayOf(ref("FaultRule"))})},
+	"POST /admin/faults":             {Request: ref("FaultRule"), Response: objectOf(map[string]interface{}{"rule": ref("FaultRule")})},
+	"GET /admin/chaos":               {Response: objectOf(map[string]interface{}{"rules": arrayOf(ref("ChaosRule"))})},
+	"POST /admin/chaos":              {Request: ref("ChaosRule"), Response: objectOf(map[string]interface{}{"rule": ref("ChaosRule")})},
+	"PUT /admin/geo/{region}":        {Request: ref("RegionProfile")},
+	"GET /admin/branding":            {Response: objectOf(map[string]interface{}{"branding": arrayOf(ref("Branding"))})},
+	"PUT /admin/branding/{rp}": {
+		Request:  ref("Branding"),
+		Response: objectOf(map[string]interface{}{"branding": ref("Branding")}),
+	},
+	"POST /api/push/devices": {Request: ref("PushDevice"), Response: objectOf(map[string]interface{}{"device": ref("PushDevice")})},
+	"GET /admin/push/{token}": {
+		Query:    []openAPIParam{{Name: "kind", Description: "Only notifications of this kind", Type: "string"}},
+		Response: objectOf(map[string]interface{}{"notifications": arrayOf(ref("PushNotification")), "device": ref("PushDevice")}),
+	},
+	"GET /admin/domains": {Response: objectOf(map[string]interface{}{"domains": arrayOf(ref("CustomDomain"))})},
+	"PUT /admin/domains/{domain}": {
+		Request:  ref("CustomDomain"),
+		Response: objectOf(map[string]interface{}{"domain": ref("CustomDomain")}),
+	},
+}
+
+// buildOpenAPISpec walks the router and assembles the document.
+func (c *Chain) buildOpenAPISpec(router *mux.Router, serverURL string) (map[string]interface{}, error) {
+	paths := make(map[string]map[string]interface{})
+	operationIDs := make(map[string]int)
+
+	err := router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		template, err := route.GetPathTemplate()
+		if err != nil || route.GetHandler() == nil {
+			return nil
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			return nil
+		}
+		path, params := openAPIPath(template)
+		for _, method := range methods {
+			if method == "OPTIONS" {
+				continue
+			}
+			doc := openAPIOperations[method+" "+path]
+			operationID, summary := describeHandler(route.GetHandler(), method, path)
+			if operationIDs[operationID]++; operationIDs[operationID] > 1 {
+				operationID = fmt.Sprintf("%s%d", operationID, operationIDs[operationID])
+			}
+			if doc.Summary != "" {
+				summary = doc.Summary
+			}
+
+			op := map[string]interface{}{
+				"operationId": operationID,
+				"summary":     summary,
+				"tags":        []string{openAPITag(path)},
+			}
+			if doc.Description != "" {
+				op["description"] = doc.Description
+			}
+
+			parameters := make([]map[string]interface{}, 0, len(params))
+			for _, name := range params {
+				parameters = append(parameters, map[string]interface{}{
+					"name": name, "in": "path", "required": true,
+					"schema": map[string]interface{}{"type": "string"},
+				})
+			}
+			query := doc.Query
+			if doc.Paginated {
+				query = append(append([]openAPIParam{}, paginationParams...), query...)
+			}
+			for _, param := range query {
+				parameters = append(parameters, map[string]interface{}{
+					"name": param.Name, "in": "query", "description": param.Description,
+					"schema": map[string]interface{}{"type": param.Type},
+				})
+			}
+			if len(parameters) > 0 {
+				op["parameters"] = parameters
+			}
+
+			if doc.Request != nil || method == "POST" || method == "PUT" {
+				request := doc.Request
+				if request == nil {
+					request = anyObject
+				}
+				op["requestBody"] = map[string]interface{}{
+					"content": map[string]interface{}{"application/json": map[string]interface{}{"schema": request}},
+				}
+			}
+
+			response := doc.Response
+			if response == nil {
+				response = anyObject
+			}
+			responses := map[string]interface{}{
+				"200": map[string]interface{}{
+					"description": "OK",
+					"content":     map[string]interface{}{"application/json": map[string]interface{}{"schema": response}},
+				},
+			}
+			if strings.HasPrefix(path, "/admin/") {
+				op["security"] = []map[string][]string{{"bearerAuth": {}}, {"adminToken": {}}}
+				responses["401"] = map[string]interface{}{"description": "Missing or wrong admin token (only when ADMIN_TOKEN is set)"}
+			}
+			if path == "/websocket" {
+				responses = map[string]interface{}{"101": map[string]interface{}{"description": "Switching Protocols"}}
+			}
+			if path == "/events" {
+				responses["200"] = map[string]interface{}{
+					"description": "Event stream",
+					"content":     map[string]interface{}{"text/event-stream": map[string]interface{}{"schema": response}},
+				}
+			}
+			op["responses"] = responses
+
+			if paths[path] == nil {
+				paths[path] = make(map[string]interface{})
+			}
+			paths[path][strings.ToLower(method)] = op
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":       "Persona mock testnet",
+			"description": "Mock Persona chain node, LCD and supporting test services.",
+			"version":     c.info.NodeInfo.Version,
+		},
+		"servers": []map[string]string{{"url": serverURL}},
+		"paths":   paths,
+		"components": map[string]interface{}{
+			"schemas": c.openAPISchemas(),
+			"securitySchemes": map[string]interface{}{
+				"bearerAuth": map[string]string{"type": "http", "scheme": "bearer"},
+				"adminToken": map[string]string{"type": "apiKey", "in": "header", "name": "X-Admin-Token"},
+			},
+		},
+	}, nil
+}
+
+var paginationParams = []openAPIParam{
+	{Name: "pagination.key", Description: "next_key from the previous page", Type: "string"},
+	{Name: "pagination.offset", Type: "integer"},
+	{Name: "pagination.limit", Type: "integer"},
+	{Name: "pagination.count_total", Type: "boolean"},
+	{Name: "pagination.reverse", Type: "boolean"},
+}
+
+var pathVarPattern = regexp.MustCompile(`\{([^}:]+)(:[^}]*)?\}`)
+
+// openAPIPath strips gorilla regexps from a route template and returns the
+// path parameters in order.
+func openAPIPath(template string) (string, []string) {
+	var params []string
+	path := pathVarPattern.ReplaceAllStringFunc(template, func(match string) string {
+		name := pathVarPattern.FindStringSubmatch(match)[1]
+		params = append(params, name)
+		return "{" + name + "}"
+	})
+	return path, params
+}
+
+// openAPITag groups operations by module: cosmos.tx, persona.did, billing,
+// admin and so on.
+func openAPITag(path string) string {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	switch {
+	case segments[0] == "admin":
+		return "admin"
+	case (segments[0] == "cosmos" || segments[0] == "persona") && len(segments) > 1:
+		return segments[0] + "." + segments[1]
+	case segments[0] == "api" && len(segments) > 1 && strings.ToLower(segments[1]) == segments[1]:
+		return segments[1]
+	case segments[0] == "api":
+		return "api"
+	}
+	return "node"
+}
+
+// describeHandler derives an operation ID and summary from the handler's
+// function name, so handleGetDIDByController becomes getDIDByController,
+// "Get DID by controller".
+func describeHandler(handler http.Handler, method, path string) (string, string) {
+	name := ""
+	if fn := reflect.ValueOf(handler); fn.Kind() == reflect.Func {
+		name = runtime.FuncForPC(fn.Pointer()).Name()
+		name = strings.TrimSuffix(name[strings.LastIndex(name, ".")+1:], "-fm")
+	}
+	if !strings.HasPrefix(name, "handle") || len(name) == len("handle") {
+		// Closures have no useful name, fall back to the path
+		words := strings.FieldsFunc(path, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		})
+		operationID := strings.ToLower(method)
+		for _, word := range words {
+			operationID += strings.ToUpper(word[:1]) + word[1:]
+		}
+		return operationID, method + " " + path
+	}
+	name = strings.TrimPrefix(name, "handle")
+
+	words := splitCamel(name)
+	for i, word := range words {
+		if i > 0 && !isAcronym(word) {
+			words[i] = strings.ToLower(word)
+		}
+	}
+	operationID := strings.ToLower(words[0][:1]) + name[1:]
+	if isAcronym(words[0]) {
+		operationID = strings.ToLower(words[0]) + name[len(words[0]):]
+	}
+	return operationID, strings.Join(words, " ")
+}
+
+// splitCamel splits GetDIDByController into Get, DID, By, Controller,
+// keeping a plural s with its acronym (ListVCs is List, VCs).
+func splitCamel(s string) []string {
+	runes := []rune(s)
+	var words []string
+	start := 0
+	for i := 1; i < len(runes); i++ {
+		prevUpper := unicode.IsUpper(runes[i-1])
+		if !unicode.IsUpper(runes[i]) {
+			continue
+		}
+		nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+		pluralAcronym := nextLower && runes[i+1] == 's' && (i+2 == len(runes) || unicode.IsUpper(runes[i+2]))
+		if !prevUpper || (nextLower && !pluralAcronym) {
+			words = append(words, string(runes[start:i]))
+			start = i
+		}
+	}
+	return append(words, string(runes[start:]))
+}
+
+func isAcronym(word string) bool {
+	upper := 0
+	for _, r := range word {
+		if unicode.IsUpper(r) {
+			upper++
+		}
+	}
+	return upper > 1
+}
+
+// openAPISchemas reflects the component schemas, plus one per registered
+// message type keyed by its @type URL.
+func (c *Chain) openAPISchemas() map[string]interface{} {
+	schemas := map[string]interface{}{
+		"PageResponse": objectOf(map[string]interface{}{
+			"next_key": map[string]interface{}{"type": "string", "format": "byte", "nullable": true},
+			"total":    map[string]interface{}{"type": "string"},
+		}),
+		"GRPCError": objectOf(map[string]interface{}{
+			"code":    map[string]interface{}{"type": "integer"},
+			"message": map[string]interface{}{"type": "string"},
+			"details": arrayOf(anyObject),
+		}),
+	}
+	for name, t := range openAPISchemaTypes {
+		schemas[name] = schemaOf(t)
+	}
+
+	typeURLs := c.msgs.TypeURLs()
+	messages := make([]interface{}, 0, len(typeURLs))
+	mapping := make(map[string]string, len(typeURLs))
+	for _, typeURL := range typeURLs {
+		name := strings.TrimPrefix(typeURL, "/")
+		schema := schemaOf(c.msgs.GoType(typeURL))
+		schema["properties"].(map[string]interface{})["@type"] = map[string]interface{}{"type": "string", "enum": []string{typeURL}}
+		required, _ := schema["required"].([]string)
+		schema["required"] = append([]string{"@type"}, required...)
+		schemas[name] = schema
+		messages = append(messages, ref(name))
+		mapping[typeURL] = "#/components/schemas/" + name
+	}
+	schemas["Msg"] = map[string]interface{}{
+		"oneOf":         messages,
+		"discriminator": map[string]interface{}{"propertyName": "@type", "mapping": mapping},
+	}
+	schemas["BroadcastTxRequest"] = objectOf(map[string]interface{}{
+		"tx": objectOf(map[string]interface{}{
+			"body": objectOf(map[string]interface{}{"messages": arrayOf(ref("Msg"))}),
+			"auth_info": objectOf(map[string]interface{}{
+				"signer_infos": arrayOf(objectOf(map[string]interface{}{
+					"public_key": objectOf(map[string]interface{}{
+						"@type": map[string]interface{}{"type": "string", "enum": []string{secp256k1PubKeyType}},
+						"key":   map[string]interface{}{"type": "string", "format": "byte"},
+					}),
+					"sequence": map[string]interface{}{"type": "string"},
+				})),
+				"fee": anyObject,
+			}),
+			"signatures": arrayOf(map[string]interface{}{"type": "string", "format": "byte"}),
+		}),
+		"msgs":     arrayOf(ref("Msg")),
+		"tx_bytes": map[string]interface{}{"type": "string", "format": "byte"},
+		"mode":     map[string]interface{}{"type": "string", "enum": []string{"BROADCAST_MODE_SYNC", "BROADCAST_MODE_ASYNC", "BROADCAST_MODE_BLOCK"}},
+	})
+	return schemas
+}
+
+var (
+	timeType    = reflect.TypeOf(time.Time{})
+	rawJSONType = reflect.TypeOf(json.RawMessage{})
+)
+
+// schemaOf reflects a JSON schema from a Go type, following encoding/json's
+// field naming. Fields without omitempty are required.
+func schemaOf(t reflect.Type) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == timeType {
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	}
+	if t == rawJSONType {
+		return map[string]interface{}{}
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Int64, reflect.Uint, reflect.Uint64:
+		return map[string]interface{}{"type": "integer", "format": "int64"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string", "format": "byte"}
+		}
+		return arrayOf(schemaOf(t.Elem()))
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": schemaOf(t.Elem())}
+	case reflect.Interface:
+		return map[string]interface{}{}
+	case reflect.Struct:
+		properties := make(map[string]interface{})
+		required := []string{}
+		addStructFields(t, properties, &required)
+		schema := map[string]interface{}{"type": "object", "properties": properties}
+		// OpenAPI 3.0 does not allow an empty required list
+		if len(required) > 0 {
+			sort.Strings(required)
+			schema["required"] = required
+		}
+		return schema
+	}
+	return map[string]interface{}{}
+}
+
+func addStructFields(t reflect.Type, properties map[string]interface{}, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" || (!field.IsExported() && !field.Anonymous) {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			addStructFields(field.Type, properties, required)
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		properties[name] = schemaOf(field.Type)
+		if !strings.Contains(options, "omitempty") {
+			*required = append(*required, name)
+		}
+	}
+}
+
+// requestBaseURL is the URL clients reached the server at, behind proxies
+// too.
+func requestBaseURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if proto := r.Header.Get("X-Forwarded-Proto"); proto != "" {
+		scheme = proto
+	}
+	// Keep the port, which requestHost drops for domain matching
+	host := r.Host
+	if forwarded := r.Header.Get("X-Forwarded-Host"); forwarded != "" {
+		host = strings.TrimSpace(strings.Split(forwarded, ",")[0])
+	}
+	return scheme + "://" + host
+}
+
+// Handler for GET /openapi.json
+func (c *Chain) openAPIHandler(router *mux.Router) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		spec, err := c.buildOpenAPISpec(router, requestBaseURL(r))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(spec)
+	}
+}
+
+const swaggerUIPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>Persona mock testnet API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+  <script>
+    window.ui = SwaggerUIBundle({
+      url: "/openapi.json",
+      dom_id: "#swagger-ui",
+      deepLinking: true,
+      persistAuthorization: true
+    });
+  </script>
+</body>
+</html>
+`
+
+// Handler for GET /docs
+func handleSwaggerUI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fmt.Fprint(w, swaggerUIPage)
+}
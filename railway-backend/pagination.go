@@ -0,0 +1,153 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+)
+
+// Cosmos-style pagination for list endpoints.
+// Supports pagination.key, pagination.offset, pagination.limit,
+// pagination.count_total and pagination.reverse query parameters.
+
+const defaultPageLimit = 100
+
+type pageRequest struct {
+	Key        string
+	Offset     int
+	Limit      int
+	CountTotal bool
+	Reverse    bool
+}
+
+// parsePageRequest reads the pagination.* query parameters. count_total
+// defaults to true so existing callers keep receiving a total.
+func parsePageRequest(r *http.Request) (pageRequest, error) {
+	q := r.URL.Query()
+	req := pageRequest{Limit: defaultPageLimit, CountTotal: true}
+
+	if key := q.Get("pagination.key"); key != "" {
+		decoded, err := base64.StdEncoding.DecodeString(key)
+		if err != nil {
+			return req, fmt.Errorf("invalid pagination.key: %v", err)
+		}
+		req.Key = string(decoded)
+	}
+	if offset := q.Get("pagination.offset"); offset != "" {
+		n, err := strconv.Atoi(offset)
+		if err != nil || n < 0 {
+			return req, fmt.Errorf("invalid pagination.offset: %s", offset)
+		}
+		req.Offset = n
+	}
+	if limit := q.Get("pagination.limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 0 {
+			return req, fmt.Errorf("invalid pagination.limit: %s", limit)
+		}
+		if n > 0 {
+			req.Limit = n
+		}
+	}
+	if countTotal := q.Get("pagination.count_total"); countTotal != "" {
+		b, err := strconv.ParseBool(countTotal)
+		if err != nil {
+			return req, fmt.Errorf("invalid pagination.count_total: %s", countTotal)
+		}
+		req.CountTotal = b
+	}
+	if reverse := q.Get("pagination.reverse"); reverse != "" {
+		b, err := strconv.ParseBool(reverse)
+		if err != nil {
+			return req, fmt.Errorf("invalid pagination.reverse: %s", reverse)
+		}
+		req.Reverse = b
+	}
+
+	if req.Key != "" && req.Offset > 0 {
+		return req, fmt.Errorf("invalid request, either offset or key is expected, got both")
+	}
+	return req, nil
+}
+
+// paginate sorts items by keyOf, applies the page request and returns the
+// page along with the Cosmos pagination response object. Items sharing a
+// key, or without one, keep their relative order; next_key tells them
+// apart, so it is never empty and only null once the last page is served.
+func paginate(items []map[string]interface{}, keyOf func(int, map[string]interface{}) string, req pageRequest) ([]map[string]interface{}, map[string]interface{}) {
+	type entry struct {
+		key  string
+		item map[string]interface{}
+	}
+	entries := make([]entry, len(items))
+	for i, item := range items {
+		entries[i] = entry{key: keyOf(i, item), item: item}
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].key < entries[j].key })
+	// Suffix each key with its position among equal keys; the NUL keeps
+	// the sort order ("a" before "ab")
+	previous, n := "", 0
+	for i := range entries {
+		if i > 0 && entries[i].key == previous {
+			n++
+		} else {
+			previous, n = entries[i].key, 0
+		}
+		entries[i].key = fmt.Sprintf("%s\x00%020d", entries[i].key, n)
+	}
+	if req.Reverse {
+		for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+			entries[i], entries[j] = entries[j], entries[i]
+		}
+	}
+
+	start := req.Offset
+	if req.Key != "" {
+		start = len(entries)
+		for i, e := range entries {
+			if (!req.Reverse && e.key >= req.Key) || (req.Reverse && e.key <= req.Key) {
+				start = i
+				break
+			}
+		}
+	}
+	if start > len(entries) {
+		start = len(entries)
+	}
+	end := start + req.Limit
+	if end > len(entries) {
+		end = len(entries)
+	}
+
+	page := make([]map[string]interface{}, 0, end-start)
+	for _, e := range entries[start:end] {
+		page = append(page, e.item)
+	}
+
+	var nextKey interface{}
+	if end < len(entries) {
+		nextKey = base64.StdEncoding.EncodeToString([]byte(entries[end].key))
+	}
+	total := "0"
+	if req.CountTotal {
+		total = fmt.Sprintf("%d", len(entries))
+	}
+
+	return page, map[string]interface{}{
+		"next_key": nextKey,
+		"total":    total,
+	}
+}
+
+// keyByID orders items by their "id" field.
+func keyByID(_ int, item map[string]interface{}) string {
+	id, _ := item["id"].(string)
+	return id
+}
+
+// keyByIndex keeps insertion order for items without a stable ID.
+func keyByIndex(i int, _ map[string]interface{}) string {
+	return fmt.Sprintf("%020d", i)
+}
@@ -0,0 +1,357 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"persona-backend/idgen"
+)
+
+// Payments module mock for pay-per-verification. A relying party deposits
+// test tokens into escrow, every completed verification deducts the
+// verification fee (VERIFICATION_FEE, default 1000 of the chain's denom,
+// 1000uprsn on the default network), and each deduction is kept as a
+// settlement entry for the settlement report.
+
+func init() {
+	RegisterModule(newPaymentsModule)
+}
+
+const defaultVerificationFee = "1000uprsn"
+
+type coin struct {
+	Denom  string `json:"denom"`
+	Amount string `json:"amount"`
+}
+
+var coinPattern = regexp.MustCompile(`^(\d+)([a-zA-Z][a-zA-Z0-9/:._-]*)$`)
+
+// parseCoin parses a coin string such as "1000uprsn".
+func parseCoin(s string) (coin, error) {
+	m := coinPattern.FindStringSubmatch(s)
+	if m == nil {
+		return coin{}, fmt.Errorf("invalid coin: %s", s)
+	}
+	return coin{Denom: m[2], Amount: m[1]}, nil
+}
+
+// amountOf returns the coin amount as an integer.
+func (c coin) amountOf() (int64, error) {
+	n, err := strconv.ParseInt(c.Amount, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid amount: %s", c.Amount)
+	}
+	return n, nil
+}
+
+func newCoin(denom string, amount int64) coin {
+	return coin{Denom: denom, Amount: strconv.FormatInt(amount, 10)}
+}
+
+type escrowAccount struct {
+	RelyingParty      string `json:"relying_party"`
+	Balance           int64  `json:"balance"`
+	TotalDeposited    int64  `json:"total_deposited"`
+	TotalWithdrawn    int64  `json:"total_withdrawn"`
+	TotalSpent        int64  `json:"total_spent"`
+	VerificationCount int    `json:"verification_count"`
+}
+
+type settlement struct {
+	ID             string `json:"id"`
+	RelyingParty   string `json:"relying_party"`
+	Subject        string `json:"subject"`
+	ProofID        string `json:"proof_id,omitempty"`
+	CredentialType string `json:"credential_type,omitempty"`
+	Fee            coin   `json:"fee"`
+	Height         int64  `json:"height"`
+	TxHash         string `json:"tx_hash"`
+	SettledAt      int64  `json:"settled_at"`
+}
+
+// paymentsStore holds escrow accounts and settlements.
+type paymentsStore struct {
+	Escrows     map[string]*escrowAccount `json:"escrows"`
+	Settlements []*settlement             `json:"settlements"`
+}
+
+func (s *paymentsStore) Reset() {
+	s.Escrows = make(map[string]*escrowAccount)
+	s.Settlements = []*settlement{}
+}
+
+func (s *paymentsStore) escrow(relyingParty string) *escrowAccount {
+	if e, ok := s.Escrows[relyingParty]; ok {
+		return e
+	}
+	e := &escrowAccount{RelyingParty: relyingParty}
+	s.Escrows[relyingParty] = e
+	return e
+}
+
+// paymentsModule implements the persona payments module.
+type paymentsModule struct {
+	chain *Chain
+	store *paymentsStore
+	fee   coin
+}
+
+func newPaymentsModule(c *Chain) Module {
+	fee, err := parseCoin(os.Getenv("VERIFICATION_FEE"))
+	if err != nil {
+		if os.Getenv("VERIFICATION_FEE") != "" {
+			log.Printf("Invalid VERIFICATION_FEE, using %s", defaultVerificationFee)
+		}
+		fee, _ = parseCoin(defaultVerificationFee)
+		fee.Denom = c.denom
+	}
+	store := &paymentsStore{}
+	store.Reset()
+	return &paymentsModule{chain: c, store: store, fee: fee}
+}
+
+func (m *paymentsModule) Name() string { return "payments" }
+
+func (m *paymentsModule) Store() ModuleStore { return m.store }
+
+func (m *paymentsModule) EventTypes() []string {
+	return []string{"payments.deposited", "payments.withdrawn", "payments.verification_settled"}
+}
+
+func (m *paymentsModule) RegisterMsgs(reg *MsgRegistry) {
+	registerMsg(reg, "/persona.payments.v1.MsgDeposit", m.handleMsgDeposit)
+	registerMsg(reg, "/persona.payments.v1.MsgWithdraw", m.handleMsgWithdraw)
+	registerMsg(reg, "/persona.payments.v1.MsgCompleteVerification", m.handleMsgCompleteVerification)
+}
+
+func (m *paymentsModule) RegisterRoutes(r *mux.Router) {
+	r.HandleFunc("/persona/payments/v1beta1/params", m.handleParams).Methods("GET", "OPTIONS")
+	r.HandleFunc("/persona/payments/v1beta1/escrow/{address}", m.handleGetEscrow).Methods("GET", "OPTIONS")
+	r.HandleFunc("/persona/payments/v1beta1/settlements/{address}", m.handleSettlementReport).Methods("GET", "OPTIONS")
+}
+
+func (m *paymentsModule) params() map[string]interface{} {
+	return map[string]interface{}{
+		"verification_fee": m.fee,
+	}
+}
+
+func (m *paymentsModule) ExportGenesis() interface{} {
+	escrows := []map[string]interface{}{}
+	for _, address := range sortedMapKeys(m.store.Escrows) {
+		escrows = append(escrows, m.escrowJSON(m.store.Escrows[address]))
+	}
+	return map[string]interface{}{
+		"params":      m.params(),
+		"escrows":     escrows,
+		"settlements": m.store.Settlements,
+	}
+}
+
+func (m *paymentsModule) escrowJSON(e *escrowAccount) map[string]interface{} {
+	return map[string]interface{}{
+		"relying_party":      e.RelyingParty,
+		"balance":            newCoin(m.fee.Denom, e.Balance),
+		"total_deposited":    newCoin(m.fee.Denom, e.TotalDeposited),
+		"total_withdrawn":    newCoin(m.fee.Denom, e.TotalWithdrawn),
+		"total_spent":        newCoin(m.fee.Denom, e.TotalSpent),
+		"verification_count": fmt.Sprintf("%d", e.VerificationCount),
+	}
+}
+
+// Handler for GET /persona/payments/v1beta1/params
+func (m *paymentsModule) handleParams(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"params": m.params()})
+}
+
+// Handler for GET /persona/payments/v1beta1/escrow/{address}
+func (m *paymentsModule) handleGetEscrow(w http.ResponseWriter, r *http.Request) {
+	address := mux.Vars(r)["address"]
+
+	m.chain.mu.RLock()
+	e, ok := m.store.Escrows[address]
+	if !ok {
+		e = &escrowAccount{RelyingParty: address}
+	}
+	response := map[string]interface{}{
+		"escrow": m.escrowJSON(e),
+	}
+	m.chain.mu.RUnlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// Handler for GET /persona/payments/v1beta1/settlements/{address}
+// Optional from/to (unix seconds, inclusive) bound the report period.
+func (m *paymentsModule) handleSettlementReport(w http.ResponseWriter, r *http.Request) {
+	address := mux.Vars(r)["address"]
+	q := r.URL.Query()
+
+	var from, to int64
+	for _, bound := range []struct {
+		name string
+		dst  *int64
+	}{{"from", &from}, {"to", &to}} {
+		if value := q.Get(bound.name); value != "" {
+			n, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				http.Error(w, fmt.Sprintf("invalid %s: %s", bound.name, value), http.StatusBadRequest)
+				return
+			}
+			*bound.dst = n
+		}
+	}
+	pageReq, err := parsePageRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	m.chain.mu.RLock()
+	items := []map[string]interface{}{}
+	var totalFees int64
+	for _, s := range m.store.Settlements {
+		if s.RelyingParty != address || (from > 0 && s.SettledAt < from) || (to > 0 && s.SettledAt > to) {
+			continue
+		}
+		fee, _ := s.Fee.amountOf()
+		totalFees += fee
+		data, _ := json.Marshal(s)
+		var item map[string]interface{}
+		json.Unmarshal(data, &item)
+		items = append(items, item)
+	}
+	m.chain.mu.RUnlock()
+
+	page, pagination := paginate(items, keyByID, pageReq)
+
+	response := map[string]interface{}{
+		"report": map[string]interface{}{
+			"relying_party":      address,
+			"from":               from,
+			"to":                 to,
+			"verification_count": fmt.Sprintf("%d", len(items)),
+			"total_fees":         newCoin(m.fee.Denom, totalFees),
+			"generated_at":       m.chain.now().Unix(),
+		},
+		"settlements": page,
+		"pagination":  pagination,
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+type MsgDeposit struct {
+	Creator string `json:"creator"`
+	Amount  coin   `json:"amount"`
+}
+
+func (m MsgDeposit) ValidateBasic() error {
+	if m.Creator == "" {
+		return errors.New("creator is required")
+	}
+	if n, err := m.Amount.amountOf(); err != nil || n == 0 {
+		return errors.New("amount must be positive")
+	}
+	return nil
+}
+
+func (m *paymentsModule) handleMsgDeposit(ctx *msgContext, msg MsgDeposit) *txError {
+	if msg.Amount.Denom != m.fee.Denom {
+		return txErrorf(codeInvalidRequest, "escrow only accepts %s, got %s", m.fee.Denom, msg.Amount.Denom)
+	}
+	amount, _ := msg.Amount.amountOf()
+
+	e := m.store.escrow(msg.Creator)
+	e.Balance += amount
+	e.TotalDeposited += amount
+	log.Printf("Escrow deposit of %d%s by %s", amount, msg.Amount.Denom, msg.Creator)
+	return nil
+}
+
+type MsgWithdraw struct {
+	Creator string `json:"creator"`
+	Amount  coin   `json:"amount"`
+}
+
+func (m MsgWithdraw) ValidateBasic() error {
+	if m.Creator == "" {
+		return errors.New("creator is required")
+	}
+	if n, err := m.Amount.amountOf(); err != nil || n == 0 {
+		return errors.New("amount must be positive")
+	}
+	return nil
+}
+
+func (m *paymentsModule) handleMsgWithdraw(ctx *msgContext, msg MsgWithdraw) *txError {
+	if msg.Amount.Denom != m.fee.Denom {
+		return txErrorf(codeInvalidRequest, "escrow only holds %s, got %s", m.fee.Denom, msg.Amount.Denom)
+	}
+	amount, _ := msg.Amount.amountOf()
+
+	e := m.store.escrow(msg.Creator)
+	if e.Balance < amount {
+		return txErrorf(codeInsufficientFunds, "%d%s is smaller than %d%s", e.Balance, m.fee.Denom, amount, m.fee.Denom)
+	}
+	e.Balance -= amount
+	e.TotalWithdrawn += amount
+	log.Printf("Escrow withdrawal of %d%s by %s", amount, msg.Amount.Denom, msg.Creator)
+	return nil
+}
+
+type MsgCompleteVerification struct {
+	Creator        string `json:"creator"`
+	Subject        string `json:"subject"`
+	ProofID        string `json:"proof_id"`
+	CredentialType string `json:"credential_type"`
+}
+
+func (m MsgCompleteVerification) ValidateBasic() error {
+	if m.Creator == "" {
+		return errors.New("creator is required")
+	}
+	if m.Subject == "" {
+		return errors.New("subject is required")
+	}
+	return nil
+}
+
+// handleMsgCompleteVerification charges the relying party (the signer) the
+// verification fee from its escrow and records the settlement.
+func (m *paymentsModule) handleMsgCompleteVerification(ctx *msgContext, msg MsgCompleteVerification) *txError {
+	fee, _ := m.fee.amountOf()
+
+	e := m.store.escrow(msg.Creator)
+	if e.Balance < fee {
+		return txErrorf(codeInsufficientFunds, "escrow balance %d%s is smaller than verification fee %s%s", e.Balance, m.fee.Denom, m.fee.Amount, m.fee.Denom)
+	}
+	e.Balance -= fee
+	e.TotalSpent += fee
+	e.VerificationCount++
+
+	s := &settlement{
+		ID:             idgen.NewWithPrefix("stl"),
+		RelyingParty:   msg.Creator,
+		Subject:        msg.Subject,
+		ProofID:        msg.ProofID,
+		CredentialType: msg.CredentialType,
+		Fee:            m.fee,
+		Height:         ctx.Height,
+		TxHash:         ctx.TxHash,
+		SettledAt:      m.chain.now().Unix(),
+	}
+	m.store.Settlements = append(m.store.Settlements, s)
+	log.Printf("Settled verification %s: %s charged %s%s", s.ID, msg.Creator, m.fee.Amount, m.fee.Denom)
+	return nil
+}
@@ -0,0 +1,301 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"regexp"
+	"sync"
+
+	"github.com/gorilla/mux"
+)
+
+// Terms of service and other policy documents, versioned per kind (terms,
+// privacy, ...), with the versions each DID accepted, for the onboarding
+// compliance gate. GET /api/dids/{did}/policies tells the frontend whether
+// to show the (re-)acceptance modal: a DID needs to accept a kind it never
+// accepted, or whose latest version requiring re-acceptance is newer than
+// the one it accepted. A version published with requires_reacceptance
+// false (a typo fix, say) becomes current without prompting anyone.
+//
+// Documents come from POLICIES (a JSON array) at startup, defaulting to
+// version 1 of terms and privacy, and are published through
+// /admin/policies. /admin/reset restores the startup documents and clears
+// acceptances.
+
+type policyDocument struct {
+	Kind    string `json:"kind"`
+	Version string `json:"version"`
+	Title   string `json:"title"`
+	URL     string `json:"url,omitempty"`
+	// RequiresReacceptance prompts DIDs that accepted an earlier version
+	RequiresReacceptance bool  `json:"requires_reacceptance"`
+	PublishedAt          int64 `json:"published_at"`
+}
+
+type policyAcceptance struct {
+	DID        string `json:"did"`
+	Kind       string `json:"kind"`
+	Version    string `json:"version"`
+	AcceptedAt int64  `json:"accepted_at"`
+	IP         string `json:"ip,omitempty"`
+}
+
+// policyStatus is a DID's standing on the current version of a kind.
+type policyStatus struct {
+	Kind            string            `json:"kind"`
+	Current         policyDocument    `json:"current"`
+	Accepted        *policyAcceptance `json:"accepted,omitempty"`
+	NeedsAcceptance bool              `json:"needs_acceptance"`
+}
+
+var policyKind = regexp.MustCompile(`^[a-z][a-z0-9_-]*$`)
+
+var defaultPolicies = []*policyDocument{
+	{Kind: "terms", Version: "1", Title: "Terms of Service", RequiresReacceptance: true},
+	{Kind: "privacy", Version: "1", Title: "Privacy Policy", RequiresReacceptance: true},
+}
+
+var (
+	policiesMu sync.Mutex
+	// Published versions by kind, oldest first
+	policies = make(map[string][]*policyDocument)
+	// Acceptances by DID, then kind, latest only
+	policyAcceptances = make(map[string]map[string]*policyAcceptance)
+	startupPolicies   = defaultPolicies
+)
+
+func init() {
+	if raw := os.Getenv("POLICIES"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &startupPolicies); err != nil {
+			log.Printf("Invalid POLICIES: %v", err)
+			startupPolicies = defaultPolicies
+		}
+	}
+	resetPolicies()
+	registerAdminState("policies", func() interface{} {
+		policiesMu.Lock()
+		defer policiesMu.Unlock()
+		acceptances := []policyAcceptance{}
+		for _, did := range sortedMapKeys(policyAcceptances) {
+			for _, kind := range sortedMapKeys(policyAcceptances[did]) {
+				acceptances = append(acceptances, *policyAcceptances[did][kind])
+			}
+		}
+		return map[string]interface{}{"documents": policyVersions(), "acceptances": acceptances}
+	}, resetPolicies)
+}
+
+func resetPolicies() {
+	policiesMu.Lock()
+	defer policiesMu.Unlock()
+	policies = make(map[string][]*policyDocument)
+	policyAcceptances = make(map[string]map[string]*policyAcceptance)
+	for _, p := range startupPolicies {
+		doc := *p
+		if err := publishPolicy(&doc); err != nil {
+			log.Printf("Skipping policy %s %s: %v", p.Kind, p.Version, err)
+		}
+	}
+}
+
+// publishPolicy validates and publishes a new version. Must be called with
+// policiesMu held.
+func publishPolicy(doc *policyDocument) error {
+	if !policyKind.MatchString(doc.Kind) {
+		return fmt.Errorf("kind must be lowercase letters, digits, - or _")
+	}
+	if doc.Version == "" {
+		return fmt.Errorf("version is required")
+	}
+	if doc.Title == "" {
+		return fmt.Errorf("title is required")
+	}
+	for _, existing := range policies[doc.Kind] {
+		if existing.Version == doc.Version {
+			return fmt.Errorf("%s version %s is already published", doc.Kind, doc.Version)
+		}
+	}
+	if len(policies[doc.Kind]) == 0 {
+		// Nobody has accepted anything yet
+		doc.RequiresReacceptance = true
+	}
+	doc.PublishedAt = appClock.Now().Unix()
+	policies[doc.Kind] = append(policies[doc.Kind], doc)
+	return nil
+}
+
+// policyVersions lists every published version. Must be called with
+// policiesMu held.
+func policyVersions() []policyDocument {
+	docs := []policyDocument{}
+	for _, kind := range sortedMapKeys(policies) {
+		for _, doc := range policies[kind] {
+			docs = append(docs, *doc)
+		}
+	}
+	return docs
+}
+
+// policyStatuses returns a DID's standing on every kind. Must be called
+// with policiesMu held.
+func policyStatuses(did string) ([]policyStatus, bool) {
+	statuses := []policyStatus{}
+	needsAcceptance := false
+	for _, kind := range sortedMapKeys(policies) {
+		versions := policies[kind]
+		status := policyStatus{Kind: kind, Current: *versions[len(versions)-1], NeedsAcceptance: true}
+		if accepted := policyAcceptances[did][kind]; accepted != nil {
+			copied := *accepted
+			status.Accepted = &copied
+			// Accepted unless a later version requires re-acceptance
+			status.NeedsAcceptance = false
+			seen := false
+			for _, doc := range versions {
+				if seen && doc.RequiresReacceptance {
+					status.NeedsAcceptance = true
+				}
+				seen = seen || doc.Version == accepted.Version
+			}
+		}
+		needsAcceptance = needsAcceptance || status.NeedsAcceptance
+		statuses = append(statuses, status)
+	}
+	return statuses, needsAcceptance
+}
+
+// Handler for GET /api/policies. Lists the current version of every kind.
+func handleListPolicies(w http.ResponseWriter, r *http.Request) {
+	policiesMu.Lock()
+	current := []policyDocument{}
+	for _, kind := range sortedMapKeys(policies) {
+		versions := policies[kind]
+		current = append(current, *versions[len(versions)-1])
+	}
+	policiesMu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"policies": current})
+}
+
+// Handler for GET /api/dids/{did}/policies
+func handleGetPolicyStatus(w http.ResponseWriter, r *http.Request) {
+	did := mux.Vars(r)["did"]
+
+	policiesMu.Lock()
+	statuses, needsAcceptance := policyStatuses(did)
+	policiesMu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"did":              did,
+		"policies":         statuses,
+		"needs_acceptance": needsAcceptance,
+	})
+}
+
+// Handler for POST /api/dids/{did}/policies/accept. Takes
+// {"kind", "version"} or {"acceptances": [...]} for several kinds; only
+// current versions can be accepted.
+func handleAcceptPolicies(w http.ResponseWriter, r *http.Request) {
+	type acceptance struct {
+		Kind    string `json:"kind"`
+		Version string `json:"version"`
+	}
+	var req struct {
+		acceptance
+		Acceptances []acceptance `json:"acceptances"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+	if req.Kind != "" {
+		req.Acceptances = append(req.Acceptances, req.acceptance)
+	}
+	if len(req.Acceptances) == 0 {
+		http.Error(w, "kind and version are required", http.StatusBadRequest)
+		return
+	}
+	did := mux.Vars(r)["did"]
+	ip := ""
+	if clientIP := clientIP(r); clientIP != nil {
+		ip = clientIP.String()
+	}
+
+	policiesMu.Lock()
+	defer policiesMu.Unlock()
+	for _, a := range req.Acceptances {
+		versions := policies[a.Kind]
+		if len(versions) == 0 {
+			http.Error(w, "Unknown policy "+a.Kind, http.StatusNotFound)
+			return
+		}
+		if current := versions[len(versions)-1]; a.Version != current.Version {
+			http.Error(w, fmt.Sprintf("%s version %s is not current, accept version %s", a.Kind, a.Version, current.Version), http.StatusConflict)
+			return
+		}
+	}
+	now := appClock.Now().Unix()
+	if policyAcceptances[did] == nil {
+		policyAcceptances[did] = make(map[string]*policyAcceptance)
+	}
+	for _, a := range req.Acceptances {
+		policyAcceptances[did][a.Kind] = &policyAcceptance{DID: did, Kind: a.Kind, Version: a.Version, AcceptedAt: now, IP: ip}
+		log.Printf("%s accepted %s version %s", did, a.Kind, a.Version)
+	}
+	statuses, needsAcceptance := policyStatuses(did)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"did":              did,
+		"policies":         statuses,
+		"needs_acceptance": needsAcceptance,
+	})
+}
+
+// Handler for GET /admin/policies. Lists every version with the number of
+// DIDs whose latest acceptance is that version.
+func handleListPolicyVersions(w http.ResponseWriter, r *http.Request) {
+	policiesMu.Lock()
+	versions := policyVersions()
+	counts := make(map[string]int)
+	for _, byKind := range policyAcceptances {
+		for kind, a := range byKind {
+			counts[kind+"@"+a.Version]++
+		}
+	}
+	policiesMu.Unlock()
+
+	entries := make([]map[string]interface{}, len(versions))
+	for i, doc := range versions {
+		entries[i] = map[string]interface{}{"document": doc, "acceptances": counts[doc.Kind+"@"+doc.Version]}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"versions": entries})
+}
+
+// Handler for POST /admin/policies. Publishes a new version, which becomes
+// the kind's current version.
+func handlePublishPolicy(w http.ResponseWriter, r *http.Request) {
+	var doc policyDocument
+	if err := json.NewDecoder(r.Body).Decode(&doc); err != nil {
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	policiesMu.Lock()
+	err := publishPolicy(&doc)
+	policiesMu.Unlock()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	log.Printf("Published %s version %s (re-acceptance: %v)", doc.Kind, doc.Version, doc.RequiresReacceptance)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{"policy": doc})
+}
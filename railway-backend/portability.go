@@ -0,0 +1,331 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/gorilla/mux"
+)
+
+// Cross-network credential portability: POST /api/networks/portability
+// checks whether a credential issued on one simulated network (see
+// networks.go) would be accepted on another, for the frontend's migration
+// wizard. The body is
+//
+//	{"from": "persona-testnet-1", "to": "persona-devnet-1", "credential_id": "..."}
+//
+// or an inline "credential" in place of credential_id. The report lists
+// each check as passed or failed; "must" failures make the credential not
+// portable, "should" failures are warnings.
+//
+// Acceptance follows the target network's trust policy: the networks whose
+// issuers it trusts, individually trusted issuer DIDs and the credential
+// types it accepts. Policies come from NETWORK_TRUST (a JSON array) at
+// startup and are managed through /admin/networks/{chain_id}/trust;
+// /admin/reset restores the startup policies. A network without a policy
+// trusts every network.
+
+type trustPolicy struct {
+	ChainID         string   `json:"chain_id"`
+	TrustedNetworks []string `json:"trusted_networks"`
+	TrustedIssuers  []string `json:"trusted_issuers,omitempty"`
+	// AcceptedTypes limits the credential types accepted; empty accepts any
+	AcceptedTypes []string `json:"accepted_types,omitempty"`
+	UpdatedAt     int64    `json:"updated_at"`
+}
+
+type portabilityCheck struct {
+	ID     string `json:"id"`
+	Level  string `json:"level"`
+	Passed bool   `json:"passed"`
+	Detail string `json:"detail,omitempty"`
+}
+
+var (
+	trustPoliciesMu      sync.Mutex
+	trustPolicies        = make(map[string]*trustPolicy)
+	startupTrustPolicies []*trustPolicy
+)
+
+func init() {
+	if raw := os.Getenv("NETWORK_TRUST"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &startupTrustPolicies); err != nil {
+			log.Printf("Invalid NETWORK_TRUST: %v", err)
+			startupTrustPolicies = nil
+		}
+	}
+	resetTrustPolicies()
+	registerAdminState("network_trust", func() interface{} {
+		return listTrustPolicies()
+	}, resetTrustPolicies)
+}
+
+func resetTrustPolicies() {
+	trustPoliciesMu.Lock()
+	defer trustPoliciesMu.Unlock()
+	trustPolicies = make(map[string]*trustPolicy)
+	for _, p := range startupTrustPolicies {
+		if p.ChainID == "" {
+			log.Printf("Skipping NETWORK_TRUST policy without chain_id")
+			continue
+		}
+		policy := *p
+		policy.UpdatedAt = appClock.Now().Unix()
+		trustPolicies[p.ChainID] = &policy
+	}
+}
+
+func listTrustPolicies() []trustPolicy {
+	trustPoliciesMu.Lock()
+	defer trustPoliciesMu.Unlock()
+	policies := make([]trustPolicy, 0, len(trustPolicies))
+	for _, chainID := range sortedMapKeys(trustPolicies) {
+		policies = append(policies, *trustPolicies[chainID])
+	}
+	return policies
+}
+
+// lookupTrustPolicy returns a copy of a network's trust policy.
+func lookupTrustPolicy(chainID string) (trustPolicy, bool) {
+	trustPoliciesMu.Lock()
+	defer trustPoliciesMu.Unlock()
+	p, ok := trustPolicies[chainID]
+	if !ok {
+		return trustPolicy{}, false
+	}
+	return *p, true
+}
+
+// didResolvableOn reports whether did resolves on chain: did:persona DIDs
+// must be stored and active there, other supported methods resolve
+// anywhere.
+func didResolvableOn(c *Chain, did string) (bool, string) {
+	method, valid := didMethod(did)
+	switch {
+	case !valid:
+		return false, did + " is not a valid DID"
+	case method != "persona":
+		if didMethodResolvers[method] == nil {
+			return false, "DID method " + method + " is not supported"
+		}
+		return true, "did:" + method + " resolves independently of the network"
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	stored := c.did().store.Documents[did]
+	switch {
+	case stored == nil:
+		return false, did + " is not anchored on " + c.ChainID()
+	case stored["is_active"] == false:
+		return false, did + " is deactivated on " + c.ChainID()
+	}
+	return true, did + " is active on " + c.ChainID()
+}
+
+// checkPortability evaluates credential, issued on from, against to.
+// revoked is the credential's status on from.
+func checkPortability(from, to *Chain, credential map[string]interface{}, revoked bool) []portabilityCheck {
+	var checks []portabilityCheck
+	check := func(id, level string, passed bool, format string, args ...interface{}) {
+		checks = append(checks, portabilityCheck{ID: id, Level: level, Passed: passed, Detail: fmt.Sprintf(format, args...)})
+	}
+
+	if revoked {
+		check("status", "must", false, "credential is revoked on %s", from.ChainID())
+	} else {
+		check("status", "must", true, "credential is active on %s", from.ChainID())
+	}
+
+	if violations := validateVCDataModel(credential, vcValidation == "strict"); len(violations) > 0 {
+		check("format", "must", false, "%s", strings.Join(violations, "; "))
+	} else {
+		check("format", "must", true, "credential follows the VC data model")
+	}
+
+	to.mu.RLock()
+	violations := to.vc().checkSchemas(credential)
+	credentialID, _ := credential["id"].(string)
+	_, existing := to.vc().findCredential(credentialID)
+	to.mu.RUnlock()
+	if len(violations) > 0 {
+		check("schema", "must", false, "%s", strings.Join(violations, "; "))
+	} else {
+		check("schema", "must", true, "credentialSubject matches the schemas registered on %s", to.ChainID())
+	}
+
+	issuer, _ := credentialIssuer(credential).(string)
+	policy, hasPolicy := lookupTrustPolicy(to.ChainID())
+	switch {
+	case issuer == "":
+		check("issuer_trusted", "must", false, "credential has no issuer")
+	case from == to:
+		check("issuer_trusted", "must", true, "same network")
+	case !hasPolicy:
+		check("issuer_trusted", "must", true, "%s has no trust policy and trusts every network", to.ChainID())
+	case containsString(policy.TrustedIssuers, issuer):
+		check("issuer_trusted", "must", true, "%s trusts issuer %s", to.ChainID(), issuer)
+	case containsString(policy.TrustedNetworks, from.ChainID()):
+		check("issuer_trusted", "must", true, "%s trusts issuers of %s", to.ChainID(), from.ChainID())
+	default:
+		check("issuer_trusted", "must", false, "%s trusts neither %s nor issuer %s", to.ChainID(), from.ChainID(), issuer)
+	}
+
+	if hasPolicy && len(policy.AcceptedTypes) > 0 {
+		var rejected []string
+		for _, credentialType := range credentialTypes(credential) {
+			if credentialType != "VerifiableCredential" && !containsString(policy.AcceptedTypes, credentialType) {
+				rejected = append(rejected, credentialType)
+			}
+		}
+		if len(rejected) > 0 {
+			check("credential_type", "must", false, "%s does not accept %s", to.ChainID(), strings.Join(rejected, ", "))
+		} else {
+			check("credential_type", "must", true, "%s accepts the credential's types", to.ChainID())
+		}
+	}
+
+	if issuer != "" {
+		resolvable, detail := didResolvableOn(to, issuer)
+		check("issuer_resolvable", "must", resolvable, "%s", detail)
+	}
+	if subject, ok := credential["credentialSubject"].(map[string]interface{}); ok {
+		if id, _ := subject["id"].(string); id != "" {
+			resolvable, detail := didResolvableOn(to, id)
+			check("subject_resolvable", "should", resolvable, "%s", detail)
+		}
+	}
+	if credentialID != "" {
+		if existing != nil {
+			check("not_migrated", "should", false, "%s already holds credential %s", to.ChainID(), credentialID)
+		} else {
+			check("not_migrated", "should", true, "%s does not hold the credential yet", to.ChainID())
+		}
+	}
+	return checks
+}
+
+// Handler for POST /api/networks/portability
+func handleCheckPortability(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		From         string                 `json:"from"`
+		To           string                 `json:"to"`
+		CredentialID string                 `json:"credential_id"`
+		Credential   map[string]interface{} `json:"credential"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+	if req.From == "" {
+		req.From = defaultChain.ChainID()
+	}
+	if req.To == "" {
+		http.Error(w, "to is required", http.StatusBadRequest)
+		return
+	}
+	if (req.CredentialID == "") == (req.Credential == nil) {
+		http.Error(w, "Either credential_id or credential is required", http.StatusBadRequest)
+		return
+	}
+	from, to := findNetwork(req.From), findNetwork(req.To)
+	for chainID, n := range map[string]*network{req.From: from, req.To: to} {
+		if n == nil {
+			http.Error(w, "Unknown network "+chainID, http.StatusNotFound)
+			return
+		}
+	}
+
+	credential, revoked := req.Credential, false
+	if req.CredentialID != "" {
+		from.chain.mu.RLock()
+		_, stored := from.chain.vc().findCredential(req.CredentialID)
+		if stored != nil {
+			// Copy so the checks run without the source chain's lock
+			data, _ := json.Marshal(stored)
+			json.Unmarshal(data, &credential)
+			revoked = stored["is_revoked"] == true
+		}
+		from.chain.mu.RUnlock()
+		if stored == nil {
+			http.Error(w, fmt.Sprintf("Credential %s not found on %s", req.CredentialID, req.From), http.StatusNotFound)
+			return
+		}
+	}
+
+	checks := checkPortability(from.chain, to.chain, credential, revoked)
+	portable := true
+	for _, c := range checks {
+		if c.Level == "must" && !c.Passed {
+			portable = false
+		}
+	}
+	credentialID, _ := credential["id"].(string)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"from":          req.From,
+		"to":            req.To,
+		"credential_id": credentialID,
+		"portable":      portable,
+		"checks":        checks,
+		"checked_at":    to.chain.now().Unix(),
+	})
+}
+
+// Handler for GET /admin/networks/trust
+func handleListTrustPolicies(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"policies": listTrustPolicies()})
+}
+
+// Handler for PUT /admin/networks/{chain_id}/trust
+func handlePutTrustPolicy(w http.ResponseWriter, r *http.Request) {
+	var p trustPolicy
+	if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+	p.ChainID = mux.Vars(r)["chain_id"]
+	if findNetwork(p.ChainID) == nil {
+		http.Error(w, "Unknown network "+p.ChainID, http.StatusNotFound)
+		return
+	}
+	if p.TrustedNetworks == nil {
+		p.TrustedNetworks = []string{}
+	}
+	p.UpdatedAt = appClock.Now().Unix()
+
+	trustPoliciesMu.Lock()
+	_, existed := trustPolicies[p.ChainID]
+	trustPolicies[p.ChainID] = &p
+	trustPoliciesMu.Unlock()
+
+	log.Printf("Trust policy for %s updated", p.ChainID)
+	w.Header().Set("Content-Type", "application/json")
+	if !existed {
+		w.WriteHeader(http.StatusCreated)
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{"policy": p})
+}
+
+// Handler for DELETE /admin/networks/{chain_id}/trust
+func handleDeleteTrustPolicy(w http.ResponseWriter, r *http.Request) {
+	chainID := mux.Vars(r)["chain_id"]
+
+	trustPoliciesMu.Lock()
+	_, ok := trustPolicies[chainID]
+	delete(trustPolicies, chainID)
+	trustPoliciesMu.Unlock()
+
+	if !ok {
+		http.Error(w, "Trust policy not found", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"removed": 1})
+}
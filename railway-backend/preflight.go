@@ -0,0 +1,303 @@
+package main
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// E2E preflight: GET /api/preflight checks, in one call, that every
+// subsystem a test suite depends on works, so a Playwright run can fail
+// fast with a clear reason instead of timing out halfway. ?suite= picks a
+// predefined set of checks and ?checks= lists them explicitly. Storage and
+// the block producer are checked on the live daemon; issuance,
+// verification and events run canonical operations on a scratch chain, as
+// the compat selftest does. Responds 200 when every check passes, 503
+// otherwise.
+
+const preflightTimeout = 2 * time.Second
+
+var preflightSuites = map[string][]string{
+	"all":          {"storage", "block_producer", "issuance", "verification", "groth16", "events", "contract"},
+	"issuance":     {"storage", "block_producer", "issuance", "events"},
+	"verification": {"block_producer", "issuance", "verification", "groth16", "events"},
+	"explorer":     {"storage", "block_producer", "events"},
+}
+
+type preflightResult struct {
+	Name       string `json:"name"`
+	Status     string `json:"status"` // pass or fail
+	DurationMs int64  `json:"duration_ms"`
+	Detail     string `json:"detail,omitempty"`
+}
+
+var preflightChecks = map[string]func(c *Chain) (string, error){
+	"storage":        preflightStorage,
+	"block_producer": preflightBlockProducer,
+	"issuance":       preflightIssuance,
+	"verification":   preflightVerification,
+	"groth16":        preflightGroth16,
+	"events":         preflightEvents,
+	"contract":       preflightContract,
+}
+
+// preflightStorage checks the chain state lock can be taken, then round
+// trips an object through S3 when object storage is configured.
+func preflightStorage(c *Chain) (string, error) {
+	locked := make(chan struct{})
+	go func() {
+		c.mu.RLock()
+		c.mu.RUnlock()
+		close(locked)
+	}()
+	select {
+	case <-locked:
+	case <-time.After(preflightTimeout):
+		return "", fmt.Errorf("chain state lock not acquired within %s", preflightTimeout)
+	}
+
+	if objectStore == nil {
+		return "in-memory state ok; object storage not configured", nil
+	}
+	key := "preflight/" + fmt.Sprintf("%d", time.Now().UnixNano())
+	payload := []byte("preflight")
+	if err := objectStore.Put(key, payload, "text/plain"); err != nil {
+		return "", fmt.Errorf("object storage put: %v", err)
+	}
+	defer objectStore.Delete(key)
+	data, err := objectStore.Get(key)
+	if err != nil {
+		return "", fmt.Errorf("object storage get: %v", err)
+	}
+	if !bytes.Equal(data, payload) {
+		return "", fmt.Errorf("object storage returned different content")
+	}
+	return "in-memory state and bucket " + objectStore.Bucket() + " ok", nil
+}
+
+// preflightBlockProducer checks a block was produced recently.
+func preflightBlockProducer(c *Chain) (string, error) {
+	c.mu.RLock()
+	height, lastBlockAt := c.info.LatestHeight, c.lastBlockAt
+	c.mu.RUnlock()
+
+	if lastBlockAt.IsZero() {
+		return "", fmt.Errorf("block producer not started")
+	}
+	// Allow a missed tick before calling it stalled
+	since := time.Since(lastBlockAt)
+	if since > 2*c.blockTime+time.Second {
+		return "", fmt.Errorf("no block for %s (block time %s), stalled at height %d", since.Round(time.Millisecond), c.blockTime, height)
+	}
+	return fmt.Sprintf("height %d, last block %s ago", height, since.Round(time.Millisecond)), nil
+}
+
+func preflightIssuance(c *Chain) (string, error) {
+	cr := c.newScratchRunner()
+	if _, err := cr.broadcast(map[string]interface{}{
+		"@type":        "/persona.did.v1.MsgCreateDid",
+		"creator":      compatAddress,
+		"did_document": map[string]interface{}{"id": compatDID, "controller": compatAddress},
+	}); err != nil {
+		return "", fmt.Errorf("create DID: %v", err)
+	}
+	if _, err := cr.broadcast(map[string]interface{}{
+		"@type":   "/persona.vc.v1.MsgIssueCredential",
+		"creator": compatAddress,
+		"vc_data": w3cCredential(compatVC, compatDID, nil, map[string]interface{}{"id": compatDID}, cr.chain.now()),
+	}); err != nil {
+		return "", fmt.Errorf("issue credential: %v", err)
+	}
+	resp, err := cr.get("/persona/vc/v1beta1/credentials/" + compatVC + "/status")
+	if err != nil {
+		return "", fmt.Errorf("credential status: %v", err)
+	}
+	if resp["status"] != "active" {
+		return "", fmt.Errorf("issued credential has status %v", resp["status"])
+	}
+	return "DID created, credential issued and active", nil
+}
+
+func preflightVerification(c *Chain) (string, error) {
+	cr := c.newScratchRunner()
+	if _, err := cr.broadcast(map[string]interface{}{
+		"@type":      "/persona.zk.v1.MsgSubmitProof",
+		"creator":    compatAddress,
+		"circuit_id": "circuit_001",
+		"proof":      "preflight",
+	}); err != nil {
+		return "", fmt.Errorf("submit proof: %v", err)
+	}
+	resp, err := cr.get("/persona/zk/v1beta1/proofs_by_controller/" + compatAddress)
+	if err != nil {
+		return "", fmt.Errorf("query proofs: %v", err)
+	}
+	proofs, _ := resp["zk_proofs"].([]interface{})
+	if len(proofs) != 1 {
+		return "", fmt.Errorf("submitted proof not returned")
+	}
+	if proof, _ := proofs[0].(map[string]interface{}); proof["is_verified"] != true {
+		return "", fmt.Errorf("submitted proof is not verified")
+	}
+	return "proof submitted and verified", nil
+}
+
+// snarkjs fixtures for x^3 + x + 5 = out with out = 35: a verifying key,
+// a proof of x = 3 and the same proof with pi_c moved
+var (
+	//go:embed testdata/groth16/verification_key.json
+	groth16FixtureKey []byte
+	//go:embed testdata/groth16/proof.json
+	groth16FixtureProof string
+	//go:embed testdata/groth16/proof_tampered.json
+	groth16FixtureTampered string
+	//go:embed testdata/groth16/public.json
+	groth16FixturePublic []byte
+)
+
+// preflightGroth16 checks the Groth16 verifier accepts the fixture proof
+// and rejects the tampered one, whether or not VERIFY_PROOFS is on.
+func preflightGroth16(c *Chain) (string, error) {
+	vk, err := parseVerifyingKey(groth16FixtureKey)
+	if err != nil {
+		return "", fmt.Errorf("fixture verifying key: %v", err)
+	}
+	var public interface{}
+	json.Unmarshal(groth16FixturePublic, &public)
+	inputs, err := publicSignals(public)
+	if err != nil {
+		return "", fmt.Errorf("fixture public inputs: %v", err)
+	}
+	proof, err := decodeProofData(groth16FixtureProof)
+	if err != nil {
+		return "", fmt.Errorf("fixture proof: %v", err)
+	}
+	if err := vk.verify(proof, inputs); err != nil {
+		return "", fmt.Errorf("valid proof rejected: %v", err)
+	}
+	tampered, err := decodeProofData(groth16FixtureTampered)
+	if err != nil {
+		return "", fmt.Errorf("fixture tampered proof: %v", err)
+	}
+	if vk.verify(tampered, inputs) == nil {
+		return "", fmt.Errorf("tampered proof accepted")
+	}
+	return "valid proof accepted, tampered proof rejected", nil
+}
+
+// preflightEvents checks Tx and NewBlock events reach a subscriber.
+func preflightEvents(c *Chain) (string, error) {
+	cr := c.newScratchRunner()
+	events, unsubscribe := cr.chain.subscribeEvents()
+	defer unsubscribe()
+
+	if _, err := cr.broadcast(map[string]interface{}{
+		"@type":      "/persona.zk.v1.MsgSubmitProof",
+		"creator":    compatAddress,
+		"circuit_id": "circuit_001",
+		"proof":      "preflight",
+	}); err != nil {
+		return "", fmt.Errorf("broadcast: %v", err)
+	}
+	cr.chain.produceBlock()
+
+	seen := map[string]bool{}
+	deadline := time.After(preflightTimeout)
+	for !seen["Tx"] || !seen["NewBlock"] {
+		select {
+		case ev := <-events:
+			seen[ev.Type] = true
+		case <-deadline:
+			return "", fmt.Errorf("events not delivered within %s (Tx %t, NewBlock %t)", preflightTimeout, seen["Tx"], seen["NewBlock"])
+		}
+	}
+	return "Tx and NewBlock events delivered", nil
+}
+
+// preflightWarnings lists server settings that commonly break suites.
+func preflightWarnings() []string {
+	warnings := []string{}
+	if n := len(faults.snapshot()); n > 0 {
+		warnings = append(warnings, fmt.Sprintf("%d broadcast fault rule(s) active, see /admin/faults", n))
+	}
+	if n := len(chaos.snapshot()); n > 0 {
+		warnings = append(warnings, fmt.Sprintf("%d chaos rule(s) active, see /admin/chaos", n))
+	}
+	geo.mu.Lock()
+	if geo.enabled {
+		warnings = append(warnings, "geo latency emulation is enabled, see /admin/geo")
+	}
+	geo.mu.Unlock()
+	return warnings
+}
+
+// Handler for GET /api/preflight
+func (c *Chain) handlePreflight(w http.ResponseWriter, r *http.Request) {
+	suite := r.URL.Query().Get("suite")
+	var names []string
+	if checks := r.URL.Query().Get("checks"); checks != "" {
+		names = splitList(checks)
+		suite = "custom"
+	} else {
+		if suite == "" {
+			suite = "all"
+		}
+		names = preflightSuites[suite]
+		if names == nil {
+			http.Error(w, fmt.Sprintf("Unknown suite %q; known suites: %s", suite, strings.Join(sortedMapKeys(preflightSuites), ", ")), http.StatusBadRequest)
+			return
+		}
+	}
+	for _, name := range names {
+		if preflightChecks[name] == nil {
+			http.Error(w, fmt.Sprintf("Unknown check %q; known checks: %s", name, strings.Join(sortedMapKeys(preflightChecks), ", ")), http.StatusBadRequest)
+			return
+		}
+	}
+
+	results := make([]preflightResult, len(names))
+	done := make(chan struct{})
+	for i, name := range names {
+		go func(i int, name string) {
+			defer func() { done <- struct{}{} }()
+			start := time.Now()
+			detail, err := preflightChecks[name](c)
+			result := preflightResult{Name: name, Status: "pass", Detail: detail}
+			if err != nil {
+				result.Status, result.Detail = "fail", err.Error()
+			}
+			result.DurationMs = time.Since(start).Milliseconds()
+			results[i] = result
+		}(i, name)
+	}
+	for range names {
+		<-done
+	}
+
+	failed := []string{}
+	for _, result := range results {
+		if result.Status == "fail" {
+			failed = append(failed, result.Name)
+		}
+	}
+	sort.Strings(failed)
+
+	status := http.StatusOK
+	if len(failed) > 0 {
+		status = http.StatusServiceUnavailable
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"ok":       len(failed) == 0,
+		"suite":    suite,
+		"checks":   results,
+		"failed":   failed,
+		"warnings": preflightWarnings(),
+	})
+}
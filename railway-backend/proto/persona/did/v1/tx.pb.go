@@ -0,0 +1,165 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.34.2
+// 	protoc        (unknown)
+// source: persona/did/v1/tx.proto
+
+// DID messages for protobuf-encoded txs. Documents are free-form JSON, as
+// in Amino-JSON txs.
+
+package didv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	structpb "google.golang.org/protobuf/types/known/structpb"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type MsgCreateDid struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Creator     string           `protobuf:"bytes,1,opt,name=creator,proto3" json:"creator,omitempty"`
+	DidDocument *structpb.Struct `protobuf:"bytes,2,opt,name=did_document,json=didDocument,proto3" json:"did_document,omitempty"`
+}
+
+func (x *MsgCreateDid) Reset() {
+	*x = MsgCreateDid{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_persona_did_v1_tx_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *MsgCreateDid) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MsgCreateDid) ProtoMessage() {}
+
+func (x *MsgCreateDid) ProtoReflect() protoreflect.Message {
+	mi := &file_persona_did_v1_tx_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MsgCreateDid.ProtoReflect.Descriptor instead.
+func (*MsgCreateDid) Descriptor() ([]byte, []int) {
+	return file_persona_did_v1_tx_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *MsgCreateDid) GetCreator() string {
+	if x != nil {
+		return x.Creator
+	}
+	return ""
+}
+
+func (x *MsgCreateDid) GetDidDocument() *structpb.Struct {
+	if x != nil {
+		return x.DidDocument
+	}
+	return nil
+}
+
+var File_persona_did_v1_tx_proto protoreflect.FileDescriptor
+
+var file_persona_did_v1_tx_proto_rawDesc = []byte{
+	0x0a, 0x17, 0x70, 0x65, 0x72, 0x73, 0x6f, 0x6e, 0x61, 0x2f, 0x64, 0x69, 0x64, 0x2f, 0x76, 0x31,
+	0x2f, 0x74, 0x78, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x0e, 0x70, 0x65, 0x72, 0x73, 0x6f,
+	0x6e, 0x61, 0x2e, 0x64, 0x69, 0x64, 0x2e, 0x76, 0x31, 0x1a, 0x1c, 0x67, 0x6f, 0x6f, 0x67, 0x6c,
+	0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x73, 0x74, 0x72, 0x75, 0x63,
+	0x74, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0x64, 0x0a, 0x0c, 0x4d, 0x73, 0x67, 0x43, 0x72,
+	0x65, 0x61, 0x74, 0x65, 0x44, 0x69, 0x64, 0x12, 0x18, 0x0a, 0x07, 0x63, 0x72, 0x65, 0x61, 0x74,
+	0x6f, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x63, 0x72, 0x65, 0x61, 0x74, 0x6f,
+	0x72, 0x12, 0x3a, 0x0a, 0x0c, 0x64, 0x69, 0x64, 0x5f, 0x64, 0x6f, 0x63, 0x75, 0x6d, 0x65, 0x6e,
+	0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65,
+	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x53, 0x74, 0x72, 0x75, 0x63, 0x74,
+	0x52, 0x0b, 0x64, 0x69, 0x64, 0x44, 0x6f, 0x63, 0x75, 0x6d, 0x65, 0x6e, 0x74, 0x42, 0x2c, 0x5a,
+	0x2a, 0x70, 0x65, 0x72, 0x73, 0x6f, 0x6e, 0x61, 0x2d, 0x62, 0x61, 0x63, 0x6b, 0x65, 0x6e, 0x64,
+	0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2f, 0x70, 0x65, 0x72, 0x73, 0x6f, 0x6e, 0x61, 0x2f, 0x64,
+	0x69, 0x64, 0x2f, 0x76, 0x31, 0x3b, 0x64, 0x69, 0x64, 0x76, 0x31, 0x62, 0x06, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x33,
+}
+
+var (
+	file_persona_did_v1_tx_proto_rawDescOnce sync.Once
+	file_persona_did_v1_tx_proto_rawDescData = file_persona_did_v1_tx_proto_rawDesc
+)
+
+func file_persona_did_v1_tx_proto_rawDescGZIP() []byte {
+	file_persona_did_v1_tx_proto_rawDescOnce.Do(func() {
+		file_persona_did_v1_tx_proto_rawDescData = protoimpl.X.CompressGZIP(file_persona_did_v1_tx_proto_rawDescData)
+	})
+	return file_persona_did_v1_tx_proto_rawDescData
+}
+
+var file_persona_did_v1_tx_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
+var file_persona_did_v1_tx_proto_goTypes = []any{
+	(*MsgCreateDid)(nil),    // 0: persona.did.v1.MsgCreateDid
+	(*structpb.Struct)(nil), // 1: google.protobuf.Struct
+}
+var file_persona_did_v1_tx_proto_depIdxs = []int32{
+	1, // 0: persona.did.v1.MsgCreateDid.did_document:type_name -> google.protobuf.Struct
+	1, // [1:1] is the sub-list for method output_type
+	1, // [1:1] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_persona_did_v1_tx_proto_init() }
+func file_persona_did_v1_tx_proto_init() {
+	if File_persona_did_v1_tx_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_persona_did_v1_tx_proto_msgTypes[0].Exporter = func(v any, i int) any {
+			switch v := v.(*MsgCreateDid); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_persona_did_v1_tx_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   1,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_persona_did_v1_tx_proto_goTypes,
+		DependencyIndexes: file_persona_did_v1_tx_proto_depIdxs,
+		MessageInfos:      file_persona_did_v1_tx_proto_msgTypes,
+	}.Build()
+	File_persona_did_v1_tx_proto = out.File
+	file_persona_did_v1_tx_proto_rawDesc = nil
+	file_persona_did_v1_tx_proto_goTypes = nil
+	file_persona_did_v1_tx_proto_depIdxs = nil
+}
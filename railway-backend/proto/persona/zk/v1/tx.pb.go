@@ -0,0 +1,196 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.34.2
+// 	protoc        (unknown)
+// source: persona/zk/v1/tx.proto
+
+// Proof messages for protobuf-encoded txs.
+
+package zkv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	structpb "google.golang.org/protobuf/types/known/structpb"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type MsgSubmitProof struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Creator      string           `protobuf:"bytes,1,opt,name=creator,proto3" json:"creator,omitempty"`
+	CircuitId    string           `protobuf:"bytes,2,opt,name=circuit_id,json=circuitId,proto3" json:"circuit_id,omitempty"`
+	Proof        string           `protobuf:"bytes,3,opt,name=proof,proto3" json:"proof,omitempty"`
+	PublicInputs *structpb.Value  `protobuf:"bytes,4,opt,name=public_inputs,json=publicInputs,proto3" json:"public_inputs,omitempty"`
+	Metadata     *structpb.Struct `protobuf:"bytes,5,opt,name=metadata,proto3" json:"metadata,omitempty"`
+}
+
+func (x *MsgSubmitProof) Reset() {
+	*x = MsgSubmitProof{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_persona_zk_v1_tx_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *MsgSubmitProof) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MsgSubmitProof) ProtoMessage() {}
+
+func (x *MsgSubmitProof) ProtoReflect() protoreflect.Message {
+	mi := &file_persona_zk_v1_tx_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MsgSubmitProof.ProtoReflect.Descriptor instead.
+func (*MsgSubmitProof) Descriptor() ([]byte, []int) {
+	return file_persona_zk_v1_tx_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *MsgSubmitProof) GetCreator() string {
+	if x != nil {
+		return x.Creator
+	}
+	return ""
+}
+
+func (x *MsgSubmitProof) GetCircuitId() string {
+	if x != nil {
+		return x.CircuitId
+	}
+	return ""
+}
+
+func (x *MsgSubmitProof) GetProof() string {
+	if x != nil {
+		return x.Proof
+	}
+	return ""
+}
+
+func (x *MsgSubmitProof) GetPublicInputs() *structpb.Value {
+	if x != nil {
+		return x.PublicInputs
+	}
+	return nil
+}
+
+func (x *MsgSubmitProof) GetMetadata() *structpb.Struct {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+var File_persona_zk_v1_tx_proto protoreflect.FileDescriptor
+
+var file_persona_zk_v1_tx_proto_rawDesc = []byte{
+	0x0a, 0x16, 0x70, 0x65, 0x72, 0x73, 0x6f, 0x6e, 0x61, 0x2f, 0x7a, 0x6b, 0x2f, 0x76, 0x31, 0x2f,
+	0x74, 0x78, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x0d, 0x70, 0x65, 0x72, 0x73, 0x6f, 0x6e,
+	0x61, 0x2e, 0x7a, 0x6b, 0x2e, 0x76, 0x31, 0x1a, 0x1c, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x73, 0x74, 0x72, 0x75, 0x63, 0x74, 0x2e,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0xd1, 0x01, 0x0a, 0x0e, 0x4d, 0x73, 0x67, 0x53, 0x75, 0x62,
+	0x6d, 0x69, 0x74, 0x50, 0x72, 0x6f, 0x6f, 0x66, 0x12, 0x18, 0x0a, 0x07, 0x63, 0x72, 0x65, 0x61,
+	0x74, 0x6f, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x63, 0x72, 0x65, 0x61, 0x74,
+	0x6f, 0x72, 0x12, 0x1d, 0x0a, 0x0a, 0x63, 0x69, 0x72, 0x63, 0x75, 0x69, 0x74, 0x5f, 0x69, 0x64,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x63, 0x69, 0x72, 0x63, 0x75, 0x69, 0x74, 0x49,
+	0x64, 0x12, 0x14, 0x0a, 0x05, 0x70, 0x72, 0x6f, 0x6f, 0x66, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x05, 0x70, 0x72, 0x6f, 0x6f, 0x66, 0x12, 0x3b, 0x0a, 0x0d, 0x70, 0x75, 0x62, 0x6c, 0x69,
+	0x63, 0x5f, 0x69, 0x6e, 0x70, 0x75, 0x74, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x16,
+	0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66,
+	0x2e, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x52, 0x0c, 0x70, 0x75, 0x62, 0x6c, 0x69, 0x63, 0x49, 0x6e,
+	0x70, 0x75, 0x74, 0x73, 0x12, 0x33, 0x0a, 0x08, 0x6d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61,
+	0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x53, 0x74, 0x72, 0x75, 0x63, 0x74, 0x52,
+	0x08, 0x6d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x42, 0x2a, 0x5a, 0x28, 0x70, 0x65, 0x72,
+	0x73, 0x6f, 0x6e, 0x61, 0x2d, 0x62, 0x61, 0x63, 0x6b, 0x65, 0x6e, 0x64, 0x2f, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x2f, 0x70, 0x65, 0x72, 0x73, 0x6f, 0x6e, 0x61, 0x2f, 0x7a, 0x6b, 0x2f, 0x76, 0x31,
+	0x3b, 0x7a, 0x6b, 0x76, 0x31, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_persona_zk_v1_tx_proto_rawDescOnce sync.Once
+	file_persona_zk_v1_tx_proto_rawDescData = file_persona_zk_v1_tx_proto_rawDesc
+)
+
+func file_persona_zk_v1_tx_proto_rawDescGZIP() []byte {
+	file_persona_zk_v1_tx_proto_rawDescOnce.Do(func() {
+		file_persona_zk_v1_tx_proto_rawDescData = protoimpl.X.CompressGZIP(file_persona_zk_v1_tx_proto_rawDescData)
+	})
+	return file_persona_zk_v1_tx_proto_rawDescData
+}
+
+var file_persona_zk_v1_tx_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
+var file_persona_zk_v1_tx_proto_goTypes = []any{
+	(*MsgSubmitProof)(nil),  // 0: persona.zk.v1.MsgSubmitProof
+	(*structpb.Value)(nil),  // 1: google.protobuf.Value
+	(*structpb.Struct)(nil), // 2: google.protobuf.Struct
+}
+var file_persona_zk_v1_tx_proto_depIdxs = []int32{
+	1, // 0: persona.zk.v1.MsgSubmitProof.public_inputs:type_name -> google.protobuf.Value
+	2, // 1: persona.zk.v1.MsgSubmitProof.metadata:type_name -> google.protobuf.Struct
+	2, // [2:2] is the sub-list for method output_type
+	2, // [2:2] is the sub-list for method input_type
+	2, // [2:2] is the sub-list for extension type_name
+	2, // [2:2] is the sub-list for extension extendee
+	0, // [0:2] is the sub-list for field type_name
+}
+
+func init() { file_persona_zk_v1_tx_proto_init() }
+func file_persona_zk_v1_tx_proto_init() {
+	if File_persona_zk_v1_tx_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_persona_zk_v1_tx_proto_msgTypes[0].Exporter = func(v any, i int) any {
+			switch v := v.(*MsgSubmitProof); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_persona_zk_v1_tx_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   1,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_persona_zk_v1_tx_proto_goTypes,
+		DependencyIndexes: file_persona_zk_v1_tx_proto_depIdxs,
+		MessageInfos:      file_persona_zk_v1_tx_proto_msgTypes,
+	}.Build()
+	File_persona_zk_v1_tx_proto = out.File
+	file_persona_zk_v1_tx_proto_rawDesc = nil
+	file_persona_zk_v1_tx_proto_goTypes = nil
+	file_persona_zk_v1_tx_proto_depIdxs = nil
+}
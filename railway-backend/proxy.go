@@ -0,0 +1,275 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gorilla/mux"
+)
+
+// Record-and-replay proxy for routes the mock does not implement, so
+// real-chain behaviour can be captured once and replayed deterministically
+// in E2E runs. With PROXY_MODE=record unknown routes are forwarded to
+// UPSTREAM_URL (a real Persona or Cosmos node) and every exchange is
+// appended to PROXY_CASSETTE (proxy-cassette.jsonl), one JSON object per
+// line. With PROXY_MODE=replay the cassette is loaded at startup and
+// unknown routes are answered from it without any network: a request
+// matches on method, path, query and body, and repeated requests get the
+// recorded responses in order, the last one repeating. Requests with no
+// recording get a 404 with X-Persona-Source: replay-miss.
+//
+// Responses carry X-Persona-Source (upstream or replay). /admin/reset
+// rewinds replay to the start of the cassette. GET /admin/proxy shows the
+// mode and counters.
+
+const maxProxyBodyBytes = 10 << 20
+
+type proxyExchange struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+	// Query is encoded with sorted keys
+	Query       string `json:"query,omitempty"`
+	RequestBody string `json:"request_body,omitempty"`
+	Status      int    `json:"status"`
+	ContentType string `json:"content_type,omitempty"`
+	Body        string `json:"body"`
+	// BodyEncoding is "base64" for bodies that are not UTF-8
+	BodyEncoding string `json:"body_encoding,omitempty"`
+	RecordedAt   string `json:"recorded_at"`
+}
+
+func (e *proxyExchange) key() string {
+	return e.Method + " " + e.Path + "?" + e.Query + "\n" + e.RequestBody
+}
+
+var (
+	proxyMode     = os.Getenv("PROXY_MODE")
+	upstreamURL   = strings.TrimSuffix(os.Getenv("UPSTREAM_URL"), "/")
+	proxyCassette = os.Getenv("PROXY_CASSETTE")
+	proxyClient   = &http.Client{Timeout: 30 * time.Second}
+
+	proxyMu sync.Mutex
+	// Recorded exchanges by key, in recording order, and how many of each
+	// replay has served
+	proxyRecordings = make(map[string][]*proxyExchange)
+	proxyCursors    = make(map[string]int)
+	proxyStats      = map[string]int{"recorded": 0, "replayed": 0, "misses": 0, "errors": 0}
+)
+
+func init() {
+	if proxyCassette == "" {
+		proxyCassette = "proxy-cassette.jsonl"
+	}
+	registerAdminState("proxy", func() interface{} {
+		return proxyState()
+	}, func() {
+		proxyMu.Lock()
+		defer proxyMu.Unlock()
+		proxyCursors = make(map[string]int)
+		for name := range proxyStats {
+			proxyStats[name] = 0
+		}
+	})
+}
+
+// setupProxy makes the router send unknown routes through the proxy when
+// PROXY_MODE is set.
+func setupProxy(r *mux.Router) error {
+	switch proxyMode {
+	case "":
+		return nil
+	case "record":
+		if upstreamURL == "" {
+			return fmt.Errorf("PROXY_MODE=record needs UPSTREAM_URL")
+		}
+		log.Printf("Proxy recording unknown routes from %s to %s", upstreamURL, proxyCassette)
+	case "replay":
+		n, err := loadProxyCassette(proxyCassette)
+		if err != nil {
+			return err
+		}
+		log.Printf("Proxy replaying %d recorded exchanges from %s", n, proxyCassette)
+	default:
+		return fmt.Errorf("PROXY_MODE must be record or replay, not %q", proxyMode)
+	}
+	// Middleware only runs for matched routes, so add CORS and recording here
+	r.NotFoundHandler = recordingMiddleware(corsMiddleware(http.HandlerFunc(handleProxy)))
+	return nil
+}
+
+// loadProxyCassette reads a cassette for replay.
+func loadProxyCassette(path string) (int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	proxyMu.Lock()
+	defer proxyMu.Unlock()
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64<<10), 4*maxProxyBodyBytes)
+	n := 0
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var exchange proxyExchange
+		if err := json.Unmarshal(scanner.Bytes(), &exchange); err != nil {
+			return 0, fmt.Errorf("%s:%d: %v", path, line, err)
+		}
+		proxyRecordings[exchange.key()] = append(proxyRecordings[exchange.key()], &exchange)
+		n++
+	}
+	return n, scanner.Err()
+}
+
+// Handler for routes no mock handler matched
+func handleProxy(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxProxyBodyBytes+1))
+	if err != nil || len(body) > maxProxyBodyBytes {
+		http.Error(w, "Failed to read request body", http.StatusBadRequest)
+		return
+	}
+	request := &proxyExchange{
+		Method:      r.Method,
+		Path:        r.URL.Path,
+		Query:       r.URL.Query().Encode(),
+		RequestBody: string(body),
+	}
+
+	var exchange *proxyExchange
+	source := "replay"
+	if proxyMode == "record" {
+		source = "upstream"
+		if exchange, err = forwardToUpstream(r, request, body); err != nil {
+			proxyMu.Lock()
+			proxyStats["errors"]++
+			proxyMu.Unlock()
+			log.Printf("Proxy %s %s failed: %v", r.Method, r.URL.Path, err)
+			writeGRPCError(w, http.StatusBadGateway, 14, "upstream "+err.Error())
+			return
+		}
+	} else if exchange = nextRecording(request.key()); exchange == nil {
+		w.Header().Set("X-Persona-Source", "replay-miss")
+		writeGRPCError(w, http.StatusNotFound, 5, fmt.Sprintf("no recording for %s %s", r.Method, r.URL.RequestURI()))
+		return
+	}
+
+	data := []byte(exchange.Body)
+	if exchange.BodyEncoding == "base64" {
+		data, _ = base64.StdEncoding.DecodeString(exchange.Body)
+	}
+	if exchange.ContentType != "" {
+		w.Header().Set("Content-Type", exchange.ContentType)
+	}
+	w.Header().Set("X-Persona-Source", source)
+	w.WriteHeader(exchange.Status)
+	w.Write(data)
+}
+
+// forwardToUpstream sends a request to UPSTREAM_URL and records the
+// exchange.
+func forwardToUpstream(r *http.Request, request *proxyExchange, body []byte) (*proxyExchange, error) {
+	req, err := http.NewRequest(r.Method, upstreamURL+r.URL.RequestURI(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for _, header := range []string{"Accept", "Content-Type"} {
+		if value := r.Header.Get(header); value != "" {
+			req.Header.Set(header, value)
+		}
+	}
+	resp, err := proxyClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxProxyBodyBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxProxyBodyBytes {
+		return nil, fmt.Errorf("response is larger than %d bytes", maxProxyBodyBytes)
+	}
+
+	exchange := *request
+	exchange.Status = resp.StatusCode
+	exchange.ContentType = resp.Header.Get("Content-Type")
+	exchange.Body = string(data)
+	if !utf8.Valid(data) {
+		exchange.Body = base64.StdEncoding.EncodeToString(data)
+		exchange.BodyEncoding = "base64"
+	}
+	exchange.RecordedAt = time.Now().UTC().Format(time.RFC3339)
+
+	line, err := json.Marshal(exchange)
+	if err != nil {
+		return nil, err
+	}
+	proxyMu.Lock()
+	defer proxyMu.Unlock()
+	f, err := os.OpenFile(proxyCassette, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return nil, err
+	}
+	proxyStats["recorded"]++
+	return &exchange, nil
+}
+
+// nextRecording returns the next recorded response for a request key, the
+// last one once all have been served, or nil.
+func nextRecording(key string) *proxyExchange {
+	proxyMu.Lock()
+	defer proxyMu.Unlock()
+	recordings := proxyRecordings[key]
+	if len(recordings) == 0 {
+		proxyStats["misses"]++
+		return nil
+	}
+	i := min(proxyCursors[key], len(recordings)-1)
+	proxyCursors[key]++
+	proxyStats["replayed"]++
+	return recordings[i]
+}
+
+func proxyState() map[string]interface{} {
+	proxyMu.Lock()
+	defer proxyMu.Unlock()
+	exchanges := 0
+	for _, recordings := range proxyRecordings {
+		exchanges += len(recordings)
+	}
+	stats := make(map[string]int, len(proxyStats))
+	for name, n := range proxyStats {
+		stats[name] = n
+	}
+	return map[string]interface{}{
+		"mode":      proxyMode,
+		"upstream":  upstreamURL,
+		"cassette":  proxyCassette,
+		"exchanges": exchanges,
+		"stats":     stats,
+	}
+}
+
+// Handler for GET /admin/proxy
+func handleGetProxy(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(proxyState())
+}
@@ -0,0 +1,289 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+
+	"persona-backend/idgen"
+
+	"github.com/gorilla/mux"
+)
+
+// Mock push notification gateway, standing in for FCM and APNs in mobile
+// E2E tests. Apps register their device token at /api/push/devices, and
+// anything that would send a push (a proof request, say) posts it to
+// /api/push/send. Nothing leaves the daemon: each notification is stored
+// in its device's inbox, which tests read back from /admin/push/{token}.
+
+const maxPushInbox = 200
+
+type pushDevice struct {
+	Token    string `json:"token"`
+	Platform string `json:"platform"` // fcm or apns
+	// Address is the account the device belongs to, so a push can target
+	// every device of a user
+	Address      string `json:"address,omitempty"`
+	AppID        string `json:"app_id,omitempty"`
+	RegisteredAt int64  `json:"registered_at"`
+}
+
+type pushNotification struct {
+	ID       string            `json:"id"`
+	Token    string            `json:"token"`
+	Platform string            `json:"platform"`
+	Kind     string            `json:"kind,omitempty"` // e.g. proof_request
+	Title    string            `json:"title,omitempty"`
+	Body     string            `json:"body,omitempty"`
+	Data     map[string]string `json:"data,omitempty"`
+	Priority string            `json:"priority"`
+	Status   string            `json:"status"`
+	SentAt   int64             `json:"sent_at"`
+}
+
+var (
+	pushMu      sync.Mutex
+	pushDevices = make(map[string]*pushDevice)
+	// Sent notifications by device token, oldest first
+	pushInbox = make(map[string][]pushNotification)
+)
+
+func init() {
+	registerAdminState("push", func() interface{} {
+		pushMu.Lock()
+		defer pushMu.Unlock()
+		devices := make([]pushDevice, 0, len(pushDevices))
+		for _, token := range sortedMapKeys(pushDevices) {
+			devices = append(devices, *pushDevices[token])
+		}
+		inbox := make(map[string][]pushNotification, len(pushInbox))
+		for token, notifications := range pushInbox {
+			inbox[token] = append([]pushNotification{}, notifications...)
+		}
+		return map[string]interface{}{"devices": devices, "notifications": inbox}
+	}, func() {
+		pushMu.Lock()
+		defer pushMu.Unlock()
+		pushDevices = make(map[string]*pushDevice)
+		pushInbox = make(map[string][]pushNotification)
+	})
+}
+
+// writePushError mirrors the FCM v1 error shape.
+func writePushError(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"error": map[string]interface{}{
+			"code":    status,
+			"status":  code,
+			"message": message,
+		},
+	})
+}
+
+// Handler for POST /api/push/devices. Registering a known token again
+// updates it.
+func handleRegisterPushDevice(w http.ResponseWriter, r *http.Request) {
+	var device pushDevice
+	if err := json.NewDecoder(r.Body).Decode(&device); err != nil {
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+	device.Token = strings.TrimSpace(device.Token)
+	device.Platform = strings.ToLower(device.Platform)
+	if device.Token == "" {
+		writePushError(w, http.StatusBadRequest, "INVALID_ARGUMENT", "token is required")
+		return
+	}
+	if device.Platform != "fcm" && device.Platform != "apns" {
+		writePushError(w, http.StatusBadRequest, "INVALID_ARGUMENT", "platform must be fcm or apns")
+		return
+	}
+
+	pushMu.Lock()
+	_, exists := pushDevices[device.Token]
+	device.RegisteredAt = appClock.Now().Unix()
+	pushDevices[device.Token] = &device
+	pushMu.Unlock()
+
+	status := http.StatusCreated
+	if exists {
+		status = http.StatusOK
+	}
+	log.Printf("Push device registered: %s (%s)", device.Token, device.Platform)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{"device": device})
+}
+
+// Handler for DELETE /api/push/devices/{token}. The inbox is kept so tests
+// can still read what was sent before the app unregistered.
+func handleUnregisterPushDevice(w http.ResponseWriter, r *http.Request) {
+	token := mux.Vars(r)["token"]
+
+	pushMu.Lock()
+	_, ok := pushDevices[token]
+	delete(pushDevices, token)
+	pushMu.Unlock()
+
+	if !ok {
+		writePushError(w, http.StatusNotFound, "UNREGISTERED", "device token is not registered")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"removed": 1})
+}
+
+// Handler for POST /api/push/send, which "delivers" a notification to one
+// device token, or to every device registered for an address.
+func handleSendPush(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Token        string `json:"token"`
+		Address      string `json:"address"`
+		Kind         string `json:"kind"`
+		Notification struct {
+			Title string `json:"title"`
+			Body  string `json:"body"`
+		} `json:"notification"`
+		Data     map[string]string `json:"data"`
+		Priority string            `json:"priority"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+	if (req.Token == "") == (req.Address == "") {
+		writePushError(w, http.StatusBadRequest, "INVALID_ARGUMENT", "exactly one of token or address is required")
+		return
+	}
+	if req.Priority == "" {
+		req.Priority = "high"
+	}
+
+	pushMu.Lock()
+	var targets []*pushDevice
+	if req.Token != "" {
+		if device, ok := pushDevices[req.Token]; ok {
+			targets = append(targets, device)
+		}
+	} else {
+		for _, token := range sortedMapKeys(pushDevices) {
+			if device := pushDevices[token]; device.Address == req.Address {
+				targets = append(targets, device)
+			}
+		}
+	}
+	if len(targets) == 0 {
+		pushMu.Unlock()
+		writePushError(w, http.StatusNotFound, "UNREGISTERED", "no registered device for the target")
+		return
+	}
+
+	now := appClock.Now().Unix()
+	sent := make([]pushNotification, 0, len(targets))
+	for _, device := range targets {
+		notification := pushNotification{
+			ID:       idgen.NewWithPrefix("push"),
+			Token:    device.Token,
+			Platform: device.Platform,
+			Kind:     req.Kind,
+			Title:    req.Notification.Title,
+			Body:     req.Notification.Body,
+			Data:     req.Data,
+			Priority: req.Priority,
+			Status:   "sent",
+			SentAt:   now,
+		}
+		inbox := append(pushInbox[device.Token], notification)
+		if len(inbox) > maxPushInbox {
+			inbox = inbox[len(inbox)-maxPushInbox:]
+		}
+		pushInbox[device.Token] = inbox
+		sent = append(sent, notification)
+	}
+	pushMu.Unlock()
+
+	log.Printf("Push %q sent to %d device(s)", req.Kind, len(sent))
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":       len(sent),
+		"notifications": sent,
+	})
+}
+
+// Handler for GET /admin/push, listing registered devices with the number
+// of notifications each received.
+func handleListPushDevices(w http.ResponseWriter, r *http.Request) {
+	pushMu.Lock()
+	devices := make([]map[string]interface{}, 0, len(pushDevices))
+	for _, token := range sortedMapKeys(pushDevices) {
+		device := pushDevices[token]
+		devices = append(devices, map[string]interface{}{
+			"token":         device.Token,
+			"platform":      device.Platform,
+			"address":       device.Address,
+			"app_id":        device.AppID,
+			"registered_at": device.RegisteredAt,
+			"received":      len(pushInbox[token]),
+		})
+	}
+	pushMu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"devices": devices})
+}
+
+// Handler for GET /admin/push/{token}, the notifications sent to a device,
+// newest first. ?kind= filters by kind.
+func handleGetPushInbox(w http.ResponseWriter, r *http.Request) {
+	token := mux.Vars(r)["token"]
+	kind := r.URL.Query().Get("kind")
+
+	pushMu.Lock()
+	device, registered := pushDevices[token]
+	inbox, received := pushInbox[token]
+	notifications := make([]pushNotification, 0, len(inbox))
+	for _, n := range inbox {
+		if kind == "" || n.Kind == kind {
+			notifications = append(notifications, n)
+		}
+	}
+	var deviceCopy *pushDevice
+	if registered {
+		d := *device
+		deviceCopy = &d
+	}
+	pushMu.Unlock()
+
+	if !registered && !received {
+		writePushError(w, http.StatusNotFound, "NOT_FOUND", "unknown device token")
+		return
+	}
+	for i, j := 0, len(notifications)-1; i < j; i, j = i+1, j-1 {
+		notifications[i], notifications[j] = notifications[j], notifications[i]
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"token":         token,
+		"registered":    registered,
+		"device":        deviceCopy,
+		"notifications": notifications,
+	})
+}
+
+// Handler for DELETE /admin/push/{token}, which empties a device's inbox.
+func handleClearPushInbox(w http.ResponseWriter, r *http.Request) {
+	token := mux.Vars(r)["token"]
+
+	pushMu.Lock()
+	removed := len(pushInbox[token])
+	delete(pushInbox, token)
+	pushMu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"removed": removed})
+}
@@ -0,0 +1,434 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// Usage quotas, so the frontend's quota banners and upgrade prompts follow
+// real enforcement:
+//
+//	verifications  per relying party per day: proof and credential
+//	               verifications, a batch counting each item
+//	issuances      per issuer per day: MsgIssueCredential, by the
+//	               credential's issuer or the creator's DID
+//	proofs         per DID per hour: MsgSubmitProof, by the prover's DID
+//	               or address
+//
+// The relying party of a verification is the one of the custom domain it
+// reached (domains.go) or the X-Persona-RP header; verifications without one
+// are not metered. Windows are fixed on the app clock (UTC days and hours).
+// A request over quota gets a 429 with gRPC code 8 and Retry-After, before
+// anything runs, so a rejected tx pays no fee. A tx's usage is counted when
+// it is checked and refunded when the tx fails. Metered responses carry
+// X-Quota-Kind, X-Quota-Limit, X-Quota-Remaining and X-Quota-Reset (seconds
+// until the window ends).
+//
+// Limits come from QUOTA_VERIFICATIONS_PER_DAY, QUOTA_ISSUANCES_PER_DAY and
+// QUOTA_PROOFS_PER_HOUR (0, the default, is unlimited), and per-subject
+// overrides from QUOTA_OVERRIDES ({"verifications": {"rp-id": 1000}}, where
+// 0 is unlimited too). PUT /admin/quotas changes both; GET
+// /api/quotas/{subject} reports a subject's usage.
+
+const rpHeader = "X-Persona-RP"
+
+type quotaKind struct {
+	Name       string
+	Window     time.Duration
+	WindowName string
+	EnvVar     string
+	Subject    string
+}
+
+var quotaKinds = []quotaKind{
+	{Name: "verifications", Window: 24 * time.Hour, WindowName: "day", EnvVar: "QUOTA_VERIFICATIONS_PER_DAY", Subject: "relying party"},
+	{Name: "issuances", Window: 24 * time.Hour, WindowName: "day", EnvVar: "QUOTA_ISSUANCES_PER_DAY", Subject: "issuer"},
+	{Name: "proofs", Window: time.Hour, WindowName: "hour", EnvVar: "QUOTA_PROOFS_PER_HOUR", Subject: "DID"},
+}
+
+type quotaConfig struct {
+	// Limits by kind; 0 is unlimited
+	Limits map[string]int `json:"limits"`
+	// Overrides by kind and subject; 0 is unlimited
+	Overrides map[string]map[string]int `json:"overrides"`
+}
+
+type quotaCounter struct {
+	windowStart time.Time
+	used        int
+}
+
+type quotaUsage struct {
+	Kind    string `json:"kind"`
+	Subject string `json:"subject"`
+	// Limit is 0 when the subject is unlimited
+	Limit         int   `json:"limit"`
+	Used          int   `json:"used"`
+	Remaining     int   `json:"remaining"`
+	WindowSeconds int64 `json:"window_seconds"`
+	ResetsAt      int64 `json:"resets_at"`
+}
+
+// quotaCharge is units of a kind a request uses up for a subject.
+type quotaCharge struct {
+	kind    string
+	subject string
+	units   int
+}
+
+var (
+	startupQuotas = quotaConfigFromEnv()
+
+	quotaMu       sync.Mutex
+	quotaSettings quotaConfig
+	// Counters by kind and subject
+	quotaCounters map[string]map[string]*quotaCounter
+)
+
+func init() {
+	resetQuotas()
+	registerAdminState("quotas", func() interface{} {
+		quotaMu.Lock()
+		defer quotaMu.Unlock()
+		return map[string]interface{}{"config": copyQuotaConfig(quotaSettings), "usage": quotaUsageList(appClock.Now())}
+	}, resetQuotas)
+}
+
+func quotaConfigFromEnv() quotaConfig {
+	config := quotaConfig{Limits: make(map[string]int), Overrides: make(map[string]map[string]int)}
+	for _, kind := range quotaKinds {
+		config.Limits[kind.Name] = 0
+		if raw := os.Getenv(kind.EnvVar); raw != "" {
+			if limit, err := strconv.Atoi(raw); err == nil && limit >= 0 {
+				config.Limits[kind.Name] = limit
+			} else {
+				log.Printf("Invalid %s %q", kind.EnvVar, raw)
+			}
+		}
+		config.Overrides[kind.Name] = make(map[string]int)
+	}
+	if raw := os.Getenv("QUOTA_OVERRIDES"); raw != "" {
+		var overrides map[string]map[string]int
+		if err := json.Unmarshal([]byte(raw), &overrides); err != nil {
+			log.Printf("Invalid QUOTA_OVERRIDES: %v", err)
+			return config
+		}
+		for kind, subjects := range overrides {
+			if config.Overrides[kind] == nil {
+				log.Printf("Skipping QUOTA_OVERRIDES for unknown quota %s", kind)
+				continue
+			}
+			for subject, limit := range subjects {
+				config.Overrides[kind][subject] = limit
+			}
+		}
+	}
+	return config
+}
+
+func copyQuotaConfig(config quotaConfig) quotaConfig {
+	copied := quotaConfig{Limits: make(map[string]int), Overrides: make(map[string]map[string]int)}
+	for kind, limit := range config.Limits {
+		copied.Limits[kind] = limit
+	}
+	for kind, subjects := range config.Overrides {
+		copied.Overrides[kind] = make(map[string]int, len(subjects))
+		for subject, limit := range subjects {
+			copied.Overrides[kind][subject] = limit
+		}
+	}
+	return copied
+}
+
+func resetQuotas() {
+	quotaMu.Lock()
+	defer quotaMu.Unlock()
+	quotaSettings = copyQuotaConfig(startupQuotas)
+	quotaCounters = make(map[string]map[string]*quotaCounter)
+	for _, kind := range quotaKinds {
+		quotaCounters[kind.Name] = make(map[string]*quotaCounter)
+	}
+}
+
+func findQuotaKind(name string) (quotaKind, bool) {
+	for _, kind := range quotaKinds {
+		if kind.Name == name {
+			return kind, true
+		}
+	}
+	return quotaKind{}, false
+}
+
+// quotaUsageOf returns a subject's usage of a kind at now. Must be called with
+// quotaMu held.
+func quotaUsageOf(kind quotaKind, subject string, now time.Time) quotaUsage {
+	limit := quotaSettings.Limits[kind.Name]
+	if override, ok := quotaSettings.Overrides[kind.Name][subject]; ok {
+		limit = override
+	}
+	windowStart := now.UTC().Truncate(kind.Window)
+	used := 0
+	if counter := quotaCounters[kind.Name][subject]; counter != nil && counter.windowStart.Equal(windowStart) {
+		used = counter.used
+	}
+	usage := quotaUsage{
+		Kind:          kind.Name,
+		Subject:       subject,
+		Limit:         limit,
+		Used:          used,
+		WindowSeconds: int64(kind.Window.Seconds()),
+		ResetsAt:      windowStart.Add(kind.Window).Unix(),
+	}
+	if limit > 0 {
+		usage.Remaining = max(limit-used, 0)
+	}
+	return usage
+}
+
+// quotaUsageList returns every subject's usage in the current windows.
+// Must be called with quotaMu held.
+func quotaUsageList(now time.Time) []quotaUsage {
+	list := []quotaUsage{}
+	for _, kind := range quotaKinds {
+		for _, subject := range sortedMapKeys(quotaCounters[kind.Name]) {
+			if usage := quotaUsageOf(kind, subject, now); usage.Used > 0 {
+				list = append(list, usage)
+			}
+		}
+	}
+	return list
+}
+
+// checkQuotas returns the usage of the first charge that does not fit, or
+// nil. Must be called with quotaMu held.
+func checkQuotas(charges []quotaCharge, now time.Time) *quotaUsage {
+	for _, charge := range charges {
+		kind, _ := findQuotaKind(charge.kind)
+		usage := quotaUsageOf(kind, charge.subject, now)
+		if usage.Limit > 0 && usage.Used+charge.units > usage.Limit {
+			return &usage
+		}
+	}
+	return nil
+}
+
+// consumeQuotas counts the charges and returns the usage after the first.
+// Must be called with quotaMu held.
+func consumeQuotas(charges []quotaCharge, now time.Time) *quotaUsage {
+	var first *quotaUsage
+	for _, charge := range charges {
+		kind, _ := findQuotaKind(charge.kind)
+		windowStart := now.UTC().Truncate(kind.Window)
+		counter := quotaCounters[kind.Name][charge.subject]
+		if counter == nil || !counter.windowStart.Equal(windowStart) {
+			counter = &quotaCounter{windowStart: windowStart}
+			quotaCounters[kind.Name][charge.subject] = counter
+		}
+		counter.used += charge.units
+		if first == nil {
+			usage := quotaUsageOf(kind, charge.subject, now)
+			first = &usage
+		}
+	}
+	return first
+}
+
+// refundQuotas gives back charges counted at chargedAt, unless their window
+// has rolled over since. Must be called with quotaMu held.
+func refundQuotas(charges []quotaCharge, chargedAt time.Time) {
+	for _, charge := range charges {
+		kind, _ := findQuotaKind(charge.kind)
+		counter := quotaCounters[kind.Name][charge.subject]
+		if counter != nil && counter.windowStart.Equal(chargedAt.UTC().Truncate(kind.Window)) {
+			counter.used = max(counter.used-charge.units, 0)
+		}
+	}
+}
+
+func setQuotaHeaders(w http.ResponseWriter, usage quotaUsage, now time.Time) {
+	w.Header().Set("X-Quota-Kind", usage.Kind)
+	if usage.Limit == 0 {
+		return
+	}
+	w.Header().Set("X-Quota-Limit", strconv.Itoa(usage.Limit))
+	w.Header().Set("X-Quota-Remaining", strconv.Itoa(usage.Remaining))
+	w.Header().Set("X-Quota-Reset", strconv.FormatInt(usage.ResetsAt-now.Unix(), 10))
+}
+
+func writeQuotaExceeded(w http.ResponseWriter, usage quotaUsage, now time.Time) {
+	kind, _ := findQuotaKind(usage.Kind)
+	setQuotaHeaders(w, usage, now)
+	w.Header().Set("Retry-After", strconv.FormatInt(max(usage.ResetsAt-now.Unix(), 1), 10))
+	writeGRPCError(w, http.StatusTooManyRequests, 8, fmt.Sprintf("%s quota of %d per %s exceeded for %s %s, resets at %s",
+		usage.Kind, usage.Limit, kind.WindowName, kind.Subject, usage.Subject, time.Unix(usage.ResetsAt, 0).UTC().Format(time.RFC3339)))
+}
+
+// requestRelyingParty returns the relying party a request is made for, or
+// "".
+func requestRelyingParty(r *http.Request) string {
+	if rp, ok := relyingPartyFromContext(r.Context()); ok {
+		return rp
+	}
+	return strings.TrimSpace(r.Header.Get(rpHeader))
+}
+
+// chargeVerifications meters units verifications against the request's
+// relying party. It writes the 429 and returns false when they do not fit.
+func chargeVerifications(w http.ResponseWriter, r *http.Request, units int) bool {
+	rp := requestRelyingParty(r)
+	if rp == "" {
+		return true
+	}
+	charges := []quotaCharge{{kind: "verifications", subject: rp, units: units}}
+	now := appClock.Now()
+	quotaMu.Lock()
+	defer quotaMu.Unlock()
+	if exceeded := checkQuotas(charges, now); exceeded != nil {
+		writeQuotaExceeded(w, *exceeded, now)
+		return false
+	}
+	setQuotaHeaders(w, *consumeQuotas(charges, now), now)
+	return true
+}
+
+// txQuotaCharges returns the issuances and proofs a tx's messages use.
+// Must be called with c.mu held.
+func (c *Chain) txQuotaCharges(msgs []interface{}) []quotaCharge {
+	subjectOf := func(address string) string {
+		if did, ok := c.did().store.ByController[address]; ok {
+			return did
+		}
+		return address
+	}
+	units := make(map[quotaCharge]int)
+	var order []quotaCharge
+	add := func(kind, subject string) {
+		key := quotaCharge{kind: kind, subject: subject}
+		if units[key] == 0 {
+			order = append(order, key)
+		}
+		units[key]++
+	}
+	for _, m := range msgs {
+		msg, ok := m.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		switch msg["@type"] {
+		case "/persona.vc.v1.MsgIssueCredential":
+			vcData, _ := msg["vc_data"].(map[string]interface{})
+			issuer, _ := credentialIssuer(vcData).(string)
+			if issuer == "" {
+				creator, _ := msg["creator"].(string)
+				issuer = subjectOf(creator)
+			}
+			if issuer != "" {
+				add("issuances", issuer)
+			}
+		case "/persona.zk.v1.MsgSubmitProof":
+			prover, _ := msg["creator"].(string)
+			if prover == "" {
+				prover, _ = msg["prover"].(string)
+			}
+			if prover != "" {
+				add("proofs", subjectOf(prover))
+			}
+		}
+	}
+	charges := make([]quotaCharge, len(order))
+	for i, key := range order {
+		charges[i] = quotaCharge{kind: key.kind, subject: key.subject, units: units[key]}
+	}
+	return charges
+}
+
+// Handler for GET /api/quotas/{subject}. The subject is a relying party,
+// issuer DID or DID; every kind is reported.
+func handleGetQuotas(w http.ResponseWriter, r *http.Request) {
+	subject := mux.Vars(r)["subject"]
+	now := appClock.Now()
+	quotaMu.Lock()
+	quotas := make([]quotaUsage, len(quotaKinds))
+	for i, kind := range quotaKinds {
+		quotas[i] = quotaUsageOf(kind, subject, now)
+	}
+	quotaMu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"subject": subject, "quotas": quotas})
+}
+
+// Handler for GET /admin/quotas
+func handleListQuotas(w http.ResponseWriter, r *http.Request) {
+	quotaMu.Lock()
+	config := copyQuotaConfig(quotaSettings)
+	usage := quotaUsageList(appClock.Now())
+	quotaMu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"limits": config.Limits, "overrides": config.Overrides, "usage": usage})
+}
+
+// Handler for PUT /admin/quotas. Takes {"limits": {"verifications": 100},
+// "overrides": {"verifications": {"rp-id": 1000, "other-rp": null}}}; a
+// null override is removed, and omitted kinds and subjects are unchanged.
+// Usage is kept.
+func handleSetQuotas(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Limits    map[string]int             `json:"limits"`
+		Overrides map[string]map[string]*int `json:"overrides"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+	for kind, limit := range req.Limits {
+		if _, ok := findQuotaKind(kind); !ok {
+			http.Error(w, "unknown quota "+kind+", expected verifications, issuances or proofs", http.StatusBadRequest)
+			return
+		}
+		if limit < 0 {
+			http.Error(w, "limits must not be negative", http.StatusBadRequest)
+			return
+		}
+	}
+	for kind, subjects := range req.Overrides {
+		if _, ok := findQuotaKind(kind); !ok {
+			http.Error(w, "unknown quota "+kind+", expected verifications, issuances or proofs", http.StatusBadRequest)
+			return
+		}
+		for _, limit := range subjects {
+			if limit != nil && *limit < 0 {
+				http.Error(w, "limits must not be negative", http.StatusBadRequest)
+				return
+			}
+		}
+	}
+
+	quotaMu.Lock()
+	for kind, limit := range req.Limits {
+		quotaSettings.Limits[kind] = limit
+	}
+	for kind, subjects := range req.Overrides {
+		for subject, limit := range subjects {
+			if limit == nil {
+				delete(quotaSettings.Overrides[kind], subject)
+			} else {
+				quotaSettings.Overrides[kind][subject] = *limit
+			}
+		}
+	}
+	log.Printf("Quotas set: %v", quotaSettings.Limits)
+	quotaMu.Unlock()
+
+	handleListQuotas(w, r)
+}
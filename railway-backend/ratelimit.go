@@ -0,0 +1,257 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Token-bucket rate limiting, so the frontend can exercise its backoff and
+// runaway polling loops cannot knock the shared mock over. Requests with an
+// X-API-Key header draw from a bucket per key, everything else from a
+// bucket per client IP. Each bucket holds up to burst tokens and refills at
+// rate tokens per second; a request takes one token or gets a 429 with
+// Retry-After, the seconds until a token is available.
+//
+// Every limited response carries X-RateLimit-Limit (the burst),
+// X-RateLimit-Remaining and X-RateLimit-Reset (seconds until the bucket is
+// full again). Limits come from RATE_LIMIT_RPS / RATE_LIMIT_BURST and
+// RATE_LIMIT_KEY_RPS / RATE_LIMIT_KEY_BURST (a rate of 0 disables that
+// limit, the default) and are changed through PUT /admin/ratelimit. Admin
+// routes, /health and /metrics are never limited.
+
+const apiKeyHeader = "X-API-Key"
+
+// Buckets beyond this are pruned of the ones that refilled completely
+const maxRateLimitBuckets = 10000
+
+type rateLimit struct {
+	// Rate is the refill in tokens per second; 0 disables the limit
+	Rate  float64 `json:"rate"`
+	Burst int     `json:"burst"`
+}
+
+type rateLimitConfig struct {
+	IP  rateLimit `json:"ip"`
+	Key rateLimit `json:"key"`
+}
+
+type tokenBucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+type rateLimiter struct {
+	mu      sync.Mutex
+	config  rateLimitConfig
+	buckets map[string]*tokenBucket
+	// Rejections by bucket since the last reset
+	rejected map[string]int
+}
+
+var (
+	startupRateLimits = rateLimitConfig{
+		IP:  rateLimitFromEnv("RATE_LIMIT_RPS", "RATE_LIMIT_BURST"),
+		Key: rateLimitFromEnv("RATE_LIMIT_KEY_RPS", "RATE_LIMIT_KEY_BURST"),
+	}
+	limiter = &rateLimiter{}
+)
+
+func init() {
+	limiter.reset()
+	registerAdminState("ratelimit", func() interface{} { return limiter.state() }, limiter.reset)
+}
+
+// rateLimitFromEnv reads a limit, bursting to the rate (at least 1) unless
+// the burst is set.
+func rateLimitFromEnv(rateVar, burstVar string) rateLimit {
+	limit := rateLimit{}
+	if raw := os.Getenv(rateVar); raw != "" {
+		rate, err := strconv.ParseFloat(raw, 64)
+		if err != nil || rate < 0 {
+			log.Printf("Invalid %s %q", rateVar, raw)
+			return limit
+		}
+		limit.Rate = rate
+	}
+	limit.Burst = intFromEnv(burstVar, 0)
+	if err := limit.normalize(); err != nil {
+		log.Printf("Invalid %s: %v", burstVar, err)
+		return rateLimit{}
+	}
+	return limit
+}
+
+func (l *rateLimit) normalize() error {
+	if l.Rate < 0 || l.Burst < 0 || math.IsNaN(l.Rate) || math.IsInf(l.Rate, 0) {
+		return fmt.Errorf("rate and burst must not be negative")
+	}
+	if l.Burst == 0 && l.Rate > 0 {
+		l.Burst = int(math.Max(1, math.Ceil(l.Rate)))
+	}
+	return nil
+}
+
+func (rl *rateLimiter) reset() {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	rl.config = startupRateLimits
+	rl.buckets = make(map[string]*tokenBucket)
+	rl.rejected = make(map[string]int)
+}
+
+// rateLimitKey returns the bucket of a request and its limit.
+func (rl *rateLimiter) rateLimitKey(r *http.Request) (string, rateLimit) {
+	if key := r.Header.Get(apiKeyHeader); key != "" {
+		return "key:" + key, rl.config.Key
+	}
+	ip := "unknown"
+	if clientIP := clientIP(r); clientIP != nil {
+		ip = clientIP.String()
+	}
+	return "ip:" + ip, rl.config.IP
+}
+
+// take draws a token for the request. It returns the limit applied,
+// whether the request may proceed, the tokens left and the wait for the
+// next token.
+func (rl *rateLimiter) take(r *http.Request, now time.Time) (limit rateLimit, allowed bool, remaining float64, wait time.Duration) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	key, limit := rl.rateLimitKey(r)
+	if limit.Rate == 0 {
+		return limit, true, 0, 0
+	}
+	bucket := rl.buckets[key]
+	if bucket == nil {
+		if len(rl.buckets) >= maxRateLimitBuckets {
+			rl.prune(now)
+		}
+		bucket = &tokenBucket{tokens: float64(limit.Burst), updated: now}
+		rl.buckets[key] = bucket
+	}
+	bucket.refill(limit, now)
+	if bucket.tokens < 1 {
+		rl.rejected[key]++
+		return limit, false, bucket.tokens, time.Duration((1 - bucket.tokens) / limit.Rate * float64(time.Second))
+	}
+	bucket.tokens--
+	return limit, true, bucket.tokens, 0
+}
+
+func (b *tokenBucket) refill(limit rateLimit, now time.Time) {
+	if elapsed := now.Sub(b.updated).Seconds(); elapsed > 0 {
+		b.tokens = math.Min(float64(limit.Burst), b.tokens+elapsed*limit.Rate)
+	}
+	b.updated = now
+}
+
+// prune drops buckets that are full again. Must be called with rl.mu held.
+func (rl *rateLimiter) prune(now time.Time) {
+	for key, bucket := range rl.buckets {
+		limit := rl.config.IP
+		if strings.HasPrefix(key, "key:") {
+			limit = rl.config.Key
+		}
+		if limit.Rate == 0 {
+			delete(rl.buckets, key)
+			continue
+		}
+		bucket.refill(limit, now)
+		if bucket.tokens >= float64(limit.Burst) {
+			delete(rl.buckets, key)
+		}
+	}
+}
+
+func (rl *rateLimiter) state() map[string]interface{} {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	rejected := make(map[string]int, len(rl.rejected))
+	for key, count := range rl.rejected {
+		rejected[key] = count
+	}
+	return map[string]interface{}{
+		"limits":   rl.config,
+		"buckets":  len(rl.buckets),
+		"rejected": rejected,
+	}
+}
+
+// ceilSeconds rounds a duration up to whole seconds, at least 1.
+func ceilSeconds(d time.Duration) int {
+	return int(math.Max(1, math.Ceil(d.Seconds())))
+}
+
+func rateLimitMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "OPTIONS" || strings.HasPrefix(r.URL.Path, "/admin/") || r.URL.Path == "/health" || r.URL.Path == "/metrics" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		limit, allowed, remaining, wait := limiter.take(r, time.Now())
+		if limit.Rate == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		full := time.Duration((float64(limit.Burst) - remaining) / limit.Rate * float64(time.Second))
+		w.Header().Set("X-RateLimit-Limit", strconv.Itoa(limit.Burst))
+		w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(int(remaining)))
+		w.Header().Set("X-RateLimit-Reset", strconv.Itoa(int(math.Ceil(full.Seconds()))))
+		if !allowed {
+			w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(wait)))
+			writeGRPCError(w, http.StatusTooManyRequests, 8, fmt.Sprintf("rate limit of %g requests per second exceeded, retry in %ds", limit.Rate, ceilSeconds(wait)))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Handler for GET /admin/ratelimit
+func handleGetRateLimits(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(limiter.state())
+}
+
+// Handler for PUT /admin/ratelimit. Takes {"ip": {"rate", "burst"}, "key":
+// {...}}; an omitted limit is left unchanged. Buckets start over.
+func handleSetRateLimits(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		IP  *rateLimit `json:"ip"`
+		Key *rateLimit `json:"key"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+	for _, limit := range []*rateLimit{req.IP, req.Key} {
+		if limit == nil {
+			continue
+		}
+		if err := limit.normalize(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	limiter.mu.Lock()
+	if req.IP != nil {
+		limiter.config.IP = *req.IP
+	}
+	if req.Key != nil {
+		limiter.config.Key = *req.Key
+	}
+	limiter.buckets = make(map[string]*tokenBucket)
+	log.Printf("Rate limits set: ip %g/s burst %d, key %g/s burst %d", limiter.config.IP.Rate, limiter.config.IP.Burst, limiter.config.Key.Rate, limiter.config.Key.Burst)
+	limiter.mu.Unlock()
+
+	handleGetRateLimits(w, r)
+}
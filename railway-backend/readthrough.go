@@ -0,0 +1,127 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Read-through fallback: when a queried DID or credential is not in local
+// state, the same path is fetched from a real LCD (READ_THROUGH_URL) and the
+// result cached for READ_THROUGH_TTL, so mixed environments behave
+// seamlessly for the frontend.
+
+type upstreamEntry struct {
+	body      map[string]interface{} // nil caches a miss
+	fetchedAt time.Time
+}
+
+var (
+	readThroughURL    = strings.TrimSuffix(os.Getenv("READ_THROUGH_URL"), "/")
+	readThroughTTL    = durationFromEnv("READ_THROUGH_TTL", 5*time.Minute)
+	readThroughClient = &http.Client{Timeout: 10 * time.Second}
+
+	readThroughMu    sync.Mutex
+	readThroughCache = make(map[string]upstreamEntry)
+)
+
+// fetchUpstream returns the upstream response for path, using the cache
+// when fresh. ok is false when read-through is disabled or upstream has
+// nothing for this path.
+func fetchUpstream(path string) (map[string]interface{}, bool) {
+	if readThroughURL == "" {
+		return nil, false
+	}
+
+	readThroughMu.Lock()
+	entry, cached := readThroughCache[path]
+	readThroughMu.Unlock()
+	if cached && time.Since(entry.fetchedAt) < readThroughTTL {
+		return entry.body, entry.body != nil
+	}
+
+	body, err := getUpstreamJSON(path)
+	if err != nil {
+		// Serve stale data rather than nothing if upstream is unreachable
+		log.Printf("Read-through fetch of %s failed: %v", path, err)
+		return entry.body, entry.body != nil
+	}
+
+	readThroughMu.Lock()
+	readThroughCache[path] = upstreamEntry{body: body, fetchedAt: time.Now()}
+	readThroughMu.Unlock()
+	if body != nil {
+		log.Printf("Read-through cached %s from upstream", path)
+	}
+	return body, body != nil
+}
+
+// getUpstreamJSON returns (nil, nil) when upstream responds 404.
+func getUpstreamJSON(path string) (map[string]interface{}, error) {
+	resp, err := readThroughClient.Get(readThroughURL + path)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, &upstreamStatusError{status: resp.Status}
+	}
+	var body map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+type upstreamStatusError struct {
+	status string
+}
+
+func (e *upstreamStatusError) Error() string {
+	return "upstream returned " + e.status
+}
+
+// writeUpstream writes a read-through response, tagged with its source.
+func writeUpstream(w http.ResponseWriter, body map[string]interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Persona-Source", "upstream")
+	json.NewEncoder(w).Encode(body)
+}
+
+// durationFromEnv parses a Go duration from the environment, falling back
+// to def when unset or invalid.
+func durationFromEnv(name string, def time.Duration) time.Duration {
+	value := os.Getenv(name)
+	if value == "" {
+		return def
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("Invalid %s=%q, using %s", name, value, def)
+		return def
+	}
+	return d
+}
+
+// intFromEnv reads a positive integer from the environment, falling back to
+// def when unset or invalid.
+func intFromEnv(name string, def int) int {
+	value := os.Getenv(name)
+	if value == "" {
+		return def
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 {
+		log.Printf("Invalid %s=%q, using %d", name, value, def)
+		return def
+	}
+	return n
+}
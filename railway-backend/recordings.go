@@ -0,0 +1,312 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Traffic recording for debugging flaky E2E runs. With the
+// traffic_recording flag on (RECORD_TRAFFIC=true, or PUT
+// /admin/v1/flags/traffic_recording) every request the mock serves is kept
+// with its response, and GET /admin/recordings returns them as a HAR 1.2
+// file to open next to the browser's own. Tests tag their requests with an
+// X-Test-Run header and fetch only theirs with ?run=.
+//
+// The last RECORDINGS_MAX (2000) exchanges are kept, bodies cut at
+// RECORDINGS_BODY_MAX bytes (64 KiB). Credentials are redacted from
+// headers. Times are wall-clock, to line up with the browser; websocket
+// upgrades are not recorded. /admin/reset and DELETE /admin/recordings
+// clear the recordings.
+
+const testRunHeader = "X-Test-Run"
+
+var (
+	trafficRecording = newFeatureFlag("traffic_recording", "RECORD_TRAFFIC", "Record requests and responses for GET /admin/recordings")
+	maxRecordings    = intFromEnv("RECORDINGS_MAX", 2000)
+	maxRecordedBody  = intFromEnv("RECORDINGS_BODY_MAX", 64<<10)
+
+	// Headers whose values never reach a recording
+	redactedHeaders = map[string]bool{
+		"Authorization": true,
+		"Cookie":        true,
+		"Set-Cookie":    true,
+		"X-Admin-Token": true,
+		"X-Api-Key":     true,
+	}
+
+	unsafeFilenameChars = regexp.MustCompile(`[^A-Za-z0-9._-]`)
+
+	recordingsMu sync.Mutex
+	recordings   []*recordedExchange
+)
+
+type recordedExchange struct {
+	Started  time.Time
+	Duration time.Duration
+	Run      string
+	TraceID  string
+
+	Method         string
+	URL            string
+	Query          url.Values
+	Proto          string
+	RequestHeader  http.Header
+	RequestBody    []byte
+	RequestSize    int64
+	Status         int
+	ResponseHeader http.Header
+	ResponseBody   []byte
+	ResponseSize   int64
+}
+
+func init() {
+	registerAdminState("recordings", func() interface{} {
+		recordingsMu.Lock()
+		defer recordingsMu.Unlock()
+		return map[string]interface{}{"enabled": trafficRecording.Enabled(), "entries": len(recordings)}
+	}, func() {
+		recordingsMu.Lock()
+		defer recordingsMu.Unlock()
+		recordings = nil
+	})
+}
+
+// cappedBuffer keeps the first max bytes written to it and counts the rest.
+type cappedBuffer struct {
+	bytes.Buffer
+	max  int
+	size int64
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	b.size += int64(len(p))
+	if room := b.max - b.Len(); room > 0 {
+		b.Buffer.Write(p[:min(room, len(p))])
+	}
+	return len(p), nil
+}
+
+// recordingWriter captures the response for the recording.
+type recordingWriter struct {
+	http.ResponseWriter
+	status int
+	body   *cappedBuffer
+}
+
+func (rw *recordingWriter) WriteHeader(status int) {
+	if rw.status == 0 {
+		rw.status = status
+	}
+	rw.ResponseWriter.WriteHeader(status)
+}
+
+func (rw *recordingWriter) Write(p []byte) (int, error) {
+	if rw.status == 0 {
+		rw.status = http.StatusOK
+	}
+	rw.body.Write(p)
+	return rw.ResponseWriter.Write(p)
+}
+
+func (rw *recordingWriter) Flush() {
+	if flusher, ok := rw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func recordingMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Fetching the recordings is left out of them
+		path := strings.TrimPrefix(r.URL.Path, "/admin/v1")
+		if !trafficRecording.Enabled() || r.Header.Get("Upgrade") != "" || strings.HasPrefix(path, "/admin/recordings") || strings.HasPrefix(path, "/recordings") {
+			next.ServeHTTP(w, r)
+			return
+		}
+		exchange := &recordedExchange{
+			Started:       time.Now(),
+			Run:           r.Header.Get(testRunHeader),
+			Method:        r.Method,
+			URL:           requestBaseURL(r) + r.URL.RequestURI(),
+			Query:         r.URL.Query(),
+			Proto:         r.Proto,
+			RequestHeader: r.Header.Clone(),
+		}
+		// The handler reads the body through a copy that keeps its start
+		requestBody := &cappedBuffer{max: maxRecordedBody}
+		if r.Body != nil {
+			r.Body = struct {
+				io.Reader
+				io.Closer
+			}{io.TeeReader(r.Body, requestBody), r.Body}
+		}
+		rw := &recordingWriter{ResponseWriter: w, body: &cappedBuffer{max: maxRecordedBody}}
+		next.ServeHTTP(rw, r)
+		if rw.status == 0 {
+			rw.status = http.StatusOK
+		}
+
+		exchange.Duration = time.Since(exchange.Started)
+		exchange.RequestBody = requestBody.Bytes()
+		exchange.RequestSize = requestBody.size
+		exchange.Status = rw.status
+		exchange.ResponseHeader = w.Header().Clone()
+		exchange.ResponseBody = rw.body.Bytes()
+		exchange.ResponseSize = rw.body.size
+		exchange.TraceID = w.Header().Get(traceHeader)
+
+		recordingsMu.Lock()
+		recordings = append(recordings, exchange)
+		if len(recordings) > maxRecordings {
+			recordings = recordings[len(recordings)-maxRecordings:]
+		}
+		recordingsMu.Unlock()
+	})
+}
+
+// harHeaders lists headers as HAR name/value pairs, sorted, with
+// credentials redacted.
+func harHeaders(header http.Header) []map[string]string {
+	list := []map[string]string{}
+	for _, name := range sortedMapKeys(header) {
+		for _, value := range header[name] {
+			if redactedHeaders[name] {
+				value = "[redacted]"
+			}
+			list = append(list, map[string]string{"name": name, "value": value})
+		}
+	}
+	return list
+}
+
+// harText returns a body as HAR text, base64 encoded unless it is UTF-8.
+func harText(body []byte) (text, encoding string) {
+	if utf8.Valid(body) {
+		return string(body), ""
+	}
+	return base64.StdEncoding.EncodeToString(body), "base64"
+}
+
+func (e *recordedExchange) har() map[string]interface{} {
+	query := []map[string]string{}
+	for _, name := range sortedMapKeys(e.Query) {
+		for _, value := range e.Query[name] {
+			query = append(query, map[string]string{"name": name, "value": value})
+		}
+	}
+	request := map[string]interface{}{
+		"method":      e.Method,
+		"url":         e.URL,
+		"httpVersion": e.Proto,
+		"cookies":     []interface{}{},
+		"headers":     harHeaders(e.RequestHeader),
+		"queryString": query,
+		"headersSize": -1,
+		"bodySize":    e.RequestSize,
+	}
+	if e.RequestSize > 0 {
+		text, _ := harText(e.RequestBody)
+		request["postData"] = map[string]interface{}{
+			"mimeType": e.RequestHeader.Get("Content-Type"),
+			"text":     text,
+		}
+	}
+
+	text, encoding := harText(e.ResponseBody)
+	content := map[string]interface{}{
+		"size":     e.ResponseSize,
+		"mimeType": e.ResponseHeader.Get("Content-Type"),
+		"text":     text,
+	}
+	if encoding != "" {
+		content["encoding"] = encoding
+	}
+	if int64(len(e.ResponseBody)) < e.ResponseSize {
+		content["comment"] = "truncated to " + strconv.Itoa(len(e.ResponseBody)) + " bytes"
+	}
+	ms := float64(e.Duration.Microseconds()) / 1000
+	return map[string]interface{}{
+		"startedDateTime": e.Started.UTC().Format("2006-01-02T15:04:05.000Z07:00"),
+		"time":            ms,
+		"request":         request,
+		"response": map[string]interface{}{
+			"status":      e.Status,
+			"statusText":  http.StatusText(e.Status),
+			"httpVersion": e.Proto,
+			"cookies":     []interface{}{},
+			"headers":     harHeaders(e.ResponseHeader),
+			"content":     content,
+			"redirectURL": e.ResponseHeader.Get("Location"),
+			"headersSize": -1,
+			"bodySize":    e.ResponseSize,
+		},
+		"cache":    map[string]interface{}{},
+		"timings":  map[string]interface{}{"send": 0, "wait": ms, "receive": 0},
+		"_testRun": e.Run,
+		"_traceId": e.TraceID,
+	}
+}
+
+// Handler for GET /admin/recordings
+func handleGetRecordings(w http.ResponseWriter, r *http.Request) {
+	run := r.URL.Query().Get("run")
+	limit := 0
+	if s := r.URL.Query().Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 {
+			http.Error(w, "limit must be a positive integer", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	recordingsMu.Lock()
+	var selected []*recordedExchange
+	for _, e := range recordings {
+		if run == "" || e.Run == run {
+			selected = append(selected, e)
+		}
+	}
+	recordingsMu.Unlock()
+	if limit > 0 && len(selected) > limit {
+		selected = selected[len(selected)-limit:]
+	}
+	sort.SliceStable(selected, func(i, j int) bool { return selected[i].Started.Before(selected[j].Started) })
+
+	entries := make([]map[string]interface{}, 0, len(selected))
+	for _, e := range selected {
+		entries = append(entries, e.har())
+	}
+	filename := "persona-mock.har"
+	if run != "" {
+		filename = "persona-mock-" + unsafeFilenameChars.ReplaceAllString(run, "_") + ".har"
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"log": map[string]interface{}{
+			"version": "1.2",
+			"creator": map[string]string{"name": "persona-mock", "version": "1.0"},
+			"comment": "recording enabled: " + strconv.FormatBool(trafficRecording.Enabled()),
+			"entries": entries,
+		},
+	})
+}
+
+// Handler for DELETE /admin/recordings
+func handleClearRecordings(w http.ResponseWriter, r *http.Request) {
+	recordingsMu.Lock()
+	recordings = nil
+	recordingsMu.Unlock()
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,314 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"persona-backend/idgen"
+)
+
+// Reputation module prototype: DIDs issue lightweight attestations about
+// other DIDs (a 1-5 rating or an endorsement with an optional tag), and the
+// scores endpoint aggregates the active ones. Only the latest rating from
+// each issuer counts towards a subject's average.
+
+func init() {
+	RegisterModule(newReputationModule)
+}
+
+const (
+	attestationRating      = "rating"
+	attestationEndorsement = "endorsement"
+)
+
+type attestation struct {
+	ID         string `json:"id"`
+	IssuerDID  string `json:"issuer_did"`
+	SubjectDID string `json:"subject_did"`
+	Kind       string `json:"kind"`
+	Rating     int    `json:"rating,omitempty"`
+	Tag        string `json:"tag,omitempty"`
+	Comment    string `json:"comment,omitempty"`
+	Creator    string `json:"creator"`
+	CreatedAt  int64  `json:"created_at"`
+	IsRevoked  bool   `json:"is_revoked"`
+	RevokedAt  int64  `json:"revoked_at,omitempty"`
+}
+
+func (a *attestation) toMap() map[string]interface{} {
+	data, _ := json.Marshal(a)
+	var m map[string]interface{}
+	json.Unmarshal(data, &m)
+	return m
+}
+
+// reputationStore holds attestations in issue order.
+type reputationStore struct {
+	Attestations []*attestation `json:"attestations"`
+}
+
+func (s *reputationStore) Reset() {
+	s.Attestations = []*attestation{}
+}
+
+func (s *reputationStore) find(id string) *attestation {
+	for _, a := range s.Attestations {
+		if a.ID == id {
+			return a
+		}
+	}
+	return nil
+}
+
+// reputationModule implements the persona reputation module.
+type reputationModule struct {
+	chain *Chain
+	store *reputationStore
+}
+
+func newReputationModule(c *Chain) Module {
+	store := &reputationStore{}
+	store.Reset()
+	return &reputationModule{chain: c, store: store}
+}
+
+func (m *reputationModule) Name() string { return "reputation" }
+
+func (m *reputationModule) Store() ModuleStore { return m.store }
+
+func (m *reputationModule) EventTypes() []string {
+	return []string{"reputation.attested", "reputation.revoked"}
+}
+
+func (m *reputationModule) RegisterMsgs(reg *MsgRegistry) {
+	registerMsg(reg, "/persona.reputation.v1.MsgAttest", m.handleMsgAttest)
+	registerMsg(reg, "/persona.reputation.v1.MsgRevokeAttestation", m.handleMsgRevokeAttestation)
+}
+
+func (m *reputationModule) RegisterRoutes(r *mux.Router) {
+	r.HandleFunc("/persona/reputation/v1beta1/attestations", m.handleListAttestations).Methods("GET", "OPTIONS")
+	r.HandleFunc("/persona/reputation/v1beta1/attestations/{id}", m.handleGetAttestation).Methods("GET", "OPTIONS")
+	r.HandleFunc("/persona/reputation/v1beta1/scores/{did}", m.handleGetScore).Methods("GET", "OPTIONS")
+}
+
+func (m *reputationModule) ExportGenesis() interface{} {
+	attestations := make([]map[string]interface{}, 0, len(m.store.Attestations))
+	for _, a := range m.store.Attestations {
+		attestations = append(attestations, a.toMap())
+	}
+	return map[string]interface{}{
+		"params":       map[string]interface{}{},
+		"attestations": attestations,
+	}
+}
+
+// Handler for GET /persona/reputation/v1beta1/attestations
+// Optional filters: issuer, subject, kind, include_revoked=true
+func (m *reputationModule) handleListAttestations(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	pageReq, err := parsePageRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	m.chain.mu.RLock()
+	items := []map[string]interface{}{}
+	for _, a := range m.store.Attestations {
+		if (q.Get("issuer") != "" && a.IssuerDID != q.Get("issuer")) ||
+			(q.Get("subject") != "" && a.SubjectDID != q.Get("subject")) ||
+			(q.Get("kind") != "" && a.Kind != q.Get("kind")) ||
+			(a.IsRevoked && q.Get("include_revoked") != "true") {
+			continue
+		}
+		items = append(items, a.toMap())
+	}
+	m.chain.mu.RUnlock()
+
+	page, pagination := paginate(items, keyByID, pageReq)
+
+	response := map[string]interface{}{
+		"attestations": page,
+		"pagination":   pagination,
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// Handler for GET /persona/reputation/v1beta1/attestations/{id}
+func (m *reputationModule) handleGetAttestation(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	m.chain.mu.RLock()
+	a := m.store.find(id)
+	var item map[string]interface{}
+	if a != nil {
+		item = a.toMap()
+	}
+	m.chain.mu.RUnlock()
+
+	if item == nil {
+		writeGRPCError(w, http.StatusNotFound, 5, fmt.Sprintf("attestation %s not found", id))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"attestation": item})
+}
+
+// Handler for GET /persona/reputation/v1beta1/scores/{did}
+//
+// score is the average rating scaled to 0-100; it is 0 until the DID has
+// been rated.
+func (m *reputationModule) handleGetScore(w http.ResponseWriter, r *http.Request) {
+	did := mux.Vars(r)["did"]
+
+	m.chain.mu.RLock()
+	latestRating := make(map[string]int)
+	endorsementsByTag := make(map[string]int)
+	issuers := make(map[string]bool)
+	endorsements := 0
+	var lastAttestedAt int64
+	for _, a := range m.store.Attestations {
+		if a.SubjectDID != did || a.IsRevoked {
+			continue
+		}
+		issuers[a.IssuerDID] = true
+		if a.CreatedAt > lastAttestedAt {
+			lastAttestedAt = a.CreatedAt
+		}
+		switch a.Kind {
+		case attestationRating:
+			latestRating[a.IssuerDID] = a.Rating
+		case attestationEndorsement:
+			endorsements++
+			if a.Tag != "" {
+				endorsementsByTag[a.Tag]++
+			}
+		}
+	}
+	m.chain.mu.RUnlock()
+
+	var average float64
+	if len(latestRating) > 0 {
+		total := 0
+		for _, rating := range latestRating {
+			total += rating
+		}
+		average = float64(total) / float64(len(latestRating))
+	}
+
+	response := map[string]interface{}{
+		"did": did,
+		"score": map[string]interface{}{
+			"score":               int(math.Round(average * 20)),
+			"average_rating":      math.Round(average*100) / 100,
+			"rating_count":        len(latestRating),
+			"endorsement_count":   endorsements,
+			"endorsements_by_tag": endorsementsByTag,
+			"unique_issuers":      len(issuers),
+			"last_attested_at":    lastAttestedAt,
+		},
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+type MsgAttest struct {
+	Creator    string `json:"creator"`
+	IssuerDID  string `json:"issuer_did"`
+	SubjectDID string `json:"subject_did"`
+	Kind       string `json:"kind"`
+	Rating     int    `json:"rating"`
+	Tag        string `json:"tag"`
+	Comment    string `json:"comment"`
+}
+
+func (m MsgAttest) ValidateBasic() error {
+	if m.Creator == "" {
+		return errors.New("creator is required")
+	}
+	if m.IssuerDID == "" || m.SubjectDID == "" {
+		return errors.New("issuer_did and subject_did are required")
+	}
+	if m.IssuerDID == m.SubjectDID {
+		return errors.New("a DID cannot attest about itself")
+	}
+	switch m.Kind {
+	case attestationRating:
+		if m.Rating < 1 || m.Rating > 5 {
+			return errors.New("rating must be between 1 and 5")
+		}
+	case attestationEndorsement:
+	default:
+		return fmt.Errorf("kind must be %s or %s", attestationRating, attestationEndorsement)
+	}
+	return nil
+}
+
+// handleMsgAttest stores an attestation. The issuer must be an active DID
+// controlled by the signer.
+func (m *reputationModule) handleMsgAttest(ctx *msgContext, msg MsgAttest) *txError {
+	issuer, exists := m.chain.did().store.Documents[msg.IssuerDID]
+	if !exists {
+		return txErrorf(codeNotFound, "issuer DID %s", msg.IssuerDID)
+	}
+	if issuer["controller"] != msg.Creator {
+		return txErrorf(codeUnauthorized, "%s is not the controller of %s", msg.Creator, msg.IssuerDID)
+	}
+	if issuer["is_active"] == false {
+		return txErrorf(codeInvalidRequest, "issuer DID %s is deactivated", msg.IssuerDID)
+	}
+
+	a := &attestation{
+		ID:         idgen.NewWithPrefix("att"),
+		IssuerDID:  msg.IssuerDID,
+		SubjectDID: msg.SubjectDID,
+		Kind:       msg.Kind,
+		Tag:        msg.Tag,
+		Comment:    msg.Comment,
+		Creator:    msg.Creator,
+		CreatedAt:  m.chain.now().Unix(),
+	}
+	if msg.Kind == attestationRating {
+		a.Rating = msg.Rating
+	}
+	m.store.Attestations = append(m.store.Attestations, a)
+	log.Printf("Stored %s attestation %s: %s -> %s", a.Kind, a.ID, a.IssuerDID, a.SubjectDID)
+	return nil
+}
+
+type MsgRevokeAttestation struct {
+	Creator       string `json:"creator"`
+	AttestationID string `json:"attestation_id"`
+}
+
+func (m MsgRevokeAttestation) ValidateBasic() error {
+	if m.AttestationID == "" {
+		return errors.New("attestation_id is required")
+	}
+	return nil
+}
+
+// handleMsgRevokeAttestation withdraws an attestation; only its signer may.
+func (m *reputationModule) handleMsgRevokeAttestation(ctx *msgContext, msg MsgRevokeAttestation) *txError {
+	a := m.store.find(msg.AttestationID)
+	if a == nil {
+		return txErrorf(codeNotFound, "attestation %s", msg.AttestationID)
+	}
+	if a.Creator != msg.Creator {
+		return txErrorf(codeUnauthorized, "%s cannot revoke attestation %s", msg.Creator, a.ID)
+	}
+	if a.IsRevoked {
+		return txErrorf(codeInvalidRequest, "attestation %s is already revoked", a.ID)
+	}
+	a.IsRevoked = true
+	a.RevokedAt = m.chain.now().Unix()
+	log.Printf("Revoked attestation %s by %s", a.ID, msg.Creator)
+	return nil
+}
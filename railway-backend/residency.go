@@ -0,0 +1,160 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// Data residency (DATA_RESIDENCY=true), for the frontend's enterprise
+// data-residency demo. DIDs and credentials are tagged with the residency
+// region of the request that created them: the X-Data-Residency header,
+// else the jurisdiction of the client region (us-east is us, eu-west is
+// eu, see geolatency.go), else DATA_RESIDENCY_DEFAULT (us). Untagged
+// records, such as those created before residency was enabled, belong to
+// the default region.
+//
+// Queries from another region are rejected with 403 for single records
+// and leave the records out of lists, unless the request is flagged with
+// ?cross_region=true or X-Allow-Cross-Region: true. GET /admin/snapshot
+// ?region= exports a region's partition only, stored at
+// snapshots/<region>/<name>.tar.zst with object storage.
+
+var residencyRegions = []string{"us", "eu", "ap", "sa"}
+
+var (
+	residencyEnabled = newFeatureFlag("data_residency", "DATA_RESIDENCY", "Pin DIDs and credentials to a home region")
+	residencyDefault = residencyDefaultFromEnv()
+)
+
+func residencyDefaultFromEnv() string {
+	region := strings.ToLower(os.Getenv("DATA_RESIDENCY_DEFAULT"))
+	if !containsString(residencyRegions, region) {
+		return "us"
+	}
+	return region
+}
+
+// requestResidency returns the residency region of a request, or "" when
+// residency is disabled.
+func requestResidency(r *http.Request) string {
+	if !residencyEnabled.Enabled() {
+		return ""
+	}
+	if region := strings.ToLower(r.Header.Get("X-Data-Residency")); containsString(residencyRegions, region) {
+		return region
+	}
+	if region := strings.SplitN(clientRegion(r), "-", 2)[0]; containsString(residencyRegions, region) {
+		return region
+	}
+	return residencyDefault
+}
+
+// recordResidency returns the region a record resides in.
+func recordResidency(record map[string]interface{}) string {
+	if region, _ := record["residency"].(string); region != "" {
+		return region
+	}
+	return residencyDefault
+}
+
+// crossRegionAllowed reports whether a request may read records of any
+// region.
+func crossRegionAllowed(r *http.Request) bool {
+	return !residencyEnabled.Enabled() || r.URL.Query().Get("cross_region") == "true" ||
+		strings.EqualFold(r.Header.Get("X-Allow-Cross-Region"), "true")
+}
+
+// residencyVisible reports whether a request may read a record.
+func residencyVisible(r *http.Request, record map[string]interface{}) bool {
+	return crossRegionAllowed(r) || recordResidency(record) == requestResidency(r)
+}
+
+// residencyDenied writes a 403 and returns true when a request may not
+// read a record.
+func residencyDenied(w http.ResponseWriter, r *http.Request, record map[string]interface{}) bool {
+	if residencyVisible(r, record) {
+		return false
+	}
+	writeGRPCError(w, http.StatusForbidden, 7, "record resides in region "+recordResidency(record)+", not "+requestResidency(r)+"; set cross_region=true to read it")
+	return true
+}
+
+// residencyPartitioner is implemented by module stores holding personal
+// data.
+type residencyPartitioner interface {
+	// partition returns a copy of the store with only the region's records.
+	partition(region string) ModuleStore
+}
+
+func (s *didStore) partition(region string) ModuleStore {
+	p := &didStore{}
+	p.Reset()
+	for id, document := range s.Documents {
+		if recordResidency(document) != region {
+			continue
+		}
+		p.Documents[id] = document
+		if versions, ok := s.Versions[id]; ok {
+			p.Versions[id] = versions
+		}
+	}
+	for controller, id := range s.ByController {
+		if _, ok := p.Documents[id]; ok {
+			p.ByController[controller] = id
+		}
+	}
+	return p
+}
+
+func (s *vcStore) partition(region string) ModuleStore {
+	p := &vcStore{Schemas: s.Schemas, ByController: make(map[string][]map[string]interface{})}
+	for controller, credentials := range s.ByController {
+		for _, credential := range credentials {
+			if recordResidency(credential) == region {
+				p.ByController[controller] = append(p.ByController[controller], credential)
+			}
+		}
+	}
+	return p
+}
+
+// Handler for GET /admin/residency. Counts the records of each partition.
+func (c *Chain) handleGetResidency(w http.ResponseWriter, r *http.Request) {
+	partitions := make(map[string]map[string]int, len(residencyRegions))
+	for _, region := range residencyRegions {
+		partitions[region] = map[string]int{"did_documents": 0, "credentials": 0, "txs": 0}
+	}
+	count := func(region, kind string) {
+		if partitions[region] != nil {
+			partitions[region][kind]++
+		}
+	}
+	c.mu.RLock()
+	for _, document := range c.did().store.Documents {
+		count(recordResidency(document), "did_documents")
+	}
+	for _, credentials := range c.vc().store.ByController {
+		for _, credential := range credentials {
+			count(recordResidency(credential), "credentials")
+		}
+	}
+	for _, tx := range c.txsByHash {
+		if tx.Residency != "" {
+			count(tx.Residency, "txs")
+		} else {
+			count(residencyDefault, "txs")
+		}
+	}
+	c.mu.RUnlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"enabled":        residencyEnabled.Enabled(),
+		"default_region": residencyDefault,
+		"regions":        residencyRegions,
+		"your_region":    requestResidency(r),
+		"partitions":     partitions,
+	})
+}
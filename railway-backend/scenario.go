@@ -0,0 +1,459 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"persona-backend/clock"
+)
+
+// Scenario scripts: POST /admin/scenarios/run takes a YAML or JSON script
+// of steps and runs them server-side in one call, so a suite can set up
+// complex preconditions without dozens of requests. For example
+//
+//	name: revoked after a month
+//	reset: true
+//	steps:
+//	  - create_did: {id: did:persona:alice, controller: cosmos1alice}
+//	  - issue_vc: {id: "urn:uuid:vc-age", issuer: did:persona:alice, subject: did:persona:bob, type: AgeCredential}
+//	  - advance_time: 30d
+//	  - revoke_vc: {id: "urn:uuid:vc-age", reason: expired}
+//
+// Steps run through the same message handlers as broadcast txs but are not
+// stored as txs. The script is atomic: if a step fails, module stores and
+// the clock are rolled back and the report says which step failed.
+// ?dry_run=true always rolls back. reset: true wipes the state first, as
+// /admin/seed?reset=true does; the wipe itself is not rolled back, and is
+// skipped on a dry run.
+
+type scenarioScript struct {
+	Name  string                   `json:"name"`
+	Reset bool                     `json:"reset"`
+	Steps []map[string]interface{} `json:"steps"`
+}
+
+type scenarioStepResult struct {
+	Index  int    `json:"index"`
+	Action string `json:"action"`
+	Status string `json:"status"` // ok, failed or skipped
+	Detail string `json:"detail,omitempty"`
+}
+
+type scenarioReport struct {
+	Name       string               `json:"name,omitempty"`
+	OK         bool                 `json:"ok"`
+	DryRun     bool                 `json:"dry_run"`
+	RolledBack bool                 `json:"rolled_back"`
+	FailedStep *int                 `json:"failed_step"`
+	Error      string               `json:"error,omitempty"`
+	Steps      []scenarioStepResult `json:"steps"`
+	// Time is the chain time after the script, as Unix seconds
+	Time int64 `json:"time"`
+}
+
+// scenarioRun is the state of one script run. Steps run with c.mu held.
+type scenarioRun struct {
+	chain    *Chain
+	advanced time.Duration
+	// Activity events to publish if the script commits
+	activity []activityEvent
+}
+
+// scenarioActions map each step action to its implementation, which gets
+// the step's arguments and returns a detail line for the report.
+var scenarioActions = map[string]func(run *scenarioRun, args map[string]interface{}) (string, error){
+	"create_did":     scenarioCreateDID,
+	"update_did":     scenarioUpdateDID,
+	"deactivate_did": scenarioDeactivateDID,
+	"issue_vc":       scenarioIssueVC,
+	"revoke_vc":      scenarioRevokeVC,
+	"submit_proof":   scenarioSubmitProof,
+	"msg":            scenarioMsg,
+	"seed":           scenarioSeed,
+	"advance_time":   scenarioAdvanceTime,
+}
+
+func stringArg(args map[string]interface{}, key string) string {
+	value, _ := args[key].(string)
+	return value
+}
+
+func requireArgs(args map[string]interface{}, keys ...string) error {
+	for _, key := range keys {
+		if stringArg(args, key) == "" {
+			return fmt.Errorf("%s is required", key)
+		}
+	}
+	return nil
+}
+
+// deliver runs one message through the chain's message handlers.
+func (run *scenarioRun) deliver(msg map[string]interface{}) error {
+	data, _ := json.Marshal(msg)
+	ctx := &msgContext{TxHash: computeTxHash(data), Height: run.chain.info.LatestHeight}
+	if _, txErr := run.chain.msgs.Deliver(ctx, []interface{}{msg}); txErr != nil {
+		return fmt.Errorf("%s (code %d)", txErr.Log, txErr.Code)
+	}
+	// Scenario steps are not stored as txs, so there is no hash to link
+	now := run.chain.now().Unix()
+	for _, ev := range ctx.Activity {
+		ev.TxHash, ev.Time = "", now
+		run.activity = append(run.activity, ev)
+	}
+	return nil
+}
+
+// didController returns the controller of a stored DID, or the controller
+// argument when one is given.
+func (run *scenarioRun) didController(args map[string]interface{}, did string) (string, error) {
+	if controller := stringArg(args, "controller"); controller != "" {
+		return controller, nil
+	}
+	if controller := run.chain.did().controllerOf(did); controller != "" {
+		return controller, nil
+	}
+	return "", fmt.Errorf("unknown DID %s; give its controller", did)
+}
+
+// Arguments: id, controller and optionally document, merged into the DID
+// document.
+func scenarioCreateDID(run *scenarioRun, args map[string]interface{}) (string, error) {
+	if err := requireArgs(args, "id", "controller"); err != nil {
+		return "", err
+	}
+	document := map[string]interface{}{}
+	if extra, ok := args["document"].(map[string]interface{}); ok {
+		for key, value := range extra {
+			document[key] = value
+		}
+	}
+	document["id"], document["controller"] = args["id"], args["controller"]
+	err := run.deliver(map[string]interface{}{
+		"@type":        "/persona.did.v1.MsgCreateDid",
+		"creator":      args["controller"],
+		"did_document": document,
+	})
+	return fmt.Sprintf("created %s controlled by %s", args["id"], args["controller"]), err
+}
+
+// Arguments: id, document and optionally controller.
+func scenarioUpdateDID(run *scenarioRun, args map[string]interface{}) (string, error) {
+	if err := requireArgs(args, "id"); err != nil {
+		return "", err
+	}
+	controller, err := run.didController(args, stringArg(args, "id"))
+	if err != nil {
+		return "", err
+	}
+	document, _ := args["document"].(map[string]interface{})
+	if document == nil {
+		return "", fmt.Errorf("document is required")
+	}
+	document["id"] = args["id"]
+	err = run.deliver(map[string]interface{}{
+		"@type":        "/persona.did.v1.MsgUpdateDid",
+		"creator":      controller,
+		"did_document": document,
+	})
+	return fmt.Sprintf("updated %s", args["id"]), err
+}
+
+// Arguments: id and optionally controller.
+func scenarioDeactivateDID(run *scenarioRun, args map[string]interface{}) (string, error) {
+	if err := requireArgs(args, "id"); err != nil {
+		return "", err
+	}
+	controller, err := run.didController(args, stringArg(args, "id"))
+	if err != nil {
+		return "", err
+	}
+	err = run.deliver(map[string]interface{}{
+		"@type":   "/persona.did.v1.MsgDeactivateDid",
+		"creator": controller,
+		"did_id":  args["id"],
+	})
+	return fmt.Sprintf("deactivated %s", args["id"]), err
+}
+
+// Arguments: id, issuer (a DID) and optionally controller (defaults to the
+// issuer's controller), subject (defaulting to the issuer when there are no
+// claims), type, claims (merged into credentialSubject) and credential
+// (merged into the credential). id must be a URI.
+func scenarioIssueVC(run *scenarioRun, args map[string]interface{}) (string, error) {
+	if err := requireArgs(args, "id", "issuer"); err != nil {
+		return "", err
+	}
+	controller, err := run.didController(args, stringArg(args, "issuer"))
+	if err != nil {
+		return "", err
+	}
+
+	subject := map[string]interface{}{}
+	if claims, ok := args["claims"].(map[string]interface{}); ok {
+		for key, value := range claims {
+			subject[key] = value
+		}
+	}
+	if id := stringArg(args, "subject"); id != "" {
+		subject["id"] = id
+	} else if len(subject) == 0 {
+		// The data model needs a claim; without one the credential is self-issued
+		subject["id"] = args["issuer"]
+	}
+	var types []interface{}
+	switch t := args["type"].(type) {
+	case string:
+		types = append(types, t)
+	case []interface{}:
+		types = append(types, t...)
+	}
+	credential := w3cCredential(stringArg(args, "id"), stringArg(args, "issuer"), types, subject, run.chain.now())
+	if extra, ok := args["credential"].(map[string]interface{}); ok {
+		for key, value := range extra {
+			credential[key] = value
+		}
+	}
+
+	err = run.deliver(map[string]interface{}{
+		"@type":   "/persona.vc.v1.MsgIssueCredential",
+		"creator": controller,
+		"vc_data": credential,
+	})
+	return fmt.Sprintf("issued %s by %s", args["id"], args["issuer"]), err
+}
+
+// Arguments: id and optionally reason, reason_code, explanation and by
+// (the revoking address, defaulting to the controller the credential is
+// stored under).
+func scenarioRevokeVC(run *scenarioRun, args map[string]interface{}) (string, error) {
+	if err := requireArgs(args, "id"); err != nil {
+		return "", err
+	}
+	by := stringArg(args, "by")
+	if by == "" {
+		by, _ = run.chain.vc().findCredential(stringArg(args, "id"))
+	}
+	err := run.deliver(map[string]interface{}{
+		"@type":         "/persona.vc.v1.MsgRevokeCredential",
+		"creator":       by,
+		"credential_id": args["id"],
+		"reason":        args["reason"],
+		"reason_code":   args["reason_code"],
+		"explanation":   args["explanation"],
+	})
+	return fmt.Sprintf("revoked %s", args["id"]), err
+}
+
+// Arguments: prover, circuit_id and optionally proof, public_inputs and
+// metadata.
+func scenarioSubmitProof(run *scenarioRun, args map[string]interface{}) (string, error) {
+	if err := requireArgs(args, "prover", "circuit_id"); err != nil {
+		return "", err
+	}
+	proof := stringArg(args, "proof")
+	if proof == "" {
+		proof = "scenario"
+	}
+	err := run.deliver(map[string]interface{}{
+		"@type":         "/persona.zk.v1.MsgSubmitProof",
+		"creator":       args["prover"],
+		"circuit_id":    args["circuit_id"],
+		"proof":         proof,
+		"public_inputs": args["public_inputs"],
+		"metadata":      args["metadata"],
+	})
+	return fmt.Sprintf("proof for %s by %s", args["circuit_id"], args["prover"]), err
+}
+
+// The arguments are a message with @type, as it would appear in a tx.
+func scenarioMsg(run *scenarioRun, args map[string]interface{}) (string, error) {
+	typeURL := stringArg(args, "@type")
+	if run.chain.msgs.GoType(typeURL) == nil {
+		return "", fmt.Errorf("unknown message type %q", typeURL)
+	}
+	return typeURL, run.deliver(args)
+}
+
+// The arguments are a seed payload as accepted by POST /admin/seed.
+func scenarioSeed(run *scenarioRun, args map[string]interface{}) (string, error) {
+	records := map[string][]map[string]interface{}{}
+	for key, value := range args {
+		list, ok := value.([]interface{})
+		if !ok {
+			return "", fmt.Errorf("%s must be an array of objects", key)
+		}
+		for _, item := range list {
+			record, ok := item.(map[string]interface{})
+			if !ok {
+				return "", fmt.Errorf("%s must be an array of objects", key)
+			}
+			records[key] = append(records[key], record)
+		}
+	}
+	if err := run.chain.seedLocked(nil, records); err != nil {
+		return "", err
+	}
+	counts := []string{}
+	for _, key := range sortedMapKeys(records) {
+		counts = append(counts, fmt.Sprintf("%d %s", len(records[key]), key))
+	}
+	return "seeded " + strings.Join(counts, ", "), nil
+}
+
+// The argument is a duration such as 30d, 12h or 90m.
+func scenarioAdvanceTime(run *scenarioRun, args map[string]interface{}) (string, error) {
+	d, err := parseScenarioDuration(stringArg(args, "duration"))
+	if err != nil {
+		return "", err
+	}
+	advancer, ok := run.chain.clock.(clock.Advancer)
+	if !ok {
+		return "", fmt.Errorf("the chain clock cannot be advanced")
+	}
+	advancer.Advance(d)
+	run.advanced += d
+	return "now " + run.chain.now().UTC().Format(time.RFC3339), nil
+}
+
+// parseScenarioDuration accepts Go durations plus a d (day) suffix.
+func parseScenarioDuration(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.ParseFloat(days, 64)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		return time.Duration(n * float64(24*time.Hour)), nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	return d, nil
+}
+
+// parseScenarioStep splits a step into its action and arguments. A step
+// is a single-key object; a scalar argument such as advance_time: 30d is
+// taken as the duration.
+func parseScenarioStep(step map[string]interface{}) (string, map[string]interface{}, error) {
+	if len(step) != 1 {
+		return "", nil, fmt.Errorf("a step must have exactly one action, got %s", strings.Join(sortedMapKeys(step), ", "))
+	}
+	for action, value := range step {
+		if scenarioActions[action] == nil {
+			return action, nil, fmt.Errorf("unknown action %q; known actions: %s", action, strings.Join(sortedMapKeys(scenarioActions), ", "))
+		}
+		switch v := value.(type) {
+		case map[string]interface{}:
+			return action, v, nil
+		case string:
+			return action, map[string]interface{}{"duration": v}, nil
+		case nil:
+			return action, map[string]interface{}{}, nil
+		}
+		return action, nil, fmt.Errorf("%s takes an object", action)
+	}
+	return "", nil, nil
+}
+
+// runScenario runs a script atomically and reports each step.
+func (c *Chain) runScenario(script scenarioScript, dryRun bool) scenarioReport {
+	report := scenarioReport{Name: script.Name, DryRun: dryRun, Steps: []scenarioStepResult{}}
+
+	if script.Reset && !dryRun {
+		c.resetState()
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	backup := c.backupStoresLocked()
+	run := &scenarioRun{chain: c}
+
+	for i, step := range script.Steps {
+		result := scenarioStepResult{Index: i, Status: "ok"}
+		action, args, err := parseScenarioStep(step)
+		result.Action = action
+		if err == nil {
+			result.Detail, err = scenarioActions[action](run, args)
+		}
+		if err != nil {
+			result.Status, result.Detail = "failed", err.Error()
+			report.FailedStep = &result.Index
+			report.Error = fmt.Sprintf("step %d (%s): %v", i, action, err)
+		}
+		report.Steps = append(report.Steps, result)
+		if err != nil {
+			break
+		}
+	}
+	if report.FailedStep != nil {
+		for _, step := range script.Steps[*report.FailedStep+1:] {
+			action, _, _ := parseScenarioStep(step)
+			report.Steps = append(report.Steps, scenarioStepResult{Index: len(report.Steps), Action: action, Status: "skipped"})
+		}
+	}
+
+	report.OK = report.FailedStep == nil
+	if !report.OK || dryRun {
+		c.restoreStoresLocked(backup)
+		if run.advanced != 0 {
+			c.clock.(clock.Advancer).Advance(-run.advanced)
+		}
+		report.RolledBack = true
+	} else {
+		c.publishActivity(run.activity)
+	}
+	report.Time = c.now().Unix()
+	return report
+}
+
+// Handler for POST /admin/scenarios/run. The body is a YAML or JSON
+// script; JSON is valid YAML, so both go through the YAML decoder.
+func (c *Chain) handleRunScenario(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Failed to read request body", http.StatusBadRequest)
+		return
+	}
+	var content interface{}
+	if err := yaml.Unmarshal(body, &content); err != nil {
+		http.Error(w, "Invalid scenario: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	// A bare list is the steps
+	if steps, ok := content.([]interface{}); ok {
+		content = map[string]interface{}{"steps": steps}
+	}
+	encoded, _ := json.Marshal(content)
+	var script scenarioScript
+	if err := json.Unmarshal(encoded, &script); err != nil {
+		http.Error(w, "Invalid scenario: expected an object with a steps list", http.StatusBadRequest)
+		return
+	}
+	if len(script.Steps) == 0 {
+		http.Error(w, "Invalid scenario: no steps", http.StatusBadRequest)
+		return
+	}
+
+	report := c.runScenario(script, r.URL.Query().Get("dry_run") == "true")
+	if report.OK {
+		log.Printf("Scenario %q: %d steps run", script.Name, len(report.Steps))
+	} else {
+		log.Printf("Scenario %q failed, rolled back: %s", script.Name, report.Error)
+	}
+
+	status := http.StatusOK
+	if !report.OK {
+		status = http.StatusUnprocessableEntity
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(report)
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"persona-backend/jsonschema"
+)
+
+// Credential schema registry of the vc module. A JSON Schema registered
+// for a credential type at POST /persona/vc/v1beta1/schemas is checked
+// against the credentialSubject of every credential issued with that type
+// afterwards, either in its W3C type list or as credentialSubject
+// .credentialType. Violations reject MsgIssueCredential with code 12 in
+// the vc codespace, listing each violation in raw_log. Schemas are chain
+// state: /admin/reset removes them.
+
+type credentialSchema struct {
+	Type      string      `json:"type"`
+	Schema    interface{} `json:"schema"`
+	CreatedAt int64       `json:"created_at"`
+}
+
+// credentialTypes returns the types a credential declares, apart from the
+// VerifiableCredential base type.
+func credentialTypes(credential map[string]interface{}) []string {
+	var types []string
+	switch t := credential["type"].(type) {
+	case string:
+		types = append(types, t)
+	case []interface{}:
+		for _, item := range t {
+			if name, ok := item.(string); ok {
+				types = append(types, name)
+			}
+		}
+	}
+	if subject, ok := credential["credentialSubject"].(map[string]interface{}); ok {
+		if name, ok := subject["credentialType"].(string); ok {
+			types = appendUnique(types, name)
+		}
+	}
+	kept := types[:0]
+	for _, name := range types {
+		if name != "VerifiableCredential" {
+			kept = append(kept, name)
+		}
+	}
+	return kept
+}
+
+// checkSchemas validates the credential subject against the schema of each
+// of the credential's types and returns the violations. Must be called
+// with the chain lock held.
+func (m *vcModule) checkSchemas(credential map[string]interface{}) []string {
+	var violations []string
+	for _, credentialType := range credentialTypes(credential) {
+		registered := m.store.Schemas[credentialType]
+		if registered == nil {
+			continue
+		}
+		schema, err := jsonschema.Compile(registered.Schema)
+		if err != nil {
+			violations = append(violations, fmt.Sprintf("schema for %s: %v", credentialType, err))
+			continue
+		}
+		for _, violation := range schema.Validate(credential["credentialSubject"]) {
+			path := "credentialSubject"
+			if violation.Path != "" {
+				path += "." + violation.Path
+			}
+			violations = append(violations, fmt.Sprintf("%s %s (%s)", path, violation.Message, credentialType))
+		}
+	}
+	return violations
+}
+
+// Handler for POST /persona/vc/v1beta1/schemas
+//
+// Takes {"type": "AgeCredential", "schema": {...}}; registering a type
+// again replaces its schema.
+func (m *vcModule) handleRegisterSchema(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Type   string      `json:"type"`
+		Schema interface{} `json:"schema"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+	if req.Type == "" || req.Schema == nil {
+		http.Error(w, "Missing required fields: type, schema", http.StatusBadRequest)
+		return
+	}
+	if _, err := jsonschema.Compile(req.Schema); err != nil {
+		http.Error(w, "Invalid schema: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	m.chain.mu.Lock()
+	_, replaced := m.store.Schemas[req.Type]
+	registered := &credentialSchema{Type: req.Type, Schema: req.Schema, CreatedAt: m.chain.now().Unix()}
+	m.store.Schemas[req.Type] = registered
+	m.chain.mu.Unlock()
+
+	log.Printf("Registered credential schema for %s", req.Type)
+	w.Header().Set("Content-Type", "application/json")
+	if !replaced {
+		w.WriteHeader(http.StatusCreated)
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{"schema": registered})
+}
+
+// Handler for GET /persona/vc/v1beta1/schemas
+func (m *vcModule) handleListSchemas(w http.ResponseWriter, r *http.Request) {
+	pageReq, err := parsePageRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	m.chain.mu.RLock()
+	schemas := make([]map[string]interface{}, 0, len(m.store.Schemas))
+	for _, credentialType := range sortedMapKeys(m.store.Schemas) {
+		registered := m.store.Schemas[credentialType]
+		schemas = append(schemas, map[string]interface{}{
+			"type":       registered.Type,
+			"schema":     registered.Schema,
+			"created_at": registered.CreatedAt,
+		})
+	}
+	m.chain.mu.RUnlock()
+
+	page, pagination := paginate(schemas, func(_ int, item map[string]interface{}) string {
+		return item["type"].(string)
+	}, pageReq)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"schemas":    page,
+		"pagination": pagination,
+	})
+}
+
+// Handler for GET /persona/vc/v1beta1/schemas/{type}
+func (m *vcModule) handleGetSchema(w http.ResponseWriter, r *http.Request) {
+	credentialType := mux.Vars(r)["type"]
+
+	m.chain.mu.RLock()
+	registered := m.store.Schemas[credentialType]
+	m.chain.mu.RUnlock()
+
+	if registered == nil {
+		writeGRPCError(w, http.StatusNotFound, 5, fmt.Sprintf("no schema for credential type %s", credentialType))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"schema": registered})
+}
+
+// Handler for DELETE /persona/vc/v1beta1/schemas/{type}
+func (m *vcModule) handleDeleteSchema(w http.ResponseWriter, r *http.Request) {
+	credentialType := mux.Vars(r)["type"]
+
+	m.chain.mu.Lock()
+	_, ok := m.store.Schemas[credentialType]
+	delete(m.store.Schemas, credentialType)
+	m.chain.mu.Unlock()
+
+	if !ok {
+		writeGRPCError(w, http.StatusNotFound, 5, fmt.Sprintf("no schema for credential type %s", credentialType))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"removed": 1})
+}
@@ -0,0 +1,544 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SD-JWT VCs (IETF SD-JWT and SD-JWT VC drafts) for the selective
+// disclosure UI. POST /persona/vc/v1beta1/sd-jwt/issue signs a credential
+// for a stored issuer DID in which each claim is a salted disclosure only
+// its SHA-256 digest is signed over:
+//
+//	<issuer-signed JWT>~<disclosure>~<disclosure>~[<key binding JWT>]
+//
+// The holder presents the JWT with the disclosures it chooses to reveal,
+// and POST /persona/vc/v1beta1/sd-jwt/verify checks the signature, expiry
+// and digests (and the key binding JWT, when the credential is bound to a
+// holder key) and returns the revealed claims. JWTs are ES256 under a P-256
+// key generated at startup, published at GET
+// /persona/vc/v1beta1/sd-jwt/jwks. Issuance does not write chain state; a
+// credential_id ties the SD-JWT to an issued credential whose revocation
+// then fails verification.
+
+const (
+	sdJWTType        = "dc+sd-jwt"
+	sdJWTKeyBindType = "kb+jwt"
+	sdJWTKeyID       = "persona-sd-jwt-1"
+)
+
+var sdJWTKey = mustGenerateSDJWTKey()
+
+func mustGenerateSDJWTKey() *ecdsa.PrivateKey {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		log.Fatalf("Failed to generate SD-JWT signing key: %v", err)
+	}
+	return key
+}
+
+var b64url = base64.RawURLEncoding
+
+func b64JSON(v interface{}) string {
+	data, _ := json.Marshal(v)
+	return b64url.EncodeToString(data)
+}
+
+func sdDigest(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return b64url.EncodeToString(sum[:])
+}
+
+// signES256 returns a compact JWS of header and payload.
+func signES256(key *ecdsa.PrivateKey, header, payload map[string]interface{}) (string, error) {
+	input := b64JSON(header) + "." + b64JSON(payload)
+	hash := sha256.Sum256([]byte(input))
+	r, s, err := ecdsa.Sign(rand.Reader, key, hash[:])
+	if err != nil {
+		return "", err
+	}
+	sig := make([]byte, 64)
+	r.FillBytes(sig[:32])
+	s.FillBytes(sig[32:])
+	return input + "." + b64url.EncodeToString(sig), nil
+}
+
+// parseES256 checks a compact JWS against key and returns its header and
+// payload.
+func parseES256(jws string, key *ecdsa.PublicKey) (header, payload map[string]interface{}, err error) {
+	parts := strings.Split(jws, ".")
+	if len(parts) != 3 {
+		return nil, nil, errors.New("not a compact JWT")
+	}
+	if err := decodeJWTPart(parts[0], &header); err != nil {
+		return nil, nil, fmt.Errorf("header: %v", err)
+	}
+	if header["alg"] != "ES256" {
+		return nil, nil, fmt.Errorf("unsupported alg %v, want ES256", header["alg"])
+	}
+	sig, err := b64url.DecodeString(parts[2])
+	if err != nil || len(sig) != 64 {
+		return nil, nil, errors.New("malformed signature")
+	}
+	hash := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	r, s := new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:])
+	if !ecdsa.Verify(key, hash[:], r, s) {
+		return nil, nil, errors.New("invalid signature")
+	}
+	if err := decodeJWTPart(parts[1], &payload); err != nil {
+		return nil, nil, fmt.Errorf("payload: %v", err)
+	}
+	return header, payload, nil
+}
+
+func decodeJWTPart(part string, v interface{}) error {
+	data, err := b64url.DecodeString(part)
+	if err != nil {
+		return errors.New("not base64url")
+	}
+	return json.Unmarshal(data, v)
+}
+
+func publicJWK(key *ecdsa.PublicKey) map[string]interface{} {
+	x, y := make([]byte, 32), make([]byte, 32)
+	key.X.FillBytes(x)
+	key.Y.FillBytes(y)
+	return map[string]interface{}{
+		"kty": "EC",
+		"crv": "P-256",
+		"x":   b64url.EncodeToString(x),
+		"y":   b64url.EncodeToString(y),
+	}
+}
+
+// parseJWK reads a P-256 public key in JWK form, as cnf.jwk carries it.
+func parseJWK(v interface{}) (*ecdsa.PublicKey, error) {
+	jwk, ok := v.(map[string]interface{})
+	if !ok || jwk["kty"] != "EC" || jwk["crv"] != "P-256" {
+		return nil, errors.New("only EC P-256 keys are supported")
+	}
+	xs, _ := jwk["x"].(string)
+	ys, _ := jwk["y"].(string)
+	x, errX := b64url.DecodeString(xs)
+	y, errY := b64url.DecodeString(ys)
+	if errX != nil || errY != nil || len(x) != 32 || len(y) != 32 {
+		return nil, errors.New("malformed x or y")
+	}
+	key := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+	if !key.Curve.IsOnCurve(key.X, key.Y) {
+		return nil, errors.New("point is not on P-256")
+	}
+	return key, nil
+}
+
+type sdJWTDisclosure struct {
+	Claim      string      `json:"claim"`
+	Value      interface{} `json:"value"`
+	Disclosure string      `json:"disclosure"`
+	Digest     string      `json:"digest"`
+}
+
+func newDisclosure(claim string, value interface{}) sdJWTDisclosure {
+	salt := make([]byte, 16)
+	rand.Read(salt)
+	encoded := b64JSON([]interface{}{b64url.EncodeToString(salt), claim, value})
+	return sdJWTDisclosure{Claim: claim, Value: value, Disclosure: encoded, Digest: sdDigest(encoded)}
+}
+
+type sdJWTIssueRequest struct {
+	Issuer  string                 `json:"issuer"`
+	Subject string                 `json:"subject"`
+	VCT     string                 `json:"vct"`
+	Claims  map[string]interface{} `json:"claims"`
+	// AlwaysDisclosed names claims signed in the clear rather than as
+	// disclosures
+	AlwaysDisclosed []string `json:"always_disclosed"`
+	// ExpiresIn is a Go duration such as 720h, on the chain clock
+	ExpiresIn    string `json:"expires_in"`
+	CredentialID string `json:"credential_id"`
+	// HolderJWK binds the credential to a holder key, which must then sign
+	// a key binding JWT when presenting it
+	HolderJWK map[string]interface{} `json:"holder_jwk"`
+}
+
+// Handler for POST /persona/vc/v1beta1/sd-jwt/issue
+func (m *vcModule) handleIssueSDJWT(w http.ResponseWriter, r *http.Request) {
+	var req sdJWTIssueRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+	if req.Issuer == "" || req.VCT == "" || len(req.Claims) == 0 {
+		http.Error(w, "Missing required fields: issuer, vct, claims", http.StatusBadRequest)
+		return
+	}
+	var expiresIn time.Duration
+	if req.ExpiresIn != "" {
+		var err error
+		if expiresIn, err = time.ParseDuration(req.ExpiresIn); err != nil || expiresIn <= 0 {
+			http.Error(w, "expires_in must be a positive duration such as 720h", http.StatusBadRequest)
+			return
+		}
+	}
+	if req.HolderJWK != nil {
+		if _, err := parseJWK(req.HolderJWK); err != nil {
+			http.Error(w, "Invalid holder_jwk: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	m.chain.mu.RLock()
+	issuer := m.chain.did().store.Documents[req.Issuer]
+	deactivated := issuer != nil && issuer["is_active"] == false
+	now := m.chain.now()
+	m.chain.mu.RUnlock()
+	if issuer == nil {
+		writeGRPCError(w, http.StatusNotFound, 5, fmt.Sprintf("issuer DID %s not found", req.Issuer))
+		return
+	}
+	if deactivated {
+		writeGRPCError(w, http.StatusBadRequest, 9, fmt.Sprintf("issuer DID %s is deactivated", req.Issuer))
+		return
+	}
+
+	payload := map[string]interface{}{
+		"iss":     req.Issuer,
+		"iat":     now.Unix(),
+		"vct":     req.VCT,
+		"_sd_alg": "sha-256",
+	}
+	if req.Subject != "" {
+		payload["sub"] = req.Subject
+	}
+	if expiresIn > 0 {
+		payload["exp"] = now.Add(expiresIn).Unix()
+	}
+	if req.CredentialID != "" {
+		payload["jti"] = req.CredentialID
+	}
+	if req.HolderJWK != nil {
+		payload["cnf"] = map[string]interface{}{"jwk": req.HolderJWK}
+	}
+	inClear := make(map[string]bool, len(req.AlwaysDisclosed))
+	for _, claim := range req.AlwaysDisclosed {
+		inClear[claim] = true
+	}
+	disclosures := []sdJWTDisclosure{}
+	digests := []string{}
+	for _, claim := range sortedMapKeys(req.Claims) {
+		if _, reserved := payload[claim]; reserved || claim == "_sd" {
+			http.Error(w, "claims may not use the registered claim "+claim, http.StatusBadRequest)
+			return
+		}
+		if inClear[claim] {
+			payload[claim] = req.Claims[claim]
+			continue
+		}
+		disclosure := newDisclosure(claim, req.Claims[claim])
+		disclosures = append(disclosures, disclosure)
+		digests = append(digests, disclosure.Digest)
+	}
+	// Sorted so the digest order does not give away the claim order
+	sort.Strings(digests)
+	payload["_sd"] = digests
+
+	jwt, err := signES256(sdJWTKey, map[string]interface{}{"alg": "ES256", "typ": sdJWTType, "kid": sdJWTKeyID}, payload)
+	if err != nil {
+		http.Error(w, "Failed to sign SD-JWT", http.StatusInternalServerError)
+		return
+	}
+	combined := jwt + "~"
+	for _, disclosure := range disclosures {
+		combined += disclosure.Disclosure + "~"
+	}
+
+	log.Printf("Issued SD-JWT %s for %s with %d disclosures", req.VCT, req.Issuer, len(disclosures))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"sd_jwt":      combined,
+		"issuer_jwt":  jwt,
+		"disclosures": disclosures,
+	})
+}
+
+type sdJWTVerifyRequest struct {
+	// Presentation is the combined <JWT>~<disclosure>~...~[<KB-JWT>] form;
+	// alternatively SDJWT carries the issuer-signed JWT and Disclosures the
+	// selected disclosures
+	Presentation string   `json:"presentation"`
+	SDJWT        string   `json:"sd_jwt"`
+	Disclosures  []string `json:"disclosures"`
+	KeyBinding   string   `json:"kb_jwt"`
+	// Audience and Nonce, when set, must match the key binding JWT
+	Audience          string `json:"aud"`
+	Nonce             string `json:"nonce"`
+	RequireKeyBinding bool   `json:"require_key_binding"`
+}
+
+// split returns the issuer-signed JWT, the disclosures and the key binding
+// JWT of the presentation.
+func (req sdJWTVerifyRequest) split() (string, []string, string) {
+	if req.Presentation == "" {
+		jwt := strings.Split(req.SDJWT, "~")[0]
+		return jwt, req.Disclosures, req.KeyBinding
+	}
+	parts := strings.Split(req.Presentation, "~")
+	var disclosures []string
+	for _, part := range parts[1 : len(parts)-1] {
+		if part != "" {
+			disclosures = append(disclosures, part)
+		}
+	}
+	return parts[0], disclosures, parts[len(parts)-1]
+}
+
+// revealClaims replaces the digests in an SD-JWT payload with the claims of
+// the matching disclosures, dropping undisclosed ones, and returns the
+// names of the disclosed claims. Every disclosure must be referenced
+// exactly once.
+func revealClaims(payload map[string]interface{}, disclosures []string) (map[string]interface{}, []string, error) {
+	byDigest := make(map[string][]interface{}, len(disclosures))
+	for _, disclosure := range disclosures {
+		var decoded []interface{}
+		if err := decodeJWTPart(disclosure, &decoded); err != nil || (len(decoded) != 2 && len(decoded) != 3) {
+			return nil, nil, fmt.Errorf("malformed disclosure %s", disclosure)
+		}
+		digest := sdDigest(disclosure)
+		if _, dup := byDigest[digest]; dup {
+			return nil, nil, errors.New("disclosure presented twice")
+		}
+		byDigest[digest] = decoded
+	}
+
+	used := make(map[string]bool)
+	disclosed := []string{}
+	var reveal func(value interface{}) (interface{}, error)
+	reveal = func(value interface{}) (interface{}, error) {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			out := make(map[string]interface{}, len(v))
+			for key, item := range v {
+				if key == "_sd" || key == "_sd_alg" {
+					continue
+				}
+				revealed, err := reveal(item)
+				if err != nil {
+					return nil, err
+				}
+				out[key] = revealed
+			}
+			digests, _ := v["_sd"].([]interface{})
+			for _, d := range digests {
+				digest, _ := d.(string)
+				decoded, ok := byDigest[digest]
+				if !ok {
+					continue
+				}
+				if used[digest] {
+					return nil, errors.New("digest referenced twice")
+				}
+				used[digest] = true
+				name, isString := decoded[1].(string)
+				if len(decoded) != 3 || !isString {
+					return nil, errors.New("object disclosure must be [salt, name, value]")
+				}
+				if _, exists := out[name]; exists || name == "_sd" || name == "..." {
+					return nil, fmt.Errorf("disclosed claim %s conflicts with the payload", name)
+				}
+				revealed, err := reveal(decoded[2])
+				if err != nil {
+					return nil, err
+				}
+				out[name] = revealed
+				disclosed = append(disclosed, name)
+			}
+			return out, nil
+		case []interface{}:
+			out := make([]interface{}, 0, len(v))
+			for _, item := range v {
+				if element, ok := item.(map[string]interface{}); ok && len(element) == 1 && element["..."] != nil {
+					digest, _ := element["..."].(string)
+					decoded, ok := byDigest[digest]
+					if !ok {
+						continue
+					}
+					if used[digest] || len(decoded) != 2 {
+						return nil, errors.New("array disclosure must be [salt, value], referenced once")
+					}
+					used[digest] = true
+					item = decoded[1]
+				}
+				revealed, err := reveal(item)
+				if err != nil {
+					return nil, err
+				}
+				out = append(out, revealed)
+			}
+			return out, nil
+		}
+		return value, nil
+	}
+
+	revealed, err := reveal(payload)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(used) != len(byDigest) {
+		return nil, nil, errors.New("disclosure not referenced by the SD-JWT")
+	}
+	sort.Strings(disclosed)
+	return revealed.(map[string]interface{}), disclosed, nil
+}
+
+// verifyKeyBinding checks a key binding JWT against the holder key in cnf
+// and the presented SD-JWT.
+func verifyKeyBinding(kbJWT string, cnf interface{}, presented string, req sdJWTVerifyRequest) error {
+	confirmation, _ := cnf.(map[string]interface{})
+	holderKey, err := parseJWK(confirmation["jwk"])
+	if err != nil {
+		return fmt.Errorf("cnf.jwk: %v", err)
+	}
+	header, payload, err := parseES256(kbJWT, holderKey)
+	if err != nil {
+		return fmt.Errorf("key binding JWT: %v", err)
+	}
+	if header["typ"] != sdJWTKeyBindType {
+		return fmt.Errorf("key binding JWT typ must be %s", sdJWTKeyBindType)
+	}
+	if payload["sd_hash"] != sdDigest(presented) {
+		return errors.New("key binding JWT sd_hash does not match the presentation")
+	}
+	if req.Audience != "" && payload["aud"] != req.Audience {
+		return errors.New("key binding JWT aud does not match")
+	}
+	if req.Nonce != "" && payload["nonce"] != req.Nonce {
+		return errors.New("key binding JWT nonce does not match")
+	}
+	return nil
+}
+
+// verifySDJWT verifies a presentation and returns the revealed claims.
+func (m *vcModule) verifySDJWT(req sdJWTVerifyRequest) map[string]interface{} {
+	result := map[string]interface{}{"verified": false}
+	jwt, disclosures, kbJWT := req.split()
+	header, payload, err := parseES256(jwt, &sdJWTKey.PublicKey)
+	if err != nil {
+		result["error"] = err.Error()
+		return result
+	}
+	result["issuer"] = payload["iss"]
+	result["vct"] = payload["vct"]
+	if header["typ"] != sdJWTType && header["typ"] != "vc+sd-jwt" {
+		result["error"] = fmt.Sprintf("typ must be %s", sdJWTType)
+		return result
+	}
+	if alg, ok := payload["_sd_alg"]; ok && alg != "sha-256" {
+		result["error"] = fmt.Sprintf("unsupported _sd_alg %v", alg)
+		return result
+	}
+
+	m.chain.mu.RLock()
+	now := m.chain.now()
+	issuerDoc := m.chain.did().store.Documents[fmt.Sprint(payload["iss"])]
+	deactivated := issuerDoc != nil && issuerDoc["is_active"] == false
+	var revoked bool
+	if jti, ok := payload["jti"].(string); ok {
+		if _, credential := m.findCredential(jti); credential != nil {
+			revoked = credential["is_revoked"] == true
+		}
+	}
+	m.chain.mu.RUnlock()
+
+	if exp, ok := payload["exp"].(float64); ok && now.Unix() >= int64(exp) {
+		result["error"] = "SD-JWT has expired"
+		return result
+	}
+	if deactivated {
+		result["error"] = "issuer DID is deactivated"
+		return result
+	}
+	if revoked {
+		result["error"] = fmt.Sprintf("credential %v is revoked", payload["jti"])
+		return result
+	}
+
+	claims, disclosed, err := revealClaims(payload, disclosures)
+	if err != nil {
+		result["error"] = err.Error()
+		return result
+	}
+	result["key_binding"] = false
+	if kbJWT != "" {
+		if payload["cnf"] == nil {
+			result["error"] = "key binding JWT presented for an SD-JWT without cnf"
+			return result
+		}
+		presented := jwt + "~"
+		for _, disclosure := range disclosures {
+			presented += disclosure + "~"
+		}
+		if err := verifyKeyBinding(kbJWT, payload["cnf"], presented, req); err != nil {
+			result["error"] = err.Error()
+			return result
+		}
+		result["key_binding"] = true
+	} else if req.RequireKeyBinding {
+		result["error"] = "key binding JWT is required"
+		return result
+	}
+
+	result["verified"] = true
+	result["claims"] = claims
+	result["disclosed"] = disclosed
+	return result
+}
+
+// Handler for POST /persona/vc/v1beta1/sd-jwt/verify
+//
+// A failed check is a 200 with verified=false.
+func (m *vcModule) handleVerifySDJWT(w http.ResponseWriter, r *http.Request) {
+	var req sdJWTVerifyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+	if req.Presentation == "" && req.SDJWT == "" {
+		http.Error(w, "Missing required field: presentation or sd_jwt", http.StatusBadRequest)
+		return
+	}
+	if req.Presentation != "" && !strings.Contains(req.Presentation, "~") {
+		http.Error(w, "presentation must be in the <JWT>~<disclosure>~... form", http.StatusBadRequest)
+		return
+	}
+	if !chargeVerifications(w, r, 1) {
+		return
+	}
+
+	result := m.verifySDJWT(req)
+	log.Printf("Verified SD-JWT from %v: %v", result["issuer"], result["verified"])
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// Handler for GET /persona/vc/v1beta1/sd-jwt/jwks
+func handleSDJWTKeys(w http.ResponseWriter, r *http.Request) {
+	jwk := publicJWK(&sdJWTKey.PublicKey)
+	jwk["kid"] = sdJWTKeyID
+	jwk["alg"] = "ES256"
+	jwk["use"] = "sig"
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"keys": []interface{}{jwk}})
+}
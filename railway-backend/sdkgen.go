@@ -0,0 +1,426 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/gorilla/mux"
+	"github.com/spf13/cobra"
+)
+
+// TypeScript client for the wallet SDK and the frontend, generated from the
+// OpenAPI document so its types always match the deployed mock:
+//
+//	types.ts     one type per component schema (models and tx messages)
+//	client.ts    PersonaClient, one method per operation, named by its
+//	             operationId
+//	index.ts     re-exports both
+//	openapi.json the document it was generated from
+//
+// GET /api/sdk/typescript.zip serves a freshly generated client, and
+// `make generate-clients` (the generate-clients subcommand) writes one
+// into the frontend from a running daemon's /openapi.json.
+
+const sdkPackageName = "@persona/mock-client"
+
+// generateTypeScriptClient renders the client files for an OpenAPI
+// document.
+func generateTypeScriptClient(specJSON []byte) (map[string][]byte, error) {
+	var spec struct {
+		Info struct {
+			Version string `json:"version"`
+		} `json:"info"`
+		Servers []struct {
+			URL string `json:"url"`
+		} `json:"servers"`
+		Paths      map[string]map[string]tsOperation `json:"paths"`
+		Components struct {
+			Schemas map[string]interface{} `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(specJSON, &spec); err != nil {
+		return nil, fmt.Errorf("parsing OpenAPI document: %v", err)
+	}
+	baseURL := ""
+	if len(spec.Servers) > 0 {
+		baseURL = spec.Servers[0].URL
+	}
+
+	var types bytes.Buffer
+	types.WriteString("// Generated from the Persona mock testnet OpenAPI document. Do not edit.\n")
+	for _, name := range sortedMapKeys(spec.Components.Schemas) {
+		schema, _ := spec.Components.Schemas[name].(map[string]interface{})
+		fmt.Fprintf(&types, "\nexport type %s = %s;\n", tsTypeName(name), tsType(schema, ""))
+	}
+
+	var client bytes.Buffer
+	client.WriteString(tsClientPrelude)
+	fmt.Fprintf(&client, "\nexport const DEFAULT_BASE_URL = %q;\n", baseURL)
+	client.WriteString(tsClientClass)
+	for _, path := range sortedMapKeys(spec.Paths) {
+		for _, method := range []string{"get", "post", "put", "patch", "delete"} {
+			if op, ok := spec.Paths[path][method]; ok {
+				writeTSMethod(&client, strings.ToUpper(method), path, op)
+			}
+		}
+	}
+	client.WriteString("}\n")
+
+	version := strings.TrimPrefix(spec.Info.Version, "v")
+	packageJSON, _ := json.MarshalIndent(map[string]interface{}{
+		"name":    sdkPackageName,
+		"version": version,
+		"private": true,
+		"type":    "module",
+		"main":    "index.ts",
+		"types":   "index.ts",
+	}, "", "  ")
+
+	return map[string][]byte{
+		"types.ts":     types.Bytes(),
+		"client.ts":    client.Bytes(),
+		"index.ts":     []byte("export * from './types';\nexport * from './client';\n"),
+		"openapi.json": specJSON,
+		"package.json": append(packageJSON, '\n'),
+	}, nil
+}
+
+type tsOperation struct {
+	OperationID string `json:"operationId"`
+	Summary     string `json:"summary"`
+	Description string `json:"description"`
+	Parameters  []struct {
+		Name     string                 `json:"name"`
+		In       string                 `json:"in"`
+		Required bool                   `json:"required"`
+		Schema   map[string]interface{} `json:"schema"`
+	} `json:"parameters"`
+	RequestBody *struct {
+		Content map[string]struct {
+			Schema map[string]interface{} `json:"schema"`
+		} `json:"content"`
+	} `json:"requestBody"`
+	Responses map[string]struct {
+		Content map[string]struct {
+			Schema map[string]interface{} `json:"schema"`
+		} `json:"content"`
+	} `json:"responses"`
+}
+
+func writeTSMethod(w *bytes.Buffer, method, path string, op tsOperation) {
+	var args, queryFields []string
+	url := "`" + path + "`"
+	for _, param := range op.Parameters {
+		switch param.In {
+		case "path":
+			arg := tsIdentifier(param.Name)
+			args = append(args, arg+": string")
+			url = strings.Replace(url, "{"+param.Name+"}", "${encodeURIComponent("+arg+")}", 1)
+		case "query":
+			queryFields = append(queryFields, fmt.Sprintf("%q?: %s", param.Name, tsType(param.Schema, "")))
+		}
+	}
+	query, body := "undefined", "undefined"
+	if len(queryFields) > 0 {
+		args = append(args, "query: { "+strings.Join(queryFields, "; ")+" } = {}")
+		query = "query"
+	}
+	if op.RequestBody != nil {
+		schema := op.RequestBody.Content["application/json"].Schema
+		args = append(args, "body: "+tsType(schema, "  "))
+		body = "body"
+	}
+	args = append(args, "init: RequestInit = {}")
+
+	result := "unknown"
+	if ok, found := op.Responses["200"]; found {
+		if content, isJSON := ok.Content["application/json"]; isJSON {
+			result = tsType(content.Schema, "  ")
+		}
+	}
+
+	w.WriteString("\n  /**\n")
+	for _, line := range []string{op.Summary, method + " " + path, op.Description} {
+		if line != "" {
+			fmt.Fprintf(w, "   * %s\n", strings.ReplaceAll(line, "*/", "*\\/"))
+		}
+	}
+	w.WriteString("   */\n")
+	fmt.Fprintf(w, "  %s(%s): Promise<%s> {\n", tsIdentifier(op.OperationID), strings.Join(args, ", "), result)
+	fmt.Fprintf(w, "    return this.request(%q, %s, %s, %s, init);\n", method, url, query, body)
+	w.WriteString("  }\n")
+}
+
+var tsNonIdentifier = regexp.MustCompile(`[^A-Za-z0-9_$]+`)
+
+var tsReserved = map[string]bool{
+	"break": true, "case": true, "catch": true, "class": true, "const": true, "continue": true,
+	"debugger": true, "default": true, "delete": true, "do": true, "else": true, "enum": true,
+	"export": true, "extends": true, "false": true, "finally": true, "for": true, "function": true,
+	"if": true, "import": true, "in": true, "instanceof": true, "new": true, "null": true,
+	"return": true, "super": true, "switch": true, "this": true, "throw": true, "true": true,
+	"try": true, "typeof": true, "var": true, "void": true, "while": true, "with": true,
+	"request": true,
+}
+
+// tsIdentifier turns a parameter or operation name into a camelCase
+// identifier.
+func tsIdentifier(name string) string {
+	parts := tsNonIdentifier.Split(name, -1)
+	id := ""
+	for _, part := range parts {
+		if part == "" {
+			continue
+		}
+		if id == "" {
+			id = part
+		} else {
+			id += strings.ToUpper(part[:1]) + part[1:]
+		}
+	}
+	if id == "" || unicode.IsDigit(rune(id[0])) {
+		id = "_" + id
+	}
+	if tsReserved[id] {
+		id += "_"
+	}
+	return id
+}
+
+// tsTypeName turns a schema name such as persona.did.v1.MsgCreateDid into
+// a type name, PersonaDidV1MsgCreateDid.
+func tsTypeName(name string) string {
+	var out strings.Builder
+	for _, part := range tsNonIdentifier.Split(name, -1) {
+		if part != "" {
+			out.WriteString(strings.ToUpper(part[:1]) + part[1:])
+		}
+	}
+	return out.String()
+}
+
+// tsType renders a JSON schema as a TypeScript type. indent is the
+// indentation of the enclosing line.
+func tsType(schema map[string]interface{}, indent string) string {
+	if schema == nil {
+		return "unknown"
+	}
+	t := tsBaseType(schema, indent)
+	if schema["nullable"] == true {
+		t += " | null"
+	}
+	return t
+}
+
+func tsBaseType(schema map[string]interface{}, indent string) string {
+	if ref, ok := schema["$ref"].(string); ok {
+		return tsTypeName(strings.TrimPrefix(ref, "#/components/schemas/"))
+	}
+	if oneOf, ok := schema["oneOf"].([]interface{}); ok {
+		variants := make([]string, 0, len(oneOf))
+		for _, variant := range oneOf {
+			v, _ := variant.(map[string]interface{})
+			variants = append(variants, tsType(v, indent))
+		}
+		return strings.Join(variants, " | ")
+	}
+	if enum, ok := schema["enum"].([]interface{}); ok && len(enum) > 0 {
+		values := make([]string, 0, len(enum))
+		for _, value := range enum {
+			literal, _ := json.Marshal(value)
+			values = append(values, string(literal))
+		}
+		return strings.Join(values, " | ")
+	}
+	switch schema["type"] {
+	case "string":
+		return "string"
+	case "integer", "number":
+		return "number"
+	case "boolean":
+		return "boolean"
+	case "array":
+		items, _ := schema["items"].(map[string]interface{})
+		item := tsType(items, indent)
+		if strings.ContainsAny(item, " |") {
+			return "Array<" + item + ">"
+		}
+		return item + "[]"
+	case "object":
+		properties, _ := schema["properties"].(map[string]interface{})
+		if len(properties) == 0 {
+			if additional, ok := schema["additionalProperties"].(map[string]interface{}); ok && len(additional) > 0 {
+				return "Record<string, " + tsType(additional, indent) + ">"
+			}
+			return "Record<string, unknown>"
+		}
+		required := map[string]bool{}
+		if list, ok := schema["required"].([]interface{}); ok {
+			for _, name := range list {
+				required[fmt.Sprint(name)] = true
+			}
+		}
+		inner := indent + "  "
+		var out strings.Builder
+		out.WriteString("{\n")
+		for _, name := range sortedMapKeys(properties) {
+			property, _ := properties[name].(map[string]interface{})
+			key := name
+			if tsNonIdentifier.MatchString(name) || (name != "" && unicode.IsDigit(rune(name[0]))) {
+				key = fmt.Sprintf("%q", name)
+			}
+			optional := "?"
+			if required[name] {
+				optional = ""
+			}
+			fmt.Fprintf(&out, "%s%s%s: %s;\n", inner, key, optional, tsType(property, inner))
+		}
+		out.WriteString(indent + "}")
+		return out.String()
+	}
+	return "unknown"
+}
+
+const tsClientPrelude = `// Generated from the Persona mock testnet OpenAPI document. Do not edit.
+import type * as types from './types';
+export type { types };
+
+export interface ClientOptions {
+  /** Sent as X-Admin-Token on every request, for the /admin routes */
+  adminToken?: string;
+  headers?: Record<string, string>;
+  fetch?: typeof fetch;
+}
+
+/** A non-2xx response. body is the decoded JSON, or the text */
+export class PersonaApiError extends Error {
+  constructor(
+    readonly status: number,
+    readonly body: unknown,
+  ) {
+    super(typeof body === 'object' && body !== null && 'message' in body ? String((body as { message: unknown }).message) : 'HTTP ' + status);
+    this.name = 'PersonaApiError';
+  }
+}
+`
+
+const tsClientClass = `
+export class PersonaClient {
+  constructor(
+    readonly baseUrl: string = DEFAULT_BASE_URL,
+    readonly options: ClientOptions = {},
+  ) {}
+
+  protected async request<T>(
+    method: string,
+    path: string,
+    query: Record<string, string | number | boolean | undefined> | undefined,
+    body: unknown,
+    init: RequestInit,
+  ): Promise<T> {
+    const url = new URL(path, this.baseUrl);
+    for (const [key, value] of Object.entries(query ?? {})) {
+      if (value !== undefined) url.searchParams.set(key, String(value));
+    }
+    const headers: Record<string, string> = { Accept: 'application/json', ...this.options.headers };
+    if (body !== undefined) headers['Content-Type'] = 'application/json';
+    if (this.options.adminToken) headers['X-Admin-Token'] = this.options.adminToken;
+    const response = await (this.options.fetch ?? fetch)(url, {
+      ...init,
+      method,
+      headers: { ...headers, ...(init.headers as Record<string, string> | undefined) },
+      body: body === undefined ? undefined : JSON.stringify(body),
+    });
+    const text = await response.text();
+    let decoded: unknown = text;
+    if ((response.headers.get('Content-Type') ?? '').includes('json') && text !== '') {
+      decoded = JSON.parse(text);
+    }
+    if (!response.ok) throw new PersonaApiError(response.status, decoded);
+    return decoded as T;
+  }
+`
+
+// Handler for GET /api/sdk/typescript.zip
+func (c *Chain) typeScriptSDKHandler(router *mux.Router) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		spec, err := c.buildOpenAPISpec(router, requestBaseURL(r))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		specJSON, _ := json.MarshalIndent(spec, "", "  ")
+		files, err := generateTypeScriptClient(specJSON)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		var buf bytes.Buffer
+		zw := zip.NewWriter(&buf)
+		for _, name := range sortedMapKeys(files) {
+			f, err := zw.Create("persona-client/" + name)
+			if err == nil {
+				_, err = f.Write(files[name])
+			}
+			if err != nil {
+				http.Error(w, "Failed to build archive: "+err.Error(), http.StatusInternalServerError)
+				return
+			}
+		}
+		if err := zw.Close(); err != nil {
+			http.Error(w, "Failed to build archive: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/zip")
+		w.Header().Set("Content-Disposition", `attachment; filename="persona-client.zip"`)
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Write(buf.Bytes())
+	}
+}
+
+// newGenerateClientsCommand implements `persona-mock generate-clients`,
+// writing the TypeScript client for a running daemon into a directory.
+func newGenerateClientsCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "generate-clients",
+		Short: "Generate the TypeScript client from the OpenAPI spec",
+		Args:  cobra.NoArgs,
+	}
+	url := cmd.Flags().String("url", "http://localhost:8080", "base URL of the running mock daemon")
+	out := cmd.Flags().String("out", "../src/lib/persona-client", "output directory")
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		return runGenerateClients(*url, *out)
+	}
+	return cmd
+}
+
+func runGenerateClients(url, out string) error {
+	specJSON, err := daemonRequest("GET", url, "", "/openapi.json", nil)
+	if err != nil {
+		return err
+	}
+	files, err := generateTypeScriptClient(specJSON)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(out, 0o755); err != nil {
+		return err
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(out, name), data, 0o644); err != nil {
+			return err
+		}
+	}
+	fmt.Printf("Wrote TypeScript client (%d files) to %s\n", len(files), out)
+	return nil
+}
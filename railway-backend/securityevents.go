@@ -0,0 +1,214 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+
+	"persona-backend/idgen"
+
+	"github.com/gorilla/mux"
+)
+
+// Security events per DID for the frontend's security center, at
+// GET /api/dids/{did}/security/events, newest first. They are generated
+// when
+//
+//	new_device_login  a device is registered (warning)
+//	challenge_failed  a device's sign-in has the wrong passkey (warning;
+//	                  critical from the third failure in a row)
+//	key_rotated       MsgRotateKey replaces a verification method on the
+//	                  default network (warning)
+//
+// Events stay in the feed once acknowledged, with acknowledged_at set;
+// ?unacknowledged=true and ?severity= narrow the list. Like devices,
+// events are server-level state cleared by /admin/reset.
+
+const (
+	// maxSecurityEvents bounds the events kept per DID
+	maxSecurityEvents = 500
+	// challengeFailureAlert is the run of failed challenges reported as
+	// critical
+	challengeFailureAlert = 3
+)
+
+var securitySeverities = []string{"info", "warning", "critical"}
+
+type securityEvent struct {
+	ID             string                 `json:"id"`
+	DID            string                 `json:"did"`
+	Type           string                 `json:"type"`
+	Severity       string                 `json:"severity"`
+	Message        string                 `json:"message"`
+	Data           map[string]interface{} `json:"data,omitempty"`
+	CreatedAt      int64                  `json:"created_at"`
+	Acknowledged   bool                   `json:"acknowledged"`
+	AcknowledgedAt int64                  `json:"acknowledged_at,omitempty"`
+	// TraceID is the trace of the request behind the event (trace.go)
+	TraceID string `json:"trace_id,omitempty"`
+}
+
+var (
+	securityEventsMu sync.Mutex
+	// Events by DID, oldest first
+	securityEvents = make(map[string][]*securityEvent)
+)
+
+func init() {
+	registerAdminState("security_events", func() interface{} {
+		securityEventsMu.Lock()
+		defer securityEventsMu.Unlock()
+		events := make(map[string][]securityEvent, len(securityEvents))
+		for did, list := range securityEvents {
+			for _, ev := range list {
+				events[did] = append(events[did], *ev)
+			}
+		}
+		return events
+	}, func() {
+		securityEventsMu.Lock()
+		defer securityEventsMu.Unlock()
+		securityEvents = make(map[string][]*securityEvent)
+	})
+}
+
+// recordSecurityEvent adds an event to a DID's feed. traceID is the trace
+// of the request behind it, or "".
+func recordSecurityEvent(traceID, did, eventType, severity, message string, data map[string]interface{}) {
+	ev := &securityEvent{
+		ID:        idgen.NewWithPrefix("sec"),
+		DID:       did,
+		Type:      eventType,
+		Severity:  severity,
+		Message:   message,
+		Data:      data,
+		CreatedAt: appClock.Now().Unix(),
+		TraceID:   traceID,
+	}
+	recordTrace(traceID, func(t *traceRecord) { t.SecurityEvents = append(t.SecurityEvents, ev.ID) })
+	securityEventsMu.Lock()
+	list := append(securityEvents[did], ev)
+	if len(list) > maxSecurityEvents {
+		list = list[len(list)-maxSecurityEvents:]
+	}
+	securityEvents[did] = list
+	securityEventsMu.Unlock()
+	log.Printf("Security event %s (%s) for %s: %s", eventType, severity, did, message)
+}
+
+// startSecurityEventRecorder records key rotations on c as security
+// events in the background.
+func (c *Chain) startSecurityEventRecorder() {
+	events, _ := c.subscribeEvents()
+	go func() {
+		for ev := range events {
+			activity, ok := ev.Data["value"].(activityEvent)
+			if !ok || ev.Type != "Activity" || activity.Type != "did.key_rotated" {
+				continue
+			}
+			data := map[string]interface{}{"tx_hash": activity.TxHash, "controller": activity.Address}
+			message := "A verification method was rotated"
+			if rotation := c.keyRotation(activity); rotation != nil {
+				data["old_key_id"], data["new_key_id"] = rotation.OldKeyID, rotation.NewKeyID
+				message = fmt.Sprintf("Key %s was rotated to %s", rotation.OldKeyID, rotation.NewKeyID)
+			}
+			recordSecurityEvent(activity.TraceID, activity.Subject, "key_rotated", "warning", message, data)
+		}
+	}()
+}
+
+// keyRotation returns the rotation recorded with the document version of a
+// did.key_rotated event, or nil.
+func (c *Chain) keyRotation(activity activityEvent) *didKeyRotation {
+	var document struct {
+		VersionID int `json:"version_id"`
+	}
+	if json.Unmarshal(activity.Data, &document) != nil || document.VersionID == 0 {
+		return nil
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	versions := c.did().store.Versions[activity.Subject]
+	if document.VersionID > len(versions) {
+		return nil
+	}
+	return versions[document.VersionID-1].Rotation
+}
+
+// Handler for GET /api/dids/{did}/security/events
+func handleListSecurityEvents(w http.ResponseWriter, r *http.Request) {
+	did := mux.Vars(r)["did"]
+	query := r.URL.Query()
+	severity := query.Get("severity")
+	if severity != "" && !containsString(securitySeverities, severity) {
+		http.Error(w, "severity must be info, warning or critical", http.StatusBadRequest)
+		return
+	}
+	unacknowledgedOnly := query.Get("unacknowledged") == "true"
+
+	securityEventsMu.Lock()
+	list := securityEvents[did]
+	events := []securityEvent{}
+	unacknowledged := 0
+	for i := len(list) - 1; i >= 0; i-- {
+		ev := list[i]
+		if !ev.Acknowledged {
+			unacknowledged++
+		}
+		if (severity == "" || ev.Severity == severity) && !(unacknowledgedOnly && ev.Acknowledged) {
+			events = append(events, *ev)
+		}
+	}
+	securityEventsMu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"did":            did,
+		"events":         events,
+		"unacknowledged": unacknowledged,
+	})
+}
+
+// Handler for POST /api/dids/{did}/security/events/{id}/ack
+func handleAcknowledgeSecurityEvent(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	securityEventsMu.Lock()
+	defer securityEventsMu.Unlock()
+	for _, ev := range securityEvents[vars["did"]] {
+		if ev.ID != vars["id"] {
+			continue
+		}
+		if !ev.Acknowledged {
+			ev.Acknowledged = true
+			ev.AcknowledgedAt = appClock.Now().Unix()
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{"event": *ev})
+		return
+	}
+	http.Error(w, "Security event not found", http.StatusNotFound)
+}
+
+// Handler for POST /api/dids/{did}/security/events/ack. Acknowledges every
+// event of the DID.
+func handleAcknowledgeSecurityEvents(w http.ResponseWriter, r *http.Request) {
+	did := mux.Vars(r)["did"]
+	now := appClock.Now().Unix()
+
+	securityEventsMu.Lock()
+	acknowledged := 0
+	for _, ev := range securityEvents[did] {
+		if !ev.Acknowledged {
+			ev.Acknowledged = true
+			ev.AcknowledgedAt = now
+			acknowledged++
+		}
+	}
+	securityEventsMu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"did": did, "acknowledged": acknowledged})
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"strconv"
+
+	"github.com/cosmos/btcutil/bech32"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+	"golang.org/x/crypto/ripemd160"
+	"google.golang.org/protobuf/proto"
+
+	txv1beta1 "persona-backend/proto/cosmos/tx/v1beta1"
+)
+
+// Strict signing: with STRICT_SIGNING=true the ante handler verifies a
+// secp256k1 signature for every signer of the tx, so bugs in the
+// frontend's signing code fail here instead of on a real node. For JSON
+// txs the signed bytes are the amino JSON StdSignDoc
+// (SIGN_MODE_LEGACY_AMINO_JSON, what cosmjs and Keplr sign for JSON txs)
+//
+//	{"account_number", "chain_id", "fee", "memo", "msgs", "sequence"}
+//
+// with sorted keys and no whitespace, msgs as broadcast and the fee from
+// auth_info.fee. For tx_bytes they are the SIGN_MODE_DIRECT SignDoc over
+// the TxRaw's body and auth info bytes (see txdecode.go). Signers are the
+// distinct message signers in order of first appearance, and the i-th
+// signs with the key of auth_info.signer_infos[i] (or the i-th legacy
+// StdTx signature's pub_key), which must hash to its address. Failures
+// are rejected with the Cosmos codes: 15 without a signature or for a
+// message without a signer, 8 for a missing or mismatched public key, 7
+// for a signer that is not a bech32 address and 4 when the signature does
+// not verify. The verified key is stored as the account's pub_key.
+// Isolated chains never check signatures.
+
+const secp256k1PubKeyType = "/cosmos.crypto.secp256k1.PubKey"
+
+var strictSigning = newFeatureFlag("strict_signing", "STRICT_SIGNING", "Reject txs without a valid secp256k1 signature")
+
+// txSignatureData is the signature part of a broadcast tx.
+type txSignatureData struct {
+	pubKey    []byte
+	signature []byte
+	fee       interface{}
+	memo      interface{}
+}
+
+// decodeBase64Field decodes a base64 string, nil for anything else.
+func decodeBase64Field(value interface{}) []byte {
+	s, _ := value.(string)
+	data, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+// txSignatureOf extracts the i-th signature, its public key and the signed
+// fee and memo from Cosmos tx JSON or a legacy StdTx.
+func txSignatureOf(txData map[string]interface{}, i int) txSignatureData {
+	var data txSignatureData
+	tx, _ := txData["tx"].(map[string]interface{})
+	if tx == nil {
+		return data
+	}
+	data.fee, data.memo = tx["fee"], tx["memo"]
+	if body, ok := tx["body"].(map[string]interface{}); ok {
+		data.memo = body["memo"]
+	}
+	if authInfo, ok := tx["auth_info"].(map[string]interface{}); ok {
+		if fee, ok := authInfo["fee"].(map[string]interface{}); ok {
+			data.fee = map[string]interface{}{"amount": fee["amount"], "gas": fee["gas_limit"]}
+		}
+		if infos, ok := authInfo["signer_infos"].([]interface{}); ok && len(infos) > i {
+			if info, ok := infos[i].(map[string]interface{}); ok {
+				if key, ok := info["public_key"].(map[string]interface{}); ok {
+					data.pubKey = decodeBase64Field(key["key"])
+				}
+			}
+		}
+	}
+	if sigs, ok := tx["signatures"].([]interface{}); ok && len(sigs) > i {
+		switch sig := sigs[i].(type) {
+		case string:
+			data.signature = decodeBase64Field(sig)
+		case map[string]interface{}:
+			data.signature = decodeBase64Field(sig["signature"])
+			if key, ok := sig["pub_key"].(map[string]interface{}); ok && data.pubKey == nil {
+				data.pubKey = decodeBase64Field(key["value"])
+			}
+		}
+	}
+	return data
+}
+
+// stdSignBytes returns the amino JSON sign bytes of a tx.
+func stdSignBytes(chainID string, accountNumber, sequence uint64, fee, memo interface{}, msgs []interface{}) []byte {
+	feeObject, _ := fee.(map[string]interface{})
+	if feeObject == nil {
+		feeObject = map[string]interface{}{}
+	}
+	if feeObject["amount"] == nil {
+		feeObject["amount"] = []interface{}{}
+	}
+	if feeObject["gas"] == nil {
+		feeObject["gas"] = "0"
+	}
+	if memo == nil {
+		memo = ""
+	}
+	if msgs == nil {
+		msgs = []interface{}{}
+	}
+	// encoding/json sorts map keys, which gives amino's canonical form
+	signDoc, _ := json.Marshal(map[string]interface{}{
+		"account_number": strconv.FormatUint(accountNumber, 10),
+		"chain_id":       chainID,
+		"fee":            feeObject,
+		"memo":           memo,
+		"msgs":           msgs,
+		"sequence":       strconv.FormatUint(sequence, 10),
+	})
+	return signDoc
+}
+
+// addressBytes returns the 20-byte account address of a compressed
+// secp256k1 public key.
+func addressBytes(pubKey []byte) []byte {
+	sha := sha256.Sum256(pubKey)
+	hasher := ripemd160.New()
+	hasher.Write(sha[:])
+	return hasher.Sum(nil)
+}
+
+// verifySignature checks the signature of the signer at index i over the
+// tx. sequence is the one the tx declares, or the account's when it
+// declares none. Must be called with the chain lock held.
+func (m *authModule) verifySignature(txData map[string]interface{}, msgs []interface{}, signer string, i int, sequence uint64, hasSequence bool) *txError {
+	acc := m.store.account(signer)
+	if !hasSequence {
+		sequence = acc.Sequence
+	}
+	data := txSignatureOf(txData, i)
+	if data.signature == nil {
+		return txErrorf(codeNoSignatures, "signer %s", signer)
+	}
+	if len(data.pubKey) != secp256k1.PubKeyBytesLenCompressed {
+		return txErrorf(codeInvalidPubKey, "missing or malformed secp256k1 public key for signer %s", signer)
+	}
+	pubKey, err := secp256k1.ParsePubKey(data.pubKey)
+	if err != nil {
+		return txErrorf(codeInvalidPubKey, "%v", err)
+	}
+	_, decoded, err := bech32.DecodeNoLimit(signer)
+	if err == nil {
+		decoded, err = bech32.ConvertBits(decoded, 5, 8, false)
+	}
+	if err != nil {
+		return txErrorf(codeInvalidAddress, "invalid signer address %s", signer)
+	}
+	if !bytes.Equal(decoded, addressBytes(data.pubKey)) {
+		return txErrorf(codeInvalidPubKey, "pubKey does not match signer address %s with signer index: %d", signer, i)
+	}
+
+	invalid := txErrorf(codeUnauthorized, "signature verification failed; please verify account number (%d), sequence (%d) and chain-id (%s)", acc.AccountNumber, sequence, m.chain.ChainID())
+	if len(data.signature) != 64 {
+		return invalid
+	}
+	var r, s secp256k1.ModNScalar
+	if r.SetByteSlice(data.signature[:32]) || s.SetByteSlice(data.signature[32:]) || s.IsOverHalfOrder() {
+		return invalid
+	}
+	signBytes := stdSignBytes(m.chain.ChainID(), acc.AccountNumber, sequence, data.fee, data.memo, msgs)
+	if raw := txRawOf(txData); raw != nil {
+		signBytes, _ = proto.MarshalOptions{Deterministic: true}.Marshal(&txv1beta1.SignDoc{
+			BodyBytes:     raw.BodyBytes,
+			AuthInfoBytes: raw.AuthInfoBytes,
+			ChainId:       m.chain.ChainID(),
+			AccountNumber: acc.AccountNumber,
+		})
+	}
+	hash := sha256.Sum256(signBytes)
+	if !ecdsa.NewSignature(&r, &s).Verify(hash[:], pubKey) {
+		return invalid
+	}
+	acc.PubKey = base64.StdEncoding.EncodeToString(data.pubKey)
+	return nil
+}
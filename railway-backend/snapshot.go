@@ -0,0 +1,394 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+
+	"persona-backend/objstore"
+)
+
+// Chain snapshots for moving generated datasets between environments. GET
+// /admin/snapshot returns the module stores and stored txs as a
+// zstd-compressed tar: one JSON file per module store plus txs.json, and a
+// manifest.json listing each file's size and SHA-256. POST
+// /admin/snapshot/import verifies every file against the manifest before
+// replacing the state, so a truncated or edited archive is rejected whole;
+// ?sha256= additionally pins the digest of the archive itself, which the
+// export returns in X-Snapshot-SHA256. With object storage configured,
+// ?name= on either stores or loads the archive at snapshots/<name>.tar.zst
+// instead of passing it through the request. Server-level state (webhooks,
+// pools, faults) is configuration and not part of a snapshot. ?region= on
+// the export builds a data residency partition (residency.go) holding only
+// the region's records and txs, stored under snapshots/<region>/.
+
+const (
+	snapshotFormat       = "persona-snapshot/v1"
+	snapshotManifestName = "manifest.json"
+	snapshotContentType  = "application/zstd"
+)
+
+// snapshotMaxBytes caps the decompressed size of an imported snapshot.
+var snapshotMaxBytes = int64(intFromEnv("SNAPSHOT_MAX_MB", 1024)) << 20
+
+type snapshotManifest struct {
+	Format       string `json:"format"`
+	ChainID      string `json:"chain_id"`
+	LatestHeight int64  `json:"latest_height"`
+	CreatedAt    string `json:"created_at"`
+	// Region is set on data residency partitions
+	Region string         `json:"region,omitempty"`
+	Files  []snapshotFile `json:"files"`
+}
+
+type snapshotFile struct {
+	Name   string `json:"name"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// buildSnapshot encodes the chain state as a snapshot archive, or only
+// the partition of region when it is not "".
+func (c *Chain) buildSnapshot(region string) ([]byte, snapshotManifest, error) {
+	files := map[string][]byte{}
+	c.mu.RLock()
+	var err error
+	for _, m := range c.modules {
+		var store interface{} = m.Store()
+		if partitioner, ok := store.(residencyPartitioner); ok && region != "" {
+			store = partitioner.partition(region)
+		}
+		if files["modules/"+m.Name()+".json"], err = json.Marshal(store); err != nil {
+			break
+		}
+	}
+	txs := make([]*storedTx, 0, len(c.txsByHash))
+	for _, tx := range c.txsByHash {
+		if region == "" || tx.Residency == region || (tx.Residency == "" && region == residencyDefault) {
+			txs = append(txs, tx)
+		}
+	}
+	sort.Slice(txs, func(i, j int) bool { return txs[i].Seq < txs[j].Seq })
+	if err == nil {
+		files["txs.json"], err = json.Marshal(txs)
+	}
+	manifest := snapshotManifest{
+		Format:       snapshotFormat,
+		ChainID:      c.info.ChainID,
+		LatestHeight: c.info.LatestHeight,
+		CreatedAt:    c.now().UTC().Format(time.RFC3339),
+		Region:       region,
+	}
+	c.mu.RUnlock()
+	if err != nil {
+		return nil, manifest, err
+	}
+
+	for _, name := range sortedMapKeys(files) {
+		manifest.Files = append(manifest.Files, snapshotFile{
+			Name:   name,
+			Size:   int64(len(files[name])),
+			SHA256: sha256Hex(files[name]),
+		})
+	}
+	manifestJSON, _ := json.MarshalIndent(manifest, "", "  ")
+
+	var buf bytes.Buffer
+	zw, err := zstd.NewWriter(&buf)
+	if err != nil {
+		return nil, manifest, err
+	}
+	tw := tar.NewWriter(zw)
+	write := func(name string, data []byte) error {
+		header := &tar.Header{Name: name, Mode: 0644, Size: int64(len(data)), ModTime: time.Unix(0, 0)}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		_, err := tw.Write(data)
+		return err
+	}
+	// The manifest goes first so a reader can check the rest as it streams
+	if err := write(snapshotManifestName, manifestJSON); err != nil {
+		return nil, manifest, err
+	}
+	for _, file := range manifest.Files {
+		if err := write(file.Name, files[file.Name]); err != nil {
+			return nil, manifest, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, manifest, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, manifest, err
+	}
+	return buf.Bytes(), manifest, nil
+}
+
+// readSnapshot decompresses a snapshot archive and checks every file
+// against the manifest.
+func readSnapshot(archive []byte) (snapshotManifest, map[string][]byte, error) {
+	var manifest snapshotManifest
+	zr, err := zstd.NewReader(bytes.NewReader(archive), zstd.WithDecoderMaxMemory(uint64(snapshotMaxBytes)))
+	if err != nil {
+		return manifest, nil, err
+	}
+	defer zr.Close()
+
+	files := map[string][]byte{}
+	tr := tar.NewReader(zr)
+	var total int64
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return manifest, nil, fmt.Errorf("not a zstd-compressed snapshot: %v", err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			return manifest, nil, fmt.Errorf("unexpected entry %s", header.Name)
+		}
+		if _, dup := files[header.Name]; dup {
+			return manifest, nil, fmt.Errorf("duplicate entry %s", header.Name)
+		}
+		total += header.Size
+		if total > snapshotMaxBytes {
+			return manifest, nil, fmt.Errorf("snapshot is larger than SNAPSHOT_MAX_MB")
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return manifest, nil, fmt.Errorf("reading %s: %v", header.Name, err)
+		}
+		files[header.Name] = data
+	}
+
+	manifestJSON, ok := files[snapshotManifestName]
+	if !ok {
+		return manifest, nil, errors.New("snapshot has no manifest.json")
+	}
+	delete(files, snapshotManifestName)
+	if err := json.Unmarshal(manifestJSON, &manifest); err != nil {
+		return manifest, nil, fmt.Errorf("manifest.json: %v", err)
+	}
+	if manifest.Format != snapshotFormat {
+		return manifest, nil, fmt.Errorf("unsupported snapshot format %q, want %s", manifest.Format, snapshotFormat)
+	}
+	listed := map[string]bool{}
+	for _, file := range manifest.Files {
+		data, ok := files[file.Name]
+		if !ok {
+			return manifest, nil, fmt.Errorf("%s is in the manifest but missing", file.Name)
+		}
+		if int64(len(data)) != file.Size || sha256Hex(data) != file.SHA256 {
+			return manifest, nil, fmt.Errorf("%s does not match its manifest checksum", file.Name)
+		}
+		listed[file.Name] = true
+	}
+	for name := range files {
+		if !listed[name] {
+			return manifest, nil, fmt.Errorf("%s is not in the manifest", name)
+		}
+	}
+	return manifest, files, nil
+}
+
+// restoreSnapshot replaces the module stores and txs with a verified
+// snapshot's. On error the state is left untouched.
+func (c *Chain) restoreSnapshot(files map[string][]byte) ([]string, int, error) {
+	stores := map[string]json.RawMessage{}
+	var txs []*storedTx
+	for name, data := range files {
+		switch {
+		case name == "txs.json":
+			if err := json.Unmarshal(data, &txs); err != nil {
+				return nil, 0, fmt.Errorf("txs.json: %v", err)
+			}
+		case strings.HasPrefix(name, "modules/") && strings.HasSuffix(name, ".json"):
+			module := strings.TrimSuffix(strings.TrimPrefix(name, "modules/"), ".json")
+			if c.Module(module) == nil {
+				return nil, 0, fmt.Errorf("unknown module: %s", module)
+			}
+			stores[module] = data
+		default:
+			return nil, 0, fmt.Errorf("unexpected file %s", name)
+		}
+	}
+
+	c.mu.Lock()
+	backup := c.backupStoresLocked()
+	for _, m := range c.modules {
+		m.Store().Reset()
+	}
+	if err := c.seedLocked(stores, nil); err != nil {
+		c.restoreStoresLocked(backup)
+		c.mu.Unlock()
+		return nil, 0, err
+	}
+	c.txSeq = 0
+	c.indexTxsLocked(txs)
+	c.pendingTxs = nil
+	for _, tx := range txs {
+		c.txSeq = max(c.txSeq, tx.Seq)
+	}
+	c.mu.Unlock()
+
+	// The activity history does not travel with a snapshot
+	c.eventsMu.Lock()
+	c.recentActivity = nil
+	c.activityBySubject = make(map[string][]activityEvent)
+	c.eventsMu.Unlock()
+	verifyCache.Flush()
+	return sortedMapKeys(stores), len(txs), nil
+}
+
+// indexTxsLocked replaces the stored txs and their search indexes. Must be
+// called with c.mu held.
+func (c *Chain) indexTxsLocked(txs []*storedTx) {
+	c.txsByHash = make(map[string]*storedTx)
+	c.txsBySender = make(map[string][]string)
+	c.txsByAction = make(map[string][]string)
+	c.txsByHeight = make(map[int64][]string)
+	for _, tx := range txs {
+		hash := normalizeTxHash(tx.Response.TxHash)
+		c.txsByHash[hash] = tx
+		for _, sender := range tx.Senders {
+			c.txsBySender[sender] = append(c.txsBySender[sender], hash)
+		}
+		for _, action := range tx.Actions {
+			c.txsByAction[action] = append(c.txsByAction[action], hash)
+		}
+		c.txsByHeight[tx.Response.Height] = append(c.txsByHeight[tx.Response.Height], hash)
+	}
+}
+
+func snapshotObjectKey(name, region string) (string, error) {
+	if region != "" {
+		name = region + "/" + name
+	}
+	return artifactKey("snapshots", name+".tar.zst")
+}
+
+// Handler for GET /admin/snapshot
+func (c *Chain) handleExportSnapshot(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Query().Get("name")
+	region := r.URL.Query().Get("region")
+	if region != "" && !containsString(residencyRegions, region) {
+		http.Error(w, "region must be one of "+strings.Join(residencyRegions, ", "), http.StatusBadRequest)
+		return
+	}
+	if name != "" && objectStore == nil {
+		writeStorageUnavailable(w)
+		return
+	}
+	archive, manifest, err := c.buildSnapshot(region)
+	if err != nil {
+		http.Error(w, "Failed to build snapshot: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	digest := sha256Hex(archive)
+	log.Printf("Built snapshot at height %d: %d files, %d bytes, sha256 %s", manifest.LatestHeight, len(manifest.Files), len(archive), digest)
+
+	if name != "" {
+		key, err := snapshotObjectKey(name, region)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := objectStore.Put(key, archive, snapshotContentType); err != nil {
+			log.Printf("Failed to store snapshot %s: %v", key, err)
+			http.Error(w, "Failed to store snapshot", http.StatusBadGateway)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"key":      key,
+			"bytes":    len(archive),
+			"sha256":   digest,
+			"manifest": manifest,
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", snapshotContentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fmt.Sprintf("%s-%d.tar.zst", manifest.ChainID, manifest.LatestHeight)))
+	w.Header().Set("X-Snapshot-SHA256", digest)
+	w.Write(archive)
+}
+
+// Handler for POST /admin/snapshot/import, taking the archive as the body
+// or loading it from object storage with ?name= (and ?region= for a
+// partition).
+func (c *Chain) handleImportSnapshot(w http.ResponseWriter, r *http.Request) {
+	var archive []byte
+	var err error
+	if name := r.URL.Query().Get("name"); name != "" {
+		if objectStore == nil {
+			writeStorageUnavailable(w)
+			return
+		}
+		key, err := snapshotObjectKey(name, r.URL.Query().Get("region"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		archive, err = objectStore.Get(key)
+		if errors.Is(err, objstore.ErrNotFound) {
+			http.Error(w, "Snapshot "+key+" not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			log.Printf("Failed to load snapshot %s: %v", key, err)
+			http.Error(w, "Failed to load snapshot", http.StatusBadGateway)
+			return
+		}
+	} else if archive, err = io.ReadAll(io.LimitReader(r.Body, snapshotMaxBytes+1)); err != nil {
+		http.Error(w, "Failed to read request body", http.StatusBadRequest)
+		return
+	} else if len(archive) == 0 {
+		http.Error(w, "Snapshot archive or name required", http.StatusBadRequest)
+		return
+	}
+
+	digest := sha256Hex(archive)
+	if want := r.URL.Query().Get("sha256"); want != "" && !strings.EqualFold(want, digest) {
+		http.Error(w, fmt.Sprintf("Snapshot sha256 is %s, expected %s", digest, want), http.StatusUnprocessableEntity)
+		return
+	}
+	manifest, files, err := readSnapshot(archive)
+	if err != nil {
+		http.Error(w, "Invalid snapshot: "+err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	modules, txCount, err := c.restoreSnapshot(files)
+	if err != nil {
+		http.Error(w, "Invalid snapshot: "+err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	log.Printf("Imported snapshot of %s at height %d: modules %v, %d txs", manifest.ChainID, manifest.LatestHeight, modules, txCount)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"sha256":   digest,
+		"manifest": manifest,
+		"modules":  modules,
+		"txs":      txCount,
+	})
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Billing events are delivered as Stripe webhooks: the body is a Stripe
+// event object and the Stripe-Signature header carries
+// t=<timestamp>,v1=<HMAC-SHA256 of "<timestamp>.<body>">, so the
+// frontend's stripe.webhooks.constructEvent handlers verify them unchanged.
+//
+// Endpoints are configured per environment, either as a JSON array
+//
+//	BILLING_WEBHOOK_ENDPOINTS='[{"url": "...", "secret": "whsec_...", "enabled_events": ["invoice.*"]}]'
+//
+// or as a single endpoint through BILLING_WEBHOOK_URL, BILLING_WEBHOOK_SECRET
+// and BILLING_WEBHOOK_EVENTS (comma-separated, default "*"). An endpoint
+// without a secret gets a generated one, logged at startup. BILLING_LIVEMODE
+// sets the livemode flag on events and objects.
+
+const (
+	stripeAPIVersion       = "2023-10-16"
+	maxWebhookAttempts     = 3
+	maxWebhookDeliveryLogs = 1000
+)
+
+type webhookEndpoint struct {
+	URL           string   `json:"url"`
+	Secret        string   `json:"secret"`
+	EnabledEvents []string `json:"enabled_events"`
+}
+
+// accepts reports whether the endpoint subscribes to the event type.
+// Entries may be "*" or end in ".*" to match a whole category.
+func (e webhookEndpoint) accepts(eventType string) bool {
+	for _, enabled := range e.EnabledEvents {
+		if enabled == "*" || enabled == eventType ||
+			(strings.HasSuffix(enabled, ".*") && strings.HasPrefix(eventType, strings.TrimSuffix(enabled, "*"))) {
+			return true
+		}
+	}
+	return false
+}
+
+type webhookDelivery struct {
+	EventID   string `json:"event_id"`
+	EventType string `json:"event_type"`
+	URL       string `json:"url"`
+	Attempts  int    `json:"attempts"`
+	Status    int    `json:"last_status,omitempty"`
+	Error     string `json:"last_error,omitempty"`
+	Delivered bool   `json:"delivered"`
+	SentAt    int64  `json:"sent_at"`
+}
+
+var (
+	billingLivemode  = os.Getenv("BILLING_LIVEMODE") == "true"
+	webhookEndpoints = webhookEndpointsFromEnv()
+	webhookClient    = &http.Client{Timeout: 10 * time.Second}
+
+	webhookDeliveriesMu sync.Mutex
+	webhookDeliveries   []*webhookDelivery
+)
+
+func webhookEndpointsFromEnv() []webhookEndpoint {
+	var endpoints []webhookEndpoint
+	if raw := os.Getenv("BILLING_WEBHOOK_ENDPOINTS"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &endpoints); err != nil {
+			log.Printf("Invalid BILLING_WEBHOOK_ENDPOINTS: %v", err)
+			endpoints = nil
+		}
+	}
+	if url := os.Getenv("BILLING_WEBHOOK_URL"); url != "" {
+		endpoint := webhookEndpoint{URL: url, Secret: os.Getenv("BILLING_WEBHOOK_SECRET")}
+		if events := os.Getenv("BILLING_WEBHOOK_EVENTS"); events != "" {
+			for _, event := range strings.Split(events, ",") {
+				endpoint.EnabledEvents = append(endpoint.EnabledEvents, strings.TrimSpace(event))
+			}
+		}
+		endpoints = append(endpoints, endpoint)
+	}
+
+	for i := range endpoints {
+		if len(endpoints[i].EnabledEvents) == 0 {
+			endpoints[i].EnabledEvents = []string{"*"}
+		}
+		if endpoints[i].Secret == "" {
+			endpoints[i].Secret = newWebhookSecret()
+			log.Printf("Webhook signing secret for %s: %s", endpoints[i].URL, endpoints[i].Secret)
+		}
+	}
+	return endpoints
+}
+
+func newWebhookSecret() string {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		panic("crypto/rand failed: " + err.Error())
+	}
+	return "whsec_" + hex.EncodeToString(buf)
+}
+
+// stripeSignature builds the Stripe-Signature header value for a payload.
+func stripeSignature(secret string, timestamp int64, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.", timestamp)
+	mac.Write(payload)
+	return fmt.Sprintf("t=%d,v1=%s", timestamp, hex.EncodeToString(mac.Sum(nil)))
+}
+
+func countWebhookEndpoints(eventType string) int {
+	count := 0
+	for _, endpoint := range webhookEndpoints {
+		if endpoint.accepts(eventType) {
+			count++
+		}
+	}
+	return count
+}
+
+// sendBillingWebhooks delivers an event to every subscribed endpoint in the
+// background.
+func sendBillingWebhooks(ev billingEvent) {
+	if len(webhookEndpoints) == 0 {
+		return
+	}
+	payload, _ := json.Marshal(ev)
+	for _, endpoint := range webhookEndpoints {
+		if !endpoint.accepts(ev.Type) {
+			continue
+		}
+		delivery := &webhookDelivery{
+			EventID:   ev.ID,
+			EventType: ev.Type,
+			URL:       endpoint.URL,
+			SentAt:    appClock.Now().Unix(),
+		}
+		webhookDeliveriesMu.Lock()
+		webhookDeliveries = append(webhookDeliveries, delivery)
+		if len(webhookDeliveries) > maxWebhookDeliveryLogs {
+			webhookDeliveries = webhookDeliveries[len(webhookDeliveries)-maxWebhookDeliveryLogs:]
+		}
+		webhookDeliveriesMu.Unlock()
+
+		go deliverWebhook(endpoint, payload, delivery)
+	}
+}
+
+// deliverWebhook POSTs the payload, retrying with backoff on failure. Each
+// attempt is signed with a fresh timestamp.
+func deliverWebhook(endpoint webhookEndpoint, payload []byte, delivery *webhookDelivery) {
+	backoff := time.Second
+	for attempt := 1; attempt <= maxWebhookAttempts; attempt++ {
+		status, err := postWebhook(endpoint, payload)
+
+		webhookDeliveriesMu.Lock()
+		delivery.Attempts = attempt
+		delivery.Status = status
+		delivery.Error = ""
+		if err != nil {
+			delivery.Error = err.Error()
+		}
+		delivery.Delivered = err == nil && status/100 == 2
+		delivered := delivery.Delivered
+		webhookDeliveriesMu.Unlock()
+
+		if delivered {
+			return
+		}
+		log.Printf("Webhook %s to %s failed (attempt %d): status %d %v", delivery.EventID, endpoint.URL, attempt, status, err)
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+func postWebhook(endpoint webhookEndpoint, payload []byte) (int, error) {
+	req, err := http.NewRequest("POST", endpoint.URL, bytes.NewReader(payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.Header.Set("User-Agent", "Stripe/1.0 (+https://stripe.com/docs/webhooks)")
+	req.Header.Set("Stripe-Signature", stripeSignature(endpoint.Secret, time.Now().Unix(), payload))
+
+	resp, err := webhookClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+// Handler for GET /admin/billing/webhooks
+func handleListWebhooks(w http.ResponseWriter, r *http.Request) {
+	webhookDeliveriesMu.Lock()
+	deliveries := make([]webhookDelivery, 0, len(webhookDeliveries))
+	for i := len(webhookDeliveries) - 1; i >= 0; i-- {
+		deliveries = append(deliveries, *webhookDeliveries[i])
+	}
+	webhookDeliveriesMu.Unlock()
+
+	response := map[string]interface{}{
+		"api_version": stripeAPIVersion,
+		"livemode":    billingLivemode,
+		"endpoints":   webhookEndpoints,
+		"deliveries":  deliveries,
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
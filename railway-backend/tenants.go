@@ -0,0 +1,303 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// Tenants: isolated chains created at runtime, so parallel E2E shards
+// against one deployment each get their own state. POST /admin/tenants
+// creates a tenant with its own chain ID, stores and block producer,
+// served under /t/{tenant}/ (for example /t/shard-3/persona/did/v1beta1/...
+// and /t/shard-3/websocket). A shard resets, seeds, snapshots or restores
+// only its tenant with POST /admin/tenants/{id}/reset, /seed, /snapshot and
+// /restore/{snapshot}, and deletes it when done.
+//
+// Unlike NETWORKS chains, tenants come and go while serving, so they are
+// not mounted on the main router: one /t/{tenant}/ route looks the tenant
+// up and hands the request to the tenant's own router. Server-level APIs
+// (/api, /admin, quotas, faults) stay shared. /admin/reset leaves tenants
+// in place, since resetting them is each shard's business; a tenant created
+// with a ttl is deleted once it has been idle that long. At most
+// TENANTS_MAX (50) tenants exist at a time.
+
+type tenant struct {
+	ID        string
+	CreatedAt int64
+	// TTL is how long the tenant may sit idle before it is deleted; zero
+	// keeps it until deleted
+	TTL time.Duration
+
+	chain  *Chain
+	router *mux.Router
+	// lastUsed is the wall time of the last request, for the TTL
+	lastUsed time.Time
+}
+
+var (
+	tenantID   = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,62}$`)
+	maxTenants = intFromEnv("TENANTS_MAX", 50)
+
+	tenantsMu sync.Mutex
+	tenants   = make(map[string]*tenant)
+)
+
+// tenantPrefix returns the route prefix of a tenant's chain.
+func tenantPrefix(id string) string {
+	return "/t/" + id
+}
+
+// sweepTenantsLocked deletes tenants idle past their TTL. Must be called
+// with tenantsMu held.
+func sweepTenantsLocked() {
+	now := time.Now()
+	for id, t := range tenants {
+		if t.TTL > 0 && now.Sub(t.lastUsed) > t.TTL {
+			t.chain.stopBlockProducer()
+			delete(tenants, id)
+			log.Printf("Tenant %s expired after %s idle", id, t.TTL)
+		}
+	}
+}
+
+// lookupTenant returns a live tenant and marks it used, or nil.
+func lookupTenant(id string) *tenant {
+	tenantsMu.Lock()
+	defer tenantsMu.Unlock()
+	sweepTenantsLocked()
+	t := tenants[id]
+	if t != nil {
+		t.lastUsed = time.Now()
+	}
+	return t
+}
+
+func (t *tenant) view(r *http.Request) map[string]interface{} {
+	c := t.chain
+	c.mu.RLock()
+	height := c.info.LatestHeight
+	c.mu.RUnlock()
+
+	rest := requestBaseURL(r) + tenantPrefix(t.ID)
+	view := map[string]interface{}{
+		"id":            t.ID,
+		"chain_id":      c.ChainID(),
+		"denom":         c.denom,
+		"block_time":    c.blockTime.String(),
+		"latest_height": height,
+		"created_at":    t.CreatedAt,
+		"prefix":        tenantPrefix(t.ID),
+		"rest":          rest,
+		"rpc":           rest,
+		"websocket":     "ws" + strings.TrimPrefix(rest, "http") + "/websocket",
+	}
+	if t.TTL > 0 {
+		view["ttl"] = t.TTL.String()
+	}
+	return view
+}
+
+// Handler for /t/{tenant}/...
+func handleTenantRequest(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["tenant"]
+	t := lookupTenant(id)
+	if t == nil {
+		writeGRPCError(w, http.StatusNotFound, 5, "tenant "+id+" not found")
+		return
+	}
+	t.router.ServeHTTP(w, r)
+}
+
+// Handler for POST /admin/tenants
+func handleCreateTenant(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ID        string `json:"id"`
+		ChainID   string `json:"chain_id"`
+		Denom     string `json:"denom"`
+		BlockTime string `json:"block_time"`
+		TTL       string `json:"ttl"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+	if !tenantID.MatchString(req.ID) {
+		http.Error(w, "id must be 1-63 lowercase letters, digits or dashes", http.StatusBadRequest)
+		return
+	}
+	if req.ChainID == "" {
+		req.ChainID = "persona-" + req.ID
+	}
+	cfg := ChainConfig{
+		ChainID:       req.ChainID,
+		InitialHeight: 1,
+		BlockTime:     defaultChain.blockTime,
+		Clock:         appClock,
+		Denom:         req.Denom,
+		NodeInfo: NodeInfo{
+			ID:      "mock-node-" + req.ID,
+			Moniker: req.ID + "-node",
+			Version: defaultChain.info.NodeInfo.Version,
+		},
+	}
+	if req.BlockTime != "" {
+		d, err := time.ParseDuration(req.BlockTime)
+		if err != nil || d <= 0 {
+			http.Error(w, fmt.Sprintf("Invalid block_time %q", req.BlockTime), http.StatusBadRequest)
+			return
+		}
+		cfg.BlockTime = d
+	}
+	var ttl time.Duration
+	if req.TTL != "" {
+		d, err := time.ParseDuration(req.TTL)
+		if err != nil || d <= 0 {
+			http.Error(w, fmt.Sprintf("Invalid ttl %q", req.TTL), http.StatusBadRequest)
+			return
+		}
+		ttl = d
+	}
+
+	tenantsMu.Lock()
+	sweepTenantsLocked()
+	if tenants[req.ID] != nil {
+		tenantsMu.Unlock()
+		http.Error(w, "Tenant "+req.ID+" already exists", http.StatusConflict)
+		return
+	}
+	if len(tenants) >= maxTenants {
+		tenantsMu.Unlock()
+		writeGRPCError(w, http.StatusTooManyRequests, 8, fmt.Sprintf("at most %d tenants, delete one first", maxTenants))
+		return
+	}
+	t := &tenant{ID: req.ID, CreatedAt: appClock.Now().Unix(), TTL: ttl, chain: NewChain(cfg), lastUsed: time.Now()}
+	t.router = mux.NewRouter()
+	t.chain.RegisterRoutes(t.router.PathPrefix(tenantPrefix(t.ID)).Subrouter())
+	tenants[t.ID] = t
+	tenantsMu.Unlock()
+
+	t.chain.startBlockProducer()
+	log.Printf("Tenant %s (%s) at %s/", t.ID, cfg.ChainID, tenantPrefix(t.ID))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{"tenant": t.view(r)})
+}
+
+// Handler for GET /admin/tenants
+func handleListTenants(w http.ResponseWriter, r *http.Request) {
+	tenantsMu.Lock()
+	sweepTenantsLocked()
+	list := make([]*tenant, 0, len(tenants))
+	for _, t := range tenants {
+		list = append(list, t)
+	}
+	tenantsMu.Unlock()
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+
+	views := []map[string]interface{}{}
+	for _, t := range list {
+		views = append(views, t.view(r))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"tenants": views, "max": maxTenants})
+}
+
+// adminTenant returns the tenant of an admin request, writing a 404 when
+// there is none.
+func adminTenant(w http.ResponseWriter, r *http.Request) *tenant {
+	id := mux.Vars(r)["id"]
+	t := lookupTenant(id)
+	if t == nil {
+		http.Error(w, "Tenant "+id+" not found", http.StatusNotFound)
+	}
+	return t
+}
+
+// Handler for GET /admin/tenants/{id}
+func handleGetTenant(w http.ResponseWriter, r *http.Request) {
+	t := adminTenant(w, r)
+	if t == nil {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"tenant": t.view(r)})
+}
+
+// Handler for DELETE /admin/tenants/{id}
+func handleDeleteTenant(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	tenantsMu.Lock()
+	t := tenants[id]
+	delete(tenants, id)
+	tenantsMu.Unlock()
+	if t == nil {
+		http.Error(w, "Tenant "+id+" not found", http.StatusNotFound)
+		return
+	}
+	t.chain.stopBlockProducer()
+	log.Printf("Tenant %s deleted", id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Handler for POST /admin/tenants/{id}/reset. Wipes the tenant's chain
+// state only.
+func handleResetTenant(w http.ResponseWriter, r *http.Request) {
+	t := adminTenant(w, r)
+	if t == nil {
+		return
+	}
+	t.chain.resetState()
+	log.Printf("Tenant %s reset", t.ID)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"reset": []string{"chain"}, "tenant": t.ID})
+}
+
+// Handler for POST /admin/tenants/{id}/seed. Takes the payload of POST
+// /admin/seed, including ?reset=true.
+func handleSeedTenant(w http.ResponseWriter, r *http.Request) {
+	t := adminTenant(w, r)
+	if t == nil {
+		return
+	}
+	t.chain.handleAdminSeed(w, r)
+}
+
+// Handler for POST /admin/tenants/{id}/snapshot. Takes an in-memory
+// snapshot of the tenant's chain, see checkpoint.go.
+func handleSnapshotTenant(w http.ResponseWriter, r *http.Request) {
+	t := adminTenant(w, r)
+	if t == nil {
+		return
+	}
+	t.chain.handleCreateCheckpoint(w, r)
+}
+
+// Handler for POST /admin/tenants/{id}/restore/{snapshot}
+func handleRestoreTenant(w http.ResponseWriter, r *http.Request) {
+	t := adminTenant(w, r)
+	if t == nil {
+		return
+	}
+	id := mux.Vars(r)["snapshot"]
+	cp := t.chain.checkpoints.get(id)
+	if cp == nil {
+		http.Error(w, "Snapshot "+id+" not found for tenant "+t.ID, http.StatusNotFound)
+		return
+	}
+	if err := t.chain.restoreCheckpoint(cp); err != nil {
+		http.Error(w, "Failed to restore snapshot: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"restored": cp, "tenant": t.ID})
+}
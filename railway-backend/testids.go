@@ -0,0 +1,208 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"persona-backend/idgen"
+
+	"github.com/cosmos/btcutil/bech32"
+	"github.com/gorilla/mux"
+)
+
+// Reserved test identifiers for parallel E2E shards. Each worker calls
+// POST /api/test-ids/reserve?count=10 at the start of its run and gets
+// DIDs, bech32 addresses and handles no other worker (or earlier run of
+// the daemon) will be given, so shards never collide on shared state.
+// Passing ?run= adds to an existing run. A run is released when its TTL
+// (?ttl=, default TEST_IDS_TTL or 1h, wall clock) passes, or explicitly
+// with DELETE /api/test-ids/runs/{run}; released identifiers are never
+// handed out again.
+
+const (
+	maxTestIDsPerCall = 500
+	maxTestRunTTL     = 24 * time.Hour
+)
+
+type testIdentity struct {
+	DID     string `json:"did"`
+	Address string `json:"address"`
+	Handle  string `json:"handle"`
+}
+
+type testRun struct {
+	ID         string         `json:"run_id"`
+	CreatedAt  time.Time      `json:"created_at"`
+	ExpiresAt  time.Time      `json:"expires_at"`
+	Identities []testIdentity `json:"ids"`
+}
+
+var (
+	testIDsMu  sync.Mutex
+	testRuns   = make(map[string]*testRun)
+	testIDSeq  uint64
+	testIDsTTL = durationFromEnv("TEST_IDS_TTL", time.Hour)
+	// testIDNonce makes identifiers unique across daemon restarts
+	testIDNonce = newTestIDNonce()
+)
+
+func init() {
+	registerAdminState("test_ids", func() interface{} {
+		testIDsMu.Lock()
+		defer testIDsMu.Unlock()
+		expireTestRunsLocked(time.Now())
+		runs := make([]testRun, 0, len(testRuns))
+		for _, id := range sortedMapKeys(testRuns) {
+			runs = append(runs, *testRuns[id])
+		}
+		return runs
+	}, func() {
+		// The sequence keeps counting, so identifiers stay unique
+		testIDsMu.Lock()
+		defer testIDsMu.Unlock()
+		testRuns = make(map[string]*testRun)
+	})
+}
+
+func newTestIDNonce() string {
+	if deterministic {
+		return "00000000"
+	}
+	var b [4]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// newTestIdentityLocked derives the next identity from the sequence. Must
+// be called with testIDsMu held.
+func newTestIdentityLocked() testIdentity {
+	testIDSeq++
+	name := fmt.Sprintf("%s%06d", testIDNonce, testIDSeq)
+	sum := sha256.Sum256([]byte("persona-test-id/" + name))
+	address, err := bech32.EncodeFromBase256("cosmos", sum[:20])
+	if err != nil {
+		panic("bech32 encoding failed: " + err.Error())
+	}
+	return testIdentity{
+		DID:     "did:persona:test-" + name,
+		Address: address,
+		Handle:  "test_" + name,
+	}
+}
+
+// expireTestRunsLocked releases runs past their TTL. Must be called with
+// testIDsMu held.
+func expireTestRunsLocked(now time.Time) {
+	for id, run := range testRuns {
+		if !now.Before(run.ExpiresAt) {
+			log.Printf("Test ID run %s expired, released %d identifiers", id, len(run.Identities))
+			delete(testRuns, id)
+		}
+	}
+}
+
+// Handler for POST /api/test-ids/reserve
+func handleReserveTestIDs(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	count := 1
+	if raw := q.Get("count"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > maxTestIDsPerCall {
+			http.Error(w, fmt.Sprintf("count must be between 1 and %d", maxTestIDsPerCall), http.StatusBadRequest)
+			return
+		}
+		count = n
+	}
+	ttl := testIDsTTL
+	if raw := q.Get("ttl"); raw != "" {
+		d, err := time.ParseDuration(raw)
+		if err != nil || d <= 0 || d > maxTestRunTTL {
+			http.Error(w, fmt.Sprintf("ttl must be a duration up to %s", maxTestRunTTL), http.StatusBadRequest)
+			return
+		}
+		ttl = d
+	}
+
+	now := time.Now()
+	testIDsMu.Lock()
+	expireTestRunsLocked(now)
+	runID := q.Get("run")
+	run, exists := testRuns[runID]
+	if runID != "" && !exists {
+		testIDsMu.Unlock()
+		http.Error(w, "Unknown or expired run "+runID, http.StatusNotFound)
+		return
+	}
+	if !exists {
+		run = &testRun{ID: idgen.NewWithPrefix("run"), CreatedAt: now}
+		testRuns[run.ID] = run
+	}
+	// Reserving again renews the run
+	run.ExpiresAt = now.Add(ttl)
+	reserved := make([]testIdentity, count)
+	for i := range reserved {
+		reserved[i] = newTestIdentityLocked()
+	}
+	run.Identities = append(run.Identities, reserved...)
+	total := len(run.Identities)
+	testIDsMu.Unlock()
+
+	status := http.StatusCreated
+	if exists {
+		status = http.StatusOK
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"run_id":     run.ID,
+		"expires_at": run.ExpiresAt.UTC().Format(time.RFC3339),
+		"ids":        reserved,
+		"total":      total,
+	})
+}
+
+// Handler for GET /api/test-ids/runs/{run}
+func handleGetTestRun(w http.ResponseWriter, r *http.Request) {
+	runID := mux.Vars(r)["run"]
+
+	testIDsMu.Lock()
+	expireTestRunsLocked(time.Now())
+	run, ok := testRuns[runID]
+	var snapshot testRun
+	if ok {
+		snapshot = *run
+	}
+	testIDsMu.Unlock()
+
+	if !ok {
+		http.Error(w, "Unknown or expired run "+runID, http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(snapshot)
+}
+
+// Handler for DELETE /api/test-ids/runs/{run}
+func handleReleaseTestRun(w http.ResponseWriter, r *http.Request) {
+	runID := mux.Vars(r)["run"]
+
+	testIDsMu.Lock()
+	run, ok := testRuns[runID]
+	delete(testRuns, runID)
+	testIDsMu.Unlock()
+
+	if !ok {
+		http.Error(w, "Unknown or expired run "+runID, http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"released": len(run.Identities)})
+}
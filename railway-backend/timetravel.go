@@ -0,0 +1,130 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"persona-backend/clock"
+)
+
+// Time travel for expiry testing. POST /admin/time moves the app clock,
+// which every chain reads for issuance and expiration dates, proof
+// freshness, block timestamps, quota windows and billing, with
+// {"advance": "720h"} (Go durations plus a d suffix, e.g. "30d"; negative
+// goes back) or {"set": "2030-01-01T00:00:00Z"}. Time keeps running from
+// there. DELETE /admin/time and /admin/reset go back to the wall clock.
+//
+// In deterministic mode the clock is manual: it can be moved, but not back
+// to a wall clock it never followed. Real-world deadlines (websocket
+// pings, cache TTLs, presigned URLs) stay on the wall clock.
+
+func init() {
+	registerAdminState("clock", func() interface{} {
+		return clockState()
+	}, func() {
+		resetAppClock()
+	})
+}
+
+// resetAppClock puts an offset app clock back on the wall clock. Returns
+// false for a manual clock.
+func resetAppClock() bool {
+	offset, ok := appClock.(*clock.Offset)
+	if !ok {
+		return false
+	}
+	if d := offset.Offset(); d != 0 {
+		offset.Advance(-d)
+		verifyCache.Flush()
+		log.Printf("App clock back on the wall clock")
+	}
+	return true
+}
+
+func clockState() map[string]interface{} {
+	now := appClock.Now()
+	state := map[string]interface{}{
+		"now":  now.UTC().Format(time.RFC3339),
+		"unix": now.Unix(),
+		"mode": "offset",
+	}
+	if offset, ok := appClock.(*clock.Offset); ok {
+		state["offset"] = offset.Offset().Round(time.Second).String()
+		state["offset_seconds"] = int64(offset.Offset().Seconds())
+	} else {
+		state["mode"] = "manual"
+	}
+	return state
+}
+
+// Handler for GET /admin/time
+func handleGetTime(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(clockState())
+}
+
+// Handler for POST /admin/time
+func handleSetTime(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Advance string `json:"advance"`
+		Set     string `json:"set"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+	if (req.Advance == "") == (req.Set == "") {
+		http.Error(w, "Expected exactly one of advance and set", http.StatusBadRequest)
+		return
+	}
+	advancer, ok := appClock.(clock.Advancer)
+	if !ok {
+		http.Error(w, "The app clock cannot be moved", http.StatusConflict)
+		return
+	}
+
+	var d time.Duration
+	if req.Advance != "" {
+		// parseScenarioDuration takes no sign, so handle going back here
+		back := req.Advance[0] == '-'
+		duration := req.Advance
+		if back {
+			duration = duration[1:]
+		}
+		parsed, err := parseScenarioDuration(duration)
+		if err != nil {
+			http.Error(w, "advance: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		d = parsed
+		if back {
+			d = -d
+		}
+	} else {
+		target, err := time.Parse(time.RFC3339, req.Set)
+		if err != nil {
+			http.Error(w, "set must be an RFC 3339 time such as 2030-01-01T00:00:00Z", http.StatusBadRequest)
+			return
+		}
+		d = target.Sub(appClock.Now())
+	}
+	advancer.Advance(d)
+	// Cached verdicts may depend on expiry
+	verifyCache.Flush()
+	log.Printf("App clock moved by %s to %s", d, appClock.Now().UTC().Format(time.RFC3339))
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(clockState())
+}
+
+// Handler for DELETE /admin/time
+func handleResetTime(w http.ResponseWriter, r *http.Request) {
+	if !resetAppClock() {
+		http.Error(w, "The deterministic clock has no wall clock to go back to", http.StatusConflict)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(clockState())
+}
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"persona-backend/idgen"
+
+	"github.com/gorilla/mux"
+)
+
+// Request trace IDs, so one frontend action can be followed across the
+// effects it has after the response. A request's trace ID is its
+// X-Request-ID header, else the trace-id of a W3C traceparent header, else
+// a generated trace_... ID, and is echoed in X-Request-ID. Everything the
+// request causes carries it as trace_id: the txs it broadcasts, their
+// activity events (on /events, and on the websocket as activity.trace_id),
+// the webhook deliveries of those events (in the body and the X-Request-ID
+// header) and the security events it records.
+//
+// GET /api/trace/{id} gathers all of that. Requests themselves are listed
+// when they change something (anything but GET, HEAD and OPTIONS) or came
+// with a trace ID from the client. The last maxTraces traces are kept;
+// they are server-level state cleared by /admin/reset.
+
+const (
+	traceHeader = "X-Request-ID"
+	maxTraces   = 1000
+	// Longest client trace ID accepted
+	maxTraceIDLength = 128
+)
+
+var (
+	clientTraceID = regexp.MustCompile(`^[A-Za-z0-9._:/+=@-]+$`)
+	traceparent   = regexp.MustCompile(`^[0-9a-f]{2}-([0-9a-f]{32})-[0-9a-f]{16}-[0-9a-f]{2}$`)
+)
+
+type traceIDKey struct{}
+
+type traceRequest struct {
+	Method     string `json:"method"`
+	Path       string `json:"path"`
+	Status     int    `json:"status"`
+	Time       int64  `json:"time"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+type traceRecord struct {
+	ID        string
+	FirstSeen int64
+	Requests  []traceRequest
+	TxHashes  []string
+	Events    []activityEvent
+	// Webhook deliveries and security events by ID, looked up when the
+	// trace is read so their current state shows
+	Deliveries     []string
+	SecurityEvents []string
+}
+
+var (
+	tracesMu sync.Mutex
+	traces   = make(map[string]*traceRecord)
+	// Trace IDs, oldest first
+	traceOrder []string
+)
+
+func init() {
+	registerAdminState("traces", func() interface{} {
+		tracesMu.Lock()
+		defer tracesMu.Unlock()
+		return map[string]interface{}{"traces": len(traces)}
+	}, func() {
+		tracesMu.Lock()
+		defer tracesMu.Unlock()
+		traces = make(map[string]*traceRecord)
+		traceOrder = nil
+	})
+}
+
+// incomingTraceID returns the trace ID a client sent, or "".
+func incomingTraceID(r *http.Request) string {
+	if id := strings.TrimSpace(r.Header.Get(traceHeader)); id != "" && len(id) <= maxTraceIDLength && clientTraceID.MatchString(id) {
+		return id
+	}
+	if m := traceparent.FindStringSubmatch(strings.TrimSpace(r.Header.Get("traceparent"))); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+// requestTraceID returns the trace ID of a request, or "" for requests that
+// did not pass through traceMiddleware, such as selftest scratch requests.
+func requestTraceID(r *http.Request) string {
+	id, _ := r.Context().Value(traceIDKey{}).(string)
+	return id
+}
+
+// recordTrace updates the trace of id, creating it and dropping the oldest
+// trace when needed. Does nothing for an empty id.
+func recordTrace(id string, update func(t *traceRecord)) {
+	if id == "" {
+		return
+	}
+	tracesMu.Lock()
+	defer tracesMu.Unlock()
+	t := traces[id]
+	if t == nil {
+		t = &traceRecord{ID: id, FirstSeen: appClock.Now().Unix()}
+		traces[id] = t
+		traceOrder = append(traceOrder, id)
+		if len(traceOrder) > maxTraces {
+			delete(traces, traceOrder[0])
+			traceOrder = traceOrder[1:]
+		}
+	}
+	update(t)
+}
+
+// traceStatusWriter captures the status of a traced request.
+type traceStatusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (tw *traceStatusWriter) WriteHeader(status int) {
+	if tw.status == 0 {
+		tw.status = status
+	}
+	tw.ResponseWriter.WriteHeader(status)
+}
+
+func (tw *traceStatusWriter) Write(p []byte) (int, error) {
+	if tw.status == 0 {
+		tw.status = http.StatusOK
+	}
+	return tw.ResponseWriter.Write(p)
+}
+
+func (tw *traceStatusWriter) Flush() {
+	if flusher, ok := tw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func traceMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := incomingTraceID(r)
+		fromClient := id != ""
+		if !fromClient {
+			id = idgen.NewWithPrefix("trace")
+		}
+		w.Header().Set(traceHeader, id)
+		r = r.WithContext(context.WithValue(r.Context(), traceIDKey{}, id))
+
+		readOnly := r.Method == "GET" || r.Method == "HEAD" || r.Method == "OPTIONS"
+		if (readOnly && !fromClient) || r.Header.Get("Upgrade") != "" || strings.HasPrefix(r.URL.Path, "/api/trace/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+		start := time.Now()
+		tw := &traceStatusWriter{ResponseWriter: w}
+		next.ServeHTTP(tw, r)
+		if tw.status == 0 {
+			tw.status = http.StatusOK
+		}
+		recordTrace(id, func(t *traceRecord) {
+			t.Requests = append(t.Requests, traceRequest{
+				Method:     r.Method,
+				Path:       r.URL.Path,
+				Status:     tw.status,
+				Time:       appClock.Now().Unix(),
+				DurationMs: time.Since(start).Milliseconds(),
+			})
+		})
+	})
+}
+
+// Handler for GET /api/trace/{id}
+func (c *Chain) handleGetTrace(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	tracesMu.Lock()
+	t := traces[id]
+	var record traceRecord
+	if t != nil {
+		record = *t
+		record.Requests = append([]traceRequest(nil), t.Requests...)
+		record.TxHashes = append([]string(nil), t.TxHashes...)
+		record.Events = append([]activityEvent(nil), t.Events...)
+		record.Deliveries = append([]string(nil), t.Deliveries...)
+		record.SecurityEvents = append([]string(nil), t.SecurityEvents...)
+	}
+	tracesMu.Unlock()
+	if t == nil {
+		writeGRPCError(w, http.StatusNotFound, 5, "trace "+id+" not found")
+		return
+	}
+
+	txs := []map[string]interface{}{}
+	c.mu.RLock()
+	for _, hash := range record.TxHashes {
+		if tx := c.txsByHash[normalizeTxHash(hash)]; tx != nil {
+			txs = append(txs, map[string]interface{}{
+				"txhash":  tx.Response.TxHash,
+				"height":  tx.Response.Height,
+				"code":    tx.Response.Code,
+				"actions": tx.Actions,
+			})
+		}
+	}
+	c.mu.RUnlock()
+
+	deliveries := []identityWebhookDelivery{}
+	identityWebhooksMu.Lock()
+	for _, d := range identityWebhookDeliveries {
+		if containsString(record.Deliveries, d.ID) {
+			deliveries = append(deliveries, *d)
+		}
+	}
+	identityWebhooksMu.Unlock()
+
+	security := []securityEvent{}
+	securityEventsMu.Lock()
+	byID := make(map[string]securityEvent)
+	for _, list := range securityEvents {
+		for _, ev := range list {
+			byID[ev.ID] = *ev
+		}
+	}
+	securityEventsMu.Unlock()
+	for _, id := range record.SecurityEvents {
+		if ev, ok := byID[id]; ok {
+			security = append(security, ev)
+		}
+	}
+
+	if record.Requests == nil {
+		record.Requests = []traceRequest{}
+	}
+	if record.Events == nil {
+		record.Events = []activityEvent{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"trace_id":           record.ID,
+		"first_seen":         record.FirstSeen,
+		"requests":           record.Requests,
+		"txs":                txs,
+		"events":             record.Events,
+		"webhook_deliveries": deliveries,
+		"security_events":    security,
+	})
+}
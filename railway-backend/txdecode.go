@@ -0,0 +1,139 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strconv"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoregistry"
+
+	secp256k1pb "persona-backend/proto/cosmos/crypto/secp256k1"
+	txv1beta1 "persona-backend/proto/cosmos/tx/v1beta1"
+	_ "persona-backend/proto/persona/did/v1"
+	_ "persona-backend/proto/persona/vc/v1"
+	_ "persona-backend/proto/persona/zk/v1"
+)
+
+// Protobuf tx decoding: a broadcast carrying tx_bytes (a TxRaw, as cosmjs
+// sends with protobuf encoding) is decoded into the Cosmos tx JSON shape
+// that JSON broadcasts use, so its messages are delivered and stored the
+// same way. Messages are resolved by type URL among the generated message
+// types (proto/persona/*/v1/tx.proto) and rendered with their proto field
+// names, which match the JSON message fields. Bytes that are not a TxRaw,
+// or carry a message type without a proto definition, are rejected with
+// code 2 like a real node's tx decoder. So do broadcasts carrying tx_bytes
+// next to a JSON tx or msgs: the signatures and tx hash cover tx_bytes, so
+// the JSON messages must not be the ones delivered.
+
+// decodeProtoTx replaces the tx_bytes of a broadcast with the decoded tx.
+func decodeProtoTx(txData map[string]interface{}) *txError {
+	encoded, ok := txData["tx_bytes"].(string)
+	if !ok || encoded == "" {
+		return nil
+	}
+	if txData["tx"] != nil || txData["msgs"] != nil {
+		return txErrorf(codeTxDecode, "tx_bytes cannot be combined with tx or msgs")
+	}
+	txBytes, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return txErrorf(codeTxDecode, "tx_bytes is not base64")
+	}
+	tx, txErr := decodeTxRaw(txBytes)
+	if txErr != nil {
+		return txErr
+	}
+	txData["tx"] = tx
+	return nil
+}
+
+// txRawOf returns the TxRaw of a protobuf broadcast, or nil.
+func txRawOf(txData map[string]interface{}) *txv1beta1.TxRaw {
+	encoded, _ := txData["tx_bytes"].(string)
+	txBytes, err := base64.StdEncoding.DecodeString(encoded)
+	if encoded == "" || err != nil {
+		return nil
+	}
+	var raw txv1beta1.TxRaw
+	if proto.Unmarshal(txBytes, &raw) != nil {
+		return nil
+	}
+	return &raw
+}
+
+// decodeTxRaw decodes TxRaw bytes into Cosmos tx JSON.
+func decodeTxRaw(txBytes []byte) (map[string]interface{}, *txError) {
+	var raw txv1beta1.TxRaw
+	var body txv1beta1.TxBody
+	var authInfo txv1beta1.AuthInfo
+	if err := proto.Unmarshal(txBytes, &raw); err != nil {
+		return nil, txErrorf(codeTxDecode, "%v", err)
+	}
+	if err := proto.Unmarshal(raw.BodyBytes, &body); err != nil {
+		return nil, txErrorf(codeTxDecode, "body: %v", err)
+	}
+	if err := proto.Unmarshal(raw.AuthInfoBytes, &authInfo); err != nil {
+		return nil, txErrorf(codeTxDecode, "auth_info: %v", err)
+	}
+
+	messages := make([]interface{}, 0, len(body.Messages))
+	for _, anyMsg := range body.Messages {
+		msgType, err := protoregistry.GlobalTypes.FindMessageByURL(anyMsg.TypeUrl)
+		if err != nil {
+			return nil, txErrorf(codeTxDecode, "unable to resolve type URL %s", anyMsg.TypeUrl)
+		}
+		msg := msgType.New().Interface()
+		if err := proto.Unmarshal(anyMsg.Value, msg); err != nil {
+			return nil, txErrorf(codeTxDecode, "%s: %v", anyMsg.TypeUrl, err)
+		}
+		data, err := protojson.MarshalOptions{UseProtoNames: true}.Marshal(msg)
+		if err != nil {
+			return nil, txErrorf(codeTxDecode, "%s: %v", anyMsg.TypeUrl, err)
+		}
+		message := map[string]interface{}{}
+		json.Unmarshal(data, &message)
+		// Cosmos type URLs are "/" + the full name, whatever prefix was sent
+		message["@type"] = "/" + string(msgType.Descriptor().FullName())
+		messages = append(messages, message)
+	}
+
+	signerInfos := make([]interface{}, 0, len(authInfo.SignerInfos))
+	for _, info := range authInfo.SignerInfos {
+		signerInfo := map[string]interface{}{"sequence": strconv.FormatUint(info.Sequence, 10)}
+		if info.PublicKey != nil {
+			publicKey := map[string]interface{}{"@type": "/" + string(info.PublicKey.MessageName())}
+			var key secp256k1pb.PubKey
+			if info.PublicKey.MessageIs(&key) && info.PublicKey.UnmarshalTo(&key) == nil {
+				publicKey["key"] = base64.StdEncoding.EncodeToString(key.Key)
+			}
+			signerInfo["public_key"] = publicKey
+		}
+		signerInfos = append(signerInfos, signerInfo)
+	}
+	amount := []interface{}{}
+	gasLimit := "0"
+	if fee := authInfo.Fee; fee != nil {
+		for _, coin := range fee.Amount {
+			amount = append(amount, map[string]interface{}{"denom": coin.Denom, "amount": coin.Amount})
+		}
+		gasLimit = strconv.FormatUint(fee.GasLimit, 10)
+	}
+	signatures := make([]interface{}, len(raw.Signatures))
+	for i, signature := range raw.Signatures {
+		signatures[i] = base64.StdEncoding.EncodeToString(signature)
+	}
+
+	return map[string]interface{}{
+		"body": map[string]interface{}{
+			"messages":       messages,
+			"memo":           body.Memo,
+			"timeout_height": strconv.FormatUint(body.TimeoutHeight, 10),
+		},
+		"auth_info": map[string]interface{}{
+			"signer_infos": signerInfos,
+			"fee":          map[string]interface{}{"amount": amount, "gas_limit": gasLimit},
+		},
+		"signatures": signatures,
+	}, nil
+}
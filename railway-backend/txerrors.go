@@ -0,0 +1,74 @@
+package main
+
+import "fmt"
+
+// Cosmos SDK error codes (codespace "sdk") used in mock tx responses so the
+// frontend sees the same codes a real node would return.
+const (
+	codeOK                = 0
+	codeTxDecode          = 2
+	codeUnauthorized      = 4
+	codeInsufficientFunds = 5
+	codeInvalidAddress    = 7
+	codeInvalidPubKey     = 8
+	codeOutOfGas          = 11
+	codeInsufficientFee   = 13
+	codeNoSignatures      = 15
+	codeInvalidRequest    = 18
+	codeTxInMempoolCache  = 19
+	codeMempoolIsFull     = 20
+	codeWrongSequence     = 32
+	codeNotFound          = 38
+)
+
+var codeNames = map[int]string{
+	codeTxDecode:          "tx parse error",
+	codeUnauthorized:      "unauthorized",
+	codeInsufficientFunds: "insufficient funds",
+	codeInvalidAddress:    "invalid address",
+	codeInvalidPubKey:     "invalid pubkey",
+	codeOutOfGas:          "out of gas",
+	codeInsufficientFee:   "insufficient fee",
+	codeNoSignatures:      "no signatures supplied",
+	codeInvalidRequest:    "invalid request",
+	codeTxInMempoolCache:  "tx already in mempool",
+	codeMempoolIsFull:     "mempool is full",
+	codeWrongSequence:     "incorrect account sequence",
+	codeNotFound:          "not found",
+}
+
+// Module error codes, reported under the module's own codespace.
+const (
+	codeVCSchemaViolation   = 12
+	codeVCInvalidCredential = 13
+)
+
+// txError is a failed message execution, reported as a non-zero tx code.
+type txError struct {
+	Code      int
+	Codespace string
+	Log       string
+}
+
+func (e *txError) Error() string {
+	return e.Log
+}
+
+// txErrorf builds a txError whose log mirrors the Cosmos "<detail>: <code name>" format.
+func txErrorf(code int, format string, args ...interface{}) *txError {
+	return &txError{
+		Code:      code,
+		Codespace: "sdk",
+		Log:       fmt.Sprintf(format, args...) + ": " + codeNames[code],
+	}
+}
+
+// moduleErrorf builds a txError registered by a module under its own
+// codespace, with the error's name appended like the SDK's.
+func moduleErrorf(codespace string, code int, name string, format string, args ...interface{}) *txError {
+	return &txError{
+		Code:      code,
+		Codespace: codespace,
+		Log:       fmt.Sprintf(format, args...) + ": " + name,
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ABCI events of a delivered tx, in the shape real nodes return in
+// tx_response.events and tx_response.logs. Every message gets a message
+// event with its action, sender and module, and handlers add their own
+// with ctx.event:
+//
+//	did.created         did_id, controller
+//	vc.issued           credential_id, issuer, subject, creator
+//	zk.proof_submitted  proof_id, circuit_id, prover, verified
+//
+// Events carry a msg_index attribute, as on SDK 0.47+, and logs group them
+// per message for clients that read logs[i].events. Failed txs have
+// neither, since a real node discards the events of a failed tx.
+
+type abciAttribute struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+	Index bool   `json:"index"`
+}
+
+type abciEvent struct {
+	Type       string          `json:"type"`
+	Attributes []abciAttribute `json:"attributes"`
+}
+
+type abciMessageLog struct {
+	MsgIndex int         `json:"msg_index"`
+	Log      string      `json:"log"`
+	Events   []abciEvent `json:"events"`
+}
+
+// msgModule returns the module of a message type URL, e.g. did for
+// /persona.did.v1.MsgCreateDid.
+func msgModule(typeURL string) string {
+	parts := strings.Split(strings.TrimPrefix(typeURL, "/"), ".")
+	if len(parts) < 3 {
+		return ""
+	}
+	return parts[len(parts)-3]
+}
+
+// event records an event of the current message. attributes are key,
+// value pairs.
+func (ctx *msgContext) event(eventType string, attributes ...string) {
+	ev := abciEvent{Type: eventType}
+	for i := 0; i+1 < len(attributes); i += 2 {
+		ev.Attributes = append(ev.Attributes, abciAttribute{Key: attributes[i], Value: attributes[i+1], Index: true})
+	}
+	ev.Attributes = append(ev.Attributes, abciAttribute{Key: "msg_index", Value: strconv.Itoa(ctx.MsgIndex), Index: true})
+	ctx.Events = append(ctx.Events, ev)
+}
+
+// txLogs groups events into the per-message logs of a tx of n messages.
+func txLogs(events []abciEvent, n int) []abciMessageLog {
+	logs := make([]abciMessageLog, n)
+	for i := range logs {
+		logs[i] = abciMessageLog{MsgIndex: i, Events: []abciEvent{}}
+	}
+	for _, ev := range events {
+		for _, attribute := range ev.Attributes {
+			if attribute.Key != "msg_index" {
+				continue
+			}
+			if i, err := strconv.Atoi(attribute.Value); err == nil && i < n {
+				logs[i].Events = append(logs[i].Events, ev)
+			}
+		}
+	}
+	return logs
+}
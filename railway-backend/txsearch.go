@@ -0,0 +1,190 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Tx search: GET /cosmos/tx/v1beta1/txs?events=message.sender='cosmos1...'
+// Conditions are ANDed. Supported keys are message.sender, message.action,
+// tx.hash and tx.height (tx.height also accepts <, <=, > and >=).
+
+type eventCondition struct {
+	Key   string
+	Op    string
+	Value string
+}
+
+var (
+	eventConditionPattern = regexp.MustCompile(`^\s*([\w.]+)\s*(<=|>=|=|<|>)\s*'?([^']*)'?\s*$`)
+	queryAndPattern       = regexp.MustCompile(`(?i)\s+AND\s+`)
+)
+
+// parseEventConditions accepts repeated events= params (Cosmos <= 0.47) and
+// a single query= param with AND-joined conditions (Cosmos >= 0.50).
+func parseEventConditions(r *http.Request) ([]eventCondition, error) {
+	q := r.URL.Query()
+	raw := append([]string{}, q["events"]...)
+	if query := q.Get("query"); query != "" {
+		raw = append(raw, queryAndPattern.Split(query, -1)...)
+	}
+	if len(raw) == 0 {
+		return nil, fmt.Errorf("must declare at least one event to search")
+	}
+
+	conditions := make([]eventCondition, 0, len(raw))
+	for _, expr := range raw {
+		cond, err := parseEventCondition(expr)
+		if err != nil {
+			return nil, err
+		}
+		if cond.Op != "=" && cond.Key != "tx.height" {
+			return nil, fmt.Errorf("operator %s is only supported for tx.height", cond.Op)
+		}
+		conditions = append(conditions, cond)
+	}
+	return conditions, nil
+}
+
+// parseEventCondition parses a single {eventType}.{eventAttribute}{op}{value}
+// expression.
+func parseEventCondition(expr string) (eventCondition, error) {
+	m := eventConditionPattern.FindStringSubmatch(expr)
+	if m == nil {
+		return eventCondition{}, fmt.Errorf("invalid event; event %s should be of the format: {eventType}.{eventAttribute}={value}", expr)
+	}
+	return eventCondition{Key: m[1], Op: m[2], Value: m[3]}, nil
+}
+
+// matchingTxHashes resolves one condition against the indexes. Must be
+// called with c.mu held.
+func (c *Chain) matchingTxHashes(cond eventCondition) (map[string]bool, error) {
+	set := make(map[string]bool)
+	switch cond.Key {
+	case "message.sender":
+		for _, hash := range c.txsBySender[cond.Value] {
+			set[hash] = true
+		}
+	case "message.action":
+		for _, hash := range c.txsByAction[cond.Value] {
+			set[hash] = true
+		}
+	case "tx.hash":
+		hash := normalizeTxHash(cond.Value)
+		if _, ok := c.txsByHash[hash]; ok {
+			set[hash] = true
+		}
+	case "tx.height":
+		want, err := strconv.ParseInt(cond.Value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid tx.height: %s", cond.Value)
+		}
+		for height, hashes := range c.txsByHeight {
+			if compareHeight(height, cond.Op, want) {
+				for _, hash := range hashes {
+					set[hash] = true
+				}
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unsupported event key: %s", cond.Key)
+	}
+	return set, nil
+}
+
+func compareHeight(height int64, op string, want int64) bool {
+	switch op {
+	case "<":
+		return height < want
+	case "<=":
+		return height <= want
+	case ">":
+		return height > want
+	case ">=":
+		return height >= want
+	default:
+		return height == want
+	}
+}
+
+// Handler for GET /cosmos/tx/v1beta1/txs
+func (c *Chain) handleSearchTxs(w http.ResponseWriter, r *http.Request) {
+	conditions, err := parseEventConditions(r)
+	if err != nil {
+		// gRPC InvalidArgument
+		writeGRPCError(w, http.StatusBadRequest, 3, err.Error())
+		return
+	}
+
+	pageReq, err := parsePageRequest(r)
+	if err != nil {
+		writeGRPCError(w, http.StatusBadRequest, 3, err.Error())
+		return
+	}
+	// Legacy page/limit parameters and order_by
+	q := r.URL.Query()
+	if limit, err := strconv.Atoi(q.Get("limit")); err == nil && limit > 0 {
+		pageReq.Limit = limit
+	}
+	if page, err := strconv.Atoi(q.Get("page")); err == nil && page > 0 {
+		pageReq.Offset = (page - 1) * pageReq.Limit
+	}
+	if strings.EqualFold(q.Get("order_by"), "ORDER_BY_DESC") {
+		pageReq.Reverse = true
+	}
+
+	c.mu.RLock()
+	var matched map[string]bool
+	for _, cond := range conditions {
+		set, err := c.matchingTxHashes(cond)
+		if err != nil {
+			c.mu.RUnlock()
+			writeGRPCError(w, http.StatusBadRequest, 3, err.Error())
+			return
+		}
+		if matched == nil {
+			matched = set
+			continue
+		}
+		for hash := range matched {
+			if !set[hash] {
+				delete(matched, hash)
+			}
+		}
+	}
+
+	items := make([]map[string]interface{}, 0, len(matched))
+	for hash := range matched {
+		tx := c.txsByHash[hash]
+		items = append(items, map[string]interface{}{
+			"seq":         fmt.Sprintf("%020d", tx.Seq),
+			"tx":          tx.txJSON(),
+			"tx_response": tx.txResponseJSON(),
+		})
+	}
+	c.mu.RUnlock()
+
+	page, pagination := paginate(items, func(_ int, item map[string]interface{}) string {
+		return item["seq"].(string)
+	}, pageReq)
+
+	txs := make([]interface{}, 0, len(page))
+	txResponses := make([]interface{}, 0, len(page))
+	for _, item := range page {
+		txs = append(txs, item["tx"])
+		txResponses = append(txResponses, item["tx_response"])
+	}
+
+	response := map[string]interface{}{
+		"txs":          txs,
+		"tx_responses": txResponses,
+		"pagination":   pagination,
+		"total":        fmt.Sprintf("%d", len(items)),
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// Every broadcast tx is kept so it can be queried back by hash, the way the
+// frontend polls a real node after broadcasting.
+
+type storedTx struct {
+	Seq       int
+	Response  MockTxResponse
+	Messages  []interface{}
+	Senders   []string
+	Actions   []string
+	Timestamp time.Time
+	// Residency is the region of the broadcast, see residency.go
+	Residency string `json:",omitempty"`
+	// TraceID is the trace of the broadcast request, see trace.go
+	TraceID string `json:",omitempty"`
+}
+
+// computeTxHash hashes a broadcast the way real nodes do: uppercase hex
+// SHA-256 over the decoded tx_bytes. Amino-JSON broadcasts without tx_bytes
+// are hashed over the request body instead.
+func computeTxHash(body []byte) string {
+	sum := sha256.Sum256(txBytesOf(body))
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+// txBytesOf returns the decoded tx_bytes of a broadcast, or the body itself
+// for Amino-JSON broadcasts.
+func txBytesOf(body []byte) []byte {
+	var req struct {
+		TxBytes string `json:"tx_bytes"`
+	}
+	if json.Unmarshal(body, &req) == nil && req.TxBytes != "" {
+		if txBytes, err := base64.StdEncoding.DecodeString(req.TxBytes); err == nil {
+			return txBytes
+		}
+	}
+	return body
+}
+
+// normalizeTxHash makes hash lookups insensitive to case and a 0x prefix.
+func normalizeTxHash(hash string) string {
+	return strings.ToUpper(strings.TrimPrefix(strings.TrimPrefix(hash, "0x"), "0X"))
+}
+
+// recordTx stores a broadcast tx and indexes it. Must be called with c.mu held.
+func (c *Chain) recordTx(response MockTxResponse, msgs []interface{}) *storedTx {
+	if msgs == nil {
+		msgs = []interface{}{}
+	}
+	tx := &storedTx{
+		Response:  response,
+		Messages:  msgs,
+		Timestamp: c.now().UTC(),
+	}
+	for _, m := range msgs {
+		msg, ok := m.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if action, ok := msg["@type"].(string); ok {
+			tx.Actions = appendUnique(tx.Actions, action)
+		}
+		if sender := msgSigner(msg); sender != "" {
+			tx.Senders = appendUnique(tx.Senders, sender)
+		}
+	}
+
+	hash := normalizeTxHash(response.TxHash)
+	c.txSeq++
+	tx.Seq = c.txSeq
+	c.txsByHash[hash] = tx
+	for _, sender := range tx.Senders {
+		c.txsBySender[sender] = append(c.txsBySender[sender], hash)
+	}
+	for _, action := range tx.Actions {
+		c.txsByAction[action] = append(c.txsByAction[action], hash)
+	}
+	c.txsByHeight[response.Height] = append(c.txsByHeight[response.Height], hash)
+	return tx
+}
+
+// msgSigner returns the address that signed a message, trying the field
+// names used across persona and Cosmos messages.
+func msgSigner(msg map[string]interface{}) string {
+	for _, field := range []string{"creator", "sender", "from_address", "prover", "revoker", "controller", "signer"} {
+		if value, ok := msg[field].(string); ok && value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+func appendUnique(values []string, value string) []string {
+	for _, v := range values {
+		if v == value {
+			return values
+		}
+	}
+	return append(values, value)
+}
+
+func (c *Chain) lookupTx(hash string) *storedTx {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.txsByHash[normalizeTxHash(hash)]
+}
+
+// txJSON renders the stored tx as a cosmos.tx.v1beta1.Tx.
+func (tx *storedTx) txJSON() map[string]interface{} {
+	return map[string]interface{}{
+		"@type": "/cosmos.tx.v1beta1.Tx",
+		"body": map[string]interface{}{
+			"messages":       tx.Messages,
+			"memo":           "",
+			"timeout_height": "0",
+		},
+		"auth_info":  map[string]interface{}{},
+		"signatures": []string{},
+	}
+}
+
+// txResponseJSON renders the stored tx as a Cosmos TxResponse.
+func (tx *storedTx) txResponseJSON() map[string]interface{} {
+	return map[string]interface{}{
+		"height":     fmt.Sprintf("%d", tx.Response.Height),
+		"txhash":     tx.Response.TxHash,
+		"codespace":  tx.Response.Codespace,
+		"code":       tx.Response.Code,
+		"data":       tx.Response.Data,
+		"raw_log":    tx.Response.RawLog,
+		"logs":       tx.logs(),
+		"info":       "",
+		"gas_wanted": "200000",
+		"gas_used":   "0",
+		"tx":         tx.txJSON(),
+		"timestamp":  tx.Timestamp.Format(time.RFC3339),
+		"events":     tx.events(),
+	}
+}
+
+// events returns the tx's events, never nil.
+func (tx *storedTx) events() []abciEvent {
+	if tx.Response.Events == nil {
+		return []abciEvent{}
+	}
+	return tx.Response.Events
+}
+
+// logs returns the tx's logs, never nil.
+func (tx *storedTx) logs() []abciMessageLog {
+	if tx.Response.Logs == nil {
+		return []abciMessageLog{}
+	}
+	return tx.Response.Logs
+}
+
+// writeGRPCError writes an error in the grpc-gateway shape real LCDs use.
+func writeGRPCError(w http.ResponseWriter, status int, grpcCode int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"code":    grpcCode,
+		"message": message,
+		"details": []interface{}{},
+	})
+}
+
+// Handler for GET /cosmos/tx/v1beta1/txs/{hash}
+func (c *Chain) handleGetTx(w http.ResponseWriter, r *http.Request) {
+	hash := mux.Vars(r)["hash"]
+
+	tx := c.lookupTx(hash)
+	if tx == nil {
+		// gRPC NotFound
+		writeGRPCError(w, http.StatusNotFound, 5, fmt.Sprintf("tx not found: %s", normalizeTxHash(hash)))
+		return
+	}
+
+	txResponse := tx.txResponseJSON()
+	if locale := setErrorLocale(w, r); tx.Response.Code != codeOK {
+		txResponse["localized_log"] = localizedErrorMessage(locale, tx.Response.Codespace, tx.Response.Code)
+	}
+	response := map[string]interface{}{
+		"tx":          tx.txJSON(),
+		"tx_response": txResponse,
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
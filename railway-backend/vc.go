@@ -0,0 +1,482 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"persona-backend/jsonschema"
+)
+
+func init() {
+	RegisterModule(newVCModule)
+}
+
+// vcStore holds issued credentials.
+type vcStore struct {
+	// Credentials keyed by the controller that issued them
+	ByController map[string][]map[string]interface{} `json:"by_controller"`
+	// Credential schemas keyed by credential type, see schemas.go
+	Schemas map[string]*credentialSchema `json:"schemas"`
+}
+
+func (s *vcStore) Reset() {
+	s.ByController = make(map[string][]map[string]interface{})
+	s.Schemas = make(map[string]*credentialSchema)
+}
+
+// vcModule implements the persona vc module.
+type vcModule struct {
+	chain *Chain
+	store *vcStore
+}
+
+func newVCModule(c *Chain) Module {
+	store := &vcStore{}
+	store.Reset()
+	return &vcModule{chain: c, store: store}
+}
+
+func (m *vcModule) Name() string { return "vc" }
+
+func (m *vcModule) Store() ModuleStore { return m.store }
+
+func (m *vcModule) EventTypes() []string {
+	return []string{"vc.issued", "vc.revoked"}
+}
+
+func (m *vcModule) RegisterMsgs(reg *MsgRegistry) {
+	registerMsg(reg, "/persona.vc.v1.MsgIssueCredential", m.handleMsgIssueCredential)
+	registerMsg(reg, "/persona.vc.v1.MsgRevokeCredential", m.handleMsgRevokeCredential)
+}
+
+func (m *vcModule) RegisterRoutes(r *mux.Router) {
+	r.HandleFunc("/persona/vc/v1beta1/credentials", m.handleListVCs).Methods("GET", "OPTIONS")
+	r.HandleFunc("/persona/vc/v1beta1/credentials_by_controller/{controller}", m.handleGetCredentialsByController).Methods("GET", "OPTIONS")
+	r.HandleFunc("/persona/vc/v1beta1/credentials/{id:.+}/status", m.handleGetCredentialStatus).Methods("GET", "OPTIONS")
+	r.HandleFunc("/persona/vc/v1beta1/revocation_reasons", handleListRevocationReasons).Methods("GET", "OPTIONS")
+	r.HandleFunc("/persona/vc/v1beta1/verify/batch", m.handleVerifyCredentialBatch).Methods("POST", "OPTIONS")
+	r.HandleFunc("/persona/vc/v1beta1/schemas", m.handleListSchemas).Methods("GET", "OPTIONS")
+	r.HandleFunc("/persona/vc/v1beta1/schemas", m.handleRegisterSchema).Methods("POST")
+	r.HandleFunc("/persona/vc/v1beta1/schemas/{type}", m.handleGetSchema).Methods("GET", "OPTIONS")
+	r.HandleFunc("/persona/vc/v1beta1/schemas/{type}", m.handleDeleteSchema).Methods("DELETE")
+	r.HandleFunc("/persona/vc/v1beta1/sd-jwt/issue", m.handleIssueSDJWT).Methods("POST", "OPTIONS")
+	r.HandleFunc("/persona/vc/v1beta1/sd-jwt/verify", m.handleVerifySDJWT).Methods("POST", "OPTIONS")
+	r.HandleFunc("/persona/vc/v1beta1/sd-jwt/jwks", handleSDJWTKeys).Methods("GET", "OPTIONS")
+	r.HandleFunc("/persona/vc/v1beta1/derive", m.handleDeriveCredential).Methods("POST", "OPTIONS")
+	r.HandleFunc("/persona/vc/v1beta1/derive/verify", m.handleVerifyDerivedCredential).Methods("POST", "OPTIONS")
+	r.HandleFunc("/persona/vc/v1beta1/bbs/keys/{did:.+}", handleBBSKey).Methods("GET", "OPTIONS")
+}
+
+func (m *vcModule) ExportGenesis() interface{} {
+	vcRecords := []map[string]interface{}{}
+	for _, controller := range sortedMapKeys(m.store.ByController) {
+		for _, credential := range m.store.ByController[controller] {
+			vcRecords = append(vcRecords, vcRecord(controller, credential))
+		}
+	}
+	return map[string]interface{}{
+		"params":     map[string]interface{}{},
+		"vc_records": vcRecords,
+	}
+}
+
+// vcRecord is a stored credential as the chain's VcRecord.
+func vcRecord(controller string, credential map[string]interface{}) map[string]interface{} {
+	vcData, _ := json.Marshal(credential)
+	record := map[string]interface{}{
+		"id":         credential["id"],
+		"controller": controller,
+		"vc_data":    string(vcData),
+		"issued_at":  credential["created_at"],
+		"is_revoked": credential["is_revoked"],
+	}
+	if issuer, ok := credential["issuer"].(string); ok {
+		record["issuer_did"] = issuer
+	}
+	if subject, ok := credential["credentialSubject"].(map[string]interface{}); ok {
+		record["subject_did"] = subject["id"]
+	}
+	return record
+}
+
+func (m *vcModule) SeedKeys() []string { return []string{"schemas", "credentials"} }
+
+// Seed stores schemas, which need a type and a schema, or credentials
+// under their "controller" address, which is removed from the stored
+// credential. Schemas are seeded first but seeded credentials are not
+// validated against them.
+func (m *vcModule) Seed(key string, records []map[string]interface{}) error {
+	if key == "schemas" {
+		for i, record := range records {
+			credentialType, _ := record["type"].(string)
+			if credentialType == "" || record["schema"] == nil {
+				return fmt.Errorf("record %d: type and schema are required", i)
+			}
+			if _, err := jsonschema.Compile(record["schema"]); err != nil {
+				return fmt.Errorf("record %d: invalid schema: %v", i, err)
+			}
+			m.store.Schemas[credentialType] = &credentialSchema{
+				Type:      credentialType,
+				Schema:    record["schema"],
+				CreatedAt: m.chain.now().Unix(),
+			}
+		}
+		return nil
+	}
+	for i, record := range records {
+		controller, _ := record["controller"].(string)
+		if controller == "" {
+			return fmt.Errorf("record %d: controller is required", i)
+		}
+		credential := map[string]interface{}{"created_at": m.chain.now().Unix(), "is_revoked": false}
+		for key, value := range record {
+			if key != "controller" {
+				credential[key] = value
+			}
+		}
+		m.store.ByController[controller] = append(m.store.ByController[controller], credential)
+	}
+	return nil
+}
+
+// findCredential looks up a stored credential by ID and returns it with the
+// controller it is stored under. Must be called with the chain lock held.
+func (m *vcModule) findCredential(id string) (string, map[string]interface{}) {
+	for controller, credentials := range m.store.ByController {
+		for _, credential := range credentials {
+			if credId, ok := credential["id"].(string); ok && credId == id {
+				return controller, credential
+			}
+		}
+	}
+	return "", nil
+}
+
+// credentialExpiry returns a credential's expirationDate, if it has a
+// valid one.
+func credentialExpiry(credential map[string]interface{}) (time.Time, bool) {
+	s, _ := credential["expirationDate"].(string)
+	expires, err := time.Parse(time.RFC3339, s)
+	return expires, err == nil
+}
+
+// credentialExpired reports whether a credential's expirationDate has
+// passed.
+func credentialExpired(credential map[string]interface{}, now time.Time) bool {
+	expires, ok := credentialExpiry(credential)
+	return ok && !now.Before(expires)
+}
+
+// Handler for GET /persona/vc/v1beta1/credentials. Takes the search
+// parameters of credentialfilter.go.
+func (m *vcModule) handleListVCs(w http.ResponseWriter, r *http.Request) {
+	pageReq, err := parsePageRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	filter, err := parseCredentialFilter(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	m.chain.mu.RLock()
+	now := m.chain.now()
+	// Start with the default mock credential
+	mockVCs := []map[string]interface{}{
+		{
+			"id":          "vc_001",
+			"issuer_did":  "did:persona:123",
+			"subject_did": "did:persona:456",
+			"issued_at":   now.Unix(),
+			"is_revoked":  false,
+		},
+	}
+	// Add any issued or seeded credentials from the request's region,
+	// marked expired as of now
+	for _, controller := range sortedMapKeys(m.store.ByController) {
+		for _, credential := range m.store.ByController[controller] {
+			if !residencyVisible(r, credential) {
+				continue
+			}
+			record := make(map[string]interface{}, len(credential)+1)
+			for key, value := range credential {
+				record[key] = value
+			}
+			record["is_expired"] = credentialExpired(credential, now)
+			mockVCs = append(mockVCs, record)
+		}
+	}
+	m.chain.mu.RUnlock()
+
+	records := []map[string]interface{}{}
+	for _, record := range mockVCs {
+		if filter.matches(record, now) {
+			records = append(records, record)
+		}
+	}
+	filter.sortCredentials(records)
+
+	// Sorted records page in their order, the rest by ID
+	keyOf := keyByID
+	if filter.Sort != "" {
+		keyOf = keyByIndex
+	}
+	page, pagination := paginate(records, keyOf, pageReq)
+
+	response := map[string]interface{}{
+		"vc_records": page,
+		"pagination": pagination,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (m *vcModule) handleGetCredentialsByController(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	controller := vars["controller"]
+
+	log.Printf("Looking up credentials for controller: %s", controller)
+
+	pageReq, err := parsePageRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	filter, err := parseCredentialFilter(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	m.chain.mu.RLock()
+	defer m.chain.mu.RUnlock()
+
+	// Get the matching credentials for this controller from the request's
+	// region, marked expired as of now
+	now := m.chain.now()
+	credentials := []map[string]interface{}{}
+	for _, credential := range m.store.ByController[controller] {
+		if !residencyVisible(r, credential) || !filter.matches(credential, now) {
+			continue
+		}
+		record := make(map[string]interface{}, len(credential)+1)
+		for key, value := range credential {
+			record[key] = value
+		}
+		record["is_expired"] = credentialExpired(credential, now)
+		credentials = append(credentials, record)
+	}
+	filter.sortCredentials(credentials)
+
+	page, pagination := paginate(credentials, keyByIndex, pageReq)
+
+	response := map[string]interface{}{
+		"vc_records": page,
+		"pagination": pagination,
+	}
+
+	log.Printf("Returning %d credentials for controller %s", len(credentials), controller)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+type MsgIssueCredential struct {
+	Creator string     `json:"creator"`
+	VcData  jsonObject `json:"vc_data"`
+}
+
+func (m MsgIssueCredential) ValidateBasic() error {
+	if m.Creator == "" {
+		return errors.New("creator is required")
+	}
+	if m.VcData == nil {
+		return errors.New("vc_data is required")
+	}
+	return nil
+}
+
+func (m *vcModule) handleMsgIssueCredential(ctx *msgContext, msg MsgIssueCredential) *txError {
+	credential := map[string]interface{}(msg.VcData)
+	if vcValidation != "off" {
+		if violations := validateVCDataModel(credential, vcValidation == "strict"); len(violations) > 0 {
+			return moduleErrorf("vc", codeVCInvalidCredential, "invalid verifiable credential", "%s", strings.Join(violations, "; "))
+		}
+	}
+	if violations := m.checkSchemas(credential); len(violations) > 0 {
+		return moduleErrorf("vc", codeVCSchemaViolation, "credential schema violation", "%s", strings.Join(violations, "; "))
+	}
+	if bbsProofRequested(credential) {
+		if err := m.signBBSCredential(credential); err != nil {
+			return moduleErrorf("vc", codeVCInvalidCredential, "invalid verifiable credential", "%v", err)
+		}
+	}
+
+	// Add metadata
+	credential["created_at"] = m.chain.now().Unix()
+	credential["is_revoked"] = false
+	if ctx.Residency != "" {
+		credential["residency"] = ctx.Residency
+	}
+
+	// Store credential by controller
+	if m.store.ByController[msg.Creator] == nil {
+		m.store.ByController[msg.Creator] = []map[string]interface{}{}
+	}
+	m.store.ByController[msg.Creator] = append(m.store.ByController[msg.Creator], credential)
+	credentialId, _ := credential["id"].(string)
+	ctx.emit("credential.issued", msg.Creator, credentialId, credential)
+	issuer, _ := credentialIssuer(credential).(string)
+	subject := ""
+	if credentialSubject, ok := credential["credentialSubject"].(map[string]interface{}); ok {
+		subject, _ = credentialSubject["id"].(string)
+	}
+	ctx.event("vc.issued", "credential_id", credentialId, "issuer", issuer, "subject", subject, "creator", msg.Creator)
+	log.Printf("Stored credential for controller: %s", msg.Creator)
+	return nil
+}
+
+type MsgRevokeCredential struct {
+	Creator string `json:"creator"`
+	// Revoker is the frontend's name for the signer
+	Revoker      string `json:"revoker"`
+	CredentialID string `json:"credential_id"`
+	ID           string `json:"id"`
+	Reason       string `json:"reason"`
+	// ReasonCode is a code from revocationreasons.go
+	ReasonCode string `json:"reason_code"`
+	// Explanation is shown to the holder
+	Explanation string `json:"explanation"`
+}
+
+// revoker returns the signer, from creator or revoker.
+func (m MsgRevokeCredential) revoker() string {
+	if m.Creator != "" {
+		return m.Creator
+	}
+	return m.Revoker
+}
+
+func (m MsgRevokeCredential) ValidateBasic() error {
+	if m.CredentialID == "" && m.ID == "" {
+		return errors.New("credential_id is required")
+	}
+	if _, ok := revocationReasonOf(m.ReasonCode); m.ReasonCode != "" && !ok {
+		return fmt.Errorf("unknown reason_code %s", m.ReasonCode)
+	}
+	if len([]rune(m.Explanation)) > maxRevocationExplanation {
+		return fmt.Errorf("explanation must be at most %d characters", maxRevocationExplanation)
+	}
+	return nil
+}
+
+// reasonCode returns the reason code, taken from reason when it is a code.
+func (m MsgRevokeCredential) reasonCode() string {
+	if m.ReasonCode != "" {
+		return m.ReasonCode
+	}
+	if _, ok := revocationReasonOf(m.Reason); ok {
+		return m.Reason
+	}
+	return "unspecified"
+}
+
+// handleMsgRevokeCredential marks a credential revoked. Either the
+// controller the credential is stored under or its issuer may revoke it.
+func (m *vcModule) handleMsgRevokeCredential(ctx *msgContext, msg MsgRevokeCredential) *txError {
+	credentialId := msg.CredentialID
+	if credentialId == "" {
+		credentialId = msg.ID
+	}
+
+	controller, credential := m.findCredential(credentialId)
+	if credential == nil {
+		return txErrorf(codeNotFound, "credential %s", credentialId)
+	}
+
+	revoker := msg.revoker()
+	if revoker == "" || (revoker != controller && revoker != credential["issuer"]) {
+		return txErrorf(codeUnauthorized, "%s cannot revoke credential %s", revoker, credentialId)
+	}
+	if credential["is_revoked"] == true {
+		return txErrorf(codeInvalidRequest, "credential %s is already revoked", credentialId)
+	}
+
+	credential["is_revoked"] = true
+	credential["revocation_reason"] = msg.Reason
+	credential["revocation_reason_code"] = msg.reasonCode()
+	if msg.Explanation != "" {
+		credential["revocation_explanation"] = msg.Explanation
+	}
+	credential["revoked_at"] = m.chain.now().Unix()
+	ctx.emit("credential.revoked", revoker, credentialId, credential)
+	verifyCache.Invalidate(credentialTag(credentialId))
+
+	log.Printf("Revoked credential %s by %s (reason: %s %s)", credentialId, revoker, msg.reasonCode(), msg.Reason)
+	return nil
+}
+
+// Handler for GET /persona/vc/v1beta1/credentials/{id}/status
+func (m *vcModule) handleGetCredentialStatus(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	m.chain.mu.RLock()
+	var response map[string]interface{}
+	if _, credential := m.findCredential(id); credential != nil {
+		if residencyDenied(w, r, credential) {
+			m.chain.mu.RUnlock()
+			return
+		}
+		now := m.chain.now()
+		expired := credentialExpired(credential, now)
+		status := "active"
+		if credential["is_revoked"] == true {
+			status = "revoked"
+		} else if expired {
+			status = "expired"
+		}
+		response = map[string]interface{}{
+			"credential_id":     id,
+			"status":            status,
+			"is_revoked":        credential["is_revoked"] == true,
+			"is_expired":        expired,
+			"expiration_date":   credential["expirationDate"],
+			"revocation_reason": credential["revocation_reason"],
+			"revoked_at":        credential["revoked_at"],
+			"checked_at":        now.Unix(),
+		}
+		if status == "revoked" {
+			for key, value := range revocationDetails(credential) {
+				response[key] = value
+			}
+		}
+	}
+	m.chain.mu.RUnlock()
+
+	if response == nil {
+		if upstream, ok := fetchUpstream(r.URL.Path); ok {
+			writeUpstream(w, upstream)
+			return
+		}
+		response := map[string]interface{}{
+			"error":         "Credential not found",
+			"credential_id": id,
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(response)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// W3C VC Data Model 1.1 validation of issued credentials. MsgIssueCredential
+// checks vc_data for the structure the data model requires: @context
+// starting with the credentials v1 context, a type list including
+// VerifiableCredential, an issuer URI (or object with one), an
+// issuanceDate (and expirationDate, when present) in XML Schema dateTime
+// form, and a credentialSubject object or list. A malformed credential is
+// rejected with code 13 in the vc codespace and one raw_log entry per
+// field. Like STRICT_SIGNING and VERIFY_PROOFS this is opt-in, since the
+// frontend issues credentials with a bech32 address as issuer:
+// VC_VALIDATION=standard checks a proof only when present, strict requires
+// one, and off (default) skips the validation.
+
+const vcContextV1 = "https://www.w3.org/2018/credentials/v1"
+
+var vcValidation = vcValidationFromEnv()
+
+func vcValidationFromEnv() string {
+	switch mode := os.Getenv("VC_VALIDATION"); mode {
+	case "":
+		return "off"
+	case "standard", "strict", "off":
+		return mode
+	default:
+		log.Printf("Unknown VC_VALIDATION=%q, using off", mode)
+		return "off"
+	}
+}
+
+// validateVCDataModel returns the data model violations of a credential as
+// "field: problem" entries.
+func validateVCDataModel(credential map[string]interface{}, requireProof bool) []string {
+	var violations []string
+	fail := func(field, format string, args ...interface{}) {
+		violations = append(violations, field+": "+fmt.Sprintf(format, args...))
+	}
+
+	switch ctx := credential["@context"].(type) {
+	case nil:
+		fail("@context", "is required")
+	case string:
+		if ctx != vcContextV1 {
+			fail("@context", "must start with %s", vcContextV1)
+		}
+	case []interface{}:
+		if len(ctx) == 0 || ctx[0] != vcContextV1 {
+			fail("@context", "must start with %s", vcContextV1)
+		}
+		for i, entry := range ctx {
+			switch entry.(type) {
+			case string, map[string]interface{}:
+			default:
+				fail(fmt.Sprintf("@context[%d]", i), "must be a URI or an object")
+			}
+		}
+	default:
+		fail("@context", "must be a URI or a list")
+	}
+
+	if id, ok := credential["id"]; ok {
+		if s, isString := id.(string); !isString || !isURI(s) {
+			fail("id", "must be a URI")
+		}
+	}
+
+	switch types := credential["type"].(type) {
+	case nil:
+		fail("type", "is required")
+	case string:
+		if types != "VerifiableCredential" {
+			fail("type", "must include VerifiableCredential")
+		}
+	case []interface{}:
+		found := false
+		for i, t := range types {
+			if _, ok := t.(string); !ok {
+				fail(fmt.Sprintf("type[%d]", i), "must be a string")
+			}
+			found = found || t == "VerifiableCredential"
+		}
+		if !found {
+			fail("type", "must include VerifiableCredential")
+		}
+	default:
+		fail("type", "must be a string or a list")
+	}
+
+	switch issuer := credential["issuer"].(type) {
+	case nil:
+		fail("issuer", "is required")
+	case string:
+		if !isURI(issuer) {
+			fail("issuer", "must be a URI, got %q", issuer)
+		}
+	case map[string]interface{}:
+		if id, _ := issuer["id"].(string); !isURI(id) {
+			fail("issuer.id", "must be a URI")
+		}
+	default:
+		fail("issuer", "must be a URI or an object with an id")
+	}
+
+	if issued, ok := credential["issuanceDate"]; !ok {
+		fail("issuanceDate", "is required")
+	} else if !isDateTime(issued) {
+		fail("issuanceDate", "must be an XML Schema dateTime such as 2024-01-01T00:00:00Z")
+	}
+	if expires, ok := credential["expirationDate"]; ok && !isDateTime(expires) {
+		fail("expirationDate", "must be an XML Schema dateTime such as 2025-01-01T00:00:00Z")
+	}
+
+	switch subject := credential["credentialSubject"].(type) {
+	case nil:
+		fail("credentialSubject", "is required")
+	case map[string]interface{}:
+		validateSubject("credentialSubject", subject, fail)
+	case []interface{}:
+		if len(subject) == 0 {
+			fail("credentialSubject", "must not be empty")
+		}
+		for i, entry := range subject {
+			field := fmt.Sprintf("credentialSubject[%d]", i)
+			if obj, ok := entry.(map[string]interface{}); ok {
+				validateSubject(field, obj, fail)
+			} else {
+				fail(field, "must be an object")
+			}
+		}
+	default:
+		fail("credentialSubject", "must be an object or a list of objects")
+	}
+
+	switch proof := credential["proof"].(type) {
+	case nil:
+		if requireProof {
+			fail("proof", "is required")
+		}
+	case map[string]interface{}:
+		validateProof("proof", proof, fail)
+	case []interface{}:
+		for i, entry := range proof {
+			field := fmt.Sprintf("proof[%d]", i)
+			if obj, ok := entry.(map[string]interface{}); ok {
+				validateProof(field, obj, fail)
+			} else {
+				fail(field, "must be an object")
+			}
+		}
+	default:
+		fail("proof", "must be an object or a list of objects")
+	}
+
+	if status, ok := credential["credentialStatus"].(map[string]interface{}); ok {
+		if id, _ := status["id"].(string); !isURI(id) {
+			fail("credentialStatus.id", "must be a URI")
+		}
+		if t, _ := status["type"].(string); t == "" {
+			fail("credentialStatus.type", "is required")
+		}
+	}
+	return violations
+}
+
+func validateSubject(field string, subject map[string]interface{}, fail func(field, format string, args ...interface{})) {
+	if len(subject) == 0 {
+		fail(field, "must make at least one claim")
+	}
+	if id, ok := subject["id"]; ok {
+		if s, isString := id.(string); !isString || !isURI(s) {
+			fail(field+".id", "must be a URI")
+		}
+	}
+}
+
+func validateProof(field string, proof map[string]interface{}, fail func(field, format string, args ...interface{})) {
+	if t, _ := proof["type"].(string); t == "" {
+		fail(field+".type", "is required")
+	}
+	if created, ok := proof["created"]; ok && !isDateTime(created) {
+		fail(field+".created", "must be an XML Schema dateTime")
+	}
+}
+
+// isURI accepts absolute URIs, including DIDs and URNs.
+func isURI(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && u.Scheme != "" && !strings.ContainsAny(s, " \t\n")
+}
+
+func isDateTime(value interface{}) bool {
+	s, ok := value.(string)
+	if !ok {
+		return false
+	}
+	_, err := time.Parse(time.RFC3339, s)
+	return err == nil
+}
+
+// w3cCredential builds a credential the data model validation accepts,
+// with a mock proof, for the server's own issuance (scenarios, selftests).
+func w3cCredential(id, issuer string, types []interface{}, subject map[string]interface{}, issuedAt time.Time) map[string]interface{} {
+	issued := issuedAt.UTC().Format(time.RFC3339)
+	return map[string]interface{}{
+		"@context":          []interface{}{vcContextV1},
+		"id":                id,
+		"type":              append([]interface{}{"VerifiableCredential"}, types...),
+		"issuer":            issuer,
+		"issuanceDate":      issued,
+		"credentialSubject": subject,
+		"proof": map[string]interface{}{
+			"type":               "Ed25519Signature2020",
+			"created":            issued,
+			"verificationMethod": issuer + "#key-1",
+			"proofPurpose":       "assertionMethod",
+			"proofValue":         "zMockSignature",
+		},
+	}
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// Verification result cache. Proof verification results are cached by a
+// content hash of what was verified and the verification mode (mock or
+// groth16), so the RP dashboard re-checking the same presentation skips
+// the pairing check. VERIFY_CACHE picks the implementation: memory
+// (default) or off. Entries live for VERIFY_CACHE_TTL (default 5m, chain
+// clock) and are tagged with what they depend on, so revoking a credential
+// or replacing or removing a circuit's verifying key drops every result
+// that involved it. /admin/reset flushes the cache.
+
+type verificationResult struct {
+	Verified  bool   `json:"verified"`
+	Mode      string `json:"mode"`
+	Error     string `json:"error,omitempty"`
+	CheckedAt int64  `json:"checked_at"`
+}
+
+// verificationCache stores verification results under a content hash,
+// tagged with the entities they depend on.
+type verificationCache interface {
+	Get(key string) (verificationResult, bool)
+	Put(key string, result verificationResult, tags []string)
+	// Invalidate drops every entry carrying tag and returns how many
+	Invalidate(tag string) int
+	Flush()
+	Stats() map[string]interface{}
+}
+
+var verifyCache = newVerificationCacheFromEnv()
+
+func newVerificationCacheFromEnv() verificationCache {
+	switch kind := os.Getenv("VERIFY_CACHE"); kind {
+	case "off":
+		return noVerificationCache{}
+	case "", "memory":
+	default:
+		log.Printf("Unknown VERIFY_CACHE=%q, using memory", kind)
+	}
+	return &memoryVerificationCache{
+		ttl:     durationFromEnv("VERIFY_CACHE_TTL", 5*time.Minute),
+		entries: make(map[string]*cachedVerification),
+		byTag:   make(map[string]map[string]bool),
+	}
+}
+
+func init() {
+	registerAdminState("verification_cache", func() interface{} {
+		return verifyCache.Stats()
+	}, func() {
+		verifyCache.Flush()
+	})
+}
+
+// verificationKey hashes what a result depends on into a cache key.
+func verificationKey(parts ...interface{}) string {
+	data, _ := json.Marshal(parts)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func circuitTag(circuit string) string { return "circuit:" + circuit }
+
+func credentialTag(id string) string { return "credential:" + id }
+
+type cachedVerification struct {
+	result  verificationResult
+	expires time.Time
+	tags    []string
+}
+
+type memoryVerificationCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]*cachedVerification
+	// Keys of the entries carrying each tag
+	byTag map[string]map[string]bool
+
+	hits, misses, invalidated int
+}
+
+func (c *memoryVerificationCache) Get(key string) (verificationResult, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if ok && !appClock.Now().Before(entry.expires) {
+		c.removeLocked(key)
+		ok = false
+	}
+	if !ok {
+		c.misses++
+		return verificationResult{}, false
+	}
+	c.hits++
+	return entry.result, true
+}
+
+func (c *memoryVerificationCache) Put(key string, result verificationResult, tags []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.removeLocked(key)
+	c.entries[key] = &cachedVerification{result: result, expires: appClock.Now().Add(c.ttl), tags: tags}
+	for _, tag := range tags {
+		if c.byTag[tag] == nil {
+			c.byTag[tag] = make(map[string]bool)
+		}
+		c.byTag[tag][key] = true
+	}
+}
+
+func (c *memoryVerificationCache) Invalidate(tag string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	keys := sortedMapKeys(c.byTag[tag])
+	for _, key := range keys {
+		c.removeLocked(key)
+	}
+	c.invalidated += len(keys)
+	return len(keys)
+}
+
+// removeLocked drops an entry and its tag index. Must be called with c.mu
+// held.
+func (c *memoryVerificationCache) removeLocked(key string) {
+	entry, ok := c.entries[key]
+	if !ok {
+		return
+	}
+	delete(c.entries, key)
+	for _, tag := range entry.tags {
+		delete(c.byTag[tag], key)
+		if len(c.byTag[tag]) == 0 {
+			delete(c.byTag, tag)
+		}
+	}
+}
+
+func (c *memoryVerificationCache) Flush() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[string]*cachedVerification)
+	c.byTag = make(map[string]map[string]bool)
+	c.hits, c.misses, c.invalidated = 0, 0, 0
+}
+
+func (c *memoryVerificationCache) Stats() map[string]interface{} {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return map[string]interface{}{
+		"kind":        "memory",
+		"ttl":         c.ttl.String(),
+		"entries":     len(c.entries),
+		"hits":        c.hits,
+		"misses":      c.misses,
+		"invalidated": c.invalidated,
+	}
+}
+
+// noVerificationCache caches nothing (VERIFY_CACHE=off).
+type noVerificationCache struct{}
+
+func (noVerificationCache) Get(string) (verificationResult, bool)    { return verificationResult{}, false }
+func (noVerificationCache) Put(string, verificationResult, []string) {}
+func (noVerificationCache) Invalidate(string) int                    { return 0 }
+func (noVerificationCache) Flush()                                   {}
+func (noVerificationCache) Stats() map[string]interface{} {
+	return map[string]interface{}{"kind": "off"}
+}
@@ -0,0 +1,264 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Resource watchdog for multi-hour soak tests. Every WATCHDOG_INTERVAL
+// (default 30s) it samples the goroutine count, the live heap and the open
+// HTTP connections, and flags a leak when a metric keeps growing across the
+// last WATCHDOG_WINDOW samples (default 10): every sample in the newer half
+// of the window is above every sample in the older half, and the growth
+// passes the metric's threshold. GC noise rarely passes both. Anomalies are
+// logged and reported at /api/watchdog and, with the current sample, as
+// Prometheus gauges at /metrics.
+
+const (
+	maxWatchdogSamples   = 720
+	maxWatchdogAnomalies = 100
+)
+
+type watchdogSample struct {
+	Time        time.Time `json:"time"`
+	Goroutines  int       `json:"goroutines"`
+	HeapAlloc   uint64    `json:"heap_alloc_bytes"`
+	HeapObjects uint64    `json:"heap_objects"`
+	OpenConns   int64     `json:"open_connections"`
+}
+
+type watchdogAnomaly struct {
+	Metric     string    `json:"metric"`
+	From       float64   `json:"from"`
+	To         float64   `json:"to"`
+	Message    string    `json:"message"`
+	DetectedAt time.Time `json:"detected_at"`
+}
+
+// watchdogMetric is a sampled value and how much it may grow over a window
+// before it counts as a leak. A zero limit disables that check.
+type watchdogMetric struct {
+	Name  string
+	Value func(s watchdogSample) float64
+	// MinGrowth is absolute, MinGrowthPct relative to the window's start
+	MinGrowth    float64
+	MinGrowthPct float64
+}
+
+type watchdog struct {
+	mu        sync.Mutex
+	interval  time.Duration
+	window    int
+	metrics   []watchdogMetric
+	started   time.Time
+	samples   []watchdogSample
+	anomalies []watchdogAnomaly
+	// anomalyCount counts every anomaly, including trimmed ones
+	anomalyCount int
+	// growing holds the metrics with an ongoing anomaly, so a leak is
+	// reported once rather than on every sample
+	growing map[string]bool
+}
+
+// openConns counts the HTTP connections the server holds, see trackConnState.
+var openConns int64
+
+var resourceWatchdog = newWatchdogFromEnv()
+
+func newWatchdogFromEnv() *watchdog {
+	return &watchdog{
+		interval: durationFromEnv("WATCHDOG_INTERVAL", 30*time.Second),
+		window:   intFromEnv("WATCHDOG_WINDOW", 10),
+		metrics: []watchdogMetric{
+			{Name: "goroutines", Value: func(s watchdogSample) float64 { return float64(s.Goroutines) },
+				MinGrowth: float64(intFromEnv("WATCHDOG_GOROUTINE_GROWTH", 50))},
+			{Name: "heap_alloc_bytes", Value: func(s watchdogSample) float64 { return float64(s.HeapAlloc) },
+				MinGrowthPct: float64(intFromEnv("WATCHDOG_HEAP_GROWTH_PCT", 50))},
+			{Name: "open_connections", Value: func(s watchdogSample) float64 { return float64(s.OpenConns) },
+				MinGrowth: float64(intFromEnv("WATCHDOG_CONN_GROWTH", 50))},
+		},
+		growing: make(map[string]bool),
+	}
+}
+
+// trackConnState is the server's ConnState hook. Hijacked connections
+// (websockets) leave the count, as the server no longer tracks them.
+func trackConnState(_ net.Conn, state http.ConnState) {
+	switch state {
+	case http.StateNew:
+		atomic.AddInt64(&openConns, 1)
+	case http.StateClosed, http.StateHijacked:
+		atomic.AddInt64(&openConns, -1)
+	}
+}
+
+func takeWatchdogSample() watchdogSample {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	return watchdogSample{
+		Time:        time.Now().UTC(),
+		Goroutines:  runtime.NumGoroutine(),
+		HeapAlloc:   mem.HeapAlloc,
+		HeapObjects: mem.HeapObjects,
+		OpenConns:   atomic.LoadInt64(&openConns),
+	}
+}
+
+// start samples every interval until the process exits.
+func (wd *watchdog) start() {
+	wd.mu.Lock()
+	wd.started = time.Now()
+	wd.mu.Unlock()
+	wd.record(takeWatchdogSample())
+
+	log.Printf("Watchdog started: sampling every %s, window of %d samples", wd.interval, wd.window)
+	go func() {
+		ticker := time.NewTicker(wd.interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			wd.record(takeWatchdogSample())
+		}
+	}()
+}
+
+// record stores a sample and checks every metric for sustained growth.
+func (wd *watchdog) record(sample watchdogSample) {
+	wd.mu.Lock()
+	defer wd.mu.Unlock()
+
+	wd.samples = append(wd.samples, sample)
+	if len(wd.samples) > maxWatchdogSamples {
+		wd.samples = wd.samples[len(wd.samples)-maxWatchdogSamples:]
+	}
+	if wd.window < 2 || len(wd.samples) < wd.window {
+		return
+	}
+	window := wd.samples[len(wd.samples)-wd.window:]
+	for _, metric := range wd.metrics {
+		from, to, growing := metric.growth(window)
+		if !growing {
+			delete(wd.growing, metric.Name)
+			continue
+		}
+		if wd.growing[metric.Name] {
+			continue
+		}
+		wd.growing[metric.Name] = true
+		anomaly := watchdogAnomaly{
+			Metric:     metric.Name,
+			From:       from,
+			To:         to,
+			Message:    fmt.Sprintf("%s grew from %.0f to %.0f over %d samples", metric.Name, from, to, len(window)),
+			DetectedAt: sample.Time,
+		}
+		wd.anomalies = append(wd.anomalies, anomaly)
+		wd.anomalyCount++
+		if len(wd.anomalies) > maxWatchdogAnomalies {
+			wd.anomalies = wd.anomalies[len(wd.anomalies)-maxWatchdogAnomalies:]
+		}
+		log.Printf("Watchdog: possible leak, %s", anomaly.Message)
+	}
+}
+
+// growth reports whether the metric grew steadily across the window: the
+// newer half stays above the older half and the growth passes the limits.
+func (m watchdogMetric) growth(window []watchdogSample) (from, to float64, growing bool) {
+	half := len(window) / 2
+	olderMax, newerMin := m.Value(window[0]), m.Value(window[half])
+	for _, s := range window[:half] {
+		if v := m.Value(s); v > olderMax {
+			olderMax = v
+		}
+	}
+	for _, s := range window[half:] {
+		if v := m.Value(s); v < newerMin {
+			newerMin = v
+		}
+	}
+	from, to = m.Value(window[0]), m.Value(window[len(window)-1])
+	if newerMin <= olderMax {
+		return from, to, false
+	}
+	if m.MinGrowth > 0 && to-from < m.MinGrowth {
+		return from, to, false
+	}
+	if m.MinGrowthPct > 0 && (from <= 0 || (to-from)*100/from < m.MinGrowthPct) {
+		return from, to, false
+	}
+	return from, to, true
+}
+
+// Handler for GET /api/watchdog
+func handleWatchdog(w http.ResponseWriter, r *http.Request) {
+	current := takeWatchdogSample()
+
+	resourceWatchdog.mu.Lock()
+	samples := append([]watchdogSample{}, resourceWatchdog.samples...)
+	anomalies := append([]watchdogAnomaly{}, resourceWatchdog.anomalies...)
+	growing := sortedMapKeys(resourceWatchdog.growing)
+	started := resourceWatchdog.started
+	resourceWatchdog.mu.Unlock()
+
+	status := "ok"
+	if len(growing) > 0 {
+		status = "leak_suspected"
+	}
+	response := map[string]interface{}{
+		"status":         status,
+		"uptime_seconds": int64(time.Since(started).Seconds()),
+		"interval":       resourceWatchdog.interval.String(),
+		"window":         resourceWatchdog.window,
+		"current":        current,
+		"growing":        growing,
+		"anomalies":      anomalies,
+		"samples":        samples,
+	}
+	if len(samples) > 0 {
+		response["baseline"] = samples[0]
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// Handler for GET /metrics, in the Prometheus text format
+func handleMetrics(w http.ResponseWriter, r *http.Request) {
+	current := takeWatchdogSample()
+
+	resourceWatchdog.mu.Lock()
+	anomalies := resourceWatchdog.anomalyCount
+	growing := map[string]bool{}
+	for name := range resourceWatchdog.growing {
+		growing[name] = true
+	}
+	started := resourceWatchdog.started
+	metrics := resourceWatchdog.metrics
+	resourceWatchdog.mu.Unlock()
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	gauge := func(name, help string, value float64) {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n%s %g\n", name, help, name, name, value)
+	}
+	gauge("persona_goroutines", "Number of goroutines.", float64(current.Goroutines))
+	gauge("persona_heap_alloc_bytes", "Bytes of allocated heap objects.", float64(current.HeapAlloc))
+	gauge("persona_heap_objects", "Number of allocated heap objects.", float64(current.HeapObjects))
+	gauge("persona_open_connections", "Open HTTP connections.", float64(current.OpenConns))
+	gauge("persona_uptime_seconds", "Seconds since the watchdog started.", time.Since(started).Seconds())
+	fmt.Fprintf(w, "# HELP persona_watchdog_anomalies_total Leak anomalies detected by the watchdog.\n# TYPE persona_watchdog_anomalies_total counter\npersona_watchdog_anomalies_total %d\n", anomalies)
+	fmt.Fprintf(w, "# HELP persona_watchdog_leak_suspected Whether the metric is currently growing like a leak.\n# TYPE persona_watchdog_leak_suspected gauge\n")
+	for _, metric := range metrics {
+		value := 0
+		if growing[metric.Name] {
+			value = 1
+		}
+		fmt.Fprintf(w, "persona_watchdog_leak_suspected{metric=%q} %d\n", metric.Name, value)
+	}
+	writeWorkerPoolMetrics(w)
+}
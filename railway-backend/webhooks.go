@@ -0,0 +1,352 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"persona-backend/idgen"
+
+	"github.com/gorilla/mux"
+)
+
+// Identity webhooks: callbacks registered at POST /admin/webhooks receive a
+// signed POST for each activity event (see activity.go) they subscribe to,
+// such as credential.issued, credential.revoked or proof.verified, so an
+// issuer backend can react in staging. The body is
+//
+//	{"id": "evt_...", "type": "credential.issued", "created": 1700000000, "data": {...activity event}}
+//
+// and X-Persona-Signature carries t=<timestamp>,v1=<HMAC-SHA256 of
+// "<timestamp>.<body>"> under the webhook's secret, the scheme billing
+// webhooks use. Failed deliveries are retried WEBHOOK_MAX_ATTEMPTS times
+// (default 5), backing off from WEBHOOK_BACKOFF (default 1s), doubling up
+// to a minute. Attempts run on the webhooks worker pool (see
+// workerpools.go); one its queue has no room for is dropped. Like fault
+// rules, registered webhooks are configuration and survive /admin/reset;
+// their delivery log does not.
+
+const (
+	maxIdentityWebhookBackoff = time.Minute
+	maxIdentityDeliveryLogs   = 1000
+)
+
+type identityWebhook struct {
+	ID     string   `json:"id"`
+	URL    string   `json:"url"`
+	Secret string   `json:"secret"`
+	Events []string `json:"events"`
+	// Description is free text, e.g. the team that registered it
+	Description string `json:"description,omitempty"`
+	CreatedAt   int64  `json:"created_at"`
+}
+
+type identityWebhookDelivery struct {
+	ID        string `json:"id"`
+	WebhookID string `json:"webhook_id"`
+	EventID   string `json:"event_id"`
+	EventType string `json:"event_type"`
+	Attempts  int    `json:"attempts"`
+	Status    int    `json:"last_status,omitempty"`
+	Error     string `json:"last_error,omitempty"`
+	Delivered bool   `json:"delivered"`
+	// NextRetryAt is set while a failed delivery waits for its next attempt
+	NextRetryAt int64 `json:"next_retry_at,omitempty"`
+	CreatedAt   int64 `json:"created_at"`
+	// TraceID is the trace of the request behind the event (trace.go)
+	TraceID string `json:"trace_id,omitempty"`
+}
+
+var (
+	identityWebhooksMu         sync.Mutex
+	identityWebhooks           = make(map[string]*identityWebhook)
+	identityWebhookDeliveries  []*identityWebhookDelivery
+	identityWebhookMaxAttempts = intFromEnv("WEBHOOK_MAX_ATTEMPTS", 5)
+	identityWebhookBackoff     = durationFromEnv("WEBHOOK_BACKOFF", time.Second)
+)
+
+func init() {
+	registerAdminState("webhooks", func() interface{} {
+		identityWebhooksMu.Lock()
+		defer identityWebhooksMu.Unlock()
+		hooks := make([]identityWebhook, 0, len(identityWebhooks))
+		for _, id := range sortedMapKeys(identityWebhooks) {
+			hooks = append(hooks, *identityWebhooks[id])
+		}
+		deliveries := make([]identityWebhookDelivery, len(identityWebhookDeliveries))
+		for i, d := range identityWebhookDeliveries {
+			deliveries[i] = *d
+		}
+		return map[string]interface{}{"webhooks": hooks, "deliveries": deliveries}
+	}, func() {
+		identityWebhooksMu.Lock()
+		defer identityWebhooksMu.Unlock()
+		identityWebhookDeliveries = nil
+	})
+}
+
+func (h *identityWebhook) accepts(eventType string) bool {
+	return webhookEndpoint{EnabledEvents: h.Events}.accepts(eventType)
+}
+
+// startWebhookDispatcher delivers c's activity events to the registered
+// webhooks in the background.
+func (c *Chain) startWebhookDispatcher() {
+	events, _ := c.subscribeEvents()
+	go func() {
+		for ev := range events {
+			if activity, ok := ev.Data["value"].(activityEvent); ok && ev.Type == "Activity" {
+				dispatchIdentityWebhooks(activity.Type, activity, activity.TraceID)
+			}
+		}
+	}()
+}
+
+// dispatchIdentityWebhooks sends an event to every subscribed webhook.
+// traceID is the trace of the request that caused it, or "".
+func dispatchIdentityWebhooks(eventType string, data interface{}, traceID string) {
+	event := map[string]interface{}{
+		"id":      idgen.NewWithPrefix("evt"),
+		"type":    eventType,
+		"created": appClock.Now().Unix(),
+		"data":    data,
+	}
+	if traceID != "" {
+		event["trace_id"] = traceID
+	}
+	payload, _ := json.Marshal(event)
+
+	identityWebhooksMu.Lock()
+	defer identityWebhooksMu.Unlock()
+	for _, id := range sortedMapKeys(identityWebhooks) {
+		hook := identityWebhooks[id]
+		if !hook.accepts(eventType) {
+			continue
+		}
+		delivery := &identityWebhookDelivery{
+			ID:        idgen.NewWithPrefix("whd"),
+			WebhookID: hook.ID,
+			EventID:   event["id"].(string),
+			EventType: eventType,
+			CreatedAt: appClock.Now().Unix(),
+			TraceID:   traceID,
+		}
+		recordTrace(traceID, func(t *traceRecord) { t.Deliveries = append(t.Deliveries, delivery.ID) })
+		identityWebhookDeliveries = append(identityWebhookDeliveries, delivery)
+		if len(identityWebhookDeliveries) > maxIdentityDeliveryLogs {
+			identityWebhookDeliveries = identityWebhookDeliveries[len(identityWebhookDeliveries)-maxIdentityDeliveryLogs:]
+		}
+		attemptIdentityWebhook(*hook, payload, delivery, 1, identityWebhookBackoff)
+	}
+}
+
+// attemptIdentityWebhook queues one delivery attempt on the webhook pool.
+// A failed attempt schedules the next after backoff, doubling it, so
+// workers are not held while waiting. Must be called with
+// identityWebhooksMu held.
+func attemptIdentityWebhook(hook identityWebhook, payload []byte, delivery *identityWebhookDelivery, attempt int, backoff time.Duration) {
+	err := webhookPool.Submit(func() {
+		deliverIdentityWebhook(hook, payload, delivery, attempt, backoff)
+	})
+	if err != nil {
+		delivery.Attempts = attempt
+		delivery.Error = "webhook " + err.Error()
+		delivery.NextRetryAt = 0
+		log.Printf("Webhook %s to %s dropped: webhook %v", delivery.EventID, hook.URL, err)
+	}
+}
+
+// deliverIdentityWebhook POSTs the payload once and schedules a retry with
+// exponential backoff on failure. Each attempt is signed with a fresh
+// timestamp.
+func deliverIdentityWebhook(hook identityWebhook, payload []byte, delivery *identityWebhookDelivery, attempt int, backoff time.Duration) {
+	status, err := postIdentityWebhook(hook, payload, delivery)
+
+	identityWebhooksMu.Lock()
+	defer identityWebhooksMu.Unlock()
+	delivery.Attempts = attempt
+	delivery.Status = status
+	delivery.Error = ""
+	if err == nil && status/100 != 2 {
+		err = fmt.Errorf("status %d", status)
+	}
+	if err != nil {
+		delivery.Error = err.Error()
+	}
+	delivery.Delivered = err == nil
+	delivery.NextRetryAt = 0
+	if err == nil {
+		return
+	}
+	log.Printf("Webhook %s to %s failed (attempt %d/%d): %v", delivery.EventID, hook.URL, attempt, identityWebhookMaxAttempts, err)
+	if attempt < identityWebhookMaxAttempts {
+		delivery.NextRetryAt = time.Now().Add(backoff).Unix()
+		time.AfterFunc(backoff, func() {
+			identityWebhooksMu.Lock()
+			defer identityWebhooksMu.Unlock()
+			attemptIdentityWebhook(hook, payload, delivery, attempt+1, min(backoff*2, maxIdentityWebhookBackoff))
+		})
+	}
+}
+
+func postIdentityWebhook(hook identityWebhook, payload []byte, delivery *identityWebhookDelivery) (int, error) {
+	req, err := http.NewRequest("POST", hook.URL, bytes.NewReader(payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.Header.Set("User-Agent", "persona-webhooks/1.0")
+	req.Header.Set("X-Persona-Event", delivery.EventType)
+	req.Header.Set("X-Persona-Delivery", delivery.ID)
+	if delivery.TraceID != "" {
+		req.Header.Set(traceHeader, delivery.TraceID)
+	}
+	req.Header.Set("X-Persona-Signature", stripeSignature(hook.Secret, time.Now().Unix(), payload))
+
+	resp, err := webhookClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+// Handler for POST /admin/webhooks
+func handleRegisterWebhook(w http.ResponseWriter, r *http.Request) {
+	var hook identityWebhook
+	if err := json.NewDecoder(r.Body).Decode(&hook); err != nil {
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+	if u, err := url.Parse(hook.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		http.Error(w, "url must be an absolute http(s) URL", http.StatusBadRequest)
+		return
+	}
+	if len(hook.Events) == 0 {
+		hook.Events = []string{"*"}
+	}
+	if hook.Secret == "" {
+		hook.Secret = newWebhookSecret()
+	}
+	hook.ID = idgen.NewWithPrefix("wh")
+	hook.CreatedAt = appClock.Now().Unix()
+
+	identityWebhooksMu.Lock()
+	identityWebhooks[hook.ID] = &hook
+	identityWebhooksMu.Unlock()
+
+	log.Printf("Webhook %s registered for %v: %s", hook.ID, hook.Events, hook.URL)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{"webhook": hook})
+}
+
+// Handler for GET /admin/webhooks
+func handleListWebhookRegistrations(w http.ResponseWriter, r *http.Request) {
+	identityWebhooksMu.Lock()
+	hooks := make([]identityWebhook, 0, len(identityWebhooks))
+	for _, id := range sortedMapKeys(identityWebhooks) {
+		hooks = append(hooks, *identityWebhooks[id])
+	}
+	identityWebhooksMu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"webhooks": hooks})
+}
+
+// Handler for GET /admin/webhooks/{id}, the webhook with its deliveries,
+// newest first.
+func handleGetWebhookRegistration(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	identityWebhooksMu.Lock()
+	hook, ok := identityWebhooks[id]
+	var snapshot identityWebhook
+	deliveries := []identityWebhookDelivery{}
+	if ok {
+		snapshot = *hook
+		for i := len(identityWebhookDeliveries) - 1; i >= 0; i-- {
+			if d := identityWebhookDeliveries[i]; d.WebhookID == id {
+				deliveries = append(deliveries, *d)
+			}
+		}
+	}
+	identityWebhooksMu.Unlock()
+
+	if !ok {
+		http.Error(w, "Webhook not found", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"webhook": snapshot, "deliveries": deliveries})
+}
+
+// Handler for DELETE /admin/webhooks/{id}
+func handleDeleteWebhookRegistration(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	identityWebhooksMu.Lock()
+	_, ok := identityWebhooks[id]
+	delete(identityWebhooks, id)
+	identityWebhooksMu.Unlock()
+
+	if !ok {
+		http.Error(w, "Webhook not found", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"removed": 1})
+}
+
+// Handler for POST /admin/webhooks/{id}/ping, which sends a ping event to
+// one webhook so its receiver and signature check can be tried out.
+func handlePingWebhook(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	identityWebhooksMu.Lock()
+	hook, ok := identityWebhooks[id]
+	var snapshot identityWebhook
+	if ok {
+		snapshot = *hook
+	}
+	identityWebhooksMu.Unlock()
+
+	if !ok {
+		http.Error(w, "Webhook not found", http.StatusNotFound)
+		return
+	}
+	event := map[string]interface{}{
+		"id":      idgen.NewWithPrefix("evt"),
+		"type":    "ping",
+		"created": appClock.Now().Unix(),
+		"data":    map[string]interface{}{"webhook_id": id},
+	}
+	payload, _ := json.Marshal(event)
+	delivery := &identityWebhookDelivery{
+		ID:        idgen.NewWithPrefix("whd"),
+		WebhookID: id,
+		EventID:   event["id"].(string),
+		EventType: "ping",
+		Attempts:  1,
+		CreatedAt: appClock.Now().Unix(),
+	}
+	// Delivered once and synchronously, so the caller sees the outcome
+	status, err := postIdentityWebhook(snapshot, payload, delivery)
+	delivery.Status = status
+	delivery.Delivered = err == nil && status/100 == 2
+	if err != nil {
+		delivery.Error = err.Error()
+	}
+
+	identityWebhooksMu.Lock()
+	identityWebhookDeliveries = append(identityWebhookDeliveries, delivery)
+	identityWebhooksMu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"delivery": delivery})
+}
@@ -0,0 +1,263 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Tendermint RPC websocket: GET /websocket speaks the JSON-RPC
+// subscribe/unsubscribe/unsubscribe_all protocol, so clients written against
+// a real node's tm.event='NewBlock' and tm.event='Tx' subscriptions work
+// unchanged.
+
+const (
+	// Same default as Tendermint's max_subscriptions_per_client
+	maxSubscriptionsPerClient = 5
+	wsWriteWait               = 10 * time.Second
+	wsPingPeriod              = 27 * time.Second
+	wsReadWait                = 30 * time.Second
+)
+
+var wsUpgrader = websocket.Upgrader{
+	// Any origin may subscribe, matching the CORS policy
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    string `json:"data,omitempty"`
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+// JSON-RPC 2.0 error codes used by Tendermint
+var (
+	rpcParseError     = rpcError{Code: -32700, Message: "Parse error. Invalid JSON"}
+	rpcMethodNotFound = rpcError{Code: -32601, Message: "Method not found"}
+	rpcInvalidParams  = rpcError{Code: -32602, Message: "Invalid params"}
+	rpcInternalError  = rpcError{Code: -32603, Message: "Internal error"}
+)
+
+type wsSubscription struct {
+	id    json.RawMessage
+	query eventQuery
+}
+
+type wsClient struct {
+	conn *websocket.Conn
+	send chan rpcResponse
+	done chan struct{}
+
+	mu   sync.Mutex
+	subs map[string]*wsSubscription
+}
+
+// Handler for GET /websocket
+func (c *Chain) handleWebsocket(w http.ResponseWriter, r *http.Request) {
+	conn, err := wsUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Printf("Websocket upgrade failed: %v", err)
+		return
+	}
+	client := &wsClient{
+		conn: conn,
+		send: make(chan rpcResponse, 16),
+		done: make(chan struct{}),
+		subs: make(map[string]*wsSubscription),
+	}
+	events, unsubscribe := c.subscribeEvents()
+	log.Printf("Websocket client connected: %s", r.RemoteAddr)
+
+	go client.writeLoop(events)
+	client.readLoop()
+
+	close(client.done)
+	unsubscribe()
+	conn.Close()
+	log.Printf("Websocket client disconnected: %s", r.RemoteAddr)
+}
+
+func (cl *wsClient) readLoop() {
+	cl.conn.SetReadDeadline(time.Now().Add(wsReadWait))
+	cl.conn.SetPongHandler(func(string) error {
+		return cl.conn.SetReadDeadline(time.Now().Add(wsReadWait))
+	})
+	for {
+		_, data, err := cl.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		cl.conn.SetReadDeadline(time.Now().Add(wsReadWait))
+
+		var req rpcRequest
+		if err := json.Unmarshal(data, &req); err != nil {
+			cl.reply(rpcResponse{Error: &rpcParseError})
+			continue
+		}
+		cl.reply(cl.handleRequest(req))
+	}
+}
+
+func (cl *wsClient) reply(resp rpcResponse) {
+	resp.JSONRPC = "2.0"
+	select {
+	case cl.send <- resp:
+	case <-cl.done:
+	}
+}
+
+func (cl *wsClient) handleRequest(req rpcRequest) rpcResponse {
+	resp := rpcResponse{ID: req.ID}
+	fail := func(base rpcError, data string) rpcResponse {
+		base.Data = data
+		resp.Error = &base
+		return resp
+	}
+
+	switch req.Method {
+	case "subscribe", "unsubscribe":
+		query, err := queryParam(req.Params)
+		if err != nil {
+			return fail(rpcInvalidParams, err.Error())
+		}
+
+		cl.mu.Lock()
+		defer cl.mu.Unlock()
+		if req.Method == "unsubscribe" {
+			if _, ok := cl.subs[query]; !ok {
+				return fail(rpcInternalError, "subscription not found")
+			}
+			delete(cl.subs, query)
+			resp.Result = map[string]interface{}{}
+			return resp
+		}
+
+		parsed, err := parseEventQuery(query)
+		if err != nil {
+			return fail(rpcInternalError, fmt.Sprintf("failed to parse query: %v", err))
+		}
+		if _, ok := cl.subs[query]; ok {
+			return fail(rpcInternalError, "already subscribed")
+		}
+		if len(cl.subs) >= maxSubscriptionsPerClient {
+			return fail(rpcInternalError, fmt.Sprintf("max_subscriptions_per_client %d reached", maxSubscriptionsPerClient))
+		}
+		cl.subs[query] = &wsSubscription{id: req.ID, query: parsed}
+		resp.Result = map[string]interface{}{}
+		return resp
+
+	case "unsubscribe_all":
+		cl.mu.Lock()
+		defer cl.mu.Unlock()
+		if len(cl.subs) == 0 {
+			return fail(rpcInternalError, "subscription not found")
+		}
+		cl.subs = make(map[string]*wsSubscription)
+		resp.Result = map[string]interface{}{}
+		return resp
+
+	default:
+		return fail(rpcMethodNotFound, req.Method)
+	}
+}
+
+// queryParam accepts both {"query": "..."} and ["..."] params.
+func queryParam(params json.RawMessage) (string, error) {
+	var named struct {
+		Query string `json:"query"`
+	}
+	if json.Unmarshal(params, &named) == nil && named.Query != "" {
+		return named.Query, nil
+	}
+	var positional []string
+	if json.Unmarshal(params, &positional) == nil && len(positional) > 0 && positional[0] != "" {
+		return positional[0], nil
+	}
+	return "", fmt.Errorf("missing query parameter")
+}
+
+// writeLoop is the only goroutine writing to the connection. It sends RPC
+// replies, matching events and keepalive pings.
+func (cl *wsClient) writeLoop(events <-chan chainEvent) {
+	ticker := time.NewTicker(wsPingPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case resp := <-cl.send:
+			if cl.write(resp) != nil {
+				cl.conn.Close()
+				return
+			}
+		case ev, ok := <-events:
+			if !ok {
+				return
+			}
+			for _, resp := range cl.matchEvent(ev) {
+				if cl.write(resp) != nil {
+					cl.conn.Close()
+					return
+				}
+			}
+		case <-ticker.C:
+			cl.conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if cl.conn.WriteMessage(websocket.PingMessage, nil) != nil {
+				cl.conn.Close()
+				return
+			}
+		case <-cl.done:
+			return
+		}
+	}
+}
+
+func (cl *wsClient) write(resp rpcResponse) error {
+	cl.conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+	return cl.conn.WriteJSON(resp)
+}
+
+// matchEvent builds one event message per subscription whose query matches.
+func (cl *wsClient) matchEvent(ev chainEvent) []rpcResponse {
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+
+	var out []rpcResponse
+	for query, sub := range cl.subs {
+		if !sub.query.Matches(ev) {
+			continue
+		}
+		attributes := map[string][]string{"tm.event": {ev.Type}}
+		for key, values := range ev.Attributes {
+			attributes[key] = values
+		}
+		out = append(out, rpcResponse{
+			JSONRPC: "2.0",
+			ID:      sub.id,
+			Result: map[string]interface{}{
+				"query":  query,
+				"data":   ev.Data,
+				"events": attributes,
+			},
+		})
+	}
+	return out
+}
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+
+	"github.com/gorilla/mux"
+)
+
+// Worker pools for the server's background work, sized for the deployment
+// rather than by goroutine-per-task:
+//
+//	proving       groth16 checks of submitted proofs (VERIFY_PROOFS=true)
+//	verification  POST /persona/zk/v1beta1/verify and both batch endpoints
+//	webhooks      identity webhook delivery attempts
+//
+// <NAME>_WORKERS sets a pool's workers and <NAME>_QUEUE how many tasks may
+// wait for one (PROVING_*, VERIFICATION_*, WEBHOOK_*). A task submitted to
+// a full queue is rejected instead of piling up. GET /admin/pools shows the
+// live numbers, also exported on /metrics, and PUT /admin/pools/{name}
+// resizes a pool without a restart. Sizes are configuration and survive
+// /admin/reset.
+
+var errPoolFull = errors.New("queue is full")
+
+type workerPool struct {
+	name string
+
+	mu   sync.Mutex
+	cond *sync.Cond
+	// Workers wanted, and worker goroutines still running; extra workers
+	// exit once they are idle after a shrink
+	size, running int
+	capacity      int
+	queue         []func()
+	busy          int
+
+	completed, rejected int
+}
+
+func newWorkerPool(name string, size, capacity int) *workerPool {
+	p := &workerPool{name: name, capacity: capacity}
+	p.cond = sync.NewCond(&p.mu)
+	p.Resize(size, capacity)
+	return p
+}
+
+var (
+	provingPool      = newWorkerPool("proving", intFromEnv("PROVING_WORKERS", 2), intFromEnv("PROVING_QUEUE", 64))
+	verificationPool = newWorkerPool("verification", intFromEnv("VERIFICATION_WORKERS", 8), intFromEnv("VERIFICATION_QUEUE", 1024))
+	webhookPool      = newWorkerPool("webhooks", intFromEnv("WEBHOOK_WORKERS", 4), intFromEnv("WEBHOOK_QUEUE", 1000))
+
+	workerPools = map[string]*workerPool{
+		provingPool.name:      provingPool,
+		verificationPool.name: verificationPool,
+		webhookPool.name:      webhookPool,
+	}
+)
+
+// Submit queues task for a worker, or returns errPoolFull when every
+// worker is busy and capacity tasks are already waiting.
+func (p *workerPool) Submit(task func()) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	idle := max(p.size-p.busy, 0)
+	if len(p.queue)-idle >= p.capacity {
+		p.rejected++
+		return errPoolFull
+	}
+	p.queue = append(p.queue, task)
+	p.cond.Signal()
+	return nil
+}
+
+// Do runs task on a worker and waits for it to finish.
+func (p *workerPool) Do(task func()) error {
+	done := make(chan struct{})
+	if err := p.Submit(func() {
+		defer close(done)
+		task()
+	}); err != nil {
+		return err
+	}
+	<-done
+	return nil
+}
+
+// Resize changes the number of workers and the queue capacity. Tasks
+// already queued beyond a smaller capacity still run.
+func (p *workerPool) Resize(size, capacity int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.size, p.capacity = size, capacity
+	for ; p.running < p.size; p.running++ {
+		go p.work()
+	}
+	// Wake idle workers so surplus ones exit
+	p.cond.Broadcast()
+}
+
+func (p *workerPool) work() {
+	p.mu.Lock()
+	for {
+		for len(p.queue) == 0 && p.running <= p.size {
+			p.cond.Wait()
+		}
+		if p.running > p.size {
+			p.running--
+			p.mu.Unlock()
+			return
+		}
+		task := p.queue[0]
+		p.queue[0] = nil
+		p.queue = p.queue[1:]
+		p.busy++
+		p.mu.Unlock()
+
+		p.run(task)
+
+		p.mu.Lock()
+		p.busy--
+		p.completed++
+	}
+}
+
+// run calls task, keeping the worker alive if it panics.
+func (p *workerPool) run(task func()) {
+	defer func() {
+		if err := recover(); err != nil {
+			log.Printf("Task in %s pool panicked: %v", p.name, err)
+		}
+	}()
+	task()
+}
+
+type workerPoolStats struct {
+	Name      string `json:"name"`
+	Workers   int    `json:"workers"`
+	Running   int    `json:"running"`
+	Busy      int    `json:"busy"`
+	Queued    int    `json:"queued"`
+	Capacity  int    `json:"queue_capacity"`
+	Completed int    `json:"completed"`
+	Rejected  int    `json:"rejected"`
+}
+
+func (p *workerPool) Stats() workerPoolStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return workerPoolStats{
+		Name:      p.name,
+		Workers:   p.size,
+		Running:   p.running,
+		Busy:      p.busy,
+		Queued:    len(p.queue),
+		Capacity:  p.capacity,
+		Completed: p.completed,
+		Rejected:  p.rejected,
+	}
+}
+
+func workerPoolStatsList() []workerPoolStats {
+	stats := make([]workerPoolStats, 0, len(workerPools))
+	for _, name := range sortedMapKeys(workerPools) {
+		stats = append(stats, workerPools[name].Stats())
+	}
+	return stats
+}
+
+// writeWorkerPoolMetrics adds the pools to the /metrics exposition.
+func writeWorkerPoolMetrics(w http.ResponseWriter) {
+	stats := workerPoolStatsList()
+	for _, metric := range []struct {
+		name, kind, help string
+		value            func(workerPoolStats) int
+	}{
+		{"persona_pool_workers", "gauge", "Workers configured for the pool.", func(s workerPoolStats) int { return s.Workers }},
+		{"persona_pool_busy_workers", "gauge", "Workers running a task.", func(s workerPoolStats) int { return s.Busy }},
+		{"persona_pool_queue_depth", "gauge", "Tasks waiting for a worker.", func(s workerPoolStats) int { return s.Queued }},
+		{"persona_pool_queue_capacity", "gauge", "Tasks that may wait for a worker.", func(s workerPoolStats) int { return s.Capacity }},
+		{"persona_pool_tasks_completed_total", "counter", "Tasks the pool has run.", func(s workerPoolStats) int { return s.Completed }},
+		{"persona_pool_tasks_rejected_total", "counter", "Tasks rejected because the queue was full.", func(s workerPoolStats) int { return s.Rejected }},
+	} {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", metric.name, metric.help, metric.name, metric.kind)
+		for _, s := range stats {
+			fmt.Fprintf(w, "%s{pool=%q} %d\n", metric.name, s.Name, metric.value(s))
+		}
+	}
+}
+
+// Handler for GET /admin/pools
+func handleListWorkerPools(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"pools": workerPoolStatsList()})
+}
+
+// Handler for PUT /admin/pools/{name}
+//
+// Takes {"workers": 16, "queue_capacity": 2048}; an omitted field keeps its
+// current value.
+func handleResizeWorkerPool(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+	pool, ok := workerPools[name]
+	if !ok {
+		http.Error(w, "Unknown pool "+name, http.StatusNotFound)
+		return
+	}
+	var req struct {
+		Workers  *int `json:"workers"`
+		Capacity *int `json:"queue_capacity"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+	current := pool.Stats()
+	size, capacity := current.Workers, current.Capacity
+	if req.Workers != nil {
+		size = *req.Workers
+	}
+	if req.Capacity != nil {
+		capacity = *req.Capacity
+	}
+	if size < 1 || capacity < 0 {
+		http.Error(w, "workers must be at least 1 and queue_capacity at least 0", http.StatusBadRequest)
+		return
+	}
+
+	pool.Resize(size, capacity)
+	log.Printf("Resized %s pool to %d workers, queue capacity %d", name, size, capacity)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"pool": pool.Stats()})
+}
@@ -0,0 +1,455 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"persona-backend/idgen"
+)
+
+func init() {
+	RegisterModule(newZKModule)
+}
+
+// zkStore holds submitted proofs.
+type zkStore struct {
+	// Proofs keyed by the controller that submitted them
+	ByController map[string][]map[string]interface{} `json:"by_controller"`
+	// Circuits registered on top of the built-in test circuit
+	Circuits []map[string]interface{} `json:"circuits"`
+}
+
+func (s *zkStore) Reset() {
+	s.ByController = make(map[string][]map[string]interface{})
+	s.Circuits = []map[string]interface{}{}
+}
+
+// zkModule implements the persona zk module.
+type zkModule struct {
+	chain *Chain
+	store *zkStore
+}
+
+func newZKModule(c *Chain) Module {
+	store := &zkStore{}
+	store.Reset()
+	return &zkModule{chain: c, store: store}
+}
+
+func (m *zkModule) Name() string { return "zk" }
+
+func (m *zkModule) Store() ModuleStore { return m.store }
+
+func (m *zkModule) EventTypes() []string {
+	return []string{"zk.proof_submitted", "zk.proof_verified"}
+}
+
+func (m *zkModule) RegisterMsgs(reg *MsgRegistry) {
+	registerMsg(reg, "/persona.zk.v1.MsgSubmitProof", m.handleMsgSubmitProof)
+}
+
+func (m *zkModule) RegisterRoutes(r *mux.Router) {
+	r.HandleFunc("/persona/zk/v1beta1/proofs", m.handleListProofs).Methods("GET", "OPTIONS")
+	r.HandleFunc("/persona/zk/v1beta1/proofs_by_controller/{controller}", m.handleGetProofsByController).Methods("GET", "OPTIONS")
+	r.HandleFunc("/persona/zk/v1beta1/circuits", m.handleListCircuits).Methods("GET", "OPTIONS")
+	r.HandleFunc("/persona/zk/v1beta1/verify", m.handleVerifyProof).Methods("POST", "OPTIONS")
+	r.HandleFunc("/persona/zk/v1beta1/verify/batch", m.handleVerifyProofBatch).Methods("POST", "OPTIONS")
+}
+
+func (m *zkModule) ExportGenesis() interface{} {
+	proofs := []map[string]interface{}{}
+	for _, controller := range sortedMapKeys(m.store.ByController) {
+		proofs = append(proofs, m.store.ByController[controller]...)
+	}
+	return map[string]interface{}{
+		"params":   map[string]interface{}{},
+		"circuits": m.listCircuits(),
+		"proofs":   proofs,
+	}
+}
+
+func (m *zkModule) SeedKeys() []string { return []string{"circuits", "proofs"} }
+
+// Seed stores circuits, which need an id, or proofs under their prover
+// address. Missing proof ids are generated and seeded proofs are verified
+// unless is_verified says otherwise.
+func (m *zkModule) Seed(key string, records []map[string]interface{}) error {
+	for i, record := range records {
+		if key == "circuits" {
+			if id, _ := record["id"].(string); id == "" {
+				return fmt.Errorf("record %d: id is required", i)
+			}
+			circuit := map[string]interface{}{"is_active": true, "created_at": m.chain.now().Unix()}
+			for k, value := range record {
+				circuit[k] = value
+			}
+			m.store.Circuits = append(m.store.Circuits, circuit)
+			continue
+		}
+
+		prover, _ := record["prover"].(string)
+		if prover == "" {
+			return fmt.Errorf("record %d: prover is required", i)
+		}
+		proof := map[string]interface{}{
+			"id":          idgen.NewWithPrefix("proof"),
+			"is_verified": true,
+			"created_at":  m.chain.now().Unix(),
+		}
+		for k, value := range record {
+			proof[k] = value
+		}
+		m.store.ByController[prover] = append(m.store.ByController[prover], proof)
+	}
+	return nil
+}
+
+// listCircuits returns the registered ZK circuits. Must be called with the
+// chain lock held.
+func (m *zkModule) listCircuits() []map[string]interface{} {
+	circuits := []map[string]interface{}{
+		{
+			"id":         "circuit_001",
+			"name":       "test_circuit",
+			"creator":    "cosmos1test1",
+			"is_active":  true,
+			"created_at": m.chain.now().Unix(),
+		},
+	}
+	return append(circuits, m.store.Circuits...)
+}
+
+func (m *zkModule) handleListProofs(w http.ResponseWriter, r *http.Request) {
+	// Start with the default mock proof
+	mockProofs := []map[string]interface{}{
+		{
+			"id":          "proof_001",
+			"circuit_id":  "circuit_001",
+			"prover":      "cosmos1test1",
+			"is_verified": true,
+			"created_at":  m.chain.now().Unix(),
+		},
+	}
+	// Stored proofs are keyed by prover and submission order, which is
+	// unique even for seeded proofs sharing an id
+	keys := []string{""}
+
+	pageReq, err := parsePageRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	m.chain.mu.RLock()
+	defer m.chain.mu.RUnlock()
+
+	// Add any submitted or seeded proofs
+	for _, prover := range sortedMapKeys(m.store.ByController) {
+		for i, proof := range m.store.ByController[prover] {
+			mockProofs = append(mockProofs, proof)
+			keys = append(keys, fmt.Sprintf("%s/%020d", prover, i))
+		}
+	}
+
+	page, pagination := paginate(mockProofs, func(i int, _ map[string]interface{}) string { return keys[i] }, pageReq)
+
+	response := map[string]interface{}{
+		"zk_proofs":  page,
+		"pagination": pagination,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (m *zkModule) handleListCircuits(w http.ResponseWriter, r *http.Request) {
+	m.chain.mu.RLock()
+	mockCircuits := m.listCircuits()
+	m.chain.mu.RUnlock()
+
+	pageReq, err := parsePageRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	page, pagination := paginate(mockCircuits, keyByID, pageReq)
+
+	response := map[string]interface{}{
+		"circuits":   page,
+		"pagination": pagination,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (m *zkModule) handleGetProofsByController(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	controller := vars["controller"]
+
+	log.Printf("Looking up proofs for controller: %s", controller)
+
+	pageReq, err := parsePageRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	m.chain.mu.RLock()
+	defer m.chain.mu.RUnlock()
+
+	// Get proofs for this controller
+	proofs, exists := m.store.ByController[controller]
+	if !exists {
+		proofs = []map[string]interface{}{}
+	}
+
+	page, pagination := paginate(proofs, keyByIndex, pageReq)
+
+	response := map[string]interface{}{
+		"zk_proofs":  page,
+		"pagination": pagination,
+	}
+
+	log.Printf("Returning %d proofs for controller %s", len(proofs), controller)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+type MsgSubmitProof struct {
+	Creator      string      `json:"creator"`
+	Prover       string      `json:"prover"`
+	CircuitID    string      `json:"circuit_id"`
+	Proof        string      `json:"proof"`
+	ProofData    string      `json:"proof_data"`
+	PublicInputs interface{} `json:"public_inputs"`
+	Metadata     interface{} `json:"metadata"`
+}
+
+// Handle field name variations
+func (m MsgSubmitProof) prover() string {
+	if m.Creator != "" {
+		return m.Creator
+	}
+	return m.Prover
+}
+
+func (m MsgSubmitProof) proofData() string {
+	if m.Proof != "" {
+		return m.Proof
+	}
+	return m.ProofData
+}
+
+func (m MsgSubmitProof) ValidateBasic() error {
+	if m.prover() == "" {
+		return errors.New("creator is required")
+	}
+	if m.proofData() == "" {
+		return errors.New("proof is required")
+	}
+	if m.CircuitID == "" {
+		return errors.New("circuit_id is required")
+	}
+	return nil
+}
+
+// preverifyProofs verifies the proofs of a tx's MsgSubmitProof messages on
+// the proving pool, before the chain lock is taken, and returns the
+// results by message index. It returns nil when VERIFY_PROOFS is off, and
+// code 20 when the pool is full.
+func preverifyProofs(msgs []interface{}) (map[int]error, *txError) {
+	if !verifyProofs.Enabled() {
+		return nil, nil
+	}
+	results := map[int]error{}
+	for i, rawMsg := range msgs {
+		raw, _ := rawMsg.(map[string]interface{})
+		if raw == nil || raw["@type"] != "/persona.zk.v1.MsgSubmitProof" {
+			continue
+		}
+		var msg MsgSubmitProof
+		data, _ := json.Marshal(raw)
+		if json.Unmarshal(data, &msg) != nil {
+			// Deliver rejects it
+			continue
+		}
+		var verifyErr error
+		if err := provingPool.Do(func() {
+			verifyErr = verifyGroth16(msg.CircuitID, msg.proofData(), msg.PublicInputs)
+		}); err != nil {
+			return nil, txErrorf(codeMempoolIsFull, "proving %v", err)
+		}
+		results[i] = verifyErr
+	}
+	return results, nil
+}
+
+func (m *zkModule) handleMsgSubmitProof(ctx *msgContext, msg MsgSubmitProof) *txError {
+	prover := msg.prover()
+
+	// Create proof record
+	proof := map[string]interface{}{
+		"id":            idgen.NewWithPrefix("proof"),
+		"circuit_id":    msg.CircuitID,
+		"prover":        prover,
+		"proof_data":    msg.proofData(),
+		"public_inputs": msg.PublicInputs,
+		"metadata":      msg.Metadata,
+		"is_verified":   true, // Mock verification
+		"created_at":    m.chain.now().Unix(),
+	}
+	var verifyErr error
+	if verifyProofs.Enabled() {
+		var verified bool
+		if verifyErr, verified = ctx.Proofs[ctx.MsgIndex]; !verified {
+			// Scenario steps, or VERIFY_PROOFS turned on since the broadcast
+			verifyErr = verifyGroth16(msg.CircuitID, msg.proofData(), msg.PublicInputs)
+		}
+		proof["is_verified"] = verifyErr == nil
+		if verifyErr != nil {
+			proof["verification_error"] = verifyErr.Error()
+		}
+	}
+
+	// Store proof by controller
+	if m.store.ByController[prover] == nil {
+		m.store.ByController[prover] = []map[string]interface{}{}
+	}
+	m.store.ByController[prover] = append(m.store.ByController[prover], proof)
+	proofId := proof["id"].(string)
+	ctx.emit("proof.submitted", prover, proofId, proof)
+	ctx.event("zk.proof_submitted", "proof_id", proofId, "circuit_id", msg.CircuitID, "prover", prover, "verified", strconv.FormatBool(verifyErr == nil))
+	// Verification, mocked or not, completes at once
+	if verifyErr != nil {
+		ctx.emit("proof.verification_failed", prover, proofId, proof)
+		log.Printf("Proof %s failed verification: %v", proofId, verifyErr)
+		if !m.chain.isolated {
+			abuse.recordFailure(prover, abuseVerification)
+		}
+	} else {
+		ctx.emit("proof.verified", prover, proofId, proof)
+	}
+	log.Printf("Stored proof for controller: %s", prover)
+	return nil
+}
+
+type verifyProofRequest struct {
+	CircuitID string `json:"circuit_id"`
+	// Proof is the proof_data string, or the proof object itself as a
+	// scanned presentation carries it
+	Proof        json.RawMessage `json:"proof"`
+	PublicInputs interface{}     `json:"public_inputs"`
+	// CredentialIDs are the credentials the proof presents, which must
+	// not be revoked
+	CredentialIDs []string `json:"credential_ids"`
+}
+
+// proofData returns the proof as MsgSubmitProof carries it, or "" when
+// missing.
+func (req verifyProofRequest) proofData() string {
+	var proofString string
+	if json.Unmarshal(req.Proof, &proofString) == nil {
+		return proofString
+	}
+	if string(req.Proof) == "null" {
+		return ""
+	}
+	return string(req.Proof)
+}
+
+// verifyProof verifies a proof the way MsgSubmitProof does, without storing
+// anything: against the circuit's verifying key with VERIFY_PROOFS=true,
+// and mocked as verified otherwise. Presented credentials must exist and
+// not be revoked. Results are cached per verification mode, see
+// verifycache.go, so toggling verify_proofs never serves the other mode's
+// result.
+func (m *zkModule) verifyProof(req verifyProofRequest) (verificationResult, bool) {
+	mode := "mock"
+	if verifyProofs.Enabled() {
+		mode = "groth16"
+	}
+	key := verificationKey(m.chain.ChainID(), mode, req.CircuitID, req.proofData(), req.PublicInputs, req.CredentialIDs)
+	if result, ok := verifyCache.Get(key); ok {
+		return result, true
+	}
+
+	result := verificationResult{Verified: true, Mode: mode, CheckedAt: m.chain.now().Unix()}
+	if mode == "groth16" {
+		if err := verifyGroth16(req.CircuitID, req.proofData(), req.PublicInputs); err != nil {
+			result.Verified = false
+			result.Error = err.Error()
+		}
+	}
+	tags := []string{circuitTag(req.CircuitID)}
+	m.chain.mu.RLock()
+	for _, id := range req.CredentialIDs {
+		tags = append(tags, credentialTag(id))
+		if !result.Verified {
+			continue
+		}
+		_, credential := m.chain.vc().findCredential(id)
+		if credential == nil {
+			result.Verified = false
+			result.Error = "credential " + id + " not found"
+		} else if credential["is_revoked"] == true {
+			result.Verified = false
+			result.Error = "credential " + id + " is revoked"
+		}
+	}
+	m.chain.mu.RUnlock()
+
+	verifyCache.Put(key, result, tags)
+	return result, false
+}
+
+// Handler for POST /persona/zk/v1beta1/verify
+//
+// A failed check is a 200 with verified=false; a full verification queue
+// is a 429.
+func (m *zkModule) handleVerifyProof(w http.ResponseWriter, r *http.Request) {
+	var req verifyProofRequest
+	// Public inputs stay exact as json.Number
+	decoder := json.NewDecoder(r.Body)
+	decoder.UseNumber()
+	if err := decoder.Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+	if req.CircuitID == "" || req.proofData() == "" {
+		http.Error(w, "Missing required fields: circuit_id, proof", http.StatusBadRequest)
+		return
+	}
+	if !chargeVerifications(w, r, 1) {
+		return
+	}
+
+	var result verificationResult
+	var cached bool
+	if err := verificationPool.Do(func() {
+		result, cached = m.verifyProof(req)
+	}); err != nil {
+		writeGRPCError(w, http.StatusTooManyRequests, 8, "verification "+err.Error())
+		return
+	}
+	log.Printf("Verified proof for circuit %s: %v (cached: %v)", req.CircuitID, result.Verified, cached)
+
+	response := map[string]interface{}{
+		"circuit_id": req.CircuitID,
+		"verified":   result.Verified,
+		"mode":       result.Mode,
+		"checked_at": result.CheckedAt,
+		"cached":     cached,
+	}
+	if result.Error != "" {
+		response["error"] = result.Error
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}